@@ -0,0 +1,245 @@
+package apisecurity
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Security applies the TLS and authentication settings to the health, metrics, admin and
+// inspection endpoints of the node services and to the requests which the node sends to them.
+// The zero value serves plain HTTP without authentication.
+type Security struct {
+	cfg       config.APISecurityConfig
+	token     string
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Load loads the certificates from the Forta dir. The token is the admin token which is
+// required by all endpoints if the config says so.
+func Load(fortaDir string, cfg config.APISecurityConfig, token string) (*Security, error) {
+	sec := &Security{cfg: cfg, token: token}
+	if cfg.RequireToken && len(token) == 0 {
+		return nil, errors.New("the api token is required but the admin token is not available")
+	}
+	if !cfg.TLS {
+		if cfg.RequireClientCert {
+			return nil, errors.New("client certificates can only be required with tls")
+		}
+		return sec, nil
+	}
+
+	certFile, keyFile, err := certFiles(fortaDir, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api certificate: %v", err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the api certificate: %v", err)
+	}
+	sec.cert = &cert
+
+	if !cfg.RequireClientCert {
+		return sec, nil
+	}
+	sec.clientCAs = x509.NewCertPool()
+	if len(cfg.ClientCAFile) == 0 {
+		sec.clientCAs.AddCert(cert.Leaf)
+		return sec, nil
+	}
+	caFile, err := fortaDirPath(fortaDir, cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client ca file: %v", err)
+	}
+	if !sec.clientCAs.AppendCertsFromPEM(b) {
+		return nil, errors.New("no certificates found in the client ca file")
+	}
+	return sec, nil
+}
+
+// certFiles returns the paths of the configured or the generated certificate and key.
+func certFiles(fortaDir string, cfg config.APISecurityConfig) (string, string, error) {
+	if len(cfg.CertFile) == 0 {
+		return path.Join(fortaDir, config.DefaultTLSCertFileName), path.Join(fortaDir, config.DefaultTLSKeyFileName), nil
+	}
+	certFile, err := fortaDirPath(fortaDir, cfg.CertFile)
+	if err != nil {
+		return "", "", err
+	}
+	keyFile, err := fortaDirPath(fortaDir, cfg.KeyFile)
+	if err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// fortaDirPath resolves the path in the Forta dir so that the containers can read the same file.
+func fortaDirPath(fortaDir, filePath string) (string, error) {
+	if path.IsAbs(filePath) || strings.HasPrefix(path.Clean(filePath), "..") {
+		return "", fmt.Errorf("%s should be a path relative to the forta dir", filePath)
+	}
+	return path.Join(fortaDir, filePath), nil
+}
+
+// Scheme returns the URL scheme of the endpoints.
+func (sec *Security) Scheme() string {
+	if sec.cert != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// ServerTLSConfig returns the TLS config of the servers or nil if TLS is disabled.
+func (sec *Security) ServerTLSConfig() *tls.Config {
+	if sec.cert == nil {
+		return nil
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{*sec.cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if sec.clientCAs != nil {
+		tlsConfig.ClientCAs = sec.clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig
+}
+
+// ClientTLSConfig returns the TLS config of the requests which the node sends to its own
+// endpoints or nil if TLS is disabled. The node trusts only its own certificate regardless of
+// the host name so that the same certificate works for the containers and the localhost ports.
+// The node certificate is also presented as the client certificate.
+func (sec *Security) ClientTLSConfig() *tls.Config {
+	if sec.cert == nil {
+		return nil
+	}
+	nodeCert := sec.cert.Leaf.Raw
+	return &tls.Config{
+		Certificates: []tls.Certificate{*sec.cert},
+		MinVersion:   tls.VersionTLS12,
+		// the peer certificate is verified below instead of the host name
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], nodeCert) {
+				return errors.New("the server did not present the node certificate")
+			}
+			return nil
+		},
+	}
+}
+
+// HTTPClient creates a client which can send requests to the endpoints of the node.
+func (sec *Security) HTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = sec.ClientTLSConfig()
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// SetToken sets the token of the request if the endpoints require it.
+func (sec *Security) SetToken(req *http.Request) {
+	if sec.cfg.RequireToken {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sec.token))
+	}
+}
+
+// Authenticate wraps the handler so that the requests need to present the token if the
+// config requires it.
+func (sec *Security) Authenticate(handler http.Handler) http.Handler {
+	if !sec.cfg.RequireToken {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sec.validToken(r.Header.Get("Authorization")) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (sec *Security) validToken(authHeader string) bool {
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	return len(sec.token) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(sec.token)) == 1
+}
+
+// ListenAndServe serves with TLS if it is enabled.
+func (sec *Security) ListenAndServe(server *http.Server) error {
+	server.TLSConfig = sec.ServerTLSConfig()
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
+
+// GoListenAndServe starts the server and does meaningful error handling on exit.
+func (sec *Security) GoListenAndServe(server *http.Server) {
+	go func() {
+		switch err := sec.ListenAndServe(server); err {
+		case nil, http.ErrServerClosed:
+			// do nothing
+		default:
+			log.WithError(err).Panic("server error")
+		}
+	}()
+}
+
+// GrpcServerOptions returns the TLS credentials and the token interceptors of the gRPC servers.
+func (sec *Security) GrpcServerOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if tlsConfig := sec.ServerTLSConfig(); tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if sec.cfg.RequireToken {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				if err := sec.checkGrpcToken(ctx); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := sec.checkGrpcToken(ss.Context()); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	return opts
+}
+
+func (sec *Security) checkGrpcToken(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authHeader := range md.Get("authorization") {
+		if sec.validToken(authHeader) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
@@ -0,0 +1,151 @@
+package apisecurity
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestServer(sec *Security) *httptest.Server {
+	server := httptest.NewUnstartedServer(sec.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	server.TLS = sec.ServerTLSConfig()
+	server.StartTLS()
+	return server
+}
+
+func get(client *http.Client, sec *Security, url string) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	sec.SetToken(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestMutualTLSAndToken(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	cfg := config.APISecurityConfig{TLS: true, RequireClientCert: true, RequireToken: true}
+	r.NoError(EnsureCertificate(dir, cfg))
+	sec, err := Load(dir, cfg, "test-token")
+	r.NoError(err)
+	r.Equal("https", sec.Scheme())
+
+	server := newTestServer(sec)
+	defer server.Close()
+
+	// the node trusts its own certificate and presents the token
+	code, err := get(sec.HTTPClient(time.Second), sec, server.URL)
+	r.NoError(err)
+	r.Equal(http.StatusOK, code)
+
+	// no token
+	noToken, err := Load(dir, config.APISecurityConfig{TLS: true}, "")
+	r.NoError(err)
+	code, err = get(noToken.HTTPClient(time.Second), noToken, server.URL)
+	r.NoError(err)
+	r.Equal(http.StatusUnauthorized, code)
+
+	// no client certificate
+	clientTLS := sec.ClientTLSConfig()
+	clientTLS.Certificates = nil
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{TLSClientConfig: clientTLS}}
+	_, err = get(client, sec, server.URL)
+	r.Error(err)
+
+	// a different certificate is not trusted even if the host matches
+	otherDir := t.TempDir()
+	r.NoError(EnsureCertificate(otherDir, cfg))
+	other, err := Load(otherDir, cfg, "test-token")
+	r.NoError(err)
+	_, err = get(other.HTTPClient(time.Second), other, server.URL)
+	r.Error(err)
+}
+
+func TestGrpcToken(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	cfg := config.APISecurityConfig{TLS: true, RequireToken: true}
+	r.NoError(EnsureCertificate(dir, cfg))
+	sec, err := Load(dir, cfg, "test-token")
+	r.NoError(err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	server := grpc.NewServer(sec.GrpcServerOptions()...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(sec.ClientTLSConfig())))
+	r.NoError(err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	r.Error(err)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer test-token")
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	r.NoError(err)
+	r.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestLoad(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	sec, err := Load(dir, config.APISecurityConfig{}, "")
+	r.NoError(err)
+	r.Equal("http", sec.Scheme())
+	r.Nil(sec.ServerTLSConfig())
+
+	_, err = Load(dir, config.APISecurityConfig{RequireToken: true}, "")
+	r.Error(err)
+	_, err = Load(dir, config.APISecurityConfig{RequireClientCert: true}, "")
+	r.Error(err)
+	_, err = Load(dir, config.APISecurityConfig{TLS: true, CertFile: "/etc/node.crt", KeyFile: "/etc/node.key"}, "")
+	r.Error(err)
+
+	// the generated certificate is not replaced
+	cfg := config.APISecurityConfig{TLS: true}
+	r.NoError(EnsureCertificate(dir, cfg))
+	certPEM, err := os.ReadFile(path.Join(dir, config.DefaultTLSCertFileName))
+	r.NoError(err)
+	r.NoError(EnsureCertificate(dir, cfg))
+	certPEM2, err := os.ReadFile(path.Join(dir, config.DefaultTLSCertFileName))
+	r.NoError(err)
+	r.Equal(certPEM, certPEM2)
+
+	// operator-provided certificate relative to the forta dir
+	r.NoError(os.Rename(path.Join(dir, config.DefaultTLSCertFileName), path.Join(dir, "node.crt")))
+	r.NoError(os.Rename(path.Join(dir, config.DefaultTLSKeyFileName), path.Join(dir, "node.key")))
+	sec, err = Load(dir, config.APISecurityConfig{TLS: true, CertFile: "node.crt", KeyFile: "node.key"}, "")
+	r.NoError(err)
+	r.Equal([]tls.Certificate{*sec.cert}, sec.ServerTLSConfig().Certificates)
+}
@@ -0,0 +1,103 @@
+package apisecurity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+const certValidity = time.Hour * 24 * 365 * 10
+
+// EnsureCertificate generates a self-signed certificate in the Forta dir if TLS is enabled
+// without a certificate and it was not generated before.
+func EnsureCertificate(fortaDir string, cfg config.APISecurityConfig) error {
+	if !cfg.TLS || len(cfg.CertFile) > 0 {
+		return nil
+	}
+	certFile := path.Join(fortaDir, config.DefaultTLSCertFileName)
+	keyFile := path.Join(fortaDir, config.DefaultTLSKeyFileName)
+	if _, err := os.Stat(certFile); err == nil {
+		return nil
+	}
+
+	certPEM, keyPEM, err := generateCertificate(certHosts())
+	if err != nil {
+		return fmt.Errorf("failed to generate the api certificate: %v", err)
+	}
+	if err := os.MkdirAll(path.Dir(certFile), 0755); err != nil {
+		return fmt.Errorf("failed to create the tls dir: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write the api key: %v", err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write the api certificate: %v", err)
+	}
+	return nil
+}
+
+// certHosts returns the names which the node endpoints are reachable at.
+func certHosts() []string {
+	hosts := []string{
+		"localhost", "127.0.0.1", "::1", "host.docker.internal",
+		config.DockerSupervisorContainerName, config.DockerScannerContainerName,
+		config.DockerJSONRPCProxyContainerName, config.DockerUpdaterContainerName,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	return hosts
+}
+
+// generateCertificate creates a self-signed certificate which can also verify itself
+// as the client certificate.
+func generateCertificate(hosts []string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Forta"}, CommonName: "forta-node"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
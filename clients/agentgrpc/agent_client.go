@@ -3,6 +3,8 @@ package agentgrpc
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/forta-network/forta-core-go/protocol"
@@ -10,10 +12,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-const defaultAgentResponseMaxByteCount = 1000000 // 1M
-
 // Method is gRPC method type.
 type Method string
 
@@ -26,28 +28,54 @@ const (
 
 // Client allows us to communicate with an agent.
 type Client struct {
-	conn *grpc.ClientConn
+	cfg        config.AgentGrpcConfig
+	conn       *grpc.ClientConn
+	compressor string
+	token      string
+	target     string
 	protocol.AgentClient
+
+	onStateChange func(ConnectionEvent)
+	onCall        func(*CallStats)
+
+	onHealthChange func(serving bool)
+	serving        *bool
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	mu        sync.RWMutex
 }
 
 // NewClient creates a new client.
-func NewClient() *Client {
-	return &Client{}
+func NewClient(cfg config.AgentGrpcConfig) *Client {
+	return &Client{
+		cfg:        cfg,
+		compressor: cfg.Compression,
+		closed:     make(chan struct{}),
+	}
+}
+
+// OnConnectionEvent sets the handler which is called with the connection events.
+func (client *Client) OnConnectionEvent(handler func(ConnectionEvent)) {
+	client.onStateChange = handler
+}
+
+// WithTarget overrides the address which is dialed instead of the agent container.
+func (client *Client) WithTarget(target string) {
+	client.target = target
 }
 
 // Dial dials an agent using the config.
 func (client *Client) Dial(cfg config.AgentConfig) error {
+	client.WithToken(cfg.Token)
 	var (
 		conn *grpc.ClientConn
 		err  error
 	)
 	for i := 0; i < 10; i++ {
 		conn, err = grpc.Dial(
-			fmt.Sprintf("%s:%s", cfg.ContainerName(), cfg.GrpcPort()),
-			grpc.WithInsecure(),
-			grpc.WithBlock(),
-			grpc.WithTimeout(10*time.Second),
-			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(defaultAgentResponseMaxByteCount)),
+			client.agentTarget(cfg),
+			append(client.dialOptions(), grpc.WithBlock(), grpc.WithTimeout(10*time.Second))...,
 		)
 		if err == nil {
 			break
@@ -62,24 +90,96 @@ func (client *Client) Dial(cfg config.AgentConfig) error {
 	}
 	client.WithConn(conn)
 	log.Debugf("connected to agent: %s", cfg.ContainerName())
+	go client.watchConnection(cfg)
+	go client.watchHealth(cfg)
 	return nil
 }
 
+func (client *Client) agentTarget(cfg config.AgentConfig) string {
+	if len(client.target) > 0 {
+		return client.target
+	}
+	return fmt.Sprintf("%s:%s", cfg.ContainerName(), cfg.GrpcPort())
+}
+
+// Compressor returns the compressor which is used for the requests. The configured compressor
+// is used until the agent rejects a compressed request.
+func (client *Client) Compressor() string {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.compressor
+}
+
+func (client *Client) disableCompression(compressor string, err error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.compressor != compressor {
+		return
+	}
+	client.compressor = ""
+	log.WithError(err).WithField("compressor", compressor).Warn("agent does not accept the compressed requests - disabling compression")
+}
+
+// isCompressionRejected tells if the agent could have failed the request because it cannot
+// decompress it. The gRPC servers respond with the unimplemented code if the decompressor
+// is missing.
+func isCompressionRejected(err error) bool {
+	st := status.Convert(err)
+	return st.Code() == codes.Unimplemented || strings.Contains(st.Message(), "Decompressor is not installed")
+}
+
 // WithConn sets the client conn.
 func (client *Client) WithConn(conn *grpc.ClientConn) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
 	client.conn = conn
 	client.AgentClient = protocol.NewAgentClient(conn)
 }
 
-// Invoke is a generalization of client methods.
+func (client *Client) getConn() *grpc.ClientConn {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.conn
+}
+
+// Invoke is a generalization of client methods. If the compression is enabled and the compressed
+// versions of the message are provided as a call option, the compressed message is sent instead.
+// If the agent rejects the compressed message, the message is sent again without compression.
 func (client *Client) Invoke(ctx context.Context, method Method, in, out interface{}, opts ...grpc.CallOption) error {
-	return client.conn.Invoke(ctx, string(method), in, out, opts...)
+	compressor := client.Compressor()
+	if len(compressor) == 0 {
+		return client.getConn().Invoke(ctx, string(method), in, out, opts...)
+	}
+	for _, opt := range opts {
+		compressed, ok := opt.(*CompressedMessages)
+		if !ok {
+			continue
+		}
+		msg, err := compressed.Get(compressor)
+		if err != nil {
+			return err
+		}
+		err = client.getConn().Invoke(ctx, string(method), msg, out, append(opts, grpc.UseCompressor(compressor))...)
+		if err == nil || !isCompressionRejected(err) {
+			return err
+		}
+		retryErr := client.getConn().Invoke(ctx, string(method), in, out, opts...)
+		// the compression is disabled only if the agent implements the method
+		if status.Code(retryErr) != codes.Unimplemented {
+			client.disableCompression(compressor, err)
+		}
+		return retryErr
+	}
+	return client.getConn().Invoke(ctx, string(method), in, out, opts...)
 }
 
 // Close implements io.Closer.
 func (client *Client) Close() error {
-	if client.conn != nil {
-		return client.conn.Close()
+	client.closeOnce.Do(func() {
+		close(client.closed)
+	})
+	if conn := client.getConn(); conn != nil {
+		return conn.Close()
 	}
 	return nil
 }
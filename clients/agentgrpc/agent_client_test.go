@@ -0,0 +1,104 @@
+package agentgrpc_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingAgentServer struct {
+	agentServer
+	initialized int32
+	evaluated   int32
+}
+
+func (as *countingAgentServer) Initialize(ctx context.Context, req *protocol.InitializeRequest) (*protocol.InitializeResponse, error) {
+	atomic.AddInt32(&as.initialized, 1)
+	return as.agentServer.Initialize(ctx, req)
+}
+
+func (as *countingAgentServer) EvaluateTx(ctx context.Context, req *protocol.EvaluateTxRequest) (*protocol.EvaluateTxResponse, error) {
+	atomic.AddInt32(&as.evaluated, 1)
+	return as.agentServer.EvaluateTx(ctx, req)
+}
+
+func startCompressionTestServer(t *testing.T, opts ...grpc.ServerOption) (*countingAgentServer, *agentgrpc.Client) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	server := grpc.NewServer(opts...)
+	as := &countingAgentServer{agentServer: agentServer{r: r, disableAssertion: true}}
+	protocol.RegisterAgentServer(server, as)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{Compression: agentgrpc.CompressorZstd})
+	agentClient.WithTarget(lis.Addr().String())
+	r.NoError(agentClient.Dial(config.AgentConfig{ID: "0x1"}))
+	t.Cleanup(func() { agentClient.Close() })
+	return as, agentClient
+}
+
+func invokeCompressed(t *testing.T, agentClient *agentgrpc.Client) {
+	r := require.New(t)
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+	var resp protocol.EvaluateTxResponse
+	r.NoError(agentClient.Invoke(
+		context.Background(), agentgrpc.MethodEvaluateTx, preparedMsg, &resp,
+		agentgrpc.NewCompressedMessages(preparedMsg),
+	))
+	r.Equal(protocol.ResponseStatus_SUCCESS, resp.Status)
+}
+
+func TestClient_Compression(t *testing.T) {
+	r := require.New(t)
+
+	var encoding atomic.Value
+	recordEncoding := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if stream, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ RecvCompress() string }); ok {
+			encoding.Store(stream.RecvCompress())
+		}
+		return handler(ctx, req)
+	}
+	as, agentClient := startCompressionTestServer(t, grpc.UnaryInterceptor(recordEncoding))
+	invokeCompressed(t, agentClient)
+	r.Equal(agentgrpc.CompressorZstd, agentClient.Compressor())
+	r.Equal(agentgrpc.CompressorZstd, encoding.Load())
+	r.Equal(int32(1), atomic.LoadInt32(&as.evaluated))
+	r.Equal(int32(0), atomic.LoadInt32(&as.initialized), "compression should not need the initialize call")
+}
+
+func TestClient_CompressionFallback(t *testing.T) {
+	r := require.New(t)
+
+	// the decompressors are registered for the whole process so the server rejects the zstd
+	// requests in the same way as the servers which do not have the zstd decompressor
+	rejectZstd := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		stream, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ RecvCompress() string })
+		if ok && stream.RecvCompress() == agentgrpc.CompressorZstd {
+			return nil, status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", agentgrpc.CompressorZstd)
+		}
+		return handler(ctx, req)
+	}
+	as, agentClient := startCompressionTestServer(t, grpc.UnaryInterceptor(rejectZstd))
+	invokeCompressed(t, agentClient)
+	r.Empty(agentClient.Compressor(), "compression should be disabled after the rejection")
+	r.Equal(int32(1), atomic.LoadInt32(&as.evaluated))
+
+	// the next requests are sent without compression
+	invokeCompressed(t, agentClient)
+	r.Equal(int32(2), atomic.LoadInt32(&as.evaluated))
+	r.Equal(int32(0), atomic.LoadInt32(&as.initialized))
+}
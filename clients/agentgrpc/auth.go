@@ -0,0 +1,80 @@
+package agentgrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"os"
+
+	"github.com/forta-network/forta-node/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthTokenKey is the metadata key which carries the token injected to the agent container.
+// The scanner sends it with every request and the agents send it back in the response headers.
+const AuthTokenKey = "x-forta-agent-token"
+
+var (
+	errMissingToken = status.Error(codes.Unauthenticated, "missing or invalid agent token")
+	errAgentAuth    = status.Error(codes.Unauthenticated, "agent failed to authenticate")
+)
+
+// WithToken sets the token to present to the agent.
+func (client *Client) WithToken(token string) {
+	client.token = token
+}
+
+func (client *Client) requiresAgentAuth() bool {
+	return len(client.token) > 0 && client.cfg.RequireAgentAuth
+}
+
+func hasToken(md metadata.MD, token string) bool {
+	for _, value := range md.Get(AuthTokenKey) {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenFromEnv returns the token injected to the agent container by the supervisor.
+func TokenFromEnv() string {
+	return os.Getenv(config.EnvAgentGrpcToken)
+}
+
+// AuthServerOptions returns the server options which make an agent reject the requests
+// without the token and send the token back to the scanner. It is a no-op if the token
+// is empty.
+func AuthServerOptions(token string) []grpc.ServerOption {
+	if len(token) == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(
+			ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if !hasToken(md, token) {
+				return nil, errMissingToken
+			}
+			if err := grpc.SetHeader(ctx, metadata.Pairs(AuthTokenKey, token)); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(
+			srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+		) error {
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			if !hasToken(md, token) {
+				return errMissingToken
+			}
+			if err := stream.SetHeader(metadata.Pairs(AuthTokenKey, token)); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+}
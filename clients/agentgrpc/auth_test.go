@@ -0,0 +1,60 @@
+package agentgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testAgentToken = "test-token"
+
+func testAuthStream(t *testing.T, serverToken, clientToken string) error {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer(agentgrpc.AuthServerOptions(serverToken)...)
+	agentgrpc.RegisterStreamServer(server, &streamServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{RequireAgentAuth: true})
+	agentClient.WithToken(clientToken)
+	conn, err := grpc.Dial(lis.Addr().String(), append(agentClient.Interceptors(), grpc.WithInsecure())...)
+	r.NoError(err)
+	agentClient.WithConn(conn)
+	defer agentClient.Close()
+
+	stream, err := agentClient.EvaluateTxStream(context.Background())
+	r.NoError(err)
+	defer stream.CloseSend()
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+	r.NoError(stream.Send(preparedMsg, nil))
+
+	_, err = stream.Recv()
+	return err
+}
+
+func TestAuth(t *testing.T) {
+	r := require.New(t)
+
+	r.NoError(testAuthStream(t, testAgentToken, testAgentToken))
+
+	err := testAuthStream(t, testAgentToken, "wrong-token")
+	r.Equal(codes.Unauthenticated, status.Code(err))
+
+	// agent does not send the token back
+	err = testAuthStream(t, "", testAgentToken)
+	r.Equal(codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,105 @@
+package agentgrpc
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// TraceChunkKey is the metadata key which tells the agents that the request contains only
+// a part of the traces. The value is in "<index>/<count>" format and the index starts from zero.
+const TraceChunkKey = "x-forta-trace-chunk"
+
+// traceFieldOverhead is the max number of bytes used for the tag and the length prefix
+// of a trace in the encoded transaction event.
+const traceFieldOverhead = 6
+
+// EncodedSize returns the payload size of the encoded message.
+func EncodedSize(msg *grpc.PreparedMsg) int {
+	return len((*preparedMsg)((unsafe.Pointer)(msg)).payload)
+}
+
+// ContextWithTraceChunk returns a context which makes the call tell the agent about the chunk.
+func ContextWithTraceChunk(ctx context.Context, index, count int) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, TraceChunkKey, fmt.Sprintf("%d/%d", index, count))
+}
+
+// SplitTxRequest splits the traces of the request across multiple requests so that each
+// request fits in the max size. Every request contains the rest of the event as is.
+// A trace which does not fit in a request alone is sent without the input and output data,
+// or is left out if it still does not fit. The number of these traces is returned too.
+func SplitTxRequest(req *protocol.EvaluateTxRequest, maxBytes int) ([]*protocol.EvaluateTxRequest, int) {
+	if req.Event == nil || len(req.Event.Traces) == 0 {
+		return []*protocol.EvaluateTxRequest{req}, 0
+	}
+	base := proto.Clone(req).(*protocol.EvaluateTxRequest)
+	base.Event.Traces = nil
+	// leave room for the event field which becomes larger with the traces
+	baseSize := proto.Size(base) + traceFieldOverhead
+
+	var (
+		chunks     []*protocol.EvaluateTxRequest
+		traces     []*protocol.TransactionEvent_Trace
+		tracesSize int
+		truncated  int
+	)
+	addChunk := func() {
+		chunk := proto.Clone(base).(*protocol.EvaluateTxRequest)
+		chunk.Event.Traces = traces
+		chunks = append(chunks, chunk)
+		traces = nil
+		tracesSize = 0
+	}
+	for _, trace := range req.Event.Traces {
+		traceSize := proto.Size(trace) + traceFieldOverhead
+		if baseSize+traceSize > maxBytes {
+			truncated++
+			trace = stripTrace(trace)
+			traceSize = proto.Size(trace) + traceFieldOverhead
+			if baseSize+traceSize > maxBytes {
+				continue
+			}
+		}
+		if len(traces) > 0 && baseSize+tracesSize+traceSize > maxBytes {
+			addChunk()
+		}
+		traces = append(traces, trace)
+		tracesSize += traceSize
+	}
+	addChunk()
+	return chunks, truncated
+}
+
+// stripTrace returns a copy of the trace without the call data, the code and the output.
+func stripTrace(trace *protocol.TransactionEvent_Trace) *protocol.TransactionEvent_Trace {
+	stripped := proto.Clone(trace).(*protocol.TransactionEvent_Trace)
+	if stripped.Action != nil {
+		stripped.Action.Input = ""
+		stripped.Action.Init = ""
+	}
+	if stripped.Result != nil {
+		stripped.Result.Code = ""
+		stripped.Result.Output = ""
+	}
+	return stripped
+}
+
+// EncodeTxChunks splits the request by the traces and encodes the chunks. It also returns
+// the number of the traces which were truncated or left out.
+func EncodeTxChunks(req *protocol.EvaluateTxRequest, maxBytes int) ([]*grpc.PreparedMsg, int, error) {
+	chunks, truncated := SplitTxRequest(req, maxBytes)
+	var encodedChunks []*grpc.PreparedMsg
+	for _, chunk := range chunks {
+		encoded, err := EncodeMessage(chunk)
+		if err != nil {
+			return nil, 0, err
+		}
+		encodedChunks = append(encodedChunks, encoded)
+	}
+	return encodedChunks, truncated, nil
+}
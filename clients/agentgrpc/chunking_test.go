@@ -0,0 +1,93 @@
+package agentgrpc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSplitTxRequest(t *testing.T) {
+	r := require.New(t)
+
+	req := &protocol.EvaluateTxRequest{
+		RequestId: "123",
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		req.Event.Traces = append(req.Event.Traces, &protocol.TransactionEvent_Trace{
+			Type:            "call",
+			TransactionHash: strings.Repeat("a", 1000),
+		})
+	}
+
+	chunks, truncated := agentgrpc.SplitTxRequest(req, 2*proto.Size(req))
+	r.Equal(1, len(chunks))
+	r.Zero(truncated)
+
+	const maxBytes = 3000
+	chunks, truncated = agentgrpc.SplitTxRequest(req, maxBytes)
+	r.Greater(len(chunks), 1)
+	r.Zero(truncated)
+	var traceCount int
+	for _, chunk := range chunks {
+		r.LessOrEqual(proto.Size(chunk), maxBytes)
+		r.Equal(req.RequestId, chunk.RequestId)
+		r.Equal(req.Event.Transaction.Hash, chunk.Event.Transaction.Hash)
+		traceCount += len(chunk.Event.Traces)
+	}
+	r.Equal(len(req.Event.Traces), traceCount)
+
+	encodedChunks, truncated, err := agentgrpc.EncodeTxChunks(req, maxBytes)
+	r.NoError(err)
+	r.Zero(truncated)
+	r.Len(encodedChunks, len(chunks))
+	for _, encoded := range encodedChunks {
+		r.LessOrEqual(agentgrpc.EncodedSize(encoded), maxBytes)
+	}
+}
+
+func TestSplitTxRequest_OversizedTrace(t *testing.T) {
+	r := require.New(t)
+
+	const maxBytes = 3000
+	req := &protocol.EvaluateTxRequest{
+		RequestId: "123",
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"},
+			Traces: []*protocol.TransactionEvent_Trace{
+				{Type: "call", Action: &protocol.TransactionEvent_TraceAction{To: "0x2"}},
+				// larger than the max size because of the input and the output
+				{
+					Type:   "call",
+					Action: &protocol.TransactionEvent_TraceAction{To: "0x3", Input: strings.Repeat("a", maxBytes)},
+					Result: &protocol.TransactionEvent_TraceResult{GasUsed: "0x1", Output: strings.Repeat("b", maxBytes)},
+				},
+				// larger than the max size even without the input and the output
+				{Type: "call", TransactionHash: strings.Repeat("c", maxBytes)},
+			},
+		},
+	}
+
+	chunks, truncated := agentgrpc.SplitTxRequest(req, maxBytes)
+	r.Equal(2, truncated)
+	var traces []*protocol.TransactionEvent_Trace
+	for _, chunk := range chunks {
+		r.LessOrEqual(proto.Size(chunk), maxBytes)
+		traces = append(traces, chunk.Event.Traces...)
+	}
+	r.Len(traces, 2)
+	r.Equal("0x2", traces[0].Action.To)
+	r.Equal("0x3", traces[1].Action.To)
+	r.Empty(traces[1].Action.Input)
+	r.Empty(traces[1].Result.Output)
+	r.Equal("0x1", traces[1].Result.GasUsed)
+
+	// the original request is not changed
+	r.Len(req.Event.Traces[1].Action.Input, maxBytes)
+}
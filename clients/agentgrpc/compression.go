@@ -0,0 +1,108 @@
+package agentgrpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor
+)
+
+// Compressor names
+const (
+	CompressorGzip = "gzip"
+	CompressorZstd = "zstd"
+)
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+type zstdCompressor struct{}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return CompressorZstd
+}
+
+// CompressMessage compresses the payload of an encoded message so that it can be sent
+// with the compressor specified as a call option.
+func CompressMessage(msg *grpc.PreparedMsg, compressorName string) (*grpc.PreparedMsg, error) {
+	compressor := encoding.GetCompressor(compressorName)
+	if compressor == nil {
+		return nil, fmt.Errorf("agentgrpc: unknown compressor '%s'", compressorName)
+	}
+	original := (*preparedMsg)((unsafe.Pointer)(msg))
+
+	var buf bytes.Buffer
+	w, err := compressor.Compress(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to create compressor: %v", err)
+	}
+	if _, err := w.Write(original.encodedData); err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to compress message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to compress message: %v", err)
+	}
+
+	payload := buf.Bytes()
+	hdr := make([]byte, 5)
+	hdr[0] = 1 // compressed flag
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	return (*grpc.PreparedMsg)((unsafe.Pointer)(&preparedMsg{
+		encodedData: original.encodedData,
+		payload:     payload,
+		hdr:         hdr,
+	})), nil
+}
+
+// CompressedMessages compresses an encoded message lazily and only once per compressor,
+// so that the same compressed payload is shared by all of the agents.
+type CompressedMessages struct {
+	grpc.EmptyCallOption
+
+	encoded *grpc.PreparedMsg
+	msgs    map[string]*grpc.PreparedMsg
+	mu      sync.Mutex
+}
+
+// NewCompressedMessages creates a new compressed message set for an encoded message.
+func NewCompressedMessages(encoded *grpc.PreparedMsg) *CompressedMessages {
+	return &CompressedMessages{
+		encoded: encoded,
+		msgs:    make(map[string]*grpc.PreparedMsg),
+	}
+}
+
+// Get returns the message compressed with the compressor.
+func (cm *CompressedMessages) Get(compressorName string) (*grpc.PreparedMsg, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if msg, ok := cm.msgs[compressorName]; ok {
+		return msg, nil
+	}
+	msg, err := CompressMessage(cm.encoded, compressorName)
+	if err != nil {
+		return nil, err
+	}
+	cm.msgs[compressorName] = msg
+	return msg, nil
+}
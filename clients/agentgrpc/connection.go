@@ -0,0 +1,118 @@
+package agentgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Connection defaults
+const (
+	DefaultRedialAfter = time.Minute
+)
+
+// ConnectionEvent is a change in the agent connection.
+type ConnectionEvent struct {
+	State    connectivity.State
+	Redialed bool
+}
+
+func (client *Client) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(client.cfg.GetMaxRecvMessageBytes()),
+			grpc.MaxCallSendMsgSize(client.cfg.GetMaxSendMessageBytes()),
+		),
+	}
+	opts = append(opts, client.Interceptors()...)
+	if client.cfg.KeepaliveIntervalSeconds > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(client.cfg.KeepaliveIntervalSeconds) * time.Second,
+			Timeout:             time.Duration(client.cfg.KeepaliveTimeoutSeconds) * time.Second,
+			PermitWithoutStream: true,
+		}))
+	}
+	if client.cfg.MaxBackoffSeconds > 0 {
+		backoffCfg := backoff.DefaultConfig
+		backoffCfg.MaxDelay = time.Duration(client.cfg.MaxBackoffSeconds) * time.Second
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoffCfg,
+			MinConnectTimeout: 10 * time.Second,
+		}))
+	}
+	return opts
+}
+
+// watchConnection reports the connection state changes and replaces the connection
+// with a new one if it stays in the transient failure state for too long.
+func (client *Client) watchConnection(cfg config.AgentConfig) {
+	logger := log.WithFields(log.Fields{
+		"agent":     cfg.ID,
+		"component": "agent-grpc",
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-client.closed
+		cancel()
+	}()
+
+	var failingSince time.Time
+	for {
+		conn := client.getConn()
+		state := conn.GetState()
+
+		// wait for a change but also check periodically to see if we are stuck
+		waitCtx, waitCancel := context.WithTimeout(ctx, DefaultRedialAfter)
+		changed := conn.WaitForStateChange(waitCtx, state)
+		waitCancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if changed {
+			state = conn.GetState()
+			logger.WithField("state", state.String()).Info("agent connection state changed")
+			client.notify(ConnectionEvent{State: state})
+			if state == connectivity.Shutdown {
+				return
+			}
+		}
+
+		if state != connectivity.TransientFailure {
+			failingSince = time.Time{}
+			continue
+		}
+		if failingSince.IsZero() {
+			failingSince = time.Now()
+			continue
+		}
+		if time.Since(failingSince) < DefaultRedialAfter {
+			continue
+		}
+
+		// redial without blocking and let the backoff do the rest
+		newConn, err := grpc.Dial(client.agentTarget(cfg), client.dialOptions()...)
+		if err != nil {
+			logger.WithError(err).Warn("failed to redial agent")
+			continue
+		}
+		client.WithConn(newConn)
+		conn.Close()
+		failingSince = time.Time{}
+		logger.Info("redialed agent")
+		client.notify(ConnectionEvent{State: newConn.GetState(), Redialed: true})
+	}
+}
+
+func (client *Client) notify(event ConnectionEvent) {
+	if client.onStateChange != nil {
+		client.onStateChange(event)
+	}
+}
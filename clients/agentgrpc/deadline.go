@@ -0,0 +1,57 @@
+package agentgrpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DeadlineKey is the metadata key which carries the scanner-side evaluation deadline
+// as Unix milliseconds. It lets the agents which cannot use the gRPC timeout header
+// abort the work when the scanner has already given up on the result.
+const DeadlineKey = "x-forta-deadline"
+
+// DeadlineFromMetadata returns the scanner-side evaluation deadline from the metadata.
+func DeadlineFromMetadata(md metadata.MD) (time.Time, bool) {
+	values := md.Get(DeadlineKey)
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true
+}
+
+func withDeadline(ctx context.Context) context.Context {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(
+		ctx, DeadlineKey, strconv.FormatInt(deadline.UnixNano()/int64(time.Millisecond), 10),
+	)
+}
+
+// DeadlineServerOptions returns the server options which make the agent handlers use the
+// scanner-side evaluation deadline if the incoming context does not have an earlier one.
+func DeadlineServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(
+			ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			deadline, ok := DeadlineFromMetadata(md)
+			if !ok {
+				return handler(ctx, req)
+			}
+			ctx, cancel := context.WithDeadline(ctx, deadline)
+			defer cancel()
+			return handler(ctx, req)
+		}),
+	}
+}
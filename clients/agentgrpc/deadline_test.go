@@ -0,0 +1,60 @@
+package agentgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type deadlineAgentServer struct {
+	protocol.UnimplementedAgentServer
+	mdDeadline  time.Time
+	ctxDeadline time.Time
+}
+
+func (as *deadlineAgentServer) EvaluateTx(ctx context.Context, req *protocol.EvaluateTxRequest) (*protocol.EvaluateTxResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	as.mdDeadline, _ = agentgrpc.DeadlineFromMetadata(md)
+	as.ctxDeadline, _ = ctx.Deadline()
+	return &protocol.EvaluateTxResponse{Status: protocol.ResponseStatus_SUCCESS}, nil
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer(agentgrpc.DeadlineServerOptions()...)
+	as := &deadlineAgentServer{}
+	protocol.RegisterAgentServer(server, as)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{})
+	conn, err := grpc.Dial(lis.Addr().String(), append(agentClient.Interceptors(), grpc.WithInsecure())...)
+	r.NoError(err)
+	agentClient.WithConn(conn)
+	defer agentClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+	r.NoError(agentClient.Invoke(ctx, agentgrpc.MethodEvaluateTx, preparedMsg, new(protocol.EvaluateTxResponse)))
+
+	r.WithinDuration(deadline, as.mdDeadline, time.Millisecond)
+	r.False(as.ctxDeadline.IsZero())
+	r.False(as.ctxDeadline.After(deadline))
+}
@@ -40,7 +40,7 @@ func init() {
 const benchAgentReqCount = 25
 
 func getBenchClient() *agentgrpc.Client {
-	agentClient := agentgrpc.NewClient()
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{})
 	for {
 		conn, err := grpc.Dial(fmt.Sprintf("localhost:%s", config.AgentGrpcPort), grpc.WithInsecure())
 		if err == nil {
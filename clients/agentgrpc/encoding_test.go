@@ -70,7 +70,7 @@ func TestEncodeMessage(t *testing.T) {
 	protocol.RegisterAgentServer(server, as)
 	go server.Serve(lis)
 
-	agentClient := agentgrpc.NewClient()
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{})
 	conn, err := grpc.Dial(fmt.Sprintf("localhost:%s", config.AgentGrpcPort), grpc.WithInsecure())
 	r.NoError(err)
 	agentClient.WithConn(conn)
@@ -79,3 +79,37 @@ func TestEncodeMessage(t *testing.T) {
 	r.NoError(agentClient.Invoke(context.Background(), agentgrpc.MethodEvaluateTx, preparedMsg, &resp))
 	<-as.doneCh
 }
+
+func TestCompressMessage(t *testing.T) {
+	r := require.New(t)
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer()
+	as := &agentServer{r: r, disableAssertion: true}
+	protocol.RegisterAgentServer(server, as)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	r.NoError(err)
+	defer conn.Close()
+
+	compressed := agentgrpc.NewCompressedMessages(preparedMsg)
+	for _, compressor := range []string{agentgrpc.CompressorGzip, agentgrpc.CompressorZstd} {
+		compressedMsg, err := compressed.Get(compressor)
+		r.NoError(err)
+		sameMsg, err := compressed.Get(compressor)
+		r.NoError(err)
+		r.Equal(compressedMsg, sameMsg)
+
+		var resp protocol.EvaluateTxResponse
+		r.NoError(conn.Invoke(context.Background(), string(agentgrpc.MethodEvaluateTx), compressedMsg, &resp, grpc.UseCompressor(compressor)))
+		r.Equal(protocol.ResponseStatus_SUCCESS, resp.Status)
+	}
+}
@@ -0,0 +1,98 @@
+package agentgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultHealthRewatchAfter is how long to wait before watching the agent health again
+// after the watch stream breaks.
+const DefaultHealthRewatchAfter = time.Second * 10
+
+// CheckHealth checks the agent health by using the standard gRPC health protocol.
+func (client *Client) CheckHealth(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	resp, err := healthpb.NewHealthClient(client.getConn()).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, err
+	}
+	return resp.Status, nil
+}
+
+// OnHealthChange sets the handler which is called whenever the agent starts or stops serving.
+// The handler is called immediately if the agent health is already known.
+func (client *Client) OnHealthChange(handler func(serving bool)) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.onHealthChange = handler
+	if client.serving != nil {
+		handler(*client.serving)
+	}
+}
+
+func (client *Client) setServing(serving bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.serving != nil && *client.serving == serving {
+		return
+	}
+	client.serving = &serving
+	if client.onHealthChange != nil {
+		client.onHealthChange(serving)
+	}
+}
+
+func (client *Client) isServingKnown() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.serving != nil
+}
+
+// watchHealth watches the agent health until the client is closed. The agents which do not
+// implement the health protocol are assumed to be always serving.
+func (client *Client) watchHealth(cfg config.AgentConfig) {
+	logger := log.WithField("agent", cfg.ContainerName())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-client.closed
+		cancel()
+	}()
+
+	for {
+		err := client.watchHealthOnce(ctx)
+		if status.Code(err) == codes.Unimplemented {
+			logger.Debug("agent does not implement the health protocol - stopped watching")
+			return
+		}
+		// an agent which was known to be healthy is not responsive anymore
+		if status.Code(err) == codes.Unavailable && client.isServingKnown() {
+			client.setServing(false)
+		}
+		select {
+		case <-client.closed:
+			return
+		case <-time.After(DefaultHealthRewatchAfter):
+		}
+		logger.WithError(err).Debug("agent health watch stream failed - watching again")
+	}
+}
+
+func (client *Client) watchHealthOnce(ctx context.Context) error {
+	stream, err := healthpb.NewHealthClient(client.getConn()).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		client.setServing(resp.Status == healthpb.HealthCheckResponse_SERVING)
+	}
+}
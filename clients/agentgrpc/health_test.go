@@ -0,0 +1,47 @@
+package agentgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWatchHealth(t *testing.T) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer()
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	r.NoError(err)
+	client := NewClient(config.AgentGrpcConfig{})
+	client.WithConn(conn)
+	defer client.Close()
+
+	status, err := client.CheckHealth(context.Background())
+	r.NoError(err)
+	r.Equal(healthpb.HealthCheckResponse_SERVING, status)
+
+	servingCh := make(chan bool, 2)
+	client.OnHealthChange(func(serving bool) {
+		servingCh <- serving
+	})
+	go client.watchHealth(config.AgentConfig{ID: "test-agent"})
+	r.True(<-servingCh)
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	r.False(<-servingCh)
+}
@@ -0,0 +1,208 @@
+package agentgrpc
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// TraceIDKey is the metadata key which carries the trace ID to the agents.
+const TraceIDKey = "x-forta-trace-id"
+
+type traceIDKey struct{}
+
+// ContextWithTraceID returns a context which makes the calls propagate the trace ID.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID from the context.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// CallStats contains the stats of an agent gRPC call or stream.
+type CallStats struct {
+	Method        string
+	Duration      time.Duration
+	RequestBytes  int
+	ResponseBytes int
+	Code          codes.Code
+}
+
+// MethodName returns the short method name.
+func (cs *CallStats) MethodName() string {
+	return cs.Method[strings.LastIndex(cs.Method, "/")+1:]
+}
+
+// OnCall sets the handler which is called with the stats of every call.
+func (client *Client) OnCall(handler func(*CallStats)) {
+	client.onCall = handler
+}
+
+// Interceptors returns the dial options which install the metrics and tracing interceptors.
+func (client *Client) Interceptors() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(client.unaryInterceptor),
+		grpc.WithStreamInterceptor(client.streamInterceptor),
+	}
+}
+
+func (client *Client) reportCall(stats *CallStats) {
+	if client.onCall != nil {
+		client.onCall(stats)
+	}
+}
+
+// outgoingContext attaches the trace ID, the auth token and the deadline to the outgoing metadata.
+func (client *Client) outgoingContext(ctx context.Context) context.Context {
+	ctx = withDeadline(ctx)
+	if traceID := TraceIDFromContext(ctx); len(traceID) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, TraceIDKey, traceID)
+	}
+	// let the agents continue the trace of the block if they are instrumented
+	carrier := make(propagation.MapCarrier)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for key, value := range carrier {
+		ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+	}
+	if len(client.token) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, AuthTokenKey, client.token)
+	}
+	return ctx
+}
+
+func messageSize(msg interface{}) int {
+	switch m := msg.(type) {
+	case *grpc.PreparedMsg:
+		return EncodedSize(m)
+	case proto.Message:
+		return proto.Size(m)
+	default:
+		return 0
+	}
+}
+
+func (client *Client) unaryInterceptor(
+	ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	var header metadata.MD
+	if client.requiresAgentAuth() {
+		opts = append(opts, grpc.Header(&header))
+	}
+	startTime := time.Now()
+	err := invoker(client.outgoingContext(ctx), method, req, reply, cc, opts...)
+	if err == nil && client.requiresAgentAuth() && !hasToken(header, client.token) {
+		err = errAgentAuth
+	}
+	stats := &CallStats{
+		Method:       method,
+		Duration:     time.Since(startTime),
+		RequestBytes: messageSize(req),
+		Code:         status.Code(err),
+	}
+	if err == nil {
+		stats.ResponseBytes = messageSize(reply)
+	}
+	client.reportCall(stats)
+	return err
+}
+
+func (client *Client) streamInterceptor(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	stream, err := streamer(client.outgoingContext(ctx), desc, cc, method, opts...)
+	if err != nil {
+		client.reportCall(&CallStats{Method: method, Code: status.Code(err)})
+		return nil, err
+	}
+	ms := &monitoredStream{
+		ClientStream: stream,
+		client:       client,
+		stats:        &CallStats{Method: method},
+		startTime:    time.Now(),
+		requireAuth:  client.requiresAgentAuth(),
+	}
+	// the stream context is done when the stream is finished or cancelled
+	go func() {
+		<-stream.Context().Done()
+		ms.end(status.FromContextError(stream.Context().Err()).Err())
+	}()
+	return ms, nil
+}
+
+// monitoredStream collects the stats of a stream and reports them when the stream ends.
+type monitoredStream struct {
+	grpc.ClientStream
+	client    *Client
+	stats     *CallStats
+	startTime time.Time
+	endOnce   sync.Once
+	mu        sync.Mutex
+
+	requireAuth   bool
+	authenticated bool
+}
+
+func (ms *monitoredStream) SendMsg(m interface{}) error {
+	err := ms.ClientStream.SendMsg(m)
+	if err == nil {
+		ms.mu.Lock()
+		ms.stats.RequestBytes += messageSize(m)
+		ms.mu.Unlock()
+	}
+	return err
+}
+
+func (ms *monitoredStream) RecvMsg(m interface{}) error {
+	err := ms.ClientStream.RecvMsg(m)
+	if err == nil && ms.requireAuth && !ms.authenticated {
+		err = ms.authenticate()
+	}
+	if err != nil {
+		ms.end(err)
+		return err
+	}
+	ms.mu.Lock()
+	ms.stats.ResponseBytes += messageSize(m)
+	ms.mu.Unlock()
+	return nil
+}
+
+// authenticate verifies the token in the response headers of the stream.
+func (ms *monitoredStream) authenticate() error {
+	header, err := ms.Header()
+	if err != nil {
+		return err
+	}
+	if !hasToken(header, ms.client.token) {
+		return errAgentAuth
+	}
+	ms.authenticated = true
+	return nil
+}
+
+func (ms *monitoredStream) end(err error) {
+	ms.endOnce.Do(func() {
+		ms.mu.Lock()
+		defer ms.mu.Unlock()
+		ms.stats.Duration = time.Since(ms.startTime)
+		if err != io.EOF {
+			ms.stats.Code = status.Code(err)
+		}
+		ms.client.reportCall(ms.stats)
+	})
+}
@@ -0,0 +1,80 @@
+package agentgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+type tracingStreamServer struct {
+	streamServer
+}
+
+func (ss *tracingStreamServer) EvaluateTxStream(stream agentgrpc.TxServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if err := stream.Send(&protocol.EvaluateTxResponse{
+			Status: protocol.ResponseStatus_SUCCESS,
+			Metadata: map[string]string{
+				agentgrpc.StreamRequestIDKey: req.RequestId,
+				agentgrpc.TraceIDKey:         md.Get(agentgrpc.TraceIDKey)[0],
+			},
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer()
+	agentgrpc.RegisterStreamServer(server, &tracingStreamServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{})
+	callStats := make(chan *agentgrpc.CallStats, 1)
+	agentClient.OnCall(func(stats *agentgrpc.CallStats) {
+		callStats <- stats
+	})
+	conn, err := grpc.Dial(lis.Addr().String(), append(agentClient.Interceptors(), grpc.WithInsecure())...)
+	r.NoError(err)
+	agentClient.WithConn(conn)
+	defer agentClient.Close()
+
+	ctx, cancel := context.WithCancel(agentgrpc.ContextWithTraceID(context.Background(), "trace-1"))
+	stream, err := agentClient.EvaluateTxStream(ctx)
+	r.NoError(err)
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+	r.NoError(stream.Send(preparedMsg, nil))
+
+	resp, err := stream.Recv()
+	r.NoError(err)
+	r.Equal("trace-1", resp.Metadata[agentgrpc.TraceIDKey])
+	cancel()
+
+	stats := <-callStats
+	r.Equal("EvaluateTx", stats.MethodName())
+	r.Equal(codes.Canceled, stats.Code)
+	r.Greater(stats.RequestBytes, 0)
+	r.Greater(stats.ResponseBytes, 0)
+}
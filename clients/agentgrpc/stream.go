@@ -0,0 +1,187 @@
+package agentgrpc
+
+import (
+	"context"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"google.golang.org/grpc"
+)
+
+// Agent streaming gRPC methods
+const (
+	MethodEvaluateTxStream    Method = "/network.forta.AgentStream/EvaluateTx"
+	MethodEvaluateBlockStream Method = "/network.forta.AgentStream/EvaluateBlock"
+)
+
+// StreamRequestIDKey is the response metadata key which the agents should use to refer
+// to the request ID, since the responses can be sent asynchronously on the streams.
+const StreamRequestIDKey = "requestId"
+
+var bidiStreamDesc = &grpc.StreamDesc{
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// TxStream sends tx requests to an agent and receives the responses asynchronously.
+type TxStream interface {
+	Send(encoded *grpc.PreparedMsg, compressed *CompressedMessages) error
+	Recv() (*protocol.EvaluateTxResponse, error)
+	CloseSend() error
+}
+
+// BlockStream sends block requests to an agent and receives the responses asynchronously.
+type BlockStream interface {
+	Send(encoded *grpc.PreparedMsg, compressed *CompressedMessages) error
+	Recv() (*protocol.EvaluateBlockResponse, error)
+	CloseSend() error
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	compressor string
+}
+
+// Send sends the compressed message if the compression is enabled.
+func (cs *clientStream) Send(encoded *grpc.PreparedMsg, compressed *CompressedMessages) error {
+	if len(cs.compressor) > 0 && compressed != nil {
+		msg, err := compressed.Get(cs.compressor)
+		if err != nil {
+			return err
+		}
+		return cs.SendMsg(msg)
+	}
+	return cs.SendMsg(encoded)
+}
+
+type txStream struct {
+	*clientStream
+}
+
+func (s *txStream) Recv() (*protocol.EvaluateTxResponse, error) {
+	resp := new(protocol.EvaluateTxResponse)
+	if err := s.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type blockStream struct {
+	*clientStream
+}
+
+func (s *blockStream) Recv() (*protocol.EvaluateBlockResponse, error) {
+	resp := new(protocol.EvaluateBlockResponse)
+	if err := s.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (client *Client) newStream(ctx context.Context, method Method) (*clientStream, error) {
+	var opts []grpc.CallOption
+	compressor := client.Compressor()
+	if len(compressor) > 0 {
+		opts = append(opts, grpc.UseCompressor(compressor))
+	}
+	stream, err := client.getConn().NewStream(ctx, bidiStreamDesc, string(method), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &clientStream{ClientStream: stream, compressor: compressor}, nil
+}
+
+// EvaluateTxStream opens a bi-directional tx evaluation stream.
+func (client *Client) EvaluateTxStream(ctx context.Context) (TxStream, error) {
+	stream, err := client.newStream(ctx, MethodEvaluateTxStream)
+	if err != nil {
+		return nil, err
+	}
+	return &txStream{clientStream: stream}, nil
+}
+
+// EvaluateBlockStream opens a bi-directional block evaluation stream.
+func (client *Client) EvaluateBlockStream(ctx context.Context) (BlockStream, error) {
+	stream, err := client.newStream(ctx, MethodEvaluateBlockStream)
+	if err != nil {
+		return nil, err
+	}
+	return &blockStream{clientStream: stream}, nil
+}
+
+// StreamServer is implemented by the agents which support the streaming API.
+type StreamServer interface {
+	EvaluateTxStream(TxServerStream) error
+	EvaluateBlockStream(BlockServerStream) error
+}
+
+// TxServerStream is the agent side of the tx evaluation stream.
+type TxServerStream interface {
+	Recv() (*protocol.EvaluateTxRequest, error)
+	Send(*protocol.EvaluateTxResponse) error
+	grpc.ServerStream
+}
+
+// BlockServerStream is the agent side of the block evaluation stream.
+type BlockServerStream interface {
+	Recv() (*protocol.EvaluateBlockRequest, error)
+	Send(*protocol.EvaluateBlockResponse) error
+	grpc.ServerStream
+}
+
+type txServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *txServerStream) Recv() (*protocol.EvaluateTxRequest, error) {
+	req := new(protocol.EvaluateTxRequest)
+	if err := s.RecvMsg(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *txServerStream) Send(resp *protocol.EvaluateTxResponse) error {
+	return s.SendMsg(resp)
+}
+
+type blockServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *blockServerStream) Recv() (*protocol.EvaluateBlockRequest, error) {
+	req := new(protocol.EvaluateBlockRequest)
+	if err := s.RecvMsg(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *blockServerStream) Send(resp *protocol.EvaluateBlockResponse) error {
+	return s.SendMsg(resp)
+}
+
+// RegisterStreamServer registers the streaming API implementation of an agent.
+func RegisterStreamServer(s *grpc.Server, srv StreamServer) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "network.forta.AgentStream",
+		HandlerType: (*StreamServer)(nil),
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName: "EvaluateTx",
+				Handler: func(srv interface{}, stream grpc.ServerStream) error {
+					return srv.(StreamServer).EvaluateTxStream(&txServerStream{ServerStream: stream})
+				},
+				ServerStreams: true,
+				ClientStreams: true,
+			},
+			{
+				StreamName: "EvaluateBlock",
+				Handler: func(srv interface{}, stream grpc.ServerStream) error {
+					return srv.(StreamServer).EvaluateBlockStream(&blockServerStream{ServerStream: stream})
+				},
+				ServerStreams: true,
+				ClientStreams: true,
+			},
+		},
+	}, srv)
+}
@@ -0,0 +1,65 @@
+package agentgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type streamServer struct{}
+
+func (ss *streamServer) EvaluateTxStream(stream agentgrpc.TxServerStream) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if err := stream.Send(&protocol.EvaluateTxResponse{
+			Status:   protocol.ResponseStatus_SUCCESS,
+			Metadata: map[string]string{agentgrpc.StreamRequestIDKey: req.RequestId},
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (ss *streamServer) EvaluateBlockStream(stream agentgrpc.BlockServerStream) error {
+	return nil
+}
+
+func TestTxStream(t *testing.T) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	r.NoError(err)
+	defer lis.Close()
+
+	server := grpc.NewServer()
+	agentgrpc.RegisterStreamServer(server, &streamServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	r.NoError(err)
+	agentClient := agentgrpc.NewClient(config.AgentGrpcConfig{})
+	agentClient.WithConn(conn)
+	defer agentClient.Close()
+
+	stream, err := agentClient.EvaluateTxStream(context.Background())
+	r.NoError(err)
+
+	preparedMsg, err := agentgrpc.EncodeMessage(txMsg)
+	r.NoError(err)
+	r.NoError(stream.Send(preparedMsg, nil))
+
+	resp, err := stream.Recv()
+	r.NoError(err)
+	r.Equal(txMsg.RequestId, resp.Metadata[agentgrpc.StreamRequestIDKey])
+	r.NoError(stream.CloseSend())
+}
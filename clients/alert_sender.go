@@ -2,11 +2,10 @@ package clients
 
 import (
 	"context"
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/forta-network/forta-core-go/domain"
 	"github.com/forta-network/forta-core-go/protocol"
-	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/signer"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -36,14 +35,14 @@ type alertSender struct {
 }
 
 type AlertSenderConfig struct {
-	Key *keystore.Key
+	Signer signer.Signer
 }
 
 func (a *alertSender) SignAlertAndNotify(rt *AgentRoundTrip, alert *protocol.Alert, chainID, blockNumber string, ts *domain.TrackingTimestamps) error {
 	alert.Scanner = &protocol.ScannerInfo{
-		Address: a.cfg.Key.Address.Hex(),
+		Address: a.cfg.Signer.Address().Hex(),
 	}
-	signedAlert, err := security.SignAlert(a.cfg.Key, alert)
+	signedAlert, err := signer.SignAlert(a.cfg.Signer, alert)
 	if err != nil {
 		log.Errorf("could not sign alert (id=%s), skipping", alert.Id)
 		return err
@@ -0,0 +1,35 @@
+package awssig
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the get-vanilla case of the AWS Signature Version 4 test suite
+func TestSignRequest(t *testing.T) {
+	r := require.New(t)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	r.NoError(err)
+	now, err := time.Parse(dateFormat, "20150830T123600Z")
+	r.NoError(err)
+
+	SignRequest(req, Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service", PayloadHash(nil), now)
+
+	r.Equal("20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	r.Equal(
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"),
+	)
+	r.Empty(req.Header.Get("X-Amz-Security-Token"))
+
+	SignRequest(req, Credentials{AccessKeyID: "AKIDEXAMPLE", SessionToken: "token"}, "us-east-1", "service", PayloadHash(nil), now)
+	r.Equal("token", req.Header.Get("X-Amz-Security-Token"))
+	r.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,")
+}
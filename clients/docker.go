@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/forta-network/forta-core-go/utils/workers"
 	"github.com/forta-network/forta-node/config"
@@ -53,6 +55,13 @@ type DockerContainer struct {
 	Config    DockerContainerConfig
 }
 
+// ContainerStats is the resource usage of a container.
+type ContainerStats struct {
+	CPUPercent       float64 `json:"cpuPercent"`
+	MemoryBytes      uint64  `json:"memoryBytes"`
+	MemoryLimitBytes uint64  `json:"memoryLimitBytes"`
+}
+
 // DockerContainerConfig is configuration for a particular container
 type DockerContainerConfig struct {
 	Name            string
@@ -67,7 +76,18 @@ type DockerContainerConfig struct {
 	MaxLogSize      string
 	MaxLogFiles     int
 	CPUQuota        int64
+	CPUShares       int64
 	Memory          int64
+	GPUs            int
+	DiskQuotaMiB    int
+	ReadOnlyRootFS  bool
+	Tmpfs           map[string]string
+	SecurityOpts    []string
+	CapDrop         []string
+	CapAdd          []string
+	UsernsMode      string
+	DNS             []string
+	ExtraHosts      []string
 	Cmd             []string
 	DialHost        bool
 	Labels          map[string]string
@@ -114,6 +134,7 @@ type dockerClient struct {
 	username string
 	password string
 	labels   []dockerLabel
+	mirrors  []config.ImageMirrorConfig
 }
 
 func (cfg DockerContainerConfig) envVars() []string {
@@ -143,8 +164,33 @@ func (d *dockerClient) PullImage(ctx context.Context, refStr string) error {
 }
 
 func (d *dockerClient) pullImage(ctx context.Context, refStr string) error {
+	err := d.pullImageFrom(ctx, refStr, d.username, d.password)
+	if err == nil {
+		return nil
+	}
+	for _, mirror := range d.mirrors {
+		mirroredRef, ok := mirrorImageRef(refStr, mirror.Host)
+		if !ok {
+			break
+		}
+		logger := log.WithFields(log.Fields{
+			"ref":    refStr,
+			"mirror": mirror.Host,
+		})
+		logger.WithError(err).Warn("failed to pull image - trying the mirror")
+		if mirrorErr := d.pullImageFrom(ctx, mirroredRef, mirror.Username, mirror.Password); mirrorErr != nil {
+			logger.WithError(mirrorErr).Warn("failed to pull image from the mirror")
+			continue
+		}
+		logger.Info("pulled image from the mirror")
+		return nil
+	}
+	return err
+}
+
+func (d *dockerClient) pullImageFrom(ctx context.Context, refStr, username, password string) error {
 	r, err := d.cli.ImagePull(ctx, refStr, types.ImagePullOptions{
-		RegistryAuth: registryAuthValue(d.username, d.password),
+		RegistryAuth: registryAuthValue(username, password),
 	})
 	if err != nil {
 		return err
@@ -435,7 +481,7 @@ func (d *dockerClient) StartContainer(ctx context.Context, config DockerContaine
 	}
 
 	cntCfg := &container.Config{
-		Image:  config.Image,
+		Image:  d.resolveLocalImage(ctx, config.Image),
 		Env:    config.envVars(),
 		Labels: labelsToMap(d.labels),
 	}
@@ -461,9 +507,29 @@ func (d *dockerClient) StartContainer(ctx context.Context, config DockerContaine
 			Type: "json-file",
 		},
 		Resources: container.Resources{
-			CPUQuota: config.CPUQuota,
-			Memory:   config.Memory,
+			CPUQuota:  config.CPUQuota,
+			CPUShares: config.CPUShares,
+			Memory:    config.Memory,
 		},
+		ReadonlyRootfs: config.ReadOnlyRootFS,
+		Tmpfs:          config.Tmpfs,
+		SecurityOpt:    config.SecurityOpts,
+		CapDrop:        config.CapDrop,
+		CapAdd:         config.CapAdd,
+		UsernsMode:     container.UsernsMode(config.UsernsMode),
+		DNS:            config.DNS,
+		ExtraHosts:     config.ExtraHosts,
+	}
+	if config.DiskQuotaMiB > 0 {
+		hostCfg.StorageOpt = map[string]string{"size": fmt.Sprintf("%dM", config.DiskQuotaMiB)}
+	}
+	if config.GPUs > 0 {
+		// the nvidia runtime exposes the devices listed in the env var
+		hostCfg.Runtime = RuntimeNvidia
+		cntCfg.Env = append(cntCfg.Env,
+			fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", gpuDeviceList(config.GPUs)),
+			"NVIDIA_DRIVER_CAPABILITIES=compute,utility",
+		)
 	}
 
 	if config.DialHost {
@@ -542,7 +608,8 @@ func (d *dockerClient) stopContainer(ctx context.Context, containerID, signal st
 // RemoveContainer kills and a container by ID.
 func (d *dockerClient) RemoveContainer(ctx context.Context, containerID string) error {
 	return d.cli.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
-		Force: true,
+		Force:         true,
+		RemoveVolumes: true, // the anonymous volumes are never reused
 	})
 }
 
@@ -550,6 +617,13 @@ func isNoSuchContainerErr(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "no such container")
 }
 
+// IsStorageOptUnsupportedErr tells if the container could not be created because the storage
+// driver of the host does not support the disk quotas.
+func IsStorageOptUnsupportedErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "storage-opt") || strings.Contains(msg, "storage opt")
+}
+
 func isNotRunningErr(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "is not running")
 }
@@ -583,6 +657,18 @@ func (d *dockerClient) WaitContainerExit(ctx context.Context, id string) error {
 	}
 }
 
+// GetContainerExitCode returns the exit code of the container which has exited.
+func (d *dockerClient) GetContainerExitCode(ctx context.Context, id string) (int, error) {
+	inspection, err := d.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if inspection.State == nil || inspection.State.Status != "exited" {
+		return 0, fmt.Errorf("container has not exited")
+	}
+	return inspection.State.ExitCode, nil
+}
+
 // WaitContainerStart waits for container start by checking periodically.
 func (d *dockerClient) WaitContainerStart(ctx context.Context, id string) error {
 	ticker := time.NewTicker(time.Second)
@@ -644,10 +730,52 @@ func (d *dockerClient) WaitContainerPrune(ctx context.Context, id string) error
 
 // HasLocalImage checks if we have an image locally.
 func (d *dockerClient) HasLocalImage(ctx context.Context, ref string) bool {
-	_, _, err := d.cli.ImageInspectWithRaw(ctx, ref)
+	_, _, err := d.cli.ImageInspectWithRaw(ctx, d.resolveLocalImage(ctx, ref))
 	return err == nil
 }
 
+// GetImageDigests returns the repo digests of the local image.
+func (d *dockerClient) GetImageDigests(ctx context.Context, ref string) ([]string, error) {
+	inspection, _, err := d.cli.ImageInspectWithRaw(ctx, d.resolveLocalImage(ctx, ref))
+	if err != nil {
+		return nil, err
+	}
+	return inspection.RepoDigests, nil
+}
+
+// gpuDeviceList returns the indexes of the first n GPUs.
+func gpuDeviceList(n int) string {
+	devices := make([]string, n)
+	for i := range devices {
+		devices[i] = strconv.Itoa(i)
+	}
+	return strings.Join(devices, ",")
+}
+
+// GetImages returns all of the local images.
+func (d *dockerClient) GetImages(ctx context.Context) ([]types.ImageSummary, error) {
+	return d.cli.ImageList(ctx, types.ImageListOptions{})
+}
+
+// RemoveImage removes the image ref and the image itself if it was the last ref. The images
+// which are used by containers are not removed.
+func (d *dockerClient) RemoveImage(ctx context.Context, ref string) error {
+	_, err := d.cli.ImageRemove(ctx, ref, types.ImageRemoveOptions{
+		PruneChildren: true,
+	})
+	return err
+}
+
+// HasRuntime tells if the container runtime with given name is registered on the host.
+func (d *dockerClient) HasRuntime(ctx context.Context, name string) (bool, error) {
+	info, err := d.cli.Info(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := info.Runtimes[name]
+	return ok, nil
+}
+
 // EnsureLocalImage ensures that we have the image locally.
 func (d *dockerClient) EnsureLocalImage(ctx context.Context, name, ref string) error {
 	log.WithFields(log.Fields{
@@ -708,6 +836,59 @@ func (d *dockerClient) GetContainerLogs(ctx context.Context, containerID, tail s
 	return strings.Join(lines, "\n"), nil
 }
 
+// GetContainerStats returns the current resource usage of the container.
+func (d *dockerClient) GetContainerStats(ctx context.Context, containerID string) (*ContainerStats, error) {
+	resp, err := d.cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode the container stats: %v", err)
+	}
+
+	result := &ContainerStats{
+		MemoryBytes:      stats.MemoryStats.Usage,
+		MemoryLimitBytes: stats.MemoryStats.Limit,
+	}
+	if cache := stats.MemoryStats.Stats["cache"]; cache < result.MemoryBytes {
+		result.MemoryBytes -= cache
+	}
+	// same as 'docker stats'
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+	return result, nil
+}
+
+// StreamContainerLogs writes the stdout and stderr of the container to the writer. If follow
+// is set, it keeps writing the new logs until the context is done or the container stops.
+func (d *dockerClient) StreamContainerLogs(ctx context.Context, containerID, tail string, follow bool, w io.Writer) error {
+	r, err := d.cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Follow:     follow,
+		Tail:       tail,
+	})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = stdcopy.StdCopy(w, w, r)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
 func (d *dockerClient) labelFilter() filters.Args {
 	filter := filters.NewArgs()
 	for _, label := range d.labels {
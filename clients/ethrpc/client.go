@@ -0,0 +1,290 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	coreeth "github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	methodTraceBlock = "trace_block"
+	methodGetLogs    = "eth_getLogs"
+
+	requestTimeout     = time.Minute
+	maxErrorBodyLength = 1024
+)
+
+// the errors which should not be retried
+var permanentErrors = []string{
+	"method not found",
+	"hash is not currently canonical",
+	"unknown block",
+	"unable to complete request at this time",
+	"503 service unavailable",
+	"trace_block is not available",
+	"invalid host",
+}
+
+// retryOptions are the backoff settings of a request.
+type retryOptions struct {
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	maxElapsedTime time.Duration
+}
+
+var (
+	traceBlockRetry = retryOptions{
+		minBackoff:     15 * time.Second,
+		maxBackoff:     15 * time.Second,
+		maxElapsedTime: 12 * time.Hour,
+	}
+	getLogsRetry = retryOptions{
+		minBackoff:     5 * time.Second,
+		maxBackoff:     15 * time.Second,
+		maxElapsedTime: 12 * time.Hour,
+	}
+)
+
+// Client is an Ethereum client which decodes the trace_block and eth_getLogs responses while
+// reading them instead of buffering the whole response body. The rest of the requests are
+// sent by the wrapped client.
+type Client struct {
+	coreeth.Client
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+	requestID  uint64
+
+	lastTraceBlockReq health.TimeTracker
+	lastTraceBlockErr health.ErrorTracker
+}
+
+// NewClient wraps the Ethereum client of the JSON-RPC API.
+func NewClient(client coreeth.Client, cfg config.JsonRpcConfig) *Client {
+	return &Client{
+		Client:  client,
+		url:     cfg.Url,
+		headers: cfg.Headers,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					KeepAlive: 30 * time.Second,
+					Timeout:   5 * time.Second,
+				}).DialContext,
+				IdleConnTimeout:       5 * time.Second,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ExpectContinueTimeout: 1 * time.Second,
+			},
+		},
+	}
+}
+
+// TraceBlock returns the traces of the block.
+func (c *Client) TraceBlock(ctx context.Context, number *big.Int) ([]domain.Trace, error) {
+	name := fmt.Sprintf("%s(%s)", methodTraceBlock, number)
+	log.Debug(name)
+	var traces []domain.Trace
+	err := withRetry(ctx, name, traceBlockRetry, func(ctx context.Context) error {
+		traces = nil
+		err := c.call(ctx, methodTraceBlock, []interface{}{hexutil.EncodeBig(number)}, func(dec *json.Decoder) error {
+			return decodeArray(dec, func(dec *json.Decoder) error {
+				traces = append(traces, domain.Trace{})
+				return dec.Decode(&traces[len(traces)-1])
+			})
+		})
+		c.lastTraceBlockReq.Set()
+		c.lastTraceBlockErr.Set(err)
+		if err != nil {
+			return err
+		}
+		if len(traces) == 0 {
+			return coreeth.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// GetLogs returns the logs which match the query.
+func (c *Client) GetLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	name := fmt.Sprintf("%s(%v)", methodGetLogs, q)
+	log.Debug(name)
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	var logs []types.Log
+	err = withRetry(ctx, name, getLogsRetry, func(ctx context.Context) error {
+		logs = nil
+		return c.call(ctx, methodGetLogs, []interface{}{arg}, func(dec *json.Decoder) error {
+			return decodeArray(dec, func(dec *json.Decoder) error {
+				logs = append(logs, types.Log{})
+				return dec.Decode(&logs[len(logs)-1])
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Health implements health.Reporter interface.
+func (c *Client) Health() health.Reports {
+	var reports health.Reports
+	for _, report := range c.Client.Health() {
+		// the trace requests are sent by this client
+		if !strings.HasPrefix(report.Name, "request.trace-block.") {
+			reports = append(reports, report)
+		}
+	}
+	return append(reports,
+		c.lastTraceBlockReq.GetReport("request.trace-block.time"),
+		c.lastTraceBlockErr.GetReport("request.trace-block.error"),
+	)
+}
+
+type jsonRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// call sends the request and decodes the result from the response body stream.
+func (c *Client) call(ctx context.Context, method string, params []interface{}, decodeResult func(dec *json.Decoder) error) error {
+	b, err := json.Marshal(&jsonRequest{
+		Version: "2.0",
+		ID:      atomic.AddUint64(&c.requestID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	return decodeResponse(json.NewDecoder(resp.Body), decodeResult)
+}
+
+func isPermanentError(err error) bool {
+	errStr := strings.ToLower(err.Error())
+	for _, pe := range permanentErrors {
+		if strings.Contains(errStr, pe) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry retries the operation with exponential backoff until it succeeds, fails with
+// a permanent error, the context is done or the max elapsed time is reached.
+func withRetry(ctx context.Context, name string, options retryOptions, operation func(ctx context.Context) error) error {
+	start := time.Now()
+	backoff := options.minBackoff
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		opCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		err := operation(opCtx)
+		cancel()
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			log.Errorf("%s context err found: %s", name, ctx.Err())
+			return ctx.Err()
+		case isPermanentError(err):
+			log.Errorf("%s failed with permanent error: %s", name, err)
+			return err
+		case time.Since(start)+backoff > options.maxElapsedTime:
+			log.Errorf("%s failed with error: %s", name, err)
+			return err
+		}
+		log.Warnf("%s failed...retrying: %s", name, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > options.maxBackoff {
+			backoff = options.maxBackoff
+		}
+	}
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	if number.Cmp(big.NewInt(-1)) == 0 {
+		return "pending"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
+	arg := make(map[string]interface{})
+	if len(q.Addresses) > 0 {
+		arg["address"] = q.Addresses
+	}
+	if len(q.Topics) > 0 {
+		arg["topics"] = q.Topics
+	}
+	if q.BlockHash != nil {
+		arg["blockHash"] = *q.BlockHash
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, fmt.Errorf("cannot specify both BlockHash and FromBlock/ToBlock")
+		}
+		return arg, nil
+	}
+	if q.FromBlock == nil {
+		arg["fromBlock"] = "0x0"
+	} else {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	return arg, nil
+}
@@ -0,0 +1,159 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/forta-network/forta-core-go/clients/health"
+	mock_ethereum "github.com/forta-network/forta-core-go/ethereum/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testLog = `{"address":"0x5a98fcbea516cf06857215779fd812ca3bef1b32","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],"data":"0x","blockNumber":"0x10","transactionHash":"0x1111111111111111111111111111111111111111111111111111111111111111","transactionIndex":"0x0","blockHash":"0x2222222222222222222222222222222222222222222222222222222222222222","logIndex":"0x0","removed":false}`
+
+type testServer struct {
+	*httptest.Server
+	requests []*jsonRequest
+	headers  []http.Header
+}
+
+func newTestServer(t *testing.T, responses ...string) *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		var req jsonRequest
+		require.NoError(t, json.Unmarshal(b, &req))
+		ts.requests = append(ts.requests, &req)
+		ts.headers = append(ts.headers, r.Header)
+		response := responses[0]
+		if len(responses) > 1 {
+			responses = responses[1:]
+		}
+		fmt.Fprint(w, response)
+	}))
+	return ts
+}
+
+func newTestClient(t *testing.T, url string) *Client {
+	return NewClient(mock_ethereum.NewMockClient(gomock.NewController(t)), config.JsonRpcConfig{
+		Url:     url,
+		Headers: map[string]string{"X-Api-Key": "test"},
+	})
+}
+
+func TestTraceBlock(t *testing.T) {
+	r := require.New(t)
+
+	traces := make([]string, 1000)
+	for i := range traces {
+		traces[i] = fmt.Sprintf(`{"action":{"callType":"call","from":"0x1","to":"0x2"},"blockHash":"0xb10c","blockNumber":16,"result":{"gasUsed":"0x0","output":"0x"},"subtraces":0,"traceAddress":[%d],"transactionHash":"0x1","transactionPosition":0,"type":"call"}`, i)
+	}
+	server := newTestServer(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":[%s]}`, strings.Join(traces, ",")))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	result, err := client.TraceBlock(context.Background(), big.NewInt(16))
+	r.NoError(err)
+	r.Len(result, len(traces))
+	r.Equal("0x2", *result[0].Action.To)
+	r.Equal(16, *result[0].BlockNumber)
+	r.Equal([]int{999}, result[999].TraceAddress)
+
+	r.Len(server.requests, 1)
+	r.Equal(methodTraceBlock, server.requests[0].Method)
+	r.Equal([]interface{}{"0x10"}, server.requests[0].Params)
+	r.Equal("test", server.headers[0].Get("X-Api-Key"))
+}
+
+func TestGetLogs(t *testing.T) {
+	r := require.New(t)
+
+	// the result does not need to be the first field
+	server := newTestServer(t, fmt.Sprintf(`{"result":[%s,%s],"id":1,"jsonrpc":"2.0"}`, testLog, testLog))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	logs, err := client.GetLogs(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(16), ToBlock: big.NewInt(16)})
+	r.NoError(err)
+	r.Len(logs, 2)
+	r.Equal(uint64(16), logs[0].BlockNumber)
+	r.Equal("0x5A98FcBEA516Cf06857215779Fd812CA3beF1B32", logs[1].Address.Hex())
+
+	r.Equal([]interface{}{map[string]interface{}{"fromBlock": "0x10", "toBlock": "0x10"}}, server.requests[0].Params)
+}
+
+func TestGetLogsEmpty(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(t, `{"jsonrpc":"2.0","id":1,"result":null}`)
+	defer server.Close()
+
+	logs, err := newTestClient(t, server.URL).GetLogs(context.Background(), ethereum.FilterQuery{})
+	r.NoError(err)
+	r.Empty(logs)
+}
+
+func TestPermanentError(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`)
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL).TraceBlock(context.Background(), big.NewInt(16))
+	r.Error(err)
+	r.Equal("Method not found", err.Error())
+	r.Len(server.requests, 1)
+}
+
+func TestRetry(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`, `{"jsonrpc":"2.0","id":2,"result":[]}`)
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	logs, err := client.GetLogs(context.Background(), ethereum.FilterQuery{})
+	r.NoError(err)
+	r.Empty(logs)
+	r.Len(server.requests, 2)
+}
+
+func TestRetryContext(t *testing.T) {
+	r := require.New(t)
+
+	server := newTestServer(t, `{"jsonrpc":"2.0","id":1,"result":[}`)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	_, err := newTestClient(t, server.URL).GetLogs(ctx, ethereum.FilterQuery{})
+	r.Equal(context.DeadlineExceeded, err)
+}
+
+func TestHealth(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	ethClient := mock_ethereum.NewMockClient(ctrl)
+	ethClient.EXPECT().Health().Return(health.Reports{
+		{Name: "request.block-by-number.time"},
+		{Name: "request.trace-block.time"},
+	})
+	client := NewClient(ethClient, config.JsonRpcConfig{})
+	var names []string
+	for _, report := range client.Health() {
+		names = append(names, report.Name)
+	}
+	r.Equal([]string{"request.block-by-number.time", "request.trace-block.time", "request.trace-block.error"}, names)
+}
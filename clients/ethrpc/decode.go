@@ -0,0 +1,93 @@
+package ethrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var errNoResult = errors.New("response has no result")
+
+// jsonError is the error object of a JSON-RPC response.
+type jsonError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (err *jsonError) Error() string {
+	if len(err.Message) == 0 {
+		return fmt.Sprintf("json-rpc error %d", err.Code)
+	}
+	return err.Message
+}
+
+// decodeResponse reads the JSON-RPC response object and lets the result be decoded from the
+// stream so that the response is never kept in memory as a whole.
+func decodeResponse(dec *json.Decoder, decodeResult func(dec *json.Decoder) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	var hasResult bool
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "result":
+			if err := decodeResult(dec); err != nil {
+				return fmt.Errorf("failed to decode the result: %v", err)
+			}
+			hasResult = true
+
+		case "error":
+			var rpcErr jsonError
+			if err := dec.Decode(&rpcErr); err != nil {
+				return err
+			}
+			return &rpcErr
+
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return err
+			}
+		}
+	}
+	if !hasResult {
+		return errNoResult
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeArray decodes the items of an array one by one. A null array has no items.
+func decodeArray(dec *json.Decoder, decodeItem func(dec *json.Decoder) error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array but found %v", token)
+	}
+	for dec.More() {
+		if err := decodeItem(dec); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, expected json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("expected '%s' but found %v", expected, token)
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 )
 
@@ -32,13 +33,20 @@ type DockerClient interface {
 	TerminateContainer(ctx context.Context, id string) error
 	RemoveContainer(ctx context.Context, containerID string) error
 	WaitContainerExit(ctx context.Context, id string) error
+	GetContainerExitCode(ctx context.Context, id string) (int, error)
 	WaitContainerStart(ctx context.Context, id string) error
 	Prune(ctx context.Context) error
 	WaitContainerPrune(ctx context.Context, id string) error
 	Nuke(ctx context.Context) error
 	HasLocalImage(ctx context.Context, ref string) bool
 	EnsureLocalImage(ctx context.Context, name, ref string) error
+	GetImageDigests(ctx context.Context, ref string) ([]string, error)
+	HasRuntime(ctx context.Context, name string) (bool, error)
+	GetImages(ctx context.Context) ([]types.ImageSummary, error)
+	RemoveImage(ctx context.Context, ref string) error
 	GetContainerLogs(ctx context.Context, containerID, tail string, truncate int) (string, error)
+	GetContainerStats(ctx context.Context, containerID string) (*ContainerStats, error)
+	StreamContainerLogs(ctx context.Context, containerID, tail string, follow bool, w io.Writer) error
 }
 
 // MessageClient receives and publishes messages.
@@ -48,10 +56,27 @@ type MessageClient interface {
 	PublishProto(subject string, payload proto.Message)
 }
 
+// the message bus implementations
+var (
+	_ MessageClient = &messaging.Client{}
+	_ MessageClient = &messaging.LocalClient{}
+)
+
+// NewMessageClient creates the client of the message bus which is selected in the config.
+// The services in the same process share the bus if it is local.
+func NewMessageClient(name string, cfg config.MessagingConfig) MessageClient {
+	if cfg.IsLocal() {
+		return messaging.LocalBus()
+	}
+	return messaging.NewClient(name, messaging.ServerAddress(cfg))
+}
+
 // AgentClient makes the gRPC requests to evaluate block and txs and receive results.
 type AgentClient interface {
 	Dial(config.AgentConfig) error
 	Invoke(ctx context.Context, method agentgrpc.Method, in, out interface{}, opts ...grpc.CallOption) error
+	EvaluateTxStream(ctx context.Context) (agentgrpc.TxStream, error)
+	EvaluateBlockStream(ctx context.Context) (agentgrpc.BlockStream, error)
 	protocol.AgentClient
 	io.Closer
 }
@@ -0,0 +1,20 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMessageClient_Local(t *testing.T) {
+	r := require.New(t)
+
+	// the services in the same process share the local bus
+	cfg := config.MessagingConfig{Type: config.MessagingTypeLocal}
+	scannerClient := NewMessageClient("scanner", cfg)
+	publisherClient := NewMessageClient("publisher", cfg)
+	r.Same(messaging.LocalBus(), scannerClient)
+	r.Same(scannerClient, publisherClient)
+}
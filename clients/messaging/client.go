@@ -2,6 +2,7 @@ package messaging
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/forta-network/forta-core-go/protocol"
@@ -16,10 +17,20 @@ var (
 	BufferSize = 1000
 )
 
+// Durable delivery settings
+var (
+	DurableStreamName = "agents"
+	DurableMaxAge     = time.Hour
+	DurableMaxDeliver = 10
+)
+
 // Client wraps the NATS client to publish and receive our messages.
 type Client struct {
-	logger *log.Entry
-	nc     *nats.Conn
+	name        string
+	logger      *log.Entry
+	nc          *nats.Conn
+	js          nats.JetStreamContext // nil if JetStream is not available
+	deadLetters *DeadLetterStore
 }
 
 // NewClient creates and starts a new client.
@@ -43,12 +54,51 @@ func NewClient(name, natsURL string) *Client {
 	}
 	logger.Info("successfully connected")
 	client := &Client{
-		logger: logger,
-		nc:     nc,
+		name:        name,
+		logger:      logger,
+		nc:          nc,
+		deadLetters: DeadLetters,
 	}
+	client.initJetStream()
 	return client
 }
 
+// initJetStream ensures the stream which keeps the durable subjects. The client falls back
+// to plain publish and subscribe if the server does not have JetStream enabled.
+func (client *Client) initJetStream() {
+	js, err := client.nc.JetStream()
+	if err != nil {
+		client.logger.WithError(err).Warn("failed to get jetstream context - durable delivery is disabled")
+		return
+	}
+	streamCfg := &nats.StreamConfig{
+		Name:     DurableStreamName,
+		Subjects: DurableSubjects,
+		Storage:  nats.MemoryStorage,
+		MaxAge:   DurableMaxAge,
+	}
+	// the stream may already be created by another client with a different config
+	if _, err = js.AddStream(streamCfg); err != nil {
+		_, err = js.UpdateStream(streamCfg)
+	}
+	if err != nil {
+		client.logger.WithError(err).Warn("failed to ensure the durable stream - durable delivery is disabled")
+		return
+	}
+	client.js = js
+}
+
+// isDurable tells if the messages of the subject should be delivered through JetStream.
+func (client *Client) isDurable(subject string) bool {
+	return client.js != nil && IsDurableSubject(subject)
+}
+
+// durableName makes a consumer name which is unique per client and subject so that
+// a restarted subscriber continues from where it left off.
+func durableName(clientName, subject string) string {
+	return strings.NewReplacer(".", "-", "*", "all", ">", "all", " ", "-").Replace(fmt.Sprintf("%s-%s", clientName, subject))
+}
+
 // AgentsHandler handles agents.* subjects.
 type AgentsHandler func(AgentPayload) error
 type AgentMetricHandler func(*protocol.AgentMetricList) error
@@ -56,59 +106,78 @@ type ScannerHandler func(ScannerPayload) error
 
 // Subscribe subscribes the consumer to this client.
 func (client *Client) Subscribe(subject string, handler interface{}) {
-	// TODO: Configure redelivery options somehow.
 	logger := client.logger.WithField("subject", subject)
-	_, err := client.nc.Subscribe(subject, func(m *nats.Msg) {
+	durable := client.isDurable(subject)
+	cb := func(m *nats.Msg) {
 		logger.Debugf("received: %s", string(m.Data))
 
-		var err error
-		switch h := handler.(type) {
-		case AgentsHandler:
-			var payload AgentPayload
-			err = json.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
+		// the failed messages are kept as dead letters so they are acked as well
+		client.deadLetters.handle(logger, subject, handler, m.Data, msgSchemaVersion(m))
+		if durable {
+			if err := m.Ack(); err != nil {
+				logger.Errorf("failed to send ack: %v", err)
 			}
-			err = h(payload)
-
-		case AgentMetricHandler:
-			var payload protocol.AgentMetricList
-			err = proto.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(&payload)
+		}
+	}
+	var err error
+	if durable {
+		logger = logger.WithField("durable", true)
+		_, err = client.js.Subscribe(
+			subject, cb,
+			nats.Durable(durableName(client.name, subject)),
+			// a new consumer should not replay the stale actions in the stream while an
+			// existing one still continues from its last ack
+			nats.DeliverNew(),
+			nats.ManualAck(),
+			nats.MaxDeliver(DurableMaxDeliver),
+		)
+	} else {
+		_, err = client.nc.Subscribe(subject, cb)
+	}
+	if err != nil {
+		logger.Panicf("failed to subscribe: %v", err)
+	}
+	logger.Info("subscribed")
+}
 
-		case ScannerHandler:
-			var payload ScannerPayload
-			err = json.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(payload)
+// handleMessage decodes the message data of the schema version for the handler and calls it.
+func handleMessage(logger *log.Entry, handler interface{}, data []byte, version int) error {
+	if version > SchemaVersion {
+		logger.WithField("version", version).Debug("decoding a newer schema version")
+	}
+	switch h := handler.(type) {
+	case AgentsHandler:
+		payload, err := decodeAgentPayload(logger, data, version)
+		if err != nil {
+			return err
+		}
+		return h(payload)
 
-		default:
-			logger.Panicf("no handler found")
+	case AgentMetricHandler:
+		var payload protocol.AgentMetricList
+		if err := proto.Unmarshal(data, &payload); err != nil {
+			return err
 		}
+		return h(&payload)
 
-		if err != nil {
-			if err := m.Nak(); err != nil {
-				logger.Errorf("failed to send nak: %v", err)
-			}
-			logger.Errorf("failed to handle msg: %v", err)
+	case ScannerHandler:
+		var payload ScannerPayload
+		if err := decodeJSONPayload(data, version, &payload); err != nil {
+			return err
 		}
-	})
-	if err != nil {
-		logger.Panicf("failed to subscribe: %v", err)
+		return h(payload)
+
+	default:
+		logger.Panicf("no handler found")
 	}
-	logger.Info("subscribed")
+	return nil
 }
 
 // Publish publishes new messages.
 func (client *Client) Publish(subject string, payload interface{}) {
 	logger := client.logger.WithField("subject", subject)
 	data, _ := json.Marshal(payload)
-	if err := client.nc.Publish(subject, data); err != nil {
+	if err := client.publish(subject, data); err != nil {
 		logger.Errorf("failed to publish msg: %v", err)
 	}
 	logger.Debugf("published: %s", string(data))
@@ -118,8 +187,18 @@ func (client *Client) Publish(subject string, payload interface{}) {
 func (client *Client) PublishProto(subject string, payload proto.Message) {
 	logger := client.logger.WithField("subject", subject)
 	data, _ := proto.Marshal(payload)
-	if err := client.nc.Publish(subject, data); err != nil {
+	if err := client.publish(subject, data); err != nil {
 		logger.Errorf("failed to publish msg: %v", err)
 	}
 	logger.Debugf("published: %s", string(data))
 }
+
+// publish sends the messages with the schema version. It stores the durable messages in the stream
+// before returning and publishes the rest without waiting.
+func (client *Client) publish(subject string, data []byte) error {
+	if client.isDurable(subject) {
+		_, err := client.js.PublishMsg(newMsg(subject, data))
+		return err
+	}
+	return client.nc.PublishMsg(newMsg(subject, data))
+}
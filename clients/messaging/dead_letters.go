@@ -0,0 +1,183 @@
+package messaging
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Message handler retry settings
+var (
+	HandlerRetries    = 3
+	HandlerRetryDelay = time.Second
+	MaxDeadLetters    = 1000
+)
+
+// ErrDeadLetterNotFound is returned when there is no dead letter message with the given ID.
+var ErrDeadLetterNotFound = errors.New("dead letter message not found")
+
+// DeadLetters keeps the messages which the handlers of this process failed to handle.
+var DeadLetters = NewDeadLetterStore(MaxDeadLetters)
+
+// DeadLetter is a message which could not be handled within the retry budget.
+type DeadLetter struct {
+	ID            string    `json:"id"`
+	Subject       string    `json:"subject"`
+	Reason        string    `json:"reason"`
+	Attempts      int       `json:"attempts"`
+	SchemaVersion int       `json:"schemaVersion"`
+	FailedAt      time.Time `json:"failedAt"`
+	Data          []byte    `json:"data"`
+
+	handler interface{}
+}
+
+// ReplayDeadLettersRequest selects the dead letter messages to replay. All of them are
+// replayed if no IDs are specified.
+type ReplayDeadLettersRequest struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+// ReplayDeadLettersResponse contains the replay results.
+type ReplayDeadLettersResponse struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// DeadLetterStore keeps the failed messages in memory with their handlers so that they
+// can be inspected and replayed.
+type DeadLetterStore struct {
+	letters []*DeadLetter
+	max     int
+	mu      sync.Mutex
+}
+
+// NewDeadLetterStore creates a new store which keeps up to max messages by dropping the oldest.
+func NewDeadLetterStore(max int) *DeadLetterStore {
+	return &DeadLetterStore{max: max}
+}
+
+// handle calls the handler until it succeeds or the retries are exhausted, and keeps
+// the message as a dead letter if all attempts fail.
+func (store *DeadLetterStore) handle(logger *log.Entry, subject string, handler interface{}, data []byte, version int) error {
+	var err error
+	for attempt := 1; attempt <= HandlerRetries+1; attempt++ {
+		if attempt > 1 {
+			time.Sleep(HandlerRetryDelay * time.Duration(attempt-1))
+		}
+		if err = handleMessage(logger, handler, data, version); err == nil {
+			return nil
+		}
+		handlerErrors.WithLabelValues(subject).Inc()
+		logger.WithError(err).WithField("attempt", attempt).Warn("failed to handle msg")
+	}
+
+	letter := &DeadLetter{
+		ID:            uuid.New().String(),
+		Subject:       subject,
+		Reason:        err.Error(),
+		Attempts:      HandlerRetries + 1,
+		SchemaVersion: version,
+		FailedAt:      time.Now().UTC(),
+		Data:          data,
+		handler:       handler,
+	}
+	store.put(logger, letter)
+	logger.WithField("deadLetter", letter.ID).Error("moved the msg to dead letters")
+	return err
+}
+
+func (store *DeadLetterStore) put(logger *log.Entry, letter *DeadLetter) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.max > 0 && len(store.letters) >= store.max {
+		dropped := store.letters[0]
+		store.letters = store.letters[1:]
+		deadLetterMessages.WithLabelValues(dropped.Subject).Dec()
+		logger.WithField("deadLetter", dropped.ID).Warn("too many dead letters - dropped the oldest")
+	}
+	store.letters = append(store.letters, letter)
+	deadLetterMessages.WithLabelValues(letter.Subject).Inc()
+}
+
+// List returns the dead letters from oldest to newest.
+func (store *DeadLetterStore) List() []*DeadLetter {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	letters := make([]*DeadLetter, 0, len(store.letters))
+	for _, letter := range store.letters {
+		result := *letter
+		letters = append(letters, &result)
+	}
+	return letters
+}
+
+// Get returns the dead letter with the given ID.
+func (store *DeadLetterStore) Get(id string) (*DeadLetter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, letter := range store.letters {
+		if letter.ID == id {
+			result := *letter
+			return &result, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+// Len returns the number of dead letters.
+func (store *DeadLetterStore) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return len(store.letters)
+}
+
+// Replay calls the handlers of the dead letters again. The replayed messages are removed and
+// the failed ones are kept. All of the dead letters are replayed if no IDs are specified and
+// the unknown IDs are ignored, since the letters of a node are kept in multiple processes.
+func (store *DeadLetterStore) Replay(ids []string) *ReplayDeadLettersResponse {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	selected := make(map[string]bool)
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var (
+		resp = &ReplayDeadLettersResponse{}
+		kept []*DeadLetter
+	)
+	for _, letter := range store.letters {
+		if len(selected) > 0 && !selected[letter.ID] {
+			kept = append(kept, letter)
+			continue
+		}
+		logger := log.WithFields(log.Fields{
+			"subject":    letter.Subject,
+			"deadLetter": letter.ID,
+		})
+		err := handleMessage(logger, letter.handler, letter.Data, letter.SchemaVersion)
+		if err != nil {
+			letter.Attempts++
+			letter.Reason = err.Error()
+			letter.FailedAt = time.Now().UTC()
+			kept = append(kept, letter)
+			resp.Failed++
+			deadLetterReplays.WithLabelValues(letter.Subject, "failed").Inc()
+			logger.WithError(err).Warn("failed to replay the dead letter")
+			continue
+		}
+		resp.Replayed++
+		deadLetterMessages.WithLabelValues(letter.Subject).Dec()
+		deadLetterReplays.WithLabelValues(letter.Subject, "replayed").Inc()
+		logger.Info("replayed the dead letter")
+	}
+	store.letters = kept
+	return resp
+}
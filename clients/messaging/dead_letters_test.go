@@ -0,0 +1,82 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterStore(t *testing.T) {
+	r := require.New(t)
+	logger := log.WithField("test", t.Name())
+
+	retryDelay := HandlerRetryDelay
+	HandlerRetryDelay = time.Millisecond
+	defer func() { HandlerRetryDelay = retryDelay }()
+
+	var (
+		calls   int
+		fail    bool
+		handled AgentPayload
+	)
+	handler := AgentsHandler(func(payload AgentPayload) error {
+		calls++
+		if fail {
+			return errors.New("failed")
+		}
+		handled = payload
+		return nil
+	})
+	data, _ := json.Marshal(AgentPayload{{ID: "0x1"}})
+
+	store := NewDeadLetterStore(2)
+	r.NoError(store.handle(logger, SubjectAgentsVersionsLatest, handler, data, SchemaVersion))
+	r.Equal(0, store.Len())
+
+	// fails all attempts and becomes a dead letter
+	calls = 0
+	fail = true
+	r.Error(store.handle(logger, SubjectAgentsVersionsLatest, handler, data, SchemaVersion))
+	r.Equal(HandlerRetries+1, calls)
+	letters := store.List()
+	r.Len(letters, 1)
+	r.Equal(SubjectAgentsVersionsLatest, letters[0].Subject)
+	r.Equal("failed", letters[0].Reason)
+	r.Equal(HandlerRetries+1, letters[0].Attempts)
+	letter, err := store.Get(letters[0].ID)
+	r.NoError(err)
+	r.Equal(data, letter.Data)
+	_, err = store.Get("unknown")
+	r.ErrorIs(err, ErrDeadLetterNotFound)
+
+	// the oldest is dropped when the store is full
+	store.handle(logger, SubjectAgentsVersionsLatest, handler, data, SchemaVersion)
+	store.handle(logger, SubjectAgentsVersionsLatest, handler, data, SchemaVersion)
+	r.Equal(2, store.Len())
+	_, err = store.Get(letters[0].ID)
+	r.ErrorIs(err, ErrDeadLetterNotFound)
+	letters = store.List()
+
+	// a failed replay keeps the letter
+	resp := store.Replay([]string{letters[0].ID, "unknown"})
+	r.Equal(&ReplayDeadLettersResponse{Failed: 1}, resp)
+	r.Equal(2, store.Len())
+	letter, err = store.Get(letters[0].ID)
+	r.NoError(err)
+	r.Equal(HandlerRetries+2, letter.Attempts)
+
+	fail = false
+	resp = store.Replay([]string{letters[0].ID})
+	r.Equal(&ReplayDeadLettersResponse{Replayed: 1}, resp)
+	r.Equal([]config.AgentConfig{{ID: "0x1"}}, []config.AgentConfig(handled))
+	r.Equal(1, store.Len())
+
+	resp = store.Replay(nil)
+	r.Equal(&ReplayDeadLettersResponse{Replayed: 1}, resp)
+	r.Equal(0, store.Len())
+}
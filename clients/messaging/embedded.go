@@ -0,0 +1,48 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/nats-io/nats-server/v2/server"
+	log "github.com/sirupsen/logrus"
+)
+
+const embeddedServerStartTimeout = 10 * time.Second
+
+// ServerAddress returns the address of the message bus server which the node services connect to.
+func ServerAddress(cfg config.MessagingConfig) string {
+	if cfg.IsEmbedded() {
+		return fmt.Sprintf("%s:%s", config.DockerSupervisorContainerName, config.DefaultNatsPort)
+	}
+	return fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort)
+}
+
+// StartEmbeddedServer starts a NATS server in this process so that the node does not need
+// to run a NATS container.
+func StartEmbeddedServer() (*server.Server, error) {
+	port, err := strconv.Atoi(config.DefaultNatsPort)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := server.NewServer(&server.Options{
+		Host:      "0.0.0.0",
+		Port:      port,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the embedded nats server: %v", err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(embeddedServerStartTimeout) {
+		ns.Shutdown()
+		return nil, errors.New("embedded nats server is not ready for connections")
+	}
+	log.WithField("port", port).Info("started the embedded nats server")
+	return ns, nil
+}
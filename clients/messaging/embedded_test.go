@@ -0,0 +1,120 @@
+package messaging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAddress(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort), ServerAddress(config.MessagingConfig{Type: config.MessagingTypeNATS}))
+	r.Equal(fmt.Sprintf("%s:%s", config.DockerSupervisorContainerName, config.DefaultNatsPort), ServerAddress(config.MessagingConfig{Type: config.MessagingTypeEmbedded}))
+}
+
+func TestEmbeddedServer(t *testing.T) {
+	r := require.New(t)
+
+	ns, err := StartEmbeddedServer()
+	if err != nil {
+		t.Skipf("cannot start the embedded server: %v", err)
+	}
+	defer ns.Shutdown()
+
+	client := NewClient("test", fmt.Sprintf("127.0.0.1:%s", config.DefaultNatsPort))
+	received := make(chan AgentPayload, 1)
+	client.Subscribe(SubjectAgentsActionRun, AgentsHandler(func(payload AgentPayload) error {
+		received <- payload
+		return nil
+	}))
+	client.Publish(SubjectAgentsActionRun, []config.AgentConfig{{ID: "0x1"}})
+	select {
+	case payload := <-received:
+		r.Len(payload, 1)
+		r.Equal("0x1", payload[0].ID)
+	case <-time.After(5 * time.Second):
+		r.FailNow("timed out waiting for the message")
+	}
+}
+
+func TestDurableDelivery(t *testing.T) {
+	r := require.New(t)
+
+	ns, err := StartEmbeddedServer()
+	if err != nil {
+		t.Skipf("cannot start the embedded server: %v", err)
+	}
+	defer ns.Shutdown()
+
+	natsURL := fmt.Sprintf("127.0.0.1:%s", config.DefaultNatsPort)
+	publisher := NewClient("publisher", natsURL)
+	r.NotNil(publisher.js)
+
+	received := make(chan AgentPayload, 1)
+	handler := AgentsHandler(func(payload AgentPayload) error {
+		received <- payload
+		return nil
+	})
+	subscriber := NewClient("subscriber", natsURL)
+	subscriber.Subscribe(SubjectAgentsStatusRunning, handler)
+	publisher.Publish(SubjectAgentsStatusRunning, []config.AgentConfig{{ID: "0x1"}})
+	select {
+	case payload := <-received:
+		r.Equal("0x1", payload[0].ID)
+	case <-time.After(5 * time.Second):
+		r.FailNow("timed out waiting for the first message")
+	}
+
+	// publish while the subscriber is down and expect it after it subscribes again
+	subscriber.nc.Close()
+	time.Sleep(time.Second) // let the server notice the lost interest
+	publisher.Publish(SubjectAgentsStatusRunning, []config.AgentConfig{{ID: "0x2"}})
+	subscriber = NewClient("subscriber", natsURL)
+	subscriber.Subscribe(SubjectAgentsStatusRunning, handler)
+	select {
+	case payload := <-received:
+		r.Equal("0x2", payload[0].ID)
+	case <-time.After(5 * time.Second):
+		r.FailNow("timed out waiting for the message published during the restart")
+	}
+}
+
+func TestDurableDelivery_LateSubscriber(t *testing.T) {
+	r := require.New(t)
+
+	ns, err := StartEmbeddedServer()
+	if err != nil {
+		t.Skipf("cannot start the embedded server: %v", err)
+	}
+	defer ns.Shutdown()
+
+	natsURL := fmt.Sprintf("127.0.0.1:%s", config.DefaultNatsPort)
+	publisher := NewClient("publisher", natsURL)
+	r.NotNil(publisher.js)
+
+	// published before the subscriber ever subscribed
+	publisher.Publish(SubjectAgentsActionRun, []config.AgentConfig{{ID: "0x1"}})
+
+	received := make(chan AgentPayload, 2)
+	subscriber := NewClient("late-subscriber", natsURL)
+	subscriber.Subscribe(SubjectAgentsActionRun, AgentsHandler(func(payload AgentPayload) error {
+		received <- payload
+		return nil
+	}))
+	publisher.Publish(SubjectAgentsActionRun, []config.AgentConfig{{ID: "0x2"}})
+	select {
+	case payload := <-received:
+		r.Equal("0x2", payload[0].ID)
+	case <-time.After(5 * time.Second):
+		r.FailNow("timed out waiting for the message")
+	}
+	select {
+	case payload := <-received:
+		r.FailNow("received an unexpected message", payload[0].ID)
+	case <-time.After(time.Second):
+	}
+}
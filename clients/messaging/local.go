@@ -0,0 +1,106 @@
+package messaging
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+// LocalClient delivers the messages to the subscribers in the same process over channels. It is
+// useful for running the services outside of the node, where there is no NATS server.
+type LocalClient struct {
+	logger        *log.Entry
+	subscriptions map[string][]*localSubscription
+	deadLetters   *DeadLetterStore
+	mu            sync.RWMutex
+}
+
+var (
+	localBus     *LocalClient
+	localBusOnce sync.Once
+)
+
+// NewLocalClient creates a new local client.
+func NewLocalClient(name string) *LocalClient {
+	return &LocalClient{
+		logger:        log.WithField("name", fmt.Sprintf("%s/messaging", name)),
+		subscriptions: make(map[string][]*localSubscription),
+		deadLetters:   DeadLetters,
+	}
+}
+
+// LocalBus returns the local client which is shared by all of the services in this process.
+func LocalBus() *LocalClient {
+	localBusOnce.Do(func() {
+		localBus = NewLocalClient("local")
+	})
+	return localBus
+}
+
+// localSubscription queues the messages of a subscriber without a limit so that the publishers
+// are never blocked and no message is dropped.
+type localSubscription struct {
+	queue  [][]byte
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+func (sub *localSubscription) push(data []byte) {
+	sub.mu.Lock()
+	sub.queue = append(sub.queue, data)
+	sub.mu.Unlock()
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *localSubscription) pop() (data [][]byte) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	data, sub.queue = sub.queue, nil
+	return
+}
+
+// Subscribe subscribes the consumer to this client. The messages are handled in the order
+// they are published, like they are with a NATS subscription.
+func (client *LocalClient) Subscribe(subject string, handler interface{}) {
+	logger := client.logger.WithField("subject", subject)
+	sub := &localSubscription{notify: make(chan struct{}, 1)}
+	go func() {
+		for range sub.notify {
+			for _, data := range sub.pop() {
+				client.deadLetters.handle(logger, subject, handler, data, SchemaVersion)
+			}
+		}
+	}()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.subscriptions[subject] = append(client.subscriptions[subject], sub)
+}
+
+// Publish publishes new messages.
+func (client *LocalClient) Publish(subject string, payload interface{}) {
+	data, _ := json.Marshal(payload)
+	client.deliver(subject, data)
+}
+
+// PublishProto publishes new messages.
+func (client *LocalClient) PublishProto(subject string, payload proto.Message) {
+	data, _ := proto.Marshal(payload)
+	client.deliver(subject, data)
+}
+
+// deliver queues the message for the subscribers without blocking so that the publishers
+// can publish while holding their locks.
+func (client *LocalClient) deliver(subject string, data []byte) {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	for _, sub := range client.subscriptions[subject] {
+		sub.push(data)
+	}
+}
@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalClient(t *testing.T) {
+	r := require.New(t)
+
+	client := NewLocalClient("test")
+	received := make(chan AgentPayload, 2)
+	handler := AgentsHandler(func(payload AgentPayload) error {
+		received <- payload
+		return nil
+	})
+	client.Subscribe(SubjectAgentsActionRun, handler)
+	client.Subscribe(SubjectAgentsActionRun, handler)
+
+	client.Publish(SubjectAgentsActionStop, []config.AgentConfig{{ID: "0x1"}})
+	client.Publish(SubjectAgentsActionRun, []config.AgentConfig{{ID: "0x2"}})
+	for i := 0; i < 2; i++ {
+		select {
+		case payload := <-received:
+			r.Len(payload, 1)
+			r.Equal("0x2", payload[0].ID)
+		case <-time.After(time.Second):
+			r.FailNow("timed out waiting for the message")
+		}
+	}
+	r.Len(received, 0)
+}
+
+func TestLocalClientOrder(t *testing.T) {
+	r := require.New(t)
+
+	client := NewLocalClient("test")
+	received := make(chan uint64, 10)
+	client.Subscribe(SubjectScannerBlock, ScannerHandler(func(payload ScannerPayload) error {
+		received <- payload.LatestBlockInput
+		return nil
+	}))
+	for i := uint64(0); i < 10; i++ {
+		client.Publish(SubjectScannerBlock, &ScannerPayload{LatestBlockInput: i})
+	}
+	for i := uint64(0); i < 10; i++ {
+		select {
+		case blockNumber := <-received:
+			r.Equal(i, blockNumber)
+		case <-time.After(time.Second):
+			r.FailNow("timed out waiting for the message")
+		}
+	}
+}
+
+func TestLocalClientSlowSubscriber(t *testing.T) {
+	r := require.New(t)
+
+	client := NewLocalClient("test")
+	unblock := make(chan struct{})
+	received := make(chan uint64, BufferSize*2)
+	client.Subscribe(SubjectScannerBlock, ScannerHandler(func(payload ScannerPayload) error {
+		<-unblock
+		received <- payload.LatestBlockInput
+		return nil
+	}))
+
+	// more than the buffer size of a NATS subscription while the subscriber is blocked
+	count := uint64(BufferSize * 2)
+	for i := uint64(0); i < count; i++ {
+		client.Publish(SubjectScannerBlock, &ScannerPayload{LatestBlockInput: i})
+	}
+	close(unblock)
+	for i := uint64(0); i < count; i++ {
+		select {
+		case blockNumber := <-received:
+			r.Equal(i, blockNumber)
+		case <-time.After(5 * time.Second):
+			r.FailNow("timed out waiting for the message")
+		}
+	}
+}
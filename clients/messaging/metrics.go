@@ -0,0 +1,27 @@
+package messaging
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Message bus metrics
+var (
+	handlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "bus_handler_errors_total",
+		Help:      "Number of failed message handler attempts by subject",
+	}, []string{"subject"})
+
+	deadLetterMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "bus_dead_letters",
+		Help:      "Number of messages which could not be handled within the retry budget by subject",
+	}, []string{"subject"})
+
+	deadLetterReplays = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "bus_dead_letter_replays_total",
+		Help:      "Number of dead letter message replays by subject and result",
+	}, []string{"subject", "result"})
+)
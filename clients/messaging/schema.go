@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+)
+
+// HeaderSchemaVersion is the message header which carries the payload schema version. The version
+// is not a part of the payload so that the nodes which do not know about it can still decode it.
+const HeaderSchemaVersion = "Forta-Schema-Version"
+
+// SchemaVersion is the version of the payloads published by this node. It should be incremented
+// when a payload changes in a way that the decoders of the previous version need to know about.
+const SchemaVersion = 1
+
+// newMsg creates a message which has the schema version.
+func newMsg(subject string, data []byte) *nats.Msg {
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(HeaderSchemaVersion, strconv.Itoa(SchemaVersion))
+	msg.Data = data
+	return msg
+}
+
+// msgSchemaVersion returns the schema version of the message. The messages without a version
+// are from the nodes before versioning and are treated as the first version.
+func msgSchemaVersion(msg *nats.Msg) int {
+	if msg.Header == nil {
+		return 1
+	}
+	version, err := strconv.Atoi(msg.Header.Get(HeaderSchemaVersion))
+	if err != nil || version < 1 {
+		return 1
+	}
+	return version
+}
+
+// decodeAgentPayload decodes the agent configs one by one and skips the ones which do not
+// match this version, so that a rolling upgrade does not fail the whole message.
+func decodeAgentPayload(logger *log.Entry, data []byte, version int) (AgentPayload, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, schemaError(err, version)
+	}
+	payload := make(AgentPayload, 0, len(items))
+	for i, item := range items {
+		var agentCfg config.AgentConfig
+		if err := json.Unmarshal(item, &agentCfg); err != nil {
+			logger.WithError(schemaError(err, version)).WithField("index", i).Warn("skipping agent config")
+			continue
+		}
+		payload = append(payload, agentCfg)
+	}
+	if len(payload) == 0 && len(items) > 0 {
+		return nil, schemaError(errors.New("no decodable agent configs"), version)
+	}
+	return payload, nil
+}
+
+// decodeJSONPayload decodes a JSON payload. The unknown fields from newer versions are ignored.
+func decodeJSONPayload(data []byte, version int, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return schemaError(err, version)
+	}
+	return nil
+}
+
+func schemaError(err error, version int) error {
+	if version == SchemaVersion {
+		return err
+	}
+	return fmt.Errorf("failed to decode schema version %d (supported: %d): %v", version, SchemaVersion, err)
+}
@@ -0,0 +1,59 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgSchemaVersion(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(SchemaVersion, msgSchemaVersion(newMsg(SubjectAgentsActionRun, nil)))
+	r.Equal(1, msgSchemaVersion(&nats.Msg{Subject: SubjectAgentsActionRun}))
+
+	msg := nats.NewMsg(SubjectAgentsActionRun)
+	msg.Header.Set(HeaderSchemaVersion, "2")
+	r.Equal(2, msgSchemaVersion(msg))
+	msg.Header.Set(HeaderSchemaVersion, "bad")
+	r.Equal(1, msgSchemaVersion(msg))
+}
+
+func TestDecodeAgentPayload(t *testing.T) {
+	r := require.New(t)
+	logger := log.WithField("test", t.Name())
+
+	payload, err := decodeAgentPayload(logger, []byte(`[{"id":"0x1","image":"img"}]`), 1)
+	r.NoError(err)
+	r.Len(payload, 1)
+	r.Equal("0x1", payload[0].ID)
+
+	// unknown fields and the configs with a different shape are tolerated
+	payload, err = decodeAgentPayload(logger, []byte(`[{"id":"0x1","newField":{"a":1}},{"id":2},{"id":"0x3"}]`), 2)
+	r.NoError(err)
+	r.Len(payload, 2)
+	r.Equal("0x1", payload[0].ID)
+	r.Equal("0x3", payload[1].ID)
+
+	payload, err = decodeAgentPayload(logger, []byte(`[]`), 1)
+	r.NoError(err)
+	r.Len(payload, 0)
+
+	_, err = decodeAgentPayload(logger, []byte(`[{"id":2}]`), 2)
+	r.Error(err)
+	r.Contains(err.Error(), "schema version 2")
+
+	_, err = decodeAgentPayload(logger, []byte(`{"agents":[]}`), 1)
+	r.Error(err)
+}
+
+func TestDecodeJSONPayload(t *testing.T) {
+	r := require.New(t)
+
+	var payload ScannerPayload
+	r.NoError(decodeJSONPayload([]byte(`{"latestBlockInput":10,"newField":true}`), 2, &payload))
+	r.Equal(uint64(10), payload.LatestBlockInput)
+	r.Error(decodeJSONPayload([]byte(`{"latestBlockInput":"10"}`), 1, &payload))
+}
@@ -7,16 +7,40 @@ import (
 
 // Message types
 const (
-	SubjectAgentsVersionsLatest = "agents.versions.latest"
-	SubjectAgentsActionRun      = "agents.action.run"
-	SubjectAgentsActionStop     = "agents.action.stop"
-	SubjectAgentsStatusRunning  = "agents.status.running"
-	SubjectAgentsStatusAttached = "agents.status.attached"
-	SubjectAgentsStatusStopped  = "agents.status.stopped"
-	SubjectMetricAgent          = "metric.agent"
-	SubjectScannerBlock         = "scanner.block"
+	SubjectAgentsVersionsLatest  = "agents.versions.latest"
+	SubjectAgentsActionRun       = "agents.action.run"
+	SubjectAgentsActionStop      = "agents.action.stop"
+	SubjectAgentsActionPause     = "agents.action.pause"
+	SubjectAgentsActionResume    = "agents.action.resume"
+	SubjectAgentsStatusRunning   = "agents.status.running"
+	SubjectAgentsStatusAttached  = "agents.status.attached"
+	SubjectAgentsStatusStopped   = "agents.status.stopped"
+	SubjectAgentsStatusUnhealthy = "agents.status.unhealthy"
+	SubjectAgentsStatusRestarted = "agents.status.restarted"
+	SubjectAgentsStatusCrashLoop = "agents.status.crashloop"
+	SubjectMetricAgent           = "metric.agent"
+	SubjectScannerBlock          = "scanner.block"
 )
 
+// DurableSubjects are delivered through JetStream so that the messages published while
+// a subscriber is restarting are delivered to it afterwards.
+var DurableSubjects = []string{
+	SubjectAgentsActionRun,
+	SubjectAgentsActionStop,
+	SubjectAgentsStatusRunning,
+	SubjectAgentsStatusStopped,
+}
+
+// IsDurableSubject tells if the subject is a durable subject.
+func IsDurableSubject(subject string) bool {
+	for _, durableSubject := range DurableSubjects {
+		if subject == durableSubject {
+			return true
+		}
+	}
+	return false
+}
+
 // AgentPayload is the message payload.
 type AgentPayload []config.AgentConfig
 
@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// SetImageMirrors sets the mirror registries which are tried in order when an image
+// cannot be pulled from its own registry.
+func (d *dockerClient) SetImageMirrors(mirrors []config.ImageMirrorConfig) {
+	d.mirrors = mirrors
+}
+
+// mirrorImageRef replaces the registry host of the image ref with the mirror host. The refs
+// without a registry host cannot be mirrored.
+func mirrorImageRef(ref, mirrorHost string) (string, bool) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s", mirrorHost, parts[1]), true
+}
+
+// resolveLocalImage returns the ref of the local image which was pulled either from the
+// original registry or from one of the mirrors.
+func (d *dockerClient) resolveLocalImage(ctx context.Context, ref string) string {
+	if _, _, err := d.cli.ImageInspectWithRaw(ctx, ref); err == nil {
+		return ref
+	}
+	for _, mirror := range d.mirrors {
+		mirroredRef, ok := mirrorImageRef(ref, mirror.Host)
+		if !ok {
+			break
+		}
+		if _, _, err := d.cli.ImageInspectWithRaw(ctx, mirroredRef); err == nil {
+			return mirroredRef
+		}
+	}
+	return ref
+}
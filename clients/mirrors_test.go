@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorImageRef(t *testing.T) {
+	r := require.New(t)
+
+	ref, ok := mirrorImageRef("disco.forta.network/bafybeie@sha256:cdd4", "mirror.example.com:5000")
+	r.True(ok)
+	r.Equal("mirror.example.com:5000/bafybeie@sha256:cdd4", ref)
+
+	ref, ok = mirrorImageRef("localhost/foo:latest", "mirror.example.com")
+	r.True(ok)
+	r.Equal("mirror.example.com/foo:latest", ref)
+
+	_, ok = mirrorImageRef("nats:2.3.2", "mirror.example.com")
+	r.False(ok)
+	_, ok = mirrorImageRef("library/nats:2.3.2", "mirror.example.com")
+	r.False(ok)
+}
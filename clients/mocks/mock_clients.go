@@ -6,6 +6,7 @@ package mock_clients
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	types "github.com/docker/docker/api/types"
@@ -130,6 +131,21 @@ func (mr *MockDockerClientMockRecorder) GetContainerByName(ctx, name interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerByName", reflect.TypeOf((*MockDockerClient)(nil).GetContainerByName), ctx, name)
 }
 
+// GetContainerExitCode mocks base method.
+func (m *MockDockerClient) GetContainerExitCode(ctx context.Context, id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainerExitCode", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainerExitCode indicates an expected call of GetContainerExitCode.
+func (mr *MockDockerClientMockRecorder) GetContainerExitCode(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerExitCode", reflect.TypeOf((*MockDockerClient)(nil).GetContainerExitCode), ctx, id)
+}
+
 // GetContainerLogs mocks base method.
 func (m *MockDockerClient) GetContainerLogs(ctx context.Context, containerID, tail string, truncate int) (string, error) {
 	m.ctrl.T.Helper()
@@ -145,6 +161,21 @@ func (mr *MockDockerClientMockRecorder) GetContainerLogs(ctx, containerID, tail,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerLogs", reflect.TypeOf((*MockDockerClient)(nil).GetContainerLogs), ctx, containerID, tail, truncate)
 }
 
+// GetContainerStats mocks base method.
+func (m *MockDockerClient) GetContainerStats(ctx context.Context, containerID string) (*clients.ContainerStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainerStats", ctx, containerID)
+	ret0, _ := ret[0].(*clients.ContainerStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainerStats indicates an expected call of GetContainerStats.
+func (mr *MockDockerClientMockRecorder) GetContainerStats(ctx, containerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerStats", reflect.TypeOf((*MockDockerClient)(nil).GetContainerStats), ctx, containerID)
+}
+
 // GetContainers mocks base method.
 func (m *MockDockerClient) GetContainers(ctx context.Context) (clients.DockerContainerList, error) {
 	m.ctrl.T.Helper()
@@ -175,6 +206,36 @@ func (mr *MockDockerClientMockRecorder) GetFortaServiceContainers(ctx interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFortaServiceContainers", reflect.TypeOf((*MockDockerClient)(nil).GetFortaServiceContainers), ctx)
 }
 
+// GetImageDigests mocks base method.
+func (m *MockDockerClient) GetImageDigests(ctx context.Context, ref string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageDigests", ctx, ref)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageDigests indicates an expected call of GetImageDigests.
+func (mr *MockDockerClientMockRecorder) GetImageDigests(ctx, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageDigests", reflect.TypeOf((*MockDockerClient)(nil).GetImageDigests), ctx, ref)
+}
+
+// GetImages mocks base method.
+func (m *MockDockerClient) GetImages(ctx context.Context) ([]types.ImageSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImages", ctx)
+	ret0, _ := ret[0].([]types.ImageSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImages indicates an expected call of GetImages.
+func (mr *MockDockerClientMockRecorder) GetImages(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImages", reflect.TypeOf((*MockDockerClient)(nil).GetImages), ctx)
+}
+
 // HasLocalImage mocks base method.
 func (m *MockDockerClient) HasLocalImage(ctx context.Context, ref string) bool {
 	m.ctrl.T.Helper()
@@ -189,6 +250,21 @@ func (mr *MockDockerClientMockRecorder) HasLocalImage(ctx, ref interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasLocalImage", reflect.TypeOf((*MockDockerClient)(nil).HasLocalImage), ctx, ref)
 }
 
+// HasRuntime mocks base method.
+func (m *MockDockerClient) HasRuntime(ctx context.Context, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasRuntime", ctx, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasRuntime indicates an expected call of HasRuntime.
+func (mr *MockDockerClientMockRecorder) HasRuntime(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasRuntime", reflect.TypeOf((*MockDockerClient)(nil).HasRuntime), ctx, name)
+}
+
 // InterruptContainer mocks base method.
 func (m *MockDockerClient) InterruptContainer(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -259,6 +335,20 @@ func (mr *MockDockerClientMockRecorder) RemoveContainer(ctx, containerID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveContainer", reflect.TypeOf((*MockDockerClient)(nil).RemoveContainer), ctx, containerID)
 }
 
+// RemoveImage mocks base method.
+func (m *MockDockerClient) RemoveImage(ctx context.Context, ref string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveImage", ctx, ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveImage indicates an expected call of RemoveImage.
+func (mr *MockDockerClientMockRecorder) RemoveImage(ctx, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveImage", reflect.TypeOf((*MockDockerClient)(nil).RemoveImage), ctx, ref)
+}
+
 // RemoveNetworkByName mocks base method.
 func (m *MockDockerClient) RemoveNetworkByName(ctx context.Context, networkName string) error {
 	m.ctrl.T.Helper()
@@ -302,6 +392,20 @@ func (mr *MockDockerClientMockRecorder) StopContainer(ctx, id interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopContainer", reflect.TypeOf((*MockDockerClient)(nil).StopContainer), ctx, id)
 }
 
+// StreamContainerLogs mocks base method.
+func (m *MockDockerClient) StreamContainerLogs(ctx context.Context, containerID, tail string, follow bool, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamContainerLogs", ctx, containerID, tail, follow, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamContainerLogs indicates an expected call of StreamContainerLogs.
+func (mr *MockDockerClientMockRecorder) StreamContainerLogs(ctx, containerID, tail, follow, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamContainerLogs", reflect.TypeOf((*MockDockerClient)(nil).StreamContainerLogs), ctx, containerID, tail, follow, w)
+}
+
 // TerminateContainer mocks base method.
 func (m *MockDockerClient) TerminateContainer(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -488,6 +592,21 @@ func (mr *MockAgentClientMockRecorder) EvaluateBlock(ctx, in interface{}, opts .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateBlock", reflect.TypeOf((*MockAgentClient)(nil).EvaluateBlock), varargs...)
 }
 
+// EvaluateBlockStream mocks base method.
+func (m *MockAgentClient) EvaluateBlockStream(ctx context.Context) (agentgrpc.BlockStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateBlockStream", ctx)
+	ret0, _ := ret[0].(agentgrpc.BlockStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateBlockStream indicates an expected call of EvaluateBlockStream.
+func (mr *MockAgentClientMockRecorder) EvaluateBlockStream(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateBlockStream", reflect.TypeOf((*MockAgentClient)(nil).EvaluateBlockStream), ctx)
+}
+
 // EvaluateTx mocks base method.
 func (m *MockAgentClient) EvaluateTx(ctx context.Context, in *protocol.EvaluateTxRequest, opts ...grpc.CallOption) (*protocol.EvaluateTxResponse, error) {
 	m.ctrl.T.Helper()
@@ -508,6 +627,21 @@ func (mr *MockAgentClientMockRecorder) EvaluateTx(ctx, in interface{}, opts ...i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateTx", reflect.TypeOf((*MockAgentClient)(nil).EvaluateTx), varargs...)
 }
 
+// EvaluateTxStream mocks base method.
+func (m *MockAgentClient) EvaluateTxStream(ctx context.Context) (agentgrpc.TxStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvaluateTxStream", ctx)
+	ret0, _ := ret[0].(agentgrpc.TxStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateTxStream indicates an expected call of EvaluateTxStream.
+func (mr *MockAgentClientMockRecorder) EvaluateTxStream(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateTxStream", reflect.TypeOf((*MockAgentClient)(nil).EvaluateTxStream), ctx)
+}
+
 // Initialize mocks base method.
 func (m *MockAgentClient) Initialize(ctx context.Context, in *protocol.InitializeRequest, opts ...grpc.CallOption) (*protocol.InitializeResponse, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,75 @@
+package clients
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/docker/docker/client"
+	"github.com/forta-network/forta-core-go/utils/workers"
+	"github.com/forta-network/forta-node/config"
+)
+
+// Supported container runtimes
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// RuntimeNvidia is the OCI runtime which passes the NVIDIA GPUs to the containers.
+const RuntimeNvidia = "nvidia"
+
+// ContainerRuntimeSocketPath is where the runtime socket is mounted in the node containers.
+// The node containers always talk to the runtime through the Docker compatible API.
+const ContainerRuntimeSocketPath = "/var/run/docker.sock"
+
+const defaultDockerSocketPath = "/var/run/docker.sock"
+
+// RuntimeSocketPath returns the path of the runtime API socket on the host. Only the runtimes
+// which serve the Docker compatible API are supported, so the runtime type is checked before
+// the socket override.
+func RuntimeSocketPath(runtimeCfg config.ContainerRuntimeConfig) (string, error) {
+	var socketPath string
+	switch runtimeCfg.Type {
+	case "", RuntimeDocker:
+		socketPath = defaultDockerSocketPath
+	case RuntimePodman:
+		socketPath = podmanSocketPath()
+	default:
+		return "", fmt.Errorf("unknown container runtime: %s", runtimeCfg.Type)
+	}
+	if len(runtimeCfg.Socket) > 0 {
+		return runtimeCfg.Socket, nil
+	}
+	return socketPath, nil
+}
+
+// podmanSocketPath returns the rootless socket path for the regular users.
+func podmanSocketPath() string {
+	uid := os.Getuid()
+	if uid == 0 {
+		return "/run/podman/podman.sock"
+	}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(runtimeDir) == 0 {
+		runtimeDir = fmt.Sprintf("/run/user/%d", uid)
+	}
+	return path.Join(runtimeDir, "podman", "podman.sock")
+}
+
+// NewRuntimeClient creates a new client for the configured container runtime on the host.
+func NewRuntimeClient(name string, runtimeCfg config.ContainerRuntimeConfig) (*dockerClient, error) {
+	socketPath, err := RuntimeSocketPath(runtimeCfg)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := client.NewClientWithOpts(client.WithHost(fmt.Sprintf("unix://%s", socketPath)))
+	if err != nil {
+		return nil, err
+	}
+	return &dockerClient{
+		cli:     cli,
+		workers: workers.New(10),
+		labels:  initLabels(name),
+	}, nil
+}
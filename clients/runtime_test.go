@@ -0,0 +1,34 @@
+package clients
+
+import (
+	"os"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeSocketPath(t *testing.T) {
+	r := require.New(t)
+
+	socketPath, err := RuntimeSocketPath(config.ContainerRuntimeConfig{})
+	r.NoError(err)
+	r.Equal("/var/run/docker.sock", socketPath)
+
+	socketPath, err = RuntimeSocketPath(config.ContainerRuntimeConfig{Type: RuntimePodman, Socket: "/custom.sock"})
+	r.NoError(err)
+	r.Equal("/custom.sock", socketPath)
+
+	socketPath, err = RuntimeSocketPath(config.ContainerRuntimeConfig{Type: RuntimePodman})
+	r.NoError(err)
+	if os.Getuid() == 0 {
+		r.Equal("/run/podman/podman.sock", socketPath)
+	} else {
+		r.Contains(socketPath, "podman/podman.sock")
+	}
+
+	_, err = RuntimeSocketPath(config.ContainerRuntimeConfig{Type: "containerd", Socket: "/run/containerd/containerd.sock"})
+	r.Error(err)
+	_, err = RuntimeSocketPath(config.ContainerRuntimeConfig{Type: "lxc"})
+	r.Error(err)
+}
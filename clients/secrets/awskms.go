@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/forta-network/forta-node/clients/awssig"
+)
+
+const kmsService = "kms"
+
+type kmsDecryptRequest struct {
+	CiphertextBlob string `json:"CiphertextBlob"`
+}
+
+type kmsDecryptResponse struct {
+	Plaintext string `json:"Plaintext"`
+}
+
+// decryptKMS decrypts the base64 ciphertext with the AWS KMS Decrypt API. The key is identified
+// from the ciphertext by KMS.
+func (r *Resolver) decryptKMS(ctx context.Context, ciphertext string) (string, error) {
+	if len(r.awsRegion) == 0 {
+		return "", errors.New("AWS_REGION is not set")
+	}
+	if len(r.awsAccessKeyID) == 0 || len(r.awsSecretAccessKey) == 0 {
+		return "", errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+	body, _ := json.Marshal(&kmsDecryptRequest{CiphertextBlob: ciphertext})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.kmsEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	r.signAWSRequest(req, body)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("kms returned %d: %s", resp.StatusCode, string(b))
+	}
+
+	var decrypted kmsDecryptResponse
+	if err := json.Unmarshal(b, &decrypted); err != nil {
+		return "", fmt.Errorf("failed to decode kms response: %v", err)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(decrypted.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode plaintext: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// signAWSRequest signs the request with AWS Signature Version 4.
+func (r *Resolver) signAWSRequest(req *http.Request, body []byte) {
+	awssig.SignRequest(req, awssig.Credentials{
+		AccessKeyID:     r.awsAccessKeyID,
+		SecretAccessKey: r.awsSecretAccessKey,
+		SessionToken:    r.awsSessionToken,
+	}, r.awsRegion, kmsService, awssig.PayloadHash(body), r.now())
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// Resolver resolves the secret references in the config by using the credentials from the
+// environment:
+//   - vault://<path>#<key> reads the key from the secret at the path (VAULT_ADDR, VAULT_TOKEN, VAULT_NAMESPACE)
+//   - awskms://<base64 ciphertext> decrypts the ciphertext (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN)
+//   - sops://<file>#<key path> decrypts the value from the file with the sops binary
+type Resolver struct {
+	httpClient *http.Client
+	baseDir    string
+
+	vaultAddr      string
+	vaultToken     string
+	vaultNamespace string
+
+	kmsEndpoint        string
+	awsRegion          string
+	awsAccessKeyID     string
+	awsSecretAccessKey string
+	awsSessionToken    string
+
+	sopsBinary string
+
+	now func() time.Time
+}
+
+// NewResolver creates a new resolver. Relative sops file paths are resolved from the base directory.
+func NewResolver(baseDir string) *Resolver {
+	r := &Resolver{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		baseDir:            baseDir,
+		vaultAddr:          strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/"),
+		vaultToken:         os.Getenv("VAULT_TOKEN"),
+		vaultNamespace:     os.Getenv("VAULT_NAMESPACE"),
+		awsRegion:          os.Getenv("AWS_REGION"),
+		awsAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		awsSecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		awsSessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		sopsBinary:         "sops",
+		now:                time.Now,
+	}
+	if len(r.vaultToken) == 0 {
+		r.vaultToken = readVaultTokenFile()
+	}
+	if len(r.awsRegion) == 0 {
+		r.awsRegion = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	r.kmsEndpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", r.awsRegion)
+	return r
+}
+
+// ResolveConfig resolves all secret references in the config and replaces them with the values.
+func (r *Resolver) ResolveConfig(ctx context.Context, cfg *config.Config) error {
+	secrets := make(config.Secrets)
+	for _, ref := range config.SecretRefs(cfg) {
+		value, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret %s: %v", ref, err)
+		}
+		secrets[ref] = value
+	}
+	return secrets.Apply(cfg)
+}
+
+// Resolve resolves a secret reference.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, config.SecretSchemeVault):
+		secretPath, key, err := splitKey(strings.TrimPrefix(ref, config.SecretSchemeVault))
+		if err != nil {
+			return "", err
+		}
+		return r.readVault(ctx, secretPath, key)
+
+	case strings.HasPrefix(ref, config.SecretSchemeAWSKMS):
+		return r.decryptKMS(ctx, strings.TrimPrefix(ref, config.SecretSchemeAWSKMS))
+
+	case strings.HasPrefix(ref, config.SecretSchemeSOPS):
+		file, key, err := splitKey(strings.TrimPrefix(ref, config.SecretSchemeSOPS))
+		if err != nil {
+			return "", err
+		}
+		if !path.IsAbs(file) {
+			file = path.Join(r.baseDir, file)
+		}
+		return r.decryptSOPS(ctx, file, key)
+	}
+	return "", fmt.Errorf("unknown secret scheme")
+}
+
+func splitKey(ref string) (string, string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("expected <path>#<key>")
+	}
+	return ref[:i], ref[i+1:], nil
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveConfig(t *testing.T) {
+	r := require.New(t)
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "test-token" || req.URL.Path != "/v1/secret/data/forta" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"scanUrl":"https://scan-url"}}}`))
+	}))
+	defer vault.Close()
+
+	kms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-key/20220601/us-east-1/kms/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=") ||
+			req.Header.Get("X-Amz-Target") != "TrentService.Decrypt" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var decryptReq kmsDecryptRequest
+		json.NewDecoder(req.Body).Decode(&decryptReq)
+		if decryptReq.CiphertextBlob != "AQID" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"Plaintext":"cGFzc3BocmFzZQ=="}`)) // passphrase
+	}))
+	defer kms.Close()
+
+	dir := t.TempDir()
+	sopsBinary := path.Join(dir, "sops")
+	r.NoError(ioutil.WriteFile(sopsBinary, []byte("#!/bin/sh\necho \"$3 $4\"\n"), 0755))
+
+	resolver := NewResolver(dir)
+	resolver.vaultAddr = vault.URL
+	resolver.vaultToken = "test-token"
+	resolver.kmsEndpoint = kms.URL
+	resolver.awsRegion = "us-east-1"
+	resolver.awsAccessKeyID = "test-key"
+	resolver.awsSecretAccessKey = "test-secret"
+	resolver.sopsBinary = sopsBinary
+	resolver.now = func() time.Time {
+		return time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	var cfg config.Config
+	cfg.Passphrase = "awskms://AQID"
+	cfg.Scan.JsonRpc.Url = "vault://secret/data/forta#scanUrl"
+	cfg.Publish.Notifications = []config.NotificationChannelConfig{{BotToken: "sops://secrets.yml#telegram.token"}}
+	r.NoError(resolver.ResolveConfig(context.Background(), &cfg))
+
+	r.Equal("passphrase", cfg.Passphrase)
+	r.Equal("https://scan-url", cfg.Scan.JsonRpc.Url)
+	r.Equal(`["telegram"]["token"] `+path.Join(dir, "secrets.yml"), cfg.Publish.Notifications[0].BotToken)
+	r.Len(cfg.Secrets, 3)
+
+	cfg.Scan.JsonRpc.Url = "vault://secret/data/forta#missing"
+	r.Error(resolver.ResolveConfig(context.Background(), &cfg))
+
+	_, err := resolver.Resolve(context.Background(), "vault://secret/data/forta")
+	r.Error(err)
+}
@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// decryptSOPS extracts the value at the dot-separated key path from the encrypted file.
+func (r *Resolver) decryptSOPS(ctx context.Context, file, keyPath string) (string, error) {
+	var extract strings.Builder
+	for _, key := range strings.Split(keyPath, ".") {
+		extract.WriteString(fmt.Sprintf("[%q]", key))
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.sopsBinary, "--decrypt", "--extract", extract.String(), file)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("sops failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
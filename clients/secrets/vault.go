@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// readVault reads the key from the KV secret. Both the v1 and the v2 secret engines are supported.
+func (r *Resolver) readVault(ctx context.Context, secretPath, key string) (string, error) {
+	if len(r.vaultAddr) == 0 {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s", r.vaultAddr, strings.TrimPrefix(secretPath, "/")), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", r.vaultToken)
+	if len(r.vaultNamespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", r.vaultNamespace)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %d: %s", resp.StatusCode, string(b))
+	}
+
+	var secret vaultResponse
+	if err := json.Unmarshal(b, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %v", err)
+	}
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested // kv v2
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %s not found", key)
+	}
+	return value, nil
+}
+
+// readVaultTokenFile reads the token saved by 'vault login'.
+func readVaultTokenFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	b, err := ioutil.ReadFile(path.Join(home, ".vault-token"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/healthutils"
+	"github.com/forta-network/forta-node/services/admin"
+)
+
+// newAdminClient finds the localhost port of the admin API of given node container
+// and creates a client for it.
+func newAdminClient(containerName string) (*admin.Client, error) {
+	token, err := admin.ReadToken(cfg.FortaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the admin token (is the node running?): %v", err)
+	}
+	apiSec, err := apisecurity.Load(cfg.FortaDir, cfg.APISecurity, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
+	dockerClient, err := clients.NewRuntimeClient("", cfg.ContainerRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
+	}
+	container, err := dockerClient.GetContainerByName(context.Background(), containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the %s container (is the node running?): %v", containerName, err)
+	}
+	for _, port := range container.Ports {
+		if strconv.Itoa(int(port.PrivatePort)) == config.DefaultAdminPort && port.PublicPort != 0 {
+			return admin.NewClient(fmt.Sprintf("%s://localhost:%d", apiSec.Scheme(), port.PublicPort), token, apiSec), nil
+		}
+	}
+	return nil, fmt.Errorf("the %s container does not expose the admin api", containerName)
+}
+
+// checkNodeHealth gets the health reports from the runner health server on localhost.
+func checkNodeHealth() health.Reports {
+	token, err := admin.ReadToken(cfg.FortaDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nodeHealthError(fmt.Errorf("failed to read the admin token: %v", err))
+	}
+	apiSec, err := apisecurity.Load(cfg.FortaDir, cfg.APISecurity, token)
+	if err != nil {
+		return nodeHealthError(fmt.Errorf("failed to load the api security settings: %v", err))
+	}
+	return healthutils.NewClient(apiSec).CheckHealth("forta", config.DefaultHealthPort)
+}
+
+func nodeHealthError(err error) health.Reports {
+	return health.Reports{
+		&health.Report{
+			Name:    "health-api",
+			Status:  health.StatusDown,
+			Details: err.Error(),
+		},
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -9,23 +10,24 @@ import (
 	"path"
 	"reflect"
 	"regexp"
-	"strings"
+	"time"
 
 	"github.com/creasty/defaults"
 
+	"github.com/forta-network/forta-node/clients/secrets"
 	"github.com/forta-network/forta-node/config"
 	"gopkg.in/yaml.v3"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 const (
-	keyFortaDir         = "forta_dir"
-	keyFortaPassphrase  = "forta_passphrase"
-	keyFortaDevelopment = "forta_development"
-	keyFortaExposeNats  = "forta_expose_nats"
+	keyFortaDir            = "forta_dir"
+	keyFortaPassphrase     = "forta_passphrase"
+	keyFortaPassphraseFile = "forta_passphrase_file"
+	keyFortaDevelopment    = "forta_development"
+	keyFortaExposeNats     = "forta_expose_nats"
 )
 
 var (
@@ -50,13 +52,31 @@ publishes alerts about them`,
 	cmdFortaInit = &cobra.Command{
 		Use:   "init",
 		Short: "initialize a config file and a private key (doesn't overwrite)",
-		RunE:  handleFortaInit,
+		RunE:  withSecrets(handleFortaInit),
 	}
 
 	cmdFortaRun = &cobra.Command{
 		Use:   "run",
 		Short: "launch the node",
-		RunE:  withContractAddresses(withInitialized(withValidConfig(handleFortaRun))),
+		RunE:  withSecrets(withContractAddresses(withInitialized(withValidConfig(handleFortaRun)))),
+	}
+
+	cmdFortaReplay = &cobra.Command{
+		Use:   "replay",
+		Short: "run agents over a historical block range and write the findings to a file",
+		RunE:  withSecrets(withContractAddresses(withInitialized(withValidConfig(handleFortaReplay)))),
+	}
+
+	cmdFortaTop = &cobra.Command{
+		Use:   "top",
+		Short: "show a live dashboard of the agents and the node components",
+		RunE:  withInitialized(withValidConfig(handleFortaTop)),
+	}
+
+	cmdFortaTestAgent = &cobra.Command{
+		Use:   "test-agent",
+		Short: "run an agent image against a block or a transaction and print the findings",
+		RunE:  withInitialized(withValidConfig(handleFortaTestAgent)),
 	}
 
 	cmdFortaAccount = &cobra.Command{
@@ -73,11 +93,34 @@ publishes alerts about them`,
 		RunE:  withInitialized(handleFortaAccountAddress),
 	}
 
+	cmdFortaAccountBalance = &cobra.Command{
+		Use:   "balance",
+		Short: "show the scanner address and its balance on the registry chain",
+		RunE:  withInitialized(handleFortaAccountBalance),
+	}
+
+	cmdFortaAccountCreate = &cobra.Command{
+		Use:   "create",
+		Short: "create a new scanner account",
+		RunE:  withSecrets(handleFortaAccountCreate),
+	}
+
 	cmdFortaAccountImport = &cobra.Command{
-		Use:    "import",
-		Short:  "import new scanner account (removes the old one)",
-		RunE:   withInitialized(handleFortaAccountImport),
-		Hidden: true,
+		Use:   "import",
+		Short: "import new scanner account from a private key or a keystore file (backs up the old one)",
+		RunE:  withSecrets(handleFortaAccountImport),
+	}
+
+	cmdFortaAccountExport = &cobra.Command{
+		Use:   "export",
+		Short: "export the scanner account as a keystore file or a private key hex",
+		RunE:  withInitialized(withSecrets(handleFortaAccountExport)),
+	}
+
+	cmdFortaAccountChangePassphrase = &cobra.Command{
+		Use:   "change-passphrase",
+		Short: "re-encrypt the scanner key with a new passphrase",
+		RunE:  withInitialized(withSecrets(handleFortaAccountChangePassphrase)),
 	}
 
 	cmdFortaAgent = &cobra.Command{
@@ -92,7 +135,95 @@ publishes alerts about them`,
 	cmdFortaAgentAdd = &cobra.Command{
 		Use:   "add",
 		Short: "try an agent by adding it to the local list",
-		RunE:  withAgentRegContractAddress(withDevOnly(withInitialized(withValidConfig(handleFortaAgentAdd)))),
+		RunE:  withSecrets(withAgentRegContractAddress(withDevOnly(withInitialized(withValidConfig(handleFortaAgentAdd))))),
+	}
+
+	cmdFortaAgents = &cobra.Command{
+		Use:   "agents",
+		Short: "inspect the agents of the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaAgentsList = &cobra.Command{
+		Use:   "list",
+		Short: "list the agents assigned to the running node",
+		RunE:  withInitialized(handleFortaAgentsList),
+	}
+
+	cmdFortaAgentsInspect = &cobra.Command{
+		Use:   "inspect <agent-id>",
+		Short: "display the manifest, resource usage and errors of an assigned agent",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaAgentsInspect),
+	}
+
+	cmdFortaAgentsDisable = &cobra.Command{
+		Use:   "disable <agent-id>",
+		Short: "stop running an agent on this node until it is enabled again",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaAgentsDisable),
+	}
+
+	cmdFortaAgentsEnable = &cobra.Command{
+		Use:   "enable <agent-id>",
+		Short: "run a disabled agent again if it is still assigned",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaAgentsEnable),
+	}
+
+	cmdFortaAgentsStatus = &cobra.Command{
+		Use:   "status",
+		Short: "display the runtime statuses of the agents in the pool",
+		RunE:  withInitialized(handleFortaAgentsStatus),
+	}
+
+	cmdFortaAgentsUsage = &cobra.Command{
+		Use:   "usage",
+		Short: "display the json-rpc proxy usage of the agents",
+		RunE:  withInitialized(handleFortaAgentsUsage),
+	}
+
+	cmdFortaLogs = &cobra.Command{
+		Use:   "logs",
+		Short: "display the recent logs of an agent",
+		RunE:  withInitialized(handleFortaLogs),
+	}
+
+	cmdFortaAlerts = &cobra.Command{
+		Use:   "alerts",
+		Short: "manage the locally stored alerts of the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaAlertsRepublish = &cobra.Command{
+		Use:   "republish",
+		Short: "publish the locally stored alerts in a time or block range again",
+		RunE:  withInitialized(handleFortaAlertsRepublish),
+	}
+
+	cmdFortaConfig = &cobra.Command{
+		Use:   "config",
+		Short: "manage the config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaConfigValidate = &cobra.Command{
+		Use:   "validate",
+		Short: "check the config file for unknown keys, invalid values and missing fields",
+		RunE:  withInitialized(handleFortaConfigValidate),
+	}
+
+	cmdFortaConfigSign = &cobra.Command{
+		Use:   "sign <file>",
+		Short: "sign a remote config file with the scanner key and write the signature to <file>.sig",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(withSecrets(handleFortaConfigSign)),
 	}
 
 	cmdFortaImages = &cobra.Command{
@@ -121,28 +252,151 @@ publishes alerts about them`,
 		RunE:  handleFortaBatchDecode,
 	}
 
+	cmdFortaBatchDeadLetters = &cobra.Command{
+		Use:   "dead-letters",
+		Short: "manage the batches which could not be published",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaBatchDeadLettersList = &cobra.Command{
+		Use:   "list",
+		Short: "list the dead letter batches",
+		RunE:  withInitialized(handleFortaBatchDeadLettersList),
+	}
+
+	cmdFortaBatchDeadLettersInspect = &cobra.Command{
+		Use:   "inspect <id>",
+		Short: "display a dead letter batch",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaBatchDeadLettersInspect),
+	}
+
+	cmdFortaBatchDeadLettersRetry = &cobra.Command{
+		Use:   "retry [id...]",
+		Short: "move the dead letter batches back to the retry queue",
+		RunE:  withInitialized(handleFortaBatchDeadLettersRetry),
+	}
+
+	cmdFortaBatchMigrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "convert the queued and the dead letter batch files to the current local format",
+		RunE:  withInitialized(handleFortaBatchMigrate),
+	}
+
+	cmdFortaVerifyBatch = &cobra.Command{
+		Use:   "verify-batch <file>",
+		Short: "verify the scanner signature and the contents of a batch file",
+		Args:  cobra.ExactArgs(1),
+		RunE:  handleFortaVerifyBatch,
+	}
+
 	cmdFortaStatus = &cobra.Command{
 		Use:   "status",
 		Short: "display statuses of node services",
 		RunE:  handleFortaStatus,
 	}
 
+	cmdFortaBus = &cobra.Command{
+		Use:   "bus",
+		Short: "inspect the message bus of the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaBusDeadLetters = &cobra.Command{
+		Use:   "dead-letters",
+		Short: "manage the messages which the node services could not handle",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaBusDeadLettersList = &cobra.Command{
+		Use:   "list",
+		Short: "list the dead letter messages",
+		RunE:  withInitialized(handleFortaBusDeadLettersList),
+	}
+
+	cmdFortaBusDeadLettersInspect = &cobra.Command{
+		Use:   "inspect <id>",
+		Short: "display a dead letter message",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaBusDeadLettersInspect),
+	}
+
+	cmdFortaBusDeadLettersReplay = &cobra.Command{
+		Use:   "replay [id...]",
+		Short: "handle the dead letter messages again",
+		RunE:  withInitialized(handleFortaBusDeadLettersReplay),
+	}
+
+	cmdFortaMetrics = &cobra.Command{
+		Use:   "metrics",
+		Short: "inspect the metrics of the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaMetricsDump = &cobra.Command{
+		Use:   "dump",
+		Short: "snapshot all metrics of the running node",
+		RunE:  withInitialized(handleFortaMetricsDump),
+	}
+
+	cmdFortaMetricsLosses = &cobra.Command{
+		Use:   "losses",
+		Short: "show the lost events by pipeline stage, reason and agent",
+		RunE:  withInitialized(handleFortaMetricsLosses),
+	}
+
+	cmdFortaLogLevel = &cobra.Command{
+		Use:   "loglevel [level]",
+		Short: "display or change the log levels of the running node services",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  withInitialized(handleFortaLogLevel),
+	}
+
+	cmdFortaDebug = &cobra.Command{
+		Use:   "debug",
+		Short: "debug the services of the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaDebugProfile = &cobra.Command{
+		Use:   "profile <heap|goroutine|cpu|block>",
+		Short: "capture a pprof profile of a node service",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaDebugProfile),
+	}
+
+	cmdFortaDiagnose = &cobra.Command{
+		Use:   "diagnose",
+		Short: "collect the redacted config, logs, health and version info into a support bundle",
+		RunE:  withInitialized(handleFortaDiagnose),
+	}
+
 	cmdFortaRegister = &cobra.Command{
 		Use:   "register",
 		Short: "register your scan node to enable it for scanning (requires MATIC in your scan node address)",
-		RunE:  withContractAddresses(withInitialized(withValidConfig(handleFortaRegister))),
+		RunE:  withSecrets(withContractAddresses(withInitialized(withValidConfig(handleFortaRegister)))),
 	}
 
 	cmdFortaEnable = &cobra.Command{
 		Use:   "enable",
 		Short: "enable your scan node (requires MATIC in your scan node address)",
-		RunE:  withContractAddresses(withInitialized(withValidConfig(handleFortaEnable))),
+		RunE:  withSecrets(withContractAddresses(withInitialized(withValidConfig(handleFortaEnable)))),
 	}
 
 	cmdFortaDisable = &cobra.Command{
 		Use:   "disable",
 		Short: "disable your scan node (requires MATIC in your scan node address)",
-		RunE:  withContractAddresses(withInitialized(withValidConfig(handleFortaDisable))),
+		RunE:  withSecrets(withContractAddresses(withInitialized(withValidConfig(handleFortaDisable)))),
 	}
 )
 
@@ -156,22 +410,64 @@ func init() {
 
 	cmdForta.AddCommand(cmdFortaInit)
 	cmdForta.AddCommand(cmdFortaRun)
+	cmdForta.AddCommand(cmdFortaReplay)
+	cmdForta.AddCommand(cmdFortaTestAgent)
+	cmdForta.AddCommand(cmdFortaTop)
 
 	cmdForta.AddCommand(cmdFortaAccount)
 	cmdFortaAccount.AddCommand(cmdFortaAccountAddress)
+	cmdFortaAccount.AddCommand(cmdFortaAccountBalance)
+	cmdFortaAccount.AddCommand(cmdFortaAccountCreate)
 	cmdFortaAccount.AddCommand(cmdFortaAccountImport)
+	cmdFortaAccount.AddCommand(cmdFortaAccountExport)
+	cmdFortaAccount.AddCommand(cmdFortaAccountChangePassphrase)
 
 	cmdForta.AddCommand(cmdFortaAgent)
 	cmdFortaAgent.AddCommand(cmdFortaAgentAdd)
 
+	cmdForta.AddCommand(cmdFortaAgents)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsList)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsInspect)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsDisable)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsEnable)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsStatus)
+	cmdFortaAgents.AddCommand(cmdFortaAgentsUsage)
+
+	cmdForta.AddCommand(cmdFortaLogs)
+
+	cmdForta.AddCommand(cmdFortaAlerts)
+	cmdFortaAlerts.AddCommand(cmdFortaAlertsRepublish)
+
+	cmdForta.AddCommand(cmdFortaConfig)
+	cmdFortaConfig.AddCommand(cmdFortaConfigValidate)
+	cmdFortaConfig.AddCommand(cmdFortaConfigSign)
+
 	cmdForta.AddCommand(cmdFortaImages)
 
 	cmdForta.AddCommand(cmdFortaVersion)
 
 	cmdForta.AddCommand(cmdFortaBatch)
 	cmdFortaBatch.AddCommand(cmdFortaBatchDecode)
+	cmdFortaBatch.AddCommand(cmdFortaBatchDeadLetters)
+	cmdFortaBatch.AddCommand(cmdFortaBatchMigrate)
+	cmdFortaBatchDeadLetters.AddCommand(cmdFortaBatchDeadLettersList)
+	cmdFortaBatchDeadLetters.AddCommand(cmdFortaBatchDeadLettersInspect)
+	cmdFortaBatchDeadLetters.AddCommand(cmdFortaBatchDeadLettersRetry)
+	cmdForta.AddCommand(cmdFortaVerifyBatch)
 
 	cmdForta.AddCommand(cmdFortaStatus)
+	cmdForta.AddCommand(cmdFortaDiagnose)
+	cmdForta.AddCommand(cmdFortaMetrics)
+	cmdFortaMetrics.AddCommand(cmdFortaMetricsDump)
+	cmdFortaMetrics.AddCommand(cmdFortaMetricsLosses)
+	cmdForta.AddCommand(cmdFortaDebug)
+	cmdForta.AddCommand(cmdFortaLogLevel)
+	cmdFortaDebug.AddCommand(cmdFortaDebugProfile)
+	cmdForta.AddCommand(cmdFortaBus)
+	cmdFortaBus.AddCommand(cmdFortaBusDeadLetters)
+	cmdFortaBusDeadLetters.AddCommand(cmdFortaBusDeadLettersList)
+	cmdFortaBusDeadLetters.AddCommand(cmdFortaBusDeadLettersInspect)
+	cmdFortaBusDeadLetters.AddCommand(cmdFortaBusDeadLettersReplay)
 
 	cmdForta.AddCommand(cmdFortaRegister)
 	cmdForta.AddCommand(cmdFortaEnable)
@@ -188,12 +484,26 @@ func init() {
 	cmdForta.PersistentFlags().String("passphrase", "", "passphrase to decrypt the private key (overrides $FORTA_PASSPHRASE)")
 	viper.BindPFlag(keyFortaPassphrase, cmdForta.PersistentFlags().Lookup("passphrase"))
 
+	cmdForta.PersistentFlags().String("passphrase-file", "", "file which contains the passphrase and is only readable by the owner (overrides $FORTA_PASSPHRASE_FILE)")
+	viper.BindPFlag(keyFortaPassphraseFile, cmdForta.PersistentFlags().Lookup("passphrase-file"))
+
 	cmdForta.PersistentFlags().Bool("expose-nats", false, "expose nats via public docker network")
 	viper.BindPFlag(keyFortaExposeNats, cmdForta.PersistentFlags().Lookup("expose-nats"))
 
 	// forta account import
 	cmdFortaAccountImport.Flags().String("file", "", "path to a file that contains a private key hex")
-	cmdFortaAccountImport.MarkFlagRequired("file")
+	cmdFortaAccountImport.Flags().String("keystore", "", "path to an encrypted keystore file")
+	cmdFortaAccountImport.Flags().String("keystore-passphrase", "", "passphrase of the keystore file (default: the forta passphrase)")
+
+	// forta account create
+	cmdFortaAccountCreate.Flags().Bool("force", false, "replace the existing account (the old keys are backed up)")
+
+	// forta account export
+	cmdFortaAccountExport.Flags().String("format", AccountExportFormatKeystore, "export format: keystore (default), hex")
+	cmdFortaAccountExport.Flags().String("output", "", "output file name (default: print to stdout)")
+
+	// forta account change-passphrase
+	cmdFortaAccountChangePassphrase.Flags().String("new-passphrase", "", "new passphrase (overrides $FORTA_NEW_PASSPHRASE)")
 
 	// forta agent add
 	cmdFortaAgentAdd.Flags().Uint64Var(&parsedArgs.Version, "version", 0, "agent version")
@@ -201,27 +511,94 @@ func init() {
 	// forta run
 	cmdFortaRun.Flags().BoolVar(&parsedArgs.NoCheck, "no-check", false, "disable scanner registry check and just run")
 
+	// forta replay
+	cmdFortaReplay.Flags().Int("chain", 0, "chain id of the blocks")
+	cmdFortaReplay.MarkFlagRequired("chain")
+	cmdFortaReplay.Flags().Uint64("from", 0, "first block of the range")
+	cmdFortaReplay.MarkFlagRequired("from")
+	cmdFortaReplay.Flags().Uint64("to", 0, "last block of the range")
+	cmdFortaReplay.MarkFlagRequired("to")
+	cmdFortaReplay.Flags().StringSlice("agent", nil, "agent id to replay - can be repeated (default: all agents in agents.yml)")
+	cmdFortaReplay.Flags().String("output", "replay-findings.jsonl", "output file for the findings")
+
+	// forta test-agent
+	cmdFortaTestAgent.Flags().String("image", "", "agent image reference")
+	cmdFortaTestAgent.MarkFlagRequired("image")
+	cmdFortaTestAgent.Flags().Uint64("block", 0, "block number to run the agent against")
+	cmdFortaTestAgent.Flags().String("tx", "", "transaction hash to run the agent against")
+	cmdFortaTestAgent.Flags().String("agent-id", "test-agent", "agent id to report the findings with")
+	cmdFortaTestAgent.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), json")
+	cmdFortaTestAgent.Flags().Duration("timeout", 5*time.Minute, "maximum duration of the test")
+
+	// forta top
+	cmdFortaTop.Flags().Duration("interval", 3*time.Second, "refresh interval")
+	cmdFortaTop.Flags().String("sort", TopSortAgent, "sort the agents by: agent (default), throughput, latency, buffer, findings")
+
 	// forta batch decode
 	cmdFortaBatchDecode.Flags().String("cid", "", "batch IPFS CID (content ID)")
 	cmdFortaBatchDecode.MarkFlagRequired("cid")
 	cmdFortaBatchDecode.Flags().String("o", "alert-batch.json", "output file name (default: alert-batch.json)")
 	cmdFortaBatchDecode.Flags().Bool("stdout", false, "print to stdout instead of writing to a file")
 
+	// forta batch dead-letters retry
+	cmdFortaBatchDeadLettersRetry.Flags().Bool("all", false, "retry all dead letters")
+
+	// forta bus dead-letters replay
+	cmdFortaBusDeadLettersReplay.Flags().Bool("all", false, "replay all dead letters")
+
+	// forta verify-batch
+	cmdFortaVerifyBatch.Flags().String("scanner", "", "expected scanner address (optional)")
+
 	// forta status
-	cmdFortaStatus.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), oneline, json, csv")
+	cmdFortaStatus.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), oneline, json, csv, table")
 	cmdFortaStatus.Flags().Bool("no-color", false, "disable colors")
 	cmdFortaStatus.Flags().String("show", StatusShowSummary, "filter statuses to show: summary (default), important, all")
 
+	// forta diagnose
+	cmdFortaDiagnose.Flags().String("output", "", "output file name (default: forta-diagnose-<time>.tar.gz)")
+	cmdFortaDiagnose.Flags().Int("tail", 1000, "number of log lines to collect from each container")
+
+	// forta metrics dump
+	cmdFortaMetricsDump.Flags().String("format", MetricsFormatPrometheus, "output formatting/encoding: prometheus (default), json")
+	cmdFortaMetricsDump.Flags().String("output", "", "output file name (default: stdout)")
+
+	// forta loglevel
+	cmdFortaLogLevel.Flags().String("service", "", "node service: scanner, supervisor, json-rpc (default: all)")
+	cmdFortaLogLevel.Flags().String("component", "", "component to change the level of, e.g. pool, agent, agent-grpc (default: the default level)")
+	cmdFortaLogLevel.Flags().Duration("duration", 0, "restore the previous level after the duration, e.g. 15m (default: until restart)")
+
+	// forta debug profile
+	cmdFortaDebugProfile.Flags().String("service", "scanner", "node service to profile: scanner (default), supervisor, json-rpc")
+	cmdFortaDebugProfile.Flags().Int("seconds", 30, "duration of the cpu profile")
+	cmdFortaDebugProfile.Flags().String("output", "", "output file name (default: forta-<service>-<profile>-<time>.pb.gz)")
+
+	// forta agents list
+	cmdFortaAgentsList.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), json")
+
+	// forta agents inspect
+	cmdFortaAgentsInspect.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), json")
+
+	// forta agents status
+	cmdFortaAgentsStatus.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), json")
+
+	// forta agents usage
+	cmdFortaAgentsUsage.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), json")
+
+	// forta logs
+	cmdFortaLogs.Flags().String("agent", "", "id of the agent (a unique prefix is enough)")
+	cmdFortaLogs.Flags().Int("tail", 100, "number of lines to show from the end of the logs")
+	cmdFortaLogs.Flags().BoolP("follow", "f", false, "keep streaming the new logs")
+	cmdFortaLogs.MarkFlagRequired("agent")
+
+	// forta alerts republish
+	cmdFortaAlertsRepublish.Flags().String("from", "", "start of the time range (RFC3339)")
+	cmdFortaAlertsRepublish.Flags().String("to", "", "end of the time range (RFC3339) (default: now)")
+	cmdFortaAlertsRepublish.Flags().Uint64("from-block", 0, "start of the block range (optional)")
+	cmdFortaAlertsRepublish.Flags().Uint64("to-block", 0, "end of the block range (optional)")
+
 	// forta register
 	cmdFortaRegister.Flags().String("owner-address", "", "Ethereum wallet address of the scanner owner")
 	cmdFortaRegister.MarkFlagRequired("owner-address")
-	cmdFortaRegister.MarkFlagRequired("passphrase")
-
-	// forta enable
-	cmdFortaEnable.MarkFlagRequired("passphrase")
-
-	// forta disable
-	cmdFortaDisable.MarkFlagRequired("passphrase")
 }
 
 func initConfig() {
@@ -229,6 +606,7 @@ func initConfig() {
 
 	viper.BindEnv(keyFortaDir)
 	viper.BindEnv(keyFortaPassphrase)
+	viper.BindEnv(keyFortaPassphraseFile)
 	viper.BindEnv(keyFortaDevelopment)
 	viper.BindEnv(keyFortaExposeNats)
 	viper.AutomaticEnv()
@@ -241,6 +619,7 @@ func initConfig() {
 	}
 
 	configPath := path.Join(fortaDir, config.DefaultConfigFileName)
+	migrateConfigFile(configPath)
 	configBytes, _ := ioutil.ReadFile(configPath)
 	yaml.Unmarshal(configBytes, &cfg)
 
@@ -252,6 +631,12 @@ func initConfig() {
 	cfg.KeyDirPath = path.Join(cfg.FortaDir, config.DefaultKeysDirName)
 	cfg.Development = viper.GetBool(keyFortaDevelopment)
 	cfg.Passphrase = viper.GetString(keyFortaPassphrase)
+	if cmdForta.PersistentFlags().Changed("passphrase") {
+		yellowBold("The --passphrase flag is visible in the process list - please prefer --passphrase-file.\n")
+	}
+	if passphraseFile := viper.GetString(keyFortaPassphraseFile); len(cfg.Passphrase) == 0 && len(passphraseFile) > 0 {
+		cfg.Passphrase, passphraseFileErr = readPassphraseFile(passphraseFile)
+	}
 	cfg.ExposeNats = viper.GetBool(keyFortaExposeNats)
 
 	cfg.LocalAgentsPath = path.Join(cfg.FortaDir, config.DefaultLocalAgentsFileName)
@@ -261,6 +646,25 @@ func initConfig() {
 	config.InitLogLevel(cfg)
 }
 
+// migrateConfigFile upgrades the config file after the node is updated.
+func migrateConfigFile(configPath string) {
+	if _, err := os.Stat(configPath); err != nil {
+		return // not initialized
+	}
+	applied, err := config.MigrateConfigFile(configPath)
+	if err != nil {
+		redBold("Failed to migrate the config file: %v\n", err)
+		return
+	}
+	if len(applied) == 0 {
+		return
+	}
+	yellowBold("Migrated the config file to version %d (the old file is saved next to it):\n", config.CurrentConfigVersion)
+	for _, description := range applied {
+		yellowBold("  - %s\n", description)
+	}
+}
+
 var configEnvVarRegexp = regexp.MustCompile(`\$[A-Z0-9_]+`)
 
 // TODO: viper.Unmarshal is a mess. Use this again somehow with a custom hook.
@@ -276,22 +680,10 @@ func resolveEnvVarsHookFunc(from reflect.Kind, to reflect.Kind, data interface{}
 }
 
 func validateConfig() error {
-	validate := validator.New()
-
-	// Use the YAML names while validating the struct.
-	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("yaml"), ",", 2)[0]
-		if name == "-" {
-			return ""
-		}
-		return name
-	})
-
-	if err := validate.Struct(&cfg); err != nil {
-		validationErrs := err.(validator.ValidationErrors)
+	if problems := config.Validate(cfg); len(problems) > 0 {
 		fmt.Fprintln(os.Stderr, "The config file has invalid or missing fields:")
-		for _, validationErr := range validationErrs {
-			fmt.Fprintf(os.Stderr, "  - %s\n", validationErr.Namespace()[7:])
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "  - %s: %s\n", problem.Field, problem.Message)
 		}
 		return errors.New("invalid config file")
 	}
@@ -318,6 +710,17 @@ func withInitialized(handler func(*cobra.Command, []string) error) func(*cobra.C
 	}
 }
 
+// withSecrets resolves the secret references in the config and the passphrase.
+func withSecrets(handler func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := secrets.NewResolver(cfg.FortaDir).ResolveConfig(context.Background(), &cfg); err != nil {
+			redBold("%v\n", err)
+			return errors.New("failed to resolve secrets")
+		}
+		return handler(cmd, args)
+	}
+}
+
 func withDevOnly(handler func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		if !cfg.Development {
@@ -1,35 +1,138 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/forta-core-go/security"
 	"github.com/spf13/cobra"
 )
 
-func handleFortaAccountAddress(cmd *cobra.Command, args []string) error {
-	ks := keystore.NewKeyStore(cfg.KeyDirPath, keystore.StandardScryptN, keystore.StandardScryptP)
-	accounts := ks.Accounts()
-	if len(accounts) > 1 {
-		redBold("You have multiple accounts. Please import your scanner account again with 'forta account import'.")
-		cmd.Println("Your current account addresses:")
-		for _, account := range accounts {
-			cmd.Println(account.Address.Hex())
+// account export formats
+const (
+	AccountExportFormatKeystore = "keystore"
+	AccountExportFormatHex      = "hex"
+)
+
+func newKeyStore() *keystore.KeyStore {
+	return keystore.NewKeyStore(cfg.KeyDirPath, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+// getScannerAccount returns the only account in the key dir.
+func getScannerAccount(ks *keystore.KeyStore) (accounts.Account, error) {
+	all := ks.Accounts()
+	if len(all) > 1 {
+		redBold("You have multiple accounts. Please import your scanner account again with 'forta account import'.\n")
+		fmt.Println("Your current account addresses:")
+		for _, account := range all {
+			fmt.Println(account.Address.Hex())
 		}
-		return errors.New("multiple accounts")
+		return accounts.Account{}, errors.New("multiple accounts")
+	}
+
+	if len(all) == 0 {
+		redBold("You have no accounts. Please create one with 'forta account create' or import your scanner account with 'forta account import'.\n")
+		return accounts.Account{}, errors.New("no accounts")
+	}
+	return all[0], nil
+}
+
+// backUpKeyDir moves the current keys away before they are replaced by a new key.
+func backUpKeyDir() error {
+	if !isKeyDirInitialized() {
+		return nil
+	}
+	entries, err := os.ReadDir(cfg.KeyDirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the key dir: %v", err)
+	}
+	if len(entries) == 0 {
+		return os.Remove(cfg.KeyDirPath)
+	}
+	backupPath := fmt.Sprintf("%s.bak.%d", cfg.KeyDirPath, time.Now().Unix())
+	if err := os.Rename(cfg.KeyDirPath, backupPath); err != nil {
+		return fmt.Errorf("failed to back up the key dir: %v", err)
+	}
+	yellowBold("Moved the old keys to %s\n", backupPath)
+	return nil
+}
+
+func handleFortaAccountAddress(cmd *cobra.Command, args []string) error {
+	account, err := getScannerAccount(newKeyStore())
+	if err != nil {
+		return err
+	}
+	cmd.Println(account.Address.Hex())
+	return nil
+}
+
+func handleFortaAccountBalance(cmd *cobra.Command, args []string) error {
+	account, err := getScannerAccount(newKeyStore())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, cfg.Registry.JsonRpc.Url)
+	if err != nil {
+		return fmt.Errorf("failed to dial the registry json-rpc api: %v", err)
+	}
+	defer client.Close()
+	balance, err := client.BalanceAt(ctx, account.Address, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get the balance: %v", err)
 	}
 
-	if len(accounts) == 0 {
-		redBold("You have no accounts. Please import your scanner account with 'forta account import'.")
-		return errors.New("no accounts")
+	cmd.Printf("Scanner address: %s\n", account.Address.Hex())
+	cmd.Printf("Balance: %s MATIC\n", formatEther(balance))
+	if balance.Sign() == 0 {
+		yellowBold("Please fund your scanner address with some MATIC before registering or enabling it.\n")
+	}
+	return nil
+}
+
+// formatEther formats the wei amount in ether units.
+func formatEther(wei *big.Int) string {
+	ether := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return ether.Text('f', 6)
+}
+
+func handleFortaAccountCreate(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	if !isDirInitialized() || !isConfigFileInitialized() {
+		yellowBold("Please make sure you do 'forta init' first and check your configuration at %s/config.yml\n", cfg.FortaDir)
+		return errors.New("not initialized")
+	}
+	if err := requirePassphrase(); err != nil {
+		return err
+	}
+	if isKeyInitialized() && !force {
+		redBold("You already have a scanner account. Please use --force to replace it.\n")
+		return errors.New("account exists")
 	}
 
-	cmd.Println(accounts[0].Address.Hex())
+	if err := backUpKeyDir(); err != nil {
+		return err
+	}
+	account, err := newKeyStore().NewAccount(cfg.Passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to create the account: %v", err)
+	}
+	printScannerAddress(account.Address.Hex())
 	return nil
 }
 
@@ -38,27 +141,138 @@ func handleFortaAccountImport(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	b, err := ioutil.ReadFile(path)
+	keystorePath, err := cmd.Flags().GetString("keystore")
 	if err != nil {
-		return fmt.Errorf("failed to read the private key: %v", err)
+		return err
+	}
+	keystorePassphrase, err := cmd.Flags().GetString("keystore-passphrase")
+	if err != nil {
+		return err
+	}
+	if (len(path) == 0) == (len(keystorePath) == 0) {
+		return errors.New("please provide either --file or --keystore")
+	}
+	if err := requirePassphrase(); err != nil {
+		return err
 	}
-	hexKey := strings.TrimSpace(string(b))
 
-	if len(cfg.Passphrase) == 0 {
-		redBold("Your passphrase is not set. Please set it with FORTA_PASSPHRASE environment variable or provide it with the --passphrase flag.\n")
-		return errors.New("empty passhphrase")
+	var privateKey *keystore.Key
+	if len(path) > 0 {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the private key: %v", err)
+		}
+		hexKey := strings.TrimPrefix(strings.TrimSpace(string(b)), "0x")
+		ecdsaKey, err := crypto.HexToECDSA(hexKey)
+		if err != nil {
+			return fmt.Errorf("could not parse the private key hex: %v", err)
+		}
+		privateKey = &keystore.Key{PrivateKey: ecdsaKey}
+	} else {
+		b, err := ioutil.ReadFile(keystorePath)
+		if err != nil {
+			return fmt.Errorf("failed to read the keystore file: %v", err)
+		}
+		if len(keystorePassphrase) == 0 {
+			keystorePassphrase = cfg.Passphrase
+		}
+		privateKey, err = keystore.DecryptKey(b, keystorePassphrase)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt the keystore file: %v", err)
+		}
 	}
 
-	os.RemoveAll(cfg.KeyDirPath)
-	ks := keystore.NewKeyStore(cfg.KeyDirPath, keystore.StandardScryptN, keystore.StandardScryptP)
-	privateKey, err := crypto.HexToECDSA(hexKey)
-	if err != nil {
-		return fmt.Errorf("could not parse the private key hex: %v", err)
+	if err := backUpKeyDir(); err != nil {
+		return err
 	}
-	account, err := ks.ImportECDSA(privateKey, cfg.Passphrase)
+	account, err := newKeyStore().ImportECDSA(privateKey.PrivateKey, cfg.Passphrase)
 	if err != nil {
 		return fmt.Errorf("failed to import: %v", err)
 	}
 	cmd.Println(account.Address.Hex())
 	return nil
 }
+
+func handleFortaAccountExport(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	ks := newKeyStore()
+	account, err := getScannerAccount(ks)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	switch format {
+	case AccountExportFormatKeystore:
+		b, err = ioutil.ReadFile(account.URL.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read the keystore file: %v", err)
+		}
+
+	case AccountExportFormatHex:
+		if err := requirePassphrase(); err != nil {
+			return err
+		}
+		key, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to load scanner key: %v", err)
+		}
+		b = []byte(fmt.Sprintf("%x\n", crypto.FromECDSA(key.PrivateKey)))
+		yellowBold("Please keep the exported private key safe - anyone with the key can control your scanner account.\n")
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	if len(output) == 0 {
+		fmt.Print(string(b))
+		return nil
+	}
+	if err := ioutil.WriteFile(output, b, 0600); err != nil {
+		return fmt.Errorf("failed to write the exported key: %v", err)
+	}
+	greenBold("Exported the scanner account %s to %s\n", account.Address.Hex(), output)
+	return nil
+}
+
+func handleFortaAccountChangePassphrase(cmd *cobra.Command, args []string) error {
+	newPassphrase, err := cmd.Flags().GetString("new-passphrase")
+	if err != nil {
+		return err
+	}
+	if err := requirePassphrase(); err != nil {
+		return err
+	}
+	if len(newPassphrase) == 0 {
+		newPassphrase = os.Getenv("FORTA_NEW_PASSPHRASE")
+	}
+	if len(newPassphrase) == 0 {
+		newPassphrase, err = promptPassphrase("New passphrase: ", true)
+		if err != nil {
+			return err
+		}
+	}
+	if len(newPassphrase) == 0 {
+		return errors.New("the new passphrase is empty")
+	}
+
+	ks := newKeyStore()
+	account, err := getScannerAccount(ks)
+	if err != nil {
+		return err
+	}
+	if err := ks.Update(account, cfg.Passphrase, newPassphrase); err != nil {
+		return fmt.Errorf("failed to change the passphrase: %v", err)
+	}
+	greenBold("Changed the passphrase of the scanner account %s\n", account.Address.Hex())
+	whiteBold("Please use the new passphrase with FORTA_PASSPHRASE or --passphrase from now on.\n")
+	return nil
+}
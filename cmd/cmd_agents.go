@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-core-go/ipfs"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	jrp "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/spf13/cobra"
+)
+
+// agentSummary is the summary of an assigned agent and its replicas.
+type agentSummary struct {
+	ID        string  `json:"id"`
+	ImageHash string  `json:"imageHash"`
+	Manifest  string  `json:"manifest,omitempty"`
+	Version   string  `json:"version,omitempty"`
+	ChainIDs  []int64 `json:"chainIds,omitempty"`
+	Status    string  `json:"status"`
+	Replicas  int     `json:"replicas"`
+	Ready     int     `json:"ready"`
+}
+
+// agentReplicaInspection is the runtime status and the resource usage of an agent replica.
+type agentReplicaInspection struct {
+	*poolagent.Status
+	Resources *clients.ContainerStats `json:"resources,omitempty"`
+}
+
+// agentInspection is the detailed view of an assigned agent.
+type agentInspection struct {
+	*agentSummary
+	AgentManifest   json.RawMessage           `json:"agentManifest,omitempty"`
+	ReplicaStatuses []*agentReplicaInspection `json:"replicaStatuses"`
+	JsonRpcUsage    *jrp.AgentUsage           `json:"jsonRpcUsage,omitempty"`
+}
+
+func getAgentStatuses() ([]*poolagent.Status, error) {
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []*poolagent.Status
+	if err := adminClient.Do(http.MethodGet, "/agents", nil, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to get agent statuses: %v", err)
+	}
+	return statuses, nil
+}
+
+// summarizeAgents groups the replica statuses by the agent.
+func summarizeAgents(statuses []*poolagent.Status) []*agentSummary {
+	var summaries []*agentSummary
+	byID := make(map[string]*agentSummary)
+	paused := make(map[string]bool)
+	for _, status := range statuses {
+		summary, ok := byID[status.ID]
+		if !ok {
+			summary = &agentSummary{
+				ID:        status.ID,
+				ImageHash: status.ImageHash,
+				Manifest:  status.Manifest,
+				Version:   status.Version,
+				ChainIDs:  status.ChainIDs,
+			}
+			byID[status.ID] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Replicas++
+		if status.Ready {
+			summary.Ready++
+		}
+		if status.Paused {
+			paused[status.ID] = true
+		}
+	}
+	for _, summary := range summaries {
+		switch {
+		case paused[summary.ID]:
+			summary.Status = "paused"
+		case summary.Ready == summary.Replicas:
+			summary.Status = "ready"
+		default:
+			summary.Status = "starting"
+		}
+	}
+	return summaries
+}
+
+func formatChainIDs(chainIDs []int64) string {
+	if len(chainIDs) == 0 {
+		return "-"
+	}
+	var strs []string
+	for _, chainID := range chainIDs {
+		strs = append(strs, fmt.Sprintf("%d", chainID))
+	}
+	return strings.Join(strs, ",")
+}
+
+func handleFortaAgentsList(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+
+	statuses, err := getAgentStatuses()
+	if err != nil {
+		return err
+	}
+	summaries := summarizeAgents(statuses)
+
+	switch format {
+	case StatusFormatPretty:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "AGENT\tIMAGE DIGEST\tVERSION\tSTATUS\tREPLICAS\tCHAINS")
+		for _, summary := range summaries {
+			version := summary.Version
+			if len(version) == 0 {
+				version = "-"
+			}
+			fmt.Fprintf(
+				w, "%s\t%s\t%s\t%s\t%d/%d\t%s\n",
+				summary.ID, utils.ShortenString(summary.ImageHash, 16), version, summary.Status,
+				summary.Ready, summary.Replicas, formatChainIDs(summary.ChainIDs),
+			)
+		}
+		return w.Flush()
+
+	case StatusFormatJSON:
+		b, _ := json.MarshalIndent(summaries, "", "  ")
+		fmt.Println(string(b))
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func handleFortaAgentsInspect(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	agentID := args[0]
+
+	statuses, err := getAgentStatuses()
+	if err != nil {
+		return err
+	}
+	var agentStatuses []*poolagent.Status
+	for _, status := range statuses {
+		if strings.EqualFold(status.ID, agentID) {
+			agentStatuses = append(agentStatuses, status)
+		}
+	}
+	if len(agentStatuses) == 0 {
+		return fmt.Errorf("agent %s is not assigned to this node", agentID)
+	}
+
+	inspection := &agentInspection{agentSummary: summarizeAgents(agentStatuses)[0]}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if len(inspection.Manifest) > 0 {
+		inspection.AgentManifest, err = getAgentManifest(ctx, inspection.Manifest)
+		if err != nil {
+			yellowBold("Failed to get the agent manifest: %v\n", err)
+		}
+	}
+	runtimeClient, err := clients.NewRuntimeClient("", cfg.ContainerRuntime)
+	if err != nil {
+		return fmt.Errorf("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
+	}
+	for _, status := range agentStatuses {
+		replica := &agentReplicaInspection{Status: status}
+		container, err := runtimeClient.GetContainerByName(ctx, status.ContainerName)
+		if err == nil {
+			replica.Resources, err = runtimeClient.GetContainerStats(ctx, container.ID)
+		}
+		if err != nil {
+			yellowBold("Failed to get the resource usage of %s: %v\n", status.ContainerName, err)
+		}
+		inspection.ReplicaStatuses = append(inspection.ReplicaStatuses, replica)
+	}
+	inspection.JsonRpcUsage = getAgentJsonRpcUsage(agentStatuses[0].ID)
+
+	switch format {
+	case StatusFormatPretty:
+		printAgentInspection(inspection)
+		return nil
+
+	case StatusFormatJSON:
+		b, _ := json.MarshalIndent(inspection, "", "  ")
+		fmt.Println(string(b))
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// getAgentManifest gets the agent manifest from IPFS.
+func getAgentManifest(ctx context.Context, ref string) (json.RawMessage, error) {
+	ipfsClient, err := ipfs.NewClient(cfg.Registry.IPFS.GatewayURL)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ipfsClient.GetBytes(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	var signedManifest struct {
+		Manifest json.RawMessage `json:"manifest"`
+	}
+	if err := json.Unmarshal(b, &signedManifest); err != nil {
+		return nil, fmt.Errorf("invalid agent manifest: %v", err)
+	}
+	return signedManifest.Manifest, nil
+}
+
+// getAgentJsonRpcUsage returns the json-rpc proxy usage of the agent, if available.
+func getAgentJsonRpcUsage(agentID string) *jrp.AgentUsage {
+	adminClient, err := newAdminClient(config.DockerJSONRPCProxyContainerName)
+	if err != nil {
+		return nil
+	}
+	var report jrp.UsageReport
+	if err := adminClient.Do(http.MethodGet, "/usage", nil, &report); err != nil {
+		return nil
+	}
+	for _, usage := range report.Agents {
+		if strings.EqualFold(usage.AgentID, agentID) {
+			return usage
+		}
+	}
+	return nil
+}
+
+func printAgentInspection(inspection *agentInspection) {
+	fmt.Printf("Agent:        %s\n", inspection.ID)
+	fmt.Printf("Version:      %s\n", inspection.Version)
+	fmt.Printf("Image digest: %s\n", inspection.ImageHash)
+	fmt.Printf("Manifest:     %s\n", inspection.Manifest)
+	fmt.Printf("Chains:       %s\n", formatChainIDs(inspection.ChainIDs))
+	fmt.Printf("Status:       %s (%d/%d replicas ready)\n", inspection.Status, inspection.Ready, inspection.Replicas)
+	if len(inspection.AgentManifest) > 0 {
+		b, _ := json.MarshalIndent(inspection.AgentManifest, "", "  ")
+		fmt.Printf("\nManifest contents:\n%s\n", string(b))
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPLICA\tCONTAINER\tCPU\tMEMORY\tTX ERRORS\tBLOCK ERRORS\tLAST ERROR")
+	for _, replica := range inspection.ReplicaStatuses {
+		cpu, memory := "-", "-"
+		if replica.Resources != nil {
+			cpu = fmt.Sprintf("%.1f%%", replica.Resources.CPUPercent)
+			memory = fmt.Sprintf("%.1fMiB", float64(replica.Resources.MemoryBytes)/(1<<20))
+		}
+		lastErr := "-"
+		if replica.LastErrorTime != nil {
+			lastErr = fmt.Sprintf("%s (%s)", replica.LastError, replica.LastErrorTime.Format(time.RFC3339))
+		}
+		fmt.Fprintf(
+			w, "%d\t%s\t%s\t%s\t%d\t%d\t%s\n",
+			replica.Replica, replica.ContainerName, cpu, memory, replica.TxErrors, replica.BlockErrors, lastErr,
+		)
+	}
+	w.Flush()
+
+	if usage := inspection.JsonRpcUsage; usage != nil {
+		fmt.Printf(
+			"\nJSON-RPC proxy: %d calls, %d errors, %d throttled, %d blocked\n",
+			usage.Calls, usage.Errors, usage.Throttled, usage.Blocked,
+		)
+	}
+}
+
+func handleFortaAgentsDisable(cmd *cobra.Command, args []string) error {
+	return setAgentDisabled(args[0], true)
+}
+
+func handleFortaAgentsEnable(cmd *cobra.Command, args []string) error {
+	return setAgentDisabled(args[0], false)
+}
+
+func setAgentDisabled(agentID string, disabled bool) error {
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	var disabledAgents []string
+	if err := adminClient.Do(http.MethodPost, fmt.Sprintf("/agents/%s/%s", agentID, action), nil, &disabledAgents); err != nil {
+		return fmt.Errorf("failed to %s the agent: %v", action, err)
+	}
+	greenBold("Agent %s is %sd\n", agentID, action)
+	if len(disabledAgents) > 0 {
+		fmt.Printf("Disabled agents: %s\n", strings.Join(disabledAgents, ", "))
+	}
+	return nil
+}
+
+func handleFortaAgentsStatus(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+
+	statuses, err := getAgentStatuses()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case StatusFormatPretty:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "AGENT\tREPLICA\tREADY\tSERVING\tPAUSED\tTX BUFFER\tBLOCK BUFFER\tTX LATENCY\tBLOCK LATENCY\tERRORS")
+		for _, status := range statuses {
+			fmt.Fprintf(
+				w, "%s\t%d\t%t\t%t\t%t\t%d/%d\t%d/%d\t%.0fms\t%.0fms\t%d\n",
+				utils.ShortenString(status.ID, 10), status.Replica, status.Ready, status.Serving, status.Paused,
+				status.TxBuffer, status.TxBufferLimit, status.BlockBuffer, status.BlockBufferLimit,
+				status.TxLatencyMs, status.BlockLatencyMs, status.TxErrors+status.BlockErrors,
+			)
+		}
+		return w.Flush()
+
+	case StatusFormatJSON:
+		b, _ := json.MarshalIndent(statuses, "", "  ")
+		fmt.Println(string(b))
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func handleFortaAgentsUsage(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+
+	adminClient, err := newAdminClient(config.DockerJSONRPCProxyContainerName)
+	if err != nil {
+		return err
+	}
+	var report jrp.UsageReport
+	if err := adminClient.Do(http.MethodGet, "/usage", nil, &report); err != nil {
+		return fmt.Errorf("failed to get the agent usage: %v", err)
+	}
+
+	switch format {
+	case StatusFormatPretty:
+		fmt.Printf("JSON-RPC proxy usage since %s\n\n", report.Since.Format(time.RFC3339))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "AGENT\tCALLS\tERRORS\tERROR RATE\tTHROTTLED\tBLOCKED\tREQUEST BYTES\tRESPONSE BYTES\tAVG LATENCY\tMAX LATENCY")
+		for _, usage := range report.Agents {
+			fmt.Fprintf(
+				w, "%s\t%d\t%d\t%.1f%%\t%d\t%d\t%d\t%d\t%.0fms\t%dms\n",
+				utils.ShortenString(usage.AgentID, 10), usage.Calls, usage.Errors, usage.ErrorRate*100,
+				usage.Throttled, usage.Blocked, usage.RequestBytes, usage.ResponseBytes,
+				usage.AvgLatencyMs, usage.MaxLatencyMs,
+			)
+		}
+		return w.Flush()
+
+	case StatusFormatJSON:
+		b, _ := json.MarshalIndent(&report, "", "  ")
+		fmt.Println(string(b))
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
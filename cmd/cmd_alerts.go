@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/spf13/cobra"
+)
+
+func parseTimeFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil || len(value) == 0 {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s value (expected RFC3339 time): %v", name, err)
+	}
+	return t, nil
+}
+
+func handleFortaAlertsRepublish(cmd *cobra.Command, args []string) error {
+	var (
+		req publisher.RepublishRequest
+		err error
+	)
+	if req.From, err = parseTimeFlag(cmd, "from"); err != nil {
+		return err
+	}
+	if req.To, err = parseTimeFlag(cmd, "to"); err != nil {
+		return err
+	}
+	if req.FromBlock, err = cmd.Flags().GetUint64("from-block"); err != nil {
+		return err
+	}
+	if req.ToBlock, err = cmd.Flags().GetUint64("to-block"); err != nil {
+		return err
+	}
+	if req.From.IsZero() && req.FromBlock == 0 {
+		return fmt.Errorf("please specify the start of the range with --from or --from-block")
+	}
+
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	var result publisher.RepublishResult
+	if err := adminClient.Do(http.MethodPost, "/alerts/republish", &req, &result); err != nil {
+		return fmt.Errorf("failed to republish alerts: %v", err)
+	}
+	greenBold("Queued %d alerts in %d batches for republishing\n", result.Alerts, result.Batches)
+	return nil
+}
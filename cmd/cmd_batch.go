@@ -7,9 +7,14 @@ import (
 	"os"
 	"path"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/forta-network/forta-core-go/encoding"
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/forta-network/forta-node/services/publisher/storage"
+	"github.com/forta-network/forta-node/store"
 	"github.com/ipfs/go-cid"
 	"github.com/spf13/cobra"
 )
@@ -80,3 +85,49 @@ func handleFortaBatchDecode(cmd *cobra.Command, args []string) error {
 	greenBold("Successfully wrote the decoded batch to %s\n", filePath)
 	return nil
 }
+
+func handleFortaVerifyBatch(cmd *cobra.Command, args []string) error {
+	expectedScanner, err := cmd.Flags().GetString("scanner")
+	if err != nil {
+		return err
+	}
+	if len(expectedScanner) > 0 && !common.IsHexAddress(expectedScanner) {
+		return fmt.Errorf("invalid scanner address")
+	}
+
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read the batch file: %v", err)
+	}
+	// the batches can be compressed in the batch storage
+	b, err = storage.Decompress(b, storage.DetectEncoding(b))
+	if err != nil {
+		return fmt.Errorf("failed to decompress the batch file: %v", err)
+	}
+	var signedBatch protocol.SignedPayload
+	if err := json.Unmarshal(b, &signedBatch); err != nil {
+		return fmt.Errorf("failed to decode batch json: %v", err)
+	}
+
+	alertBatch, err := publisher.VerifyBatch(&signedBatch, expectedScanner)
+	if err != nil {
+		redBold("Invalid batch!\n")
+		return err
+	}
+
+	greenBold("Valid batch signed by scanner %s\n", signedBatch.Signature.Signer)
+	fmt.Printf("chain: %d, blocks: %d-%d, alerts: %d, max severity: %s\n",
+		alertBatch.ChainId, alertBatch.BlockStart, alertBatch.BlockEnd, alertBatch.AlertCount, alertBatch.MaxSeverity)
+	return nil
+}
+
+func handleFortaBatchMigrate(cmd *cobra.Command, args []string) error {
+	for _, dirName := range []string{config.DefaultBatchQueueDirName, config.DefaultDeadLetterDirName} {
+		migrated, err := store.MigrateBatchFiles(path.Join(cfg.FortaDir, dirName))
+		if err != nil {
+			return fmt.Errorf("failed to migrate the batches in %s: %v", dirName, err)
+		}
+		greenBold("Migrated %d batches in %s to local format v%d\n", migrated, dirName, store.CurrentBatchFormat)
+	}
+	return nil
+}
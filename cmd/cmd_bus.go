@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+// busContainers are the node containers which keep the message bus dead letters.
+var busContainers = []string{
+	config.DockerSupervisorContainerName,
+	config.DockerScannerContainerName,
+	config.DockerJSONRPCProxyContainerName,
+}
+
+func handleFortaBusDeadLettersList(cmd *cobra.Command, args []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCONTAINER\tSUBJECT\tFAILED AT\tATTEMPTS\tREASON")
+	var found int
+	for _, containerName := range busContainers {
+		adminClient, err := newAdminClient(containerName)
+		if err != nil {
+			yellowBold("Skipping %s: %v\n", containerName, err)
+			continue
+		}
+		var letters []*messaging.DeadLetter
+		if err := adminClient.Do(http.MethodGet, "/bus/dead-letters", nil, &letters); err != nil {
+			yellowBold("Failed to list the dead letters of %s: %v\n", containerName, err)
+			continue
+		}
+		for _, letter := range letters {
+			fmt.Fprintf(
+				w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				letter.ID, containerName, letter.Subject, letter.FailedAt.Format(time.RFC3339),
+				letter.Attempts, letter.Reason,
+			)
+		}
+		found += len(letters)
+	}
+	if found == 0 {
+		greenBold("No dead letters found\n")
+		return nil
+	}
+	return w.Flush()
+}
+
+func handleFortaBusDeadLettersInspect(cmd *cobra.Command, args []string) error {
+	for _, containerName := range busContainers {
+		adminClient, err := newAdminClient(containerName)
+		if err != nil {
+			continue
+		}
+		var letter messaging.DeadLetter
+		if err := adminClient.Do(http.MethodGet, "/bus/dead-letters/"+args[0], nil, &letter); err != nil {
+			continue
+		}
+		// indent by two spaces
+		b, _ := json.MarshalIndent(&letter, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	return errors.New("dead letter not found")
+}
+
+func handleFortaBusDeadLettersReplay(cmd *cobra.Command, args []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	if all == (len(args) > 0) {
+		return fmt.Errorf("please specify either the dead letter ids or --all")
+	}
+
+	var total messaging.ReplayDeadLettersResponse
+	req := &messaging.ReplayDeadLettersRequest{IDs: args}
+	for _, containerName := range busContainers {
+		adminClient, err := newAdminClient(containerName)
+		if err != nil {
+			yellowBold("Skipping %s: %v\n", containerName, err)
+			continue
+		}
+		var resp messaging.ReplayDeadLettersResponse
+		if err := adminClient.Do(http.MethodPost, "/bus/dead-letters/replay", req, &resp); err != nil {
+			yellowBold("Failed to replay the dead letters of %s: %v\n", containerName, err)
+			continue
+		}
+		total.Replayed += resp.Replayed
+		total.Failed += resp.Failed
+	}
+	if total.Failed > 0 {
+		redBold("Failed to replay %d messages\n", total.Failed)
+	}
+	greenBold("Replayed %d messages\n", total.Replayed)
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaConfigValidate(cmd *cobra.Command, args []string) error {
+	problems, err := config.ValidateFile(cfg.ConfigFilePath())
+	if err != nil {
+		redBold("%v\n", err)
+		return errors.New("invalid config file")
+	}
+	if len(problems) == 0 {
+		greenBold("Config is valid\n")
+		return nil
+	}
+	redBold("Found %d problem(s) in %s:\n", len(problems), cfg.ConfigFilePath())
+	for _, problem := range problems {
+		field := problem.Field
+		if problem.Line > 0 {
+			field = fmt.Sprintf("%s (line %d)", field, problem.Line)
+		}
+		yellowBold("  - %s: ", field)
+		fmt.Fprintln(os.Stderr, problem.Message)
+	}
+	return errors.New("invalid config file")
+}
+
+func handleFortaConfigSign(cmd *cobra.Command, args []string) error {
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read the file: %v", err)
+	}
+	if err := config.CheckRemoteConfig(b); err != nil {
+		return err
+	}
+	scannerSigner, err := newScannerSigner()
+	if err != nil {
+		return fmt.Errorf("failed to load the scanner signer: %v", err)
+	}
+	defer scannerSigner.Close()
+	timestamp := time.Now().Unix()
+	sig, err := signer.SignBytes(scannerSigner, config.RemoteConfigSignedBytes(timestamp, b))
+	if err != nil {
+		return fmt.Errorf("failed to sign: %v", err)
+	}
+	sigFile, _ := json.Marshal(&config.RemoteConfigSignature{Timestamp: timestamp, Signature: sig.Signature})
+	sigPath := args[0] + ".sig"
+	if err := os.WriteFile(sigPath, sigFile, 0644); err != nil {
+		return fmt.Errorf("failed to write the signature: %v", err)
+	}
+	greenBold("Signed by %s - upload %s next to the file\n", sig.Signer, sigPath)
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/forta-network/forta-node/store"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaBatchDeadLettersList(cmd *cobra.Command, args []string) error {
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	var letters []*store.DeadLetter
+	if err := adminClient.Do(http.MethodGet, "/batches/dead-letters", nil, &letters); err != nil {
+		return fmt.Errorf("failed to list the dead letters: %v", err)
+	}
+	if len(letters) == 0 {
+		greenBold("No dead letters found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFAILED AT\tBLOCKS\tALERTS\tATTEMPTS\tREASON")
+	for _, letter := range letters {
+		fmt.Fprintf(
+			w, "%s\t%s\t%d-%d\t%d\t%d\t%s\n",
+			letter.ID, letter.FailedAt.Format(time.RFC3339), letter.BlockStart, letter.BlockEnd,
+			letter.AlertCount, letter.Attempts, letter.Reason,
+		)
+	}
+	return w.Flush()
+}
+
+func handleFortaBatchDeadLettersInspect(cmd *cobra.Command, args []string) error {
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	var letter store.DeadLetter
+	if err := adminClient.Do(http.MethodGet, "/batches/dead-letters/"+args[0], nil, &letter); err != nil {
+		return fmt.Errorf("failed to get the dead letter: %v", err)
+	}
+	// indent by two spaces
+	b, _ := json.MarshalIndent(&letter, "", "  ")
+	fmt.Println(string(b))
+	return nil
+}
+
+func handleFortaBatchDeadLettersRetry(cmd *cobra.Command, args []string) error {
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	if all == (len(args) > 0) {
+		return fmt.Errorf("please specify either the dead letter ids or --all")
+	}
+
+	adminClient, err := newAdminClient(config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	var resp publisher.RetryDeadLettersResponse
+	req := &publisher.RetryDeadLettersRequest{IDs: args}
+	if err := adminClient.Do(http.MethodPost, "/batches/dead-letters/retry", req, &resp); err != nil {
+		return fmt.Errorf("failed to retry the dead letters: %v", err)
+	}
+	greenBold("Moved %d batches back to the retry queue\n", resp.Retried)
+	return nil
+}
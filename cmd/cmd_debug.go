@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+// debug profile types
+const (
+	ProfileHeap      = "heap"
+	ProfileGoroutine = "goroutine"
+	ProfileCPU       = "cpu"
+	ProfileBlock     = "block"
+)
+
+// serviceContainers maps the service names to the node containers which serve the admin API.
+var serviceContainers = map[string]string{
+	"supervisor": config.DockerSupervisorContainerName,
+	"scanner":    config.DockerScannerContainerName,
+	"json-rpc":   config.DockerJSONRPCProxyContainerName,
+}
+
+func handleFortaDebugProfile(cmd *cobra.Command, args []string) error {
+	service, err := cmd.Flags().GetString("service")
+	if err != nil {
+		return err
+	}
+	seconds, err := cmd.Flags().GetInt("seconds")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	profile := args[0]
+	path, err := profilePath(profile, seconds)
+	if err != nil {
+		return err
+	}
+	containerName, ok := serviceContainers[service]
+	if !ok {
+		return fmt.Errorf("unsupported service: %s", service)
+	}
+	if len(output) == 0 {
+		output = fmt.Sprintf("forta-%s-%s-%s.pb.gz", service, profile, time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	adminClient, err := newAdminClient(containerName)
+	if err != nil {
+		return err
+	}
+	if profile == ProfileCPU {
+		yellowBold("Profiling the CPU of %s for %d seconds...\n", service, seconds)
+	}
+	var buf bytes.Buffer
+	if err := adminClient.Stream(path, &buf); err != nil {
+		return fmt.Errorf("failed to capture the %s profile (is debug.pprof enabled in the config?): %v", profile, err)
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the profile: %v", err)
+	}
+	greenBold("Wrote the %s profile of %s to %s\n", profile, service, output)
+	fmt.Printf("Inspect it with 'go tool pprof %s'\n", output)
+	return nil
+}
+
+// profilePath returns the admin API path which serves the profile.
+func profilePath(profile string, seconds int) (string, error) {
+	switch profile {
+	case ProfileHeap, ProfileGoroutine, ProfileBlock:
+		return fmt.Sprintf("/debug/pprof/%s", profile), nil
+	case ProfileCPU:
+		if seconds < 1 {
+			return "", errors.New("--seconds must be at least 1")
+		}
+		return fmt.Sprintf("/debug/pprof/profile?seconds=%d", seconds), nil
+	default:
+		return "", fmt.Errorf("unsupported profile: %s (supported: %s)", profile, strings.Join([]string{
+			ProfileHeap, ProfileGoroutine, ProfileCPU, ProfileBlock,
+		}, ", "))
+	}
+}
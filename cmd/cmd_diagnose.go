@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/secrets"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+type diagnoseContainer struct {
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	State   string `json:"state"`
+	Status  string `json:"status"`
+	Created string `json:"created"`
+}
+
+// diagnoseBundle collects the files of the support bundle and redacts the sensitive values
+// from all of them.
+type diagnoseBundle struct {
+	files    map[string][]byte
+	names    []string
+	problems []string
+	redacted []string
+}
+
+func (bundle *diagnoseBundle) add(name string, b []byte) {
+	if _, ok := bundle.files[name]; !ok {
+		bundle.names = append(bundle.names, name)
+	}
+	bundle.files[name] = b
+}
+
+func (bundle *diagnoseBundle) addJSON(name string, v interface{}) {
+	b, _ := json.MarshalIndent(v, "", "  ")
+	bundle.add(name, b)
+}
+
+func (bundle *diagnoseBundle) addProblem(format string, args ...interface{}) {
+	problem := fmt.Sprintf(format, args...)
+	bundle.problems = append(bundle.problems, problem)
+	yellowBold("%s\n", problem)
+}
+
+func handleFortaDiagnose(cmd *cobra.Command, args []string) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	tail, err := cmd.Flags().GetInt("tail")
+	if err != nil {
+		return err
+	}
+	if len(output) == 0 {
+		output = fmt.Sprintf("forta-diagnose-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	bundle := &diagnoseBundle{files: make(map[string][]byte)}
+	collectDiagnoseConfig(bundle)
+	collectDiagnoseVersion(bundle)
+	collectDiagnoseHealth(bundle)
+	collectDiagnoseContainers(bundle, tail)
+	if len(bundle.problems) > 0 {
+		bundle.add("problems.txt", []byte(strings.Join(bundle.problems, "\n")+"\n"))
+	}
+
+	if err := writeDiagnoseBundle(bundle, output); err != nil {
+		return fmt.Errorf("failed to write the support bundle: %v", err)
+	}
+	greenBold("Wrote the support bundle to %s\n", output)
+	return nil
+}
+
+// collectDiagnoseConfig adds the redacted config files and collects the sensitive values from
+// both the config files and the resolved secrets.
+func collectDiagnoseConfig(bundle *diagnoseBundle) {
+	for _, fileName := range []string{config.DefaultConfigFileName, config.DefaultRemoteConfigFileName} {
+		b, err := os.ReadFile(path.Join(cfg.FortaDir, fileName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			bundle.addProblem("failed to read %s: %v", fileName, err)
+			continue
+		}
+		redacted, values, err := config.RedactConfig(b)
+		if err != nil {
+			bundle.addProblem("failed to redact %s - skipping: %v", fileName, err)
+			continue
+		}
+		bundle.redacted = append(bundle.redacted, values...)
+		bundle.add(path.Join("config", fileName), redacted)
+	}
+
+	resolved := cfg
+	if err := secrets.NewResolver(cfg.FortaDir).ResolveConfig(context.Background(), &resolved); err != nil {
+		bundle.addProblem("failed to resolve the secrets - the resolved secret values may not be redacted from the logs: %v", err)
+	}
+	b, _ := yaml.Marshal(&resolved)
+	_, values, _ := config.RedactConfig(b)
+	bundle.redacted = append(bundle.redacted, values...)
+	for _, value := range resolved.Secrets {
+		bundle.redacted = append(bundle.redacted, value)
+	}
+	if len(resolved.Passphrase) > 0 {
+		bundle.redacted = append(bundle.redacted, resolved.Passphrase)
+	}
+}
+
+func collectDiagnoseVersion(bundle *diagnoseBundle) {
+	releaseSummary, _ := config.GetBuildReleaseSummary()
+	bundle.addJSON("version.json", map[string]interface{}{
+		"release":     releaseSummary,
+		"commit":      config.CommitHash,
+		"chainId":     cfg.ChainID,
+		"development": cfg.Development,
+		"runtime":     cfg.ContainerRuntime.Type,
+		"collectedAt": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func collectDiagnoseHealth(bundle *diagnoseBundle) {
+	reports := checkNodeHealth()
+	bundle.addJSON("health.json", reports)
+}
+
+func collectDiagnoseContainers(bundle *diagnoseBundle, tail int) {
+	runtimeClient, err := clients.NewRuntimeClient("", cfg.ContainerRuntime)
+	if err != nil {
+		bundle.addProblem("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	containers, err := runtimeClient.GetFortaServiceContainers(ctx)
+	if err != nil {
+		bundle.addProblem("failed to list the containers: %v", err)
+		return
+	}
+
+	var list []*diagnoseContainer
+	for _, container := range containers {
+		name := container.Names[0][1:]
+		list = append(list, &diagnoseContainer{
+			Name:    name,
+			Image:   container.Image,
+			State:   container.State,
+			Status:  container.Status,
+			Created: time.Unix(container.Created, 0).UTC().Format(time.RFC3339),
+		})
+		logs, err := runtimeClient.GetContainerLogs(ctx, container.ID, strconv.Itoa(tail), -1)
+		if err != nil {
+			bundle.addProblem("failed to get the logs of %s: %v", name, err)
+			continue
+		}
+		bundle.add(path.Join("logs", name+".log"), []byte(logs))
+	}
+	bundle.addJSON("containers.json", list)
+}
+
+func writeDiagnoseBundle(bundle *diagnoseBundle, output string) error {
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	dir := strings.TrimSuffix(path.Base(output), ".tar.gz")
+	now := time.Now()
+	for _, name := range bundle.names {
+		b := []byte(config.Redact(string(bundle.files[name]), bundle.redacted))
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    path.Join(dir, name),
+			Mode:    0600,
+			Size:    int64(len(b)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -46,6 +46,16 @@ func handleFortaInit(cmd *cobra.Command, args []string) error {
 	}
 
 	if !isKeyInitialized() {
+		if passphraseFileErr != nil {
+			return passphraseFileErr
+		}
+		if len(cfg.Passphrase) == 0 {
+			passphrase, err := promptPassphrase("Choose a passphrase for the scanner key: ", true)
+			if err != nil {
+				return err
+			}
+			cfg.Passphrase = passphrase
+		}
 		if len(cfg.Passphrase) == 0 {
 			yellowBold("Please provide a passphrase and do not lose it.\n\n")
 			return cmd.Help()
@@ -75,13 +85,41 @@ func printScannerAddress(address string) {
 }
 
 const defaultConfig = `# Auto generated by 'forta init' - safe to modify
+# The log level, the json-rpc proxy endpoints and limits, and the alert filters are reloaded
+# after the changes are saved; the other changes require 'forta run' to be restarted
+# Sensitive values (and the passphrase) can be secret references which are resolved by 'forta run':
+#   vault://secret/data/forta#scanUrl (uses VAULT_ADDR and VAULT_TOKEN)
+#   awskms://<base64 ciphertext> (uses AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)
+#   sops://secrets.enc.yml#scan.url (decrypted with the sops binary, relative to the forta dir)
+# The version of the config file schema, older files are migrated after the node is updated
+version: 1
+
 # The chainId is the chainId of the network that is analyzed (1=mainnet)
 chainId: 1
 
+# The chain profile contains the block time, the confirmation depth, the trace support and the
+# json-rpc rate limits (defaults to the built-in profile of the chainId)
+# Built-in profiles: mainnet, bsc, polygon, avalanche, arbitrum, optimism
+# chainProfile: my-polygon
+# chainProfiles: # custom profiles, the unset values are inherited
+#   my-polygon:
+#     inherits: polygon
+#     blockTimeSeconds: 2
+#     confirmationDepth: 5 # blocks behind the latest block
+#     trace: false
+#     blockRateLimit: 500 # min ms between block requests, half of the block time by default (max 200)
+#     rateLimit:
+#       rate: 100
+#       burst: 100
+
 # The scan settings are used to retrieve the transactions that are analyzed
 scan:
   jsonRpc:
     url: <required>
+#  blockFetch: # the block, the traces and the logs of a block are fetched concurrently
+#    timeoutSeconds: 120 # shared deadline of the requests, the block is fetched again after it
+#    requireTraces: false # fetches the block again instead of scanning it without the traces if tracing fails
+#  txEventWorkers: 4 # workers which convert the transactions to agent requests (default: number of CPUs)
 
 # The trace endpoint must support trace_block (such as alchemy)
 trace:
@@ -92,15 +130,60 @@ trace:
 # registry:
 #  jsonRpc:
 #    url: https://polygon-rpc.com/
+#  fallbacks: # tried in order when the registry endpoint is unavailable
+#    - url: <another polygon json-rpc url>
 #  ipfs:
 #    gatewayUrl: https://ipfs.forta.network
 #    username: <set if needed>
 #    password: <set if needed>
+#  listenEvents: true # checks the agents right after the registry events
+#  minimumAgentStake: 100 # in FORT, agents with less active stake are skipped
+#  legacy:
+#    ensAddress: <ens contract of the previous registry during migrations>
+#  mirrors: # tried in order when an agent or node image cannot be pulled
+#    - host: <mirror registry host>
+#      username: <set if needed>
+#      password: <set if needed>
+# Private agents which are not in the registry can be declared in agents.yml in the forta dir:
+# agents:
+#   - id: <agent id>
+#     image: <image reference>
+#     chainIds: [ 1 ]
 
 # The jsonRpcProxy settings are used make query requests (defaults to scan url)
 # jsonRpcProxy:
 #   jsonRpc:
 #     url: <enter if different from scan value>
+#   failover:
+#     jsonRpc:
+#       url: <secondary json-rpc url>
+#     healthCheckSeconds: 15
+#     failureThreshold: 3 # consecutive failures before switching
+#   cache:
+#     enable: true # caches the finalized blocks, receipts, contract code and storage
+#   maxConcurrentRequests: 10 # per agent
+#   maxResponseSizeMb: 20 # larger responses are replaced with a json-rpc error
+#   upstreamTimeoutSeconds: 30
+#   agentLimits:
+#     <agent id>:
+#       rateLimit:
+#         rate: 10
+#         burst: 20
+#       maxConcurrentRequests: 2
+#   methods:
+#     deny: [ "eth_sendRawTransaction", "admin_*", "debug_*" ]
+#   webSocket:
+#     enable: true # proxies eth_subscribe from the agents
+#     url: <enter if different from the json-rpc url with ws scheme>
+#     maxSubscriptions: 10 # per agent
+#   chains: # agents select the chain with X-Forta-Chain-Id header or /chains/<chain id> path
+#     - chainId: 137
+#       jsonRpc:
+#         url: <polygon json-rpc url>
+#   auditLog:
+#     enable: true # writes every proxied call to <forta dir>/audit as json lines
+#     maxSizeMb: 100
+#     maxFiles: 5
 
 # The publish settings drive how alerts are sent
 # publish:
@@ -108,12 +191,172 @@ trace:
 #    apiUrl: https://ipfs.forta.network
 #    username: <set if needed>
 #    password: <set if needed>
+#  storage:
+#    type: ipfs # or s3, gcs, https (the alert api still gets the ipfs cid, the other refs are only sent to the sinks)
+#    compression: zstd # or gzip (only with s3, gcs, https - rejected with ipfs)
+#    pinning: # uploads the ipfs batches to each target
+#      quorum: 1 # all targets by default
+#      targets:
+#        - name: my-ipfs-node
+#          apiUrl: http://localhost:5001
+#  enrichment:
+#    enable: true # adds token, ENS and contract info about the finding addresses
+#    creationBlock: true # adds the contract creation block (needs an archive node)
+#    alertTimeoutMs: 500 # max wait for the lookups of an alert, the rest is added to the next alerts
+#  sinks:
+#    kafka:
+#      enable: true
+#      brokers: [ "localhost:9092" ]
+#    elasticsearch:
+#      enable: true
+#      url: http://localhost:9200
+
+# The agents can only reach the scanner and the JSON-RPC proxy unless they are allowed the internet access
+# agentNetwork:
+#  allowEgress: false # set true for all agents
+#  egressAgents:
+#    - <agent id>
+#  # for the agents which are allowed the egress on corporate networks
+#  dns: [10.0.0.2]
+#  extraHosts: ["intel.corp.example:10.0.0.10"]
+#  httpProxy: http://proxy.corp.example:3128
+#  httpsProxy: http://proxy.corp.example:3128
+#  noProxy: [.corp.example]
+
+# The agentSecurity settings harden the agent containers which run third-party code
+# agentSecurity:
+#  writableRootFs: false # the agents can write only to the /tmp scratch space by default
+#  addCapabilities: [] # all capabilities are dropped by default
+#  seccompProfile: <file in the forta dir> # uses the runtime default profile if not set
+#  userNamespace: auto # with podman, or enable userns-remap in the docker daemon
+#  disableHardening: false
+
+# The agentCleanup settings control the removal of the old agent images and containers
+# agentCleanup:
+#  intervalMinutes: 60
+#  imageRetentionHours: 24
+#  disable: false
+
+# The resources settings limit the agent containers
+# resources:
+#  agentMaxCpus: 0.2
+#  agentMaxMemoryMib: 1000
+#  agentCpuShares: 512 # relative weight, the node containers have 1024
+#  agentMaxDiskMib: 2000 # needs overlay2 on xfs with pquota, ignored otherwise
+#  agentScratchMib: 256 # /tmp in memory
+#  agentMaxEgressMbps: 5 # internet upload bandwidth of the agents with egress, unlimited if not set
+#  agentOverrides:
+#    - agentId: <agent id>
+#      maxCpus: 1
+#      maxMemoryMib: 2000
+#      priority: 10 # higher priority agents are shed last under host pressure
+#  # passes the NVIDIA GPUs to the agents which request them in their manifests
+#  # (requires the NVIDIA container toolkit on the host)
+#  enableAgentGpus: true
+#  gpuAgents: [<agent id>] # optional, allows all agents if empty
+
+# The hostWatermarks settings shed the lowest priority agents when the host is running out of resources
+# hostWatermarks:
+#  enable: true
+#  memoryPercent: 90 # only with the stop action since paused agents keep their memory
+#  diskPercent: 90 # only reported since shedding the agents does not free the disk
+#  cpuPercent: 95
+#  resumeMarginPercent: 10 # resumes the agents after the usage falls below the watermarks by this much
+#  action: pause # pauses sending blocks and txs to the agents, or stop to stop the agent containers
+#  checkIntervalSeconds: 15
+
+# The containerRuntime settings select the runtime which runs the node containers
+# containerRuntime:
+#  type: podman # or docker (default)
+#  socket: <set if not the default socket of the runtime>
+
+# The messaging settings select the message bus of the node services
+# messaging:
+#  type: embedded # runs the bus inside the supervisor instead of a nats container (default: nats, local is only for the services in one process)
+
+# The tracing settings export the spans of the block and tx processing to an OpenTelemetry collector
+# tracing:
+#  enable: true
+#  endpoint: host.docker.internal:4317 # OTLP gRPC endpoint reachable from the scanner container
+#  insecure: true # disables TLS
+#  sampleRate: 0.1 # ratio of the traced blocks (default: 1)
+
+# The readiness settings are the thresholds of the /readyz endpoints of the node services
+# readiness:
+#  maxBlockLagSeconds: 300 # the scanner is not ready if the latest block is older (default: 300)
+
+# The slaReport settings enable the periodic performance reports signed with the scanner key
+# which are stored in <forta dir>/sla-reports
+# slaReport:
+#  enable: true
+#  intervalMinutes: 60 # length of the report windows (default: 60)
+#  url: <https url to post the signed reports to>
+#  maxReports: 720 # number of stored reports (default: 720)
+
+# The signer settings keep the scanner key in an HSM or a remote signer instead of the keystore
+# in the forta dir. Ledger and Clef can only sign the 'forta register', 'forta enable' and
+# 'forta disable' transactions.
+# signer:
+#  type: pkcs11 # keystore (default), pkcs11, ledger, web3signer or clef
+#  pkcs11:
+#    module: /usr/lib/softhsm/libsofthsm2.so # the PKCS#11 library of the HSM
+#    tokenLabel: forta
+#    keyLabel: scanner # label of the secp256k1 key pair
+#    userPin: vault://secret/data/forta#pin
+#  ledger:
+#    derivationPath: m/44'/60'/0'/0/0 # (default: m/44'/60'/0'/0/0)
+#  remote:
+#    url: http://localhost:9000 # web3signer or clef http api
+#    address: <scanner address> # required with clef or if web3signer has multiple keys
+#    authToken: vault://secret/data/forta#signer-token # sent as a bearer token
+
+# The apiSecurity settings protect the health, metrics, admin and graphql endpoints of the node
+# for the operators who expose them beyond localhost. The files should be in the forta dir and
+# the paths are relative to it. The admin token is in <forta dir>/.admin-token.
+# apiSecurity:
+#  tls: true # serves https with a certificate generated in <forta dir>/tls if certFile is not set
+#  certFile: certs/node.crt
+#  keyFile: certs/node.key
+#  requireClientCert: true # mtls
+#  clientCaFile: certs/ca.crt # the node certificate if not set - it should also sign the node certificate
+#  requireToken: true # requires the admin token as a bearer token also in the health and graphql endpoints
+
+# The debug settings expose the pprof profiles of the node services to 'forta debug profile'
+# debug:
+#  pprof: true
+#  blockProfileRate: 10000 # nanoseconds spent blocked per sampled event (default: 10000)
+
+# The remoteConfig settings fetch a signed config file which overrides this config file.
+# Sign the file with 'forta config sign <file>' and upload the <file>.sig next to it.
+# The signature includes the signing time and only the configs signed later than the current one are applied.
+# remoteConfig:
+#  url: <https url of the remote config file>
+#  signerAddress: <address of the signer scanner key>
+#  signatureUrl: <set if not <url>.sig>
+#  refreshIntervalSeconds: 300
 
 # The log settings drive the log output of the scan node
 # log:
 #  level: info
 #  maxLogSize: 50m
 #  maxLogFiles: 10
+#  # rotation of the agent container logs which are available with 'forta logs'
+#  agentMaxLogSize: 10m
+#  agentMaxLogFiles: 5
+#  format: json # or text
+#  # log levels of the node components (pool, agent, agent-grpc) which override the level
+#  components:
+#    pool: debug
+#  # ship the logs of the node services in addition to the container logs
+#  outputs:
+#    - type: file # writes to <forta dir>/logs/<service>.log unless a path is set
+#    - type: syslog
+#      url: udp://localhost:514
+#    - type: loki
+#      url: http://localhost:3100
+#      labels:
+#        node: my-node
+#      services: [scanner] # ships logs of all services if not set
 `
 
 func isDirInitialized() bool {
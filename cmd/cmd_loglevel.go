@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-node/logging"
+	"github.com/forta-network/forta-node/services/admin"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaLogLevel(cmd *cobra.Command, args []string) error {
+	service, err := cmd.Flags().GetString("service")
+	if err != nil {
+		return err
+	}
+	component, err := cmd.Flags().GetString("component")
+	if err != nil {
+		return err
+	}
+	duration, err := cmd.Flags().GetDuration("duration")
+	if err != nil {
+		return err
+	}
+	services, err := logLevelServices(service)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return showLogLevels(services)
+	}
+	if _, err := log.ParseLevel(args[0]); err != nil {
+		return err
+	}
+	if duration < 0 || (duration > 0 && duration < time.Second) {
+		return errors.New("--duration must be at least a second")
+	}
+	req := &admin.SetLogLevelRequest{
+		Component:       component,
+		Level:           args[0],
+		DurationSeconds: int(duration.Seconds()),
+	}
+	target := "default"
+	if len(component) > 0 {
+		target = component
+	}
+	var failed bool
+	for _, service := range services {
+		adminClient, err := newAdminClient(serviceContainers[service])
+		if err != nil {
+			yellowBold("Skipping %s: %v\n", service, err)
+			failed = true
+			continue
+		}
+		if err := adminClient.Do(http.MethodPost, "/log/levels", req, nil); err != nil {
+			redBold("Failed to change the log level of %s: %v\n", service, err)
+			failed = true
+			continue
+		}
+		if duration > 0 {
+			greenBold("Changed the %s log level of %s to %s for %s\n", target, service, args[0], duration)
+		} else {
+			greenBold("Changed the %s log level of %s to %s\n", target, service, args[0])
+		}
+	}
+	if failed {
+		return errors.New("failed to change the log level of some services")
+	}
+	return nil
+}
+
+func showLogLevels(services []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCOMPONENT\tLEVEL")
+	for _, service := range services {
+		adminClient, err := newAdminClient(serviceContainers[service])
+		if err != nil {
+			yellowBold("Skipping %s: %v\n", service, err)
+			continue
+		}
+		var levels logging.Levels
+		if err := adminClient.Do(http.MethodGet, "/log/levels", nil, &levels); err != nil {
+			yellowBold("Failed to get the log levels of %s: %v\n", service, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", service, "default", levels.Default)
+		var components []string
+		for component := range levels.Components {
+			components = append(components, component)
+		}
+		sort.Strings(components)
+		for _, component := range components {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", service, component, levels.Components[component])
+		}
+	}
+	return w.Flush()
+}
+
+// logLevelServices returns the selected service or all services.
+func logLevelServices(service string) ([]string, error) {
+	if len(service) > 0 {
+		if _, ok := serviceContainers[service]; !ok {
+			return nil, fmt.Errorf("unsupported service: %s", service)
+		}
+		return []string{service}, nil
+	}
+	var services []string
+	for service := range serviceContainers {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services, nil
+}
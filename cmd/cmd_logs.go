@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaLogs(cmd *cobra.Command, args []string) error {
+	agentID, err := cmd.Flags().GetString("agent")
+	if err != nil {
+		return err
+	}
+	tail, err := cmd.Flags().GetInt("tail")
+	if err != nil {
+		return err
+	}
+	follow, err := cmd.Flags().GetBool("follow")
+	if err != nil {
+		return err
+	}
+
+	adminClient, err := newAdminClient(config.DockerJSONRPCProxyContainerName)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("agent", agentID)
+	query.Set("tail", strconv.Itoa(tail))
+	query.Set("follow", strconv.FormatBool(follow))
+	if err := adminClient.Stream("/agents/logs?"+query.Encode(), os.Stdout); err != nil {
+		return fmt.Errorf("failed to get the agent logs: %v", err)
+	}
+	return nil
+}
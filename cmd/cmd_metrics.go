@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+)
+
+// metrics dump formats
+const (
+	MetricsFormatPrometheus = "prometheus"
+	MetricsFormatJSON       = "json"
+)
+
+// metricsContainers are the node containers which serve metrics from the admin API.
+var metricsContainers = []string{
+	config.DockerSupervisorContainerName,
+	config.DockerScannerContainerName,
+	config.DockerJSONRPCProxyContainerName,
+}
+
+type metricsDump struct {
+	CollectedAt string              `json:"collectedAt"`
+	Metrics     []*metricFamilyDump `json:"metrics"`
+}
+
+type metricFamilyDump struct {
+	Name    string        `json:"name"`
+	Help    string        `json:"help"`
+	Type    string        `json:"type"`
+	Metrics []*metricDump `json:"metrics"`
+}
+
+type metricDump struct {
+	Labels    map[string]string  `json:"labels"`
+	Value     *float64           `json:"value,omitempty"`
+	Count     *uint64            `json:"count,omitempty"`
+	Sum       *float64           `json:"sum,omitempty"`
+	Buckets   map[string]uint64  `json:"buckets,omitempty"`
+	Quantiles map[string]float64 `json:"quantiles,omitempty"`
+}
+
+func handleFortaMetricsDump(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if format != MetricsFormatPrometheus && format != MetricsFormatJSON {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	families, err := collectMetrics()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case MetricsFormatPrometheus:
+		for _, family := range families {
+			if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+				return fmt.Errorf("failed to encode the metrics: %v", err)
+			}
+		}
+	case MetricsFormatJSON:
+		b, _ := json.MarshalIndent(&metricsDump{
+			CollectedAt: time.Now().UTC().Format(time.RFC3339),
+			Metrics:     dumpMetricFamilies(families),
+		}, "", "  ")
+		buf.Write(b)
+		buf.WriteString("\n")
+	}
+
+	if len(output) == 0 {
+		_, err := io.Copy(os.Stdout, &buf)
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the metrics: %v", err)
+	}
+	greenBold("Wrote the metrics to %s\n", output)
+	return nil
+}
+
+func handleFortaMetricsLosses(cmd *cobra.Command, args []string) error {
+	families, err := collectMetrics()
+	if err != nil {
+		return err
+	}
+
+	var losses []*metricDump
+	for _, family := range families {
+		if family.GetName() == "forta_events_lost_total" {
+			losses = dumpMetricFamilies([]*dto.MetricFamily{family})[0].Metrics
+		}
+	}
+	if len(losses) == 0 {
+		greenBold("No lost events\n")
+		return nil
+	}
+	sort.Slice(losses, func(i, j int) bool {
+		return *losses[i].Value > *losses[j].Value
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER\tSTAGE\tREASON\tKIND\tAGENT\tCOUNT")
+	for _, loss := range losses {
+		agent := loss.Labels["agent"]
+		if len(agent) == 0 {
+			agent = "-"
+		}
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			loss.Labels["container"], loss.Labels["stage"], loss.Labels["reason"], loss.Labels["kind"], agent, uint64(*loss.Value),
+		)
+	}
+	return w.Flush()
+}
+
+// collectMetrics collects the metrics from all containers and merges them by adding
+// a container label.
+func collectMetrics() ([]*dto.MetricFamily, error) {
+	merged := make(map[string]*dto.MetricFamily)
+	var collected int
+	for _, containerName := range metricsContainers {
+		families, err := getContainerMetrics(containerName)
+		if err != nil {
+			yellowBold("Skipping the metrics of %s: %v\n", containerName, err)
+			continue
+		}
+		collected++
+		for name, family := range families {
+			for _, metric := range family.Metric {
+				metric.Label = append(metric.Label, &dto.LabelPair{
+					Name:  strPtr("container"),
+					Value: strPtr(containerName),
+				})
+			}
+			if existing, ok := merged[name]; ok {
+				existing.Metric = append(existing.Metric, family.Metric...)
+				continue
+			}
+			merged[name] = family
+		}
+	}
+	if collected == 0 {
+		return nil, errors.New("failed to collect any metrics (is the node running?)")
+	}
+
+	var names []string
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		families = append(families, merged[name])
+	}
+	return families, nil
+}
+
+func getContainerMetrics(containerName string) (map[string]*dto.MetricFamily, error) {
+	adminClient, err := newAdminClient(containerName)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := adminClient.Stream("/metrics", &buf); err != nil {
+		return nil, err
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the metrics: %v", err)
+	}
+	return families, nil
+}
+
+func dumpMetricFamilies(families []*dto.MetricFamily) []*metricFamilyDump {
+	dumps := make([]*metricFamilyDump, 0, len(families))
+	for _, family := range families {
+		familyDump := &metricFamilyDump{
+			Name: family.GetName(),
+			Help: family.GetHelp(),
+			Type: family.GetType().String(),
+		}
+		for _, metric := range family.Metric {
+			familyDump.Metrics = append(familyDump.Metrics, dumpMetric(metric))
+		}
+		dumps = append(dumps, familyDump)
+	}
+	return dumps
+}
+
+func dumpMetric(metric *dto.Metric) *metricDump {
+	dump := &metricDump{Labels: make(map[string]string)}
+	for _, label := range metric.Label {
+		dump.Labels[label.GetName()] = label.GetValue()
+	}
+	switch {
+	case metric.Counter != nil:
+		dump.Value = float64Ptr(metric.Counter.GetValue())
+	case metric.Gauge != nil:
+		dump.Value = float64Ptr(metric.Gauge.GetValue())
+	case metric.Untyped != nil:
+		dump.Value = float64Ptr(metric.Untyped.GetValue())
+	case metric.Histogram != nil:
+		count := metric.Histogram.GetSampleCount()
+		dump.Count = &count
+		dump.Sum = float64Ptr(metric.Histogram.GetSampleSum())
+		dump.Buckets = make(map[string]uint64)
+		for _, bucket := range metric.Histogram.Bucket {
+			dump.Buckets[strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64)] = bucket.GetCumulativeCount()
+		}
+	case metric.Summary != nil:
+		count := metric.Summary.GetSampleCount()
+		dump.Count = &count
+		dump.Sum = float64Ptr(metric.Summary.GetSampleSum())
+		dump.Quantiles = make(map[string]float64)
+		for _, quantile := range metric.Summary.Quantile {
+			dump.Quantiles[strconv.FormatFloat(quantile.GetQuantile(), 'g', -1, 64)] = quantile.GetValue()
+		}
+	}
+	return dump
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/cmd/runner"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
+	"github.com/forta-network/forta-node/services/scanner/replay"
+	"github.com/forta-network/forta-node/store"
+	"github.com/spf13/cobra"
+)
+
+const replayStatusInterval = 5 * time.Second
+
+func handleFortaReplay(cmd *cobra.Command, args []string) error {
+	chainID, err := cmd.Flags().GetInt("chain")
+	if err != nil {
+		return err
+	}
+	fromBlock, err := cmd.Flags().GetUint64("from")
+	if err != nil {
+		return err
+	}
+	toBlock, err := cmd.Flags().GetUint64("to")
+	if err != nil {
+		return err
+	}
+	agentIDs, err := cmd.Flags().GetStringSlice("agent")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if toBlock == 0 || fromBlock > toBlock {
+		return errors.New("--from must be less than or equal to --to")
+	}
+
+	if err := checkReplayChain(chainID, toBlock); err != nil {
+		return err
+	}
+	agents, err := getReplayAgents(chainID, agentIDs)
+	if err != nil {
+		return err
+	}
+
+	// create the admin token and the certificate before the containers so that they are readable by the cli
+	if _, err := admin.EnsureToken(cfg.FortaDir); err != nil {
+		return err
+	}
+	if err := apisecurity.EnsureCertificate(cfg.FortaDir, cfg.APISecurity); err != nil {
+		return err
+	}
+	if err := config.WriteReplayFile(cfg.FortaDir, config.ReplayConfig{
+		ChainID:   chainID,
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Agents:    agents,
+	}); err != nil {
+		return fmt.Errorf("failed to write the replay config: %v", err)
+	}
+	defer config.RemoveReplayFile(cfg.FortaDir)
+
+	yellowBold("Replaying blocks %d-%d on chain %d with %d agent(s)\n", fromBlock, toBlock, chainID, len(agents))
+	done := make(chan *replay.Status, 1)
+	go watchReplay(done)
+	runner.Run(cfg)
+
+	var status *replay.Status
+	select {
+	case status = <-done:
+	default:
+	}
+	b, err := ioutil.ReadFile(config.ReplayFindingsFilePath(cfg.FortaDir))
+	if err != nil {
+		return fmt.Errorf("failed to read the findings: %v", err)
+	}
+	if err := ioutil.WriteFile(output, b, 0644); err != nil {
+		return fmt.Errorf("failed to write the findings: %v", err)
+	}
+	if status == nil {
+		yellowBold("Replay was interrupted - wrote the findings so far to %s\n", output)
+		return nil
+	}
+	greenBold("Replay is done - wrote %d finding(s) to %s\n", status.Findings, output)
+	return nil
+}
+
+// checkReplayChain checks if the scan endpoint serves the chain and has the blocks.
+func checkReplayChain(chainID int, toBlock uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, cfg.Scan.JsonRpc.Url)
+	if err != nil {
+		return fmt.Errorf("failed to dial the scan json-rpc api: %v", err)
+	}
+	defer client.Close()
+	endpointChainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the chain id from the scan json-rpc api: %v", err)
+	}
+	if endpointChainID.Int64() != int64(chainID) {
+		return fmt.Errorf("scan.jsonRpc.url is for chain %s but the replay is for chain %d", endpointChainID, chainID)
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block number: %v", err)
+	}
+	if toBlock > head {
+		return fmt.Errorf("--to is after the latest block %d", head)
+	}
+	return nil
+}
+
+// getReplayAgents finds the agents in the local agent definitions first and then in the registry.
+// All local agents for the chain are replayed if no agents are specified.
+func getReplayAgents(chainID int, agentIDs []string) ([]*config.AgentConfig, error) {
+	var localAgents []*config.AgentConfig
+	b, err := ioutil.ReadFile(path.Join(cfg.FortaDir, config.DefaultAgentsFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read the local agent definitions: %v", err)
+	}
+	if err == nil {
+		localAgents, err = config.ParseLocalAgentDefinitions(b, chainID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(agentIDs) == 0 {
+		if len(localAgents) == 0 {
+			return nil, fmt.Errorf("please specify the agents with --agent or define them in %s", config.DefaultAgentsFileName)
+		}
+		return localAgents, nil
+	}
+
+	var (
+		agents []*config.AgentConfig
+		reg    store.RegistryStore
+	)
+	for _, agentID := range agentIDs {
+		if agent := findAgentConfig(localAgents, agentID); agent != nil {
+			agents = append(agents, agent)
+			continue
+		}
+		if reg == nil {
+			ethClient, err := ethereum.NewStreamEthClient(context.Background(), "registry", cfg.Registry.JsonRpc.Url)
+			if err != nil {
+				return nil, err
+			}
+			reg, err = store.NewRegistryStore(context.Background(), cfg, ethClient)
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize registry: %v", err)
+			}
+		}
+		agent, err := reg.FindAgentGlobally(agentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the agent: %v", err)
+		}
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
+
+func findAgentConfig(agents []*config.AgentConfig, agentID string) *config.AgentConfig {
+	for _, agent := range agents {
+		if agent.ID == agentID {
+			return agent
+		}
+	}
+	return nil
+}
+
+// watchReplay reports the progress and stops the node after the replay is done.
+func watchReplay(done chan<- *replay.Status) {
+	var lastBlock uint64
+	for range time.Tick(replayStatusInterval) {
+		adminClient, err := newAdminClient(config.DockerScannerContainerName)
+		if err != nil {
+			continue // not started yet
+		}
+		var status replay.Status
+		if err := adminClient.Do(http.MethodGet, "/replay", nil, &status); err != nil {
+			continue
+		}
+		if status.LastBlock != lastBlock {
+			lastBlock = status.LastBlock
+			whiteBold("Replayed block %d/%d (%d finding(s))\n", status.LastBlock, status.ToBlock, status.Findings)
+		}
+		if len(status.Error) > 0 {
+			redBold("Replay failed: %s\n", status.Error)
+			services.InterruptMainContext()
+			return
+		}
+		if status.Done {
+			done <- &status
+			services.InterruptMainContext()
+			return
+		}
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 
 	"github.com/forta-network/forta-core-go/registry"
-	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/cmd/runner"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/admin"
+	"github.com/forta-network/forta-node/signer"
 	"github.com/forta-network/forta-node/store"
 	"github.com/spf13/cobra"
 )
@@ -18,9 +21,28 @@ var (
 )
 
 func handleFortaRun(cmd *cobra.Command, args []string) error {
+	// a replay may have been interrupted without cleaning up
+	if err := config.RemoveReplayFile(cfg.FortaDir); err != nil {
+		return fmt.Errorf("failed to remove the replay config: %v", err)
+	}
+	if signer.SignsTransactionsOnly(cfg.Signer.Type) {
+		return fmt.Errorf("the %s signer can not sign the alert batches - please use the keystore, an hsm or web3signer to run the node", cfg.Signer.Type)
+	}
+	if cfg.Signer.Type == signer.TypeKeystore {
+		if err := requirePassphrase(); err != nil {
+			return err
+		}
+	}
 	if err := checkScannerState(); err != nil {
 		return err
 	}
+	// create the admin token and the certificate before the containers so that they are readable by the cli
+	if _, err := admin.EnsureToken(cfg.FortaDir); err != nil {
+		return err
+	}
+	if err := apisecurity.EnsureCertificate(cfg.FortaDir, cfg.APISecurity); err != nil {
+		return err
+	}
 	runner.Run(cfg)
 	return nil
 }
@@ -31,11 +53,12 @@ func checkScannerState() error {
 		return nil
 	}
 
-	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	scannerSigner, err := newScannerSigner()
 	if err != nil {
-		return fmt.Errorf("failed to load scanner key: %v", err)
+		return fmt.Errorf("failed to load scanner signer: %v", err)
 	}
-	scannerAddressStr := scannerKey.Address.Hex()
+	scannerAddressStr := scannerSigner.Address().Hex()
+	scannerSigner.Close()
 
 	registry, err := store.GetRegistryClient(context.Background(), cfg, registry.ClientConfig{
 		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
@@ -2,17 +2,22 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/fatih/color"
 	"github.com/forta-network/forta-core-go/clients/health"
-	"github.com/forta-network/forta-node/config"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +27,7 @@ const (
 	StatusFormatOneline = "oneline"
 	StatusFormatJSON    = "json"
 	StatusFormatCSV     = "csv"
+	StatusFormatTable   = "table"
 
 	StatusShowSummary   = "summary"
 	StatusShowImportant = "important"
@@ -51,11 +57,16 @@ func handleFortaStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// call the runner health server on localhost
-	allReports := health.NewClient().CheckHealth("forta", config.DefaultHealthPort)
+	allReports := checkNodeHealth()
 	sort.Slice(allReports, func(i, j int) bool {
 		return sort.StringsAreSorted([]string{allReports[i].Name, allReports[j].Name})
 	})
 
+	// the overview is built from all reports
+	if format == StatusFormatTable {
+		return formatReportsTable(allReports, getChainHead())
+	}
+
 	var reports health.Reports
 	for _, report := range allReports {
 		var shouldInclude bool
@@ -193,3 +204,140 @@ func writeColoredBall(w io.Writer, c color.Attribute) {
 	}
 	color.New(c).Fprint(w, ballPrefix)
 }
+
+// statusLaggingBlocks is how many blocks the scanner can be behind the chain head before it is
+// shown as lagging in the overview.
+const statusLaggingBlocks = 20
+
+type statusRow struct {
+	Component string
+	Status    health.Status
+	Details   string
+}
+
+// getChainHead gets the latest block number from the scan endpoint. The overview shows the
+// chain head as unknown if it is not available.
+func getChainHead() *big.Int {
+	if len(cfg.Scan.JsonRpc.Url) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, cfg.Scan.JsonRpc.Url)
+	if err != nil {
+		return nil
+	}
+	defer client.Close()
+	blockNumber, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil
+	}
+	return new(big.Int).SetUint64(blockNumber)
+}
+
+func formatReportsTable(reports health.Reports, chainHead *big.Int) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSTATUS\tDETAILS")
+	for _, row := range makeStatusRows(reports, chainHead, time.Now()) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.Component, row.Status, row.Details)
+	}
+	return w.Flush()
+}
+
+func makeStatusRows(reports health.Reports, chainHead *big.Int, now time.Time) []*statusRow {
+	if docker, ok := reports.GetByName("docker"); ok {
+		return []*statusRow{{Component: "docker", Status: docker.Status, Details: docker.Details}}
+	}
+	return append([]*statusRow{
+		makeChainStatusRow(reports, chainHead),
+		makeAgentsStatusRow(reports),
+		makePublisherStatusRow(reports, now),
+	}, makeContainerStatusRows(reports)...)
+}
+
+func makeChainStatusRow(reports health.Reports, chainHead *big.Int) *statusRow {
+	row := &statusRow{Component: "chain", Status: health.StatusUnknown}
+	head := "unknown"
+	if chainHead != nil {
+		head = chainHead.String()
+	}
+	scanned := "unknown"
+	lastBlock, ok := reports.NameContains("block-feed.last-block")
+	if ok && len(lastBlock.Details) > 0 {
+		scanned = lastBlock.Details
+	}
+	row.Details = fmt.Sprintf("head %s, scanned %s", head, scanned)
+
+	scannedBlock, ok := new(big.Int).SetString(scanned, 10)
+	if chainHead == nil || !ok {
+		return row
+	}
+	behind := new(big.Int).Sub(chainHead, scannedBlock)
+	if behind.Sign() < 0 {
+		behind.SetInt64(0)
+	}
+	row.Details = fmt.Sprintf("%s (%s blocks behind)", row.Details, behind)
+	row.Status = health.StatusOK
+	if behind.Cmp(big.NewInt(statusLaggingBlocks)) > 0 {
+		row.Status = health.StatusLagging
+	}
+	return row
+}
+
+func makeAgentsStatusRow(reports health.Reports) *statusRow {
+	total, ok := reports.NameContains("agent-pool.agents.total")
+	if !ok {
+		return &statusRow{Component: "agents", Status: health.StatusUnknown, Details: "no agent pool report"}
+	}
+	row := &statusRow{Component: "agents", Status: total.Status}
+	ready := "unknown"
+	if report, ok := reports.NameContains("agent-pool.agents.ready"); ok {
+		ready = report.Details
+	}
+	row.Details = fmt.Sprintf("%s/%s ready", ready, total.Details)
+	if report, ok := reports.NameContains("agent-pool.agents.lagging"); ok && report.Details != "0" {
+		row.Details = fmt.Sprintf("%s, %s lagging", row.Details, report.Details)
+		row.Status = health.StatusLagging
+	}
+	if ready != "unknown" && ready != total.Details && row.Status == health.StatusOK {
+		row.Status = health.StatusFailing
+	}
+	return row
+}
+
+func makePublisherStatusRow(reports health.Reports, now time.Time) *statusRow {
+	row := &statusRow{Component: "publisher", Status: health.StatusOK}
+	queueDepth := "unknown"
+	if report, ok := reports.NameContains("publisher.batch-queue.depth"); ok {
+		queueDepth = report.Details
+	}
+	lastBatch := "never"
+	if report, ok := reports.NameContains("publisher.event.batch-publish.time"); ok && len(report.Details) > 0 {
+		lastBatch = report.Details
+		if t, err := time.Parse(time.RFC3339, report.Details); err == nil {
+			lastBatch = fmt.Sprintf("%s ago", now.Sub(t).Truncate(time.Second))
+		}
+	}
+	row.Details = fmt.Sprintf("queue depth %s, last batch %s", queueDepth, lastBatch)
+	if report, ok := reports.NameContains("publisher.event.batch-publish.error"); ok && len(report.Details) > 0 {
+		row.Details = fmt.Sprintf("%s, error: %s", row.Details, report.Details)
+		row.Status = health.StatusFailing
+	}
+	return row
+}
+
+// makeContainerStatusRows makes a row per container from the container reports of the runner.
+func makeContainerStatusRows(reports health.Reports) (rows []*statusRow) {
+	const prefix = "forta.container."
+	for _, report := range reports {
+		if !strings.HasPrefix(report.Name, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(report.Name, prefix)
+		if strings.Contains(name, ".") {
+			continue // not a container report
+		}
+		rows = append(rows, &statusRow{Component: name, Status: report.Status, Details: report.Details})
+	}
+	return
+}
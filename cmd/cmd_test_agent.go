@@ -0,0 +1,475 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	json_rpc "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/forta-network/forta-node/services/scanner"
+	"github.com/forta-network/forta-node/services/scanner/agentpool"
+	"github.com/spf13/cobra"
+)
+
+const (
+	testAgentContainerName = config.ContainerNamePrefix + "-test-agent"
+	testAgentBufferSize    = 100000
+	testAgentPollInterval  = 500 * time.Millisecond
+	testAgentFeedRate      = 1000 // ms
+)
+
+type testAgentFinding struct {
+	AlertID     string            `json:"alertId"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Severity    string            `json:"severity"`
+	Type        string            `json:"type"`
+	Protocol    string            `json:"protocol"`
+	Addresses   []string          `json:"addresses,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	BlockNumber string            `json:"blockNumber"`
+	TxHash      string            `json:"txHash,omitempty"`
+}
+
+// testAgentSender collects the findings of the agent instead of signing and publishing them.
+type testAgentSender struct {
+	findings []*testAgentFinding
+	results  map[string]bool
+	mu       sync.Mutex
+}
+
+func (sender *testAgentSender) SignAlertAndNotify(rt *clients.AgentRoundTrip, alert *protocol.Alert, chainID, blockNumber string, ts *domain.TrackingTimestamps) error {
+	finding := &testAgentFinding{
+		AlertID:     alert.Finding.AlertId,
+		Name:        alert.Finding.Name,
+		Description: alert.Finding.Description,
+		Severity:    alert.Finding.Severity.String(),
+		Type:        alert.Finding.Type.String(),
+		Protocol:    alert.Finding.Protocol,
+		Addresses:   alert.Finding.Addresses,
+		Metadata:    alert.Finding.Metadata,
+		BlockNumber: blockNumber,
+	}
+	if rt.EvalTxRequest != nil {
+		finding.TxHash = rt.EvalTxRequest.Event.Transaction.Hash
+	}
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	sender.findings = append(sender.findings, finding)
+	sender.results[roundTripRequestID(rt)] = true
+	return nil
+}
+
+func (sender *testAgentSender) NotifyWithoutAlert(rt *clients.AgentRoundTrip, ts *domain.TrackingTimestamps) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	sender.results[roundTripRequestID(rt)] = true
+	return nil
+}
+
+func (sender *testAgentSender) Results() int {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	return len(sender.results)
+}
+
+func (sender *testAgentSender) Findings() []*testAgentFinding {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	return sender.findings
+}
+
+func roundTripRequestID(rt *clients.AgentRoundTrip) string {
+	if rt.EvalTxRequest != nil {
+		return rt.EvalTxRequest.RequestId
+	}
+	return rt.EvalBlockRequest.RequestId
+}
+
+func handleFortaTestAgent(cmd *cobra.Command, args []string) error {
+	image, err := cmd.Flags().GetString("image")
+	if err != nil {
+		return err
+	}
+	blockNumber, err := cmd.Flags().GetUint64("block")
+	if err != nil {
+		return err
+	}
+	txHash, err := cmd.Flags().GetString("tx")
+	if err != nil {
+		return err
+	}
+	agentID, err := cmd.Flags().GetString("agent-id")
+	if err != nil {
+		return err
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+	if (blockNumber == 0) == (len(txHash) == 0) {
+		return errors.New("exactly one of --block and --tx is required")
+	}
+	if format != StatusFormatPretty && format != StatusFormatJSON {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if len(txHash) > 0 {
+		txHash = strings.ToLower(txHash)
+		blockNumber, err = getTxBlockNumber(ctx, txHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	agentCfg := config.AgentConfig{
+		ID:      agentID,
+		Image:   image,
+		IsLocal: true,
+	}
+	sender, err := runTestAgent(ctx, agentCfg, blockNumber, txHash)
+	if err != nil {
+		return err
+	}
+
+	findings := sender.Findings()
+	switch format {
+	case StatusFormatPretty:
+		printTestAgentFindings(findings)
+	case StatusFormatJSON:
+		if findings == nil {
+			findings = []*testAgentFinding{}
+		}
+		b, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(b))
+	}
+	return nil
+}
+
+// getTxBlockNumber finds the block of the transaction from the scan endpoint.
+func getTxBlockNumber(ctx context.Context, txHash string) (uint64, error) {
+	client, err := ethclient.DialContext(ctx, cfg.Scan.JsonRpc.Url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dial the scan json-rpc api: %v", err)
+	}
+	defer client.Close()
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the transaction receipt: %v", err)
+	}
+	return receipt.BlockNumber.Uint64(), nil
+}
+
+// runTestAgent starts the agent container, attaches it to an agent pool and sends it the events
+// of the block through the same feeds and analyzers which the scanner uses.
+func runTestAgent(ctx context.Context, agentCfg config.AgentConfig, blockNumber uint64, txHash string) (*testAgentSender, error) {
+	token, err := generateTestAgentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// serve the json-rpc api to the agent like the proxy of the node does
+	var agentIPAddr atomic.Value
+	agentIPAddr.Store("")
+	rpcHandler, err := json_rpc.NewUpstreamRouter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the json-rpc handler: %v", err)
+	}
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the json-rpc requests: %v", err)
+	}
+	rpcServer := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// only the agent container can use the api
+			host, _, _ := net.SplitHostPort(req.RemoteAddr)
+			if host != agentIPAddr.Load().(string) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			rpcHandler.ServeHTTP(w, req)
+		}),
+	}
+	go rpcServer.Serve(listener)
+	defer rpcServer.Close()
+
+	runtimeClient, err := clients.NewRuntimeClient("", cfg.ContainerRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
+	}
+	if err := runtimeClient.EnsureLocalImage(ctx, fmt.Sprintf("agent %s", agentCfg.ID), agentCfg.Image); err != nil {
+		return nil, err
+	}
+	nwID, err := runtimeClient.CreatePublicNetwork(ctx, testAgentContainerName)
+	if err != nil {
+		return nil, err
+	}
+	defer runtimeClient.RemoveNetworkByName(context.Background(), testAgentContainerName)
+	agentContainer, err := runtimeClient.StartContainer(ctx, clients.DockerContainerConfig{
+		Name:      testAgentContainerName,
+		Image:     agentCfg.Image,
+		NetworkID: nwID,
+		Env: map[string]string{
+			config.EnvJsonRpcHost:    "host.docker.internal",
+			config.EnvJsonRpcPort:    strconv.Itoa(listener.Addr().(*net.TCPAddr).Port),
+			config.EnvAgentGrpcPort:  agentCfg.GrpcPort(),
+			config.EnvAgentGrpcToken: token,
+		},
+		Ports: map[string]string{
+			"127.0.0.1:": agentCfg.GrpcPort(),
+		},
+		DialHost: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		runtimeClient.StopContainer(context.Background(), agentContainer.ID)
+		runtimeClient.RemoveContainer(context.Background(), agentContainer.ID)
+	}()
+	agentTarget, err := getTestAgentTarget(ctx, runtimeClient, agentContainer.ID, &agentIPAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// attach the agent to the pool like the supervisor does after starting the container
+	scanCfg := cfg.Scan
+	scanCfg.AgentBuffer = config.AgentBufferConfig{Size: testAgentBufferSize}
+	scanCfg.AgentBuffers = nil
+	msgClient := clients.NewMessageClient("test-agent", config.MessagingConfig{Type: config.MessagingTypeLocal})
+	agentPool := agentpool.NewAgentPool(ctx, scanCfg, msgClient)
+	agentPool.SetAgentTarget(func(config.AgentConfig) string {
+		return agentTarget
+	})
+	msgClient.Subscribe(messaging.SubjectAgentsActionRun, messaging.AgentsHandler(func(payload messaging.AgentPayload) error {
+		for i := range payload {
+			payload[i].Token = token
+		}
+		msgClient.Publish(messaging.SubjectAgentsStatusRunning, payload)
+		return nil
+	}))
+	msgClient.Publish(messaging.SubjectAgentsVersionsLatest, messaging.AgentPayload{agentCfg})
+	if err := waitTestAgentReady(ctx, agentPool); err != nil {
+		return nil, err
+	}
+
+	sender := &testAgentSender{results: make(map[string]bool)}
+	txCh := make(chan *domain.TransactionEvent)
+	blockCh := make(chan *domain.BlockEvent)
+	txAnalyzer, err := scanner.NewTxAnalyzerService(ctx, scanner.TxAnalyzerServiceConfig{
+		TxChannel:   txCh,
+		AlertSender: sender,
+		AgentPool:   agentPool,
+		MsgClient:   msgClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	blockAnalyzer, err := scanner.NewBlockAnalyzerService(ctx, scanner.BlockAnalyzerServiceConfig{
+		BlockChannel: blockCh,
+		AlertSender:  sender,
+		AgentPool:    agentPool,
+		MsgClient:    msgClient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	txAnalyzer.Start()
+	blockAnalyzer.Start()
+
+	dispatched, err := feedTestAgent(ctx, txCh, blockCh, blockNumber, txHash)
+	if err != nil {
+		return nil, err
+	}
+	yellowBold("Sent %d request(s) to the agent - waiting for the results\n", dispatched)
+	if err := waitTestAgentResults(ctx, agentPool, sender, dispatched); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
+
+// feedTestAgent fetches the block and sends the block and the transaction events to the analyzers.
+// If the tx hash is specified, only that transaction is sent.
+func feedTestAgent(ctx context.Context, txCh chan<- *domain.TransactionEvent, blockCh chan<- *domain.BlockEvent, blockNumber uint64, txHash string) (int, error) {
+	ethClient, err := ethereum.NewStreamEthClient(ctx, "chain", cfg.Scan.JsonRpc.Url)
+	if err != nil {
+		return 0, err
+	}
+	var traceClient ethereum.Client
+	if cfg.Trace.Enabled {
+		if traceClient, err = ethereum.NewStreamEthClient(ctx, "trace", cfg.Trace.JsonRpc.Url); err != nil {
+			return 0, err
+		}
+	}
+	blockFeed, err := feeds.NewBlockFeed(ctx, ethClient, traceClient, feeds.BlockFeedConfig{
+		ChainID: config.ParseBigInt(cfg.ChainID),
+		Tracing: cfg.Trace.Enabled,
+	})
+	if err != nil {
+		return 0, err
+	}
+	txFeed, err := feeds.NewTransactionFeed(ctx, ethClient, blockFeed, nil, 10)
+	if err != nil {
+		return 0, err
+	}
+
+	var dispatched int64
+	feedErr := make(chan error, 1)
+	go func() {
+		feedErr <- txFeed.ForEachTransaction(func(evt *domain.BlockEvent) error {
+			if len(txHash) > 0 {
+				return nil
+			}
+			blockCh <- evt
+			atomic.AddInt64(&dispatched, 1)
+			return nil
+		}, func(evt *domain.TransactionEvent) error {
+			if len(txHash) > 0 && !strings.EqualFold(evt.Transaction.Hash, txHash) {
+				return nil
+			}
+			txCh <- evt
+			atomic.AddInt64(&dispatched, 1)
+			return nil
+		})
+	}()
+	// the rate limit gives the tx feed the time to subscribe before the block is fetched
+	blockFeed.StartRange(int64(blockNumber), int64(blockNumber), testAgentFeedRate)
+	if err := <-feedErr; err != nil {
+		return 0, fmt.Errorf("failed to get the block %d: %v", blockNumber, err)
+	}
+	return int(atomic.LoadInt64(&dispatched)), nil
+}
+
+// getTestAgentTarget finds the published gRPC port and the network address of the agent container.
+func getTestAgentTarget(ctx context.Context, runtimeClient clients.DockerClient, containerID string, agentIPAddr *atomic.Value) (string, error) {
+	container, err := runtimeClient.GetContainerByID(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if container.NetworkSettings != nil {
+		for _, network := range container.NetworkSettings.Networks {
+			agentIPAddr.Store(network.IPAddress)
+		}
+	}
+	for _, port := range container.Ports {
+		if strconv.Itoa(int(port.PrivatePort)) == config.AgentGrpcPort && port.PublicPort != 0 {
+			return fmt.Sprintf("127.0.0.1:%d", port.PublicPort), nil
+		}
+	}
+	return "", errors.New("the agent container does not expose the grpc port")
+}
+
+func waitTestAgentReady(ctx context.Context, agentPool *agentpool.AgentPool) error {
+	ticker := time.NewTicker(testAgentPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for the agent to be ready")
+		case <-ticker.C:
+		}
+		for _, status := range agentPool.AgentStatuses() {
+			if status.Ready {
+				return nil
+			}
+		}
+	}
+}
+
+// waitTestAgentResults waits until the agent returns a result or fails for each request.
+func waitTestAgentResults(ctx context.Context, agentPool *agentpool.AgentPool, sender *testAgentSender, dispatched int) error {
+	ticker := time.NewTicker(testAgentPollInterval)
+	defer ticker.Stop()
+	for {
+		var (
+			failed  int
+			lastErr string
+		)
+		for _, status := range agentPool.AgentStatuses() {
+			if status.Closed {
+				return errors.New("the agent has stopped")
+			}
+			failed += int(status.TxErrors + status.BlockErrors)
+			lastErr = status.LastError
+		}
+		if sender.Results()+failed >= dispatched {
+			if failed > 0 {
+				redBold("Agent failed to process %d request(s) - last error: %s\n", failed, lastErr)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the results: received %d/%d", sender.Results(), dispatched)
+		case <-ticker.C:
+		}
+	}
+}
+
+func printTestAgentFindings(findings []*testAgentFinding) {
+	if len(findings) == 0 {
+		greenBold("No findings\n")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALERT ID\tSEVERITY\tTYPE\tBLOCK\tTX\tNAME")
+	for _, finding := range findings {
+		tx := finding.TxHash
+		if len(tx) == 0 {
+			tx = "-"
+		}
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.AlertID, finding.Severity, finding.Type, finding.BlockNumber, tx, finding.Name,
+		)
+	}
+	w.Flush()
+	for _, finding := range findings {
+		fmt.Printf("\n%s: %s\n", finding.AlertID, finding.Description)
+		var keys []string
+		for k := range finding.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, finding.Metadata[k])
+		}
+	}
+}
+
+func generateTestAgentToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the agent token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/spf13/cobra"
+)
+
+// top sort orders
+const (
+	TopSortAgent      = "agent"
+	TopSortThroughput = "throughput"
+	TopSortLatency    = "latency"
+	TopSortBuffer     = "buffer"
+	TopSortFindings   = "findings"
+)
+
+// terminal control sequences
+const (
+	termAltScreenOn  = "\x1b[?1049h"
+	termAltScreenOff = "\x1b[?1049l"
+	termCursorHide   = "\x1b[?25l"
+	termCursorShow   = "\x1b[?25h"
+	termClear        = "\x1b[H\x1b[2J"
+)
+
+// topAgentRow is the live view of an agent replica.
+type topAgentRow struct {
+	*poolagent.Status
+	TxPerSec        float64
+	BlocksPerSec    float64
+	FindingsPerMin  float64
+	RatesCalculated bool
+}
+
+// topSnapshot keeps the previous agent statuses to calculate the rates from the counters.
+type topSnapshot struct {
+	statuses map[string]*poolagent.Status
+	takenAt  time.Time
+}
+
+func handleFortaTop(cmd *cobra.Command, args []string) error {
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+	sortBy, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		return err
+	}
+	switch sortBy {
+	case TopSortAgent, TopSortThroughput, TopSortLatency, TopSortBuffer, TopSortFindings:
+	default:
+		return fmt.Errorf("unsupported sort order: %s", sortBy)
+	}
+	if interval < time.Second {
+		return errors.New("--interval must be at least a second")
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	fmt.Fprint(os.Stdout, termAltScreenOn+termCursorHide)
+	defer fmt.Fprint(os.Stdout, termCursorShow+termAltScreenOff)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var prev *topSnapshot
+	for {
+		var screen bytes.Buffer
+		prev = renderTop(&screen, prev, interval, sortBy)
+		fmt.Fprint(os.Stdout, termClear)
+		screen.WriteTo(os.Stdout)
+
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderTop collects the latest state of the node and writes the dashboard.
+func renderTop(w *bytes.Buffer, prev *topSnapshot, interval time.Duration, sortBy string) *topSnapshot {
+	now := time.Now()
+	fmt.Fprintf(w, "forta top - %s (every %s, sorted by %s, ctrl+c to quit)\n\n", now.Format("15:04:05"), interval, sortBy)
+
+	reports := checkNodeHealth()
+	if docker, ok := reports.GetByName("docker"); ok {
+		fmt.Fprintf(w, "docker: %s %s\n", docker.Status, docker.Details)
+		return prev
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range []*statusRow{
+		makeChainStatusRow(reports, getChainHead()),
+		makeAgentsStatusRow(reports),
+		makePublisherStatusRow(reports, now),
+	} {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", color.New(color.Bold).Sprint(row.Component), colorTopStatus(row.Status), row.Details)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	statuses, err := getAgentStatuses()
+	if err != nil {
+		fmt.Fprintln(w, color.YellowString("Failed to get the agent statuses: %v", err))
+		return prev
+	}
+	rows := makeTopAgentRows(statuses, prev, now)
+	sortTopAgentRows(rows, sortBy)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT\tREPLICA\tSTATUS\tTX/S\tBLOCKS/S\tTX LATENCY\tBLOCK LATENCY\tTX BUFFER\tBLOCK BUFFER\tFINDINGS/MIN\tERRORS")
+	for _, row := range rows {
+		fmt.Fprintf(
+			tw, "%s\t%d\t%s\t%s\t%s\t%.0fms\t%.0fms\t%d/%d\t%d/%d\t%s\t%d\n",
+			utils.ShortenString(row.ID, 12), row.Replica, topReplicaStatus(row.Status),
+			formatTopRate(row, row.TxPerSec), formatTopRate(row, row.BlocksPerSec),
+			row.TxLatencyMs, row.BlockLatencyMs,
+			row.TxBuffer, row.TxBufferLimit, row.BlockBuffer, row.BlockBufferLimit,
+			formatTopRate(row, row.FindingsPerMin), row.TxErrors+row.BlockErrors,
+		)
+	}
+	tw.Flush()
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "\nNo agents are running")
+	}
+
+	snapshot := &topSnapshot{statuses: make(map[string]*poolagent.Status), takenAt: now}
+	for _, status := range statuses {
+		snapshot.statuses[status.ContainerName] = status
+	}
+	return snapshot
+}
+
+// makeTopAgentRows calculates the rates of the agent replicas since the previous snapshot.
+func makeTopAgentRows(statuses []*poolagent.Status, prev *topSnapshot, now time.Time) []*topAgentRow {
+	rows := make([]*topAgentRow, 0, len(statuses))
+	for _, status := range statuses {
+		row := &topAgentRow{Status: status}
+		rows = append(rows, row)
+		if prev == nil {
+			continue
+		}
+		prevStatus, ok := prev.statuses[status.ContainerName]
+		elapsed := now.Sub(prev.takenAt).Seconds()
+		// the counters restart if the agent is attached again
+		if !ok || elapsed <= 0 || status.TxProcessed < prevStatus.TxProcessed ||
+			status.BlockProcessed < prevStatus.BlockProcessed || status.Findings < prevStatus.Findings {
+			continue
+		}
+		row.TxPerSec = float64(status.TxProcessed-prevStatus.TxProcessed) / elapsed
+		row.BlocksPerSec = float64(status.BlockProcessed-prevStatus.BlockProcessed) / elapsed
+		row.FindingsPerMin = float64(status.Findings-prevStatus.Findings) / elapsed * 60
+		row.RatesCalculated = true
+	}
+	return rows
+}
+
+func sortTopAgentRows(rows []*topAgentRow, sortBy string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if ka, kb := topSortKey(a, sortBy), topSortKey(b, sortBy); ka != kb {
+			return ka > kb
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Replica < b.Replica
+	})
+}
+
+// topSortKey returns the value which the rows are sorted by in descending order.
+func topSortKey(row *topAgentRow, sortBy string) float64 {
+	switch sortBy {
+	case TopSortThroughput:
+		return row.TxPerSec + row.BlocksPerSec
+	case TopSortLatency:
+		return row.TxLatencyMs + row.BlockLatencyMs
+	case TopSortBuffer:
+		return float64(row.TxBuffer + row.BlockBuffer)
+	case TopSortFindings:
+		return row.FindingsPerMin
+	default:
+		return 0
+	}
+}
+
+// topReplicaStatus colors all statuses so that the escape codes do not misalign the columns.
+func topReplicaStatus(status *poolagent.Status) string {
+	switch {
+	case status.Closed:
+		return color.RedString("closed")
+	case status.Paused:
+		return color.YellowString("paused")
+	case status.Ready && !status.Serving:
+		return color.YellowString("unhealthy")
+	case status.Ready:
+		return color.GreenString("ready")
+	default:
+		return color.CyanString("starting")
+	}
+}
+
+func colorTopStatus(status health.Status) string {
+	switch status {
+	case health.StatusOK:
+		return color.GreenString(string(status))
+	case health.StatusDown:
+		return color.RedString(string(status))
+	case health.StatusFailing, health.StatusLagging:
+		return color.YellowString(string(status))
+	default:
+		return color.BlueString(string(status))
+	}
+}
+
+// formatTopRate shows the rate only after it can be calculated from two snapshots.
+func formatTopRate(row *topAgentRow, rate float64) string {
+	if !row.RatesCalculated {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", rate)
+}
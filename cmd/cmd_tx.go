@@ -4,16 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fatih/color"
+	"github.com/forta-network/forta-core-go/contracts/contract_scanner_registry"
 	"github.com/forta-network/forta-core-go/registry"
 	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/signer"
 	"github.com/forta-network/forta-node/store"
 	"github.com/spf13/cobra"
 )
 
+// scannerRegistryChainID is the chain of the scanner registry contract (Polygon).
+const scannerRegistryChainID = 137
+
+// newScannerSigner creates the signer in the config. The scanner key is decrypted from the
+// keystore unless an HSM or a Ledger is used.
+func newScannerSigner() (signer.Signer, error) {
+	return signer.New(cfg.Signer, func() (*keystore.Key, error) {
+		if err := requirePassphrase(); err != nil {
+			return nil, err
+		}
+		return security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	})
+}
+
 func handleFortaRegister(cmd *cobra.Command, args []string) error {
 	ownerAddressStr, err := cmd.Flags().GetString("owner-address")
 	if err != nil {
@@ -23,30 +45,22 @@ func handleFortaRegister(cmd *cobra.Command, args []string) error {
 		return errors.New("invalid owner address provided")
 	}
 
-	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	scannerSigner, err := newScannerSigner()
 	if err != nil {
-		return fmt.Errorf("failed to load scanner key: %v", err)
+		return fmt.Errorf("failed to load scanner signer: %v", err)
 	}
-	scannerPrivateKey := scannerKey.PrivateKey
-	scannerAddressStr := scannerKey.Address.Hex()
+	defer scannerSigner.Close()
+	scannerAddressStr := scannerSigner.Address().Hex()
 
 	if strings.EqualFold(scannerAddressStr, ownerAddressStr) {
 		redBold("Scanner and owner cannot be the same identity! Please provide a different wallet address of your own.\n")
 	}
 
-	registry, err := store.GetRegistryClient(context.Background(), cfg, registry.ClientConfig{
-		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
-		ENSAddress: cfg.ENSConfig.ContractAddress,
-		Name:       "registry-client",
-		PrivateKey: scannerPrivateKey,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
-	}
-
 	color.Yellow(fmt.Sprintf("Sending a transaction to register your scan node to chain %d...\n", cfg.ChainID))
 
-	txHash, err := registry.RegisterScanner(ownerAddressStr, int64(cfg.ChainID), "")
+	txHash, err := sendScannerRegistryTx(scannerSigner, func(reg *contract_scanner_registry.ScannerRegistryTransactor, opts *bind.TransactOpts) (*types.Transaction, error) {
+		return reg.Register(opts, common.HexToAddress(ownerAddressStr), big.NewInt(int64(cfg.ChainID)), "")
+	})
 	if err != nil && strings.Contains(err.Error(), "insufficient funds") {
 		yellowBold("This action requires Polygon (Mainnet) MATIC. Have you funded your address %s yet?\n", scannerAddressStr)
 	}
@@ -61,26 +75,18 @@ func handleFortaRegister(cmd *cobra.Command, args []string) error {
 }
 
 func handleFortaEnable(cmd *cobra.Command, args []string) error {
-	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	scannerSigner, err := newScannerSigner()
 	if err != nil {
-		return fmt.Errorf("failed to load scanner key: %v", err)
-	}
-	scannerPrivateKey := scannerKey.PrivateKey
-	scannerAddressStr := scannerKey.Address.Hex()
-
-	reg, err := store.GetRegistryClient(context.Background(), cfg, registry.ClientConfig{
-		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
-		ENSAddress: cfg.ENSConfig.ContractAddress,
-		Name:       "registry-client",
-		PrivateKey: scannerPrivateKey,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
+		return fmt.Errorf("failed to load scanner signer: %v", err)
 	}
+	defer scannerSigner.Close()
+	scannerAddressStr := scannerSigner.Address().Hex()
 
 	color.Yellow("Sending a transaction to enable your scan node...\n")
 
-	txHash, err := reg.EnableScanner(registry.ScannerPermissionSelf, scannerAddressStr)
+	txHash, err := sendScannerRegistryTx(scannerSigner, func(reg *contract_scanner_registry.ScannerRegistryTransactor, opts *bind.TransactOpts) (*types.Transaction, error) {
+		return reg.EnableScanner(opts, utils.ScannerIDHexToBigInt(scannerAddressStr), uint8(registry.ScannerPermissionSelf))
+	})
 	if err != nil && strings.Contains(err.Error(), "insufficient funds") {
 		yellowBold("This action requires Polygon (Mainnet) MATIC. Have you funded your address %s yet?\n", scannerAddressStr)
 	}
@@ -95,26 +101,18 @@ func handleFortaEnable(cmd *cobra.Command, args []string) error {
 }
 
 func handleFortaDisable(cmd *cobra.Command, args []string) error {
-	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
-	if err != nil {
-		return fmt.Errorf("failed to load scanner key: %v", err)
-	}
-	scannerPrivateKey := scannerKey.PrivateKey
-	scannerAddressStr := scannerKey.Address.Hex()
-
-	reg, err := store.GetRegistryClient(context.Background(), cfg, registry.ClientConfig{
-		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
-		ENSAddress: cfg.ENSConfig.ContractAddress,
-		Name:       "registry-client",
-		PrivateKey: scannerPrivateKey,
-	})
+	scannerSigner, err := newScannerSigner()
 	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
+		return fmt.Errorf("failed to load scanner signer: %v", err)
 	}
+	defer scannerSigner.Close()
+	scannerAddressStr := scannerSigner.Address().Hex()
 
 	color.Yellow("Sending a transaction to disable your scan node...\n")
 
-	txHash, err := reg.DisableScanner(registry.ScannerPermissionSelf, scannerAddressStr)
+	txHash, err := sendScannerRegistryTx(scannerSigner, func(reg *contract_scanner_registry.ScannerRegistryTransactor, opts *bind.TransactOpts) (*types.Transaction, error) {
+		return reg.DisableScanner(opts, utils.ScannerIDHexToBigInt(scannerAddressStr), uint8(registry.ScannerPermissionSelf))
+	})
 	if err != nil && strings.Contains(err.Error(), "insufficient funds") {
 		yellowBold("This action requires Polygon (Mainnet) MATIC. Have you funded your address %s yet?\n", scannerAddressStr)
 	}
@@ -127,3 +125,45 @@ func handleFortaDisable(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// sendScannerRegistryTx sends a scanner registry transaction which is signed by the scanner signer.
+func sendScannerRegistryTx(
+	scannerSigner signer.Signer,
+	send func(reg *contract_scanner_registry.ScannerRegistryTransactor, opts *bind.TransactOpts) (*types.Transaction, error),
+) (string, error) {
+	ctx := context.Background()
+	reg, err := store.GetRegistryClient(ctx, cfg, registry.ClientConfig{
+		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
+		ENSAddress: cfg.ENSConfig.ContractAddress,
+		Name:       "registry-client",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create registry client: %v", err)
+	}
+	ec, err := ethclient.DialContext(ctx, cfg.Registry.JsonRpc.Url)
+	if err != nil {
+		return "", fmt.Errorf("failed to dial the registry json-rpc api: %v", err)
+	}
+	defer ec.Close()
+	transactor, err := contract_scanner_registry.NewScannerRegistryTransactor(reg.RegistryContracts().ScannerRegistry, ec)
+	if err != nil {
+		return "", fmt.Errorf("failed to create contract transactor: %v", err)
+	}
+	opts := signer.NewTransactOpts(scannerSigner, big.NewInt(scannerRegistryChainID))
+	opts.Context = ctx
+	opts.GasPrice, err = ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get gas price suggestion: %v", err)
+	}
+	switch cfg.Signer.Type {
+	case signer.TypeLedger:
+		yellowBold("Please confirm the transaction on your Ledger device.\n")
+	case signer.TypeClef:
+		yellowBold("Please approve the transaction in Clef.\n")
+	}
+	tx, err := send(transactor, opts)
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
@@ -2,13 +2,18 @@ package json_rpc
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/forta-network/forta-core-go/clients/health"
-	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
 	jrp "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func initJsonRpcProxy(ctx context.Context, cfg config.Config) (*jrp.JsonRpcProxy, error) {
@@ -17,19 +22,40 @@ func initJsonRpcProxy(ctx context.Context, cfg config.Config) (*jrp.JsonRpcProxy
 
 func initServices(ctx context.Context, cfg config.Config) ([]services.Service, error) {
 	// can't dial localhost - need to dial host gateway from container
-	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
-	cfg.JsonRpcProxy.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.JsonRpcProxy.JsonRpc.Url)
+	jrp.ConvertToDockerHostURLs(&cfg)
 
 	proxy, err := initJsonRpcProxy(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	adminToken, err := admin.EnsureToken(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return nil, err
+	}
+	apiSec, err := apisecurity.Load(config.DefaultContainerFortaDirPath, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
+	adminAPI := admin.NewServer(ctx, adminToken, apiSec)
+	adminAPI.Handle("/usage", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, proxy.Usage())
+	})
+	adminAPI.Handle("/agents/logs", proxy.ServeAgentLogs)
+	adminAPI.HandleBusDeadLetters(messaging.DeadLetters)
+	adminAPI.HandleLogLevels()
+	adminAPI.Handle("/metrics", promhttp.Handler().ServeHTTP)
+	if cfg.Debug.Pprof {
+		adminAPI.HandlePprof(cfg.Debug.BlockProfileRate)
+	}
+
+	healthChecker := health.CheckerFrom(summarizeReports, proxy)
 	return []services.Service{
-		health.NewService(
-			ctx, "", healthutils.DefaultHealthServerErrHandler,
-			health.CheckerFrom(summarizeReports, proxy),
-		),
+		healthutils.NewHealthService(ctx, apiSec, healthChecker, healthutils.ReadinessCheck{
+			Name: "upstream", Check: proxy.CheckReady,
+		}),
+		healthutils.NewGrpcHealthService(ctx, apiSec, "json-rpc", healthChecker),
+		adminAPI,
 		proxy,
 	}, nil
 }
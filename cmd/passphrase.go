@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// passphraseFileErr is the error from reading the passphrase file during the initialization.
+var passphraseFileErr error
+
+// readPassphraseFile reads the passphrase from a file which must only be accessible by the owner.
+func readPassphraseFile(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase file: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("the passphrase file %s is accessible by other users - please do 'chmod 600 %s'", filePath, filePath)
+	}
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase file: %v", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// promptPassphrase asks for the passphrase if the cli is used from a terminal. It returns an
+// empty passphrase otherwise.
+func promptPassphrase(prompt string, confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", nil
+	}
+	passphrase, err := readPassword(fd, prompt)
+	if err != nil || !confirm || len(passphrase) == 0 {
+		return passphrase, err
+	}
+	repeated, err := readPassword(fd, "Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if repeated != passphrase {
+		return "", errors.New("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func readPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %v", err)
+	}
+	return string(b), nil
+}
+
+// requirePassphrase makes sure that the passphrase is set. It is asked interactively if it was
+// not provided with the flags or the env vars.
+func requirePassphrase() error {
+	if passphraseFileErr != nil {
+		return passphraseFileErr
+	}
+	if len(cfg.Passphrase) == 0 {
+		passphrase, err := promptPassphrase("Passphrase: ", false)
+		if err != nil {
+			return err
+		}
+		cfg.Passphrase = passphrase
+	}
+	if len(cfg.Passphrase) == 0 {
+		redBold("Your passphrase is not set. Please set it with FORTA_PASSPHRASE_FILE or FORTA_PASSPHRASE environment variable or provide it with the --passphrase-file flag.\n")
+		return errors.New("empty passhphrase")
+	}
+	return nil
+}
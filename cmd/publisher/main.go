@@ -2,6 +2,7 @@ package publisher
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/utils"
@@ -9,9 +10,12 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
 	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/forta-network/forta-node/signer"
 )
 
 func initServices(ctx context.Context, cfg config.Config) ([]services.Service, error) {
@@ -20,17 +24,31 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	cfg.Publish.IPFS.GatewayURL = utils.ConvertToDockerHostURL(cfg.Publish.IPFS.GatewayURL)
 	cfg.PrivateModeConfig.WebhookURL = utils.ConvertToDockerHostURL(cfg.PrivateModeConfig.WebhookURL)
 
-	p, err := publisher.NewPublisher(ctx, cfg)
+	scannerSigner, err := signer.Load(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the scanner signer: %v", err)
+	}
+	p, err := publisher.NewPublisher(ctx, cfg, scannerSigner)
 	if err != nil {
 		log.Errorf("Error while initializing Listener: %s", err.Error())
 		return nil, err
 	}
 
+	adminToken, err := admin.EnsureToken(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return nil, err
+	}
+	apiSec, err := apisecurity.Load(config.DefaultContainerFortaDirPath, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
+
+	healthChecker := health.CheckerFrom(summarizeReports, p)
 	return []services.Service{
-		health.NewService(
-			ctx, "", healthutils.DefaultHealthServerErrHandler,
-			health.CheckerFrom(summarizeReports, p),
-		),
+		healthutils.NewHealthService(ctx, apiSec, healthChecker, healthutils.ReadinessCheck{
+			Name: "publisher", Check: p.CheckReady,
+		}),
+		healthutils.NewGrpcHealthService(ctx, apiSec, "publisher", healthChecker),
 		p,
 	}, nil
 }
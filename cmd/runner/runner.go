@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
 	"github.com/forta-network/forta-node/services/runner"
 	"github.com/forta-network/forta-node/store"
 	log "github.com/sirupsen/logrus"
@@ -18,13 +20,24 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the image store: %v", err)
 	}
-	dockerClient, err := clients.NewDockerClient("runner")
+	dockerClient, err := clients.NewRuntimeClient("runner", cfg.ContainerRuntime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create the docker client: %v", err)
+		return nil, fmt.Errorf("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
 	}
-	globalDockerClient, err := clients.NewDockerClient("")
+	dockerClient.SetImageMirrors(cfg.Registry.Mirrors)
+	globalDockerClient, err := clients.NewRuntimeClient("", cfg.ContainerRuntime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create the docker client: %v", err)
+		return nil, fmt.Errorf("failed to create the %s client: %v", cfg.ContainerRuntime.Type, err)
+	}
+
+	// the cli creates the admin token and the certificate before starting the runner
+	adminToken, err := admin.ReadToken(cfg.FortaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the admin token: %v", err)
+	}
+	apiSec, err := apisecurity.Load(cfg.FortaDir, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
 	}
 
 	if cfg.Development {
@@ -32,7 +45,7 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	}
 
 	return []services.Service{
-		runner.NewRunner(ctx, cfg, imgStore, dockerClient, globalDockerClient),
+		runner.NewRunner(ctx, cfg, apiSec, imgStore, dockerClient, globalDockerClient),
 	}, nil
 }
 
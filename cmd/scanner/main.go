@@ -2,31 +2,47 @@ package scanner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/forta-network/forta-node/services/publisher"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethlog "github.com/ethereum/go-ethereum/log"
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/ethereum"
 	"github.com/forta-network/forta-core-go/feeds"
-	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/ethrpc"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
+	"github.com/forta-network/forta-node/services/graphql"
 	"github.com/forta-network/forta-node/services/registry"
 	"github.com/forta-network/forta-node/services/scanner"
 	"github.com/forta-network/forta-node/services/scanner/agentpool"
+	"github.com/forta-network/forta-node/services/scanner/blockfeed"
+	"github.com/forta-network/forta-node/services/scanner/replay"
+	"github.com/forta-network/forta-node/services/slareport"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/forta-network/forta-node/store"
+	"github.com/forta-network/forta-node/tracing"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const readinessTimeout = time.Second * 5
+
 func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, cfg config.Config) (*scanner.TxStreamService, feeds.BlockFeed, error) {
 	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
 	cfg.Registry.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Registry.JsonRpc.Url)
@@ -48,16 +64,22 @@ func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, c
 		rateLimit = time.NewTicker(time.Duration(cfg.Scan.BlockRateLimit) * time.Millisecond)
 	}
 
-	var maxAge time.Duration
+	// the old blocks are not skipped if the max age is not set (i.e. when replaying)
+	var maxAge *time.Duration
 	if cfg.Scan.BlockMaxAgeSeconds > 0 {
-		maxAge = time.Duration(cfg.Scan.BlockMaxAgeSeconds) * time.Second
-	}
-	blockFeed, err := feeds.NewBlockFeed(ctx, ethClient, traceClient, feeds.BlockFeedConfig{
-		ChainID:             chainID,
-		Tracing:             cfg.Trace.Enabled,
-		RateLimit:           rateLimit,
-		SkipBlocksOlderThan: &maxAge,
-		Offset:              config.GetBlockOffset(cfg.ChainID),
+		blockMaxAge := time.Duration(cfg.Scan.BlockMaxAgeSeconds) * time.Second
+		maxAge = &blockMaxAge
+	}
+	blockFeed, err := blockfeed.New(ctx, ethClient, traceClient, blockfeed.Config{
+		BlockFeedConfig: feeds.BlockFeedConfig{
+			ChainID:             chainID,
+			Tracing:             cfg.Trace.Enabled,
+			RateLimit:           rateLimit,
+			SkipBlocksOlderThan: maxAge,
+			Offset:              cfg.ChainSettings.Offset,
+		},
+		FetchTimeout:  time.Duration(cfg.Scan.BlockFetch.TimeoutSeconds) * time.Second,
+		RequireTraces: cfg.Scan.BlockFetch.RequireTraces,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -66,7 +88,7 @@ func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, c
 	txStream, err := scanner.NewTxStreamService(ctx, ethClient, blockFeed, scanner.TxStreamServiceConfig{
 		JsonRpcConfig:       cfg.Scan.JsonRpc,
 		TraceJsonRpcConfig:  cfg.Trace.JsonRpc,
-		SkipBlocksOlderThan: &maxAge,
+		SkipBlocksOlderThan: maxAge,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create the tx stream service: %v", err)
@@ -81,6 +103,7 @@ func initTxAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertSend
 		AlertSender: as,
 		AgentPool:   ap,
 		MsgClient:   msgClient,
+		Workers:     cfg.Scan.TxEventWorkers,
 	})
 }
 
@@ -93,9 +116,9 @@ func initBlockAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertS
 	})
 }
 
-func initAlertSender(ctx context.Context, key *keystore.Key, pubClient clients.PublishClient) (clients.AlertSender, error) {
+func initAlertSender(ctx context.Context, scannerSigner signer.Signer, pubClient clients.PublishClient) (clients.AlertSender, error) {
 	return clients.NewAlertSender(ctx, pubClient, clients.AlertSenderConfig{
-		Key: key,
+		Signer: scannerSigner,
 	})
 }
 
@@ -106,6 +129,9 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
 	cfg.Trace.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Trace.JsonRpc.Url)
 	cfg.Registry.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Registry.JsonRpc.Url)
+	for i := range cfg.Registry.Fallbacks {
+		cfg.Registry.Fallbacks[i].Url = utils.ConvertToDockerHostURL(cfg.Registry.Fallbacks[i].Url)
+	}
 	cfg.Registry.IPFS.APIURL = utils.ConvertToDockerHostURL(cfg.Registry.IPFS.APIURL)
 	cfg.Registry.IPFS.GatewayURL = utils.ConvertToDockerHostURL(cfg.Registry.IPFS.GatewayURL)
 	cfg.Publish.APIURL = utils.ConvertToDockerHostURL(cfg.Publish.APIURL)
@@ -113,32 +139,67 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	cfg.Publish.IPFS.GatewayURL = utils.ConvertToDockerHostURL(cfg.Publish.IPFS.GatewayURL)
 	cfg.PrivateModeConfig.WebhookURL = utils.ConvertToDockerHostURL(cfg.PrivateModeConfig.WebhookURL)
 
-	msgClient := messaging.NewClient("scanner", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	// the registry reads go through a local proxy which fails over between the registry endpoints
+	var registryProxy *registry.RPCProxy
+	if len(cfg.Registry.Fallbacks) > 0 {
+		endpoints := append([]config.JsonRpcConfig{cfg.Registry.JsonRpc}, cfg.Registry.Fallbacks...)
+		proxy, err := registry.NewRPCProxy(ctx, endpoints)
+		if err != nil {
+			return nil, err
+		}
+		registryProxy = proxy
+		cfg.Registry.JsonRpc = config.JsonRpcConfig{Url: registryProxy.URL()}
+	}
 
-	key, err := security.LoadKey(config.DefaultContainerKeyDirPath)
+	// install the tracer provider before any of the pipeline services start
+	tracingSvc, err := tracing.NewService(ctx, cfg.Tracing, "scanner")
 	if err != nil {
 		return nil, err
 	}
 
-	publisherSvc, err := publisher.NewPublisher(ctx, cfg)
+	msgClient := clients.NewMessageClient("scanner", cfg.Messaging)
+
+	scannerSigner, err := signer.Load(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the scanner signer: %v", err)
+	}
+
+	publisherSvc, err := publisher.NewPublisher(ctx, cfg, scannerSigner)
 	if err != nil {
 		return nil, err
 	}
+	slaReportSvc := slareport.NewService(ctx, cfg, scannerSigner)
+
+	// the replay findings are written to a file instead of being published
+	var (
+		pubClient clients.PublishClient = publisherSvc
+		recorder  *replay.Recorder
+	)
+	if cfg.Replay.Enabled() {
+		recorder, err = replay.NewRecorder(config.ReplayFindingsFilePath(cfg.FortaDir))
+		if err != nil {
+			return nil, err
+		}
+		pubClient = recorder
+	}
 
-	as, err := initAlertSender(ctx, key, publisherSvc)
+	as, err := initAlertSender(ctx, scannerSigner, pubClient)
 	if err != nil {
 		return nil, err
 	}
 
-	ethClient, err := ethereum.NewStreamEthClient(ctx, "chain", cfg.Scan.JsonRpc.Url)
+	chainClient, err := ethereum.NewStreamEthClient(ctx, "chain", cfg.Scan.JsonRpc.Url)
 	if err != nil {
 		return nil, err
 	}
+	// the logs and the traces are decoded from the response streams
+	ethClient := ethrpc.NewClient(chainClient, cfg.Scan.JsonRpc)
 
-	traceClient, err := ethereum.NewStreamEthClient(ctx, "trace", cfg.Trace.JsonRpc.Url)
+	streamTraceClient, err := ethereum.NewStreamEthClient(ctx, "trace", cfg.Trace.JsonRpc.Url)
 	if err != nil {
 		return nil, err
 	}
+	traceClient := ethrpc.NewClient(streamTraceClient, cfg.Trace.JsonRpc)
 
 	txStream, blockFeed, err := initTxStream(ctx, ethClient, traceClient, cfg)
 	if err != nil {
@@ -150,8 +211,9 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		return nil, err
 	}
 
-	registryService := registry.New(cfg, key.Address, msgClient, registryClient)
+	registryService := registry.New(cfg, scannerSigner.Address(), msgClient, registryClient)
 	agentPool := agentpool.NewAgentPool(ctx, cfg.Scan, msgClient)
+	prometheus.MustRegister(agentpool.NewMetricsCollector(agentPool))
 	txAnalyzer, err := initTxAnalyzer(ctx, cfg, as, txStream, agentPool, msgClient)
 	if err != nil {
 		return nil, err
@@ -161,33 +223,193 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		return nil, err
 	}
 
+	adminToken, err := admin.EnsureToken(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return nil, err
+	}
+	apiSec, err := apisecurity.Load(config.DefaultContainerFortaDirPath, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
+	adminAPI := admin.NewServer(ctx, adminToken, apiSec)
+	adminAPI.Handle("/agents", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, agentPool.AgentStatuses())
+	})
+	adminAPI.Handle("/agents/disabled", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, registryService.DisabledAgents())
+	})
+	adminAPI.Handle("/agents/{id}/disable", func(w http.ResponseWriter, r *http.Request) {
+		if err := registryService.DisableAgent(mux.Vars(r)["id"]); err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, registryService.DisabledAgents())
+	}, http.MethodPost)
+	adminAPI.Handle("/agents/{id}/enable", func(w http.ResponseWriter, r *http.Request) {
+		if err := registryService.EnableAgent(mux.Vars(r)["id"]); err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, registryService.DisabledAgents())
+	}, http.MethodPost)
+	adminAPI.Handle("/alerts", func(w http.ResponseWriter, r *http.Request) {
+		query, err := store.ParseAlertQuery(r.URL.Query())
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		page, err := publisherSvc.QueryAlerts(query)
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, page)
+	})
+	adminAPI.Handle("/alerts/republish", func(w http.ResponseWriter, r *http.Request) {
+		var req publisher.RepublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		result, err := publisherSvc.RepublishAlerts(&req)
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, result)
+	}, http.MethodPost)
+	adminAPI.Handle("/batches/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		letters, err := publisherSvc.ListDeadLetters()
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, letters)
+	})
+	adminAPI.Handle("/batches/dead-letters/retry", func(w http.ResponseWriter, r *http.Request) {
+		var req publisher.RetryDeadLettersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		count, err := publisherSvc.RetryDeadLetters(req.IDs)
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, &publisher.RetryDeadLettersResponse{Retried: count})
+	}, http.MethodPost)
+	adminAPI.Handle("/batches/dead-letters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		letter, err := publisherSvc.GetDeadLetter(mux.Vars(r)["id"])
+		if errors.Is(err, store.ErrDeadLetterNotFound) {
+			admin.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		admin.WriteJSON(w, letter)
+	})
+	adminAPI.HandleBusDeadLetters(messaging.DeadLetters)
+	adminAPI.HandleLogLevels()
+	adminAPI.Handle("/metrics", promhttp.Handler().ServeHTTP)
+	if cfg.Debug.Pprof {
+		adminAPI.HandlePprof(cfg.Debug.BlockProfileRate)
+	}
+
+	var replaySvc *replay.Service
+	if cfg.Replay.Enabled() {
+		replaySvc = replay.NewService(ctx, cfg, blockFeed, agentPool, recorder)
+		adminAPI.Handle("/replay", func(w http.ResponseWriter, r *http.Request) {
+			admin.WriteJSON(w, replaySvc.Status())
+		})
+	}
+
 	// Start the main block feed so all transaction feeds can start consuming.
 	if !cfg.Scan.DisableAutostart {
 		blockFeed.Start()
 	}
 
+	reporters := []health.Reporter{
+		ethClient, traceClient, blockFeed, txStream, txAnalyzer, blockAnalyzer, agentPool, registryService,
+		publisherSvc, slaReportSvc,
+	}
+	if registryProxy != nil {
+		reporters = append(reporters, registryProxy)
+	}
+	if replaySvc != nil {
+		reporters = append(reporters, replaySvc)
+	}
+	healthChecker := health.CheckerFrom(summarizeReports, reporters...)
 	svcs := []services.Service{
-		health.NewService(ctx, "", healthutils.DefaultHealthServerErrHandler, health.CheckerFrom(
-			summarizeReports,
-			ethClient, traceClient, blockFeed, txStream, txAnalyzer, blockAnalyzer, agentPool, registryService,
-			publisherSvc,
-		)),
+		tracingSvc,
+		healthutils.NewHealthService(ctx, apiSec, healthChecker, readinessChecks(cfg, ethClient, txStream, agentPool, publisherSvc)...),
+		healthutils.NewGrpcHealthService(ctx, apiSec, "scanner", healthChecker),
 		txStream,
 		txAnalyzer,
 		blockAnalyzer,
 		scanner.NewScannerAPI(ctx, blockFeed),
+		adminAPI,
 		scanner.NewTxLogger(ctx),
 		publisherSvc,
+		slaReportSvc,
+	}
+	if replaySvc != nil {
+		svcs = append(svcs, replaySvc)
 	}
 
 	// for performance tests, this flag avoids using registry service
 	if !cfg.Registry.Disable {
+		if registryProxy != nil {
+			svcs = append(svcs, registryProxy)
+		}
 		svcs = append(svcs, registryService)
 	}
 
+	if !cfg.Publish.LocalStore.Disable && cfg.Publish.LocalStore.GraphQL.Enable {
+		graphqlServer, err := graphql.NewServer(ctx, apiSec, publisherSvc)
+		if err != nil {
+			return nil, err
+		}
+		svcs = append(svcs, graphqlServer)
+	}
+
 	return svcs, nil
 }
 
+// readinessChecks checks that the chain api is reachable, the block feed is not lagging,
+// the agents are dialed and the publisher can upload the batches.
+func readinessChecks(
+	cfg config.Config, ethClient ethereum.Client, txStream *scanner.TxStreamService,
+	agentPool *agentpool.AgentPool, publisherSvc *publisher.Publisher,
+) []healthutils.ReadinessCheck {
+	checks := []healthutils.ReadinessCheck{
+		{
+			Name: "chain-json-rpc",
+			Check: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+				defer cancel()
+				_, err := ethClient.BlockNumber(ctx)
+				return err
+			},
+		},
+		{Name: "agents", Check: agentPool.CheckReady},
+		{Name: "publisher", Check: publisherSvc.CheckReady},
+	}
+	// the replayed blocks are old by design
+	if !cfg.Replay.Enabled() {
+		maxLag := time.Duration(cfg.Readiness.MaxBlockLagSeconds) * time.Second
+		checks = append(checks, healthutils.ReadinessCheck{
+			Name: "block-feed",
+			Check: func() error {
+				return txStream.CheckLag(maxLag)
+			},
+		})
+	}
+	return checks
+}
+
 func summarizeReports(reports health.Reports) *health.Report {
 	summary := health.NewSummary()
 
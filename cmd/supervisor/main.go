@@ -2,15 +2,22 @@ package supervisor
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
 	"github.com/forta-network/forta-node/services/supervisor"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func initServices(ctx context.Context, cfg config.Config) ([]services.Service, error) {
@@ -22,23 +29,44 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	if err != nil {
 		return nil, err
 	}
-	key, err := security.LoadKey(config.DefaultContainerKeyDirPath)
+	scannerSigner, err := signer.Load(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the scanner signer: %v", err)
+	}
+	adminToken, err := admin.EnsureToken(config.DefaultContainerFortaDirPath)
 	if err != nil {
 		return nil, err
 	}
+	apiSec, err := apisecurity.Load(config.DefaultContainerFortaDirPath, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
 	svc, err := supervisor.NewSupervisorService(ctx, supervisor.SupervisorServiceConfig{
-		Config:     cfg,
-		Passphrase: passphrase,
-		Key:        key,
+		Config:      cfg,
+		Passphrase:  passphrase,
+		Signer:      scannerSigner,
+		APISecurity: apiSec,
 	})
 	if err != nil {
 		return nil, err
 	}
+	prometheus.MustRegister(supervisor.NewMetricsCollector(svc))
+
+	adminAPI := admin.NewServer(ctx, adminToken, apiSec)
+	adminAPI.HandleBusDeadLetters(messaging.DeadLetters)
+	adminAPI.HandleLogLevels()
+	adminAPI.Handle("/metrics", promhttp.Handler().ServeHTTP)
+	if cfg.Debug.Pprof {
+		adminAPI.HandlePprof(cfg.Debug.BlockProfileRate)
+	}
+
+	healthChecker := health.CheckerFrom(summarizeReports, svc)
 	return []services.Service{
-		health.NewService(
-			ctx, "", healthutils.DefaultHealthServerErrHandler,
-			health.CheckerFrom(summarizeReports, svc),
-		),
+		healthutils.NewHealthService(ctx, apiSec, healthChecker, healthutils.ReadinessCheck{
+			Name: "containers", Check: svc.CheckReady,
+		}),
+		healthutils.NewGrpcHealthService(ctx, apiSec, "supervisor", healthChecker),
+		adminAPI,
 		svc,
 	}, nil
 }
@@ -15,9 +15,11 @@ import (
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/services/admin"
 	"github.com/forta-network/forta-node/services/updater"
 	"github.com/forta-network/forta-node/store"
 	log "github.com/sirupsen/logrus"
@@ -94,16 +96,22 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		updateDelay = *cfg.AutoUpdate.UpdateDelay
 	}
 
+	adminToken, err := admin.EnsureToken(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return nil, err
+	}
+	apiSec, err := apisecurity.Load(config.DefaultContainerFortaDirPath, cfg.APISecurity, adminToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the api security settings: %v", err)
+	}
+
 	updaterService := updater.NewUpdaterService(
 		ctx, rg, rc, config.DefaultContainerPort,
 		developmentMode, updateDelay, 0,
 	)
 
 	return []services.Service{
-		health.NewService(
-			ctx, "", healthutils.DefaultHealthServerErrHandler,
-			health.CheckerFrom(summarizeReports, updaterService),
-		),
+		healthutils.NewHealthService(ctx, apiSec, health.CheckerFrom(summarizeReports, updaterService)),
 		updaterService,
 	}, nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-core-go/utils"
@@ -9,6 +10,14 @@ import (
 
 const (
 	AgentGrpcPort = "50051"
+
+	DefaultAgentBufferSize    = 2000
+	DefaultAgentBufferMinSize = 100
+	DefaultAgentBufferMaxSize = 10000
+
+	// agents use the gRPC default of 4MB when receiving
+	DefaultAgentGrpcMaxSendMessageBytes = 4 * 1024 * 1024
+	DefaultAgentGrpcMaxRecvMessageBytes = 1000000
 )
 
 type AgentConfig struct {
@@ -18,6 +27,21 @@ type AgentConfig struct {
 	IsLocal    bool    `yaml:"isLocal" json:"isLocal"`
 	StartBlock *uint64 `yaml:"startBlock" json:"startBlock,omitempty"`
 	StopBlock  *uint64 `yaml:"stopBlock" json:"stopBlock,omitempty"`
+	Replicas   uint    `yaml:"replicas" json:"replicas,omitempty"`
+	Replica    uint    `yaml:"replica" json:"replica,omitempty"`
+	Stateful   bool    `yaml:"stateful" json:"stateful,omitempty"`
+	Token      string  `yaml:"-" json:"token,omitempty"`
+
+	// info from the manifest
+	Version  string  `yaml:"version" json:"version,omitempty"`
+	ChainIDs []int64 `yaml:"chainIds" json:"chainIds,omitempty"`
+
+	// version constraints from the manifest
+	NodeVersion     string `yaml:"nodeVersion" json:"nodeVersion,omitempty"`
+	ProtocolVersion string `yaml:"protocolVersion" json:"protocolVersion,omitempty"`
+
+	// resource requests from the manifest
+	GPUs int `yaml:"gpus" json:"gpus,omitempty"`
 }
 
 // ToAgentInfo transforms the agent config to the agent info.
@@ -37,12 +61,177 @@ func (ac AgentConfig) ImageHash() string {
 
 func (ac AgentConfig) ContainerName() string {
 	_, digest := utils.SplitImageRef(ac.Image)
+	var name string
 	if ac.IsLocal {
-		return fmt.Sprintf("%s-agent-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8))
+		name = fmt.Sprintf("%s-agent-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8))
+	} else {
+		name = fmt.Sprintf("%s-agent-%s-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8), utils.ShortenString(digest, 4))
+	}
+	if ac.IsReplicated() {
+		name = fmt.Sprintf("%s-%d", name, ac.Replica)
 	}
-	return fmt.Sprintf("%s-agent-%s-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8), utils.ShortenString(digest, 4))
+	return name
+}
+
+// IsReplicated tells if the agent runs as multiple replicas.
+func (ac AgentConfig) IsReplicated() bool {
+	return ac.Replicas > 1
 }
 
 func (ac AgentConfig) GrpcPort() string {
 	return AgentGrpcPort
 }
+
+// Allows tells if the agent with given ID is allowed to run by the local filter.
+// The denylist always wins and a non-empty allowlist rejects everything else.
+func (afc AgentFilterConfig) Allows(agentID string) bool {
+	if containsAgentID(afc.Denylist, agentID) {
+		return false
+	}
+	if len(afc.Allowlist) > 0 {
+		return containsAgentID(afc.Allowlist, agentID)
+	}
+	return true
+}
+
+// Filter returns the agents which are allowed by the local filter.
+func (afc AgentFilterConfig) Filter(agents []*AgentConfig) []*AgentConfig {
+	if len(afc.Allowlist) == 0 && len(afc.Denylist) == 0 {
+		return agents
+	}
+	filtered := make([]*AgentConfig, 0, len(agents))
+	for _, agent := range agents {
+		if afc.Allows(agent.ID) {
+			filtered = append(filtered, agent)
+		}
+	}
+	return filtered
+}
+
+// AllowsEgress tells if the agent can access the internet.
+func (anc AgentNetworkConfig) AllowsEgress(agentID string) bool {
+	return anc.AllowEgress || containsAgentID(anc.EgressAgents, agentID)
+}
+
+// ProxyEnv returns the proxy environment variables for the agent containers. Both of the
+// upper and lower case variables are set since the HTTP clients disagree on which to read.
+func (anc AgentNetworkConfig) ProxyEnv() map[string]string {
+	if len(anc.HTTPProxy) == 0 && len(anc.HTTPSProxy) == 0 {
+		return nil
+	}
+	noProxy := append([]string{
+		"localhost", "127.0.0.1", DockerJSONRPCProxyContainerName, DockerScannerContainerName,
+	}, anc.NoProxy...)
+	env := map[string]string{
+		"NO_PROXY": strings.Join(noProxy, ","),
+		"no_proxy": strings.Join(noProxy, ","),
+	}
+	if len(anc.HTTPProxy) > 0 {
+		env["HTTP_PROXY"] = anc.HTTPProxy
+		env["http_proxy"] = anc.HTTPProxy
+	}
+	if len(anc.HTTPSProxy) > 0 {
+		env["HTTPS_PROXY"] = anc.HTTPSProxy
+		env["https_proxy"] = anc.HTTPSProxy
+	}
+	return env
+}
+
+func containsAgentID(list []string, agentID string) bool {
+	for _, id := range list {
+		if strings.EqualFold(id, agentID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyAgentScaling expands the agents which should run as multiple replicas.
+func ApplyAgentScaling(agents []*AgentConfig, scaling map[string]AgentScalingConfig) []*AgentConfig {
+	if len(scaling) == 0 {
+		return agents
+	}
+	expanded := make([]*AgentConfig, 0, len(agents))
+	for _, agent := range agents {
+		scalingCfg, ok := findAgentScaling(scaling, agent.ID)
+		if !ok || scalingCfg.Replicas <= 1 {
+			expanded = append(expanded, agent)
+			continue
+		}
+		for i := uint(0); i < scalingCfg.Replicas; i++ {
+			replica := *agent
+			replica.Replicas = scalingCfg.Replicas
+			replica.Replica = i
+			replica.Stateful = scalingCfg.Stateful
+			expanded = append(expanded, &replica)
+		}
+	}
+	return expanded
+}
+
+func findAgentScaling(scaling map[string]AgentScalingConfig, agentID string) (AgentScalingConfig, bool) {
+	for id, scalingCfg := range scaling {
+		if strings.EqualFold(id, agentID) {
+			return scalingCfg, true
+		}
+	}
+	return AgentScalingConfig{}, false
+}
+
+// IsStreaming tells if the agent should be evaluated with the streaming API.
+func (agc AgentGrpcConfig) IsStreaming(agentID string) bool {
+	return containsAgentID(agc.StreamingAgents, agentID)
+}
+
+// GetMaxSendMessageBytes returns the max size of the messages sent to the agents.
+func (agc AgentGrpcConfig) GetMaxSendMessageBytes() int {
+	if agc.MaxSendMessageBytes <= 0 {
+		return DefaultAgentGrpcMaxSendMessageBytes
+	}
+	return agc.MaxSendMessageBytes
+}
+
+// GetMaxRecvMessageBytes returns the max size of the messages received from the agents.
+func (agc AgentGrpcConfig) GetMaxRecvMessageBytes() int {
+	if agc.MaxRecvMessageBytes <= 0 {
+		return DefaultAgentGrpcMaxRecvMessageBytes
+	}
+	return agc.MaxRecvMessageBytes
+}
+
+// GetAgentBufferConfig returns the request buffer config for the agent with given ID.
+// Per-agent values override the node-wide values and the unset ones fall back to defaults.
+func (sc ScannerConfig) GetAgentBufferConfig(agentID string) AgentBufferConfig {
+	bufCfg := sc.AgentBuffer
+	for id, override := range sc.AgentBuffers {
+		if !strings.EqualFold(id, agentID) {
+			continue
+		}
+		if override.Size > 0 {
+			bufCfg.Size = override.Size
+		}
+		if override.MinSize > 0 {
+			bufCfg.MinSize = override.MinSize
+		}
+		if override.MaxSize > 0 {
+			bufCfg.MaxSize = override.MaxSize
+		}
+		bufCfg.Adaptive = bufCfg.Adaptive || override.Adaptive
+	}
+	if bufCfg.Size <= 0 {
+		bufCfg.Size = DefaultAgentBufferSize
+	}
+	if bufCfg.MinSize <= 0 {
+		bufCfg.MinSize = DefaultAgentBufferMinSize
+	}
+	if bufCfg.MaxSize <= 0 {
+		bufCfg.MaxSize = DefaultAgentBufferMaxSize
+	}
+	if bufCfg.MinSize > bufCfg.Size {
+		bufCfg.MinSize = bufCfg.Size
+	}
+	if bufCfg.MaxSize < bufCfg.Size {
+		bufCfg.MaxSize = bufCfg.Size
+	}
+	return bufCfg
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
-	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAgentConfig_ContainerName(t *testing.T) {
@@ -12,3 +14,56 @@ func TestAgentConfig_ContainerName(t *testing.T) {
 	}
 	assert.Equal(t, "forta-agent-0x04f65c-de86", cfg.ContainerName())
 }
+
+func TestAgentFilterConfig_Allows(t *testing.T) {
+	const (
+		agent1 = "0x04f65c638f234548104790d7c692c9273d41f82d784b174ff2fdc3e8e5bf1636"
+		agent2 = "0x0a1f3e5ac1b0c1bb7bd0a7e3c2b0d2a4f5f1b6ad7c3e8f5a9c1d3e5f7a9b1c3d"
+	)
+
+	assert.True(t, AgentFilterConfig{}.Allows(agent1))
+	assert.False(t, AgentFilterConfig{Denylist: []string{agent1}}.Allows(agent1))
+	assert.True(t, AgentFilterConfig{Denylist: []string{agent1}}.Allows(agent2))
+	assert.True(t, AgentFilterConfig{Allowlist: []string{strings.ToUpper(agent1)}}.Allows(agent1))
+	assert.False(t, AgentFilterConfig{Allowlist: []string{agent1}}.Allows(agent2))
+	assert.False(t, AgentFilterConfig{Allowlist: []string{agent1}, Denylist: []string{agent1}}.Allows(agent1))
+}
+
+func TestApplyAgentScaling(t *testing.T) {
+	agents := []*AgentConfig{
+		{ID: "0x01", Image: "bafybeibvkqkf7i3c5ouehviwjb2dzbukgqied3cg36axl7gzm23r6ielnu@sha256:de866feeb97cba4cad6343c4137cb48bc798be0136015bec16d97c8ef28852b9"},
+		{ID: "0x02"},
+	}
+	expanded := ApplyAgentScaling(agents, map[string]AgentScalingConfig{
+		"0x01": {Replicas: 2, Stateful: true},
+	})
+
+	assert.Len(t, expanded, 3)
+	assert.Equal(t, uint(0), expanded[0].Replica)
+	assert.Equal(t, uint(1), expanded[1].Replica)
+	assert.True(t, expanded[1].Stateful)
+	assert.Equal(t, "forta-agent-0x01-de86-1", expanded[1].ContainerName())
+	assert.Equal(t, agents[1], expanded[2])
+}
+
+func TestAgentNetworkConfig_AllowsEgress(t *testing.T) {
+	const agentID = "0x04f65c638f234548104790d7c692c9273d41f82d784b174ff2fdc3e8e5bf1636"
+
+	assert.False(t, AgentNetworkConfig{}.AllowsEgress(agentID))
+	assert.True(t, AgentNetworkConfig{AllowEgress: true}.AllowsEgress(agentID))
+	assert.True(t, AgentNetworkConfig{EgressAgents: []string{strings.ToUpper(agentID)}}.AllowsEgress(agentID))
+}
+
+func TestAgentNetworkConfig_ProxyEnv(t *testing.T) {
+	assert.Nil(t, AgentNetworkConfig{}.ProxyEnv())
+
+	env := AgentNetworkConfig{
+		HTTPSProxy: "http://proxy.corp.example:3128",
+		NoProxy:    []string{".corp.example"},
+	}.ProxyEnv()
+	assert.Equal(t, "http://proxy.corp.example:3128", env["HTTPS_PROXY"])
+	assert.Equal(t, "http://proxy.corp.example:3128", env["https_proxy"])
+	assert.Empty(t, env["HTTP_PROXY"])
+	assert.Equal(t, "localhost,127.0.0.1,forta-json-rpc,forta-scanner,.corp.example", env["NO_PROXY"])
+	assert.Equal(t, env["NO_PROXY"], env["no_proxy"])
+}
@@ -1,6 +1,14 @@
 package config
 
-const defaultBlockOffset = 0
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultBlockOffset    = 0
+	defaultBlockRateLimit = 200 // ms
+)
 
 var defaultRateLimiting = &RateLimitConfig{
 	Rate:  50, // 0.347, // 30k/day
@@ -10,66 +18,203 @@ var defaultRateLimiting = &RateLimitConfig{
 // ChainSettings contains chain-specific settings.
 type ChainSettings struct {
 	Name                string
+	Profile             string
 	ChainID             int
-	Offset              int
+	BlockTimeSeconds    float64
+	Offset              int // confirmation depth
+	EnableTrace         bool
+	BlockRateLimit      int // ms between the block requests, derived from the block time if not set
 	JsonRpcRateLimiting *RateLimitConfig
 }
 
+// ChainProfileConfig is a custom chain profile which overrides the settings of the profile it
+// inherits. The unset values are inherited.
+type ChainProfileConfig struct {
+	Inherits          string           `yaml:"inherits" json:"inherits"`
+	ChainID           int              `yaml:"chainId" json:"chainId"`
+	BlockTimeSeconds  float64          `yaml:"blockTimeSeconds" json:"blockTimeSeconds" validate:"omitempty,gt=0"`
+	ConfirmationDepth *int             `yaml:"confirmationDepth" json:"confirmationDepth" validate:"omitempty,min=0"`
+	Trace             *bool            `yaml:"trace" json:"trace"`
+	BlockRateLimit    *int             `yaml:"blockRateLimit" json:"blockRateLimit" validate:"omitempty,min=0"`
+	RateLimit         *RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+}
+
 var allChainSettings = []ChainSettings{
 	{
 		Name:                "Ethereum Mainnet",
+		Profile:             "mainnet",
 		ChainID:             1,
+		BlockTimeSeconds:    12,
 		Offset:              defaultBlockOffset,
+		EnableTrace:         true,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 	{
 		Name:                "BSC",
+		Profile:             "bsc",
 		ChainID:             56,
+		BlockTimeSeconds:    3,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 	{
 		Name:                "Polygon",
+		Profile:             "polygon",
 		ChainID:             137,
+		BlockTimeSeconds:    2,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 	{
 		Name:                "Avalanche",
+		Profile:             "avalanche",
 		ChainID:             43114,
+		BlockTimeSeconds:    2,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 	{
 		Name:                "Arbitrum",
+		Profile:             "arbitrum",
 		ChainID:             42161,
+		BlockTimeSeconds:    0.25,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 	{
 		Name:                "Optimism",
+		Profile:             "optimism",
 		ChainID:             10,
+		BlockTimeSeconds:    2,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
 	},
 }
 
-// GetChainSettings returns the settings for the chain.
+// GetChainSettings returns the built-in settings for the chain.
 func GetChainSettings(chainID int) *ChainSettings {
 	for _, settings := range allChainSettings {
 		if settings.ChainID == chainID {
-			return &settings
+			return withDerivedSettings(settings)
 		}
 	}
-	return &ChainSettings{
+	return withDerivedSettings(ChainSettings{
 		Name:                "Unknown chain",
 		ChainID:             chainID,
 		Offset:              defaultBlockOffset,
 		JsonRpcRateLimiting: defaultRateLimiting,
+	})
+}
+
+// ResolveChainSettings resolves the chain profile selected in the config. The default profile is the
+// built-in profile of the chain. A custom profile from the config overrides the profile it inherits
+// and a custom profile with a built-in profile name overrides that profile.
+func ResolveChainSettings(cfg Config) (*ChainSettings, error) {
+	name := cfg.ChainProfile
+	if len(name) == 0 {
+		name = GetChainSettings(cfg.ChainID).Profile
+	}
+	if len(name) == 0 {
+		return GetChainSettings(cfg.ChainID), nil // unknown chain without a profile
+	}
+
+	settings, err := resolveChainProfile(cfg.ChainProfiles, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if settings.ChainID != cfg.ChainID {
+		return nil, fmt.Errorf("chain profile %s is for chain %d but chainId is %d", name, settings.ChainID, cfg.ChainID)
+	}
+	return withDerivedSettings(*settings), nil
+}
+
+func resolveChainProfile(profiles map[string]ChainProfileConfig, name string, visited []string) (*ChainSettings, error) {
+	if containsAgentID(visited, name) {
+		return nil, fmt.Errorf("chain profile inheritance cycle: %s -> %s", strings.Join(visited, " -> "), name)
+	}
+	visited = append(visited, name)
+
+	builtIn := getBuiltInChainProfile(name)
+	profile, ok := profiles[name]
+	if !ok {
+		if builtIn == nil {
+			return nil, fmt.Errorf("unknown chain profile %s", name)
+		}
+		return builtIn, nil
+	}
+
+	// inherit from the built-in profile of the same name or the given profile
+	base := builtIn
+	switch {
+	case len(profile.Inherits) > 0:
+		var err error
+		base, err = resolveChainProfile(profiles, profile.Inherits, visited)
+		if err != nil {
+			return nil, err
+		}
+	case base == nil:
+		base = &ChainSettings{Offset: defaultBlockOffset, JsonRpcRateLimiting: defaultRateLimiting}
+	}
+
+	settings := *base
+	settings.Profile = name
+	if profile.ChainID > 0 {
+		settings.ChainID = profile.ChainID
+	}
+	if profile.BlockTimeSeconds > 0 {
+		settings.BlockTimeSeconds = profile.BlockTimeSeconds
+		settings.BlockRateLimit = 0 // derive again
+	}
+	if profile.ConfirmationDepth != nil {
+		settings.Offset = *profile.ConfirmationDepth
+	}
+	if profile.Trace != nil {
+		settings.EnableTrace = *profile.Trace
+	}
+	if profile.BlockRateLimit != nil {
+		settings.BlockRateLimit = *profile.BlockRateLimit
+	}
+	if profile.RateLimit != nil {
+		settings.JsonRpcRateLimiting = profile.RateLimit
+	}
+	if len(settings.Name) == 0 {
+		settings.Name = name
+	}
+	return &settings, nil
+}
+
+func getBuiltInChainProfile(name string) *ChainSettings {
+	for _, settings := range allChainSettings {
+		if settings.Profile == name {
+			return &settings
+		}
+	}
+	return nil
+}
+
+// withDerivedSettings requests the blocks at least twice per block time.
+func withDerivedSettings(settings ChainSettings) *ChainSettings {
+	if settings.BlockRateLimit == 0 {
+		settings.BlockRateLimit = defaultBlockRateLimit
+		if halfBlockTime := int(settings.BlockTimeSeconds * 1000 / 2); halfBlockTime > 0 && halfBlockTime < defaultBlockRateLimit {
+			settings.BlockRateLimit = halfBlockTime
+		}
 	}
+	return &settings
 }
 
-// GetBlockOffset returns the block offset for a chain.
-func GetBlockOffset(chainID int) int {
-	return GetChainSettings(chainID).Offset
+// applyChainSettings resolves and applies the chain profile.
+func applyChainSettings(cfg *Config) error {
+	settings, err := ResolveChainSettings(*cfg)
+	if err != nil {
+		return err
+	}
+	cfg.ChainSettings = *settings
+	if settings.EnableTrace {
+		cfg.Trace.Enabled = true
+	}
+	if cfg.Scan.BlockRateLimit == 0 {
+		cfg.Scan.BlockRateLimit = settings.BlockRateLimit
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveChainSettings(t *testing.T) {
+	r := require.New(t)
+
+	var cfg Config
+	cfg.ChainID = 1
+	settings, err := ResolveChainSettings(cfg)
+	r.NoError(err)
+	r.Equal("mainnet", settings.Profile)
+	r.True(settings.EnableTrace)
+	r.Equal(200, settings.BlockRateLimit)
+
+	cfg.ChainID = 42161
+	settings, err = ResolveChainSettings(cfg)
+	r.NoError(err)
+	r.Equal(125, settings.BlockRateLimit) // half of the block time
+
+	cfg.ChainID = 12345
+	settings, err = ResolveChainSettings(cfg)
+	r.NoError(err)
+	r.Equal("Unknown chain", settings.Name)
+
+	depth := 5
+	trace := true
+	cfg.ChainID = 137
+	cfg.ChainProfile = "my-polygon"
+	cfg.ChainProfiles = map[string]ChainProfileConfig{
+		"polygon": {
+			RateLimit: &RateLimitConfig{Rate: 100, Burst: 100},
+		},
+		"my-polygon": {
+			Inherits:          "polygon",
+			ConfirmationDepth: &depth,
+			Trace:             &trace,
+		},
+	}
+	settings, err = ResolveChainSettings(cfg)
+	r.NoError(err)
+	r.Equal("my-polygon", settings.Profile)
+	r.Equal("Polygon", settings.Name)
+	r.Equal(137, settings.ChainID)
+	r.Equal(5, settings.Offset)
+	r.True(settings.EnableTrace)
+	r.Equal(float64(100), settings.JsonRpcRateLimiting.Rate)
+	r.Equal(200, settings.BlockRateLimit)
+
+	cfg.ChainID = 1
+	_, err = ResolveChainSettings(cfg)
+	r.Error(err, "profile is for another chain")
+
+	cfg.ChainID = 137
+	cfg.ChainProfile = "unknown"
+	_, err = ResolveChainSettings(cfg)
+	r.Error(err)
+
+	cfg.ChainProfile = "a"
+	cfg.ChainProfiles = map[string]ChainProfileConfig{
+		"a": {Inherits: "b"},
+		"b": {Inherits: "a"},
+	}
+	_, err = ResolveChainSettings(cfg)
+	r.Error(err)
+}
+
+func TestApplyChainSettings(t *testing.T) {
+	r := require.New(t)
+
+	var cfg Config
+	cfg.ChainID = 1
+	r.NoError(applyChainSettings(&cfg))
+	r.True(cfg.Trace.Enabled)
+	r.Equal(200, cfg.Scan.BlockRateLimit)
+
+	cfg = Config{ChainID: 42161}
+	cfg.Scan.BlockRateLimit = 1000
+	r.NoError(applyChainSettings(&cfg))
+	r.False(cfg.Trace.Enabled)
+	r.Equal(1000, cfg.Scan.BlockRateLimit)
+	r.Equal("arbitrum", cfg.ChainSettings.Profile)
+}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/creasty/defaults"
+	"gopkg.in/yaml.v3"
 )
 
 type JsonRpcConfig struct {
@@ -13,13 +15,41 @@ type JsonRpcConfig struct {
 	Headers map[string]string `yaml:"headers" json:"headers"`
 }
 
+type AgentBufferConfig struct {
+	Size     int  `yaml:"size" json:"size" validate:"omitempty,min=1"`
+	Adaptive bool `yaml:"adaptive" json:"adaptive"`
+	MinSize  int  `yaml:"minSize" json:"minSize" validate:"omitempty,min=1"`
+	MaxSize  int  `yaml:"maxSize" json:"maxSize" validate:"omitempty,min=1"`
+}
+
+type AgentGrpcConfig struct {
+	Compression              string   `yaml:"compression" json:"compression" validate:"omitempty,oneof=gzip zstd"`
+	StreamingAgents          []string `yaml:"streamingAgents" json:"streamingAgents"`
+	KeepaliveIntervalSeconds int      `yaml:"keepaliveIntervalSeconds" json:"keepaliveIntervalSeconds" default:"300"`
+	KeepaliveTimeoutSeconds  int      `yaml:"keepaliveTimeoutSeconds" json:"keepaliveTimeoutSeconds" default:"20"`
+	MaxBackoffSeconds        int      `yaml:"maxBackoffSeconds" json:"maxBackoffSeconds" default:"30"`
+	RequireAgentAuth         bool     `yaml:"requireAgentAuth" json:"requireAgentAuth"`
+	MaxSendMessageBytes      int      `yaml:"maxSendMessageBytes" json:"maxSendMessageBytes" default:"4194304"`
+	MaxRecvMessageBytes      int      `yaml:"maxRecvMessageBytes" json:"maxRecvMessageBytes" default:"1000000"`
+}
+
 type ScannerConfig struct {
-	StartBlock         int           `yaml:"-" json:"_startBlock"`
-	EndBlock           int           `yaml:"-" json:"_endBlock"`
-	JsonRpc            JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
-	DisableAutostart   bool          `yaml:"disableAutostart" json:"disableAutostart"`
-	BlockRateLimit     int           `yaml:"blockRateLimit" json:"blockRateLimit" default:"200"`
-	BlockMaxAgeSeconds int64         `json:"blockMaxAgeSeconds" json:"blockMaxAgeSeconds" default:"600"`
+	StartBlock         int                          `yaml:"-" json:"_startBlock"`
+	EndBlock           int                          `yaml:"-" json:"_endBlock"`
+	JsonRpc            JsonRpcConfig                `yaml:"jsonRpc" json:"jsonRpc"`
+	DisableAutostart   bool                         `yaml:"disableAutostart" json:"disableAutostart"`
+	BlockRateLimit     int                          `yaml:"blockRateLimit" json:"blockRateLimit"` // from the chain profile by default
+	BlockMaxAgeSeconds int64                        `json:"blockMaxAgeSeconds" json:"blockMaxAgeSeconds" default:"600"`
+	AgentBuffer        AgentBufferConfig            `yaml:"agentBuffer" json:"agentBuffer"`
+	AgentBuffers       map[string]AgentBufferConfig `yaml:"agentBuffers" json:"agentBuffers"`
+	AgentGrpc          AgentGrpcConfig              `yaml:"agentGrpc" json:"agentGrpc"`
+	BlockFetch         BlockFetchConfig             `yaml:"blockFetch" json:"blockFetch"`
+	TxEventWorkers     int                          `yaml:"txEventWorkers" json:"txEventWorkers" validate:"omitempty,min=1"` // number of CPUs by default
+}
+
+type BlockFetchConfig struct {
+	TimeoutSeconds int  `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"120" validate:"omitempty,min=1"`
+	RequireTraces  bool `yaml:"requireTraces" json:"requireTraces"`
 }
 
 type TraceConfig struct {
@@ -32,26 +62,112 @@ type RateLimitConfig struct {
 	Burst int     `yaml:"burst" json:"burst" validate:"min=1"`
 }
 
+type JsonRpcCacheConfig struct {
+	Enable          bool `yaml:"enable" json:"enable"`
+	MaxEntries      int  `yaml:"maxEntries" json:"maxEntries" default:"10000" validate:"omitempty,min=1"`
+	FinalityDepth   int  `yaml:"finalityDepth" json:"finalityDepth" default:"12" validate:"omitempty,min=0"`
+	HeadPollSeconds int  `yaml:"headPollSeconds" json:"headPollSeconds" default:"5" validate:"omitempty,min=1"`
+}
+
+type AgentRateLimitConfig struct {
+	RateLimit             *RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	MaxConcurrentRequests int              `yaml:"maxConcurrentRequests" json:"maxConcurrentRequests" validate:"omitempty,min=1"`
+}
+
+type JsonRpcMethodsConfig struct {
+	Allow []string `yaml:"allow" json:"allow"` // all methods by default
+	Deny  []string `yaml:"deny" json:"deny"`
+}
+
+type JsonRpcWebSocketConfig struct {
+	Enable           bool   `yaml:"enable" json:"enable"`
+	Url              string `yaml:"url" json:"url" validate:"omitempty,url"`                                          // derived from the json-rpc url by default
+	MaxSubscriptions int    `yaml:"maxSubscriptions" json:"maxSubscriptions" default:"10" validate:"omitempty,min=1"` // per agent
+}
+
+type JsonRpcChainConfig struct {
+	ChainID int           `yaml:"chainId" json:"chainId" validate:"required"`
+	JsonRpc JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
+}
+
+type JsonRpcAuditLogConfig struct {
+	Enable    bool `yaml:"enable" json:"enable"`
+	MaxSizeMB int  `yaml:"maxSizeMb" json:"maxSizeMb" default:"100" validate:"omitempty,min=1"`
+	MaxFiles  int  `yaml:"maxFiles" json:"maxFiles" default:"5" validate:"omitempty,min=1"`
+}
+
+type JsonRpcFailoverConfig struct {
+	JsonRpc            JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"` // the secondary upstream
+	HealthCheckSeconds int           `yaml:"healthCheckSeconds" json:"healthCheckSeconds" default:"15" validate:"omitempty,min=1"`
+	FailureThreshold   int           `yaml:"failureThreshold" json:"failureThreshold" default:"3" validate:"omitempty,min=1"`
+}
+
 type JsonRpcProxyConfig struct {
-	JsonRpc         JsonRpcConfig    `yaml:"jsonRpc" json:"jsonRpc"`
-	RateLimitConfig *RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	JsonRpc                JsonRpcConfig                   `yaml:"jsonRpc" json:"jsonRpc"`
+	RateLimitConfig        *RateLimitConfig                `yaml:"rateLimit" json:"rateLimit"`
+	MaxConcurrentRequests  int                             `yaml:"maxConcurrentRequests" json:"maxConcurrentRequests" validate:"omitempty,min=1"` // per agent, unlimited by default
+	AgentLimits            map[string]AgentRateLimitConfig `yaml:"agentLimits" json:"agentLimits" validate:"dive"`                                // by agent ID
+	Cache                  JsonRpcCacheConfig              `yaml:"cache" json:"cache"`
+	Methods                JsonRpcMethodsConfig            `yaml:"methods" json:"methods"`
+	WebSocket              JsonRpcWebSocketConfig          `yaml:"webSocket" json:"webSocket"`
+	Chains                 []JsonRpcChainConfig            `yaml:"chains" json:"chains" validate:"dive"` // other chains the agents can query
+	AuditLog               JsonRpcAuditLogConfig           `yaml:"auditLog" json:"auditLog"`
+	Failover               JsonRpcFailoverConfig           `yaml:"failover" json:"failover"`
+	MaxResponseSizeMB      int                             `yaml:"maxResponseSizeMb" json:"maxResponseSizeMb" default:"20" validate:"omitempty,min=1"`
+	UpstreamTimeoutSeconds int                             `yaml:"upstreamTimeoutSeconds" json:"upstreamTimeoutSeconds" default:"30" validate:"omitempty,min=1"`
 }
 
 type LogConfig struct {
 	Level       string `yaml:"level" json:"level" default:"info" `
 	MaxLogSize  string `yaml:"maxLogSize" json:"maxLogSize" default:"50m" `
 	MaxLogFiles int    `yaml:"maxLogFiles" json:"maxLogFiles" default:"10" `
+
+	AgentMaxLogSize  string `yaml:"agentMaxLogSize" json:"agentMaxLogSize" default:"10m" `
+	AgentMaxLogFiles int    `yaml:"agentMaxLogFiles" json:"agentMaxLogFiles" default:"5" `
+
+	Format     string            `yaml:"format" json:"format" default:"json" validate:"oneof=json text"`
+	Components map[string]string `yaml:"components" json:"components"` // log levels of the components
+	Outputs    []LogOutputConfig `yaml:"outputs" json:"outputs" validate:"dive"`
+}
+
+// LogOutputConfig ships the logs of the node services to a destination in addition to the container logs.
+type LogOutputConfig struct {
+	Type     string            `yaml:"type" json:"type" validate:"oneof=file syslog loki"`
+	Path     string            `yaml:"path" json:"path"` // relative to the forta dir, default: logs/<service>.log
+	URL      string            `yaml:"url" json:"url" validate:"required_unless=Type file"`
+	Labels   map[string]string `yaml:"labels" json:"labels"`
+	Services []string          `yaml:"services" json:"services"` // ships logs of all services if empty
 }
 
 type RegistryConfig struct {
-	JsonRpc              JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc" default:"{\"url\": \"https://polygon-rpc.com\"}"`
-	IPFS                 IPFSConfig    `yaml:"ipfs" json:"ipfs"`
-	ContractAddress      string        `yaml:"contractAddress" json:"contractAddress" validate:"eth_addr"`
-	ContainerRegistry    string        `yaml:"containerRegistry" json:"containerRegistry" validate:"hostname|hostname_port" default:"disco.forta.network" `
-	Username             string        `yaml:"username" json:"username"`
-	Password             string        `yaml:"password" json:"password"`
-	Disable              bool          `yaml:"disable" json:"disable"` // for testing situations
-	CheckIntervalSeconds int           `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"15"`
+	JsonRpc                  JsonRpcConfig        `yaml:"jsonRpc" json:"jsonRpc" default:"{\"url\": \"https://polygon-rpc.com\"}"`
+	Fallbacks                []JsonRpcConfig      `yaml:"fallbacks" json:"fallbacks" validate:"dive"` // tried in order when the registry endpoint fails
+	IPFS                     IPFSConfig           `yaml:"ipfs" json:"ipfs"`
+	ContractAddress          string               `yaml:"contractAddress" json:"contractAddress" validate:"eth_addr"`
+	ContainerRegistry        string               `yaml:"containerRegistry" json:"containerRegistry" validate:"hostname|hostname_port" default:"disco.forta.network" `
+	Username                 string               `yaml:"username" json:"username"`
+	Password                 string               `yaml:"password" json:"password"`
+	Disable                  bool                 `yaml:"disable" json:"disable"` // for testing situations
+	CheckIntervalSeconds     int                  `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"15"`
+	ListenEvents             bool                 `yaml:"listenEvents" json:"listenEvents"`                                        // checks the agents right after the registry events
+	SkipManifestVerification bool                 `yaml:"skipManifestVerification" json:"skipManifestVerification"`                // for testing situations
+	SkipImageVerification    bool                 `yaml:"skipImageVerification" json:"skipImageVerification"`                      // for testing situations
+	MinimumAgentStake        string               `yaml:"minimumAgentStake" json:"minimumAgentStake" validate:"omitempty,numeric"` // in FORT, agents with less active stake are not run
+	Legacy                   LegacyRegistryConfig `yaml:"legacy" json:"legacy"`
+	Mirrors                  []ImageMirrorConfig  `yaml:"mirrors" json:"mirrors" validate:"dive"` // tried in order when pulling an image fails
+}
+
+// ImageMirrorConfig is a container registry which mirrors the agent and the node images.
+type ImageMirrorConfig struct {
+	Host     string `yaml:"host" json:"host" validate:"hostname|hostname_port"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// LegacyRegistryConfig points to the previous version of the registry contracts which are
+// still read during a migration.
+type LegacyRegistryConfig struct {
+	ENSAddress string `yaml:"ensAddress" json:"ensAddress" validate:"omitempty,eth_addr"`
 }
 
 type IPFSConfig struct {
@@ -63,8 +179,10 @@ type IPFSConfig struct {
 
 type BatchConfig struct {
 	SkipEmpty       bool `yaml:"skipEmpty" json:"skipEmpty"`
-	IntervalSeconds *int `yaml:"intervalSeconds" json:"intervalSeconds" default:"15" `
-	MaxAlerts       *int `yaml:"maxAlerts" json:"maxAlerts" default:"1000" `
+	IntervalSeconds *int `yaml:"intervalSeconds" json:"intervalSeconds" default:"15" validate:"omitempty,min=1"`
+	IntervalMs      *int `yaml:"intervalMs" json:"intervalMs" validate:"omitempty,min=100"` // overrides intervalSeconds
+	MaxAlerts       *int `yaml:"maxAlerts" json:"maxAlerts" default:"1000" validate:"omitempty,min=1"`
+	MaxBytes        *int `yaml:"maxBytes" json:"maxBytes" validate:"omitempty,min=1024"`
 }
 
 type TestAlertsConfig struct {
@@ -72,18 +190,166 @@ type TestAlertsConfig struct {
 	WebhookURL string `yaml:"webhookUrl" json:"webhookUrl" validate:"omitempty,url"`
 }
 
+type LocalGraphQLConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	Port   int  `yaml:"port" json:"port" default:"8093" validate:"omitempty,min=1,max=65535"`
+}
+
+type LocalAlertStoreConfig struct {
+	Disable        bool               `yaml:"disable" json:"disable"`
+	RetentionHours int                `yaml:"retentionHours" json:"retentionHours" default:"168" validate:"omitempty,min=1"`
+	GraphQL        LocalGraphQLConfig `yaml:"graphql" json:"graphql"`
+}
+
+type NotificationChannelConfig struct {
+	Type        string `yaml:"type" json:"type" validate:"oneof=slack telegram discord"`
+	WebhookURL  string `yaml:"webhookUrl" json:"webhookUrl" validate:"required_unless=Type telegram,omitempty,url"`
+	BotToken    string `yaml:"botToken" json:"botToken" validate:"required_if=Type telegram"`
+	ChatID      string `yaml:"chatId" json:"chatId" validate:"required_if=Type telegram"`
+	MinSeverity string `yaml:"minSeverity" json:"minSeverity" default:"HIGH" validate:"omitempty,oneof=INFO LOW MEDIUM HIGH CRITICAL"`
+	Template    string `yaml:"template" json:"template"`
+}
+
+type BatchRetryQueueConfig struct {
+	Disable            bool `yaml:"disable" json:"disable"`
+	IntervalSeconds    int  `yaml:"intervalSeconds" json:"intervalSeconds" default:"30" validate:"omitempty,min=1"`
+	MaxIntervalSeconds int  `yaml:"maxIntervalSeconds" json:"maxIntervalSeconds" default:"1800" validate:"omitempty,min=1"` // doubled up to this after each failure
+	MaxBatches         int  `yaml:"maxBatches" json:"maxBatches" default:"1000" validate:"omitempty,min=1"`
+	MaxAttempts        int  `yaml:"maxAttempts" json:"maxAttempts" default:"20" validate:"omitempty,min=1"`
+}
+
+type BatchStorageConfig struct {
+	Type            string            `yaml:"type" json:"type" default:"ipfs" validate:"oneof=ipfs s3 gcs https"`
+	Bucket          string            `yaml:"bucket" json:"bucket"`
+	Prefix          string            `yaml:"prefix" json:"prefix"`
+	Region          string            `yaml:"region" json:"region"`
+	Endpoint        string            `yaml:"endpoint" json:"endpoint" validate:"omitempty,url"`
+	AccessKeyID     string            `yaml:"accessKeyId" json:"accessKeyId"`
+	SecretAccessKey string            `yaml:"secretAccessKey" json:"secretAccessKey"`
+	URL             string            `yaml:"url" json:"url" validate:"required_if=Type https,omitempty,url"`
+	Headers         map[string]string `yaml:"headers" json:"headers"`
+	Compression     string            `yaml:"compression" json:"compression" validate:"omitempty,oneof=gzip zstd"`
+	Pinning         IPFSPinningConfig `yaml:"pinning" json:"pinning"`
+}
+
+type IPFSPinningTarget struct {
+	Name     string            `yaml:"name" json:"name" validate:"required"`
+	APIURL   string            `yaml:"apiUrl" json:"apiUrl" validate:"required,url"`
+	Username string            `yaml:"username" json:"username"`
+	Password string            `yaml:"password" json:"password"`
+	Headers  map[string]string `yaml:"headers" json:"headers"`
+}
+
+type IPFSPinningConfig struct {
+	Targets     []IPFSPinningTarget `yaml:"targets" json:"targets" validate:"dive"`
+	Quorum      int                 `yaml:"quorum" json:"quorum" validate:"omitempty,min=1"` // all targets by default
+	MaxAttempts int                 `yaml:"maxAttempts" json:"maxAttempts" default:"3" validate:"omitempty,min=1"`
+}
+
+type AlertQuotaLimits struct {
+	MaxPerHour int `yaml:"maxPerHour" json:"maxPerHour" validate:"omitempty,min=1"`
+	MaxPerDay  int `yaml:"maxPerDay" json:"maxPerDay" validate:"omitempty,min=1"`
+}
+
+type AlertQuotaConfig struct {
+	Default AlertQuotaLimits            `yaml:"default" json:"default"`
+	Agents  map[string]AlertQuotaLimits `yaml:"agents" json:"agents" validate:"dive"`
+}
+
+type SeverityFilterConfig struct {
+	MinSeverity string            `yaml:"minSeverity" json:"minSeverity" validate:"omitempty,oneof=UNKNOWN INFO LOW MEDIUM HIGH CRITICAL"`
+	Agents      map[string]string `yaml:"agents" json:"agents" validate:"dive,oneof=UNKNOWN INFO LOW MEDIUM HIGH CRITICAL"`
+}
+
+type EnrichmentConfig struct {
+	Enable           bool `yaml:"enable" json:"enable"`
+	DisableTokens    bool `yaml:"disableTokens" json:"disableTokens"`
+	DisableENS       bool `yaml:"disableEns" json:"disableEns"`
+	DisableContracts bool `yaml:"disableContracts" json:"disableContracts"`
+	CreationBlock    bool `yaml:"creationBlock" json:"creationBlock"` // needs an archive node
+	MaxAddresses     int  `yaml:"maxAddresses" json:"maxAddresses" default:"10" validate:"min=1"`
+	CacheTTLMinutes  int  `yaml:"cacheTtlMinutes" json:"cacheTtlMinutes" default:"60" validate:"min=1"`
+	AlertTimeoutMs   int  `yaml:"alertTimeoutMs" json:"alertTimeoutMs" default:"500" validate:"min=1"`
+}
+
+type SinkTLSConfig struct {
+	Enable             bool   `yaml:"enable" json:"enable"`
+	CAFile             string `yaml:"caFile" json:"caFile"`
+	CertFile           string `yaml:"certFile" json:"certFile" validate:"required_with=KeyFile"`
+	KeyFile            string `yaml:"keyFile" json:"keyFile" validate:"required_with=CertFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify" json:"insecureSkipVerify"`
+}
+
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism" json:"mechanism" validate:"omitempty,oneof=plain scram-sha-256 scram-sha-512"`
+	Username  string `yaml:"username" json:"username" validate:"required_with=Mechanism"`
+	Password  string `yaml:"password" json:"password"`
+}
+
+type KafkaSinkConfig struct {
+	Enable     bool            `yaml:"enable" json:"enable"`
+	Brokers    []string        `yaml:"brokers" json:"brokers" validate:"required_if=Enable true"`
+	AlertTopic string          `yaml:"alertTopic" json:"alertTopic" default:"forta-alerts"`
+	BatchTopic string          `yaml:"batchTopic" json:"batchTopic" default:"forta-batches"`
+	TLS        SinkTLSConfig   `yaml:"tls" json:"tls"`
+	SASL       KafkaSASLConfig `yaml:"sasl" json:"sasl"`
+}
+
+type ElasticsearchSinkConfig struct {
+	Enable     bool          `yaml:"enable" json:"enable"`
+	URL        string        `yaml:"url" json:"url" validate:"required_if=Enable true,omitempty,url"`
+	AlertIndex string        `yaml:"alertIndex" json:"alertIndex" default:"forta-alerts"`
+	BatchIndex string        `yaml:"batchIndex" json:"batchIndex" default:"forta-batches"`
+	Username   string        `yaml:"username" json:"username"`
+	Password   string        `yaml:"password" json:"password"`
+	APIKey     string        `yaml:"apiKey" json:"apiKey"`
+	TLS        SinkTLSConfig `yaml:"tls" json:"tls"`
+}
+
+type SinksConfig struct {
+	Kafka         KafkaSinkConfig         `yaml:"kafka" json:"kafka"`
+	Elasticsearch ElasticsearchSinkConfig `yaml:"elasticsearch" json:"elasticsearch"`
+}
+
 type PublisherConfig struct {
-	SkipPublish bool             `yaml:"skipPublish" json:"skipPublish" default:"false"`
-	APIURL      string           `yaml:"apiUrl" json:"apiUrl" default:"https://alerts.forta.network" validate:"url"`
-	IPFS        IPFSConfig       `yaml:"ipfs" json:"ipfs" validate:"required_unless=SkipPublish true"`
-	Batch       BatchConfig      `yaml:"batch" json:"batch"`
-	TestAlerts  TestAlertsConfig `yaml:"testAlerts" json:"testAlerts"`
+	SkipPublish   bool                        `yaml:"skipPublish" json:"skipPublish" default:"false"`
+	APIURL        string                      `yaml:"apiUrl" json:"apiUrl" default:"https://alerts.forta.network" validate:"url"`
+	IPFS          IPFSConfig                  `yaml:"ipfs" json:"ipfs" validate:"required_unless=SkipPublish true"`
+	Batch         BatchConfig                 `yaml:"batch" json:"batch"`
+	TestAlerts    TestAlertsConfig            `yaml:"testAlerts" json:"testAlerts"`
+	LocalStore    LocalAlertStoreConfig       `yaml:"localStore" json:"localStore"`
+	Notifications []NotificationChannelConfig `yaml:"notifications" json:"notifications" validate:"dive"`
+	Filter        SeverityFilterConfig        `yaml:"filter" json:"filter"`
+	Storage       BatchStorageConfig          `yaml:"storage" json:"storage"`
+	RetryQueue    BatchRetryQueueConfig       `yaml:"retryQueue" json:"retryQueue"`
+	Quota         AlertQuotaConfig            `yaml:"quota" json:"quota"`
+	Enrichment    EnrichmentConfig            `yaml:"enrichment" json:"enrichment"`
+	Sinks         SinksConfig                 `yaml:"sinks" json:"sinks"`
 }
 
 type ResourcesConfig struct {
-	DisableAgentLimits bool    `yaml:"disableAgentLimits" json:"disableAgentLimits" default:"false" `
-	AgentMaxMemoryMiB  int     `yaml:"agentMaxMemoryMib" json:"agentMaxMemoryMib" validate:"omitempty,min=100"`
-	AgentMaxCPUs       float64 `yaml:"agentMaxCpus" json:"agentMaxCpus" validate:"omitempty,gt=0"`
+	DisableAgentLimits bool                     `yaml:"disableAgentLimits" json:"disableAgentLimits" default:"false" `
+	AgentMaxMemoryMiB  int                      `yaml:"agentMaxMemoryMib" json:"agentMaxMemoryMib" validate:"omitempty,min=100"`
+	AgentMaxCPUs       float64                  `yaml:"agentMaxCpus" json:"agentMaxCpus" validate:"omitempty,gt=0"`
+	AgentCPUShares     int64                    `yaml:"agentCpuShares" json:"agentCpuShares" validate:"omitempty,min=2"`        // relative weight, the node containers have 1024
+	AgentMaxDiskMiB    int                      `yaml:"agentMaxDiskMib" json:"agentMaxDiskMib" validate:"omitempty,min=100"`    // writable layer quota
+	AgentScratchMiB    int                      `yaml:"agentScratchMib" json:"agentScratchMib" validate:"omitempty,min=1"`      // /tmp tmpfs, counts as memory
+	AgentMaxEgressMbps float64                  `yaml:"agentMaxEgressMbps" json:"agentMaxEgressMbps" validate:"omitempty,gt=0"` // internet upload bandwidth, unlimited if not set
+	AgentOverrides     []AgentResourcesOverride `yaml:"agentOverrides" json:"agentOverrides" validate:"dive"`
+	EnableAgentGPUs    bool                     `yaml:"enableAgentGpus" json:"enableAgentGpus" default:"false" `
+	GPUAgents          []string                 `yaml:"gpuAgents" json:"gpuAgents"` // only these agents get the GPUs if not empty
+}
+
+// AgentResourcesOverride overrides the agent resource limits for a specific agent.
+type AgentResourcesOverride struct {
+	AgentID       string  `yaml:"agentId" json:"agentId" validate:"required"`
+	MaxMemoryMiB  int     `yaml:"maxMemoryMib" json:"maxMemoryMib" validate:"omitempty,min=100"`
+	MaxCPUs       float64 `yaml:"maxCpus" json:"maxCpus" validate:"omitempty,gt=0"`
+	CPUShares     int64   `yaml:"cpuShares" json:"cpuShares" validate:"omitempty,min=2"`
+	MaxDiskMiB    int     `yaml:"maxDiskMib" json:"maxDiskMib" validate:"omitempty,min=100"`
+	ScratchMiB    int     `yaml:"scratchMib" json:"scratchMib" validate:"omitempty,min=1"`
+	MaxEgressMbps float64 `yaml:"maxEgressMbps" json:"maxEgressMbps" validate:"omitempty,gt=0"`
+	Priority      int     `yaml:"priority" json:"priority"` // higher priority agents are shed last under host pressure
 }
 
 type ENSConfig struct {
@@ -98,6 +364,74 @@ type TelemetryConfig struct {
 	Disable bool   `yaml:"disable" json:"disable"`
 }
 
+// TracingConfig configures exporting the spans of the event pipeline to an OpenTelemetry collector.
+type TracingConfig struct {
+	Enable     bool              `yaml:"enable" json:"enable"`
+	Endpoint   string            `yaml:"endpoint" json:"endpoint" default:"host.docker.internal:4317"`
+	Insecure   bool              `yaml:"insecure" json:"insecure"`
+	Headers    map[string]string `yaml:"headers" json:"headers"`
+	SampleRate float64           `yaml:"sampleRate" json:"sampleRate" default:"1" validate:"min=0,max=1"`
+}
+
+// SLAReportConfig enables the periodic performance reports which are signed with the scanner key.
+type SLAReportConfig struct {
+	Enable          bool   `yaml:"enable" json:"enable"`
+	IntervalMinutes int    `yaml:"intervalMinutes" json:"intervalMinutes" default:"60" validate:"min=1"`
+	URL             string `yaml:"url" json:"url" validate:"omitempty,url"` // the reports are only stored if not set
+	MaxReports      int    `yaml:"maxReports" json:"maxReports" default:"720" validate:"min=1"`
+}
+
+// SignerConfig selects where the scanner key is kept. The keystore in the forta dir is used by default.
+type SignerConfig struct {
+	Type   string             `yaml:"type" json:"type" default:"keystore" validate:"oneof=keystore pkcs11 ledger web3signer clef"`
+	PKCS11 PKCS11Config       `yaml:"pkcs11" json:"pkcs11"`
+	Ledger LedgerConfig       `yaml:"ledger" json:"ledger"`
+	Remote RemoteSignerConfig `yaml:"remote" json:"remote"`
+}
+
+// PKCS11Config finds the secp256k1 scanner key in an HSM token.
+type PKCS11Config struct {
+	Module     string `yaml:"module" json:"module"` // path to the PKCS#11 library of the HSM
+	TokenLabel string `yaml:"tokenLabel" json:"tokenLabel"`
+	KeyLabel   string `yaml:"keyLabel" json:"keyLabel"`
+	UserPIN    string `yaml:"userPin" json:"userPin"`
+}
+
+// LedgerConfig selects the scanner account in a Ledger device.
+type LedgerConfig struct {
+	DerivationPath string `yaml:"derivationPath" json:"derivationPath" default:"m/44'/60'/0'/0/0"`
+}
+
+// RemoteSignerConfig points to the external signer which keeps the scanner key.
+type RemoteSignerConfig struct {
+	URL       string `yaml:"url" json:"url" validate:"omitempty,url"`
+	Address   string `yaml:"address" json:"address" validate:"omitempty,eth_addr"` // the scanner account in the signer
+	AuthToken string `yaml:"authToken" json:"authToken"`                           // sent as a bearer token
+}
+
+// APISecurityConfig protects the health, metrics, admin and inspection endpoints of the node
+// services for the operators who expose them beyond localhost. The files are in the Forta dir and
+// the paths are relative to it. The admin API always requires the admin token.
+type APISecurityConfig struct {
+	TLS               bool   `yaml:"tls" json:"tls"`
+	CertFile          string `yaml:"certFile" json:"certFile" validate:"required_with=KeyFile"` // generated if not set
+	KeyFile           string `yaml:"keyFile" json:"keyFile" validate:"required_with=CertFile"`
+	RequireClientCert bool   `yaml:"requireClientCert" json:"requireClientCert"`
+	ClientCAFile      string `yaml:"clientCaFile" json:"clientCaFile"` // the node certificate if not set
+	RequireToken      bool   `yaml:"requireToken" json:"requireToken"` // admin token for the health and graphql endpoints
+}
+
+// ReadinessConfig sets the thresholds of the readiness checks of the node services.
+type ReadinessConfig struct {
+	MaxBlockLagSeconds int `yaml:"maxBlockLagSeconds" json:"maxBlockLagSeconds" default:"300" validate:"min=1"`
+}
+
+// DebugConfig enables the runtime profiling endpoints on the admin API of the node services.
+type DebugConfig struct {
+	Pprof            bool `yaml:"pprof" json:"pprof"`
+	BlockProfileRate int  `yaml:"blockProfileRate" json:"blockProfileRate" default:"10000" validate:"min=0"`
+}
+
 type AutoUpdateConfig struct {
 	Disable     bool `yaml:"disable" json:"disable"`
 	UpdateDelay *int `yaml:"updateDelay" json:"updateDelay"`
@@ -120,6 +454,93 @@ type PrivateModeConfig struct {
 	ContainerRegistry *ContainerRegistryConfig `yaml:"containerRegistry" json:"containerRegistry"`
 }
 
+// ContainerRuntimeConfig selects the container runtime which runs the node and the agent containers.
+type ContainerRuntimeConfig struct {
+	Type   string `yaml:"type" json:"type" default:"docker" validate:"oneof=docker podman"`
+	Socket string `yaml:"socket" json:"socket"` // the default socket of the runtime is used if empty
+}
+
+// message bus types
+const (
+	MessagingTypeNATS     = "nats"
+	MessagingTypeEmbedded = "embedded"
+	MessagingTypeLocal    = "local"
+)
+
+// MessagingConfig selects the message bus which the node services communicate over. The NATS
+// server runs in a separate container by default and inside the supervisor if embedded. The
+// local bus delivers the messages over channels and only connects the services in the same
+// process, so it cannot be used by the node containers.
+type MessagingConfig struct {
+	Type string `yaml:"type" json:"type" default:"nats" validate:"oneof=nats embedded local"`
+}
+
+// IsEmbedded tells if the supervisor runs the message bus server.
+func (mc MessagingConfig) IsEmbedded() bool {
+	return mc.Type == MessagingTypeEmbedded
+}
+
+// IsLocal tells if the messages are delivered in the same process.
+func (mc MessagingConfig) IsLocal() bool {
+	return mc.Type == MessagingTypeLocal
+}
+
+type AgentFilterConfig struct {
+	Allowlist []string `yaml:"allowlist" json:"allowlist"`
+	Denylist  []string `yaml:"denylist" json:"denylist"`
+}
+
+// AgentNetworkConfig controls the outbound access of the agent containers. By default, the agents
+// can only reach the scanner and the JSON-RPC proxy. The DNS, hosts and proxy settings are useful
+// only for the agents which are allowed the egress.
+type AgentNetworkConfig struct {
+	AllowEgress  bool     `yaml:"allowEgress" json:"allowEgress"`   // for all agents
+	EgressAgents []string `yaml:"egressAgents" json:"egressAgents"` // agents which can access the internet
+	DNS          []string `yaml:"dns" json:"dns" validate:"dive,ip"`
+	ExtraHosts   []string `yaml:"extraHosts" json:"extraHosts" validate:"dive,contains=:"` // as "host:ip"
+	HTTPProxy    string   `yaml:"httpProxy" json:"httpProxy" validate:"omitempty,url"`
+	HTTPSProxy   string   `yaml:"httpsProxy" json:"httpsProxy" validate:"omitempty,url"`
+	NoProxy      []string `yaml:"noProxy" json:"noProxy"` // the node containers are never proxied
+}
+
+// AgentSecurityConfig controls the hardening of the agent containers. By default, the agents run
+// with a read-only root filesystem, no new privileges, the default seccomp profile of the runtime
+// and no capabilities. The agents can always write to the /tmp scratch space.
+type AgentSecurityConfig struct {
+	DisableHardening bool     `yaml:"disableHardening" json:"disableHardening"`
+	WritableRootFS   bool     `yaml:"writableRootFs" json:"writableRootFs"`
+	AddCapabilities  []string `yaml:"addCapabilities" json:"addCapabilities"`
+	SeccompProfile   string   `yaml:"seccompProfile" json:"seccompProfile"` // file in the forta dir or "unconfined"
+	UserNamespace    string   `yaml:"userNamespace" json:"userNamespace"`   // e.g. "auto" with podman
+}
+
+// AgentCleanupConfig controls the periodic removal of the agent images which are no longer
+// used and the leftover agent containers.
+type AgentCleanupConfig struct {
+	Disable             bool `yaml:"disable" json:"disable"`
+	IntervalMinutes     int  `yaml:"intervalMinutes" json:"intervalMinutes" default:"60" validate:"omitempty,min=1"`
+	ImageRetentionHours int  `yaml:"imageRetentionHours" json:"imageRetentionHours" default:"24" validate:"omitempty,min=0"` // keeps the unused images for a while in case they are assigned again
+}
+
+// HostWatermarksConfig protects the node from running out of host resources by shedding the lowest
+// priority agents when the usage crosses a watermark. Pausing the agents only relieves the CPU and
+// stopping them also frees the memory. The disk watermark is only reported since shedding the agents
+// does not free the disk.
+type HostWatermarksConfig struct {
+	Enable               bool    `yaml:"enable" json:"enable"`
+	MemoryPercent        float64 `yaml:"memoryPercent" json:"memoryPercent" default:"90" validate:"gt=0,max=100"` // used only with the stop action
+	DiskPercent          float64 `yaml:"diskPercent" json:"diskPercent" default:"90" validate:"gt=0,max=100"`
+	CPUPercent           float64 `yaml:"cpuPercent" json:"cpuPercent" default:"95" validate:"gt=0,max=100"`
+	ResumeMarginPercent  float64 `yaml:"resumeMarginPercent" json:"resumeMarginPercent" default:"10" validate:"min=0,max=100"` // usage should fall this much below the watermarks to resume the agents
+	Action               string  `yaml:"action" json:"action" default:"pause" validate:"oneof=pause stop"`                     // stop also stops the agent containers to free the memory
+	CheckIntervalSeconds int     `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"15" validate:"min=1"`
+}
+
+type AgentScalingConfig struct {
+	Replicas uint `yaml:"replicas" json:"replicas" validate:"omitempty,min=1"`
+	Stateful bool `yaml:"stateful" json:"stateful"`
+}
+
 type Config struct {
 	// runtime values
 
@@ -133,24 +554,47 @@ type Config struct {
 	AgentRegistryContractAddress   string         `yaml:"-" json:"_agentRegistryContractAddress"`
 	ScannerVersionContractAddress  string         `yaml:"-" json:"_scannerVersionContractAddress"`
 	ScannerRegistryContractAddress string         `yaml:"-" json:"_scannerRegistryContractAddress"`
+	Secrets                        Secrets        `yaml:"-" json:"-"`
+	ChainSettings                  ChainSettings  `yaml:"-" json:"_chainSettings"`
+	Replay                         ReplayConfig   `yaml:"-" json:"_replay"`
 
 	// yaml config values
 
+	Version int `yaml:"version" json:"version"` // see CurrentConfigVersion
 	ChainID int `yaml:"chainId" json:"chainId" default:"1" `
 
+	ChainProfile  string                        `yaml:"chainProfile" json:"chainProfile"` // the built-in profile of the chain by default
+	ChainProfiles map[string]ChainProfileConfig `yaml:"chainProfiles" json:"chainProfiles" validate:"dive"`
+
 	Scan  ScannerConfig `yaml:"scan" json:"scan"`
 	Trace TraceConfig   `yaml:"trace" json:"trace"`
 
-	Registry          RegistryConfig     `yaml:"registry" json:"registry"`
-	Publish           PublisherConfig    `yaml:"publish" json:"publish"`
-	JsonRpcProxy      JsonRpcProxyConfig `yaml:"jsonRpcProxy" json:"jsonRpcProxy"`
-	Log               LogConfig          `yaml:"log" json:"log"`
-	ResourcesConfig   ResourcesConfig    `yaml:"resources" json:"resources"`
-	ENSConfig         ENSConfig          `yaml:"ens" json:"ens"`
-	TelemetryConfig   TelemetryConfig    `yaml:"telemetry" json:"telemetry"`
-	AutoUpdate        AutoUpdateConfig   `yaml:"autoUpdate" json:"autoUpdate"`
-	AgentLogsConfig   AgentLogsConfig    `yaml:"agentLogs" json:"agentLogs"`
-	PrivateModeConfig PrivateModeConfig  `yaml:"privateMode" json:"privateMode"`
+	Registry          RegistryConfig         `yaml:"registry" json:"registry"`
+	Publish           PublisherConfig        `yaml:"publish" json:"publish"`
+	JsonRpcProxy      JsonRpcProxyConfig     `yaml:"jsonRpcProxy" json:"jsonRpcProxy"`
+	Log               LogConfig              `yaml:"log" json:"log"`
+	ResourcesConfig   ResourcesConfig        `yaml:"resources" json:"resources"`
+	ENSConfig         ENSConfig              `yaml:"ens" json:"ens"`
+	TelemetryConfig   TelemetryConfig        `yaml:"telemetry" json:"telemetry"`
+	AutoUpdate        AutoUpdateConfig       `yaml:"autoUpdate" json:"autoUpdate"`
+	AgentLogsConfig   AgentLogsConfig        `yaml:"agentLogs" json:"agentLogs"`
+	PrivateModeConfig PrivateModeConfig      `yaml:"privateMode" json:"privateMode"`
+	AgentFilter       AgentFilterConfig      `yaml:"agentFilter" json:"agentFilter"`
+	AgentNetwork      AgentNetworkConfig     `yaml:"agentNetwork" json:"agentNetwork"`
+	AgentSecurity     AgentSecurityConfig    `yaml:"agentSecurity" json:"agentSecurity"`
+	AgentCleanup      AgentCleanupConfig     `yaml:"agentCleanup" json:"agentCleanup"`
+	HostWatermarks    HostWatermarksConfig   `yaml:"hostWatermarks" json:"hostWatermarks"`
+	ContainerRuntime  ContainerRuntimeConfig `yaml:"containerRuntime" json:"containerRuntime"`
+	RemoteConfig      RemoteConfigConfig     `yaml:"remoteConfig" json:"remoteConfig"`
+	Messaging         MessagingConfig        `yaml:"messaging" json:"messaging"`
+	Tracing           TracingConfig          `yaml:"tracing" json:"tracing"`
+	Debug             DebugConfig            `yaml:"debug" json:"debug"`
+	Readiness         ReadinessConfig        `yaml:"readiness" json:"readiness"`
+	SLAReport         SLAReportConfig        `yaml:"slaReport" json:"slaReport"`
+	Signer            SignerConfig           `yaml:"signer" json:"signer"`
+	APISecurity       APISecurityConfig      `yaml:"apiSecurity" json:"apiSecurity"`
+
+	AgentScaling map[string]AgentScalingConfig `yaml:"agentScaling" json:"agentScaling"`
 }
 
 func (cfg *Config) ConfigFilePath() string {
@@ -167,15 +611,28 @@ func GetConfigForContainer() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	if err := mergeRemoteConfig(&cfg, DefaultContainerRemoteConfigPath); err != nil {
+		return Config{}, err
+	}
+	secrets, err := readSecrets(DefaultContainerSecretsPath)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := secrets.Apply(&cfg); err != nil {
+		return Config{}, err
+	}
 	applyContextDefaults(&cfg)
+	if err := applyReplay(&cfg, DefaultContainerReplayPath); err != nil {
+		return Config{}, err
+	}
+	if err := applyChainSettings(&cfg); err != nil {
+		return Config{}, err
+	}
 	return cfg, nil
 }
 
 // apply defaults that apply in certain contexts
 func applyContextDefaults(cfg *Config) {
-	if cfg.ChainID == 1 {
-		cfg.Trace.Enabled = true
-	}
 	if cfg.ENSConfig.DefaultContract {
 		cfg.ENSConfig.ContractAddress = ""
 	}
@@ -184,8 +641,20 @@ func applyContextDefaults(cfg *Config) {
 }
 
 func getConfigFromFile(filename string) (Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	// the runner migrates the file but the containers can be updated before
+	migrated, _, err := MigrateConfig(b)
+	if err != nil {
+		return Config{}, err
+	}
+	if migrated != nil {
+		b = migrated
+	}
 	var cfg Config
-	if err := readFile(filename, &cfg); err != nil {
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
 		return Config{}, err
 	}
 	if err := defaults.Set(&cfg); err != nil {
@@ -27,4 +27,7 @@ var (
 	DefaultContainerConfigPath          = path.Join(DefaultContainerFortaDirPath, DefaultConfigFileName)
 	DefaultContainerKeyDirPath          = path.Join(DefaultContainerFortaDirPath, DefaultKeysDirName)
 	DefaultContainerLocalAgentsFilePath = path.Join(DefaultContainerFortaDirPath, DefaultLocalAgentsFileName)
+	DefaultContainerRemoteConfigPath    = path.Join(DefaultContainerFortaDirPath, DefaultRemoteConfigFileName)
+	DefaultContainerSecretsPath         = path.Join("/", DefaultSecretsFileName) // passed by the parent container
+	DefaultContainerReplayPath          = path.Join(DefaultContainerFortaDirPath, DefaultReplayFileName)
 )
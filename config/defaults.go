@@ -1,11 +1,26 @@
 package config
 
 const (
-	DefaultLocalAgentsFileName = "local-agents.json"
-	DefaultKeysDirName         = ".keys"
-	DefaultConfigFileName      = "config.yml"
-	DefaultNatsPort            = "4222"
-	DefaultContainerPort       = "8089"
-	DefaultHealthPort          = "8090"
-	DefaultFortaNodeBinaryPath = "/forta-node" // the path for the common binary in the container image
+	DefaultLocalAgentsFileName    = "local-agents.json"
+	DefaultAgentsFileName         = "agents.yml"
+	DefaultKeysDirName            = ".keys"
+	DefaultConfigFileName         = "config.yml"
+	DefaultRemoteConfigFileName   = "remote-config.yml"
+	DefaultNatsPort               = "4222"
+	DefaultContainerPort          = "8089"
+	DefaultHealthPort             = "8090"
+	DefaultAdminPort              = "8091"
+	DefaultGrpcHealthPort         = "8092"
+	DefaultGraphQLPort            = "8093"
+	DefaultAdminTokenFileName     = ".admin-token"
+	DefaultTLSCertFileName        = "tls/node.crt"
+	DefaultTLSKeyFileName         = "tls/node.key"
+	DefaultAlertStoreDirName      = "alerts"
+	DefaultBatchQueueDirName      = "batch-queue"
+	DefaultDeadLetterDirName      = "dead-letter"
+	DefaultAuditLogDirName        = "audit"
+	DefaultSecretsFileName        = "secrets"
+	DefaultReplayFileName         = "replay.json"
+	DefaultReplayFindingsFileName = "replay-findings.jsonl"
+	DefaultFortaNodeBinaryPath    = "/forta-node" // the path for the common binary in the container image
 )
@@ -5,10 +5,13 @@ const (
 	EnvDevelopment  = "FORTA_DEVELOPMENT"
 	EnvReleaseInfo  = "FORTA_RELEASE_INFO"
 
+	EnvHostRuntimeSocket = "HOST_RUNTIME_SOCKET" // for mounting the container runtime socket on the host os
+
 	// Agent env vars
-	EnvJsonRpcHost   = "JSON_RPC_HOST"
-	EnvJsonRpcPort   = "JSON_RPC_PORT"
-	EnvAgentGrpcPort = "AGENT_GRPC_PORT"
+	EnvJsonRpcHost    = "JSON_RPC_HOST"
+	EnvJsonRpcPort    = "JSON_RPC_PORT"
+	EnvAgentGrpcPort  = "AGENT_GRPC_PORT"
+	EnvAgentGrpcToken = "AGENT_GRPC_TOKEN"
 )
 
 // EnvDefaults contain default values for one env.
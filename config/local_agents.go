@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LocalAgentDefinition declares a private agent which is not published to the registry.
+type LocalAgentDefinition struct {
+	ID       string  `yaml:"id" json:"id"`
+	Image    string  `yaml:"image" json:"image"`
+	ChainIDs []int64 `yaml:"chainIds" json:"chainIds"` // all chains if empty
+}
+
+// LocalAgentDefinitions is the contents of the local agent definitions file.
+type LocalAgentDefinitions struct {
+	Agents []LocalAgentDefinition `yaml:"agents" json:"agents"`
+}
+
+// ParseLocalAgentDefinitions parses the local agent definitions and returns the agents
+// which should run on the given chain.
+func ParseLocalAgentDefinitions(b []byte, chainID int) ([]*AgentConfig, error) {
+	var defs LocalAgentDefinitions
+	if err := yaml.Unmarshal(b, &defs); err != nil {
+		return nil, fmt.Errorf("failed to parse the agent definitions: %v", err)
+	}
+	var agents []*AgentConfig
+	for i, def := range defs.Agents {
+		if len(def.ID) == 0 || len(def.Image) == 0 {
+			return nil, fmt.Errorf("agent definition #%d: id and image are required", i+1)
+		}
+		if !def.runsOn(chainID) {
+			continue
+		}
+		agents = append(agents, &AgentConfig{
+			ID:      def.ID,
+			Image:   def.Image,
+			IsLocal: true,
+		})
+	}
+	return agents, nil
+}
+
+func (def LocalAgentDefinition) runsOn(chainID int) bool {
+	if len(def.ChainIDs) == 0 {
+		return true
+	}
+	for _, id := range def.ChainIDs {
+		if id == int64(chainID) {
+			return true
+		}
+	}
+	return false
+}
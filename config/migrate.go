@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the version of the config file schema. The config template in 'forta init'
+// should be updated together with this.
+const CurrentConfigVersion = 1
+
+// configMigration upgrades the config file from the previous version to the version. Migrate
+// returns true if it changed the config.
+type configMigration struct {
+	Version     int
+	Description string
+	Migrate     func(root *yaml.Node) (bool, error)
+}
+
+// configMigrations are the migrations of the released config versions. Version 1 only introduced
+// the version key so it has no migration.
+var configMigrations []configMigration
+
+// MigrateConfig upgrades the config to the current version and returns the descriptions of the
+// applied migrations. The migrated config is nil if none of the migrations changed the config.
+func MigrateConfig(b []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the config: %v", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, nil // empty
+	}
+	root := doc.Content[0]
+
+	version, err := getConfigVersion(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("config version %d is newer than the supported version %d - please update the node", version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return nil, nil, nil
+	}
+
+	var applied []string
+	for _, migration := range configMigrations {
+		if migration.Version <= version {
+			continue
+		}
+		changed, err := migration.Migrate(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate the config to version %d: %v", migration.Version, err)
+		}
+		if changed {
+			applied = append(applied, migration.Description)
+		}
+	}
+	// the config is not rewritten only to bump the version
+	if len(applied) == 0 {
+		return nil, nil, nil
+	}
+	setConfigVersion(root, CurrentConfigVersion)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode the migrated config: %v", err)
+	}
+	return buf.Bytes(), applied, nil
+}
+
+// MigrateConfigFile upgrades the config file in place after saving the old version as
+// <file>.v<version>.bak and returns the descriptions of the applied migrations. The backup and
+// the migrated file keep the mode of the config file.
+func MigrateConfigFile(filename string) ([]string, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	mode := info.Mode().Perm()
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	migrated, applied, err := MigrateConfig(b)
+	if err != nil || migrated == nil {
+		return nil, err
+	}
+
+	var old struct {
+		Version int `yaml:"version"`
+	}
+	_ = yaml.Unmarshal(b, &old)
+	backupPath := fmt.Sprintf("%s.v%d.bak", filename, old.Version)
+	if err := writeFileWithMode(backupPath, b, mode); err != nil {
+		return nil, fmt.Errorf("failed to back up the config file: %v", err)
+	}
+	tmpPath := filename + ".tmp"
+	if err := writeFileWithMode(tmpPath, migrated, mode); err != nil {
+		return nil, fmt.Errorf("failed to write the migrated config file: %v", err)
+	}
+	if err := os.Rename(tmpPath, filename); err != nil {
+		return nil, fmt.Errorf("failed to replace the config file: %v", err)
+	}
+	return applied, nil
+}
+
+// writeFileWithMode writes the file with the exact mode regardless of the umask and of the mode
+// of an existing file.
+func writeFileWithMode(filename string, b []byte, mode os.FileMode) error {
+	if err := ioutil.WriteFile(filename, b, mode); err != nil {
+		return err
+	}
+	return os.Chmod(filename, mode)
+}
+
+func getConfigVersion(root *yaml.Node) (int, error) {
+	value := getConfigKey(root, "version")
+	if value == nil {
+		return 0, nil // before the versioning
+	}
+	version, err := strconv.Atoi(value.Value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid config version '%s'", value.Value)
+	}
+	return version, nil
+}
+
+// setConfigVersion sets the version at the top of the config.
+func setConfigVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if getConfigKey(root, "version") != nil {
+		setMappingValue(root, "version", value)
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// moveConfigKey moves the value to a new dot-separated path. The value at the new path is kept if it
+// is already set.
+func moveConfigKey(from, to string) func(root *yaml.Node) (bool, error) {
+	return func(root *yaml.Node) (bool, error) {
+		value := removeConfigKey(root, from)
+		if value == nil {
+			return false, nil
+		}
+		if getConfigKey(root, to) == nil {
+			setConfigKey(root, to, value)
+		}
+		return true, nil
+	}
+}
+
+// getConfigKey returns the value at the dot-separated path.
+func getConfigKey(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	for _, key := range strings.Split(path, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		_, value := findConfigKey(node, key)
+		if value == nil {
+			return nil
+		}
+		node = value
+	}
+	return node
+}
+
+// setConfigKey sets the value at the dot-separated path and creates the missing parents.
+func setConfigKey(root *yaml.Node, path string, value *yaml.Node) {
+	keys := strings.Split(path, ".")
+	node := root
+	for _, key := range keys[:len(keys)-1] {
+		_, next := findConfigKey(node, key)
+		if next == nil || next.Kind != yaml.MappingNode {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(node, key, next)
+		}
+		node = next
+	}
+	setMappingValue(node, keys[len(keys)-1], value)
+}
+
+// removeConfigKey removes the value at the dot-separated path and returns it.
+func removeConfigKey(root *yaml.Node, path string) *yaml.Node {
+	keys := strings.Split(path, ".")
+	parent := root
+	if len(keys) > 1 {
+		parent = getConfigKey(root, strings.Join(keys[:len(keys)-1], "."))
+	}
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	i, value := findConfigKey(parent, keys[len(keys)-1])
+	if value == nil {
+		return nil
+	}
+	parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+	return value
+}
+
+func findConfigKey(mapping *yaml.Node, key string) (int, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i, mapping.Content[i+1]
+		}
+	}
+	return -1, nil
+}
+
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	if i, existing := findConfigKey(mapping, key); existing != nil {
+		mapping.Content[i+1] = value
+		return
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
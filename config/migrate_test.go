@@ -0,0 +1,142 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testConfigV0 = `# Auto generated by 'forta init' - safe to modify
+
+# The chainId is the chainId of the network that is analyzed (1=mainnet)
+chainId: 137
+
+old:
+  size: 512 # some size
+  enabled: true
+`
+
+func withTestMigrations(t *testing.T) {
+	configMigrations = []configMigration{
+		{
+			Version:     1,
+			Description: "moved old.size to new.size",
+			Migrate:     moveConfigKey("old.size", "new.size"),
+		},
+	}
+	t.Cleanup(func() {
+		configMigrations = nil
+	})
+}
+
+func parseTestConfig(t *testing.T, b []byte) *yaml.Node {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal(b, &doc))
+	return doc.Content[0]
+}
+
+func TestMigrateConfig(t *testing.T) {
+	r := require.New(t)
+
+	withTestMigrations(t)
+
+	migrated, applied, err := MigrateConfig([]byte(testConfigV0))
+	r.NoError(err)
+	r.Len(applied, 1)
+	r.True(strings.HasPrefix(string(migrated), "# Auto generated by 'forta init' - safe to modify\n\nversion: 1\n"), string(migrated))
+	r.Contains(string(migrated), "# The chainId is the chainId")
+
+	root := parseTestConfig(t, migrated)
+	r.Equal("137", getConfigKey(root, "chainId").Value)
+	r.Equal("512", getConfigKey(root, "new.size").Value)
+	r.Nil(getConfigKey(root, "old.size"))
+	r.Equal("true", getConfigKey(root, "old.enabled").Value)
+
+	// up-to-date
+	migrated, applied, err = MigrateConfig(migrated)
+	r.NoError(err)
+	r.Nil(migrated)
+	r.Empty(applied)
+
+	// the new key is kept
+	migrated, _, err = MigrateConfig([]byte("old:\n  size: 512\nnew:\n  size: 64\n"))
+	r.NoError(err)
+	r.Equal("64", getConfigKey(parseTestConfig(t, migrated), "new.size").Value)
+
+	_, _, err = MigrateConfig([]byte("version: 100\n"))
+	r.Error(err)
+}
+
+func TestMigrateConfigNoChange(t *testing.T) {
+	r := require.New(t)
+
+	// the released migrations do not change a config without the moved keys
+	migrated, applied, err := MigrateConfig([]byte(testConfigV0))
+	r.NoError(err)
+	r.Nil(migrated)
+	r.Empty(applied)
+
+	withTestMigrations(t)
+	migrated, applied, err = MigrateConfig([]byte("chainId: 137\n"))
+	r.NoError(err)
+	r.Nil(migrated)
+	r.Empty(applied)
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	r := require.New(t)
+
+	withTestMigrations(t)
+
+	filename := path.Join(t.TempDir(), DefaultConfigFileName)
+	r.NoError(ioutil.WriteFile(filename, []byte(testConfigV0), 0600))
+
+	applied, err := MigrateConfigFile(filename)
+	r.NoError(err)
+	r.Len(applied, 1)
+
+	backup, err := ioutil.ReadFile(filename + ".v0.bak")
+	r.NoError(err)
+	r.Equal(testConfigV0, string(backup))
+
+	// the mode of the config file is kept
+	for _, file := range []string{filename, filename + ".v0.bak"} {
+		info, err := os.Stat(file)
+		r.NoError(err)
+		r.Equal(os.FileMode(0600), info.Mode().Perm())
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	r.NoError(err)
+	r.Equal("512", getConfigKey(parseTestConfig(t, b), "new.size").Value)
+
+	applied, err = MigrateConfigFile(filename)
+	r.NoError(err)
+	r.Empty(applied)
+}
+
+func TestMigrateConfigFileNoChange(t *testing.T) {
+	r := require.New(t)
+
+	withTestMigrations(t)
+
+	dir := t.TempDir()
+	filename := path.Join(dir, DefaultConfigFileName)
+	r.NoError(ioutil.WriteFile(filename, []byte("chainId: 137\n"), 0600))
+
+	applied, err := MigrateConfigFile(filename)
+	r.NoError(err)
+	r.Empty(applied)
+
+	b, err := ioutil.ReadFile(filename)
+	r.NoError(err)
+	r.Equal("chainId: 137\n", string(b))
+	files, err := ioutil.ReadDir(dir)
+	r.NoError(err)
+	r.Len(files, 1, "no backup")
+}
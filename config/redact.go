@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the sensitive values.
+const RedactedValue = "<redacted>"
+
+// sensitiveKeys are the lowercase key fragments which mark the values as sensitive.
+var sensitiveKeys = []string{"passphrase", "password", "secret", "token", "privatekey", "apikey", "auth", "credential", "userpin"}
+
+var privateKeyRegexp = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+
+// RedactConfig removes the keys, secrets and the URL credentials from the config file contents.
+// The secret references are kept as they are. It returns the redacted config and the removed
+// values so that they can be redacted from the other outputs as well.
+func RedactConfig(b []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the config: %v", err)
+	}
+	var values []string
+	redactNode(&doc, false, &values)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode the redacted config: %v", err)
+	}
+	return buf.Bytes(), values, nil
+}
+
+func redactNode(node *yaml.Node, sensitive bool, values *[]string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			redactNode(child, sensitive, values)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			redactNode(node.Content[i+1], sensitive || isSensitiveKey(node.Content[i].Value), values)
+		}
+
+	case yaml.ScalarNode:
+		if len(node.Value) == 0 || IsSecretRef(node.Value) {
+			return
+		}
+		if sensitive || privateKeyRegexp.MatchString(node.Value) {
+			*values = append(*values, node.Value)
+			node.Value = RedactedValue
+			node.Tag = "!!str"
+			return
+		}
+		if redacted, ok := redactURL(node.Value); ok {
+			*values = append(*values, node.Value)
+			node.Value = redacted
+		}
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL removes the credentials, the path and the query from the URL because the
+// providers usually put the API keys there.
+func redactURL(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", false
+	}
+	if u.User == nil && strings.Trim(u.Path, "/") == "" && len(u.RawQuery) == 0 {
+		return "", false
+	}
+	redacted := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	if u.User != nil {
+		redacted = fmt.Sprintf("%s://%s@%s", u.Scheme, RedactedValue, u.Host)
+	}
+	if strings.Trim(u.Path, "/") != "" || len(u.RawQuery) > 0 {
+		redacted += "/" + RedactedValue
+	}
+	return redacted, true
+}
+
+// Redact replaces the given values in the text.
+func Redact(s string, values []string) string {
+	sorted := append([]string{}, values...)
+	// replace the longest values first so that the overlapping values are fully redacted
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	for _, value := range sorted {
+		if len(value) < 4 {
+			continue // too short to redact safely
+		}
+		s = strings.ReplaceAll(s, value, RedactedValue)
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ReloadableFields are the config fields which the node applies without restarting the containers.
+var ReloadableFields = []string{
+	"log.level",
+	"log.components",
+	"jsonRpcProxy.jsonRpc",
+	"jsonRpcProxy.chains",
+	"jsonRpcProxy.rateLimit",
+	"jsonRpcProxy.maxConcurrentRequests",
+	"jsonRpcProxy.agentLimits",
+	"publish.filter",
+}
+
+// ChangedFields returns the paths of the config fields which are different in the new config.
+func ChangedFields(oldCfg, newCfg Config) []string {
+	return changedFields("", reflect.ValueOf(oldCfg), reflect.ValueOf(newCfg))
+}
+
+func changedFields(prefix string, oldVal, newVal reflect.Value) []string {
+	if oldVal.Kind() != reflect.Struct {
+		if reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var (
+		changed []string
+		fields  int
+	)
+	for i := 0; i < oldVal.NumField(); i++ {
+		name := strings.Split(oldVal.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue // runtime values
+		}
+		fields++
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+		changed = append(changed, changedFields(name, oldVal.Field(i), newVal.Field(i))...)
+	}
+	// compare as a whole if this is not a config struct
+	if fields == 0 && !reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+		return []string{prefix}
+	}
+	return changed
+}
+
+// IsReloadable tells if the field at the path can be applied without a restart.
+func IsReloadable(path string) bool {
+	for _, field := range ReloadableFields {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitReloadable splits the changed fields into the ones which can be reloaded and the ones
+// which require a restart.
+func SplitReloadable(changed []string) (reloadable []string, restart []string) {
+	for _, path := range changed {
+		if IsReloadable(path) {
+			reloadable = append(reloadable, path)
+		} else {
+			restart = append(restart, path)
+		}
+	}
+	return
+}
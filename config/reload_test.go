@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedFields(t *testing.T) {
+	var oldCfg, newCfg Config
+	oldCfg.Log.Level = "info"
+	newCfg.Log.Level = "debug"
+	newCfg.JsonRpcProxy.RateLimitConfig = &RateLimitConfig{Rate: 10, Burst: 10}
+	newCfg.Scan.JsonRpc.Url = "http://localhost:8545"
+	newCfg.FortaDir = "/.forta" // not a config file field
+
+	changed := ChangedFields(oldCfg, newCfg)
+	assert.ElementsMatch(t, []string{"log.level", "jsonRpcProxy.rateLimit", "scan.jsonRpc.url"}, changed)
+
+	reloadable, restart := SplitReloadable(changed)
+	assert.ElementsMatch(t, []string{"log.level", "jsonRpcProxy.rateLimit"}, reloadable)
+	assert.Equal(t, []string{"scan.jsonRpc.url"}, restart)
+
+	assert.Empty(t, ChangedFields(newCfg, newCfg))
+}
+
+func TestIsReloadable(t *testing.T) {
+	assert.True(t, IsReloadable("jsonRpcProxy.jsonRpc.url"))
+	assert.True(t, IsReloadable("publish.filter"))
+	assert.False(t, IsReloadable("jsonRpcProxy.jsonRpcFoo"))
+	assert.False(t, IsReloadable("log.maxLogSize"))
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RemoteConfigConfig is the config of the signed remote config which overrides the local config.
+type RemoteConfigConfig struct {
+	URL                    string `yaml:"url" json:"url" validate:"omitempty,url"`
+	SignatureURL           string `yaml:"signatureUrl" json:"signatureUrl" validate:"omitempty,url"` // <url>.sig by default
+	SignerAddress          string `yaml:"signerAddress" json:"signerAddress" validate:"required_with=URL,omitempty,eth_addr"`
+	RefreshIntervalSeconds int    `yaml:"refreshIntervalSeconds" json:"refreshIntervalSeconds" default:"300" validate:"omitempty,min=10"`
+}
+
+// Enabled tells if the remote config is enabled.
+func (rcc RemoteConfigConfig) Enabled() bool {
+	return len(rcc.URL) > 0
+}
+
+// GetSignatureURL returns the URL of the remote config signature.
+func (rcc RemoteConfigConfig) GetSignatureURL() string {
+	if len(rcc.SignatureURL) > 0 {
+		return rcc.SignatureURL
+	}
+	return rcc.URL + ".sig"
+}
+
+// RemoteConfigSignature is the content of the remote config signature file. The signature covers
+// the timestamp too so that the older configs cannot be replayed.
+type RemoteConfigSignature struct {
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+// ParseRemoteConfigSignature parses the remote config signature file.
+func ParseRemoteConfigSignature(b []byte) (*RemoteConfigSignature, error) {
+	var sig RemoteConfigSignature
+	if err := json.Unmarshal(b, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse the remote config signature: %v", err)
+	}
+	if sig.Timestamp <= 0 || len(sig.Signature) == 0 {
+		return nil, errors.New("the remote config signature has no timestamp or signature")
+	}
+	return &sig, nil
+}
+
+// RemoteConfigSignedBytes returns the bytes which are signed for the remote config.
+func RemoteConfigSignedBytes(timestamp int64, b []byte) []byte {
+	return append([]byte(fmt.Sprintf("%d\n", timestamp)), b...)
+}
+
+// CheckRemoteConfig checks if the remote config contains only the known fields and does not
+// change the remote config settings.
+func CheckRemoteConfig(b []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("failed to parse the remote config: %v", err)
+	}
+	if len(root.Content) == 0 {
+		return errors.New("the remote config is empty")
+	}
+	var problems []string
+	for _, problem := range checkKeys(root.Content[0], reflect.TypeOf(Config{}), "", make(map[int]string)) {
+		problems = append(problems, problem.Error())
+	}
+	var remoteCfg struct {
+		RemoteConfig *RemoteConfigConfig `yaml:"remoteConfig"`
+	}
+	if err := yaml.Unmarshal(b, &remoteCfg); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if remoteCfg.RemoteConfig != nil {
+		problems = append(problems, "remoteConfig: cannot be set remotely")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid remote config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// mergeRemoteConfig overrides the config with the values from the remote config file.
+func mergeRemoteConfig(cfg *Config, filename string) error {
+	if !cfg.RemoteConfig.Enabled() {
+		return nil
+	}
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil // not fetched yet
+	}
+	remoteCfg := cfg.RemoteConfig
+	if err := readFile(filename, cfg); err != nil {
+		return fmt.Errorf("failed to read the remote config: %v", err)
+	}
+	cfg.RemoteConfig = remoteCfg
+	return nil
+}
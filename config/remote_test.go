@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRemoteConfig(t *testing.T) {
+	r := require.New(t)
+
+	r.NoError(CheckRemoteConfig([]byte("log:\n  level: debug\njsonRpcProxy:\n  rateLimit:\n    rate: 10\n    burst: 10\n")))
+	r.Error(CheckRemoteConfig([]byte("log:\n  levl: debug\n")))
+	r.Error(CheckRemoteConfig([]byte("remoteConfig:\n  url: https://example.com\n")))
+	r.Error(CheckRemoteConfig([]byte("")))
+}
+
+func TestParseRemoteConfigSignature(t *testing.T) {
+	r := require.New(t)
+
+	sig, err := ParseRemoteConfigSignature([]byte(`{"timestamp":100,"signature":"0x1234"}`))
+	r.NoError(err)
+	r.Equal(int64(100), sig.Timestamp)
+	r.Equal("0x1234", sig.Signature)
+
+	_, err = ParseRemoteConfigSignature([]byte("0x1234"))
+	r.Error(err)
+	_, err = ParseRemoteConfigSignature([]byte(`{"signature":"0x1234"}`))
+	r.Error(err)
+}
+
+func TestMergeRemoteConfig(t *testing.T) {
+	r := require.New(t)
+
+	filename := path.Join(t.TempDir(), DefaultRemoteConfigFileName)
+
+	var cfg Config
+	cfg.Log.Level = "info"
+	cfg.Scan.JsonRpc.Url = "http://localhost:8545"
+	cfg.RemoteConfig.URL = "https://example.com/config.yml"
+	r.NoError(mergeRemoteConfig(&cfg, filename), "not fetched yet")
+
+	r.NoError(ioutil.WriteFile(filename, []byte("log:\n  level: debug\nremoteConfig:\n  url: https://another.com\n"), 0644))
+	r.NoError(mergeRemoteConfig(&cfg, filename))
+	r.Equal("debug", cfg.Log.Level)
+	r.Equal("http://localhost:8545", cfg.Scan.JsonRpc.Url)
+	r.Equal("https://example.com/config.yml", cfg.RemoteConfig.URL)
+
+	cfg.RemoteConfig.URL = ""
+	cfg.Log.Level = "info"
+	r.NoError(mergeRemoteConfig(&cfg, filename))
+	r.Equal("info", cfg.Log.Level, "disabled")
+}
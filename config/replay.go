@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// ReplayConfig makes the node run the given agents over a historical block range and write the
+// findings to a file instead of publishing them. It is written to the Forta dir by 'forta replay'.
+type ReplayConfig struct {
+	ChainID   int            `json:"chainId"`
+	FromBlock uint64         `json:"fromBlock"`
+	ToBlock   uint64         `json:"toBlock"`
+	Agents    []*AgentConfig `json:"agents"`
+}
+
+// Enabled tells if the node is replaying blocks.
+func (rc ReplayConfig) Enabled() bool {
+	return rc.ToBlock > 0
+}
+
+// ReplayFilePath returns the path of the replay file in the Forta dir.
+func ReplayFilePath(fortaDir string) string {
+	return path.Join(fortaDir, DefaultReplayFileName)
+}
+
+// ReplayFindingsFilePath returns the path of the replay findings file in the Forta dir.
+func ReplayFindingsFilePath(fortaDir string) string {
+	return path.Join(fortaDir, DefaultReplayFindingsFileName)
+}
+
+// WriteReplayFile writes the replay config to the Forta dir.
+func WriteReplayFile(fortaDir string, replay ReplayConfig) error {
+	b, _ := json.MarshalIndent(replay, "", "  ")
+	return ioutil.WriteFile(ReplayFilePath(fortaDir), b, 0644)
+}
+
+// RemoveReplayFile removes the replay config from the Forta dir so that the node scans normally.
+func RemoveReplayFile(fortaDir string) error {
+	if err := os.Remove(ReplayFilePath(fortaDir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// applyReplay applies the replay config if the node is started by 'forta replay'.
+func applyReplay(cfg *Config, filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the replay config: %v", err)
+	}
+	var replay ReplayConfig
+	if err := json.Unmarshal(b, &replay); err != nil {
+		return fmt.Errorf("failed to decode the replay config: %v", err)
+	}
+	if !replay.Enabled() {
+		return nil
+	}
+	cfg.Replay = replay
+	if replay.ChainID != 0 && replay.ChainID != cfg.ChainID {
+		cfg.ChainID = replay.ChainID
+		cfg.ChainProfile = "" // the profile is for the configured chain
+	}
+	// the findings are written to a file and the blocks are old
+	cfg.Publish.SkipPublish = true
+	cfg.Scan.DisableAutostart = true
+	cfg.Scan.BlockMaxAgeSeconds = 0
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyReplay(t *testing.T) {
+	r := require.New(t)
+
+	fortaDir := t.TempDir()
+
+	var cfg Config
+	cfg.ChainID = 1
+	cfg.ChainProfile = "mainnet"
+	cfg.Scan.BlockMaxAgeSeconds = 600
+	r.NoError(applyReplay(&cfg, ReplayFilePath(fortaDir)), "no replay")
+	r.False(cfg.Replay.Enabled())
+	r.False(cfg.Publish.SkipPublish)
+
+	r.NoError(WriteReplayFile(fortaDir, ReplayConfig{
+		ChainID:   137,
+		FromBlock: 100,
+		ToBlock:   200,
+		Agents:    []*AgentConfig{{ID: "0x1234"}},
+	}))
+	r.NoError(applyReplay(&cfg, ReplayFilePath(fortaDir)))
+	r.True(cfg.Replay.Enabled())
+	r.Equal(uint64(100), cfg.Replay.FromBlock)
+	r.Len(cfg.Replay.Agents, 1)
+	r.Equal(137, cfg.ChainID)
+	r.Empty(cfg.ChainProfile)
+	r.True(cfg.Publish.SkipPublish)
+	r.True(cfg.Scan.DisableAutostart)
+	r.Equal(int64(0), cfg.Scan.BlockMaxAgeSeconds)
+
+	r.NoError(RemoveReplayFile(fortaDir))
+	r.NoError(RemoveReplayFile(fortaDir), "already removed")
+}
@@ -1,39 +1,135 @@
 package config
 
+import "strings"
+
+const bytesPerMiB = 1024 * 1024
+
 // AgentResourceLimits contain the agent resource limits data.
 type AgentResourceLimits struct {
-	CPUQuota int64 // in microseconds
-	Memory   int64 // in bytes
+	CPUQuota   int64 // in microseconds
+	CPUShares  int64 // relative weight
+	Memory     int64 // in bytes
+	DiskMiB    int   // writable layer quota
+	ScratchMiB int   // tmpfs scratch space size, unbounded if zero
+	EgressKbit int64 // internet upload bandwidth, unlimited if zero
 }
 
-// GetAgentResourceLimits calculates and returns the resource limits by
-// taking the configuration into account. Zero values mean no limits.
-func GetAgentResourceLimits(resourcesCfg ResourcesConfig) *AgentResourceLimits {
+// GetAgentResourceLimits calculates and returns the resource limits of the agent by
+// taking the configuration and the agent overrides into account. Zero values mean no limits.
+func GetAgentResourceLimits(resourcesCfg ResourcesConfig, agentID string) *AgentResourceLimits {
 	var limits AgentResourceLimits
 
 	if resourcesCfg.DisableAgentLimits {
 		return &limits
 	}
 
+	limits.DiskMiB = getDefaultDiskMiBPerAgent()
+	if resourcesCfg.AgentMaxDiskMiB > 0 {
+		limits.DiskMiB = resourcesCfg.AgentMaxDiskMiB
+	}
+
+	limits.ScratchMiB = getDefaultScratchMiBPerAgent()
+	if resourcesCfg.AgentScratchMiB > 0 {
+		limits.ScratchMiB = resourcesCfg.AgentScratchMiB
+	}
+
 	limits.CPUQuota = getDefaultCPUQuotaPerAgent()
 	if resourcesCfg.AgentMaxCPUs > 0 {
-		limits.CPUQuota = int64(resourcesCfg.AgentMaxCPUs * float64(100000))
+		limits.CPUQuota = cpusToQuota(resourcesCfg.AgentMaxCPUs)
+	}
+
+	limits.CPUShares = getDefaultCPUSharesPerAgent()
+	if resourcesCfg.AgentCPUShares > 0 {
+		limits.CPUShares = resourcesCfg.AgentCPUShares
 	}
 
 	limits.Memory = getDefaultMemoryPerAgent()
 	if resourcesCfg.AgentMaxMemoryMiB > 0 {
-		limits.Memory = int64(resourcesCfg.AgentMaxMemoryMiB * 104858)
+		limits.Memory = int64(resourcesCfg.AgentMaxMemoryMiB) * bytesPerMiB
+	}
+
+	if resourcesCfg.AgentMaxEgressMbps > 0 {
+		limits.EgressKbit = mbpsToKbit(resourcesCfg.AgentMaxEgressMbps)
+	}
+
+	for _, override := range resourcesCfg.AgentOverrides {
+		if !strings.EqualFold(override.AgentID, agentID) {
+			continue
+		}
+		if override.MaxCPUs > 0 {
+			limits.CPUQuota = cpusToQuota(override.MaxCPUs)
+		}
+		if override.CPUShares > 0 {
+			limits.CPUShares = override.CPUShares
+		}
+		if override.MaxMemoryMiB > 0 {
+			limits.Memory = int64(override.MaxMemoryMiB) * bytesPerMiB
+		}
+		if override.MaxDiskMiB > 0 {
+			limits.DiskMiB = override.MaxDiskMiB
+		}
+		if override.ScratchMiB > 0 {
+			limits.ScratchMiB = override.ScratchMiB
+		}
+		if override.MaxEgressMbps > 0 {
+			limits.EgressKbit = mbpsToKbit(override.MaxEgressMbps)
+		}
 	}
 
 	return &limits
 }
 
+// AllowsGPUs tells if the agent can get the GPUs it requests.
+func (rc ResourcesConfig) AllowsGPUs(agentID string) bool {
+	if !rc.EnableAgentGPUs {
+		return false
+	}
+	return len(rc.GPUAgents) == 0 || containsAgentID(rc.GPUAgents, agentID)
+}
+
+// GetAgentPriority returns the priority of the agent. The agents with lower priorities are
+// shed first when the host is under pressure.
+func (rc ResourcesConfig) GetAgentPriority(agentID string) int {
+	for _, override := range rc.AgentOverrides {
+		if strings.EqualFold(override.AgentID, agentID) {
+			return override.Priority
+		}
+	}
+	return 0
+}
+
+// cpusToQuota converts the CPU count to the CFS microseconds value in the default 100ms period.
+func cpusToQuota(cpus float64) int64 {
+	return int64(cpus * float64(100000))
+}
+
+// mbpsToKbit converts megabits per second to the kilobits per second which tc uses.
+func mbpsToKbit(mbps float64) int64 {
+	return int64(mbps * 1000)
+}
+
 // getDefaultCPUQuotaPerAgent returns the default CFS microseconds value allowed per agent
 func getDefaultCPUQuotaPerAgent() int64 {
 	return 20000 // just 20%
 }
 
+// getDefaultCPUSharesPerAgent returns the default CPU weight of an agent. It is half of the
+// Docker default so the node containers get more CPU time when the host is busy.
+func getDefaultCPUSharesPerAgent() int64 {
+	return 512
+}
+
 // getDefaultMemoryPerAgent returns the constant default memory allowed per agent.
 func getDefaultMemoryPerAgent() int64 {
-	return 1048580000 // 1000 MiB in bytes
+	return 1000 * bytesPerMiB
+}
+
+// getDefaultDiskMiBPerAgent returns the default writable layer quota of an agent.
+func getDefaultDiskMiBPerAgent() int {
+	return 2000
+}
+
+// getDefaultScratchMiBPerAgent returns the default size of the agent scratch space.
+func getDefaultScratchMiBPerAgent() int {
+	return 256
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAgentResourceLimits(t *testing.T) {
+	const agentID = "0x04f65c638f234548104790d7c692c9273d41f82d784b174ff2fdc3e8e5bf1636"
+
+	limits := GetAgentResourceLimits(ResourcesConfig{}, agentID)
+	assert.Equal(t, &AgentResourceLimits{CPUQuota: 20000, CPUShares: 512, Memory: 1000 * 1024 * 1024, DiskMiB: 2000, ScratchMiB: 256}, limits)
+
+	cfg := ResourcesConfig{
+		AgentMaxCPUs:       0.5,
+		AgentMaxMemoryMiB:  500,
+		AgentCPUShares:     256,
+		AgentMaxDiskMiB:    1000,
+		AgentMaxEgressMbps: 2.5,
+		AgentOverrides: []AgentResourcesOverride{
+			{AgentID: "0x0a1f3e5ac1b0c1bb7bd0a7e3c2b0d2a4f5f1b6ad7c3e8f5a9c1d3e5f7a9b1c3d", MaxCPUs: 4},
+			{AgentID: agentID, MaxCPUs: 2, MaxMemoryMiB: 4000, MaxDiskMiB: 5000, ScratchMiB: 1024, MaxEgressMbps: 10},
+		},
+	}
+	limits = GetAgentResourceLimits(cfg, "0x1234")
+	assert.Equal(t, &AgentResourceLimits{CPUQuota: 50000, CPUShares: 256, Memory: 500 * 1024 * 1024, DiskMiB: 1000, ScratchMiB: 256, EgressKbit: 2500}, limits)
+
+	limits = GetAgentResourceLimits(cfg, agentID)
+	assert.Equal(t, &AgentResourceLimits{CPUQuota: 200000, CPUShares: 256, Memory: 4000 * 1024 * 1024, DiskMiB: 5000, ScratchMiB: 1024, EgressKbit: 10000}, limits)
+
+	cfg.DisableAgentLimits = true
+	assert.Equal(t, &AgentResourceLimits{}, GetAgentResourceLimits(cfg, agentID))
+}
+
+func TestAllowsGPUs(t *testing.T) {
+	cfg := ResourcesConfig{}
+	assert.False(t, cfg.AllowsGPUs("0x1"))
+
+	cfg.EnableAgentGPUs = true
+	assert.True(t, cfg.AllowsGPUs("0x1"))
+
+	cfg.GPUAgents = []string{"0xABC"}
+	assert.True(t, cfg.AllowsGPUs("0xabc"))
+	assert.False(t, cfg.AllowsGPUs("0x1"))
+}
+
+func TestGetAgentPriority(t *testing.T) {
+	cfg := ResourcesConfig{
+		AgentOverrides: []AgentResourcesOverride{
+			{AgentID: "0xABC", Priority: 10},
+		},
+	}
+	assert.Equal(t, 10, cfg.GetAgentPriority("0xabc"))
+	assert.Equal(t, 0, cfg.GetAgentPriority("0x1"))
+}
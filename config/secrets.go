@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Secret reference schemes which can be used in place of the sensitive config values.
+const (
+	SecretSchemeVault  = "vault://"
+	SecretSchemeAWSKMS = "awskms://"
+	SecretSchemeSOPS   = "sops://"
+)
+
+// Secrets maps the secret references to the resolved values.
+type Secrets map[string]string
+
+// IsSecretRef tells if the value is a secret reference.
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretSchemeVault) ||
+		strings.HasPrefix(value, SecretSchemeAWSKMS) ||
+		strings.HasPrefix(value, SecretSchemeSOPS)
+}
+
+// SecretRefs returns the secret references found in the config values and the passphrase.
+func SecretRefs(cfg *Config) []string {
+	var refs []string
+	walkStrings(reflect.ValueOf(cfg).Elem(), func(value string) string {
+		if IsSecretRef(value) && !containsAgentID(refs, value) {
+			refs = append(refs, value)
+		}
+		return value
+	})
+	return refs
+}
+
+// Apply replaces the secret references in the config with the resolved values. It fails if a
+// reference was not resolved.
+func (secrets Secrets) Apply(cfg *Config) error {
+	var missing []string
+	walkStrings(reflect.ValueOf(cfg).Elem(), func(value string) string {
+		if !IsSecretRef(value) {
+			return value
+		}
+		resolved, ok := secrets[value]
+		if !ok {
+			missing = append(missing, value)
+			return value
+		}
+		return resolved
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("unresolved secrets: %s", strings.Join(missing, ", "))
+	}
+	cfg.Secrets = secrets
+	return nil
+}
+
+// Bytes encodes the secrets so that they can be passed to the containers.
+func (secrets Secrets) Bytes() []byte {
+	b, _ := json.Marshal(secrets)
+	return b
+}
+
+// readSecrets reads the secrets passed to the container. The container has no secrets if the
+// file does not exist.
+func readSecrets(filename string) (Secrets, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return Secrets{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %v", err)
+	}
+	var secrets Secrets
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %v", err)
+	}
+	return secrets, nil
+}
+
+// walkStrings replaces all string values in the structs, pointers, slices and maps.
+func walkStrings(val reflect.Value, replace func(string) string) {
+	switch val.Kind() {
+	case reflect.String:
+		if val.CanSet() {
+			val.SetString(replace(val.String()))
+		}
+
+	case reflect.Ptr:
+		if !val.IsNil() {
+			walkStrings(val.Elem(), replace)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).PkgPath != "" {
+				continue // unexported
+			}
+			walkStrings(val.Field(i), replace)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			walkStrings(val.Index(i), replace)
+		}
+
+	case reflect.Map:
+		if val.Type() == reflect.TypeOf(Secrets{}) {
+			return
+		}
+		for _, key := range val.MapKeys() {
+			// map values are not addressable so replace with a copy
+			item := reflect.New(val.Type().Elem()).Elem()
+			item.Set(val.MapIndex(key))
+			walkStrings(item, replace)
+			val.SetMapIndex(key, item)
+		}
+	}
+}
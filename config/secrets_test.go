@@ -0,0 +1,48 @@
+package config
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	r := require.New(t)
+
+	var cfg Config
+	cfg.Passphrase = "vault://secret/data/forta#passphrase"
+	cfg.Scan.JsonRpc.Url = "sops://secrets.yml#scan.url"
+	cfg.Trace.JsonRpc.Url = "sops://secrets.yml#scan.url"
+	cfg.Publish.Notifications = []NotificationChannelConfig{{BotToken: "awskms://AQID"}}
+	cfg.Registry.JsonRpc.Url = "https://polygon-rpc.com"
+	cfg.Scan.JsonRpc.Headers = map[string]string{"Authorization": "vault://secret/data/forta#passphrase"}
+
+	r.Equal([]string{
+		"vault://secret/data/forta#passphrase",
+		"sops://secrets.yml#scan.url",
+		"awskms://AQID",
+	}, SecretRefs(&cfg))
+
+	secrets := Secrets{
+		"vault://secret/data/forta#passphrase": "passphrase",
+		"sops://secrets.yml#scan.url":          "https://scan-url",
+	}
+	r.Error(secrets.Apply(&cfg))
+
+	secrets["awskms://AQID"] = "token"
+	filename := path.Join(t.TempDir(), DefaultSecretsFileName)
+	r.NoError(ioutil.WriteFile(filename, secrets.Bytes(), 0600))
+	secrets, err := readSecrets(filename)
+	r.NoError(err)
+	r.NoError(secrets.Apply(&cfg))
+
+	r.Equal("passphrase", cfg.Passphrase)
+	r.Equal("https://scan-url", cfg.Scan.JsonRpc.Url)
+	r.Equal("https://scan-url", cfg.Trace.JsonRpc.Url)
+	r.Equal("token", cfg.Publish.Notifications[0].BotToken)
+	r.Equal("https://polygon-rpc.com", cfg.Registry.JsonRpc.Url)
+	r.Equal("passphrase", cfg.Scan.JsonRpc.Headers["Authorization"])
+	r.Empty(SecretRefs(&cfg))
+}
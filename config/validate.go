@@ -0,0 +1,284 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/creasty/defaults"
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// placeholder value in the generated config file
+const requiredPlaceholder = "<required>"
+
+// resolvedFields are resolved from ENS at runtime if the config file does not set them.
+var resolvedFields = []string{"registry.contractAddress"}
+
+// ValidationError is a problem with a config field.
+type ValidationError struct {
+	Field   string
+	Line    int // zero if unknown
+	Message string
+}
+
+func (ve ValidationError) Error() string {
+	if ve.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %s", ve.Field, ve.Line, ve.Message)
+	}
+	return fmt.Sprintf("%s: %s", ve.Field, ve.Message)
+}
+
+// ValidateFile reads the config file and returns all of the problems found in it. The
+// returned error is not nil only if the file cannot be read or parsed.
+func ValidateFile(filename string) ([]ValidationError, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file: %v", err)
+	}
+	if len(root.Content) == 0 {
+		return nil, errors.New("the config file is empty")
+	}
+
+	lines := make(map[int]string)
+	problems := checkKeys(root.Content[0], reflect.TypeOf(Config{}), "", lines)
+
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to decode the config file: %v", err)
+		}
+		problems = append(problems, typeErrors(typeErr, lines)...)
+	}
+	if err := defaults.Set(&cfg); err != nil {
+		return nil, err
+	}
+	applyContextDefaults(&cfg)
+	applyChainSettings(&cfg) // reported by the validation if it fails
+
+	for _, problem := range Validate(cfg) {
+		problem.Line = findLine(lines, problem.Field)
+		if problem.Line == 0 && containsAgentID(resolvedFields, problem.Field) {
+			continue
+		}
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+// checkKeys reports the unknown keys by walking the YAML nodes together with the config types.
+// It also records the lines of the fields.
+func checkKeys(node *yaml.Node, t reflect.Type, path string, lines map[int]string) (problems []ValidationError) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, ValidationError{
+					Field: fieldPath, Line: key.Line, Message: "unknown field",
+				})
+				continue
+			}
+			lines[key.Line] = fieldPath
+			problems = append(problems, checkKeys(value, field.Type, fieldPath, lines)...)
+		}
+
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinPath(path, key.Value)
+			lines[key.Line] = fieldPath
+			problems = append(problems, checkKeys(value, t.Elem(), fieldPath, lines)...)
+		}
+
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			fieldPath := fmt.Sprintf("%s[%d]", path, i)
+			lines[item.Line] = fieldPath
+			problems = append(problems, checkKeys(item, t.Elem(), fieldPath, lines)...)
+		}
+	}
+	return
+}
+
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if len(name) > 0 && name != "-" {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+var typeErrRegexp = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// typeErrors converts the YAML decoding errors to the field errors.
+func typeErrors(typeErr *yaml.TypeError, lines map[int]string) (problems []ValidationError) {
+	for _, msg := range typeErr.Errors {
+		matches := typeErrRegexp.FindStringSubmatch(msg)
+		if matches == nil {
+			problems = append(problems, ValidationError{Field: "config", Message: msg})
+			continue
+		}
+		line, _ := strconv.Atoi(matches[1])
+		problems = append(problems, ValidationError{
+			Field: lines[line], Line: line, Message: matches[2],
+		})
+	}
+	return
+}
+
+func findLine(lines map[int]string, field string) int {
+	for line, path := range lines {
+		if path == field {
+			return line
+		}
+	}
+	return 0
+}
+
+// Validate checks the loaded config against the field rules, the required endpoints and the
+// options which should not be used together.
+func Validate(cfg Config) []ValidationError {
+	problems := validateFields(cfg)
+	problems = append(problems, validateEndpoints(cfg)...)
+	problems = append(problems, validateExclusiveOptions(cfg)...)
+	if _, err := ResolveChainSettings(cfg); err != nil {
+		problems = append(problems, ValidationError{Field: "chainProfile", Message: err.Error()})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+	return problems
+}
+
+func validateFields(cfg Config) (problems []ValidationError) {
+	validate := validator.New()
+
+	// Use the YAML names while validating the struct.
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("yaml"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := validate.Struct(&cfg)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+	for _, validationErr := range validationErrs {
+		if validationErr.Value() == requiredPlaceholder {
+			continue // reported by the endpoint checks
+		}
+		problems = append(problems, ValidationError{
+			Field:   strings.TrimPrefix(validationErr.Namespace(), "Config."),
+			Message: validationMessage(validationErr),
+		})
+	}
+	return
+}
+
+// validationMessage explains the failed validation rule.
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with":
+		return "is required"
+	case "url":
+		return fmt.Sprintf("must be a valid URL (got %q)", fe.Value())
+	case "ip":
+		return fmt.Sprintf("must be an IP address (got %q)", fe.Value())
+	case "eth_addr":
+		return fmt.Sprintf("must be an Ethereum address (got %q)", fe.Value())
+	case "hostname|hostname_port":
+		return fmt.Sprintf("must be a hostname with an optional port (got %q)", fe.Value())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s (got %q)", strings.ReplaceAll(fe.Param(), " ", ", "), fe.Value())
+	case "contains":
+		return fmt.Sprintf("must contain %q", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed the '%s' check", fe.Tag())
+	}
+}
+
+func validateEndpoints(cfg Config) (problems []ValidationError) {
+	checkEndpoint := func(field, url string) {
+		switch url {
+		case "":
+			problems = append(problems, ValidationError{Field: field, Message: "is required"})
+		case requiredPlaceholder:
+			problems = append(problems, ValidationError{Field: field, Message: "is required - replace the placeholder with your endpoint"})
+		}
+	}
+	checkEndpoint("scan.jsonRpc.url", cfg.Scan.JsonRpc.Url)
+	if cfg.Trace.Enabled {
+		checkEndpoint("trace.jsonRpc.url", cfg.Trace.JsonRpc.Url)
+	}
+	return
+}
+
+func validateExclusiveOptions(cfg Config) (problems []ValidationError) {
+	exclusive := func(field, other string) {
+		problems = append(problems, ValidationError{
+			Field: field, Message: fmt.Sprintf("cannot be used together with %s", other),
+		})
+	}
+	if cfg.Publish.LocalStore.Disable && cfg.Publish.LocalStore.GraphQL.Enable {
+		exclusive("publish.localStore.graphql.enable", "publish.localStore.disable")
+	}
+	if cfg.AgentNetwork.AllowEgress && len(cfg.AgentNetwork.EgressAgents) > 0 {
+		exclusive("agentNetwork.egressAgents", "agentNetwork.allowEgress")
+	}
+	resources := cfg.ResourcesConfig
+	if resources.DisableAgentLimits && (resources.AgentMaxCPUs > 0 || resources.AgentMaxMemoryMiB > 0 || len(resources.AgentOverrides) > 0) {
+		exclusive("resources.disableAgentLimits", "the agent limits")
+	}
+	// the alert api uploads the ipfs batches itself so their encoding can't change
+	if storage := cfg.Publish.Storage; len(storage.Compression) > 0 && (storage.Type == "" || storage.Type == "ipfs") {
+		exclusive("publish.storage.compression", "the ipfs storage")
+	}
+	for _, method := range cfg.JsonRpcProxy.Methods.Deny {
+		if containsAgentID(cfg.JsonRpcProxy.Methods.Allow, method) {
+			problems = append(problems, ValidationError{
+				Field:   "jsonRpcProxy.methods.deny",
+				Message: fmt.Sprintf("method %s is both allowed and denied", method),
+			})
+		}
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInvalidConfig = `chainId: 1
+
+scan:
+  jsonRpc:
+    url: <required>
+  fooBar: 1
+
+trace:
+  jsonRpc:
+    url: not a url
+
+resources:
+  agentMaxMemoryMib: lots
+  disableAgentLimits: true
+  agentMaxCpus: 0.5
+
+publish:
+  localStore:
+    disable: true
+    graphql:
+      enable: true
+`
+
+func TestValidateFile(t *testing.T) {
+	r := require.New(t)
+
+	filename := path.Join(t.TempDir(), "config.yml")
+	r.NoError(ioutil.WriteFile(filename, []byte(testInvalidConfig), 0644))
+
+	problems, err := ValidateFile(filename)
+	r.NoError(err)
+
+	byField := make(map[string]ValidationError)
+	for _, problem := range problems {
+		byField[problem.Field] = problem
+	}
+	r.Len(byField, 6, "%v", problems)
+
+	r.Equal(6, byField["scan.fooBar"].Line)
+	r.Equal("unknown field", byField["scan.fooBar"].Message)
+
+	r.Equal(13, byField["resources.agentMaxMemoryMib"].Line)
+	r.Contains(byField["resources.agentMaxMemoryMib"].Message, "cannot unmarshal")
+
+	r.Equal(5, byField["scan.jsonRpc.url"].Line)
+	r.Contains(byField["scan.jsonRpc.url"].Message, "placeholder")
+
+	r.Equal(10, byField["trace.jsonRpc.url"].Line)
+	r.Contains(byField["trace.jsonRpc.url"].Message, "must be a valid URL")
+
+	r.Contains(byField, "resources.disableAgentLimits")
+	r.Contains(byField, "publish.localStore.graphql.enable")
+}
+
+func TestValidate(t *testing.T) {
+	var cfg Config
+	cfg.Scan.JsonRpc.Url = "http://localhost:8545"
+	cfg.JsonRpcProxy.Methods.Allow = []string{"eth_call"}
+	cfg.JsonRpcProxy.Methods.Deny = []string{"eth_call"}
+	cfg.Trace.Enabled = true
+	cfg.Publish.Storage.Compression = "zstd"
+	cfg.ContainerRuntime.Type = "containerd"
+
+	problems := Validate(cfg)
+	var fields []string
+	for _, problem := range problems {
+		fields = append(fields, problem.Field)
+	}
+	assert.Contains(t, fields, "jsonRpcProxy.methods.deny")
+	assert.Contains(t, fields, "trace.jsonRpc.url")
+	assert.Contains(t, fields, "publish.storage.compression")
+	assert.Contains(t, fields, "containerRuntime.type")
+	assert.NotContains(t, fields, "scan.jsonRpc.url")
+}
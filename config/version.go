@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AgentProtocolVersion is the version of the agent gRPC protocol implemented by this node.
+// The agents which declare a different major version in their manifests are not run.
+const AgentProtocolVersion = "1.0.0"
+
+// SemVer is a parsed major.minor.patch version.
+type SemVer struct {
+	Major, Minor, Patch int
+}
+
+// ParseSemVer parses versions like "v1.2.3", "1.2" and "1". The pre-release and build
+// suffixes are ignored.
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version: %s", s)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: %s", s)
+		}
+		nums[i] = n
+	}
+	return SemVer{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 when the version is lower than, equal to or greater than the other.
+func (v SemVer) Compare(other SemVer) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v SemVer) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// SatisfiesVersionConstraint checks the version against a constraint like ">=0.5.0, <1.0.0".
+// All of the comma separated conditions should be satisfied.
+func SatisfiesVersionConstraint(version, constraint string) (bool, error) {
+	v, err := ParseSemVer(version)
+	if err != nil {
+		return false, err
+	}
+	for _, cond := range strings.Split(constraint, ",") {
+		cond = strings.TrimSpace(cond)
+		if len(cond) == 0 {
+			continue
+		}
+		op := strings.TrimRight(cond, "v0123456789.-+ ")
+		target, err := ParseSemVer(strings.TrimSpace(strings.TrimPrefix(cond, op)))
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint '%s': %v", constraint, err)
+		}
+		cmp := v.Compare(target)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "=", "==", "":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			return false, fmt.Errorf("invalid version constraint '%s': unknown operator '%s'", constraint, op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CheckAgentCompatibility checks the version constraints of the agent against this node and
+// the constraints of this node against the agent. The node version constraint is not checked
+// for the development builds which have no version.
+func CheckAgentCompatibility(agent *AgentConfig, nodeVersion string) error {
+	if len(agent.NodeVersion) > 0 && len(nodeVersion) > 0 {
+		ok, err := SatisfiesVersionConstraint(nodeVersion, agent.NodeVersion)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("agent requires node version '%s' but the node is %s", agent.NodeVersion, nodeVersion)
+		}
+	}
+	if len(agent.ProtocolVersion) > 0 {
+		agentProtocol, err := ParseSemVer(agent.ProtocolVersion)
+		if err != nil {
+			return fmt.Errorf("invalid agent protocol version: %v", err)
+		}
+		nodeProtocol, _ := ParseSemVer(AgentProtocolVersion)
+		if agentProtocol.Major != nodeProtocol.Major || agentProtocol.Compare(nodeProtocol) > 0 {
+			return fmt.Errorf("agent uses protocol version %s but the node supports %s", agentProtocol, nodeProtocol)
+		}
+	}
+	return nil
+}
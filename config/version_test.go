@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSatisfiesVersionConstraint(t *testing.T) {
+	for _, testCase := range []struct {
+		version    string
+		constraint string
+		satisfied  bool
+	}{
+		{"0.5.0", ">=0.5.0", true},
+		{"v0.5.1", ">=0.5.0, <0.6.0", true},
+		{"0.6.0", ">=0.5.0, <0.6.0", false},
+		{"0.4.9", ">0.4", true},
+		{"1.2.3-beta", "=1.2.3", true},
+		{"1.2.3", "!=1.2.3", false},
+	} {
+		ok, err := SatisfiesVersionConstraint(testCase.version, testCase.constraint)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.satisfied, ok, "%s %s", testCase.version, testCase.constraint)
+	}
+
+	_, err := SatisfiesVersionConstraint("0.5.0", "~>0.5")
+	assert.Error(t, err)
+	_, err = SatisfiesVersionConstraint("latest", ">=0.5.0")
+	assert.Error(t, err)
+}
+
+func TestCheckAgentCompatibility(t *testing.T) {
+	assert.NoError(t, CheckAgentCompatibility(&AgentConfig{}, "0.5.0"))
+	assert.NoError(t, CheckAgentCompatibility(&AgentConfig{NodeVersion: ">=0.6.0"}, ""))
+	assert.Error(t, CheckAgentCompatibility(&AgentConfig{NodeVersion: ">=0.6.0"}, "0.5.0"))
+	assert.NoError(t, CheckAgentCompatibility(&AgentConfig{ProtocolVersion: "1"}, "0.5.0"))
+	assert.Error(t, CheckAgentCompatibility(&AgentConfig{ProtocolVersion: "1.1.0"}, "0.5.0"))
+	assert.Error(t, CheckAgentCompatibility(&AgentConfig{ProtocolVersion: "0.9.0"}, "0.5.0"))
+}
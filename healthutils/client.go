@@ -0,0 +1,109 @@
+package healthutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/apisecurity"
+)
+
+// DefaultHealthRequestTimeout limits the health requests to the node services.
+const DefaultHealthRequestTimeout = time.Second * 30
+
+// Client sends the health requests to the node services with the TLS and authentication
+// settings of the node.
+type Client struct {
+	sec        *apisecurity.Security
+	httpClient *http.Client
+}
+
+// NewClient creates a new health client.
+func NewClient(sec *apisecurity.Security) *Client {
+	return &Client{
+		sec:        sec,
+		httpClient: sec.HTTPClient(DefaultHealthRequestTimeout),
+	}
+}
+
+// URL returns the health endpoint of a node service.
+func (c *Client) URL(host, port string) string {
+	return fmt.Sprintf("%s://%s:%s/health", c.sec.Scheme(), host, port)
+}
+
+// Get sends a request to a health endpoint.
+func (c *Client) Get(ctx context.Context, rawurl string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.sec.SetToken(req)
+	return c.httpClient.Do(req)
+}
+
+// CheckHealth implements health.HealthClient interface.
+func (c *Client) CheckHealth(name, port string) (reports health.Reports) {
+	const apiName = "health-api"
+	resp, err := c.Get(context.Background(), c.URL("localhost", port))
+	if err != nil {
+		return singleReport(apiName, health.StatusDown, fmt.Sprintf("request failed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return singleReport(apiName, health.StatusFailing, fmt.Sprintf("failed to read: %v", err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return singleReport(apiName, health.StatusFailing, fmt.Sprintf("responded with status %d: %s", resp.StatusCode, string(b)))
+	}
+	if err := json.Unmarshal(b, &reports); err != nil {
+		return singleReport(apiName, health.StatusFailing, fmt.Sprintf("bad response: %v: %s", err, string(b)))
+	}
+	reports.ObfuscateDetails()
+	return reports
+}
+
+// SendReports implements health.HealthClient interface.
+func (c *Client) SendReports(src, dest, authToken string) error {
+	resp, err := c.Get(context.Background(), src)
+	if err != nil {
+		return fmt.Errorf("get request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health api responded with status %d", resp.StatusCode)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, dest, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to create post request: %v", err)
+	}
+	if len(authToken) > 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("telemetry handler responded with '%d': %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func singleReport(name string, status health.Status, details string) health.Reports {
+	return health.Reports{
+		&health.Report{
+			Name:    name,
+			Status:  status,
+			Details: details,
+		},
+	}
+}
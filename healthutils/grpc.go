@@ -0,0 +1,104 @@
+package healthutils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultGrpcHealthCheckInterval is how often the health reports are converted to
+// the gRPC serving status.
+const DefaultGrpcHealthCheckInterval = time.Second * 10
+
+// GrpcHealthService exposes the health checks of a node service using the standard
+// gRPC health protocol so that generic tools like grpc_health_probe can be used.
+type GrpcHealthService struct {
+	ctx           context.Context
+	name          string
+	port          string
+	sec           *apisecurity.Security
+	healthChecker health.HealthChecker
+	server        *grpc.Server
+	healthServer  *grpchealth.Server
+}
+
+// NewGrpcHealthService creates a new gRPC health service which reports the status
+// using the checker both as the overall status and the status of the named service.
+func NewGrpcHealthService(ctx context.Context, sec *apisecurity.Security, name string, healthChecker health.HealthChecker) *GrpcHealthService {
+	return &GrpcHealthService{
+		ctx:           ctx,
+		name:          name,
+		port:          config.DefaultGrpcHealthPort,
+		sec:           sec,
+		healthChecker: healthChecker,
+		healthServer:  grpchealth.NewServer(),
+	}
+}
+
+// ServingStatus converts the health reports to the gRPC serving status.
+func ServingStatus(reports health.Reports) healthpb.HealthCheckResponse_ServingStatus {
+	for _, report := range reports {
+		if report.Status == health.StatusDown || report.Status == health.StatusFailing {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func (svc *GrpcHealthService) update() {
+	status := ServingStatus(svc.healthChecker())
+	svc.healthServer.SetServingStatus("", status)
+	svc.healthServer.SetServingStatus(svc.name, status)
+}
+
+// Start starts the service.
+func (svc *GrpcHealthService) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", svc.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for grpc health checks: %v", err)
+	}
+	svc.server = grpc.NewServer(svc.sec.GrpcServerOptions()...)
+	healthpb.RegisterHealthServer(svc.server, svc.healthServer)
+	svc.update()
+	go func() {
+		if err := svc.server.Serve(lis); err != nil {
+			log.WithError(err).Warn("grpc health server stopped")
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(DefaultGrpcHealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-svc.ctx.Done():
+				return
+			case <-ticker.C:
+				svc.update()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the service.
+func (svc *GrpcHealthService) Stop() error {
+	svc.healthServer.Shutdown()
+	if svc.server != nil {
+		svc.server.Stop()
+	}
+	return nil
+}
+
+// Name returns the name of the service.
+func (svc *GrpcHealthService) Name() string {
+	return "grpc-health"
+}
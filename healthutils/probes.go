@@ -0,0 +1,153 @@
+package healthutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultReadinessCheckTimeout limits the time of all readiness checks of a request.
+const DefaultReadinessCheckTimeout = time.Second * 10
+
+// ReadinessCheck checks a dependency which the service needs to be ready.
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+// ProbeResponse is the response of the liveness and readiness endpoints.
+type ProbeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// probe statuses
+const (
+	ProbeStatusOK       = "ok"
+	ProbeStatusNotReady = "not ready"
+)
+
+// HealthService serves the health reports at /health, the liveness at /healthz and
+// the readiness at /readyz so that orchestration systems and uptime monitors can supervise
+// the service.
+type HealthService struct {
+	ctx           context.Context
+	port          string
+	sec           *apisecurity.Security
+	healthChecker health.HealthChecker
+	checks        []ReadinessCheck
+	server        *http.Server
+}
+
+// NewHealthService creates a new health service.
+func NewHealthService(ctx context.Context, sec *apisecurity.Security, healthChecker health.HealthChecker, checks ...ReadinessCheck) *HealthService {
+	return &HealthService{
+		ctx:           ctx,
+		port:          config.DefaultHealthPort,
+		sec:           sec,
+		healthChecker: healthChecker,
+		checks:        checks,
+	}
+}
+
+// Start starts the service.
+func (svc *HealthService) Start() error {
+	mux := http.NewServeMux()
+	health.Handle(mux, svc.healthChecker)
+	mux.HandleFunc("/healthz", svc.handleLiveness)
+	mux.HandleFunc("/readyz", svc.handleReadiness)
+	svc.server = &http.Server{
+		Addr:    fmt.Sprintf(":%s", svc.port),
+		Handler: svc.sec.Authenticate(mux),
+	}
+	go func() {
+		if err := svc.sec.ListenAndServe(svc.server); err != nil {
+			DefaultHealthServerErrHandler(err)
+		}
+	}()
+	go func() {
+		<-svc.ctx.Done()
+		svc.server.Close()
+	}()
+	return nil
+}
+
+// Stop stops the service.
+func (svc *HealthService) Stop() error {
+	return nil
+}
+
+// Name returns the name of the service.
+func (svc *HealthService) Name() string {
+	return "health"
+}
+
+// handleLiveness responds as long as the process can serve requests.
+func (svc *HealthService) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeProbeResponse(w, http.StatusOK, &ProbeResponse{Status: ProbeStatusOK})
+}
+
+// handleReadiness runs all checks concurrently and responds with 503 if any of them fails.
+func (svc *HealthService) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	results := RunReadinessChecks(svc.checks, DefaultReadinessCheckTimeout)
+	resp := &ProbeResponse{Status: ProbeStatusOK, Checks: results}
+	code := http.StatusOK
+	for _, result := range results {
+		if result != ProbeStatusOK {
+			resp.Status = ProbeStatusNotReady
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+	writeProbeResponse(w, code, resp)
+}
+
+// RunReadinessChecks runs the checks concurrently and returns the results by check name. The checks
+// which do not complete before the timeout fail.
+func RunReadinessChecks(checks []ReadinessCheck, timeout time.Duration) map[string]string {
+	type checkResult struct {
+		name string
+		err  error
+	}
+	resultCh := make(chan *checkResult, len(checks))
+	for _, check := range checks {
+		go func(check ReadinessCheck) {
+			resultCh <- &checkResult{name: check.Name, err: check.Check()}
+		}(check)
+	}
+
+	results := make(map[string]string)
+	for _, check := range checks {
+		results[check.Name] = "timed out"
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for range checks {
+		select {
+		case result := <-resultCh:
+			if result.err != nil {
+				results[result.name] = result.err.Error()
+			} else {
+				results[result.name] = ProbeStatusOK
+			}
+		case <-timer.C:
+			return results
+		}
+	}
+	return results
+}
+
+func writeProbeResponse(w http.ResponseWriter, code int, resp *ProbeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.WithError(err).Warn("failed to encode probe response")
+	}
+}
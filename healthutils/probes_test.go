@@ -0,0 +1,52 @@
+package healthutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	r := require.New(t)
+
+	var checkErr error
+	svc := NewHealthService(context.Background(), &apisecurity.Security{}, nil, ReadinessCheck{
+		Name:  "test",
+		Check: func() error { return checkErr },
+	})
+
+	rec := httptest.NewRecorder()
+	svc.handleReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	r.Equal(http.StatusOK, rec.Code)
+
+	checkErr = errors.New("not reachable")
+	rec = httptest.NewRecorder()
+	svc.handleReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	r.Equal(http.StatusServiceUnavailable, rec.Code)
+	var resp ProbeResponse
+	r.NoError(json.NewDecoder(rec.Body).Decode(&resp))
+	r.Equal(ProbeStatusNotReady, resp.Status)
+	r.Equal("not reachable", resp.Checks["test"])
+
+	rec = httptest.NewRecorder()
+	svc.handleLiveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	r.Equal(http.StatusOK, rec.Code)
+}
+
+func TestReadinessCheckTimeout(t *testing.T) {
+	r := require.New(t)
+
+	results := RunReadinessChecks([]ReadinessCheck{
+		{Name: "slow", Check: func() error { time.Sleep(time.Second); return nil }},
+		{Name: "fast", Check: func() error { return nil }},
+	}, time.Millisecond*100)
+	r.Equal("timed out", results["slow"])
+	r.Equal(ProbeStatusOK, results["fast"])
+}
@@ -0,0 +1,245 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// log formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// FieldComponent is the log field which selects the component log level.
+const FieldComponent = "component"
+
+// Levels are the log levels of the service.
+type Levels struct {
+	Default    log.Level            `json:"default"`
+	Components map[string]log.Level `json:"components"`
+}
+
+// ParseLevels parses the log levels in the config.
+func ParseLevels(cfg config.LogConfig) (*Levels, error) {
+	levels := &Levels{Default: log.InfoLevel, Components: make(map[string]log.Level)}
+	if len(cfg.Level) > 0 {
+		lvl, err := log.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		levels.Default = lvl
+	}
+	for component, level := range cfg.Components {
+		lvl, err := log.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level for %s: %v", component, err)
+		}
+		levels.Components[component] = lvl
+	}
+	return levels, nil
+}
+
+// enabled tells if the entry should be logged.
+func (levels *Levels) enabled(entry *log.Entry) bool {
+	level := levels.Default
+	if component, ok := entry.Data[FieldComponent].(string); ok {
+		if componentLevel, ok := levels.Components[component]; ok {
+			level = componentLevel
+		}
+	}
+	return entry.Level <= level
+}
+
+// max returns the most verbose level so that the logger lets all enabled entries through.
+func (levels *Levels) max() log.Level {
+	max := levels.Default
+	for _, level := range levels.Components {
+		if level > max {
+			max = level
+		}
+	}
+	return max
+}
+
+// copy copies the levels so that they can be changed without affecting the formatter.
+func (levels *Levels) copy() *Levels {
+	levelsCopy := &Levels{Default: levels.Default, Components: make(map[string]log.Level)}
+	for component, level := range levels.Components {
+		levelsCopy.Components[component] = level
+	}
+	return levelsCopy
+}
+
+// levelRestore restores the level of a component after a temporary change.
+type levelRestore struct {
+	timer   *time.Timer
+	level   log.Level
+	existed bool
+}
+
+var (
+	currentLevels = &Levels{Default: log.InfoLevel, Components: make(map[string]log.Level)}
+	restores      = make(map[string]*levelRestore)
+	levelsMu      sync.RWMutex
+)
+
+// SetLevels replaces the log levels of the service and cancels the temporary changes.
+func SetLevels(levels *Levels) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	for component, restore := range restores {
+		restore.timer.Stop()
+		delete(restores, component)
+	}
+	setLevels(levels)
+}
+
+func setLevels(levels *Levels) {
+	currentLevels = levels
+	log.SetLevel(levels.max())
+}
+
+// SetLevel changes the level of the component, or the default level if the component is empty.
+// If the duration is positive, the level before the change is restored after the duration.
+func SetLevel(component string, level log.Level, duration time.Duration) *Levels {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	levels := currentLevels.copy()
+	prevLevel, existed := levels.get(component)
+	// keep restoring to the level before the first temporary change
+	if restore, ok := restores[component]; ok {
+		restore.timer.Stop()
+		delete(restores, component)
+		prevLevel, existed = restore.level, restore.existed
+	}
+	levels.set(component, level)
+	setLevels(levels)
+
+	if duration > 0 {
+		restore := &levelRestore{level: prevLevel, existed: existed}
+		restore.timer = time.AfterFunc(duration, func() {
+			restoreLevel(component, restore)
+		})
+		restores[component] = restore
+	}
+	return levels.copy()
+}
+
+func restoreLevel(component string, restore *levelRestore) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	// the level may have been changed again since the timer fired
+	if restores[component] != restore {
+		return
+	}
+	delete(restores, component)
+	levels := currentLevels.copy()
+	if restore.existed {
+		levels.set(component, restore.level)
+	} else {
+		delete(levels.Components, component)
+	}
+	setLevels(levels)
+}
+
+func (levels *Levels) get(component string) (log.Level, bool) {
+	if len(component) == 0 {
+		return levels.Default, true
+	}
+	level, ok := levels.Components[component]
+	return level, ok
+}
+
+func (levels *Levels) set(component string, level log.Level) {
+	if len(component) == 0 {
+		levels.Default = level
+		return
+	}
+	levels.Components[component] = level
+}
+
+// GetLevels returns the current log levels of the service.
+func GetLevels() *Levels {
+	return getLevels().copy()
+}
+
+func getLevels() *Levels {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return currentLevels
+}
+
+// Init configures the standard logger of the service: the format, the levels and the outputs
+// which the logs are shipped to.
+func Init(cfg config.LogConfig, service string) error {
+	levels, err := ParseLevels(cfg)
+	if err != nil {
+		return err
+	}
+	var formatter log.Formatter
+	switch cfg.Format {
+	case FormatText:
+		formatter = &log.TextFormatter{FullTimestamp: true}
+	default:
+		formatter = &log.JSONFormatter{}
+	}
+	outputs := []io.Writer{os.Stdout}
+	for _, outputCfg := range cfg.Outputs {
+		if !shipsService(outputCfg, service) {
+			continue
+		}
+		output, err := newOutput(outputCfg, service)
+		if err != nil {
+			return fmt.Errorf("failed to create the %s log output: %v", outputCfg.Type, err)
+		}
+		outputs = append(outputs, output)
+	}
+
+	SetLevels(levels)
+	log.SetFormatter(&serviceFormatter{service: service, formatter: formatter})
+	log.SetOutput(io.MultiWriter(outputs...))
+	return nil
+}
+
+func shipsService(cfg config.LogOutputConfig, service string) bool {
+	if len(cfg.Services) == 0 {
+		return true
+	}
+	for _, s := range cfg.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceFormatter adds the service name to the entries and drops the entries which are
+// below the level of their component.
+type serviceFormatter struct {
+	service   string
+	formatter log.Formatter
+}
+
+// Format implements log.Formatter.
+func (f *serviceFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if !getLevels().enabled(entry) {
+		return nil, nil
+	}
+	data := make(log.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	data["service"] = f.service
+	serviceEntry := *entry
+	serviceEntry.Data = data
+	return f.formatter.Format(&serviceEntry)
+}
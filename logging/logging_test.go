@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentLevels(t *testing.T) {
+	r := require.New(t)
+
+	levels, err := ParseLevels(config.LogConfig{
+		Level:      "info",
+		Components: map[string]string{"pool": "debug", "agent": "error"},
+	})
+	r.NoError(err)
+	SetLevels(levels)
+	defer SetLevels(&Levels{Default: log.InfoLevel, Components: make(map[string]log.Level)})
+	r.Equal(log.DebugLevel, log.GetLevel())
+
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetLevel(log.GetLevel())
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&serviceFormatter{service: "scanner", formatter: &log.JSONFormatter{}})
+
+	logger.Debug("dropped")
+	logger.WithField(FieldComponent, "pool").Debug("pool debug")
+	logger.WithField(FieldComponent, "agent").Warn("dropped")
+	logger.WithField(FieldComponent, "agent").Error("agent error")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	r.Len(lines, 2)
+	var entry map[string]interface{}
+	r.NoError(json.Unmarshal(lines[0], &entry))
+	r.Equal("pool debug", entry["msg"])
+	r.Equal("scanner", entry["service"])
+	r.NoError(json.Unmarshal(lines[1], &entry))
+	r.Equal("agent error", entry["msg"])
+
+	_, err = ParseLevels(config.LogConfig{Components: map[string]string{"pool": "loud"}})
+	r.Error(err)
+}
+
+func TestLokiOutput(t *testing.T) {
+	r := require.New(t)
+
+	pushed := make(chan *lokiPushRequest, 1)
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("/loki/api/v1/push", req.URL.Path)
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var pushReq lokiPushRequest
+		r.NoError(json.NewDecoder(req.Body).Decode(&pushReq))
+		pushed <- &pushReq
+	}))
+	defer server.Close()
+
+	output := &lokiOutput{
+		pushURL:    server.URL + "/loki/api/v1/push",
+		labels:     map[string]string{"service": "scanner"},
+		httpClient: server.Client(),
+	}
+	output.Write([]byte("line 1\n"))
+	output.push()
+	// the failed lines are retried with the next push
+	fail = false
+	output.Write([]byte("line 2\n"))
+	output.push()
+
+	pushReq := <-pushed
+	r.Len(pushReq.Streams, 1)
+	r.Equal("scanner", pushReq.Streams[0].Stream["service"])
+	r.Len(pushReq.Streams[0].Values, 2)
+	r.Equal("line 1", pushReq.Streams[0].Values[0][1])
+	r.Equal("line 2", pushReq.Streams[0].Values[1][1])
+}
+
+func TestTemporaryLevel(t *testing.T) {
+	r := require.New(t)
+
+	SetLevels(&Levels{Default: log.InfoLevel, Components: map[string]log.Level{"agent": log.WarnLevel}})
+	defer SetLevels(&Levels{Default: log.InfoLevel, Components: make(map[string]log.Level)})
+
+	SetLevel("pool", log.DebugLevel, time.Millisecond*100)
+	levels := SetLevel("pool", log.TraceLevel, time.Millisecond*100)
+	r.Equal(log.TraceLevel, levels.Components["pool"])
+	r.Equal(log.TraceLevel, log.GetLevel())
+	SetLevel("agent", log.DebugLevel, 0)
+
+	// the pool level is removed since it was not set before the first change
+	r.Eventually(func() bool {
+		_, ok := GetLevels().Components["pool"]
+		return !ok
+	}, time.Second, time.Millisecond*10)
+	r.Equal(log.DebugLevel, GetLevels().Components["agent"])
+	r.Equal(log.DebugLevel, log.GetLevel())
+}
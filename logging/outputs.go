@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+)
+
+// log output types
+const (
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+	OutputLoki   = "loki"
+)
+
+// Loki push settings
+var (
+	LokiPushInterval = time.Second
+	LokiMaxLines     = 10000
+)
+
+func newOutput(cfg config.LogOutputConfig, service string) (io.Writer, error) {
+	switch cfg.Type {
+	case OutputFile:
+		return newFileOutput(cfg, service)
+	case OutputSyslog:
+		return newSyslogOutput(cfg, service)
+	case OutputLoki:
+		return newLokiOutput(cfg, service), nil
+	default:
+		return nil, fmt.Errorf("unsupported log output type: %s", cfg.Type)
+	}
+}
+
+func newFileOutput(cfg config.LogOutputConfig, service string) (io.Writer, error) {
+	filePath := cfg.Path
+	if len(filePath) == 0 {
+		filePath = path.Join("logs", service+".log")
+	}
+	if !path.IsAbs(filePath) {
+		filePath = path.Join(config.DefaultContainerFortaDirPath, filePath)
+	}
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// newSyslogOutput connects to the syslog server in the url (e.g. udp://localhost:514). All lines
+// are sent with the info priority since the level is a field of the formatted line.
+func newSyslogOutput(cfg config.LogOutputConfig, service string) (io.Writer, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	return syslog.Dial(u.Scheme, u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, "forta-"+service)
+}
+
+// lokiOutput collects the lines and pushes them to Loki periodically. Writing never blocks
+// the logger: the lines are dropped when Loki is not reachable for too long.
+type lokiOutput struct {
+	pushURL    string
+	labels     map[string]string
+	httpClient *http.Client
+
+	lines   [][2]string
+	dropped int
+	mu      sync.Mutex
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func newLokiOutput(cfg config.LogOutputConfig, service string) *lokiOutput {
+	labels := map[string]string{"service": service}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	output := &lokiOutput{
+		pushURL:    cfg.URL + "/loki/api/v1/push",
+		labels:     labels,
+		httpClient: &http.Client{Timeout: time.Second * 10},
+	}
+	go output.pushPeriodically()
+	return output
+}
+
+// Write implements io.Writer.
+func (output *lokiOutput) Write(p []byte) (int, error) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if len(output.lines) >= LokiMaxLines {
+		output.dropped++
+		return len(p), nil
+	}
+	line := string(bytes.TrimRight(p, "\n"))
+	output.lines = append(output.lines, [2]string{strconv.FormatInt(time.Now().UnixNano(), 10), line})
+	return len(p), nil
+}
+
+func (output *lokiOutput) pushPeriodically() {
+	ticker := time.NewTicker(LokiPushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		output.push()
+	}
+}
+
+func (output *lokiOutput) push() {
+	output.mu.Lock()
+	lines := output.lines
+	dropped := output.dropped
+	output.lines = nil
+	output.dropped = 0
+	output.mu.Unlock()
+
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "dropped %d log lines while loki was not reachable\n", dropped)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if err := output.send(lines); err != nil {
+		// using the logger here would log the failure to loki again
+		fmt.Fprintf(os.Stderr, "failed to push logs to loki: %v\n", err)
+		output.requeue(lines)
+	}
+}
+
+func (output *lokiOutput) send(lines [][2]string) error {
+	b, err := json.Marshal(&lokiPushRequest{
+		Streams: []*lokiStream{{Stream: output.labels, Values: lines}},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := output.httpClient.Post(output.pushURL, "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("loki responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// requeue puts the lines which could not be pushed before the new lines so they are retried.
+func (output *lokiOutput) requeue(lines [][2]string) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	lines = append(lines, output.lines...)
+	if overflow := len(lines) - LokiMaxLines; overflow > 0 {
+		lines = lines[overflow:]
+		output.dropped += overflow
+	}
+	output.lines = lines
+}
@@ -1,9 +1,12 @@
 package metrics
 
 import (
-	"github.com/forta-network/forta-core-go/domain"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/forta-network/forta-core-go/domain"
+
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/messaging"
@@ -27,11 +30,27 @@ const (
 	MetricBlockSuccess     = "block.success"
 	MetricBlockDrop        = "block.drop"
 	MetricStop             = "agent.stop"
+	MetricStakeTooLow      = "agent.stake.low"
+	MetricIncompatible     = "agent.incompatible"
 	MetricJSONRPCLatency   = "jsonrpc.latency"
 	MetricJSONRPCRequest   = "jsonrpc.request"
 	MetricJSONRPCSuccess   = "jsonrpc.success"
 	MetricJSONRPCThrottled = "jsonrpc.throttled"
+	MetricJSONRPCBlocked   = "jsonrpc.blocked"
 	MetricFindingsDropped  = "findings.dropped"
+
+	MetricFindingsSuppressed = "findings.suppressed"
+
+	MetricTxBufferHighWater    = "tx.buffer.highwater"
+	MetricBlockBufferHighWater = "block.buffer.highwater"
+
+	MetricGrpcConnState  = "grpc.conn.state"
+	MetricGrpcConnRedial = "grpc.conn.redial"
+
+	MetricGrpcCallLatency       = "grpc.%s.latency"
+	MetricGrpcCallRequestBytes  = "grpc.%s.request.bytes"
+	MetricGrpcCallResponseBytes = "grpc.%s.response.bytes"
+	MetricGrpcCallStatus        = "grpc.%s.status.%s"
 )
 
 func SendAgentMetrics(client clients.MessageClient, ms []*protocol.AgentMetric) {
@@ -119,3 +138,14 @@ func GetJSONRPCMetrics(agt config.AgentConfig, at time.Time, success, throttled
 	}
 	return createMetrics(agt.ID, at.Format(time.RFC3339), values)
 }
+
+func GetGrpcCallMetrics(agentID, method string, latency time.Duration, requestBytes, responseBytes int, code string) []*protocol.AgentMetric {
+	method = strings.ToLower(method)
+	values := map[string]float64{
+		fmt.Sprintf(MetricGrpcCallLatency, method):                       float64(latency.Milliseconds()),
+		fmt.Sprintf(MetricGrpcCallRequestBytes, method):                  float64(requestBytes),
+		fmt.Sprintf(MetricGrpcCallResponseBytes, method):                 float64(responseBytes),
+		fmt.Sprintf(MetricGrpcCallStatus, method, strings.ToLower(code)): 1,
+	}
+	return createMetrics(agentID, time.Now().Format(time.RFC3339), values)
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Finding drop reasons
+const (
+	DropReasonTruncated = "truncated"
+	DropReasonSeverity  = "severity"
+	DropReasonQuota     = "quota"
+)
+
+var alertLabels = []string{"agent", "severity", "chain"}
+
+// Alert metrics
+var (
+	FindingsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "findings_processed_total",
+		Help:      "Number of findings received by the publisher",
+	}, alertLabels)
+
+	FindingsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "findings_published_total",
+		Help:      "Number of findings in the published batches",
+	}, alertLabels)
+
+	FindingsDeduplicated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "findings_deduplicated_total",
+		Help:      "Number of duplicate findings which were not published",
+	}, alertLabels)
+
+	FindingsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "findings_dropped_total",
+		Help:      "Number of findings which were not published",
+	}, append(alertLabels, "reason"))
+
+	FindingPublishDelay = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "forta",
+		Name:      "finding_publish_delay_seconds",
+		Help:      "Time from the creation of the alert until the batch is published",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, alertLabels)
+)
+
+// AlertLabels returns the metric label values for the alert.
+func AlertLabels(alert *protocol.Alert, chainID uint64) []string {
+	var agentID string
+	if alert.Agent != nil {
+		agentID = strings.ToLower(alert.Agent.Id)
+	}
+	return FindingLabels(agentID, alert.Finding, chainID)
+}
+
+// FindingLabels returns the metric label values for the finding of an agent.
+func FindingLabels(agentID string, finding *protocol.Finding, chainID uint64) []string {
+	severity := protocol.Finding_UNKNOWN
+	if finding != nil {
+		severity = finding.Severity
+	}
+	return []string{agentID, severity.String(), strconv.FormatUint(chainID, 10)}
+}
+
+// ObservePublishedAlert counts the published alert and observes the publish delay.
+func ObservePublishedAlert(alert *protocol.Alert, chainID uint64, publishedAt time.Time) {
+	labels := AlertLabels(alert, chainID)
+	FindingsPublished.WithLabelValues(labels...).Inc()
+	if createdAt, err := time.Parse(time.RFC3339Nano, alert.Timestamp); err == nil {
+		FindingPublishDelay.WithLabelValues(labels...).Observe(publishedAt.Sub(createdAt).Seconds())
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Batch metrics
+var (
+	DeadLetterBatches = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "dead_letter_batches",
+		Help:      "Number of batches which could not be published within the retry budget",
+	})
+
+	DeadLetterOldestAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "dead_letter_oldest_age_seconds",
+		Help:      "Time since the oldest dead letter batch failed",
+	})
+
+	BatchQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "batch_queue_depth",
+		Help:      "Number of batches waiting in the retry queue",
+	})
+
+	BatchQueueOldestAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "batch_queue_oldest_age_seconds",
+		Help:      "Time since the oldest batch in the retry queue was queued",
+	})
+)
+
+// BatchPins counts the batch uploads to the IPFS pinning targets.
+var BatchPins = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "forta",
+	Name:      "batch_pins_total",
+	Help:      "Number of batch uploads to the IPFS pinning targets by status",
+}, []string{"target", "status"})
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Block feed metrics
+var (
+	BlockFeedLatestBlock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "block_feed_latest_block",
+		Help:      "Number of the latest block received from the block feed",
+	})
+
+	BlockFeedLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "forta",
+		Name:      "block_feed_lag_seconds",
+		Help:      "Time between the timestamp of the latest block and receiving it from the block feed",
+	})
+
+	BlockFeedBlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "block_feed_blocks_total",
+		Help:      "Number of blocks received from the block feed",
+	})
+
+	BlockFeedTransactions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "block_feed_transactions_total",
+		Help:      "Number of transactions received from the block feed",
+	})
+
+	BlockFeedFetchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "forta",
+		Name:      "block_feed_fetch_duration_seconds",
+		Help:      "Time to fetch the block, the traces and the logs of a block",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	})
+)
+
+// ObserveBlock updates the block feed metrics with a received block.
+func ObserveBlock(block *domain.Block, receivedAt time.Time) {
+	BlockFeedBlocks.Inc()
+	if number, err := utils.HexToBigInt(block.Number); err == nil {
+		BlockFeedLatestBlock.Set(float64(number.Uint64()))
+	}
+	if ts, err := block.GetTimestamp(); err == nil {
+		BlockFeedLag.Set(receivedAt.Sub(*ts).Seconds())
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// JSON-RPC proxy request results
+const (
+	JSONRPCResultSuccess   = "success"
+	JSONRPCResultError     = "error"
+	JSONRPCResultThrottled = "throttled"
+	JSONRPCResultBlocked   = "blocked"
+)
+
+// JSON-RPC proxy metrics
+var (
+	JSONRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "jsonrpc_proxy_requests_total",
+		Help:      "Number of agent requests to the JSON-RPC proxy by result",
+	}, []string{"agent", "result"})
+
+	JSONRPCBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "jsonrpc_proxy_bytes_total",
+		Help:      "Number of request and response bytes proxied for the agents",
+	}, []string{"agent", "direction"})
+
+	JSONRPCLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "forta",
+		Name:      "jsonrpc_proxy_latency_seconds",
+		Help:      "Latency of the proxied agent requests",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"agent"})
+)
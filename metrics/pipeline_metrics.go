@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// event kinds
+const (
+	EventKindTx      = "tx"
+	EventKindBlock   = "block"
+	EventKindFinding = "finding"
+)
+
+// pipeline stages which can lose events
+const (
+	LossStageFeed     = "feed"
+	LossStageDispatch = "dispatch"
+	LossStageAgent    = "agent"
+	LossStageAnalyzer = "analyzer"
+	LossStagePublish  = "publish"
+)
+
+// event loss reasons
+const (
+	LossReasonSkipped    = "skipped"
+	LossReasonBufferFull = "buffer_full"
+	LossReasonTimeout    = "timeout"
+	LossReasonError      = "error"
+	LossReasonTooLarge   = "too_large"
+)
+
+// Pipeline metrics
+var (
+	EventsDispatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "events_dispatched_total",
+		Help:      "Number of events sent to the agents by kind",
+	}, []string{"kind"})
+
+	EventsLost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "forta",
+		Name:      "events_lost_total",
+		Help:      "Number of events which were not fully processed by pipeline stage, reason, kind and agent",
+	}, []string{"stage", "reason", "kind", "agent"})
+)
+
+// ObserveEventLoss counts the events lost at the stage. The agent is empty if the loss
+// is not specific to an agent.
+func ObserveEventLoss(stage, reason, kind, agentID string, count int) {
+	if count <= 0 {
+		return
+	}
+	EventsLost.WithLabelValues(stage, reason, kind, agentID).Add(float64(count))
+}
+
+// CounterTotal sums the values of all counters in the collector.
+func CounterTotal(collector prometheus.Collector) float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+	var total float64
+	for metric := range ch {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil || m.Counter == nil {
+			continue
+		}
+		total += m.Counter.GetValue()
+	}
+	return total
+}
+
+// GaugeValue returns the current value of the gauge.
+func GaugeValue(gauge prometheus.Gauge) float64 {
+	var m dto.Metric
+	if err := gauge.Write(&m); err != nil || m.Gauge == nil {
+		return 0
+	}
+	return m.Gauge.GetValue()
+}
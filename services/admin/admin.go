@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// Server serves the admin API of a node service. All requests need to present
+// the admin token of the node as a bearer token.
+type Server struct {
+	ctx    context.Context
+	token  string
+	sec    *apisecurity.Security
+	router *mux.Router
+	server *http.Server
+}
+
+// NewServer creates a new admin API server.
+func NewServer(ctx context.Context, token string, sec *apisecurity.Security) *Server {
+	return &Server{
+		ctx:    ctx,
+		token:  token,
+		sec:    sec,
+		router: mux.NewRouter().StrictSlash(true),
+	}
+}
+
+// Handle registers a handler for the path. If no methods are specified, GET is assumed.
+func (s *Server) Handle(path string, handler http.HandlerFunc, methods ...string) {
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	s.router.HandleFunc(path, handler).Methods(methods...)
+}
+
+// Start implements services.Service interface.
+func (s *Server) Start() error {
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%s", config.DefaultAdminPort),
+		Handler: s.authenticate(s.router),
+	}
+	s.sec.GoListenAndServe(s.server)
+	return nil
+}
+
+func (s *Server) authenticate(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if len(s.token) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Stop implements services.Service interface.
+func (s *Server) Stop() error {
+	log.Infof("Stopping %s", s.Name())
+	if s.server != nil {
+		return s.server.Close()
+	}
+	return nil
+}
+
+// Name implements services.Service interface.
+func (s *Server) Name() string {
+	return "admin-api"
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// WriteJSON writes the value as the JSON response.
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("failed to write admin api response")
+	}
+}
+
+// WriteError writes an error response.
+func WriteError(w http.ResponseWriter, code int, errMsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(&errorResponse{Error: errMsg}); err != nil {
+		log.WithError(err).Error("failed to write admin api error")
+	}
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthentication(t *testing.T) {
+	r := require.New(t)
+
+	server := NewServer(context.Background(), "test-token", &apisecurity.Security{})
+	server.Handle("/test", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, map[string]string{"foo": "bar"})
+	})
+	httpServer := httptest.NewServer(server.authenticate(server.router))
+	defer httpServer.Close()
+
+	var resp map[string]string
+	r.NoError(NewClient(httpServer.URL, "test-token", &apisecurity.Security{}).Do(http.MethodGet, "/test", nil, &resp))
+	r.Equal("bar", resp["foo"])
+
+	err := NewClient(httpServer.URL, "wrong-token", &apisecurity.Security{}).Do(http.MethodGet, "/test", nil, &resp)
+	r.Error(err)
+	r.Contains(err.Error(), "401")
+}
+
+func TestBusDeadLetters(t *testing.T) {
+	r := require.New(t)
+
+	server := NewServer(context.Background(), "test-token", &apisecurity.Security{})
+	server.HandleBusDeadLetters(messaging.NewDeadLetterStore(1))
+	httpServer := httptest.NewServer(server.authenticate(server.router))
+	defer httpServer.Close()
+	client := NewClient(httpServer.URL, "test-token", &apisecurity.Security{})
+
+	var letters []*messaging.DeadLetter
+	r.NoError(client.Do(http.MethodGet, "/bus/dead-letters", nil, &letters))
+	r.Len(letters, 0)
+
+	var resp messaging.ReplayDeadLettersResponse
+	r.NoError(client.Do(http.MethodPost, "/bus/dead-letters/replay", &messaging.ReplayDeadLettersRequest{}, &resp))
+	r.Equal(0, resp.Replayed)
+
+	var letter messaging.DeadLetter
+	err := client.Do(http.MethodGet, "/bus/dead-letters/1", nil, &letter)
+	r.Error(err)
+	r.Contains(err.Error(), "404")
+}
+
+func TestPprof(t *testing.T) {
+	r := require.New(t)
+
+	server := NewServer(context.Background(), "test-token", &apisecurity.Security{})
+	server.HandlePprof(0)
+	httpServer := httptest.NewServer(server.authenticate(server.router))
+	defer httpServer.Close()
+
+	var buf bytes.Buffer
+	r.NoError(NewClient(httpServer.URL, "test-token", &apisecurity.Security{}).Stream("/debug/pprof/goroutine?debug=1", &buf))
+	r.Contains(buf.String(), "goroutine profile")
+
+	err := NewClient(httpServer.URL, "wrong-token", &apisecurity.Security{}).Stream("/debug/pprof/heap", &buf)
+	r.Error(err)
+	r.Contains(err.Error(), "401")
+}
+
+func TestLogLevels(t *testing.T) {
+	r := require.New(t)
+
+	server := NewServer(context.Background(), "test-token", &apisecurity.Security{})
+	server.HandleLogLevels()
+	httpServer := httptest.NewServer(server.authenticate(server.router))
+	defer httpServer.Close()
+	client := NewClient(httpServer.URL, "test-token", &apisecurity.Security{})
+	defer logging.SetLevels(&logging.Levels{Default: log.InfoLevel, Components: make(map[string]log.Level)})
+
+	var levels logging.Levels
+	r.NoError(client.Do(http.MethodPost, "/log/levels", &SetLogLevelRequest{Component: "pool", Level: "debug"}, &levels))
+	r.Equal(log.DebugLevel, levels.Components["pool"])
+
+	r.NoError(client.Do(http.MethodGet, "/log/levels", nil, &levels))
+	r.Equal(log.DebugLevel, levels.Components["pool"])
+	r.Equal(log.InfoLevel, levels.Default)
+
+	err := client.Do(http.MethodPost, "/log/levels", &SetLogLevelRequest{Level: "loud"}, &levels)
+	r.Error(err)
+	r.Contains(err.Error(), "400")
+}
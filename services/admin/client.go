@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/goccy/go-json"
+)
+
+// Client sends requests to an admin API server.
+type Client struct {
+	baseURL      string
+	token        string
+	httpClient   *http.Client
+	streamClient *http.Client
+}
+
+// NewClient creates a new admin API client which uses the TLS settings of the node.
+func NewClient(baseURL, token string, sec *apisecurity.Security) *Client {
+	return &Client{
+		baseURL:      baseURL,
+		token:        token,
+		httpClient:   sec.HTTPClient(time.Second * 30),
+		streamClient: sec.HTTPClient(0),
+	}
+}
+
+// Do sends a request with optional JSON input and decodes the JSON response to the output.
+func (c *Client) Do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+		body = bytes.NewBuffer(b)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Stream sends a GET request and copies the response body to the writer until the server
+// closes the response. Streams are not limited by the request timeout.
+func (c *Client) Stream(path string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	var errResp errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || len(errResp.Error) == 0 {
+		return fmt.Errorf("admin api responded with status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("admin api responded with status %d: %s", resp.StatusCode, errResp.Error)
+}
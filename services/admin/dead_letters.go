@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/mux"
+)
+
+// HandleBusDeadLetters registers the handlers which list, inspect and replay the message bus
+// dead letters of the service.
+func (s *Server) HandleBusDeadLetters(store *messaging.DeadLetterStore) {
+	s.Handle("/bus/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, store.List())
+	})
+	s.Handle("/bus/dead-letters/replay", func(w http.ResponseWriter, r *http.Request) {
+		var req messaging.ReplayDeadLettersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		WriteJSON(w, store.Replay(req.IDs))
+	}, http.MethodPost)
+	s.Handle("/bus/dead-letters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		letter, err := store.Get(mux.Vars(r)["id"])
+		if errors.Is(err, messaging.ErrDeadLetterNotFound) {
+			WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		WriteJSON(w, letter)
+	})
+}
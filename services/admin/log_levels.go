@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-node/logging"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetLogLevelRequest changes the log level of a component or the default level if the component
+// is empty. The previous level is restored after the duration if it is set.
+type SetLogLevelRequest struct {
+	Component       string `json:"component"`
+	Level           string `json:"level"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// HandleLogLevels registers the handlers which get and change the log levels of the service.
+func (s *Server) HandleLogLevels() {
+	s.Handle("/log/levels", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, logging.GetLevels())
+	})
+	s.Handle("/log/levels", func(w http.ResponseWriter, r *http.Request) {
+		var req SetLogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		level, err := log.ParseLevel(req.Level)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.DurationSeconds < 0 {
+			WriteError(w, http.StatusBadRequest, "duration can not be negative")
+			return
+		}
+		duration := time.Duration(req.DurationSeconds) * time.Second
+		levels := logging.SetLevel(req.Component, level, duration)
+		log.WithFields(log.Fields{
+			"logComponent": req.Component,
+			"level":        level.String(),
+			"duration":     duration.String(),
+		}).Warn("changed log level")
+		WriteJSON(w, levels)
+	}, http.MethodPost)
+}
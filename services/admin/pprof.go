@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"net/http/pprof"
+	"runtime"
+)
+
+// HandlePprof registers the pprof handlers under /debug/pprof and enables the block profile
+// with the given rate so that the block profile has samples.
+func (s *Server) HandlePprof(blockProfileRate int) {
+	runtime.SetBlockProfileRate(blockProfileRate)
+	s.Handle("/debug/pprof/", pprof.Index)
+	s.Handle("/debug/pprof/cmdline", pprof.Cmdline)
+	s.Handle("/debug/pprof/profile", pprof.Profile)
+	s.Handle("/debug/pprof/symbol", pprof.Symbol)
+	s.Handle("/debug/pprof/trace", pprof.Trace)
+	// the index serves the named profiles like heap, goroutine and block
+	s.Handle("/debug/pprof/{profile}", pprof.Index)
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// EnsureToken reads the admin API token from the Forta dir and creates it first if it does not exist.
+func EnsureToken(fortaDir string) (string, error) {
+	tokenPath := path.Join(fortaDir, config.DefaultAdminTokenFileName)
+	token, err := ReadToken(fortaDir)
+	if err == nil {
+		return token, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate admin token: %v", err)
+	}
+	token = hex.EncodeToString(b)
+
+	// exclusive create so that the concurrent initializers agree on the same token
+	f, err := os.OpenFile(tokenPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return ReadToken(fortaDir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create admin token file: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(token); err != nil {
+		return "", fmt.Errorf("failed to write admin token: %v", err)
+	}
+	return token, nil
+}
+
+// ReadToken reads the admin API token from the Forta dir.
+func ReadToken(fortaDir string) (string, error) {
+	b, err := ioutil.ReadFile(path.Join(fortaDir, config.DefaultAdminTokenFileName))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
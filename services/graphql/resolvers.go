@@ -0,0 +1,368 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/store"
+)
+
+const dateFormat = "2006-01-02"
+
+// AlertQuerier queries the locally stored alerts.
+type AlertQuerier interface {
+	QueryAlerts(query *store.AlertQuery) (*store.AlertPage, error)
+}
+
+type resolver struct {
+	querier AlertQuerier
+}
+
+type alertsInput struct {
+	Addresses        *[]*string
+	After            *endCursorInput
+	Agents           *[]*string
+	AlertId          *string
+	BlockDateRange   *dateRangeInput
+	BlockNumberRange *blockRangeInput
+	ChainId          *int32
+	CreatedSince     *int32
+	First            *int32
+	ProjectId        *string
+	Severities       *[]*string
+	TransactionHash  *string
+}
+
+type endCursorInput struct {
+	AlertId     *string
+	BlockNumber *int32
+}
+
+type dateRangeInput struct {
+	StartDate *string
+	EndDate   *string
+}
+
+type blockRangeInput struct {
+	StartBlockNumber *int32
+	EndBlockNumber   *int32
+}
+
+func stringList(list *[]*string) []string {
+	if list == nil {
+		return nil
+	}
+	var result []string
+	for _, s := range *list {
+		if s != nil {
+			result = append(result, *s)
+		}
+	}
+	return result
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func tagUint(alert *protocol.Alert, tag string) (uint64, bool) {
+	n, err := strconv.ParseUint(alert.Tags[tag], 10, 64)
+	return n, err == nil
+}
+
+// toQuery converts the input to a store query. The block date range is applied to
+// the alert creation time since the stored alerts do not have the block timestamps.
+func (input *alertsInput) toQuery() (*store.AlertQuery, error) {
+	query := &store.AlertQuery{}
+	if input == nil {
+		return query, nil
+	}
+	if input.First != nil {
+		if *input.First <= 0 {
+			return nil, errors.New("first must be positive")
+		}
+		query.Limit = int(*input.First)
+	}
+	if input.After != nil && input.After.AlertId != nil {
+		query.AfterAlertID = *input.After.AlertId
+	}
+	if input.CreatedSince != nil {
+		query.From = time.Now().Add(-time.Duration(*input.CreatedSince) * time.Millisecond)
+	}
+	if dateRange := input.BlockDateRange; dateRange != nil {
+		if dateRange.StartDate != nil {
+			t, err := time.Parse(dateFormat, *dateRange.StartDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start date: %v", err)
+			}
+			if t.After(query.From) {
+				query.From = t
+			}
+		}
+		if dateRange.EndDate != nil {
+			t, err := time.Parse(dateFormat, *dateRange.EndDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end date: %v", err)
+			}
+			query.To = t.Add(time.Hour*24 - 1)
+		}
+	}
+
+	addresses := stringList(input.Addresses)
+	agents := stringList(input.Agents)
+	severities := stringList(input.Severities)
+	query.Filter = func(alert *protocol.Alert) bool {
+		if len(agents) > 0 && (alert.Agent == nil || !containsFold(agents, alert.Agent.Id)) {
+			return false
+		}
+		finding := alert.Finding
+		if finding == nil {
+			finding = &protocol.Finding{}
+		}
+		if len(addresses) > 0 {
+			var found bool
+			for _, address := range finding.Addresses {
+				if containsFold(addresses, address) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		if len(severities) > 0 && !containsFold(severities, finding.Severity.String()) {
+			return false
+		}
+		if input.AlertId != nil && finding.AlertId != *input.AlertId {
+			return false
+		}
+		if input.ProjectId != nil && !strings.EqualFold(finding.Protocol, *input.ProjectId) {
+			return false
+		}
+		if input.TransactionHash != nil && !strings.EqualFold(alert.Tags["txHash"], *input.TransactionHash) {
+			return false
+		}
+		if input.ChainId != nil {
+			if chainID, ok := tagUint(alert, "chainId"); !ok || chainID != uint64(*input.ChainId) {
+				return false
+			}
+		}
+		if blockRange := input.BlockNumberRange; blockRange != nil {
+			blockNumber, ok := tagUint(alert, "blockNumber")
+			if !ok {
+				return false
+			}
+			if blockRange.StartBlockNumber != nil && blockNumber < uint64(*blockRange.StartBlockNumber) {
+				return false
+			}
+			if blockRange.EndBlockNumber != nil && blockNumber > uint64(*blockRange.EndBlockNumber) {
+				return false
+			}
+		}
+		return true
+	}
+	return query, nil
+}
+
+func (r *resolver) Alerts(args struct{ Input *alertsInput }) (*alertsResponse, error) {
+	query, err := args.Input.toQuery()
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.querier.QueryAlerts(query)
+	if err != nil {
+		return nil, err
+	}
+	resp := &alertsResponse{hasNextPage: len(page.NextCursor) > 0}
+	for _, alert := range page.Alerts {
+		resp.alerts = append(resp.alerts, &alertResolver{alert: alert})
+	}
+	return resp, nil
+}
+
+type alertsResponse struct {
+	alerts      []*alertResolver
+	hasNextPage bool
+}
+
+func (resp *alertsResponse) Alerts() []*alertResolver {
+	return resp.alerts
+}
+
+func (resp *alertsResponse) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{resp: resp}
+}
+
+type pageInfoResolver struct {
+	resp *alertsResponse
+}
+
+func (pi *pageInfoResolver) HasNextPage() bool {
+	return pi.resp.hasNextPage
+}
+
+func (pi *pageInfoResolver) EndCursor() *endCursorResolver {
+	if len(pi.resp.alerts) == 0 {
+		return nil
+	}
+	return &endCursorResolver{alert: pi.resp.alerts[len(pi.resp.alerts)-1]}
+}
+
+type endCursorResolver struct {
+	alert *alertResolver
+}
+
+func (ec *endCursorResolver) AlertId() string {
+	return ec.alert.Hash()
+}
+
+func (ec *endCursorResolver) BlockNumber() *int32 {
+	return ec.alert.blockNumber()
+}
+
+// JSON is a map which is encoded as a JSON object.
+type JSON map[string]string
+
+// ImplementsGraphQLType implements graphql.Unmarshaler interface.
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL implements graphql.Unmarshaler interface.
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	return errors.New("JSON is not supported as input")
+}
+
+type alertResolver struct {
+	alert *protocol.Alert
+}
+
+func (ar *alertResolver) finding() *protocol.Finding {
+	if ar.alert.Finding == nil {
+		return &protocol.Finding{}
+	}
+	return ar.alert.Finding
+}
+
+func (ar *alertResolver) AlertId() string {
+	return ar.finding().AlertId
+}
+
+func (ar *alertResolver) Addresses() []string {
+	return ar.finding().Addresses
+}
+
+func (ar *alertResolver) CreatedAt() string {
+	return ar.alert.Timestamp
+}
+
+func (ar *alertResolver) Description() string {
+	return ar.finding().Description
+}
+
+func (ar *alertResolver) FindingType() string {
+	return ar.finding().Type.String()
+}
+
+func (ar *alertResolver) Hash() string {
+	return ar.alert.Id
+}
+
+func (ar *alertResolver) Metadata() *JSON {
+	metadata := JSON(ar.finding().Metadata)
+	return &metadata
+}
+
+func (ar *alertResolver) Name() string {
+	return ar.finding().Name
+}
+
+func (ar *alertResolver) Protocol() string {
+	return ar.finding().Protocol
+}
+
+func (ar *alertResolver) Severity() string {
+	return ar.finding().Severity.String()
+}
+
+func (ar *alertResolver) Source() *sourceResolver {
+	return &sourceResolver{alert: ar.alert}
+}
+
+func (ar *alertResolver) blockNumber() *int32 {
+	return optionalTagInt(ar.alert, "blockNumber")
+}
+
+type sourceResolver struct {
+	alert *protocol.Alert
+}
+
+func (sr *sourceResolver) TransactionHash() *string {
+	return optionalTag(sr.alert, "txHash")
+}
+
+func (sr *sourceResolver) Block() *blockResolver {
+	return &blockResolver{alert: sr.alert}
+}
+
+func (sr *sourceResolver) Agent() *agentResolver {
+	if sr.alert.Agent == nil {
+		return nil
+	}
+	return &agentResolver{agent: sr.alert.Agent}
+}
+
+type blockResolver struct {
+	alert *protocol.Alert
+}
+
+func (br *blockResolver) Number() *int32 {
+	return optionalTagInt(br.alert, "blockNumber")
+}
+
+func (br *blockResolver) Hash() *string {
+	return optionalTag(br.alert, "blockHash")
+}
+
+func (br *blockResolver) ChainId() *int32 {
+	return optionalTagInt(br.alert, "chainId")
+}
+
+func optionalTag(alert *protocol.Alert, tag string) *string {
+	value, ok := alert.Tags[tag]
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+func optionalTagInt(alert *protocol.Alert, tag string) *int32 {
+	n, ok := tagUint(alert, tag)
+	if !ok {
+		return nil
+	}
+	value := int32(n)
+	return &value
+}
+
+type agentResolver struct {
+	agent *protocol.AgentInfo
+}
+
+func (ar *agentResolver) Id() string {
+	return ar.agent.Id
+}
+
+func (ar *agentResolver) Image() string {
+	return ar.agent.Image
+}
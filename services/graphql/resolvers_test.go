@@ -0,0 +1,149 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/store"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/require"
+)
+
+type testQuerier struct {
+	store.AlertStore
+}
+
+func (tq *testQuerier) QueryAlerts(query *store.AlertQuery) (*store.AlertPage, error) {
+	return tq.Query(query)
+}
+
+const testQuery = `
+query($input: AlertsInput) {
+	alerts(input: $input) {
+		alerts {
+			hash
+			alertId
+			severity
+			source {
+				transactionHash
+				block { number chainId }
+				agent { id }
+			}
+		}
+		pageInfo {
+			hasNextPage
+			endCursor { alertId blockNumber }
+		}
+	}
+}`
+
+type testResponse struct {
+	Alerts struct {
+		Alerts []struct {
+			Hash     string
+			AlertID  string `json:"alertId"`
+			Severity string
+			Source   struct {
+				TransactionHash *string
+				Block           struct {
+					Number  *int32
+					ChainID *int32 `json:"chainId"`
+				}
+				Agent struct {
+					ID string `json:"id"`
+				}
+			}
+		}
+		PageInfo struct {
+			HasNextPage bool
+			EndCursor   *struct {
+				AlertID     string `json:"alertId"`
+				BlockNumber *int32
+			}
+		}
+	}
+}
+
+func testAlert(id, agentID string, severity protocol.Finding_Severity, blockNumber string, ts time.Time) *protocol.Alert {
+	return &protocol.Alert{
+		Id:        id,
+		Timestamp: ts.Format(time.RFC3339Nano),
+		Agent:     &protocol.AgentInfo{Id: agentID},
+		Finding:   &protocol.Finding{AlertId: "TEST-" + id, Severity: severity},
+		Tags: map[string]string{
+			"chainId":     "1",
+			"blockNumber": blockNumber,
+			"txHash":      "0xtx" + id,
+		},
+	}
+}
+
+func execQuery(t *testing.T, schema *graphqlgo.Schema, input map[string]interface{}) *testResponse {
+	r := require.New(t)
+	result := schema.Exec(context.Background(), testQuery, "", map[string]interface{}{"input": input})
+	r.Empty(result.Errors)
+	var resp testResponse
+	r.NoError(json.Unmarshal(result.Data, &resp))
+	return &resp
+}
+
+func TestAlerts(t *testing.T) {
+	r := require.New(t)
+
+	alertStore, err := store.NewAlertStore("", time.Hour)
+	r.NoError(err)
+	defer alertStore.Close()
+
+	start := time.Now().UTC()
+	r.NoError(alertStore.Put(testAlert("0x1", "0xagent1", protocol.Finding_LOW, "100", start)))
+	r.NoError(alertStore.Put(testAlert("0x2", "0xagent2", protocol.Finding_HIGH, "101", start.Add(time.Second))))
+	r.NoError(alertStore.Put(testAlert("0x3", "0xagent1", protocol.Finding_CRITICAL, "102", start.Add(time.Second*2))))
+
+	schema, err := graphqlgo.ParseSchema(schema, &resolver{querier: &testQuerier{AlertStore: alertStore}})
+	r.NoError(err)
+
+	resp := execQuery(t, schema, nil)
+	r.Len(resp.Alerts.Alerts, 3)
+	alert := resp.Alerts.Alerts[0]
+	r.Equal("0x3", alert.Hash)
+	r.Equal("TEST-0x3", alert.AlertID)
+	r.Equal("CRITICAL", alert.Severity)
+	r.Equal("0xtx0x3", *alert.Source.TransactionHash)
+	r.EqualValues(102, *alert.Source.Block.Number)
+	r.EqualValues(1, *alert.Source.Block.ChainID)
+	r.Equal("0xagent1", alert.Source.Agent.ID)
+	r.False(resp.Alerts.PageInfo.HasNextPage)
+
+	resp = execQuery(t, schema, map[string]interface{}{
+		"agents":     []interface{}{"0xAGENT1"},
+		"severities": []interface{}{"critical", "low"},
+		"first":      1,
+	})
+	r.Len(resp.Alerts.Alerts, 1)
+	r.Equal("0x3", resp.Alerts.Alerts[0].Hash)
+	r.True(resp.Alerts.PageInfo.HasNextPage)
+	r.Equal("0x3", resp.Alerts.PageInfo.EndCursor.AlertID)
+	r.EqualValues(102, *resp.Alerts.PageInfo.EndCursor.BlockNumber)
+
+	resp = execQuery(t, schema, map[string]interface{}{
+		"agents": []interface{}{"0xagent1"},
+		"after":  map[string]interface{}{"alertId": "0x3"},
+	})
+	r.Len(resp.Alerts.Alerts, 1)
+	r.Equal("0x1", resp.Alerts.Alerts[0].Hash)
+
+	resp = execQuery(t, schema, map[string]interface{}{
+		"chainId":          1,
+		"blockNumberRange": map[string]interface{}{"startBlockNumber": 101, "endBlockNumber": 101},
+	})
+	r.Len(resp.Alerts.Alerts, 1)
+	r.Equal("0x2", resp.Alerts.Alerts[0].Hash)
+
+	result := schema.Exec(context.Background(), testQuery, "", map[string]interface{}{
+		"input": map[string]interface{}{"first": 0},
+	})
+	r.NotEmpty(result.Errors)
+}
@@ -0,0 +1,91 @@
+package graphql
+
+// schema mirrors the alert queries of the public Forta API so that the same queries
+// can be tested against the local node.
+const schema = `
+schema {
+	query: Query
+}
+
+scalar JSON
+
+type Query {
+	alerts(input: AlertsInput): AlertsResponse!
+}
+
+input AlertsInput {
+	addresses: [String]
+	after: AlertEndCursorInput
+	agents: [String]
+	alertId: String
+	blockDateRange: DateRangeInput
+	blockNumberRange: BlockRange
+	chainId: Int
+	createdSince: Int
+	first: Int
+	projectId: String
+	severities: [String]
+	transactionHash: String
+}
+
+input AlertEndCursorInput {
+	alertId: String
+	blockNumber: Int
+}
+
+input DateRangeInput {
+	startDate: String
+	endDate: String
+}
+
+input BlockRange {
+	startBlockNumber: Int
+	endBlockNumber: Int
+}
+
+type AlertsResponse {
+	alerts: [Alert!]!
+	pageInfo: PageInfo!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: AlertEndCursor
+}
+
+type AlertEndCursor {
+	alertId: String!
+	blockNumber: Int
+}
+
+type Alert {
+	alertId: String!
+	addresses: [String!]!
+	createdAt: String!
+	description: String!
+	findingType: String!
+	hash: String!
+	metadata: JSON
+	name: String!
+	protocol: String!
+	severity: String!
+	source: AlertSource!
+}
+
+type AlertSource {
+	transactionHash: String
+	block: Block
+	agent: Agent
+}
+
+type Block {
+	number: Int
+	hash: String
+	chainId: Int
+}
+
+type Agent {
+	id: String!
+	image: String!
+}
+`
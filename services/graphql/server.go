@@ -0,0 +1,56 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/forta-network/forta-node/apisecurity"
+	"github.com/forta-network/forta-node/config"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	log "github.com/sirupsen/logrus"
+)
+
+// Server serves the local GraphQL API for the stored alerts.
+type Server struct {
+	ctx     context.Context
+	sec     *apisecurity.Security
+	handler http.Handler
+	server  *http.Server
+}
+
+// NewServer creates a new GraphQL server.
+func NewServer(ctx context.Context, sec *apisecurity.Security, querier AlertQuerier) (*Server, error) {
+	schema, err := graphqlgo.ParseSchema(schema, &resolver{querier: querier})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the graphql schema: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", &relay.Handler{Schema: schema})
+	return &Server{ctx: ctx, sec: sec, handler: sec.Authenticate(mux)}, nil
+}
+
+// Start implements services.Service interface.
+func (s *Server) Start() error {
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%s", config.DefaultGraphQLPort),
+		Handler: s.handler,
+	}
+	s.sec.GoListenAndServe(s.server)
+	return nil
+}
+
+// Stop implements services.Service interface.
+func (s *Server) Stop() error {
+	log.Infof("Stopping %s", s.Name())
+	if s.server != nil {
+		return s.server.Close()
+	}
+	return nil
+}
+
+// Name implements services.Service interface.
+func (s *Server) Name() string {
+	return "graphql"
+}
@@ -0,0 +1,106 @@
+package json_rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/admin"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultAgentLogsTail = 100
+
+// Agent lookup errors
+var (
+	errAgentNotFound  = errors.New("agent not found")
+	errAgentAmbiguous = errors.New("agent id matches multiple agents")
+)
+
+// findAgent finds a running agent by a case-insensitive agent ID prefix so that
+// the shortened IDs from the CLI outputs can be used.
+func (p *JsonRpcProxy) findAgent(idPrefix string) (*config.AgentConfig, error) {
+	p.agentConfigMu.RLock()
+	defer p.agentConfigMu.RUnlock()
+
+	idPrefix = strings.ToLower(strings.TrimSuffix(idPrefix, "..."))
+	var found *config.AgentConfig
+	for i, agentConfig := range p.agentConfigs {
+		agentID := strings.ToLower(agentConfig.ID)
+		if agentID == idPrefix {
+			return &p.agentConfigs[i], nil
+		}
+		if !strings.HasPrefix(agentID, idPrefix) {
+			continue
+		}
+		if found != nil && found.ID != agentConfig.ID {
+			return nil, fmt.Errorf("%w: %s", errAgentAmbiguous, idPrefix)
+		}
+		found = &p.agentConfigs[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", errAgentNotFound, idPrefix)
+	}
+	return found, nil
+}
+
+// ServeAgentLogs writes the recent stdout and stderr of an agent container and keeps
+// streaming the new logs if the follow parameter is set.
+func (p *JsonRpcProxy) ServeAgentLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	idPrefix := query.Get("agent")
+	if len(idPrefix) == 0 {
+		admin.WriteError(w, http.StatusBadRequest, "agent id is required")
+		return
+	}
+	tail := defaultAgentLogsTail
+	if tailStr := query.Get("tail"); len(tailStr) > 0 {
+		n, err := strconv.Atoi(tailStr)
+		if err != nil || n < 0 {
+			admin.WriteError(w, http.StatusBadRequest, "tail should be a positive number")
+			return
+		}
+		tail = n
+	}
+	follow := query.Get("follow") == "true"
+
+	agentConfig, err := p.findAgent(idPrefix)
+	switch {
+	case errors.Is(err, errAgentNotFound):
+		admin.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		admin.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	container, err := p.dockerClient.GetContainerByName(r.Context(), agentConfig.ContainerName())
+	if err != nil {
+		admin.WriteError(w, http.StatusNotFound, fmt.Sprintf("agent container not found: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := p.dockerClient.StreamContainerLogs(
+		r.Context(), container.ID, strconv.Itoa(tail), follow, &flushWriter{w: w},
+	); err != nil {
+		log.WithError(err).WithField("agent", agentConfig.ID).Warn("failed to stream agent logs")
+	}
+}
+
+// flushWriter flushes after every write so that the followed logs reach the client immediately.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw *flushWriter) Write(b []byte) (int, error) {
+	n, err := fw.w.Write(b)
+	if flusher, ok := fw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,74 @@
+package json_rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAgent(t *testing.T) {
+	r := require.New(t)
+
+	p := &JsonRpcProxy{
+		agentConfigs: []config.AgentConfig{
+			{ID: "0xabc1"},
+			{ID: "0xabc2"},
+			{ID: "0xdef"},
+		},
+	}
+
+	agent, err := p.findAgent("0xDEF")
+	r.NoError(err)
+	r.Equal("0xdef", agent.ID)
+
+	agent, err = p.findAgent("0xabc1...")
+	r.NoError(err)
+	r.Equal("0xabc1", agent.ID)
+
+	_, err = p.findAgent("0xabc")
+	r.ErrorIs(err, errAgentAmbiguous)
+
+	_, err = p.findAgent("0x123")
+	r.ErrorIs(err, errAgentNotFound)
+}
+
+func TestServeAgentLogs(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	dockerClient := mock_clients.NewMockDockerClient(ctrl)
+	agentConfig := config.AgentConfig{ID: "0xabc"}
+	p := &JsonRpcProxy{
+		dockerClient: dockerClient,
+		agentConfigs: []config.AgentConfig{agentConfig},
+	}
+
+	dockerClient.EXPECT().GetContainerByName(gomock.Any(), agentConfig.ContainerName()).
+		Return(&types.Container{ID: "container-id"}, nil)
+	dockerClient.EXPECT().StreamContainerLogs(gomock.Any(), "container-id", "10", true, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, containerID, tail string, follow bool, w io.Writer) error {
+			_, err := w.Write([]byte("agent log line\n"))
+			return err
+		})
+
+	w := httptest.NewRecorder()
+	p.ServeAgentLogs(w, httptest.NewRequest(http.MethodGet, "/agents/logs?agent=0xabc&tail=10&follow=true", nil))
+	r.Equal(http.StatusOK, w.Code)
+	r.Equal("agent log line\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	p.ServeAgentLogs(w, httptest.NewRequest(http.MethodGet, "/agents/logs?agent=0x123", nil))
+	r.Equal(http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	p.ServeAgentLogs(w, httptest.NewRequest(http.MethodGet, "/agents/logs?agent=0xabc&tail=x", nil))
+	r.Equal(http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,174 @@
+package json_rpc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+const auditLogFileName = "json-rpc-audit.log"
+
+// AuditEntry is a line in the audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	AgentID    string    `json:"agentId,omitempty"`
+	AgentImage string    `json:"agentImage,omitempty"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Method     string    `json:"method"`
+	ParamsHash string    `json:"paramsHash,omitempty"`
+	Status     int       `json:"status"`
+	Result     string    `json:"result"`
+	LatencyMs  int64     `json:"latencyMs"`
+}
+
+// AuditLog writes the proxied requests as JSON lines and rotates the file when it gets too large.
+type AuditLog struct {
+	dir      string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+	mu   sync.Mutex
+}
+
+// NewAuditLog creates a new audit log in the directory.
+func NewAuditLog(dir string, maxSize int64, maxFiles int) (*AuditLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the audit log dir: %v", err)
+	}
+	auditLog := &AuditLog{dir: dir, maxSize: maxSize, maxFiles: maxFiles}
+	if err := auditLog.open(); err != nil {
+		return nil, err
+	}
+	return auditLog, nil
+}
+
+func (auditLog *AuditLog) filePath(n int) string {
+	if n == 0 {
+		return path.Join(auditLog.dir, auditLogFileName)
+	}
+	return path.Join(auditLog.dir, fmt.Sprintf("%s.%d", auditLogFileName, n))
+}
+
+func (auditLog *AuditLog) open() error {
+	file, err := os.OpenFile(auditLog.filePath(0), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open the audit log: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat the audit log: %v", err)
+	}
+	auditLog.file = file
+	auditLog.size = info.Size()
+	return nil
+}
+
+// rotate shifts the old files by one and drops the oldest one.
+func (auditLog *AuditLog) rotate() error {
+	if err := auditLog.file.Close(); err != nil {
+		return err
+	}
+	os.Remove(auditLog.filePath(auditLog.maxFiles))
+	for n := auditLog.maxFiles - 1; n >= 0; n-- {
+		if err := os.Rename(auditLog.filePath(n), auditLog.filePath(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return auditLog.open()
+}
+
+// Write appends the entries to the log.
+func (auditLog *AuditLog) Write(entries ...*AuditEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.size > 0 && auditLog.size+int64(buf.Len()) > auditLog.maxSize {
+		if err := auditLog.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate the audit log: %v", err)
+		}
+	}
+	n, err := auditLog.file.Write(buf.Bytes())
+	auditLog.size += int64(n)
+	return err
+}
+
+// Close closes the log file.
+func (auditLog *AuditLog) Close() error {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	return auditLog.file.Close()
+}
+
+// paramsHash returns the hex encoded SHA-256 hash of the compacted params.
+func paramsHash(params []json.RawMessage) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, param := range params {
+		if err := json.Compact(&buf, param); err != nil {
+			buf.Write(param)
+		}
+		buf.WriteByte(',')
+	}
+	hash := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// NewAuditEntries makes an entry for every request in the body.
+func NewAuditEntries(agentConfig *config.AgentConfig, remoteAddr string, body []byte, status int, result string, latency time.Duration) []*AuditEntry {
+	var requests []*jsonRpcRequest
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		json.Unmarshal(trimmed, &requests)
+	} else {
+		var req jsonRpcRequest
+		if err := json.Unmarshal(body, &req); err == nil {
+			requests = append(requests, &req)
+		}
+	}
+	if len(requests) == 0 {
+		// keep a record of the invalid requests too
+		requests = append(requests, &jsonRpcRequest{})
+	}
+
+	now := time.Now().UTC()
+	var entries []*AuditEntry
+	for _, req := range requests {
+		if req == nil {
+			continue
+		}
+		entry := &AuditEntry{
+			Time:       now,
+			RemoteAddr: remoteAddr,
+			Method:     req.Method,
+			ParamsHash: paramsHash(req.Params),
+			Status:     status,
+			Result:     result,
+			LatencyMs:  latency.Milliseconds(),
+		}
+		if agentConfig != nil {
+			entry.AgentID = agentConfig.ID
+			entry.AgentImage = agentConfig.Image
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
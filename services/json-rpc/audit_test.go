@@ -0,0 +1,59 @@
+package json_rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	auditLog, err := NewAuditLog(dir, 500, 2)
+	r.NoError(err)
+
+	agentConfig := &config.AgentConfig{ID: "0xagent", Image: "image"}
+	entries := NewAuditEntries(
+		agentConfig, "1.2.3.4:5678",
+		[]byte(`[{"method":"eth_call","params":[{"to":"0x1"}, "latest"]},{"method":"eth_blockNumber"}]`),
+		200, "success", time.Millisecond*5,
+	)
+	r.Len(entries, 2)
+	r.Equal("eth_call", entries[0].Method)
+	r.NotEmpty(entries[0].ParamsHash)
+	r.Empty(entries[1].ParamsHash)
+
+	// the same params have the same hash regardless of the formatting
+	other := NewAuditEntries(nil, "", []byte(`{"method":"eth_call","params":[{"to": "0x1"},"latest"]}`), 200, "success", 0)
+	r.Equal(entries[0].ParamsHash, other[0].ParamsHash)
+
+	for i := 0; i < 10; i++ {
+		r.NoError(auditLog.Write(entries...))
+	}
+	r.NoError(auditLog.Close())
+
+	// rotated and the oldest ones are dropped
+	_, err = os.Stat(path.Join(dir, auditLogFileName+".1"))
+	r.NoError(err)
+	_, err = os.Stat(path.Join(dir, auditLogFileName+".2"))
+	r.NoError(err)
+	_, err = os.Stat(path.Join(dir, auditLogFileName+".3"))
+	r.True(os.IsNotExist(err))
+
+	f, err := os.Open(path.Join(dir, auditLogFileName))
+	r.NoError(err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	r.True(scanner.Scan())
+	var entry AuditEntry
+	r.NoError(json.Unmarshal(scanner.Bytes(), &entry))
+	r.Equal("0xagent", entry.AgentID)
+	r.Equal(200, entry.Status)
+}
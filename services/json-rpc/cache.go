@@ -0,0 +1,398 @@
+package json_rpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+type jsonRpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type jsonRpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRpcError   `json:"error,omitempty"`
+}
+
+type cacheEntry struct {
+	key    string
+	result json.RawMessage
+	// head is the block number the entry is valid for or zero if it is valid forever
+	head uint64
+}
+
+// ResponseCache caches the responses of the idempotent requests so that the same data is
+// fetched from the upstream once no matter how many agents ask for it. The data of the finalized
+// blocks is cached until evicted and the data of the latest block is cached until the head changes.
+// The least recently used entries are evicted first so that the hot contract code and storage
+// stay in the cache.
+type ResponseCache struct {
+	ctx     context.Context
+	cfg     config.JsonRpcCacheConfig
+	entries map[string]*list.Element
+	lru     *list.List
+	mu      sync.Mutex
+	head    uint64
+	group   singleflight.Group
+
+	hits   uint64
+	misses uint64
+}
+
+// NewResponseCache creates a new response cache.
+func NewResponseCache(ctx context.Context, cfg config.JsonRpcCacheConfig) *ResponseCache {
+	return &ResponseCache{
+		ctx:     ctx,
+		cfg:     cfg,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// SetHead updates the latest block number and invalidates the entries of the older heads.
+func (c *ResponseCache) SetHead(head uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if head == c.head {
+		return
+	}
+	c.head = head
+	for key, elem := range c.entries {
+		if entry := elem.Value.(*cacheEntry); entry.head != 0 && entry.head != head {
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// PollHead keeps the head up to date by polling the upstream.
+func (c *ResponseCache) PollHead(rpcClient *rpc.Client) {
+	ticker := time.NewTicker(time.Duration(c.cfg.HeadPollSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		var head hexutil.Uint64
+		if err := rpcClient.CallContext(c.ctx, &head, "eth_blockNumber"); err != nil {
+			log.WithError(err).Warn("failed to get the latest block number for the cache")
+		} else {
+			c.SetHead(uint64(head))
+		}
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *ResponseCache) get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).result, true
+}
+
+func (c *ResponseCache) put(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.head != 0 && entry.head != c.head {
+		return
+	}
+	entry.key = key
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	// evict the least recently used entry when full
+	if len(c.entries) >= c.cfg.MaxEntries {
+		if oldest := c.lru.Back(); oldest != nil {
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+}
+
+func (c *ResponseCache) getHead() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head
+}
+
+// finalized returns true if the block is deep enough to not be reorged.
+func (c *ResponseCache) finalized(blockNumber uint64) bool {
+	head := c.getHead()
+	return head >= uint64(c.cfg.FinalityDepth) && blockNumber <= head-uint64(c.cfg.FinalityDepth)
+}
+
+// cacheableBlock decides if the result for the block tag can be cached and for how long.
+func (c *ResponseCache) cacheableBlock(tag json.RawMessage) (entryHead uint64, ok bool) {
+	var s string
+	if len(tag) > 0 {
+		if err := json.Unmarshal(tag, &s); err != nil {
+			return 0, false
+		}
+	}
+	head := c.getHead()
+	if s == "" || s == "latest" {
+		return head, head != 0
+	}
+	blockNumber, err := hexutil.DecodeUint64(s)
+	if err != nil {
+		// pending, earliest, safe, finalized etc.
+		return 0, false
+	}
+	if blockNumber == head {
+		return head, true
+	}
+	return 0, c.finalized(blockNumber)
+}
+
+// blockTagIndex returns the index of the block tag in the params of the state queries.
+func blockTagIndex(method string) (int, bool) {
+	switch method {
+	case "eth_getCode":
+		return 1, true
+	case "eth_getStorageAt":
+		return 2, true
+	}
+	return 0, false
+}
+
+// pinLatest replaces the latest block tag of the state queries with the current head so that
+// the queries for the latest block and the head block share the cache entries and the result
+// is guaranteed to belong to the head the entry is cached for.
+func (c *ResponseCache) pinLatest(req *jsonRpcRequest) {
+	i, ok := blockTagIndex(req.Method)
+	if !ok || len(req.Params) < i {
+		return
+	}
+	head := c.getHead()
+	if head == 0 {
+		return
+	}
+	if len(req.Params) > i {
+		var tag string
+		if err := json.Unmarshal(req.Params[i], &tag); err != nil || (tag != "" && tag != "latest") {
+			return
+		}
+	}
+	tag, _ := json.Marshal(hexutil.EncodeUint64(head))
+	params := append([]json.RawMessage{}, req.Params[:i]...)
+	req.Params = append(params, tag)
+}
+
+// requestKey returns the cache key if the request might be cacheable.
+func requestKey(req *jsonRpcRequest) (string, bool) {
+	switch req.Method {
+	case "eth_getBlockByNumber", "eth_getTransactionReceipt", "eth_getCode", "eth_getStorageAt":
+	default:
+		return "", false
+	}
+	var params bytes.Buffer
+	for _, param := range req.Params {
+		if err := json.Compact(&params, param); err != nil {
+			return "", false
+		}
+		params.WriteByte(',')
+	}
+	return fmt.Sprintf("%s(%s)", req.Method, strings.ToLower(params.String())), true
+}
+
+// cacheEntryFor returns the entry to cache for the result or nil if it is not cacheable.
+func (c *ResponseCache) cacheEntryFor(req *jsonRpcRequest, result json.RawMessage) *cacheEntry {
+	if len(result) == 0 || string(result) == "null" {
+		return nil
+	}
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		if len(req.Params) == 0 {
+			return nil
+		}
+		if head, ok := c.cacheableBlock(req.Params[0]); ok && head == 0 {
+			return &cacheEntry{result: result}
+		}
+
+	case "eth_getTransactionReceipt":
+		var receipt struct {
+			BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		}
+		if err := json.Unmarshal(result, &receipt); err == nil && c.finalized(uint64(receipt.BlockNumber)) {
+			return &cacheEntry{result: result}
+		}
+
+	case "eth_getCode", "eth_getStorageAt":
+		i, _ := blockTagIndex(req.Method)
+		var tag json.RawMessage
+		if len(req.Params) > i {
+			tag = req.Params[i]
+		}
+		if head, ok := c.cacheableBlock(tag); ok {
+			return &cacheEntry{result: result, head: head}
+		}
+	}
+	return nil
+}
+
+// upstreamResponse is the response of the upstream request shared by the concurrent requests.
+type upstreamResponse struct {
+	result   json.RawMessage
+	recorder *httptest.ResponseRecorder // set if the upstream did not return a result
+}
+
+// Handler serves the cacheable requests from the cache and passes the rest to the next handler.
+// The concurrent requests for the same data wait for a single upstream request.
+func (c *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, "failed to read the request", http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var rpcReq jsonRpcRequest
+		if err := json.Unmarshal(body, &rpcReq); err != nil {
+			// batch requests or invalid payloads
+			next.ServeHTTP(w, req)
+			return
+		}
+		c.pinLatest(&rpcReq)
+		key, ok := requestKey(&rpcReq)
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if result, ok := c.get(key); ok {
+			atomic.AddUint64(&c.hits, 1)
+			writeResult(w, &rpcReq, result)
+			return
+		}
+		atomic.AddUint64(&c.misses, 1)
+
+		resp, _, _ := c.group.Do(key, func() (interface{}, error) {
+			return c.fetch(next, req, &rpcReq, key), nil
+		})
+		upstreamResp := resp.(*upstreamResponse)
+		if upstreamResp.recorder != nil {
+			writeUpstreamResponse(w, &rpcReq, upstreamResp.recorder)
+			return
+		}
+		writeResult(w, &rpcReq, upstreamResp.result)
+	})
+}
+
+// fetch sends the request to the upstream and caches the result if possible.
+func (c *ResponseCache) fetch(next http.Handler, req *http.Request, rpcReq *jsonRpcRequest, key string) *upstreamResponse {
+	upstreamReq := req.Clone(c.ctx)
+	body, _ := json.Marshal(&jsonRpcRequest{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: rpcReq.Method, Params: rpcReq.Params})
+	upstreamReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	upstreamReq.ContentLength = int64(len(body))
+	// let the transport handle the compression
+	upstreamReq.Header.Del("Accept-Encoding")
+
+	recorder := httptest.NewRecorder()
+	next.ServeHTTP(recorder, upstreamReq)
+	if recorder.Code != http.StatusOK {
+		return &upstreamResponse{recorder: recorder}
+	}
+	var resp jsonRpcResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || resp.Error != nil {
+		return &upstreamResponse{recorder: recorder}
+	}
+	// not cached if not cacheable but still shared with the concurrent requests
+	if entry := c.cacheEntryFor(rpcReq, resp.Result); entry != nil {
+		c.put(key, entry)
+	}
+	return &upstreamResponse{result: resp.Result}
+}
+
+// writeUpstreamResponse writes the recorded upstream response which has no result, e.g. an
+// error, with the ID of the request.
+func writeUpstreamResponse(w http.ResponseWriter, req *jsonRpcRequest, recorder *httptest.ResponseRecorder) {
+	// keep the error as is, e.g. with the revert data
+	var resp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   json.RawMessage `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || len(resp.ID) == 0 {
+		writeRecorded(w, recorder)
+		return
+	}
+	resp.ID = req.ID
+	for k, values := range recorder.Header() {
+		if k == "Content-Length" {
+			continue
+		}
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(recorder.Code)
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		log.WithError(err).Error("failed to write upstream jsonrpc response")
+	}
+}
+
+func writeResult(w http.ResponseWriter, req *jsonRpcRequest, result json.RawMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&jsonRpcResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}); err != nil {
+		log.WithError(err).Error("failed to write cached jsonrpc response")
+	}
+}
+
+// Health returns the cache stats.
+func (c *ResponseCache) Health() health.Reports {
+	c.mu.Lock()
+	size := len(c.entries)
+	c.mu.Unlock()
+	return health.Reports{
+		&health.Report{
+			Name:    "cache.size",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprint(size),
+		},
+		&health.Report{
+			Name:    "cache.hits",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprint(atomic.LoadUint64(&c.hits)),
+		},
+		&health.Report{
+			Name:    "cache.misses",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprint(atomic.LoadUint64(&c.misses)),
+		},
+	}
+}
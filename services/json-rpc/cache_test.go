@@ -0,0 +1,166 @@
+package json_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testCacheRequest(t *testing.T, handler http.Handler, id int, method, params string) *jsonRpcResponse {
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":%s}`, id, method, params)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	var resp jsonRpcResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	return &resp
+}
+
+func TestResponseCache(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamCalls int32
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&upstreamCalls, 1)
+		var rpcReq jsonRpcRequest
+		b, _ := ioutil.ReadAll(req.Body)
+		r.NoError(json.Unmarshal(b, &rpcReq))
+		result := `"0x1234"`
+		switch rpcReq.Method {
+		case "eth_getTransactionReceipt":
+			result = `{"blockNumber":"0x5a"}`
+		case "eth_getBlockByNumber":
+			result = `{"number":` + string(rpcReq.Params[0]) + `}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, string(rpcReq.ID), result)
+	})
+
+	cache := NewResponseCache(context.Background(), config.JsonRpcCacheConfig{
+		MaxEntries:    100,
+		FinalityDepth: 10,
+	})
+	cache.SetHead(100)
+	handler := cache.Handler(upstream)
+
+	// finalized block is fetched once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			resp := testCacheRequest(t, handler, id, "eth_getBlockByNumber", `["0x1", false]`)
+			r.Equal(fmt.Sprint(id), string(resp.ID))
+			r.JSONEq(`{"number":"0x1"}`, string(resp.Result))
+		}(i)
+	}
+	wg.Wait()
+	r.Equal(int32(1), atomic.LoadInt32(&upstreamCalls))
+
+	// recent blocks are not cached
+	testCacheRequest(t, handler, 1, "eth_getBlockByNumber", `["0x60", false]`)
+	testCacheRequest(t, handler, 1, "eth_getBlockByNumber", `["0x60", false]`)
+	r.Equal(int32(3), atomic.LoadInt32(&upstreamCalls))
+
+	// finalized receipt is cached
+	testCacheRequest(t, handler, 1, "eth_getTransactionReceipt", `["0xabc"]`)
+	testCacheRequest(t, handler, 1, "eth_getTransactionReceipt", `["0xABC"]`)
+	r.Equal(int32(4), atomic.LoadInt32(&upstreamCalls))
+
+	// latest code is cached until the head changes
+	testCacheRequest(t, handler, 1, "eth_getCode", `["0xdef", "latest"]`)
+	testCacheRequest(t, handler, 1, "eth_getCode", `["0xdef", "latest"]`)
+	r.Equal(int32(5), atomic.LoadInt32(&upstreamCalls))
+	cache.SetHead(101)
+	testCacheRequest(t, handler, 1, "eth_getCode", `["0xdef", "latest"]`)
+	r.Equal(int32(6), atomic.LoadInt32(&upstreamCalls))
+
+	// latest and the head block share the entry
+	testCacheRequest(t, handler, 1, "eth_getCode", `["0xdef", "0x65"]`)
+	testCacheRequest(t, handler, 1, "eth_getCode", `["0xdef"]`)
+	r.Equal(int32(6), atomic.LoadInt32(&upstreamCalls))
+
+	// storage slots are cached like the code
+	testCacheRequest(t, handler, 1, "eth_getStorageAt", `["0xdef", "0x0", "latest"]`)
+	testCacheRequest(t, handler, 1, "eth_getStorageAt", `["0xdef", "0x0", "0x65"]`)
+	testCacheRequest(t, handler, 1, "eth_getStorageAt", `["0xdef", "0x0", "0x1"]`)
+	testCacheRequest(t, handler, 1, "eth_getStorageAt", `["0xdef", "0x0", "0x1"]`)
+	r.Equal(int32(8), atomic.LoadInt32(&upstreamCalls))
+
+	// the rest are passed through
+	testCacheRequest(t, handler, 1, "eth_blockNumber", `[]`)
+	testCacheRequest(t, handler, 1, "eth_blockNumber", `[]`)
+	r.Equal(int32(10), atomic.LoadInt32(&upstreamCalls))
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	r := require.New(t)
+
+	cache := NewResponseCache(context.Background(), config.JsonRpcCacheConfig{MaxEntries: 2})
+	cache.put("a", &cacheEntry{result: json.RawMessage(`"a"`)})
+	cache.put("b", &cacheEntry{result: json.RawMessage(`"b"`)})
+	_, ok := cache.get("a")
+	r.True(ok)
+	cache.put("c", &cacheEntry{result: json.RawMessage(`"c"`)})
+
+	_, ok = cache.get("b")
+	r.False(ok)
+	_, ok = cache.get("a")
+	r.True(ok)
+	_, ok = cache.get("c")
+	r.True(ok)
+}
+
+func TestResponseCacheUpstreamError(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamCalls int32
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&upstreamCalls, 1)
+		var rpcReq jsonRpcRequest
+		b, _ := ioutil.ReadAll(req.Body)
+		r.NoError(json.Unmarshal(b, &rpcReq))
+		w.Header().Set("X-Upstream", "1")
+		if rpcReq.Method == "eth_getCode" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, "rate limited")
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"header not found","data":"0x01"}}`, string(rpcReq.ID))
+	})
+
+	cache := NewResponseCache(context.Background(), config.JsonRpcCacheConfig{MaxEntries: 100, FinalityDepth: 10})
+	cache.SetHead(100)
+	handler := cache.Handler(upstream)
+
+	// the error is returned to the client without calling the upstream again
+	resp := testCacheRequest(t, handler, 7, "eth_getBlockByNumber", `["0x1", false]`)
+	r.Equal("7", string(resp.ID))
+	r.NotNil(resp.Error)
+	r.Equal("header not found", resp.Error.Message)
+	r.Equal(int32(1), atomic.LoadInt32(&upstreamCalls))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":8,"method":"eth_getBlockByNumber","params":["0x1",false]}`))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	r.JSONEq(`{"jsonrpc":"2.0","id":8,"error":{"code":-32000,"message":"header not found","data":"0x01"}}`, recorder.Body.String())
+	r.Equal(int32(2), atomic.LoadInt32(&upstreamCalls))
+
+	// and so are the non-json-rpc responses together with the status and the headers
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["0xdef","0x1"]}`))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	r.Equal(http.StatusTooManyRequests, recorder.Code)
+	r.Equal("1", recorder.Header().Get("X-Upstream"))
+	r.Equal("rate limited", recorder.Body.String())
+	r.Equal(int32(3), atomic.LoadInt32(&upstreamCalls))
+}
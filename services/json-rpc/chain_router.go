@@ -0,0 +1,124 @@
+package json_rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// Chain hints
+const (
+	ChainIDHeader   = "X-Forta-Chain-Id"
+	chainPathPrefix = "/chains/"
+)
+
+// newUpstreamProxy creates a reverse proxy which sends all requests to the json-rpc url.
+func newUpstreamProxy(cfg config.JsonRpcConfig, limits ResponseLimits) (*httputil.ReverseProxy, error) {
+	rpcUrl, err := url.Parse(cfg.Url)
+	if err != nil {
+		return nil, err
+	}
+	rp := httputil.NewSingleHostReverseProxy(rpcUrl)
+	rp.Transport = &limitedTransport{limits: limits, transport: http.DefaultTransport}
+
+	d := rp.Director
+	rp.Director = func(r *http.Request) {
+		d(r)
+		r.Host = rpcUrl.Host
+		r.URL = rpcUrl
+		r.Header.Del(ChainIDHeader)
+		for h, v := range cfg.Headers {
+			r.Header.Set(h, v)
+		}
+	}
+	return rp, nil
+}
+
+// NewUpstreamRouter creates a handler which routes the requests to the configured upstreams
+// directly, without the agent limits of the proxy. This is useful for serving an agent which
+// runs outside of the node.
+func NewUpstreamRouter(cfg config.Config) (http.Handler, error) {
+	limits := NewResponseLimits(cfg.JsonRpcProxy)
+	rp, err := newUpstreamProxy(getUpstreamConfig(cfg), limits)
+	if err != nil {
+		return nil, err
+	}
+	return NewChainRouter(cfg.ChainID, rp, cfg.JsonRpcProxy.Chains, limits)
+}
+
+// ChainRouter routes the requests to the upstream of the chain which the agent asks for
+// in the chain ID header or with the /chains/<chain id> path. The requests without a chain
+// hint go to the default chain.
+type ChainRouter struct {
+	defaultChainID int
+	defaultHandler http.Handler
+	chains         map[int]http.Handler
+}
+
+// NewChainRouter creates a new chain router.
+func NewChainRouter(defaultChainID int, defaultHandler http.Handler, chains []config.JsonRpcChainConfig, limits ResponseLimits) (*ChainRouter, error) {
+	router := &ChainRouter{
+		defaultChainID: defaultChainID,
+		defaultHandler: defaultHandler,
+		chains:         make(map[int]http.Handler),
+	}
+	for _, chain := range chains {
+		if chain.ChainID == defaultChainID {
+			return nil, fmt.Errorf("chain %d is already the default chain", chain.ChainID)
+		}
+		if _, ok := router.chains[chain.ChainID]; ok {
+			return nil, fmt.Errorf("duplicate chain config: %d", chain.ChainID)
+		}
+		rp, err := newUpstreamProxy(chain.JsonRpc, limits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json-rpc url for chain %d: %v", chain.ChainID, err)
+		}
+		router.chains[chain.ChainID] = rp
+	}
+	return router, nil
+}
+
+// chainHint returns the chain ID requested by the agent and strips the path hint.
+func chainHint(req *http.Request) (int, bool, error) {
+	if strings.HasPrefix(req.URL.Path, chainPathPrefix) {
+		idStr := strings.TrimPrefix(req.URL.Path, chainPathPrefix)
+		idStr = strings.SplitN(idStr, "/", 2)[0]
+		chainID, err := strconv.Atoi(idStr)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid chain id in path: %s", idStr)
+		}
+		req.URL.Path = "/"
+		return chainID, true, nil
+	}
+	if header := req.Header.Get(ChainIDHeader); len(header) > 0 {
+		chainID, err := strconv.Atoi(header)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid chain id header: %s", header)
+		}
+		return chainID, true, nil
+	}
+	return 0, false, nil
+}
+
+func (router *ChainRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	chainID, ok, err := chainHint(req)
+	if err != nil {
+		writeErr(w, req, http.StatusBadRequest, -32602, err.Error())
+		return
+	}
+	if !ok || chainID == router.defaultChainID {
+		router.defaultHandler.ServeHTTP(w, req)
+		return
+	}
+	handler, ok := router.chains[chainID]
+	if !ok {
+		writeErr(w, req, http.StatusNotFound, -32602, fmt.Sprintf("chain %d is not supported by the scan node", chainID))
+		return
+	}
+	handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,86 @@
+package json_rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRouter(t *testing.T) {
+	r := require.New(t)
+
+	var polygonReq *http.Request
+	polygon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		polygonReq = req
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x89"}`))
+	}))
+	defer polygon.Close()
+
+	var defaultCalls int
+	defaultHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defaultCalls++
+	})
+
+	router, err := NewChainRouter(1, defaultHandler, []config.JsonRpcChainConfig{
+		{ChainID: 137, JsonRpc: config.JsonRpcConfig{Url: polygon.URL, Headers: map[string]string{"Authorization": "key"}}},
+	}, ResponseLimits{})
+	r.NoError(err)
+
+	send := func(path string, chainHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`))
+		if len(chainHeader) > 0 {
+			req.Header.Set(ChainIDHeader, chainHeader)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	send("/", "")
+	send("/", "1")
+	send("/chains/1", "")
+	r.Equal(3, defaultCalls)
+
+	recorder := send("/chains/137", "")
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Contains(recorder.Body.String(), "0x89")
+	r.Equal("key", polygonReq.Header.Get("Authorization"))
+
+	send("/", "137")
+	r.Empty(polygonReq.Header.Get(ChainIDHeader))
+
+	r.Equal(http.StatusNotFound, send("/", "56").Code)
+	r.Equal(http.StatusBadRequest, send("/chains/abc", "").Code)
+
+	_, err = NewChainRouter(1, defaultHandler, []config.JsonRpcChainConfig{{ChainID: 1}}, ResponseLimits{})
+	r.Error(err)
+}
+
+func TestUpstreamRouter(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamReq *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upstreamReq = req
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer upstream.Close()
+
+	var cfg config.Config
+	cfg.ChainID = 1
+	cfg.Scan.JsonRpc = config.JsonRpcConfig{Url: upstream.URL, Headers: map[string]string{"Authorization": "key"}}
+
+	router, err := NewUpstreamRouter(cfg)
+	r.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Contains(recorder.Body.String(), "0x1")
+	r.Equal("key", upstreamReq.Header.Get("Authorization"))
+}
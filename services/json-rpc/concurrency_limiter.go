@@ -0,0 +1,64 @@
+package json_rpc
+
+import (
+	"sync"
+)
+
+// ConcurrencyLimiter limits the number of in-flight requests per client.
+type ConcurrencyLimiter struct {
+	max          int
+	clientMaxes  map[string]int
+	clientActive map[string]int
+	mu           sync.Mutex
+}
+
+// NewConcurrencyLimiter creates a new concurrency limiter. Zero max means unlimited.
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		max:          max,
+		clientMaxes:  make(map[string]int),
+		clientActive: make(map[string]int),
+	}
+}
+
+// Reset sets the new default max and removes the client overrides. The in-flight requests
+// are still counted.
+func (cl *ConcurrencyLimiter) Reset(max int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.max = max
+	cl.clientMaxes = make(map[string]int)
+}
+
+// SetClientMax overrides the default max for the client.
+func (cl *ConcurrencyLimiter) SetClientMax(clientID string, max int) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.clientMaxes[clientID] = max
+}
+
+// Acquire reserves a slot for a request and returns false if the client is at the limit.
+// Every successful call must be followed by a Release call.
+func (cl *ConcurrencyLimiter) Acquire(clientID string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	max, ok := cl.clientMaxes[clientID]
+	if !ok {
+		max = cl.max
+	}
+	if max > 0 && cl.clientActive[clientID] >= max {
+		return false
+	}
+	cl.clientActive[clientID]++
+	return true
+}
+
+// Release frees the slot of a finished request.
+func (cl *ConcurrencyLimiter) Release(clientID string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.clientActive[clientID]--
+	if cl.clientActive[clientID] <= 0 {
+		delete(cl.clientActive, clientID)
+	}
+}
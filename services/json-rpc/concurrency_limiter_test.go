@@ -0,0 +1,42 @@
+package json_rpc_test
+
+import (
+	"testing"
+
+	json_rpc "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	r := require.New(t)
+	limiter := json_rpc.NewConcurrencyLimiter(1)
+	limiter.SetClientMax("2", 2)
+
+	r.True(limiter.Acquire(testClientID))
+	r.False(limiter.Acquire(testClientID))
+	limiter.Release(testClientID)
+	r.True(limiter.Acquire(testClientID))
+
+	r.True(limiter.Acquire("2"))
+	r.True(limiter.Acquire("2"))
+	r.False(limiter.Acquire("2"))
+
+	// unlimited
+	limiter = json_rpc.NewConcurrencyLimiter(0)
+	for i := 0; i < 100; i++ {
+		r.True(limiter.Acquire(testClientID))
+	}
+}
+
+func TestConcurrencyLimiter_Reset(t *testing.T) {
+	r := require.New(t)
+	limiter := json_rpc.NewConcurrencyLimiter(1)
+	limiter.SetClientMax("2", 2)
+	r.True(limiter.Acquire("2"))
+
+	// the in-flight request still counts after the reset
+	limiter.Reset(1)
+	r.False(limiter.Acquire("2"))
+	limiter.Release("2")
+	r.True(limiter.Acquire("2"))
+}
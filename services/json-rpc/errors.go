@@ -2,6 +2,7 @@ package json_rpc
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
@@ -23,7 +24,19 @@ type jsonRpcError struct {
 }
 
 func writeTooManyReqsErr(w http.ResponseWriter, req *http.Request) {
-	w.WriteHeader(http.StatusTooManyRequests)
+	writeErr(w, req, http.StatusTooManyRequests, -32000, "agent exceeds scan node request limit")
+}
+
+func writeTooManyConcurrentReqsErr(w http.ResponseWriter, req *http.Request) {
+	writeErr(w, req, http.StatusTooManyRequests, -32000, "agent exceeds scan node concurrent request limit")
+}
+
+func writeMethodNotAllowedErr(w http.ResponseWriter, req *http.Request, method string) {
+	writeErr(w, req, http.StatusForbidden, -32601, fmt.Sprintf("method %s is not allowed by the scan node", method))
+}
+
+func writeErr(w http.ResponseWriter, req *http.Request, statusCode, code int, message string) {
+	w.WriteHeader(statusCode)
 
 	var reqPayload requestPayload
 	if err := json.NewDecoder(req.Body).Decode(&reqPayload); err != nil {
@@ -35,8 +48,8 @@ func writeTooManyReqsErr(w http.ResponseWriter, req *http.Request) {
 		JSONRPC: "2.0",
 		ID:      reqPayload.ID,
 		Error: jsonRpcError{
-			Code:    -32000,
-			Message: "agent exceeds scan node request limit",
+			Code:    code,
+			Message: message,
 		},
 	}); err != nil {
 		log.WithError(err).Error("failed to write jsonrpc error response body")
@@ -0,0 +1,195 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const healthCheckTimeout = time.Second * 5
+
+type upstream struct {
+	name    string
+	handler http.Handler
+	client  *rpc.Client
+
+	failures int
+}
+
+func newUpstream(name string, cfg config.JsonRpcConfig, limits ResponseLimits) (*upstream, error) {
+	rp, err := newUpstreamProxy(cfg, limits)
+	if err != nil {
+		return nil, err
+	}
+	client, err := rpc.DialHTTP(cfg.Url)
+	if err != nil {
+		return nil, err
+	}
+	for h, v := range cfg.Headers {
+		client.SetHeader(h, v)
+	}
+	return &upstream{name: name, handler: rp, client: client}, nil
+}
+
+func (u *upstream) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	var blockNumber string
+	return u.client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+}
+
+// FailoverUpstream sends the requests to the primary upstream and switches to the secondary
+// one when the primary fails consecutively. It switches back when the primary recovers.
+// A failed request is retried once on the other upstream.
+type FailoverUpstream struct {
+	ctx              context.Context
+	primary          *upstream
+	secondary        *upstream
+	failureThreshold int
+	interval         time.Duration
+
+	active     *upstream
+	lastSwitch health.TimeTracker
+	mu         sync.Mutex
+}
+
+// NewFailoverUpstream creates a new failover upstream.
+func NewFailoverUpstream(ctx context.Context, primaryCfg config.JsonRpcConfig, cfg config.JsonRpcFailoverConfig, limits ResponseLimits) (*FailoverUpstream, error) {
+	primary, err := newUpstream("primary", primaryCfg, limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid primary upstream: %v", err)
+	}
+	secondary, err := newUpstream("secondary", cfg.JsonRpc, limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secondary upstream: %v", err)
+	}
+	return &FailoverUpstream{
+		ctx:              ctx,
+		primary:          primary,
+		secondary:        secondary,
+		failureThreshold: cfg.FailureThreshold,
+		interval:         time.Duration(cfg.HealthCheckSeconds) * time.Second,
+		active:           primary,
+	}, nil
+}
+
+// StartHealthChecks checks the upstreams periodically.
+func (fu *FailoverUpstream) StartHealthChecks() {
+	ticker := time.NewTicker(fu.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fu.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fu.report(fu.primary, fu.primary.check(fu.ctx))
+		fu.report(fu.secondary, fu.secondary.check(fu.ctx))
+	}
+}
+
+// report updates the failure count of the upstream and switches the active upstream if needed.
+func (fu *FailoverUpstream) report(u *upstream, err error) {
+	fu.mu.Lock()
+	defer fu.mu.Unlock()
+	if err != nil {
+		u.failures++
+		log.WithError(err).WithField("upstream", u.name).Warn("json-rpc upstream failed")
+	} else {
+		u.failures = 0
+	}
+
+	switch {
+	case fu.active == fu.primary && fu.primary.failures >= fu.failureThreshold && fu.secondary.failures == 0:
+		fu.switchTo(fu.secondary)
+	case fu.active == fu.secondary && fu.primary.failures == 0:
+		fu.switchTo(fu.primary)
+	}
+}
+
+func (fu *FailoverUpstream) switchTo(u *upstream) {
+	log.WithField("upstream", u.name).Warn("switching the json-rpc upstream")
+	fu.active = u
+	fu.lastSwitch.Set()
+}
+
+func (fu *FailoverUpstream) upstreams() (active, other *upstream) {
+	fu.mu.Lock()
+	defer fu.mu.Unlock()
+	if fu.active == fu.primary {
+		return fu.primary, fu.secondary
+	}
+	return fu.secondary, fu.primary
+}
+
+// Active returns the name of the active upstream.
+func (fu *FailoverUpstream) Active() string {
+	active, _ := fu.upstreams()
+	return active.name
+}
+
+func (fu *FailoverUpstream) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+
+	active, other := fu.upstreams()
+	recorder := fu.serve(active, req, body)
+	if recorder.Code < http.StatusInternalServerError {
+		fu.report(active, nil)
+		writeRecorded(w, recorder)
+		return
+	}
+	fu.report(active, fmt.Errorf("status code %d", recorder.Code))
+
+	retried := fu.serve(other, req, body)
+	if retried.Code < http.StatusInternalServerError {
+		fu.report(other, nil)
+		writeRecorded(w, retried)
+		return
+	}
+	fu.report(other, fmt.Errorf("status code %d", retried.Code))
+	writeRecorded(w, recorder)
+}
+
+func (fu *FailoverUpstream) serve(u *upstream, req *http.Request, body []byte) *httptest.ResponseRecorder {
+	upstreamReq := req.Clone(req.Context())
+	upstreamReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+	u.handler.ServeHTTP(recorder, upstreamReq)
+	return recorder
+}
+
+func writeRecorded(w http.ResponseWriter, recorder *httptest.ResponseRecorder) {
+	for k, values := range recorder.Header() {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(recorder.Code)
+	w.Write(recorder.Body.Bytes())
+}
+
+// Health returns the active upstream and the last switch time.
+func (fu *FailoverUpstream) Health() health.Reports {
+	return health.Reports{
+		&health.Report{
+			Name:    "upstream.active",
+			Status:  health.StatusInfo,
+			Details: fu.Active(),
+		},
+		fu.lastSwitch.GetReport("upstream.last-switch"),
+	}
+}
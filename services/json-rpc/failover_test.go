@@ -0,0 +1,68 @@
+package json_rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverUpstream(t *testing.T) {
+	r := require.New(t)
+
+	var primaryDown int32 = 1
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&primaryDown) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"primary"}`))
+	}))
+	defer primary.Close()
+	var secondaryBody string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b := make([]byte, 1024)
+		n, _ := req.Body.Read(b)
+		secondaryBody = string(b[:n])
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"secondary"}`))
+	}))
+	defer secondary.Close()
+
+	fu, err := NewFailoverUpstream(context.Background(), config.JsonRpcConfig{Url: primary.URL}, config.JsonRpcFailoverConfig{
+		JsonRpc:            config.JsonRpcConfig{Url: secondary.URL},
+		HealthCheckSeconds: 1,
+		FailureThreshold:   2,
+	}, ResponseLimits{})
+	r.NoError(err)
+
+	const reqBody = `{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(reqBody))
+		recorder := httptest.NewRecorder()
+		fu.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// the failed request is retried on the secondary
+	recorder := send()
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Contains(recorder.Body.String(), "secondary")
+	r.Equal(reqBody, secondaryBody)
+	r.Equal("primary", fu.Active())
+
+	// switches after the threshold
+	send()
+	r.Equal("secondary", fu.Active())
+	r.Contains(send().Body.String(), "secondary")
+
+	// switches back when the primary recovers
+	atomic.StoreInt32(&primaryDown, 0)
+	fu.report(fu.primary, fu.primary.check(context.Background()))
+	r.Equal("primary", fu.Active())
+	r.Contains(send().Body.String(), "primary")
+}
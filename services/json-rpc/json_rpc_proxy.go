@@ -1,16 +1,21 @@
 package json_rpc
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
+	"path"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 
@@ -24,10 +29,14 @@ import (
 	"github.com/forta-network/forta-node/metrics"
 )
 
+const readinessTimeout = time.Second * 5
+
 // JsonRpcProxy proxies requests from agents to json-rpc endpoint
 type JsonRpcProxy struct {
 	ctx          context.Context
 	cfg          config.JsonRpcConfig
+	chainID      int
+	chains       []config.JsonRpcChainConfig
 	server       *http.Server
 	dockerClient clients.DockerClient
 	msgClient    clients.MessageClient
@@ -35,7 +44,16 @@ type JsonRpcProxy struct {
 	agentConfigs  []config.AgentConfig
 	agentConfigMu sync.RWMutex
 
-	rateLimiter *RateLimiter
+	rateLimiter        *RateLimiter
+	concurrencyLimiter *ConcurrencyLimiter
+	methodFilter       *MethodFilter
+	usage              *UsageTracker
+	wsProxy            *WebSocketProxy
+	auditLog           *AuditLog
+	cache              *ResponseCache
+	failover           *FailoverUpstream
+	limits             ResponseLimits
+	router             *swappableHandler
 
 	lastErr health.ErrorTracker
 }
@@ -45,21 +63,24 @@ func (p *JsonRpcProxy) Start() error {
 
 	p.registerMessageHandlers()
 
-	rpcUrl, err := url.Parse(p.cfg.Url)
-	if err != nil {
-		return err
+	if p.failover != nil {
+		go p.failover.StartHealthChecks()
 	}
-	rp := httputil.NewSingleHostReverseProxy(rpcUrl)
-
-	d := rp.Director
-	rp.Director = func(r *http.Request) {
-		d(r)
-		r.Host = rpcUrl.Host
-		r.URL = rpcUrl
+	if p.cache != nil {
+		rpcClient, err := rpc.DialHTTP(p.cfg.Url)
+		if err != nil {
+			return fmt.Errorf("failed to create the rpc client for the cache: %v", err)
+		}
 		for h, v := range p.cfg.Headers {
-			r.Header.Set(h, v)
+			rpcClient.SetHeader(h, v)
 		}
+		go p.cache.PollHead(rpcClient)
+	}
+	router, err := p.newRouter(p.cfg, p.chains)
+	if err != nil {
+		return err
 	}
+	p.router.Set(router)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
@@ -68,35 +89,252 @@ func (p *JsonRpcProxy) Start() error {
 
 	p.server = &http.Server{
 		Addr:    ":8545",
-		Handler: p.metricHandler(c.Handler(rp)),
+		Handler: p.webSocketHandler(p.metricHandler(c.Handler(p.router))),
 	}
 	utils.GoListenAndServe(p.server)
 	return nil
 }
 
+// newRouter creates the handler which routes the requests to the upstreams.
+func (p *JsonRpcProxy) newRouter(jCfg config.JsonRpcConfig, chains []config.JsonRpcChainConfig) (http.Handler, error) {
+	rp, err := newUpstreamProxy(jCfg, p.limits)
+	if err != nil {
+		return nil, err
+	}
+	var upstream http.Handler = rp
+	if p.failover != nil {
+		upstream = p.failover
+	}
+	handler := upstream
+	if p.cache != nil {
+		handler = p.cache.Handler(upstream)
+	}
+	return NewChainRouter(p.chainID, handler, chains, p.limits)
+}
+
+// ReloadConfig applies the new rate limits and the upstream endpoints.
+func (p *JsonRpcProxy) ReloadConfig(cfg config.Config) error {
+	ConvertToDockerHostURLs(&cfg)
+
+	rateLimiting := getRateLimiting(cfg)
+	p.rateLimiter.Reset(rateLimiting.Rate, rateLimiting.Burst)
+	p.concurrencyLimiter.Reset(cfg.JsonRpcProxy.MaxConcurrentRequests)
+	setAgentLimits(p.rateLimiter, p.concurrencyLimiter, cfg.JsonRpcProxy.AgentLimits)
+
+	jCfg := getUpstreamConfig(cfg)
+	if reflect.DeepEqual(jCfg, p.cfg) && reflect.DeepEqual(cfg.JsonRpcProxy.Chains, p.chains) {
+		return nil
+	}
+	if p.cache != nil || p.failover != nil {
+		return errors.New("upstream changes require a restart when the cache or the failover is enabled")
+	}
+	router, err := p.newRouter(jCfg, cfg.JsonRpcProxy.Chains)
+	if err != nil {
+		return fmt.Errorf("failed to create the new router: %v", err)
+	}
+	p.router.Set(router)
+	p.cfg = jCfg
+	p.chains = cfg.JsonRpcProxy.Chains
+	log.WithField("url", jCfg.Url).Info("reloaded json-rpc upstreams")
+	return nil
+}
+
+// ReloadableFields returns the proxy config fields which are applied by ReloadConfig.
+func (p *JsonRpcProxy) ReloadableFields() []string {
+	return []string{
+		"jsonRpcProxy.jsonRpc",
+		"jsonRpcProxy.chains",
+		"jsonRpcProxy.rateLimit",
+		"jsonRpcProxy.maxConcurrentRequests",
+		"jsonRpcProxy.agentLimits",
+	}
+}
+
+// webSocketHandler passes the WebSocket upgrade requests to the WebSocket proxy.
+func (p *JsonRpcProxy) webSocketHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !websocket.IsWebSocketUpgrade(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		if p.wsProxy == nil {
+			http.Error(w, "websocket proxy is not enabled", http.StatusNotImplemented)
+			return
+		}
+		hooks := &wsAgentHooks{proxy: p, remoteAddr: req.RemoteAddr}
+		// the subscriptions of the unknown agents are limited by the address
+		key := strings.Split(req.RemoteAddr, ":")[0]
+		if agentConfig, ok := p.findAgentFromRemoteAddr(req.RemoteAddr); ok {
+			hooks.agentConfig = agentConfig
+			key = strings.ToLower(agentConfig.ID)
+		}
+		p.wsProxy.Serve(w, req, key, hooks)
+	})
+}
+
+// wsAgentHooks applies the same limits, audit log and usage tracking as the metric handler
+// to the request frames of a WebSocket connection. The agent config is nil if the agent is unknown.
+type wsAgentHooks struct {
+	proxy       *JsonRpcProxy
+	agentConfig *config.AgentConfig
+	remoteAddr  string
+}
+
+func (h *wsAgentHooks) Allow(frame []byte) (string, bool) {
+	if h.agentConfig == nil {
+		return "", true
+	}
+	t := time.Now()
+	agentID := strings.ToLower(h.agentConfig.ID)
+	message := "agent exceeds scan node request limit"
+	allowed := !h.proxy.rateLimiter.ExceedsLimit(agentID)
+	if allowed && !h.proxy.concurrencyLimiter.Acquire(agentID) {
+		message = "agent exceeds scan node concurrent request limit"
+		allowed = false
+	}
+	if allowed {
+		return "", true
+	}
+	h.proxy.recordThrottled(h.agentConfig, t)
+	h.writeAuditLog(frame, http.StatusTooManyRequests, metrics.JSONRPCResultThrottled, 0)
+	return message, false
+}
+
+func (h *wsAgentHooks) Done(frame []byte, result string, respBytes int, latency time.Duration) {
+	h.writeAuditLog(frame, http.StatusOK, result, latency)
+	if h.agentConfig == nil {
+		return
+	}
+	h.proxy.concurrencyLimiter.Release(strings.ToLower(h.agentConfig.ID))
+	h.proxy.usage.Record(h.agentConfig.ID, h.agentConfig.Image, result, len(frame), respBytes, latency)
+	h.proxy.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+		Metrics: metrics.GetJSONRPCMetrics(*h.agentConfig, time.Now().Add(-latency), 1, 0, latency),
+	})
+}
+
+func (h *wsAgentHooks) Blocked(frame []byte, method string) {
+	h.writeAuditLog(frame, http.StatusForbidden, metrics.JSONRPCResultBlocked, 0)
+	if h.agentConfig == nil {
+		return
+	}
+	h.proxy.usage.Record(h.agentConfig.ID, h.agentConfig.Image, metrics.JSONRPCResultBlocked, 0, 0, 0)
+	h.proxy.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+		Metrics: []*protocol.AgentMetric{metrics.CreateAgentMetric(h.agentConfig.ID, metrics.MetricJSONRPCBlocked, 1)},
+	})
+}
+
+func (h *wsAgentHooks) writeAuditLog(frame []byte, status int, result string, latency time.Duration) {
+	if h.proxy.auditLog == nil {
+		return
+	}
+	entries := NewAuditEntries(h.agentConfig, h.remoteAddr, frame, status, result, latency)
+	if err := h.proxy.auditLog.Write(entries...); err != nil {
+		log.WithError(err).Warn("failed to write the audit log")
+	}
+}
+
 func (p *JsonRpcProxy) metricHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		t := time.Now()
 		agentConfig, foundAgent := p.findAgentFromRemoteAddr(req.RemoteAddr)
-		if foundAgent && p.rateLimiter.ExceedsLimit(agentConfig.ID) {
+		if p.auditLog != nil {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http.Error(w, "failed to read the request", http.StatusBadRequest)
+				return
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			auditWriter := &usageResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			w = auditWriter
+			defer func() {
+				p.writeAuditLog(agentConfig, req.RemoteAddr, body, auditWriter, time.Since(t))
+			}()
+		}
+		if !p.checkMethods(w, req, agentConfig) {
+			return
+		}
+		if !foundAgent {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		agentID := strings.ToLower(agentConfig.ID)
+		if p.rateLimiter.ExceedsLimit(agentID) {
 			writeTooManyReqsErr(w, req)
-			p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
-				Metrics: metrics.GetJSONRPCMetrics(*agentConfig, t, 0, 1, 0),
-			})
+			p.recordThrottled(agentConfig, t)
 			return
 		}
+		if !p.concurrencyLimiter.Acquire(agentID) {
+			writeTooManyConcurrentReqsErr(w, req)
+			p.recordThrottled(agentConfig, t)
+			return
+		}
+		defer p.concurrencyLimiter.Release(agentID)
 
-		h.ServeHTTP(w, req)
+		body := &countingReadCloser{ReadCloser: req.Body}
+		req.Body = body
+		usageWriter := &usageResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(usageWriter, req)
 
-		if foundAgent {
-			duration := time.Since(t)
-			p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
-				Metrics: metrics.GetJSONRPCMetrics(*agentConfig, t, 1, 0, duration),
-			})
-		}
+		duration := time.Since(t)
+		p.usage.Record(agentConfig.ID, agentConfig.Image, usageWriter.result(), body.bytes, usageWriter.bytes, duration)
+		p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+			Metrics: metrics.GetJSONRPCMetrics(*agentConfig, t, 1, 0, duration),
+		})
+	})
+}
+
+func (p *JsonRpcProxy) writeAuditLog(agentConfig *config.AgentConfig, remoteAddr string, body []byte, w *usageResponseWriter, latency time.Duration) {
+	result := w.result()
+	switch w.status {
+	case http.StatusTooManyRequests:
+		result = metrics.JSONRPCResultThrottled
+	case http.StatusForbidden:
+		result = metrics.JSONRPCResultBlocked
+	}
+	entries := NewAuditEntries(agentConfig, remoteAddr, body, w.status, result, latency)
+	if err := p.auditLog.Write(entries...); err != nil {
+		log.WithError(err).Warn("failed to write the audit log")
+	}
+}
+
+func (p *JsonRpcProxy) recordThrottled(agentConfig *config.AgentConfig, t time.Time) {
+	p.usage.Record(agentConfig.ID, agentConfig.Image, metrics.JSONRPCResultThrottled, 0, 0, 0)
+	p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+		Metrics: metrics.GetJSONRPCMetrics(*agentConfig, t, 0, 1, 0),
 	})
 }
 
+// checkMethods writes an error and returns false if the request contains a method which is not
+// allowed. The agent config is nil if the agent is unknown.
+func (p *JsonRpcProxy) checkMethods(w http.ResponseWriter, req *http.Request, agentConfig *config.AgentConfig) bool {
+	if p.methodFilter == nil {
+		return true
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return false
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	method, blocked := p.methodFilter.FindBlocked(body)
+	if !blocked {
+		return true
+	}
+
+	logger := log.WithField("method", method)
+	if agentConfig != nil {
+		logger = logger.WithField("agent", agentConfig.ID)
+		p.usage.Record(agentConfig.ID, agentConfig.Image, metrics.JSONRPCResultBlocked, 0, 0, 0)
+		p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+			Metrics: []*protocol.AgentMetric{metrics.CreateAgentMetric(agentConfig.ID, metrics.MetricJSONRPCBlocked, 1)},
+		})
+	}
+	logger.Warn("blocked jsonrpc method")
+	writeMethodNotAllowedErr(w, req, method)
+	return false
+}
+
 func (p *JsonRpcProxy) findAgentFromRemoteAddr(hostPort string) (*config.AgentConfig, bool) {
 	containers, err := p.dockerClient.GetContainers(p.ctx)
 	if err != nil {
@@ -146,8 +384,16 @@ func (p *JsonRpcProxy) handleAgentVersionsUpdate(payload messaging.AgentPayload)
 	return nil
 }
 
+// Usage returns the proxy usage report of the agents.
+func (p *JsonRpcProxy) Usage() *UsageReport {
+	return p.usage.Report()
+}
+
 func (p *JsonRpcProxy) Stop() error {
 	log.Infof("Stopping %s", p.Name())
+	if p.auditLog != nil {
+		defer p.auditLog.Close()
+	}
 	if p.server != nil {
 		return p.server.Close()
 	}
@@ -160,9 +406,16 @@ func (p *JsonRpcProxy) Name() string {
 
 // Health implements health.Reporter interface.
 func (p *JsonRpcProxy) Health() health.Reports {
-	return health.Reports{
+	reports := health.Reports{
 		p.lastErr.GetReport("api"),
 	}
+	if p.cache != nil {
+		reports = append(reports, p.cache.Health()...)
+	}
+	if p.failover != nil {
+		reports = append(reports, p.failover.Health()...)
+	}
+	return reports
 }
 
 func (p *JsonRpcProxy) apiHealthChecker() {
@@ -178,34 +431,153 @@ func (p *JsonRpcProxy) testAPI() {
 	p.lastErr.Set(err)
 }
 
+// CheckReady tests the API through the proxy so that it fails if the upstream is not reachable.
+func (p *JsonRpcProxy) CheckReady() error {
+	ctx, cancel := context.WithTimeout(p.ctx, readinessTimeout)
+	defer cancel()
+	return ethereum.TestAPI(ctx, "http://localhost:8545")
+}
+
 func (p *JsonRpcProxy) registerMessageHandlers() {
 	p.msgClient.Subscribe(messaging.SubjectAgentsVersionsLatest, messaging.AgentsHandler(p.handleAgentVersionsUpdate))
 }
 
-func NewJsonRpcProxy(ctx context.Context, cfg config.Config) (*JsonRpcProxy, error) {
-	jCfg := cfg.Scan.JsonRpc
+// getUpstreamConfig returns the proxy upstream config which defaults to the scan endpoint.
+func getUpstreamConfig(cfg config.Config) config.JsonRpcConfig {
 	if len(cfg.JsonRpcProxy.JsonRpc.Url) > 0 {
-		jCfg = cfg.JsonRpcProxy.JsonRpc
+		return cfg.JsonRpcProxy.JsonRpc
+	}
+	return cfg.Scan.JsonRpc
+}
+
+func getRateLimiting(cfg config.Config) *config.RateLimitConfig {
+	if cfg.JsonRpcProxy.RateLimitConfig != nil {
+		return cfg.JsonRpcProxy.RateLimitConfig
 	}
+	return cfg.ChainSettings.JsonRpcRateLimiting
+}
+
+func setAgentLimits(rateLimiter *RateLimiter, concurrencyLimiter *ConcurrencyLimiter, agentLimits map[string]config.AgentRateLimitConfig) {
+	for agentID, limits := range agentLimits {
+		agentID = strings.ToLower(agentID)
+		if limits.RateLimit != nil {
+			rateLimiter.SetClientRate(agentID, limits.RateLimit.Rate, limits.RateLimit.Burst)
+		}
+		if limits.MaxConcurrentRequests > 0 {
+			concurrencyLimiter.SetClientMax(agentID, limits.MaxConcurrentRequests)
+		}
+	}
+}
+
+// ConvertToDockerHostURLs converts the localhost urls in the config so that they can be dialed
+// from the container.
+func ConvertToDockerHostURLs(cfg *config.Config) {
+	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
+	cfg.JsonRpcProxy.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.JsonRpcProxy.JsonRpc.Url)
+	cfg.JsonRpcProxy.Failover.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.JsonRpcProxy.Failover.JsonRpc.Url)
+	cfg.JsonRpcProxy.WebSocket.Url = convertToDockerHostWebSocketURL(cfg.JsonRpcProxy.WebSocket.Url)
+	for i, chain := range cfg.JsonRpcProxy.Chains {
+		cfg.JsonRpcProxy.Chains[i].JsonRpc.Url = utils.ConvertToDockerHostURL(chain.JsonRpc.Url)
+	}
+}
+
+// convertToDockerHostWebSocketURL applies the same conversion as the http urls.
+func convertToDockerHostWebSocketURL(rawurl string) string {
+	if !strings.HasPrefix(rawurl, "ws://") {
+		return rawurl
+	}
+	converted := utils.ConvertToDockerHostURL("http://" + strings.TrimPrefix(rawurl, "ws://"))
+	return "ws://" + strings.TrimPrefix(converted, "http://")
+}
+
+func NewJsonRpcProxy(ctx context.Context, cfg config.Config) (*JsonRpcProxy, error) {
+	jCfg := getUpstreamConfig(cfg)
 	globalClient, err := clients.NewDockerClient("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the global docker client: %v", err)
 	}
-	msgClient := messaging.NewClient("json-rpc-proxy", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	msgClient := clients.NewMessageClient("json-rpc-proxy", cfg.Messaging)
+
+	rateLimiting := getRateLimiting(cfg)
+	rateLimiter := NewRateLimiter(rateLimiting.Rate, rateLimiting.Burst)
+	concurrencyLimiter := NewConcurrencyLimiter(cfg.JsonRpcProxy.MaxConcurrentRequests)
+	setAgentLimits(rateLimiter, concurrencyLimiter, cfg.JsonRpcProxy.AgentLimits)
+
+	methodFilter := NewMethodFilter(cfg.JsonRpcProxy.Methods)
+
+	var wsProxy *WebSocketProxy
+	if wsCfg := cfg.JsonRpcProxy.WebSocket; wsCfg.Enable {
+		wsURL := wsCfg.Url
+		if len(wsURL) == 0 {
+			if wsURL, err = WebSocketURL(jCfg.Url); err != nil {
+				return nil, fmt.Errorf("failed to make the websocket url: %v", err)
+			}
+		}
+		wsProxy = NewWebSocketProxy(wsURL, jCfg.Headers, wsCfg.MaxSubscriptions, methodFilter)
+	}
+
+	var auditLog *AuditLog
+	if auditCfg := cfg.JsonRpcProxy.AuditLog; auditCfg.Enable {
+		auditLog, err = NewAuditLog(
+			path.Join(config.DefaultContainerFortaDirPath, config.DefaultAuditLogDirName),
+			int64(auditCfg.MaxSizeMB)*1024*1024, auditCfg.MaxFiles,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	rateLimiting := cfg.JsonRpcProxy.RateLimitConfig
-	if rateLimiting == nil {
-		rateLimiting = config.GetChainSettings(cfg.ChainID).JsonRpcRateLimiting
+	var cache *ResponseCache
+	if cfg.JsonRpcProxy.Cache.Enable {
+		cache = NewResponseCache(ctx, cfg.JsonRpcProxy.Cache)
+	}
+
+	limits := NewResponseLimits(cfg.JsonRpcProxy)
+
+	var failover *FailoverUpstream
+	if failoverCfg := cfg.JsonRpcProxy.Failover; len(failoverCfg.JsonRpc.Url) > 0 {
+		failover, err = NewFailoverUpstream(ctx, jCfg, failoverCfg, limits)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &JsonRpcProxy{
-		ctx:          ctx,
-		cfg:          jCfg,
-		dockerClient: globalClient,
-		msgClient:    msgClient,
-		rateLimiter: NewRateLimiter(
-			rateLimiting.Rate,
-			rateLimiting.Burst,
-		),
+		ctx:                ctx,
+		cfg:                jCfg,
+		chainID:            cfg.ChainID,
+		chains:             cfg.JsonRpcProxy.Chains,
+		dockerClient:       globalClient,
+		msgClient:          msgClient,
+		rateLimiter:        rateLimiter,
+		concurrencyLimiter: concurrencyLimiter,
+		methodFilter:       methodFilter,
+		usage:              NewUsageTracker(),
+		wsProxy:            wsProxy,
+		auditLog:           auditLog,
+		cache:              cache,
+		failover:           failover,
+		limits:             limits,
+		router:             &swappableHandler{},
 	}, nil
 }
+
+// swappableHandler lets the handler be replaced while serving.
+type swappableHandler struct {
+	handler http.Handler
+	mu      sync.RWMutex
+}
+
+// Set replaces the handler.
+func (sh *swappableHandler) Set(handler http.Handler) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.handler = handler
+}
+
+func (sh *swappableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	sh.mu.RLock()
+	handler := sh.handler
+	sh.mu.RUnlock()
+	handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,38 @@
+package json_rpc
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfig(t *testing.T) {
+	r := require.New(t)
+
+	p := &JsonRpcProxy{
+		cfg:                config.JsonRpcConfig{Url: "http://old-upstream:8545"},
+		rateLimiter:        NewRateLimiter(1, 1),
+		concurrencyLimiter: NewConcurrencyLimiter(0),
+		router:             &swappableHandler{},
+	}
+
+	var cfg config.Config
+	cfg.JsonRpcProxy.RateLimitConfig = &config.RateLimitConfig{Rate: 10, Burst: 2}
+	cfg.JsonRpcProxy.JsonRpc.Url = "http://localhost:8545"
+	r.NoError(p.ReloadConfig(cfg))
+
+	// uses the new rate limit
+	r.False(p.rateLimiter.ExceedsLimit("0x1"))
+	r.False(p.rateLimiter.ExceedsLimit("0x1"))
+
+	// routes to the new upstream
+	r.Equal("http://host.docker.internal:8545", p.cfg.Url)
+	r.NotNil(p.router.handler)
+
+	// upstream changes are not applied with the cache
+	p.cache = &ResponseCache{}
+	cfg.JsonRpcProxy.JsonRpc.Url = "http://other-upstream:8545"
+	r.Error(p.ReloadConfig(cfg))
+	r.Equal("http://host.docker.internal:8545", p.cfg.Url)
+}
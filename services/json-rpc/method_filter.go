@@ -0,0 +1,70 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// MethodFilter decides which JSON-RPC methods the agents can call. The patterns can match
+// exact method names or namespaces with a trailing wildcard like "debug_*".
+type MethodFilter struct {
+	allow []string
+	deny  []string
+}
+
+// NewMethodFilter creates a new method filter or returns nil if all methods are allowed.
+func NewMethodFilter(cfg config.JsonRpcMethodsConfig) *MethodFilter {
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return nil
+	}
+	return &MethodFilter{allow: cfg.Allow, deny: cfg.Deny}
+}
+
+func matchesMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(method, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+			continue
+		}
+		if pattern == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed checks the method against the allowlist and the denylist.
+func (filter *MethodFilter) Allowed(method string) bool {
+	if len(filter.allow) > 0 && !matchesMethod(filter.allow, method) {
+		return false
+	}
+	return !matchesMethod(filter.deny, method)
+}
+
+// FindBlocked returns the first method in the request body which is not allowed.
+// Both the single and the batch requests are supported.
+func (filter *MethodFilter) FindBlocked(body []byte) (string, bool) {
+	var requests []*jsonRpcRequest
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &requests); err != nil {
+			return "", false
+		}
+	} else {
+		var req jsonRpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", false
+		}
+		requests = append(requests, &req)
+	}
+	for _, req := range requests {
+		if req != nil && !filter.Allowed(req.Method) {
+			return req.Method, true
+		}
+	}
+	return "", false
+}
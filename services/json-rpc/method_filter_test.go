@@ -0,0 +1,38 @@
+package json_rpc_test
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	json_rpc "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodFilter(t *testing.T) {
+	r := require.New(t)
+
+	r.Nil(json_rpc.NewMethodFilter(config.JsonRpcMethodsConfig{}))
+
+	filter := json_rpc.NewMethodFilter(config.JsonRpcMethodsConfig{
+		Deny: []string{"eth_sendRawTransaction", "debug_*", "admin_*"},
+	})
+	r.True(filter.Allowed("eth_call"))
+	r.True(filter.Allowed("eth_sendTransaction"))
+	r.False(filter.Allowed("eth_sendRawTransaction"))
+	r.False(filter.Allowed("debug_traceTransaction"))
+
+	filter = json_rpc.NewMethodFilter(config.JsonRpcMethodsConfig{
+		Allow: []string{"eth_*", "net_version"},
+		Deny:  []string{"eth_sendRawTransaction"},
+	})
+	r.True(filter.Allowed("eth_call"))
+	r.True(filter.Allowed("net_version"))
+	r.False(filter.Allowed("net_peerCount"))
+	r.False(filter.Allowed("eth_sendRawTransaction"))
+
+	method, blocked := filter.FindBlocked([]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[]}`))
+	r.False(blocked)
+	method, blocked = filter.FindBlocked([]byte(`[{"method":"eth_call"},{"method":"trace_block"}]`))
+	r.True(blocked)
+	r.Equal("trace_block", method)
+}
@@ -12,10 +12,16 @@ import (
 type RateLimiter struct {
 	rate           float64
 	burst          int
+	clientRates    map[string]clientRate
 	clientLimiters map[string]*clientLimiter
 	mu             sync.Mutex
 }
 
+type clientRate struct {
+	rate  float64
+	burst int
+}
+
 type clientLimiter struct {
 	lastReservation time.Time
 	*rate.Limiter
@@ -29,12 +35,37 @@ func NewRateLimiter(rateN float64, burst int) *RateLimiter {
 	rl := &RateLimiter{
 		rate:           rateN,
 		burst:          burst,
+		clientRates:    make(map[string]clientRate),
 		clientLimiters: make(map[string]*clientLimiter),
 	}
 	go rl.autoCleanup()
 	return rl
 }
 
+// Reset sets the new default rate and burst and removes the client overrides.
+func (rl *RateLimiter) Reset(rateN float64, burst int) {
+	if rateN <= 0 {
+		log.Panic("non-positive rate limiter arg")
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = rateN
+	rl.burst = burst
+	rl.clientRates = make(map[string]clientRate)
+	rl.clientLimiters = make(map[string]*clientLimiter)
+}
+
+// SetClientRate overrides the default rate and burst for the client.
+func (rl *RateLimiter) SetClientRate(clientID string, rateN float64, burst int) {
+	if rateN <= 0 {
+		log.Panic("non-positive rate limiter arg")
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.clientRates[clientID] = clientRate{rate: rateN, burst: burst}
+	delete(rl.clientLimiters, clientID)
+}
+
 // ExceedsLimit tries adding a request to the limiting channel and returns boolean to signal
 // if we hit the rate limit.
 func (rl *RateLimiter) ExceedsLimit(clientID string) bool {
@@ -42,7 +73,11 @@ func (rl *RateLimiter) ExceedsLimit(clientID string) bool {
 	defer rl.mu.Unlock()
 	limiter := rl.clientLimiters[clientID]
 	if limiter == nil {
-		limiter = &clientLimiter{Limiter: rate.NewLimiter(rate.Limit(rl.rate), rl.burst)}
+		rateN, burst := rl.rate, rl.burst
+		if clientRate, ok := rl.clientRates[clientID]; ok {
+			rateN, burst = clientRate.rate, clientRate.burst
+		}
+		limiter = &clientLimiter{Limiter: rate.NewLimiter(rate.Limit(rateN), burst)}
 		rl.clientLimiters[clientID] = limiter
 	}
 	limiter.lastReservation = time.Now()
@@ -22,3 +22,30 @@ func TestRateLimiting(t *testing.T) {
 	reachedLimit = rateLimiter.ExceedsLimit(testClientID)
 	r.False(reachedLimit)
 }
+
+func TestRateLimiting_ClientRate(t *testing.T) {
+	r := require.New(t)
+	rateLimiter := json_rpc.NewRateLimiter(0.5, 1)
+	rateLimiter.SetClientRate(testClientID, 0.5, 3)
+	for i := 0; i < 3; i++ {
+		r.False(rateLimiter.ExceedsLimit(testClientID))
+	}
+	r.True(rateLimiter.ExceedsLimit(testClientID))
+
+	// other clients use the default limits
+	r.False(rateLimiter.ExceedsLimit("2"))
+	r.True(rateLimiter.ExceedsLimit("2"))
+}
+
+func TestRateLimiting_Reset(t *testing.T) {
+	r := require.New(t)
+	rateLimiter := json_rpc.NewRateLimiter(0.5, 1)
+	rateLimiter.SetClientRate(testClientID, 0.5, 3)
+	r.False(rateLimiter.ExceedsLimit(testClientID))
+
+	// the client override is removed and the new default is used
+	rateLimiter.Reset(0.5, 2)
+	r.False(rateLimiter.ExceedsLimit(testClientID))
+	r.False(rateLimiter.ExceedsLimit(testClientID))
+	r.True(rateLimiter.ExceedsLimit(testClientID))
+}
@@ -0,0 +1,124 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var errResponseTooLarge = errors.New("response is too large")
+
+// ResponseLimits protect the node from the enormous responses and the slow upstreams.
+// The zero values mean no limit.
+type ResponseLimits struct {
+	MaxSize int64 // in bytes
+	Timeout time.Duration
+}
+
+// NewResponseLimits creates the response limits from the proxy config.
+func NewResponseLimits(cfg config.JsonRpcProxyConfig) ResponseLimits {
+	return ResponseLimits{
+		MaxSize: int64(cfg.MaxResponseSizeMB) * 1024 * 1024,
+		Timeout: time.Duration(cfg.UpstreamTimeoutSeconds) * time.Second,
+	}
+}
+
+// limitedTransport reads the upstream responses within the limits and turns the violations
+// into JSON-RPC error responses, so the reverse proxy never streams an oversized body.
+type limitedTransport struct {
+	limits    ResponseLimits
+	transport http.RoundTripper
+}
+
+func (lt *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	parentCtx := req.Context()
+	ctx, cancel := parentCtx, context.CancelFunc(func() {})
+	if lt.limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parentCtx, lt.limits.Timeout)
+	}
+	defer cancel()
+
+	resp, err := lt.transport.RoundTrip(req.WithContext(ctx))
+	if err == nil {
+		resp.Body, err = lt.readBody(resp)
+	}
+	switch {
+	case err == errResponseTooLarge:
+		log.WithField("maxSize", lt.limits.MaxSize).Warn("json-rpc upstream response is too large")
+		return newErrorResponse(req, reqBody, http.StatusOK, -32000,
+			fmt.Sprintf("response exceeds the scan node limit of %d bytes", lt.limits.MaxSize)), nil
+
+	case err != nil && ctx.Err() == context.DeadlineExceeded && parentCtx.Err() == nil:
+		log.WithField("timeout", lt.limits.Timeout).Warn("json-rpc upstream timed out")
+		return newErrorResponse(req, reqBody, http.StatusGatewayTimeout, -32000,
+			fmt.Sprintf("upstream did not respond within %s", lt.limits.Timeout)), nil
+
+	case err != nil:
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (lt *limitedTransport) readBody(resp *http.Response) (io.ReadCloser, error) {
+	defer resp.Body.Close()
+	if lt.limits.MaxSize > 0 && resp.ContentLength > lt.limits.MaxSize {
+		return nil, errResponseTooLarge
+	}
+	var r io.Reader = resp.Body
+	if lt.limits.MaxSize > 0 {
+		r = io.LimitReader(resp.Body, lt.limits.MaxSize+1)
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if lt.limits.MaxSize > 0 && int64(len(body)) > lt.limits.MaxSize {
+		return nil, errResponseTooLarge
+	}
+	return ioutil.NopCloser(bytes.NewReader(body)), nil
+}
+
+// newErrorResponse makes an upstream response which contains a JSON-RPC error.
+func newErrorResponse(req *http.Request, reqBody []byte, statusCode, code int, message string) *http.Response {
+	var reqPayload requestPayload
+	_ = json.Unmarshal(reqBody, &reqPayload)
+	body, _ := json.Marshal(&errorResponse{
+		JSONRPC: "2.0",
+		ID:      reqPayload.ID,
+		Error: jsonRpcError{
+			Code:    code,
+			Message: message,
+		},
+	})
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
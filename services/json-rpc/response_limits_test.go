@@ -0,0 +1,60 @@
+package json_rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseLimits(t *testing.T) {
+	r := require.New(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("case") {
+		case "large":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":7,"result":"` + strings.Repeat("a", 2048) + `"}`))
+		case "slow":
+			time.Sleep(time.Millisecond * 500)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":7,"result":"0x1"}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":7,"result":"0x1"}`))
+		}
+	}))
+	defer upstream.Close()
+
+	send := func(testCase string) *httptest.ResponseRecorder {
+		rp, err := newUpstreamProxy(config.JsonRpcConfig{Url: upstream.URL + "?case=" + testCase}, ResponseLimits{
+			MaxSize: 1024,
+			Timeout: time.Millisecond * 100,
+		})
+		r.NoError(err)
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"eth_getLogs"}`))
+		recorder := httptest.NewRecorder()
+		rp.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := send("ok")
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Contains(recorder.Body.String(), `"result":"0x1"`)
+
+	var errResp errorResponse
+	recorder = send("large")
+	r.Equal(http.StatusOK, recorder.Code)
+	r.NoError(json.Unmarshal(recorder.Body.Bytes(), &errResp))
+	r.Equal(7, errResp.ID)
+	r.Equal(-32000, errResp.Error.Code)
+	r.Contains(errResp.Error.Message, "1024 bytes")
+
+	recorder = send("slow")
+	r.Equal(http.StatusGatewayTimeout, recorder.Code)
+	r.NoError(json.Unmarshal(recorder.Body.Bytes(), &errResp))
+	r.Equal(7, errResp.ID)
+	r.Contains(errResp.Error.Message, "did not respond")
+}
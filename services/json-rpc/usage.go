@@ -0,0 +1,163 @@
+package json_rpc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/metrics"
+)
+
+// errorScanLimit is how much of the response is checked for a JSON-RPC error object.
+const errorScanLimit = 256
+
+// AgentUsage contains the proxy usage stats of an agent.
+type AgentUsage struct {
+	AgentID       string  `json:"agentId"`
+	Image         string  `json:"image"`
+	Calls         uint64  `json:"calls"`
+	Errors        uint64  `json:"errors"`
+	Throttled     uint64  `json:"throttled"`
+	Blocked       uint64  `json:"blocked"`
+	RequestBytes  uint64  `json:"requestBytes"`
+	ResponseBytes uint64  `json:"responseBytes"`
+	AvgLatencyMs  float64 `json:"avgLatencyMs"`
+	MaxLatencyMs  int64   `json:"maxLatencyMs"`
+	ErrorRate     float64 `json:"errorRate"`
+
+	totalLatency time.Duration
+	proxied      uint64
+}
+
+// UsageReport contains the usage of all agents since the proxy started.
+type UsageReport struct {
+	Since  time.Time     `json:"since"`
+	Agents []*AgentUsage `json:"agents"`
+}
+
+// UsageTracker tracks the proxy usage per agent.
+type UsageTracker struct {
+	since  time.Time
+	agents map[string]*AgentUsage
+	mu     sync.Mutex
+}
+
+// NewUsageTracker creates a new usage tracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		since:  time.Now().UTC(),
+		agents: make(map[string]*AgentUsage),
+	}
+}
+
+// Record records a request of the agent and updates the metrics.
+func (tracker *UsageTracker) Record(agentID, image, result string, requestBytes, responseBytes int, latency time.Duration) {
+	metrics.JSONRPCRequests.WithLabelValues(agentID, result).Inc()
+	metrics.JSONRPCBytes.WithLabelValues(agentID, "request").Add(float64(requestBytes))
+	metrics.JSONRPCBytes.WithLabelValues(agentID, "response").Add(float64(responseBytes))
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	usage, ok := tracker.agents[agentID]
+	if !ok {
+		usage = &AgentUsage{AgentID: agentID}
+		tracker.agents[agentID] = usage
+	}
+	usage.Image = image
+	usage.Calls++
+	usage.RequestBytes += uint64(requestBytes)
+	usage.ResponseBytes += uint64(responseBytes)
+	switch result {
+	case metrics.JSONRPCResultThrottled:
+		usage.Throttled++
+		return
+	case metrics.JSONRPCResultBlocked:
+		usage.Blocked++
+		return
+	case metrics.JSONRPCResultError:
+		usage.Errors++
+	}
+	// only the proxied requests have latency
+	metrics.JSONRPCLatency.WithLabelValues(agentID).Observe(latency.Seconds())
+	usage.proxied++
+	usage.totalLatency += latency
+	if ms := latency.Milliseconds(); ms > usage.MaxLatencyMs {
+		usage.MaxLatencyMs = ms
+	}
+}
+
+// Report returns the usage of the agents ordered by the number of calls.
+func (tracker *UsageTracker) Report() *UsageReport {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	report := &UsageReport{Since: tracker.since}
+	for _, usage := range tracker.agents {
+		usageCopy := *usage
+		if usage.proxied > 0 {
+			usageCopy.AvgLatencyMs = float64(usage.totalLatency.Milliseconds()) / float64(usage.proxied)
+			usageCopy.ErrorRate = float64(usage.Errors) / float64(usage.proxied)
+		}
+		report.Agents = append(report.Agents, &usageCopy)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		if report.Agents[i].Calls == report.Agents[j].Calls {
+			return report.Agents[i].AgentID < report.Agents[j].AgentID
+		}
+		return report.Agents[i].Calls > report.Agents[j].Calls
+	})
+	return report
+}
+
+// usageResponseWriter counts the response bytes and detects the errors.
+type usageResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	head   []byte
+}
+
+func (w *usageResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *usageResponseWriter) Write(b []byte) (int, error) {
+	if remaining := errorScanLimit - len(w.head); remaining > 0 {
+		if remaining > len(b) {
+			remaining = len(b)
+		}
+		w.head = append(w.head, b[:remaining]...)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher so that the streaming responses keep working.
+func (w *usageResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *usageResponseWriter) result() string {
+	if w.status >= http.StatusBadRequest || bytes.Contains(w.head, []byte(`"error":`)) {
+		return metrics.JSONRPCResultError
+	}
+	return metrics.JSONRPCResultSuccess
+}
+
+// countingReadCloser counts the request bytes read by the proxy.
+type countingReadCloser struct {
+	io.ReadCloser
+	bytes int
+}
+
+func (rc *countingReadCloser) Read(b []byte) (int, error) {
+	n, err := rc.ReadCloser.Read(b)
+	rc.bytes += n
+	return n, err
+}
@@ -0,0 +1,52 @@
+package json_rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTracker(t *testing.T) {
+	r := require.New(t)
+
+	tracker := NewUsageTracker()
+	tracker.Record("0x1", "image1", metrics.JSONRPCResultSuccess, 100, 1000, time.Millisecond*10)
+	tracker.Record("0x1", "image1", metrics.JSONRPCResultError, 100, 50, time.Millisecond*30)
+	tracker.Record("0x1", "image1", metrics.JSONRPCResultThrottled, 0, 0, 0)
+	tracker.Record("0x2", "image2", metrics.JSONRPCResultBlocked, 0, 0, 0)
+
+	report := tracker.Report()
+	r.Len(report.Agents, 2)
+	usage := report.Agents[0]
+	r.Equal("0x1", usage.AgentID)
+	r.Equal(uint64(3), usage.Calls)
+	r.Equal(uint64(1), usage.Errors)
+	r.Equal(uint64(1), usage.Throttled)
+	r.Equal(uint64(200), usage.RequestBytes)
+	r.Equal(uint64(1050), usage.ResponseBytes)
+	r.Equal(float64(20), usage.AvgLatencyMs)
+	r.Equal(int64(30), usage.MaxLatencyMs)
+	r.Equal(0.5, usage.ErrorRate)
+	r.Equal(uint64(1), report.Agents[1].Blocked)
+}
+
+func TestUsageResponseWriter(t *testing.T) {
+	r := require.New(t)
+
+	w := &usageResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	r.Equal(metrics.JSONRPCResultSuccess, w.result())
+	r.Equal(39, w.bytes)
+
+	w = &usageResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"failed"}}`))
+	r.Equal(metrics.JSONRPCResultError, w.result())
+
+	w = &usageResponseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	w.WriteHeader(http.StatusBadGateway)
+	r.Equal(metrics.JSONRPCResultError, w.result())
+}
@@ -0,0 +1,328 @@
+package json_rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// WebSocketHooks applies the per-agent limits and the bookkeeping of the HTTP requests to the
+// request frames of a proxied connection.
+type WebSocketHooks interface {
+	// Allow returns false and the error message if the request frame exceeds the agent limits.
+	Allow(frame []byte) (string, bool)
+	// Done is called when the response of an allowed request frame arrives or the connection closes.
+	Done(frame []byte, result string, respBytes int, latency time.Duration)
+	// Blocked is called when the request frame contains a method which is not allowed.
+	Blocked(frame []byte, method string)
+}
+
+// WebSocketProxy proxies the WebSocket connections of the agents to the upstream and
+// limits the number of active subscriptions per agent.
+type WebSocketProxy struct {
+	upstreamURL      string
+	headers          http.Header
+	maxSubscriptions int
+	methodFilter     *MethodFilter
+	upgrader         websocket.Upgrader
+	dialer           *websocket.Dialer
+
+	subscriptions map[string]int
+	mu            sync.Mutex
+}
+
+// NewWebSocketProxy creates a new WebSocket proxy. Zero max subscriptions means unlimited.
+func NewWebSocketProxy(upstreamURL string, headers map[string]string, maxSubscriptions int, methodFilter *MethodFilter) *WebSocketProxy {
+	header := make(http.Header)
+	for k, v := range headers {
+		header.Set(k, v)
+	}
+	return &WebSocketProxy{
+		upstreamURL:      upstreamURL,
+		headers:          header,
+		maxSubscriptions: maxSubscriptions,
+		methodFilter:     methodFilter,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		dialer:        websocket.DefaultDialer,
+		subscriptions: make(map[string]int),
+	}
+}
+
+// WebSocketURL converts the http(s) url to a ws(s) url.
+func WebSocketURL(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+	return u.String(), nil
+}
+
+// reserveSubscription returns false if the client has reached the subscription limit.
+func (wp *WebSocketProxy) reserveSubscription(key string) bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.maxSubscriptions > 0 && wp.subscriptions[key] >= wp.maxSubscriptions {
+		return false
+	}
+	wp.subscriptions[key]++
+	return true
+}
+
+func (wp *WebSocketProxy) releaseSubscriptions(key string, n int) {
+	if n <= 0 {
+		return
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.subscriptions[key] -= n
+	if wp.subscriptions[key] <= 0 {
+		delete(wp.subscriptions, key)
+	}
+}
+
+// Subscriptions returns the number of active subscriptions of the client.
+func (wp *WebSocketProxy) Subscriptions(key string) int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.subscriptions[key]
+}
+
+// wsRequest is a request frame which waits for the upstream response.
+type wsRequest struct {
+	frame       []byte
+	start       time.Time
+	subscribe   bool
+	unsubscribe bool
+}
+
+// wsConn tracks the requests and the subscriptions of a proxied connection.
+type wsConn struct {
+	proxy    *WebSocketProxy
+	key      string
+	hooks    WebSocketHooks
+	client   *websocket.Conn
+	upstream *websocket.Conn
+
+	inflight map[string]*wsRequest
+	active   int
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+}
+
+// Serve upgrades the connection and proxies the messages in both directions. The subscriptions
+// are limited by the key which identifies the client. The hooks can be nil.
+func (wp *WebSocketProxy) Serve(w http.ResponseWriter, req *http.Request, key string, hooks WebSocketHooks) {
+	upstream, resp, err := wp.dialer.DialContext(req.Context(), wp.upstreamURL, wp.headers)
+	if err != nil {
+		log.WithError(err).Warn("failed to connect to the upstream websocket")
+		status := http.StatusBadGateway
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		http.Error(w, "failed to connect to the upstream", status)
+		return
+	}
+	defer upstream.Close()
+
+	client, err := wp.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		log.WithError(err).Warn("failed to upgrade the agent websocket")
+		return
+	}
+	defer client.Close()
+
+	conn := &wsConn{
+		proxy:    wp,
+		key:      key,
+		hooks:    hooks,
+		client:   client,
+		upstream: upstream,
+		inflight: make(map[string]*wsRequest),
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		conn.pumpFromClient()
+		done <- struct{}{}
+	}()
+	go func() {
+		conn.pumpFromUpstream()
+		done <- struct{}{}
+	}()
+	// each pump closes the other side when it returns
+	<-done
+	<-done
+
+	pendingSubscribes := 0
+	for _, r := range conn.inflight {
+		if r.subscribe {
+			pendingSubscribes++
+		}
+		conn.done(r, metrics.JSONRPCResultError, 0)
+	}
+	wp.releaseSubscriptions(key, conn.active+pendingSubscribes)
+}
+
+func (conn *wsConn) writeToClient(messageType int, b []byte) error {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	return conn.client.WriteMessage(messageType, b)
+}
+
+func (conn *wsConn) writeError(id json.RawMessage, code int, message string) error {
+	b, _ := json.Marshal(&jsonRpcResponse{JSONRPC: "2.0", ID: id, Error: &jsonRpcError{Code: code, Message: message}})
+	return conn.writeToClient(websocket.TextMessage, b)
+}
+
+func (conn *wsConn) blocked(frame []byte, method string) {
+	log.WithFields(log.Fields{"method": method, "client": conn.key}).Warn("blocked jsonrpc method")
+	if conn.hooks != nil {
+		conn.hooks.Blocked(frame, method)
+	}
+}
+
+func (conn *wsConn) done(r *wsRequest, result string, respBytes int) {
+	if conn.hooks != nil {
+		conn.hooks.Done(r.frame, result, respBytes, time.Since(r.start))
+	}
+}
+
+// hasID tells if the request expects a response which can be matched to it.
+func hasID(id json.RawMessage) bool {
+	return len(id) > 0 && string(id) != "null"
+}
+
+func (conn *wsConn) pumpFromClient() {
+	defer conn.upstream.Close()
+	for {
+		messageType, b, err := conn.client.ReadMessage()
+		if err != nil {
+			return
+		}
+		r := &wsRequest{frame: b, start: time.Now()}
+		var req jsonRpcRequest
+		parsed := messageType == websocket.TextMessage && json.Unmarshal(b, &req) == nil
+		if parsed {
+			if conn.proxy.methodFilter != nil && !conn.proxy.methodFilter.Allowed(req.Method) {
+				conn.blocked(b, req.Method)
+				if err := conn.writeError(req.ID, -32601, fmt.Sprintf("method %s is not allowed by the scan node", req.Method)); err != nil {
+					return
+				}
+				continue
+			}
+			r.subscribe = req.Method == "eth_subscribe"
+			r.unsubscribe = req.Method == "eth_unsubscribe"
+		} else if conn.proxy.methodFilter != nil {
+			if method, blocked := conn.proxy.methodFilter.FindBlocked(b); blocked {
+				conn.blocked(b, method)
+				if err := conn.writeError(nil, -32601, fmt.Sprintf("method %s is not allowed by the scan node", method)); err != nil {
+					return
+				}
+				continue
+			}
+		}
+
+		// the responses are matched to the requests by the ids so they must be unique
+		tracked := parsed && hasID(req.ID)
+		if tracked {
+			conn.mu.Lock()
+			_, duplicate := conn.inflight[string(req.ID)]
+			conn.mu.Unlock()
+			if duplicate {
+				if err := conn.writeError(req.ID, -32600, "request id is already in use"); err != nil {
+					return
+				}
+				continue
+			}
+		}
+		if conn.hooks != nil {
+			if message, ok := conn.hooks.Allow(b); !ok {
+				if err := conn.writeError(req.ID, -32000, message); err != nil {
+					return
+				}
+				continue
+			}
+		}
+		if r.subscribe && !conn.proxy.reserveSubscription(conn.key) {
+			conn.done(r, metrics.JSONRPCResultError, 0)
+			if err := conn.writeError(req.ID, -32000, "agent exceeds scan node subscription limit"); err != nil {
+				return
+			}
+			continue
+		}
+		if tracked {
+			conn.mu.Lock()
+			conn.inflight[string(req.ID)] = r
+			conn.mu.Unlock()
+		} else {
+			// no response to wait for
+			conn.done(r, metrics.JSONRPCResultSuccess, 0)
+		}
+		if err := conn.upstream.WriteMessage(messageType, b); err != nil {
+			return
+		}
+	}
+}
+
+func (conn *wsConn) pumpFromUpstream() {
+	defer conn.client.Close()
+	for {
+		messageType, b, err := conn.upstream.ReadMessage()
+		if err != nil {
+			return
+		}
+		var resp jsonRpcResponse
+		if messageType == websocket.TextMessage && json.Unmarshal(b, &resp) == nil && hasID(resp.ID) {
+			conn.handleResponse(&resp, len(b))
+		}
+		if err := conn.writeToClient(messageType, b); err != nil {
+			return
+		}
+	}
+}
+
+// handleResponse completes the request and updates the subscription count by using the
+// subscribe and unsubscribe results.
+func (conn *wsConn) handleResponse(resp *jsonRpcResponse, respBytes int) {
+	conn.mu.Lock()
+	r, ok := conn.inflight[string(resp.ID)]
+	if !ok {
+		conn.mu.Unlock()
+		return
+	}
+	delete(conn.inflight, string(resp.ID))
+	switch {
+	case r.subscribe && resp.Error != nil:
+		conn.proxy.releaseSubscriptions(conn.key, 1)
+	case r.subscribe:
+		conn.active++
+	case r.unsubscribe && resp.Error == nil && strings.TrimSpace(string(resp.Result)) == "true" && conn.active > 0:
+		conn.active--
+		conn.proxy.releaseSubscriptions(conn.key, 1)
+	}
+	conn.mu.Unlock()
+
+	result := metrics.JSONRPCResultSuccess
+	if resp.Error != nil {
+		result = metrics.JSONRPCResultError
+	}
+	conn.done(r, result, respBytes)
+}
@@ -0,0 +1,206 @@
+package json_rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebSocketUpstream(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for {
+			var rpcReq jsonRpcRequest
+			if err := conn.ReadJSON(&rpcReq); err != nil {
+				return
+			}
+			result := `"0xsub"`
+			if rpcReq.Method == "eth_unsubscribe" {
+				result = "true"
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, rpcReq.ID, result)))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func wsCall(t *testing.T, conn *websocket.Conn, id int, method string) *jsonRpcResponse {
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":[]}`, id, method))))
+	_, b, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var resp jsonRpcResponse
+	require.NoError(t, json.Unmarshal(b, &resp))
+	return &resp
+}
+
+func TestWebSocketProxy(t *testing.T) {
+	r := require.New(t)
+
+	upstream := newTestWebSocketUpstream(t)
+	upstreamURL, err := WebSocketURL(upstream.URL)
+	r.NoError(err)
+	r.True(strings.HasPrefix(upstreamURL, "ws://"))
+
+	methodFilter := NewMethodFilter(config.JsonRpcMethodsConfig{Deny: []string{"eth_sendRawTransaction"}})
+	wsProxy := NewWebSocketProxy(upstreamURL, nil, 2, methodFilter)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		wsProxy.Serve(w, req, "0xagent", nil)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := WebSocketURL(proxy.URL)
+	conn, _, err := websocket.DefaultDialer.Dial(proxyURL, nil)
+	r.NoError(err)
+
+	r.Nil(wsCall(t, conn, 1, "eth_subscribe").Error)
+	r.Nil(wsCall(t, conn, 2, "eth_subscribe").Error)
+	r.Equal(2, wsProxy.Subscriptions("0xagent"))
+
+	// over the limit
+	resp := wsCall(t, conn, 3, "eth_subscribe")
+	r.NotNil(resp.Error)
+	r.Equal("3", string(resp.ID))
+
+	r.Nil(wsCall(t, conn, 4, "eth_unsubscribe").Error)
+	r.Equal(1, wsProxy.Subscriptions("0xagent"))
+	r.Nil(wsCall(t, conn, 5, "eth_subscribe").Error)
+
+	resp = wsCall(t, conn, 6, "eth_sendRawTransaction")
+	r.NotNil(resp.Error)
+	r.Equal(-32601, resp.Error.Code)
+
+	// the subscriptions are released when the connection is closed
+	conn.Close()
+	r.Eventually(func() bool {
+		return wsProxy.Subscriptions("0xagent") == 0
+	}, time.Second*5, time.Millisecond*10)
+}
+
+type testWebSocketHooks struct {
+	allow   int
+	done    []string
+	blocked []string
+	mu      sync.Mutex
+}
+
+func (h *testWebSocketHooks) Allow(frame []byte) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.allow == 0 {
+		return "agent exceeds scan node request limit", false
+	}
+	h.allow--
+	return "", true
+}
+
+func (h *testWebSocketHooks) Done(frame []byte, result string, respBytes int, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.done = append(h.done, result)
+}
+
+func (h *testWebSocketHooks) Blocked(frame []byte, method string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blocked = append(h.blocked, method)
+}
+
+func (h *testWebSocketHooks) doneCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.done)
+}
+
+func TestWebSocketProxy_Hooks(t *testing.T) {
+	r := require.New(t)
+
+	upstream := newTestWebSocketUpstream(t)
+	upstreamURL, _ := WebSocketURL(upstream.URL)
+
+	methodFilter := NewMethodFilter(config.JsonRpcMethodsConfig{Deny: []string{"eth_sendRawTransaction"}})
+	wsProxy := NewWebSocketProxy(upstreamURL, nil, 0, methodFilter)
+	hooks := &testWebSocketHooks{allow: 2}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		wsProxy.Serve(w, req, "0xagent", hooks)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := WebSocketURL(proxy.URL)
+	conn, _, err := websocket.DefaultDialer.Dial(proxyURL, nil)
+	r.NoError(err)
+	defer conn.Close()
+
+	r.Nil(wsCall(t, conn, 1, "eth_blockNumber").Error)
+	r.Nil(wsCall(t, conn, 2, "eth_blockNumber").Error)
+	r.Equal(2, hooks.doneCount())
+
+	// exceeds the limits
+	resp := wsCall(t, conn, 3, "eth_blockNumber")
+	r.NotNil(resp.Error)
+	r.Equal(-32000, resp.Error.Code)
+
+	resp = wsCall(t, conn, 4, "eth_sendRawTransaction")
+	r.NotNil(resp.Error)
+	r.Equal([]string{"eth_sendRawTransaction"}, hooks.blocked)
+	r.Equal(2, hooks.doneCount())
+}
+
+func TestWebSocketProxy_DuplicateID(t *testing.T) {
+	r := require.New(t)
+
+	// the upstream never responds so the requests stay pending
+	upgrader := websocket.Upgrader{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := WebSocketURL(upstream.URL)
+
+	wsProxy := NewWebSocketProxy(upstreamURL, nil, 2, nil)
+	hooks := &testWebSocketHooks{allow: 10}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		wsProxy.Serve(w, req, "0xagent", hooks)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := WebSocketURL(proxy.URL)
+	conn, _, err := websocket.DefaultDialer.Dial(proxyURL, nil)
+	r.NoError(err)
+
+	r.NoError(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":[]}`)))
+	r.Eventually(func() bool {
+		return wsProxy.Subscriptions("0xagent") == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	// the same id is rejected and does not take another subscription slot
+	resp := wsCall(t, conn, 1, "eth_subscribe")
+	r.NotNil(resp.Error)
+	r.Equal(-32600, resp.Error.Code)
+	r.Equal(1, wsProxy.Subscriptions("0xagent"))
+
+	// the pending requests are completed when the connection is closed
+	conn.Close()
+	r.Eventually(func() bool {
+		return wsProxy.Subscriptions("0xagent") == 0 && hooks.doneCount() == 1
+	}, time.Second*5, time.Millisecond*10)
+}
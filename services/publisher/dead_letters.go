@@ -0,0 +1,114 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/store"
+	log "github.com/sirupsen/logrus"
+)
+
+var errDeadLettersDisabled = errors.New("dead letters are disabled (retry queue is disabled)")
+
+func (pub *Publisher) moveToDeadLetters(queued *store.QueuedBatch, reason string) error {
+	defer pub.updateDeadLetterMetrics()
+	return pub.deadLetters.Put(&store.DeadLetter{
+		ID:       queued.ID,
+		Reason:   reason,
+		Attempts: queued.Attempts,
+		QueuedAt: queued.QueuedAt,
+		FailedAt: time.Now().UTC(),
+		Batch:    queued.Batch,
+	})
+}
+
+func (pub *Publisher) updateDeadLetterMetrics() {
+	if pub.deadLetters == nil {
+		return
+	}
+	metrics.DeadLetterBatches.Set(float64(pub.deadLetters.Len()))
+	var oldestAge time.Duration
+	if oldest, ok := pub.deadLetters.Oldest(); ok {
+		oldestAge = time.Since(oldest)
+	}
+	metrics.DeadLetterOldestAge.Set(oldestAge.Seconds())
+}
+
+// RetryDeadLettersRequest selects the dead letters to retry. All of them are retried
+// if no IDs are specified.
+type RetryDeadLettersRequest struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+// RetryDeadLettersResponse contains the number of batches moved back to the retry queue.
+type RetryDeadLettersResponse struct {
+	Retried int `json:"retried"`
+}
+
+// ListDeadLetters returns the batches which could not be published.
+func (pub *Publisher) ListDeadLetters() ([]*store.DeadLetter, error) {
+	if pub.deadLetters == nil {
+		return nil, errDeadLettersDisabled
+	}
+	return pub.deadLetters.List()
+}
+
+// GetDeadLetter returns the dead letter with the batch.
+func (pub *Publisher) GetDeadLetter(id string) (*store.DeadLetter, error) {
+	if pub.deadLetters == nil {
+		return nil, errDeadLettersDisabled
+	}
+	return pub.deadLetters.Get(id)
+}
+
+// RetryDeadLetters moves the dead letters back to the retry queue. All of the dead letters
+// are retried if no IDs are specified. Returns the number of batches queued. The dead letters
+// which fail are skipped and the returned error lists them.
+func (pub *Publisher) RetryDeadLetters(ids []string) (int, error) {
+	if pub.deadLetters == nil {
+		return 0, errDeadLettersDisabled
+	}
+	defer pub.updateDeadLetterMetrics()
+
+	if len(ids) == 0 {
+		letters, err := pub.deadLetters.List()
+		if err != nil {
+			return 0, err
+		}
+		for _, letter := range letters {
+			ids = append(ids, letter.ID)
+		}
+	}
+	var (
+		count  int
+		failed []string
+	)
+	for _, id := range ids {
+		logger := log.WithField("batch", id)
+		if err := pub.retryDeadLetter(id); err != nil {
+			logger.WithError(err).Warn("failed to move the dead letter back to the retry queue")
+			failed = append(failed, id)
+			continue
+		}
+		logger.Info("moved the dead letter back to the retry queue")
+		count++
+	}
+	if len(failed) > 0 {
+		return count, fmt.Errorf("retried %d dead letters, failed to retry: %s", count, strings.Join(failed, ", "))
+	}
+	return count, nil
+}
+
+func (pub *Publisher) retryDeadLetter(id string) error {
+	letter, err := pub.deadLetters.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := pub.batchQueue.Push(letter.Batch); err != nil {
+		return err
+	}
+	return pub.deadLetters.Remove(id)
+}
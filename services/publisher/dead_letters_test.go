@@ -0,0 +1,75 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryDeadLetters(t *testing.T) {
+	r := require.New(t)
+
+	batchQueue, err := store.NewFileBatchQueue(t.TempDir(), 10)
+	r.NoError(err)
+	deadLetters, err := store.NewFileDeadLetterStore(t.TempDir())
+	r.NoError(err)
+	pub := &Publisher{
+		batchQueue:  batchQueue,
+		deadLetters: deadLetters,
+	}
+
+	r.NoError(pub.moveToDeadLetters(&store.QueuedBatch{
+		ID:       "1",
+		Batch:    &protocol.AlertBatch{BlockStart: 1},
+		QueuedAt: time.Now(),
+		Attempts: 3,
+	}, "failed"))
+	r.NoError(deadLetters.Put(&store.DeadLetter{ID: "2", Batch: &protocol.AlertBatch{BlockStart: 2}}))
+
+	letters, err := pub.ListDeadLetters()
+	r.NoError(err)
+	r.Len(letters, 2)
+	letter, err := pub.GetDeadLetter("1")
+	r.NoError(err)
+	r.Equal(3, letter.Attempts)
+	r.Equal("failed", letter.Reason)
+
+	count, err := pub.RetryDeadLetters([]string{"1"})
+	r.NoError(err)
+	r.Equal(1, count)
+	r.Equal(1, batchQueue.Len())
+	r.Equal(1, deadLetters.Len())
+
+	count, err = pub.RetryDeadLetters(nil)
+	r.NoError(err)
+	r.Equal(1, count)
+	r.Equal(2, batchQueue.Len())
+	r.Equal(0, deadLetters.Len())
+
+	// the missing ones are skipped and reported
+	r.NoError(deadLetters.Put(&store.DeadLetter{ID: "3", Batch: &protocol.AlertBatch{BlockStart: 3}}))
+	count, err = pub.RetryDeadLetters([]string{"missing-1", "3", "missing-2"})
+	r.Error(err)
+	r.Contains(err.Error(), "missing-1, missing-2")
+	r.Equal(1, count)
+	r.Equal(3, batchQueue.Len())
+	r.Equal(0, deadLetters.Len())
+
+	_, err = (&Publisher{}).ListDeadLetters()
+	r.Error(err)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := require.New(t)
+
+	pub := &Publisher{retryInterval: time.Second * 30, maxRetryInterval: time.Minute * 5}
+	r.Equal(time.Duration(0), pub.retryBackoff(0))
+	r.Equal(time.Second*30, pub.retryBackoff(1))
+	r.Equal(time.Minute, pub.retryBackoff(2))
+	r.Equal(time.Minute*4, pub.retryBackoff(4))
+	r.Equal(time.Minute*5, pub.retryBackoff(5))
+	r.Equal(time.Minute*5, pub.retryBackoff(100))
+}
@@ -0,0 +1,64 @@
+package publisher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// findingDeduplicator detects the same finding of an agent for the same tx or block. A finding
+// can be received more than once, e.g. when an agent is given the traces of a tx in chunks.
+// Only the findings of the current and the previous batch are remembered.
+type findingDeduplicator struct {
+	current  map[string]bool
+	previous map[string]bool
+}
+
+func newFindingDeduplicator() *findingDeduplicator {
+	return &findingDeduplicator{
+		current:  make(map[string]bool),
+		previous: make(map[string]bool),
+	}
+}
+
+// Rotate forgets the findings of the batches before the previous batch.
+func (dedup *findingDeduplicator) Rotate() {
+	if dedup == nil {
+		return
+	}
+	dedup.previous = dedup.current
+	dedup.current = make(map[string]bool)
+}
+
+// IsDuplicate remembers the finding of the notification and tells if it was seen before.
+func (dedup *findingDeduplicator) IsDuplicate(notif *protocol.NotifyRequest) bool {
+	if dedup == nil {
+		return false
+	}
+	key := findingKey(notif)
+	if dedup.current[key] || dedup.previous[key] {
+		return true
+	}
+	dedup.current[key] = true
+	return false
+}
+
+// findingKey identifies the finding by the agent, the tx or block hash and the finding hash.
+func findingKey(notif *protocol.NotifyRequest) string {
+	alert := notif.SignedAlert.Alert
+	var agentID, eventHash string
+	if alert.Agent != nil {
+		agentID = strings.ToLower(alert.Agent.Id)
+	}
+	if req := notif.EvalTxRequest; req != nil && req.Event != nil && req.Event.Transaction != nil {
+		eventHash = req.Event.Transaction.Hash
+	} else if req := notif.EvalBlockRequest; req != nil && req.Event != nil {
+		eventHash = req.Event.BlockHash
+	}
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(alert.Finding)
+	findingHash := sha256.Sum256(b)
+	return agentID + "|" + eventHash + "|" + hex.EncodeToString(findingHash[:])
+}
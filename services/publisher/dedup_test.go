@@ -0,0 +1,36 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindingDeduplicator(t *testing.T) {
+	r := require.New(t)
+
+	dedup := newFindingDeduplicator()
+	notif := testTxNotification("1")
+	r.False(dedup.IsDuplicate(notif))
+	r.True(dedup.IsDuplicate(testTxNotification("2")), "same agent, tx and finding")
+
+	otherTx := testTxNotification("3")
+	otherTx.EvalTxRequest.Event.Transaction.Hash = "0x2"
+	r.False(dedup.IsDuplicate(otherTx))
+
+	otherAgent := testTxNotification("4")
+	otherAgent.SignedAlert.Alert.Agent.Id = "0xagent"
+	r.False(dedup.IsDuplicate(otherAgent))
+
+	otherFinding := testTxNotification("5")
+	otherFinding.SignedAlert.Alert.Finding.Severity = protocol.Finding_HIGH
+	r.False(dedup.IsDuplicate(otherFinding))
+
+	// the findings of the previous batch are still remembered
+	dedup.Rotate()
+	r.True(dedup.IsDuplicate(testTxNotification("6")))
+	dedup.Rotate()
+	dedup.Rotate()
+	r.False(dedup.IsDuplicate(testTxNotification("7")))
+}
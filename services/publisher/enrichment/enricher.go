@@ -0,0 +1,317 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCacheTTL      = time.Hour
+	failedLookupTTL      = time.Minute
+	defaultMaxAddresses  = 10
+	defaultAlertTimeout  = time.Millisecond * 500
+	addressLookupTimeout = time.Second * 10
+	maxConcurrentLookups = 10
+
+	// MetadataPrefix is the prefix of the alert metadata keys added by the enricher.
+	MetadataPrefix = "enrichment."
+)
+
+// ChainReader reads the on-chain data needed for the enrichment.
+type ChainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// AddressInfo contains the on-chain context of an address.
+type AddressInfo struct {
+	TokenSymbol   string
+	TokenDecimals *uint8
+	ENSName       string
+	IsContract    bool
+	CreationBlock uint64
+}
+
+type cacheEntry struct {
+	info      *AddressInfo
+	expiresAt time.Time
+}
+
+// Enricher adds on-chain context about the finding addresses to the alerts.
+type Enricher struct {
+	ctx     context.Context
+	cfg     config.EnrichmentConfig
+	chainID int
+	reader  ChainReader
+
+	cache    map[common.Address]*cacheEntry
+	inflight map[common.Address]chan struct{}
+	cacheMu  sync.Mutex
+	lookups  chan struct{}
+}
+
+// NewEnricher dials the JSON-RPC API and creates a new enricher. Returns nil if the
+// enrichment is disabled.
+func NewEnricher(ctx context.Context, cfg config.EnrichmentConfig, chainID int, jsonRpcCfg config.JsonRpcConfig) (*Enricher, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	rpcClient, err := rpc.DialContext(ctx, jsonRpcCfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial json-rpc api for enrichment: %v", err)
+	}
+	for k, v := range jsonRpcCfg.Headers {
+		rpcClient.SetHeader(k, v)
+	}
+	return newEnricher(ctx, cfg, chainID, ethclient.NewClient(rpcClient)), nil
+}
+
+func newEnricher(ctx context.Context, cfg config.EnrichmentConfig, chainID int, reader ChainReader) *Enricher {
+	return &Enricher{
+		ctx:      ctx,
+		cfg:      cfg,
+		chainID:  chainID,
+		reader:   reader,
+		cache:    make(map[common.Address]*cacheEntry),
+		inflight: make(map[common.Address]chan struct{}),
+		lookups:  make(chan struct{}, maxConcurrentLookups),
+	}
+}
+
+// Enrich adds the address info to the alert metadata. It returns true if the alert was changed.
+//
+// The addresses are looked up in the background and the alert waits for the lookups only until
+// the alert timeout. The address info which is not ready by then is left out of the alert and
+// is used by the next alerts after the lookup completes.
+func (enricher *Enricher) Enrich(alert *protocol.Alert) bool {
+	if enricher == nil || alert.Finding == nil {
+		return false
+	}
+	maxAddresses := enricher.cfg.MaxAddresses
+	if maxAddresses <= 0 {
+		maxAddresses = defaultMaxAddresses
+	}
+
+	var addresses []common.Address
+	seen := make(map[common.Address]bool)
+	for _, addrStr := range alert.Finding.Addresses {
+		if len(addresses) >= maxAddresses {
+			break
+		}
+		if !common.IsHexAddress(addrStr) {
+			continue
+		}
+		address := common.HexToAddress(addrStr)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+	if len(addresses) == 0 {
+		return false
+	}
+
+	var pending []<-chan struct{}
+	for _, address := range addresses {
+		if done := enricher.startLookup(address); done != nil {
+			pending = append(pending, done)
+		}
+	}
+	enricher.waitLookups(pending)
+
+	var changed bool
+	for _, address := range addresses {
+		info := enricher.cachedInfo(address)
+		if info == nil {
+			continue
+		}
+		for k, v := range info.metadata() {
+			if alert.Metadata == nil {
+				alert.Metadata = make(map[string]string)
+			}
+			alert.Metadata[fmt.Sprintf("%s%s.%s", MetadataPrefix, strings.ToLower(address.Hex()), k)] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
+// waitLookups waits for the lookups until the alert timeout.
+func (enricher *Enricher) waitLookups(pending []<-chan struct{}) {
+	if len(pending) == 0 {
+		return
+	}
+	timeout := defaultAlertTimeout
+	if enricher.cfg.AlertTimeoutMs > 0 {
+		timeout = time.Duration(enricher.cfg.AlertTimeoutMs) * time.Millisecond
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for _, done := range pending {
+		select {
+		case <-done:
+		case <-timer.C:
+			return
+		case <-enricher.ctx.Done():
+			return
+		}
+	}
+}
+
+func (info *AddressInfo) metadata() map[string]string {
+	metadata := make(map[string]string)
+	if len(info.TokenSymbol) > 0 {
+		metadata["tokenSymbol"] = info.TokenSymbol
+	}
+	if info.TokenDecimals != nil {
+		metadata["tokenDecimals"] = strconv.Itoa(int(*info.TokenDecimals))
+	}
+	if len(info.ENSName) > 0 {
+		metadata["ensName"] = info.ENSName
+	}
+	if info.IsContract {
+		metadata["isContract"] = "true"
+	}
+	if info.CreationBlock > 0 {
+		metadata["creationBlock"] = strconv.FormatUint(info.CreationBlock, 10)
+	}
+	return metadata
+}
+
+// cachedInfo returns the address info if it was looked up before.
+func (enricher *Enricher) cachedInfo(address common.Address) *AddressInfo {
+	enricher.cacheMu.Lock()
+	defer enricher.cacheMu.Unlock()
+	entry, ok := enricher.cache[address]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.info
+}
+
+// startLookup starts looking up the address in the background unless the address info is
+// cached or is being looked up already. It returns the channel which is closed after the lookup,
+// or nil if there is nothing to wait for.
+func (enricher *Enricher) startLookup(address common.Address) <-chan struct{} {
+	enricher.cacheMu.Lock()
+	defer enricher.cacheMu.Unlock()
+	if entry, ok := enricher.cache[address]; ok && time.Now().Before(entry.expiresAt) {
+		return nil
+	}
+	if done, ok := enricher.inflight[address]; ok {
+		return done
+	}
+	// skip the address if there are too many slow lookups, a later alert can look it up
+	select {
+	case enricher.lookups <- struct{}{}:
+	default:
+		return nil
+	}
+	done := make(chan struct{})
+	enricher.inflight[address] = done
+	go func() {
+		info, err := enricher.lookupAddress(address)
+		<-enricher.lookups
+
+		ttl := defaultCacheTTL
+		if enricher.cfg.CacheTTLMinutes > 0 {
+			ttl = time.Duration(enricher.cfg.CacheTTLMinutes) * time.Minute
+		}
+		// the partial info of a failed lookup is kept only shortly so that it is retried soon
+		if err != nil {
+			log.WithError(err).WithField("address", address.Hex()).Debug("address lookup failed")
+			ttl = failedLookupTTL
+		}
+		enricher.cacheMu.Lock()
+		enricher.removeExpired()
+		enricher.cache[address] = &cacheEntry{info: info, expiresAt: time.Now().Add(ttl)}
+		delete(enricher.inflight, address)
+		enricher.cacheMu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+func (enricher *Enricher) removeExpired() {
+	now := time.Now()
+	for address, entry := range enricher.cache {
+		if now.After(entry.expiresAt) {
+			delete(enricher.cache, address)
+		}
+	}
+}
+
+// lookupAddress collects the address info. The failed token calls are not errors since the
+// address can be anything, e.g. an EOA or a contract without the token interface. The other
+// failures are returned together with the partial info.
+func (enricher *Enricher) lookupAddress(address common.Address) (*AddressInfo, error) {
+	ctx, cancel := context.WithTimeout(enricher.ctx, addressLookupTimeout)
+	defer cancel()
+
+	info := &AddressInfo{}
+
+	if !enricher.cfg.DisableContracts || !enricher.cfg.DisableTokens {
+		code, err := enricher.reader.CodeAt(ctx, address, nil)
+		if err != nil {
+			return info, fmt.Errorf("failed to get the code of the address: %v", err)
+		}
+		info.IsContract = len(code) > 0
+	}
+	var lookupErr error
+	if info.IsContract && !enricher.cfg.DisableContracts && enricher.cfg.CreationBlock {
+		creationBlock, err := enricher.findCreationBlock(ctx, address)
+		if err != nil {
+			lookupErr = fmt.Errorf("failed to find the contract creation block: %v", err)
+		}
+		info.CreationBlock = creationBlock
+	}
+	if info.IsContract && !enricher.cfg.DisableTokens {
+		info.TokenSymbol, info.TokenDecimals = enricher.lookupToken(ctx, address)
+	}
+	if !enricher.cfg.DisableENS && enricher.chainID == ensChainID {
+		name, err := enricher.lookupENSName(ctx, address)
+		if err != nil {
+			lookupErr = fmt.Errorf("failed to look up the ens name: %v", err)
+		}
+		info.ENSName = name
+	}
+	return info, lookupErr
+}
+
+// findCreationBlock finds the first block with the contract code by doing a binary search.
+// This works only with the archive nodes so it is done only if enabled.
+func (enricher *Enricher) findCreationBlock(ctx context.Context, address common.Address) (uint64, error) {
+	latest, err := enricher.reader.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	low, high := uint64(0), latest
+	for low < high {
+		mid := low + (high-low)/2
+		code, err := enricher.reader.CodeAt(ctx, address, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if len(code) > 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
@@ -0,0 +1,165 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testToken    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testEOA      = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testResolver = common.HexToAddress("0x3000000000000000000000000000000000000003")
+)
+
+const testCreationBlock = 1234
+
+type testChainReader struct {
+	calls int64
+	delay time.Duration
+	fail  bool
+}
+
+func (reader *testChainReader) BlockNumber(ctx context.Context) (uint64, error) {
+	return 100000, nil
+}
+
+func (reader *testChainReader) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	atomic.AddInt64(&reader.calls, 1)
+	time.Sleep(reader.delay)
+	if reader.fail {
+		return nil, errors.New("rpc failure")
+	}
+	if account != testToken {
+		return nil, nil
+	}
+	if blockNumber != nil && blockNumber.Uint64() < testCreationBlock {
+		return nil, nil
+	}
+	return []byte{0x60}, nil
+}
+
+func (reader *testChainReader) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	atomic.AddInt64(&reader.calls, 1)
+	switch {
+	case *msg.To == testToken && string(msg.Data) == string(tokenABI.Methods["symbol"].ID):
+		return tokenABI.Methods["symbol"].Outputs.Pack("TKN")
+	case *msg.To == testToken && string(msg.Data) == string(tokenABI.Methods["decimals"].ID):
+		return tokenABI.Methods["decimals"].Outputs.Pack(uint8(18))
+	case *msg.To == ensRegistryAddress:
+		node := nameHash(common.Bytes2Hex(testEOA.Bytes()) + ".addr.reverse")
+		var resolver common.Address
+		if common.BytesToHash(msg.Data[4:]) == node {
+			resolver = testResolver
+		}
+		return ensABI.Methods["resolver"].Outputs.Pack(resolver)
+	case *msg.To == testResolver:
+		return ensABI.Methods["name"].Outputs.Pack("test.eth")
+	}
+	return nil, nil
+}
+
+func TestNameHash(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(common.Hash{}, nameHash(""))
+	r.Equal("0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", nameHash("eth").Hex())
+	r.Equal("0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", nameHash("foo.eth").Hex())
+}
+
+func TestEnrich(t *testing.T) {
+	r := require.New(t)
+
+	reader := &testChainReader{}
+	enricher := newEnricher(context.Background(), config.EnrichmentConfig{Enable: true, CreationBlock: true}, ensChainID, reader)
+
+	alert := &protocol.Alert{
+		Finding: &protocol.Finding{
+			Addresses: []string{testToken.Hex(), testEOA.Hex(), "not-an-address"},
+		},
+	}
+	r.True(enricher.Enrich(alert))
+
+	token := MetadataPrefix + "0x1000000000000000000000000000000000000001."
+	eoa := MetadataPrefix + "0x2000000000000000000000000000000000000002."
+	r.Equal(map[string]string{
+		token + "tokenSymbol":   "TKN",
+		token + "tokenDecimals": "18",
+		token + "isContract":    "true",
+		token + "creationBlock": "1234",
+		eoa + "ensName":         "test.eth",
+	}, alert.Metadata)
+
+	// should use the cache
+	calls := atomic.LoadInt64(&reader.calls)
+	r.True(enricher.Enrich(&protocol.Alert{Finding: &protocol.Finding{Addresses: []string{testToken.Hex()}}}))
+	r.Equal(calls, atomic.LoadInt64(&reader.calls))
+
+	var nilEnricher *Enricher
+	r.False(nilEnricher.Enrich(alert))
+}
+
+func TestEnrichWithoutCreationBlock(t *testing.T) {
+	r := require.New(t)
+
+	enricher := newEnricher(context.Background(), config.EnrichmentConfig{Enable: true}, ensChainID, &testChainReader{})
+
+	alert := &protocol.Alert{Finding: &protocol.Finding{Addresses: []string{testToken.Hex()}}}
+	r.True(enricher.Enrich(alert))
+	r.NotContains(alert.Metadata, MetadataPrefix+"0x1000000000000000000000000000000000000001.creationBlock")
+}
+
+func TestEnrichTimeout(t *testing.T) {
+	r := require.New(t)
+
+	reader := &testChainReader{delay: time.Millisecond * 100}
+	enricher := newEnricher(context.Background(), config.EnrichmentConfig{Enable: true, AlertTimeoutMs: 10}, ensChainID, reader)
+
+	// the alert does not wait for the slow lookup
+	alert := &protocol.Alert{Finding: &protocol.Finding{Addresses: []string{testToken.Hex()}}}
+	start := time.Now()
+	r.False(enricher.Enrich(alert))
+	r.Less(time.Since(start), time.Millisecond*100)
+	r.Empty(alert.Metadata)
+
+	// the next alerts get the info after the lookup completes
+	r.Eventually(func() bool {
+		return enricher.cachedInfo(testToken) != nil
+	}, time.Second, time.Millisecond*10)
+	r.True(enricher.Enrich(alert))
+	r.Equal("TKN", alert.Metadata[MetadataPrefix+"0x1000000000000000000000000000000000000001.tokenSymbol"])
+}
+
+func TestEnrichFailedLookup(t *testing.T) {
+	r := require.New(t)
+
+	reader := &testChainReader{fail: true}
+	enricher := newEnricher(context.Background(), config.EnrichmentConfig{Enable: true}, ensChainID, reader)
+
+	alert := &protocol.Alert{Finding: &protocol.Finding{Addresses: []string{testToken.Hex()}}}
+	r.False(enricher.Enrich(alert))
+
+	// the failed lookup is cached only shortly
+	enricher.cacheMu.Lock()
+	entry := enricher.cache[testToken]
+	enricher.cacheMu.Unlock()
+	r.NotNil(entry)
+	r.True(entry.expiresAt.Before(time.Now().Add(failedLookupTTL + time.Second)))
+
+	// and is retried after it expires
+	reader.fail = false
+	enricher.cacheMu.Lock()
+	entry.expiresAt = time.Now().Add(-time.Second)
+	enricher.cacheMu.Unlock()
+	r.True(enricher.Enrich(alert))
+	r.Equal("TKN", alert.Metadata[MetadataPrefix+"0x1000000000000000000000000000000000000001.tokenSymbol"])
+}
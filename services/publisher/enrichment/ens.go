@@ -0,0 +1,61 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ENS reverse records are resolved only on the Ethereum mainnet.
+const ensChainID = 1
+
+var ensRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+const ensABIJSON = `[
+	{"name":"resolver","type":"function","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"name":"name","type":"function","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"string"}]}
+]`
+
+var ensABI = mustParseABI(ensABIJSON)
+
+// nameHash implements the ENS name hash algorithm.
+func nameHash(name string) common.Hash {
+	var node common.Hash
+	if len(name) == 0 {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		labelHash := crypto.Keccak256([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), labelHash)
+	}
+	return node
+}
+
+// lookupENSName finds the primary ENS name of the address from the reverse records.
+func (enricher *Enricher) lookupENSName(ctx context.Context, address common.Address) (string, error) {
+	node := nameHash(fmt.Sprintf("%x.addr.reverse", address.Bytes()))
+
+	_, value, err := enricher.call(ctx, ensABI, ensRegistryAddress, "resolver", node)
+	if err != nil {
+		return "", err
+	}
+	resolver, ok := value.(common.Address)
+	if !ok {
+		return "", errors.New("unexpected resolver output")
+	}
+	if resolver == (common.Address{}) {
+		return "", nil
+	}
+
+	_, value, err = enricher.call(ctx, ensABI, resolver, "name", node)
+	if err != nil {
+		return "", err
+	}
+	name, _ := value.(string)
+	return name, nil
+}
@@ -0,0 +1,64 @@
+package enrichment
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const tokenABIJSON = `[
+	{"name":"symbol","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]}
+]`
+
+var tokenABI = mustParseABI(tokenABIJSON)
+
+func mustParseABI(abiJSON string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// call calls a view method of a contract and unpacks the single output.
+func (enricher *Enricher) call(ctx context.Context, contractABI abi.ABI, address common.Address, method string, args ...interface{}) ([]byte, interface{}, error) {
+	input, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := enricher.reader.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := contractABI.Unpack(method, output)
+	if err != nil || len(values) == 0 {
+		return output, nil, err
+	}
+	return output, values[0], nil
+}
+
+// lookupToken returns the ERC-20 symbol and decimals of the contract.
+func (enricher *Enricher) lookupToken(ctx context.Context, address common.Address) (string, *uint8) {
+	var symbol string
+	output, value, err := enricher.call(ctx, tokenABI, address, "symbol")
+	switch {
+	case err == nil && value != nil:
+		symbol, _ = value.(string)
+	case len(output) == 32:
+		// some older tokens return bytes32 symbols
+		symbol = string(bytes.TrimRight(output, "\x00"))
+	}
+
+	var decimals *uint8
+	if _, value, err := enricher.call(ctx, tokenABI, address, "decimals"); err == nil {
+		if d, ok := value.(uint8); ok {
+			decimals = &d
+		}
+	}
+	return symbol, decimals
+}
@@ -0,0 +1,76 @@
+package publisher
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+)
+
+// severityFilter decides which alerts should be published. The alerts which are below
+// the min severity are still stored locally.
+type severityFilter struct {
+	minSeverity protocol.Finding_Severity
+	agents      map[string]protocol.Finding_Severity
+	mu          sync.RWMutex
+}
+
+func parseSeverity(severity string) (protocol.Finding_Severity, error) {
+	value, ok := protocol.Finding_Severity_value[strings.ToUpper(severity)]
+	if !ok {
+		return 0, fmt.Errorf("invalid severity: %s", severity)
+	}
+	return protocol.Finding_Severity(value), nil
+}
+
+func newSeverityFilter(cfg config.SeverityFilterConfig) (*severityFilter, error) {
+	filter := &severityFilter{agents: make(map[string]protocol.Finding_Severity)}
+	if len(cfg.MinSeverity) > 0 {
+		severity, err := parseSeverity(cfg.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		filter.minSeverity = severity
+	}
+	for agentID, minSeverity := range cfg.Agents {
+		severity, err := parseSeverity(minSeverity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter for agent %s: %v", agentID, err)
+		}
+		filter.agents[strings.ToLower(agentID)] = severity
+	}
+	return filter, nil
+}
+
+// Reload replaces the severities with the new config. The current ones are kept if the
+// new config is invalid.
+func (filter *severityFilter) Reload(cfg config.SeverityFilterConfig) error {
+	newFilter, err := newSeverityFilter(cfg)
+	if err != nil {
+		return err
+	}
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.minSeverity = newFilter.minSeverity
+	filter.agents = newFilter.agents
+	return nil
+}
+
+// ShouldPublish tells if the alert meets the min severity of the agent or the global
+// min severity if the agent does not have one.
+func (filter *severityFilter) ShouldPublish(alert *protocol.Alert) bool {
+	if filter == nil || alert.Finding == nil {
+		return true
+	}
+	filter.mu.RLock()
+	defer filter.mu.RUnlock()
+	minSeverity := filter.minSeverity
+	if alert.Agent != nil {
+		if agentMinSeverity, ok := filter.agents[strings.ToLower(alert.Agent.Id)]; ok {
+			minSeverity = agentMinSeverity
+		}
+	}
+	return alert.Finding.Severity >= minSeverity
+}
@@ -0,0 +1,197 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Channel types
+const (
+	ChannelSlack    = "slack"
+	ChannelTelegram = "telegram"
+	ChannelDiscord  = "discord"
+)
+
+const (
+	defaultMinSeverity = protocol.Finding_HIGH
+	defaultQueueSize   = 100
+	requestTimeout     = time.Second * 10
+)
+
+// DefaultTemplate is used for the channels which do not specify a template.
+const DefaultTemplate = `[{{.Finding.Severity}}] {{.Finding.Name}}
+{{.Finding.Description}}
+Agent: {{.Agent.Id}}
+Alert: {{.Id}}`
+
+var telegramAPIURL = "https://api.telegram.org"
+
+type channel struct {
+	cfg         config.NotificationChannelConfig
+	minSeverity protocol.Finding_Severity
+	tmpl        *template.Template
+}
+
+// Notifier delivers the findings to the notification channels in the background.
+type Notifier struct {
+	ctx      context.Context
+	channels []*channel
+	alertCh  chan *protocol.Alert
+	client   *http.Client
+
+	// minSeverity is the lowest severity accepted by any of the channels.
+	minSeverity protocol.Finding_Severity
+}
+
+// NewNotifier creates a new notifier. It returns nil if there are no channels.
+func NewNotifier(ctx context.Context, cfgs []config.NotificationChannelConfig) (*Notifier, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	notifier := &Notifier{
+		ctx:     ctx,
+		alertCh: make(chan *protocol.Alert, defaultQueueSize),
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+	for i, cfg := range cfgs {
+		ch, err := newChannel(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification channel #%d: %v", i, err)
+		}
+		notifier.channels = append(notifier.channels, ch)
+		if i == 0 || ch.minSeverity < notifier.minSeverity {
+			notifier.minSeverity = ch.minSeverity
+		}
+	}
+	return notifier, nil
+}
+
+func newChannel(cfg config.NotificationChannelConfig) (*channel, error) {
+	switch cfg.Type {
+	case ChannelSlack, ChannelTelegram, ChannelDiscord:
+	default:
+		return nil, fmt.Errorf("unknown type: %s", cfg.Type)
+	}
+	minSeverity := defaultMinSeverity
+	if len(cfg.MinSeverity) > 0 {
+		value, ok := protocol.Finding_Severity_value[strings.ToUpper(cfg.MinSeverity)]
+		if !ok {
+			return nil, fmt.Errorf("invalid min severity: %s", cfg.MinSeverity)
+		}
+		minSeverity = protocol.Finding_Severity(value)
+	}
+	text := cfg.Template
+	if len(text) == 0 {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New(cfg.Type).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the template: %v", err)
+	}
+	return &channel{cfg: cfg, minSeverity: minSeverity, tmpl: tmpl}, nil
+}
+
+// Start starts delivering the notifications.
+func (notifier *Notifier) Start() {
+	go func() {
+		for {
+			select {
+			case <-notifier.ctx.Done():
+				return
+			case alert := <-notifier.alertCh:
+				notifier.deliver(alert)
+			}
+		}
+	}()
+}
+
+// Notify queues the alert for the channels which accept its severity. The alert is
+// dropped if the queue is full so that the publisher is never blocked.
+func (notifier *Notifier) Notify(alert *protocol.Alert) {
+	if alert == nil || alert.Finding == nil {
+		return
+	}
+	// the alerts which none of the channels accept should not take up the queue
+	if alert.Finding.Severity < notifier.minSeverity {
+		return
+	}
+	select {
+	case notifier.alertCh <- alert:
+	default:
+		log.WithField("alert", alert.Id).Warn("notification queue is full - dropping alert")
+	}
+}
+
+func (notifier *Notifier) deliver(alert *protocol.Alert) {
+	for _, ch := range notifier.channels {
+		if alert.Finding.Severity < ch.minSeverity {
+			continue
+		}
+		if err := notifier.send(ch, alert); err != nil {
+			log.WithFields(log.Fields{
+				"alert":   alert.Id,
+				"channel": ch.cfg.Type,
+			}).WithError(err).Warn("failed to send notification")
+		}
+	}
+}
+
+func (notifier *Notifier) send(ch *channel, alert *protocol.Alert) error {
+	var buf bytes.Buffer
+	if err := ch.tmpl.Execute(&buf, alert); err != nil {
+		return fmt.Errorf("failed to execute the template: %v", err)
+	}
+	text := buf.String()
+
+	var (
+		reqURL  string
+		payload interface{}
+	)
+	switch ch.cfg.Type {
+	case ChannelSlack:
+		reqURL = ch.cfg.WebhookURL
+		payload = map[string]string{"text": text}
+	case ChannelDiscord:
+		reqURL = ch.cfg.WebhookURL
+		payload = map[string]string{"content": text}
+	case ChannelTelegram:
+		reqURL = fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIURL, ch.cfg.BotToken)
+		payload = map[string]string{"chat_id": ch.cfg.ChatID, "text": text}
+	}
+	b, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(notifier.ctx, http.MethodPost, reqURL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create the request: %v", redactURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := notifier.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", redactURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// redactURL removes the URL from the error since the webhook URLs and the Telegram bot
+// token in the URL are secrets.
+func redactURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return fmt.Errorf("%s: %v", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier(t *testing.T) {
+	r := require.New(t)
+
+	received := make(chan map[string]string, 10)
+	paths := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload map[string]string
+		r.NoError(json.NewDecoder(req.Body).Decode(&payload))
+		paths <- req.URL.Path
+		received <- payload
+	}))
+	defer server.Close()
+	telegramAPIURL = server.URL
+
+	notifier, err := NewNotifier(context.Background(), []config.NotificationChannelConfig{
+		{Type: ChannelSlack, WebhookURL: server.URL + "/slack", Template: "{{.Finding.Name}}"},
+		{Type: ChannelDiscord, WebhookURL: server.URL + "/discord", MinSeverity: "critical", Template: "{{.Id}}"},
+		{Type: ChannelTelegram, BotToken: "token", ChatID: "1", MinSeverity: "LOW", Template: "{{.Id}}"},
+	})
+	r.NoError(err)
+
+	alert := &protocol.Alert{
+		Id:      "0x1",
+		Finding: &protocol.Finding{Name: "finding", Severity: protocol.Finding_HIGH},
+	}
+	notifier.deliver(alert)
+
+	r.Equal("/slack", <-paths)
+	r.Equal(map[string]string{"text": "finding"}, <-received)
+	r.Equal("/bottoken/sendMessage", <-paths)
+	r.Equal(map[string]string{"chat_id": "1", "text": "0x1"}, <-received)
+	r.Len(received, 0)
+
+	// below the default threshold
+	alert.Finding.Severity = protocol.Finding_MEDIUM
+	notifier.deliver(alert)
+	r.Equal("/bottoken/sendMessage", <-paths)
+	<-received
+	r.Len(received, 0)
+}
+
+func TestNewNotifier_Invalid(t *testing.T) {
+	r := require.New(t)
+
+	notifier, err := NewNotifier(context.Background(), nil)
+	r.NoError(err)
+	r.Nil(notifier)
+
+	_, err = NewNotifier(context.Background(), []config.NotificationChannelConfig{{Type: "email"}})
+	r.Error(err)
+	_, err = NewNotifier(context.Background(), []config.NotificationChannelConfig{{Type: ChannelSlack, MinSeverity: "bad"}})
+	r.Error(err)
+	_, err = NewNotifier(context.Background(), []config.NotificationChannelConfig{{Type: ChannelSlack, Template: "{{"}})
+	r.Error(err)
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	r := require.New(t)
+
+	notifier, err := NewNotifier(context.Background(), []config.NotificationChannelConfig{
+		{Type: ChannelSlack, WebhookURL: "http://localhost/slack", MinSeverity: "critical"},
+		{Type: ChannelDiscord, WebhookURL: "http://localhost/discord", MinSeverity: "medium"},
+	})
+	r.NoError(err)
+
+	// the alerts below the severity of all of the channels are not queued
+	notifier.Notify(&protocol.Alert{Id: "0x1", Finding: &protocol.Finding{Severity: protocol.Finding_LOW}})
+	r.Len(notifier.alertCh, 0)
+	notifier.Notify(&protocol.Alert{Id: "0x2", Finding: &protocol.Finding{Severity: protocol.Finding_MEDIUM}})
+	r.Len(notifier.alertCh, 1)
+}
+
+func TestNotifier_RedactURL(t *testing.T) {
+	r := require.New(t)
+
+	telegramAPIURL = "http://127.0.0.1:0"
+	notifier, err := NewNotifier(context.Background(), []config.NotificationChannelConfig{
+		{Type: ChannelTelegram, BotToken: "secret-token", ChatID: "1"},
+	})
+	r.NoError(err)
+
+	err = notifier.send(notifier.channels[0], &protocol.Alert{Id: "0x1", Finding: &protocol.Finding{}})
+	r.Error(err)
+	r.NotContains(err.Error(), "secret-token")
+}
@@ -4,15 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
 	"path"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/forta-network/forta-core-go/clients/health"
@@ -23,23 +24,35 @@ import (
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-core-go/protocol/transform"
 	"github.com/forta-network/forta-core-go/release"
-	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/alertapi"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/services/publisher/enrichment"
+	"github.com/forta-network/forta-node/services/publisher/notifications"
+	"github.com/forta-network/forta-node/services/publisher/sinks"
+	"github.com/forta-network/forta-node/services/publisher/storage"
 	"github.com/forta-network/forta-node/services/publisher/testalerts"
+	"github.com/forta-network/forta-node/signer"
 	"github.com/forta-network/forta-node/store"
+	"github.com/forta-network/forta-node/tracing"
 	ipfsapi "github.com/ipfs/go-ipfs-api"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
-	defaultInterval        = time.Second * 15
-	defaultBatchLimit      = 500
-	defaultBatchBufferSize = 100
+	defaultInterval         = time.Second * 15
+	defaultBatchLimit       = 500
+	defaultBatchBufferSize  = 100
+	defaultRetryInterval    = time.Second * 30
+	defaultMaxRetryInterval = time.Minute * 30
+	defaultMaxAttempts      = 20
 )
 
 // Publisher receives, collects and publishes alerts.
@@ -48,26 +61,42 @@ type Publisher struct {
 	ctx               context.Context
 	cfg               PublisherConfig
 	contract          AlertsContract
-	ipfs              ipfs.Client
+	storage           storage.BatchStorage
+	ipfsClient        ipfs.Client
 	testAlertLogger   TestAlertLogger
 	metricsAggregator *AgentMetricsAggregator
-	messageClient     *messaging.Client
+	messageClient     clients.MessageClient
 	alertClient       clients.AlertAPIClient
 	webhookClient     webhook.AlertWebhookClient
+	notifier          *notifications.Notifier
+	severityFilter    *severityFilter
+	alertQuota        *alertQuota
+	findingDedup      *findingDeduplicator
+	enricher          *enrichment.Enricher
+	sinks             sinks.Sinks
 
 	batchRefStore    store.StringStore
 	lastReceiptStore store.StringStore
+	alertStore       store.AlertStore
+	batchQueue       store.BatchQueue
+	deadLetters      store.DeadLetterStore
 
 	server *grpc.Server
 
-	initialize    sync.Once
-	skipEmpty     bool
-	skipPublish   bool
-	batchInterval time.Duration
-	batchLimit    int
-	latestChainID uint64
-	notifCh       chan *protocol.NotifyRequest
-	batchCh       chan *protocol.AlertBatch
+	initialize       sync.Once
+	skipEmpty        bool
+	skipPublish      bool
+	batchInterval    time.Duration
+	batchLimit       int
+	batchMaxBytes    int
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	maxAttempts      int
+	latestChainID    uint64
+	notifCh          chan *protocol.NotifyRequest
+	batchCh          chan *protocol.AlertBatch
+	// the processed notification which did not fit into the previous batch
+	carryOver *protocol.NotifyRequest
 
 	lastBatchPublish    health.TimeTracker
 	lastBatchSkip       health.TimeTracker
@@ -101,7 +130,7 @@ type IPFS interface {
 
 type PublisherConfig struct {
 	ChainID         int
-	Key             *keystore.Key
+	Signer          signer.Signer
 	PublisherConfig config.PublisherConfig
 	ReleaseSummary  *release.ReleaseSummary
 	Config          config.Config
@@ -112,11 +141,25 @@ func (pub *Publisher) Notify(ctx context.Context, req *protocol.NotifyRequest) (
 	return &protocol.NotifyResponse{}, nil
 }
 
-func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
+func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (err error) {
+	// the batch is linked to the traces of the blocks it covers
+	_, span := tracing.Tracer().Start(
+		pub.ctx, "publisher.publish_batch",
+		trace.WithLinks(tracing.BlockLinks(batch.BlockStart, batch.BlockEnd)...),
+		trace.WithAttributes(
+			attribute.Int64("batch.block_start", int64(batch.BlockStart)),
+			attribute.Int64("batch.block_end", int64(batch.BlockEnd)),
+			attribute.Int64("batch.alerts", int64(batch.AlertCount)),
+		),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// flush only if we are publishing so we can make the best use of aggregated metrics
 	if _, skip := pub.shouldSkipPublishing(batch); !skip {
-		batch.Metrics = pub.metricsAggregator.TryFlush()
-		if len(batch.Metrics) > 0 {
+		// append so that the metrics of a retried batch are not lost
+		flushed := pub.metricsAggregator.TryFlush()
+		batch.Metrics = append(batch.Metrics, flushed...)
+		if len(flushed) > 0 {
 			pub.lastMetricsFlush.Set()
 		}
 	}
@@ -142,7 +185,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		batch.LatestBlockInput = batch.BlockEnd
 	}
 
-	signedBatch, err := security.SignBatch(pub.cfg.Key, batch)
+	signedBatch, err := signer.SignBatch(pub.cfg.Signer, batch)
 	if err != nil {
 		return fmt.Errorf("failed to build envelope: %v", err)
 	}
@@ -170,7 +213,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 	}
 
 	if pub.cfg.Config.PrivateModeConfig.Enable {
-		scannerJwt, err := security.CreateScannerJWT(pub.cfg.Key, map[string]interface{}{
+		scannerJwt, err := signer.CreateScannerJWT(pub.cfg.Signer, map[string]interface{}{
 			"privateMode": "true",
 		})
 		alertList := transform.ToWebhookAlertList(batch)
@@ -181,13 +224,23 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		})
 		if err != nil {
 			log.WithError(err).Error("failed to send private alerts")
+			return err
 		}
-		return err
+		observePublishedAlerts(batch)
+		return nil
 	}
 
-	cid, err := pub.ipfs.CalculateFileHash(buf.Bytes())
+	ref, err := pub.storage.Store(pub.ctx, buf.Bytes())
 	if err != nil {
-		return fmt.Errorf("failed to store alert data to ipfs: %v", err)
+		return fmt.Errorf("failed to store alert data: %v", err)
+	}
+	// the alert api makes the uncompressed batch available on ipfs so it only gets the ipfs cid,
+	// the references to the other storages and the content encodings are kept locally
+	cid := ref
+	if !storage.IsIPFS(pub.cfg.PublisherConfig.Storage.Type) {
+		if cid, err = pub.ipfsClient.CalculateFileHash(buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to calculate the batch cid: %v", err)
+		}
 	}
 	if err := pub.batchRefStore.Put(cid); err != nil {
 		return fmt.Errorf("failed to write last batch ref: %v", err)
@@ -195,12 +248,14 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 
 	logger := log.WithFields(
 		log.Fields{
-			"blockStart":  batch.BlockStart,
-			"blockEnd":    batch.BlockEnd,
-			"alertCount":  batch.AlertCount,
-			"maxSeverity": batch.MaxSeverity.String(),
-			"ref":         cid,
-			"metrics":     len(batch.Metrics),
+			"blockStart":    batch.BlockStart,
+			"blockEnd":      batch.BlockEnd,
+			"alertCount":    batch.AlertCount,
+			"maxSeverity":   batch.MaxSeverity.String(),
+			"ref":           cid,
+			"storageRef":    ref,
+			"formatVersion": store.PublishedBatchFormat,
+			"metrics":       len(batch.Metrics),
 		},
 	)
 
@@ -210,7 +265,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		lastReceipt = lr
 	}
 
-	signedBatchSummary, err := security.SignBatchSummary(pub.cfg.Key, &protocol.BatchSummary{
+	signedBatchSummary, err := signer.SignBatchSummary(pub.cfg.Signer, &protocol.BatchSummary{
 		Batch:            cid,
 		ChainId:          batch.ChainId,
 		BlockStart:       batch.BlockStart,
@@ -226,7 +281,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		return err
 	}
 
-	scannerJwt, err := security.CreateScannerJWT(pub.cfg.Key, map[string]interface{}{
+	scannerJwt, err := signer.CreateScannerJWT(pub.cfg.Signer, map[string]interface{}{
 		"batch": cid,
 	})
 
@@ -235,7 +290,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		return err
 	}
 	resp, err := pub.alertClient.PostBatch(&domain.AlertBatchRequest{
-		Scanner:            pub.cfg.Key.Address.Hex(),
+		Scanner:            pub.cfg.Signer.Address().Hex(),
 		ChainID:            int64(batch.ChainId),
 		BlockStart:         int64(batch.BlockStart),
 		BlockEnd:           int64(batch.BlockEnd),
@@ -250,6 +305,20 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 		logger.WithError(err).Error("alert while sending batch")
 		return fmt.Errorf("failed to send the alert tx: %v", err)
 	}
+	observePublishedAlerts(batch)
+	pub.sinks.WriteBatchRef(pub.ctx, &sinks.BatchRef{
+		Ref:           cid,
+		StorageRef:    ref,
+		FormatVersion: store.PublishedBatchFormat,
+		Scanner:       pub.cfg.Signer.Address().Hex(),
+		ChainID:       batch.ChainId,
+		BlockStart:    batch.BlockStart,
+		BlockEnd:      batch.BlockEnd,
+		AlertCount:    batch.AlertCount,
+		MaxSeverity:   batch.MaxSeverity.String(),
+		ReceiptID:     resp.ReceiptID,
+		PublishedAt:   time.Now().UTC(),
+	})
 
 	//TODO: after receipts are returned, make it non-optional
 	if resp.SignedReceipt != nil {
@@ -278,6 +347,57 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) error {
 	return nil
 }
 
+// migrateStoredBatches converts the queued and the dead letter batches to the current format
+// so that the older formats do not need to be supported forever.
+func migrateStoredBatches(fortaDir string) {
+	for _, dirName := range []string{config.DefaultBatchQueueDirName, config.DefaultDeadLetterDirName} {
+		logger := log.WithField("dir", dirName)
+		migrated, err := store.MigrateBatchFiles(path.Join(fortaDir, dirName))
+		if err != nil {
+			logger.WithError(err).Warn("failed to migrate the stored batches")
+		}
+		if migrated > 0 {
+			logger.WithField("count", migrated).Info("migrated the stored batches to the current format")
+		}
+	}
+}
+
+// observePublishedAlerts updates the metrics of the alerts in the published batch.
+func observePublishedAlerts(batch *protocol.AlertBatch) {
+	publishedAt := time.Now()
+	forEachAlert(batch, func(alert *protocol.SignedAlert) error {
+		if alert.Alert != nil {
+			metrics.ObservePublishedAlert(alert.Alert, batch.ChainId, publishedAt)
+		}
+		return nil
+	})
+}
+
+// forEachAlert calls the handler with every alert in the batch until it returns an error.
+func forEachAlert(batch *protocol.AlertBatch, handler func(alert *protocol.SignedAlert) error) error {
+	handleAll := func(agentAlertsList []*protocol.AgentAlerts) error {
+		for _, agentAlerts := range agentAlertsList {
+			for _, alert := range agentAlerts.Alerts {
+				if err := handler(alert); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, blockRes := range batch.Results {
+		if err := handleAll(blockRes.Results); err != nil {
+			return err
+		}
+		for _, txRes := range blockRes.Transactions {
+			if err := handleAll(txRes.Results); err != nil {
+				return err
+			}
+		}
+	}
+	return handleAll(batch.PrivateAlerts)
+}
+
 func (pub *Publisher) shouldSkipPublishing(batch *protocol.AlertBatch) (string, bool) {
 	if batch.AlertCount > 0 {
 		return "", false
@@ -316,17 +436,142 @@ func (pub *Publisher) handleScannerBlock(payload messaging.ScannerPayload) error
 }
 
 func (pub *Publisher) publishBatches() {
-	for batch := range pub.batchCh {
-		err := pub.publishNextBatch(batch)
+	var retryCh <-chan time.Time
+	if pub.batchQueue != nil {
+		// retry the batches left from the previous run first
+		pub.retryQueuedBatches()
+		ticker := time.NewTicker(pub.retryInterval)
+		defer ticker.Stop()
+		retryCh = ticker.C
+	}
+	for {
+		select {
+		case batch := <-pub.batchCh:
+			pub.handleBatch(batch)
+			time.Sleep(time.Millisecond * 20)
+		case <-retryCh:
+			pub.retryQueuedBatches()
+			pub.updateDeadLetterMetrics()
+		}
+	}
+}
+
+// handleBatch publishes the batch or queues it on disk if it cannot be published now.
+func (pub *Publisher) handleBatch(batch *protocol.AlertBatch) {
+	// keep the order if the older batches are still waiting to be published
+	if pub.batchQueue != nil && pub.batchQueue.Len() > 0 {
+		pub.queueBatch(batch)
+		return
+	}
+	err := pub.publishNextBatch(batch)
+	pub.lastBatchPublish.Set()
+	pub.lastBatchPublishErr.Set(err)
+	if err != nil {
+		log.Errorf("failed to publish alert batch: %v", err)
+		if pub.batchQueue != nil {
+			pub.queueBatch(batch)
+		} else {
+			observeBatchLoss(batch)
+		}
+	}
+}
+
+func (pub *Publisher) queueBatch(batch *protocol.AlertBatch) {
+	defer pub.updateBatchQueueMetrics()
+	if err := pub.batchQueue.Push(batch); err != nil {
+		log.WithError(err).Error("failed to queue the alert batch - dropping")
+		observeBatchLoss(batch)
+		return
+	}
+	log.WithField("queueDepth", pub.batchQueue.Len()).Info("queued the alert batch to retry publishing")
+}
+
+// observeBatchLoss counts the findings of a batch which could not be published or queued.
+func observeBatchLoss(batch *protocol.AlertBatch) {
+	metrics.ObserveEventLoss(metrics.LossStagePublish, metrics.LossReasonError, metrics.EventKindFinding, "", int(batch.AlertCount))
+}
+
+// retryQueuedBatches publishes the queued batches in order until the queue is empty
+// or publishing fails.
+func (pub *Publisher) retryQueuedBatches() {
+	defer pub.updateBatchQueueMetrics()
+	for {
+		queued, err := pub.batchQueue.Peek()
+		if err != nil {
+			log.WithError(err).Error("failed to read the batch queue")
+			return
+		}
+		if queued == nil {
+			return
+		}
+		if time.Since(queued.LastAttemptAt) < pub.retryBackoff(queued.Attempts) {
+			return
+		}
+		metricCount := len(queued.Batch.Metrics)
+		err = pub.publishNextBatch(queued.Batch)
 		pub.lastBatchPublish.Set()
 		pub.lastBatchPublishErr.Set(err)
 		if err != nil {
-			log.Errorf("failed to publish alert batch: %v", err)
+			// the metrics which were flushed into the batch are not in the aggregator anymore
+			if len(queued.Batch.Metrics) > metricCount {
+				if updateErr := pub.batchQueue.Update(queued.ID, queued.Batch); updateErr != nil {
+					log.WithError(updateErr).Error("failed to persist the flushed metrics with the queued batch")
+				}
+			}
+			attempts, attemptErr := pub.batchQueue.AddAttempt(queued.ID)
+			if attemptErr != nil {
+				log.WithError(attemptErr).Error("failed to record the attempt of the queued batch")
+				attempts = queued.Attempts + 1
+			}
+			queued.Attempts = attempts
+			logger := log.WithError(err).WithFields(log.Fields{
+				"queuedAt":   queued.QueuedAt.Format(time.RFC3339),
+				"queueDepth": pub.batchQueue.Len(),
+				"attempts":   attempts,
+				"retryIn":    pub.retryBackoff(attempts).String(),
+			})
+			if pub.deadLetters == nil || attempts < pub.maxAttempts {
+				logger.Warn("failed to publish the queued alert batch - will retry")
+				return
+			}
+			logger.Error("failed to publish the queued alert batch - moving to dead letters")
+			if err := pub.moveToDeadLetters(queued, err.Error()); err != nil {
+				log.WithError(err).Error("failed to move the batch to dead letters")
+				return
+			}
+		}
+		if err := pub.batchQueue.Remove(queued.ID); err != nil {
+			log.WithError(err).Error("failed to remove the batch from the queue")
+			return
 		}
-		time.Sleep(time.Millisecond * 20)
 	}
 }
 
+// retryBackoff doubles the retry interval after each failed attempt up to the max interval
+// so that the queued batches outlive the longer outages before they are dead lettered.
+func (pub *Publisher) retryBackoff(attempts int) time.Duration {
+	if attempts <= 0 {
+		return 0
+	}
+	backoff := pub.retryInterval
+	for i := 1; i < attempts && backoff < pub.maxRetryInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > pub.maxRetryInterval {
+		backoff = pub.maxRetryInterval
+	}
+	return backoff
+}
+
+func (pub *Publisher) updateBatchQueueMetrics() {
+	metrics.BatchQueueDepth.Set(float64(pub.batchQueue.Len()))
+	var oldestAge time.Duration
+	if oldest, ok := pub.batchQueue.Oldest(); ok {
+		oldestAge = time.Since(oldest)
+	}
+	metrics.BatchQueueOldestAge.Set(oldestAge.Seconds())
+}
+
 func (pub *Publisher) prepareBatches() {
 	for {
 		pub.prepareLatestBatch()
@@ -509,8 +754,47 @@ func (pub *Publisher) prepareLatestBatch() {
 	timeoutCh := time.After(pub.batchInterval)
 
 	var done bool
-	var i int
-	for i < pub.batchLimit {
+	var i, size, appended int
+	var batchAlerts []*protocol.Alert
+	appendNotif := func(notif *protocol.NotifyRequest, notifBlockNum uint64) {
+		alert := notif.SignedAlert
+		hasAlert := alert != nil
+
+		// Notifications with empty alerts shouldn't be taken into account while limiting the batch.
+		// Otherwise, we create too many batches very quickly.
+		if hasAlert {
+			i++
+		}
+
+		if batch.BlockStart == 0 || (batch.BlockStart > 0 && notifBlockNum < batch.BlockStart) {
+			batch.BlockStart = notifBlockNum
+		}
+		if batch.BlockEnd == 0 || (batch.BlockEnd > 0 && notifBlockNum > batch.BlockEnd) {
+			batch.BlockEnd = notifBlockNum
+		}
+
+		if hasAlert && alert.Alert.Finding.Severity > batch.MaxSeverity {
+			batch.MaxSeverity = alert.Alert.Finding.Severity
+		}
+		if hasAlert {
+			batchAlerts = append(batchAlerts, alert.Alert)
+		}
+
+		batch.AppendAlert(notif)
+		appended++
+		if pub.batchMaxBytes > 0 {
+			size = proto.Size((*protocol.AlertBatch)(batch))
+		}
+	}
+
+	pub.findingDedup.Rotate()
+	if notif := pub.carryOver; notif != nil {
+		pub.carryOver = nil
+		notifBlockNum, _ := notificationBlockNumber(notif)
+		appendNotif(notif, notifBlockNum)
+	}
+
+	for i < pub.batchLimit && (pub.batchMaxBytes <= 0 || size < pub.batchMaxBytes) {
 		select {
 		case notif := <-pub.notifCh:
 			alert := notif.SignedAlert
@@ -529,36 +813,72 @@ func (pub *Publisher) prepareLatestBatch() {
 				continue
 			}
 
-			// Notifications with empty alerts shouldn't be taken into account while limiting the batch.
-			// Otherwise, we create too many batches very quickly.
+			var labels []string
 			if hasAlert {
-				i++
+				labels = metrics.AlertLabels(alert.Alert, uint64(pub.cfg.ChainID))
+				metrics.FindingsProcessed.WithLabelValues(labels...).Inc()
+			}
+
+			// the alert is kept locally even if it is not published
+			received := hasAlert
+
+			// Keep the alerts below the min severity only in the local store and treat
+			// the notification as an empty one so that the batch still covers the block.
+			if hasAlert && !pub.severityFilter.ShouldPublish(alert.Alert) {
+				metrics.FindingsDropped.WithLabelValues(append(labels, metrics.DropReasonSeverity)...).Inc()
+				notif.SignedAlert = nil
+				hasAlert = false
+			}
+
+			// the same finding can be received more than once for a tx, e.g. for the trace chunks
+			if hasAlert && pub.findingDedup.IsDuplicate(notif) {
+				metrics.FindingsDeduplicated.WithLabelValues(labels...).Inc()
+				notif.SignedAlert = nil
+				hasAlert = false
+				received = false
 			}
 
-			var blockNum string
-			if notif.EvalBlockRequest != nil {
-				blockNum = notif.EvalBlockRequest.Event.BlockNumber
-			} else {
-				blockNum = notif.EvalTxRequest.Event.Block.BlockNumber
+			if hasAlert && !pub.alertQuota.Allow(alert.Alert) {
+				metrics.FindingsDropped.WithLabelValues(append(labels, metrics.DropReasonQuota)...).Inc()
+				notif.SignedAlert = nil
+				hasAlert = false
 			}
 
-			notifBlockNum, err := hexutil.DecodeUint64(blockNum)
+			// only the alerts which are published are enriched, and the enriched alert is signed
+			// again since the alert metadata is covered by the signature
+			if hasAlert && pub.enricher.Enrich(alert.Alert) {
+				if signedAlert, err := signer.SignAlert(pub.cfg.Signer, alert.Alert); err != nil {
+					log.WithError(err).Error("failed to sign the enriched alert")
+				} else {
+					alert.Signature = signedAlert.Signature
+				}
+			}
+
+			if received && pub.alertStore != nil {
+				if err := pub.alertStore.Put(alert.Alert); err != nil {
+					log.WithError(err).Warn("failed to store alert locally")
+				}
+			}
+
+			if received && pub.notifier != nil {
+				pub.notifier.Notify(alert.Alert)
+			}
+
+			notifBlockNum, err := notificationBlockNumber(notif)
 			if err != nil {
 				log.Errorf("failed to parse alert notif block number: %v", err)
 				continue
 			}
-			if batch.BlockStart == 0 || (batch.BlockStart > 0 && notifBlockNum < batch.BlockStart) {
-				batch.BlockStart = notifBlockNum
-			}
-			if batch.BlockEnd == 0 || (batch.BlockEnd > 0 && notifBlockNum > batch.BlockEnd) {
-				batch.BlockEnd = notifBlockNum
-			}
 
-			if hasAlert && alert.Alert.Finding.Severity > batch.MaxSeverity {
-				batch.MaxSeverity = alert.Alert.Finding.Severity
+			// The notification which would exceed the max bytes is left to the next batch.
+			// A notification which exceeds the limit alone is sent in a batch of its own.
+			if appended > 0 && !pub.fitsBatch(batch, size, notif) {
+				pub.carryOver = notif
+				done = true
+				break
 			}
 
-			batch.AppendAlert(notif)
+			appendNotif(notif, notifBlockNum)
 
 		case <-timeoutCh:
 			done = true
@@ -569,12 +889,55 @@ func (pub *Publisher) prepareLatestBatch() {
 		}
 	}
 
+	pub.reportSuppressedAlerts()
+	pub.sinks.WriteAlerts(pub.ctx, batchAlerts)
 	pub.batchCh <- (*protocol.AlertBatch)(batch)
 }
 
+// reportSuppressedAlerts summarizes the alerts which exceeded the agent quotas.
+func (pub *Publisher) reportSuppressedAlerts() {
+	var ms []*protocol.AgentMetric
+	for _, suppressed := range pub.alertQuota.TakeSuppressed() {
+		log.WithField("agent", suppressed.AgentID).Warnf("alert quota exceeded: %d additional alerts suppressed", suppressed.Count)
+		ms = append(ms, metrics.CreateAgentMetric(suppressed.AgentID, metrics.MetricFindingsSuppressed, float64(suppressed.Count)))
+	}
+	if pub.messageClient != nil {
+		metrics.SendAgentMetrics(pub.messageClient, ms)
+	}
+}
+
+// batchGrowthOverheadBytes covers the field tags and the length prefixes which are added to
+// the batch with a notification.
+const batchGrowthOverheadBytes = 128
+
+// fitsBatch tells if the batch stays within the max bytes after the notification is appended.
+func (pub *Publisher) fitsBatch(batch *BatchData, size int, notif *protocol.NotifyRequest) bool {
+	if pub.batchMaxBytes <= 0 {
+		return true
+	}
+	// the notification content is put into the batch at most twice (e.g. the agent info and
+	// the agent manifest) so the exact size is needed only close to the limit
+	if size+2*proto.Size(notif)+batchGrowthOverheadBytes <= pub.batchMaxBytes {
+		return true
+	}
+	trial := (*BatchData)(proto.Clone((*protocol.AlertBatch)(batch)).(*protocol.AlertBatch))
+	trial.AppendAlert(notif)
+	return proto.Size((*protocol.AlertBatch)(trial)) <= pub.batchMaxBytes
+}
+
+func notificationBlockNumber(notif *protocol.NotifyRequest) (uint64, error) {
+	if notif.EvalBlockRequest != nil {
+		return hexutil.DecodeUint64(notif.EvalBlockRequest.Event.BlockNumber)
+	}
+	return hexutil.DecodeUint64(notif.EvalTxRequest.Event.Block.BlockNumber)
+}
+
 func (pub *Publisher) Start() error {
 	go pub.prepareBatches()
 	go pub.publishBatches()
+	if pub.notifier != nil {
+		pub.notifier.Start()
+	}
 	pub.registerMessageHandlers()
 	return nil
 }
@@ -584,16 +947,49 @@ func (pub *Publisher) Stop() error {
 	if pub.server != nil {
 		pub.server.Stop()
 	}
+	pub.sinks.Close()
+	if pub.alertStore != nil {
+		return pub.alertStore.Close()
+	}
 	return nil
 }
 
+// QueryAlerts queries the alerts from the local alert store.
+func (pub *Publisher) QueryAlerts(query *store.AlertQuery) (*store.AlertPage, error) {
+	if pub.alertStore == nil {
+		return nil, errors.New("local alert store is disabled")
+	}
+	return pub.alertStore.Query(query)
+}
+
+// ReloadConfig applies the new alert filters.
+func (pub *Publisher) ReloadConfig(cfg config.Config) error {
+	if err := pub.severityFilter.Reload(cfg.Publish.Filter); err != nil {
+		return fmt.Errorf("failed to reload the alert filter: %v", err)
+	}
+	return nil
+}
+
+// ReloadableFields returns the publisher config fields which are applied by ReloadConfig.
+func (pub *Publisher) ReloadableFields() []string {
+	return []string{"publish.filter"}
+}
+
 func (pub *Publisher) Name() string {
 	return "publisher"
 }
 
+// CheckReady returns the error of the latest batch upload if it failed.
+func (pub *Publisher) CheckReady() error {
+	if errMsg := pub.lastBatchPublishErr.String(); len(errMsg) > 0 {
+		return fmt.Errorf("failed to publish the latest batch: %s", errMsg)
+	}
+	return nil
+}
+
 // Health implements the health.Reporter interface.
 func (pub *Publisher) Health() health.Reports {
-	return health.Reports{
+	reports := health.Reports{
 		pub.lastBatchPublish.GetReport("event.batch-publish.time"),
 		pub.lastBatchPublishErr.GetReport("event.batch-publish.error"),
 		&health.Report{
@@ -604,15 +1000,46 @@ func (pub *Publisher) Health() health.Reports {
 		pub.lastBatchSkipReason.GetReport("event.batch-skip.reason"),
 		pub.lastMetricsFlush.GetReport("event.metrics-flush.time"),
 	}
+	if reporter, ok := pub.storage.(interface{ Health() health.Reports }); ok {
+		reports = append(reports, reporter.Health()...)
+	}
+	if pub.batchQueue != nil {
+		reports = append(reports, &health.Report{
+			Name:    "batch-queue.depth",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(pub.batchQueue.Len()),
+		})
+		var oldestAge time.Duration
+		if oldest, ok := pub.batchQueue.Oldest(); ok {
+			oldestAge = time.Since(oldest).Truncate(time.Second)
+		}
+		reports = append(reports, &health.Report{
+			Name:    "batch-queue.oldest-age",
+			Status:  health.StatusInfo,
+			Details: oldestAge.String(),
+		})
+	}
+	if pub.deadLetters != nil {
+		reports = append(reports, &health.Report{
+			Name:    "dead-letter.count",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(pub.deadLetters.Len()),
+		})
+		var oldestAge time.Duration
+		if oldest, ok := pub.deadLetters.Oldest(); ok {
+			oldestAge = time.Since(oldest).Truncate(time.Second)
+		}
+		reports = append(reports, &health.Report{
+			Name:    "dead-letter.oldest-age",
+			Status:  health.StatusInfo,
+			Details: oldestAge.String(),
+		})
+	}
+	return reports
 }
 
-func NewPublisher(ctx context.Context, cfg config.Config) (*Publisher, error) {
-	mc := messaging.NewClient("metrics", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
-
-	key, err := security.LoadKey(config.DefaultContainerKeyDirPath)
-	if err != nil {
-		return nil, err
-	}
+func NewPublisher(ctx context.Context, cfg config.Config, scannerSigner signer.Signer) (*Publisher, error) {
+	mc := clients.NewMessageClient("metrics", cfg.Messaging)
 
 	releaseInfoStr := os.Getenv(config.EnvReleaseInfo)
 	var releaseSummary *release.ReleaseSummary
@@ -625,29 +1052,106 @@ func NewPublisher(ctx context.Context, cfg config.Config) (*Publisher, error) {
 
 	return initPublisher(ctx, mc, apiClient, PublisherConfig{
 		ChainID:         cfg.ChainID,
-		Key:             key,
+		Signer:          scannerSigner,
 		PublisherConfig: cfg.Publish,
 		ReleaseSummary:  releaseSummary,
 		Config:          cfg,
 	})
 }
 
-func initPublisher(ctx context.Context, mc *messaging.Client, alertClient clients.AlertAPIClient, cfg PublisherConfig) (*Publisher, error) {
+func initPublisher(ctx context.Context, mc clients.MessageClient, alertClient clients.AlertAPIClient, cfg PublisherConfig) (*Publisher, error) {
 	ipfsClient, err := ipfs.NewClient(fmt.Sprintf("http://%s:5001", config.DockerIpfsContainerName))
 	if err != nil {
 		return nil, err
 	}
+	batchStorage, err := storage.New(cfg.PublisherConfig.Storage, ipfsClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the batch storage: %v", err)
+	}
 
 	batchInterval := defaultInterval
 	if cfg.PublisherConfig.Batch.IntervalSeconds != nil {
 		batchInterval = (time.Duration)(*cfg.PublisherConfig.Batch.IntervalSeconds) * time.Second
 	}
+	if cfg.PublisherConfig.Batch.IntervalMs != nil {
+		batchInterval = (time.Duration)(*cfg.PublisherConfig.Batch.IntervalMs) * time.Millisecond
+	}
 
 	batchLimit := defaultBatchLimit
 	if cfg.PublisherConfig.Batch.MaxAlerts != nil {
 		batchLimit = *cfg.PublisherConfig.Batch.MaxAlerts
 	}
 
+	var batchMaxBytes int
+	if cfg.PublisherConfig.Batch.MaxBytes != nil {
+		batchMaxBytes = *cfg.PublisherConfig.Batch.MaxBytes
+	}
+
+	var alertStore store.AlertStore
+	if !cfg.PublisherConfig.LocalStore.Disable {
+		alertStore, err = store.NewAlertStore(
+			path.Join(cfg.Config.FortaDir, config.DefaultAlertStoreDirName),
+			time.Duration(cfg.PublisherConfig.LocalStore.RetentionHours)*time.Hour,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	notifier, err := notifications.NewNotifier(ctx, cfg.PublisherConfig.Notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		batchQueue  store.BatchQueue
+		deadLetters store.DeadLetterStore
+	)
+	if !cfg.PublisherConfig.RetryQueue.Disable {
+		migrateStoredBatches(cfg.Config.FortaDir)
+		fileBatchQueue, err := store.NewFileBatchQueue(
+			path.Join(cfg.Config.FortaDir, config.DefaultBatchQueueDirName),
+			cfg.PublisherConfig.RetryQueue.MaxBatches,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fileDeadLetters, err := store.NewFileDeadLetterStore(path.Join(cfg.Config.FortaDir, config.DefaultDeadLetterDirName))
+		if err != nil {
+			return nil, err
+		}
+		fileBatchQueue.SetDeadLetterStore(fileDeadLetters)
+		batchQueue = fileBatchQueue
+		deadLetters = fileDeadLetters
+	}
+	maxAttempts := defaultMaxAttempts
+	if cfg.PublisherConfig.RetryQueue.MaxAttempts > 0 {
+		maxAttempts = cfg.PublisherConfig.RetryQueue.MaxAttempts
+	}
+	retryInterval := defaultRetryInterval
+	if cfg.PublisherConfig.RetryQueue.IntervalSeconds > 0 {
+		retryInterval = time.Duration(cfg.PublisherConfig.RetryQueue.IntervalSeconds) * time.Second
+	}
+	maxRetryInterval := defaultMaxRetryInterval
+	if cfg.PublisherConfig.RetryQueue.MaxIntervalSeconds > 0 {
+		maxRetryInterval = time.Duration(cfg.PublisherConfig.RetryQueue.MaxIntervalSeconds) * time.Second
+	}
+
+	severityFilter, err := newSeverityFilter(cfg.PublisherConfig.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	enricher, err := enrichment.NewEnricher(ctx, cfg.PublisherConfig.Enrichment, cfg.ChainID, cfg.Config.Scan.JsonRpc)
+	if err != nil {
+		return nil, err
+	}
+
+	alertSinks, err := sinks.New(ctx, cfg.PublisherConfig.Sinks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the alert sinks: %v", err)
+	}
+
 	var testAlertLogger TestAlertLogger
 	if !cfg.PublisherConfig.TestAlerts.Disable {
 		testAlertLogger = testalerts.NewLogger(cfg.PublisherConfig.TestAlerts.WebhookURL)
@@ -665,20 +1169,34 @@ func initPublisher(ctx context.Context, mc *messaging.Client, alertClient client
 	return &Publisher{
 		ctx:               ctx,
 		cfg:               cfg,
-		ipfs:              ipfsClient,
+		storage:           batchStorage,
+		ipfsClient:        ipfsClient,
 		testAlertLogger:   testAlertLogger,
 		metricsAggregator: NewMetricsAggregator(),
 		messageClient:     mc,
 		alertClient:       alertClient,
 		webhookClient:     webhookClient,
+		notifier:          notifier,
+		severityFilter:    severityFilter,
+		alertQuota:        newAlertQuota(cfg.PublisherConfig.Quota),
+		findingDedup:      newFindingDeduplicator(),
+		enricher:          enricher,
+		sinks:             alertSinks,
 		batchRefStore:     store.NewFileStringStore(path.Join(cfg.Config.FortaDir, ".last-batch")),
 		lastReceiptStore:  store.NewFileStringStore(path.Join(cfg.Config.FortaDir, ".last-receipt")),
-
-		skipEmpty:     cfg.PublisherConfig.Batch.SkipEmpty,
-		skipPublish:   cfg.PublisherConfig.SkipPublish,
-		batchInterval: batchInterval,
-		batchLimit:    batchLimit,
-		notifCh:       make(chan *protocol.NotifyRequest, defaultBatchLimit),
-		batchCh:       make(chan *protocol.AlertBatch, defaultBatchBufferSize),
+		alertStore:        alertStore,
+		batchQueue:        batchQueue,
+		deadLetters:       deadLetters,
+
+		skipEmpty:        cfg.PublisherConfig.Batch.SkipEmpty,
+		skipPublish:      cfg.PublisherConfig.SkipPublish,
+		batchInterval:    batchInterval,
+		batchLimit:       batchLimit,
+		batchMaxBytes:    batchMaxBytes,
+		retryInterval:    retryInterval,
+		maxRetryInterval: maxRetryInterval,
+		maxAttempts:      maxAttempts,
+		notifCh:          make(chan *protocol.NotifyRequest, defaultBatchLimit),
+		batchCh:          make(chan *protocol.AlertBatch, defaultBatchBufferSize),
 	}, nil
 }
@@ -1,9 +1,16 @@
 package publisher
 
 import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
 	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
 	"github.com/stretchr/testify/assert"
-	"testing"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestBatchData_AppendPrivateAlert_PerFinding(t *testing.T) {
@@ -77,3 +84,124 @@ func TestBatchData_AppendPrivateAlert_Block(t *testing.T) {
 	assert.Len(t, bd.PrivateAlerts[0].Alerts, 1)
 	assert.EqualValues(t, alert, bd.PrivateAlerts[0].Alerts[0])
 }
+
+func testTxNotification(alertID string) *protocol.NotifyRequest {
+	return &protocol.NotifyRequest{
+		SignedAlert: &protocol.SignedAlert{
+			Alert: &protocol.Alert{
+				Id:      alertID,
+				Agent:   &protocol.AgentInfo{},
+				Finding: &protocol.Finding{Description: strings.Repeat("a", 500)},
+			},
+		},
+		EvalTxRequest: &protocol.EvaluateTxRequest{
+			Event: &protocol.TransactionEvent{
+				Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"},
+				Receipt:     &protocol.TransactionEvent_EthReceipt{TransactionHash: "0x1"},
+				Block:       &protocol.TransactionEvent_EthBlock{BlockNumber: "0x1"},
+			},
+		},
+		EvalTxResponse: &protocol.EvaluateTxResponse{},
+		AgentInfo:      &protocol.AgentInfo{Manifest: "agentInfo"},
+	}
+}
+
+func TestPrepareLatestBatch_MaxBytes(t *testing.T) {
+	single := (*BatchData)(&protocol.AlertBatch{})
+	single.AppendAlert(testTxNotification("1"))
+	maxBytes := proto.Size((*protocol.AlertBatch)(single)) * 2
+	pub := &Publisher{
+		batchInterval: time.Millisecond * 100,
+		batchLimit:    100,
+		batchMaxBytes: maxBytes,
+		notifCh:       make(chan *protocol.NotifyRequest, 10),
+		batchCh:       make(chan *protocol.AlertBatch, 1),
+	}
+	for i := 0; i < 5; i++ {
+		pub.notifCh <- testTxNotification(fmt.Sprint(i))
+	}
+
+	// the batches stay within the limit and the alert which does not fit goes to the next batch
+	var alertIDs []string
+	for _, expected := range []uint32{2, 2, 1} {
+		pub.prepareLatestBatch()
+		batch := <-pub.batchCh
+		assert.Equal(t, expected, batch.AlertCount)
+		assert.LessOrEqual(t, proto.Size(batch), maxBytes)
+		for _, alert := range batch.Results[0].Transactions[0].Results[0].Alerts {
+			alertIDs = append(alertIDs, alert.Alert.Id)
+		}
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, alertIDs)
+}
+
+func TestPrepareLatestBatch_SeverityFilter(t *testing.T) {
+	filter, err := newSeverityFilter(config.SeverityFilterConfig{
+		MinSeverity: "MEDIUM",
+		Agents:      map[string]string{"0xAGENT": "info"},
+	})
+	assert.NoError(t, err)
+	pub := &Publisher{
+		batchInterval:  time.Millisecond * 100,
+		batchLimit:     100,
+		severityFilter: filter,
+		notifCh:        make(chan *protocol.NotifyRequest, 10),
+		batchCh:        make(chan *protocol.AlertBatch, 1),
+	}
+	lowAlert := testTxNotification("1")
+	lowAlert.SignedAlert.Alert.Finding.Severity = protocol.Finding_LOW
+	highAlert := testTxNotification("2")
+	highAlert.SignedAlert.Alert.Finding.Severity = protocol.Finding_HIGH
+	agentAlert := testTxNotification("3")
+	agentAlert.SignedAlert.Alert.Agent.Id = "0xagent"
+	agentAlert.SignedAlert.Alert.Finding.Severity = protocol.Finding_INFO
+	pub.notifCh <- lowAlert
+	pub.notifCh <- highAlert
+	pub.notifCh <- agentAlert
+
+	pub.prepareLatestBatch()
+	batch := <-pub.batchCh
+	assert.Equal(t, uint32(2), batch.AlertCount)
+	assert.Equal(t, protocol.Finding_HIGH, batch.MaxSeverity)
+}
+
+func TestReloadConfig_SeverityFilter(t *testing.T) {
+	filter, err := newSeverityFilter(config.SeverityFilterConfig{MinSeverity: "HIGH"})
+	assert.NoError(t, err)
+	pub := &Publisher{severityFilter: filter}
+	alert := testTxNotification("1").SignedAlert.Alert
+	alert.Finding.Severity = protocol.Finding_MEDIUM
+	assert.False(t, pub.severityFilter.ShouldPublish(alert))
+
+	var cfg config.Config
+	cfg.Publish.Filter.MinSeverity = "MEDIUM"
+	assert.NoError(t, pub.ReloadConfig(cfg))
+	assert.True(t, pub.severityFilter.ShouldPublish(alert))
+
+	// keeps the current filter if the new one is invalid
+	cfg.Publish.Filter.MinSeverity = "SEVERE"
+	assert.Error(t, pub.ReloadConfig(cfg))
+	assert.True(t, pub.severityFilter.ShouldPublish(alert))
+}
+
+func TestPrepareLatestBatch_Deduplicate(t *testing.T) {
+	pub := &Publisher{
+		batchInterval: time.Millisecond * 100,
+		batchLimit:    100,
+		findingDedup:  newFindingDeduplicator(),
+		notifCh:       make(chan *protocol.NotifyRequest, 10),
+		batchCh:       make(chan *protocol.AlertBatch, 1),
+	}
+	// the same finding for the same tx, e.g. from two trace chunks
+	pub.notifCh <- testTxNotification("1")
+	pub.notifCh <- testTxNotification("2")
+	otherTx := testTxNotification("3")
+	otherTx.EvalTxRequest.Event.Transaction.Hash = "0x2"
+	pub.notifCh <- otherTx
+
+	deduplicated := metrics.CounterTotal(metrics.FindingsDeduplicated)
+	pub.prepareLatestBatch()
+	batch := <-pub.batchCh
+	assert.Equal(t, uint32(2), batch.AlertCount)
+	assert.Equal(t, deduplicated+1, metrics.CounterTotal(metrics.FindingsDeduplicated))
+}
@@ -0,0 +1,110 @@
+package publisher
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+)
+
+// alertQuota limits how many alerts each agent can publish per hour and per day.
+// The windows are aligned to the UTC hours and days.
+type alertQuota struct {
+	defaultLimits config.AlertQuotaLimits
+	agentLimits   map[string]config.AlertQuotaLimits
+	agents        map[string]*agentQuotaState
+	now           func() time.Time
+}
+
+type agentQuotaState struct {
+	hourStart  time.Time
+	dayStart   time.Time
+	hourCount  int
+	dayCount   int
+	suppressed int
+}
+
+func newAlertQuota(cfg config.AlertQuotaConfig) *alertQuota {
+	quota := &alertQuota{
+		defaultLimits: cfg.Default,
+		agentLimits:   make(map[string]config.AlertQuotaLimits),
+		agents:        make(map[string]*agentQuotaState),
+		now:           time.Now,
+	}
+	for agentID, limits := range cfg.Agents {
+		quota.agentLimits[strings.ToLower(agentID)] = limits
+	}
+	return quota
+}
+
+func (quota *alertQuota) limits(agentID string) config.AlertQuotaLimits {
+	if limits, ok := quota.agentLimits[agentID]; ok {
+		return limits
+	}
+	return quota.defaultLimits
+}
+
+// Allow counts the alert and tells if the agent is still within the quota. The alerts
+// which exceed the quota are counted as suppressed.
+func (quota *alertQuota) Allow(alert *protocol.Alert) bool {
+	if quota == nil || alert.Agent == nil {
+		return true
+	}
+	agentID := strings.ToLower(alert.Agent.Id)
+	limits := quota.limits(agentID)
+	if limits.MaxPerHour == 0 && limits.MaxPerDay == 0 {
+		return true
+	}
+
+	now := quota.now().UTC()
+	hourStart := now.Truncate(time.Hour)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	state, ok := quota.agents[agentID]
+	if !ok {
+		state = &agentQuotaState{}
+		quota.agents[agentID] = state
+	}
+	if !state.hourStart.Equal(hourStart) {
+		state.hourStart = hourStart
+		state.hourCount = 0
+	}
+	if !state.dayStart.Equal(dayStart) {
+		state.dayStart = dayStart
+		state.dayCount = 0
+	}
+
+	if (limits.MaxPerHour > 0 && state.hourCount >= limits.MaxPerHour) ||
+		(limits.MaxPerDay > 0 && state.dayCount >= limits.MaxPerDay) {
+		state.suppressed++
+		return false
+	}
+	state.hourCount++
+	state.dayCount++
+	return true
+}
+
+type suppressedAlerts struct {
+	AgentID string
+	Count   int
+}
+
+// TakeSuppressed returns the number of suppressed alerts per agent since the last call.
+func (quota *alertQuota) TakeSuppressed() []suppressedAlerts {
+	if quota == nil {
+		return nil
+	}
+	var result []suppressedAlerts
+	for agentID, state := range quota.agents {
+		if state.suppressed == 0 {
+			continue
+		}
+		result = append(result, suppressedAlerts{AgentID: agentID, Count: state.suppressed})
+		state.suppressed = 0
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].AgentID < result[j].AgentID
+	})
+	return result
+}
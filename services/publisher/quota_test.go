@@ -0,0 +1,45 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertQuota(t *testing.T) {
+	r := require.New(t)
+
+	quota := newAlertQuota(config.AlertQuotaConfig{
+		Default: config.AlertQuotaLimits{MaxPerHour: 2, MaxPerDay: 3},
+		Agents: map[string]config.AlertQuotaLimits{
+			"0xUNLIMITED": {},
+		},
+	})
+	now := time.Date(2022, 6, 1, 10, 30, 0, 0, time.UTC)
+	quota.now = func() time.Time { return now }
+
+	agentAlert := &protocol.Alert{Agent: &protocol.AgentInfo{Id: "0xagent"}}
+	unlimitedAlert := &protocol.Alert{Agent: &protocol.AgentInfo{Id: "0xunlimited"}}
+
+	r.True(quota.Allow(agentAlert))
+	r.True(quota.Allow(agentAlert))
+	r.False(quota.Allow(agentAlert))
+	for i := 0; i < 5; i++ {
+		r.True(quota.Allow(unlimitedAlert))
+	}
+
+	// next hour: hourly quota resets but the daily quota is almost full
+	now = now.Add(time.Hour)
+	r.True(quota.Allow(agentAlert))
+	r.False(quota.Allow(agentAlert))
+
+	r.Equal([]suppressedAlerts{{AgentID: "0xagent", Count: 2}}, quota.TakeSuppressed())
+	r.Empty(quota.TakeSuppressed())
+
+	// next day
+	now = now.Add(time.Hour * 24)
+	r.True(quota.Allow(agentAlert))
+}
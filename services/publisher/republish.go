@@ -0,0 +1,181 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/forta-network/forta-node/store"
+	log "github.com/sirupsen/logrus"
+)
+
+// RepublishRequest selects the locally stored alerts to republish.
+type RepublishRequest struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	FromBlock uint64    `json:"fromBlock,omitempty"`
+	ToBlock   uint64    `json:"toBlock,omitempty"`
+}
+
+// RepublishResult summarizes the republished alerts.
+type RepublishResult struct {
+	Alerts  int `json:"alerts"`
+	Batches int `json:"batches"`
+}
+
+func (req *RepublishRequest) matchesBlock(alert *protocol.Alert) bool {
+	if req.FromBlock == 0 && req.ToBlock == 0 {
+		return true
+	}
+	blockNumber, err := strconv.ParseUint(alert.Tags["blockNumber"], 10, 64)
+	if err != nil {
+		return false
+	}
+	if req.FromBlock > 0 && blockNumber < req.FromBlock {
+		return false
+	}
+	if req.ToBlock > 0 && blockNumber > req.ToBlock {
+		return false
+	}
+	return true
+}
+
+// RepublishAlerts reads the alerts in the given range from the local alert store and
+// publishes them again in new batches. This is useful for recovering the alerts which
+// were lost because of a misconfiguration or an outage.
+func (pub *Publisher) RepublishAlerts(req *RepublishRequest) (*RepublishResult, error) {
+	if pub.alertStore == nil {
+		return nil, errors.New("local alert store is disabled")
+	}
+	if !req.To.IsZero() && req.To.Before(req.From) {
+		return nil, errors.New("the end of the time range is before the start")
+	}
+	if req.ToBlock > 0 && req.ToBlock < req.FromBlock {
+		return nil, errors.New("the end of the block range is before the start")
+	}
+
+	alerts, err := pub.collectStoredAlerts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RepublishResult{}
+	for len(alerts) > 0 {
+		n := len(alerts)
+		if n > pub.batchLimit {
+			n = pub.batchLimit
+		}
+		batch, err := pub.makeRepublishBatch(alerts[:n])
+		if err != nil {
+			return nil, err
+		}
+		alerts = alerts[n:]
+		if batch.AlertCount == 0 {
+			continue
+		}
+		select {
+		case pub.batchCh <- batch:
+		case <-pub.ctx.Done():
+			return nil, pub.ctx.Err()
+		}
+		result.Alerts += int(batch.AlertCount)
+		result.Batches++
+	}
+
+	log.WithFields(log.Fields{
+		"alerts":  result.Alerts,
+		"batches": result.Batches,
+	}).Info("republishing stored alerts")
+	return result, nil
+}
+
+// collectStoredAlerts returns the matching alerts from oldest to newest.
+func (pub *Publisher) collectStoredAlerts(req *RepublishRequest) ([]*protocol.Alert, error) {
+	query := &store.AlertQuery{
+		From:  req.From,
+		To:    req.To,
+		Limit: store.MaxAlertQueryLimit,
+		Filter: func(alert *protocol.Alert) bool {
+			return req.matchesBlock(alert) && pub.severityFilter.ShouldPublish(alert)
+		},
+	}
+	var alerts []*protocol.Alert
+	for {
+		page, err := pub.alertStore.Query(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the stored alerts: %v", err)
+		}
+		alerts = append(alerts, page.Alerts...)
+		if len(page.NextCursor) == 0 {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	// the store returns the newest first
+	for i, j := 0, len(alerts)-1; i < j; i, j = i+1, j-1 {
+		alerts[i], alerts[j] = alerts[j], alerts[i]
+	}
+	return alerts, nil
+}
+
+// makeRepublishBatch signs the stored alerts again and puts them in a new batch by using the
+// block and transaction info from the alert tags.
+func (pub *Publisher) makeRepublishBatch(alerts []*protocol.Alert) (*protocol.AlertBatch, error) {
+	batch := (*BatchData)(&protocol.AlertBatch{ChainId: uint64(pub.cfg.ChainID)})
+	for _, alert := range alerts {
+		if alert.Agent == nil {
+			continue
+		}
+		signedAlert, err := signer.SignAlert(pub.cfg.Signer, alert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign alert %s: %v", alert.Id, err)
+		}
+		blockNum, err := strconv.ParseUint(alert.Tags["blockNumber"], 10, 64)
+		if err != nil || blockNum == 0 {
+			log.WithField("alert", alert.Id).Warn("stored alert has no block number - skipping")
+			continue
+		}
+
+		notif := &protocol.NotifyRequest{
+			SignedAlert: signedAlert,
+			AgentInfo:   alert.Agent,
+		}
+		blockHash := alert.Tags["blockHash"]
+		if txHash := alert.Tags["txHash"]; len(txHash) > 0 {
+			notif.EvalTxRequest = &protocol.EvaluateTxRequest{
+				Event: &protocol.TransactionEvent{
+					Transaction: &protocol.TransactionEvent_EthTransaction{Hash: txHash},
+					Receipt:     &protocol.TransactionEvent_EthReceipt{TransactionHash: txHash},
+					Block: &protocol.TransactionEvent_EthBlock{
+						BlockHash:   blockHash,
+						BlockNumber: hexutil.EncodeUint64(blockNum),
+					},
+				},
+			}
+		} else {
+			notif.EvalBlockRequest = &protocol.EvaluateBlockRequest{
+				Event: &protocol.BlockEvent{
+					BlockHash:   blockHash,
+					BlockNumber: hexutil.EncodeUint64(blockNum),
+					Block:       &protocol.BlockEvent_EthBlock{},
+				},
+			}
+		}
+
+		if batch.BlockStart == 0 || blockNum < batch.BlockStart {
+			batch.BlockStart = blockNum
+		}
+		if blockNum > batch.BlockEnd {
+			batch.BlockEnd = blockNum
+		}
+		if alert.Finding != nil && alert.Finding.Severity > batch.MaxSeverity {
+			batch.MaxSeverity = alert.Finding.Severity
+		}
+		batch.AppendAlert(notif)
+	}
+	return (*protocol.AlertBatch)(batch), nil
+}
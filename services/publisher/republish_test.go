@@ -0,0 +1,83 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/forta-network/forta-node/store"
+	"github.com/stretchr/testify/require"
+)
+
+func testStoredAlert(id string, blockNumber, txHash string, ts time.Time) *protocol.Alert {
+	tags := map[string]string{
+		"chainId":     "1",
+		"blockHash":   "0xblock" + blockNumber,
+		"blockNumber": blockNumber,
+	}
+	if len(txHash) > 0 {
+		tags["txHash"] = txHash
+	}
+	return &protocol.Alert{
+		Id:        id,
+		Timestamp: ts.Format(time.RFC3339Nano),
+		Agent:     &protocol.AgentInfo{Id: "0xagent", Manifest: "manifest"},
+		Finding:   &protocol.Finding{Severity: protocol.Finding_HIGH},
+		Tags:      tags,
+	}
+}
+
+func TestRepublishAlerts(t *testing.T) {
+	r := require.New(t)
+
+	alertStore, err := store.NewAlertStore("", time.Hour)
+	r.NoError(err)
+	defer alertStore.Close()
+
+	start := time.Now().UTC()
+	r.NoError(alertStore.Put(testStoredAlert("0x1", "10", "0xtx1", start)))
+	r.NoError(alertStore.Put(testStoredAlert("0x2", "11", "", start.Add(time.Second))))
+	r.NoError(alertStore.Put(testStoredAlert("0x3", "12", "0xtx3", start.Add(time.Second*2))))
+	r.NoError(alertStore.Put(testStoredAlert("0x4", "20", "0xtx4", start.Add(time.Second*3))))
+
+	key := testKey(t)
+	pub := &Publisher{
+		ctx:        context.Background(),
+		cfg:        PublisherConfig{ChainID: 1, Signer: signer.NewKeystoreSigner(key)},
+		alertStore: alertStore,
+		batchLimit: 2,
+		batchCh:    make(chan *protocol.AlertBatch, 10),
+	}
+
+	result, err := pub.RepublishAlerts(&RepublishRequest{From: start, ToBlock: 15})
+	r.NoError(err)
+	r.Equal(3, result.Alerts)
+	r.Equal(2, result.Batches)
+	r.Len(pub.batchCh, 2)
+
+	batch := <-pub.batchCh
+	r.EqualValues(2, batch.AlertCount)
+	r.EqualValues(10, batch.BlockStart)
+	r.EqualValues(11, batch.BlockEnd)
+	r.Equal(protocol.Finding_HIGH, batch.MaxSeverity)
+	r.Len(batch.Results, 2)
+	r.Len(batch.Results[0].Transactions, 1)
+	r.Equal("0xtx1", batch.Results[0].Transactions[0].Transaction.Transaction.Hash)
+	r.Len(batch.Results[1].Results, 1)
+
+	// the republished batch should be valid
+	signedBatch, err := security.SignBatch(key, batch)
+	r.NoError(err)
+	_, err = VerifyBatch(signedBatch, key.Address.Hex())
+	r.NoError(err)
+
+	batch = <-pub.batchCh
+	r.EqualValues(1, batch.AlertCount)
+	r.EqualValues(12, batch.BlockStart)
+
+	_, err = pub.RepublishAlerts(&RepublishRequest{From: start, To: start.Add(-time.Second)})
+	r.Error(err)
+}
@@ -0,0 +1,372 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	elasticsearchQueueSize      = 100
+	elasticsearchRequestTimeout = time.Second * 30
+
+	indicesRetryMinInterval = time.Second
+	indicesRetryMaxInterval = time.Minute
+)
+
+var errElasticsearchSinkClosed = errors.New("elasticsearch sink is closed")
+
+// alertIndexMapping is compatible with both Elasticsearch 7+ and OpenSearch. The metadata is
+// indexed as key-value pairs since the keys are arbitrary and can contain dots.
+const alertIndexMapping = `{
+	"mappings": {
+		"properties": {
+			"@timestamp": {"type": "date"},
+			"hash": {"type": "keyword"},
+			"alertId": {"type": "keyword"},
+			"name": {"type": "text", "fields": {"keyword": {"type": "keyword", "ignore_above": 256}}},
+			"description": {"type": "text"},
+			"severity": {"type": "keyword"},
+			"findingType": {"type": "keyword"},
+			"protocol": {"type": "keyword"},
+			"addresses": {"type": "keyword"},
+			"metadata": {
+				"type": "nested",
+				"properties": {
+					"key": {"type": "keyword"},
+					"value": {"type": "keyword", "ignore_above": 1024}
+				}
+			},
+			"agentId": {"type": "keyword"},
+			"agentImage": {"type": "keyword"},
+			"scanner": {"type": "keyword"},
+			"chainId": {"type": "long"},
+			"blockNumber": {"type": "long"},
+			"blockHash": {"type": "keyword"},
+			"txHash": {"type": "keyword"}
+		}
+	}
+}`
+
+const batchIndexMapping = `{
+	"mappings": {
+		"properties": {
+			"publishedAt": {"type": "date"},
+			"ref": {"type": "keyword"},
+			"storageRef": {"type": "keyword"},
+			"scanner": {"type": "keyword"},
+			"chainId": {"type": "long"},
+			"blockStart": {"type": "long"},
+			"blockEnd": {"type": "long"},
+			"alertCount": {"type": "long"},
+			"maxSeverity": {"type": "keyword"},
+			"receiptId": {"type": "keyword"}
+		}
+	}
+}`
+
+// ElasticsearchSink indexes the findings and the batch references in Elasticsearch or OpenSearch.
+type ElasticsearchSink struct {
+	ctx    context.Context
+	cfg    config.ElasticsearchSinkConfig
+	client *http.Client
+
+	queue   chan []byte
+	closing chan struct{}
+	closed  bool
+	mu      sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+// AlertDocument is the indexed form of an alert.
+type AlertDocument struct {
+	Timestamp   string          `json:"@timestamp"`
+	Hash        string          `json:"hash"`
+	AlertID     string          `json:"alertId"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Severity    string          `json:"severity"`
+	FindingType string          `json:"findingType"`
+	Protocol    string          `json:"protocol"`
+	Addresses   []string        `json:"addresses,omitempty"`
+	Metadata    []MetadataEntry `json:"metadata,omitempty"`
+	AgentID     string          `json:"agentId,omitempty"`
+	AgentImage  string          `json:"agentImage,omitempty"`
+	Scanner     string          `json:"scanner,omitempty"`
+	ChainID     *uint64         `json:"chainId,omitempty"`
+	BlockNumber *uint64         `json:"blockNumber,omitempty"`
+	BlockHash   string          `json:"blockHash,omitempty"`
+	TxHash      string          `json:"txHash,omitempty"`
+}
+
+// MetadataEntry is a key-value pair from the finding or the alert metadata.
+type MetadataEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NewAlertDocument converts the alert to a document.
+func NewAlertDocument(alert *protocol.Alert) *AlertDocument {
+	doc := &AlertDocument{
+		Timestamp: alert.Timestamp,
+		Hash:      alert.Id,
+		BlockHash: alert.Tags["blockHash"],
+		TxHash:    alert.Tags["txHash"],
+	}
+	if finding := alert.Finding; finding != nil {
+		doc.AlertID = finding.AlertId
+		doc.Name = finding.Name
+		doc.Description = finding.Description
+		doc.Severity = finding.Severity.String()
+		doc.FindingType = finding.Type.String()
+		doc.Protocol = finding.Protocol
+		doc.Addresses = finding.Addresses
+		doc.Metadata = appendMetadata(doc.Metadata, finding.Metadata)
+	}
+	doc.Metadata = appendMetadata(doc.Metadata, alert.Metadata)
+	if alert.Agent != nil {
+		doc.AgentID = alert.Agent.Id
+		doc.AgentImage = alert.Agent.Image
+	}
+	if alert.Scanner != nil {
+		doc.Scanner = alert.Scanner.Address
+	}
+	if n, err := strconv.ParseUint(alert.Tags["chainId"], 10, 64); err == nil {
+		doc.ChainID = &n
+	}
+	if n, err := strconv.ParseUint(alert.Tags["blockNumber"], 10, 64); err == nil {
+		doc.BlockNumber = &n
+	}
+	return doc
+}
+
+func appendMetadata(entries []MetadataEntry, metadata map[string]string) []MetadataEntry {
+	for k, v := range metadata {
+		entries = append(entries, MetadataEntry{Key: k, Value: v})
+	}
+	return entries
+}
+
+// NewElasticsearchSink creates a new sink which sends the bulk requests in the background.
+func NewElasticsearchSink(ctx context.Context, cfg config.ElasticsearchSinkConfig) (*ElasticsearchSink, error) {
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	sink := &ElasticsearchSink{
+		ctx: ctx,
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   elasticsearchRequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		queue:   make(chan []byte, elasticsearchQueueSize),
+		closing: make(chan struct{}),
+	}
+	sink.cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	sink.wg.Add(1)
+	go sink.sendBulkRequests()
+	return sink, nil
+}
+
+// Name returns the sink name.
+func (sink *ElasticsearchSink) Name() string {
+	return "elasticsearch"
+}
+
+// WriteAlerts queues the alerts to be indexed by using the alert hashes as the document IDs.
+func (sink *ElasticsearchSink) WriteAlerts(ctx context.Context, alerts []*protocol.Alert) error {
+	var buf bytes.Buffer
+	for _, alert := range alerts {
+		if err := writeBulkIndex(&buf, sink.cfg.AlertIndex, alert.Id, NewAlertDocument(alert)); err != nil {
+			return err
+		}
+	}
+	return sink.enqueue(buf.Bytes())
+}
+
+// WriteBatchRef queues the batch reference to be indexed.
+func (sink *ElasticsearchSink) WriteBatchRef(ctx context.Context, ref *BatchRef) error {
+	var buf bytes.Buffer
+	if err := writeBulkIndex(&buf, sink.cfg.BatchIndex, ref.Ref, ref); err != nil {
+		return err
+	}
+	return sink.enqueue(buf.Bytes())
+}
+
+// Close stops accepting new documents and waits until the queued ones are sent. The queued
+// documents are dropped if the indices could not be created until then.
+func (sink *ElasticsearchSink) Close() error {
+	sink.mu.Lock()
+	if sink.closed {
+		sink.mu.Unlock()
+		return nil
+	}
+	sink.closed = true
+	close(sink.closing)
+	close(sink.queue)
+	sink.mu.Unlock()
+
+	sink.wg.Wait()
+	return nil
+}
+
+func writeBulkIndex(buf *bytes.Buffer, index, id string, doc interface{}) error {
+	action := map[string]map[string]string{"index": {"_index": index, "_id": id}}
+	b, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	buf.WriteByte('\n')
+	if b, err = json.Marshal(doc); err != nil {
+		return fmt.Errorf("failed to encode the document: %v", err)
+	}
+	buf.Write(b)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// enqueue never blocks the publisher: the documents are dropped if the queue is full or
+// the sink is closed.
+func (sink *ElasticsearchSink) enqueue(body []byte) error {
+	sink.mu.RLock()
+	defer sink.mu.RUnlock()
+	if sink.closed {
+		return errElasticsearchSinkClosed
+	}
+	select {
+	case sink.queue <- body:
+		return nil
+	default:
+		return errors.New("elasticsearch queue is full - dropping documents")
+	}
+}
+
+func (sink *ElasticsearchSink) sendBulkRequests() {
+	defer sink.wg.Done()
+	var indicesCreated bool
+	for body := range sink.queue {
+		// the documents are not indexed before the indices exist, otherwise the indices
+		// would be created with the dynamic mappings
+		if !indicesCreated {
+			if indicesCreated = sink.ensureIndices(); !indicesCreated {
+				continue
+			}
+		}
+		if err := sink.bulk(body); err != nil {
+			log.WithError(err).Warn("failed to index documents in elasticsearch")
+		}
+	}
+}
+
+// ensureIndices retries creating the indices with a backoff until it succeeds. It returns false
+// if the sink is closed or the context is done before that.
+func (sink *ElasticsearchSink) ensureIndices() bool {
+	interval := indicesRetryMinInterval
+	for {
+		err := sink.createIndices()
+		if err == nil {
+			return true
+		}
+		log.WithError(err).Warn("failed to create the elasticsearch indices - retrying")
+		select {
+		case <-time.After(interval):
+		case <-sink.closing:
+			return false
+		case <-sink.ctx.Done():
+			return false
+		}
+		if interval *= 2; interval > indicesRetryMaxInterval {
+			interval = indicesRetryMaxInterval
+		}
+	}
+}
+
+// createIndices creates the indices with the mappings unless they already exist.
+func (sink *ElasticsearchSink) createIndices() error {
+	for index, mapping := range map[string]string{
+		sink.cfg.AlertIndex: alertIndexMapping,
+		sink.cfg.BatchIndex: batchIndexMapping,
+	} {
+		resp, err := sink.do(http.MethodPut, "/"+index, "application/json", []byte(mapping))
+		if err != nil {
+			return err
+		}
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode >= 300 && !strings.Contains(string(body), "resource_already_exists_exception") {
+			return fmt.Errorf("failed to create index %s: status code %d: %s", index, resp.StatusCode, string(body))
+		}
+	}
+	return nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Error *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (sink *ElasticsearchSink) bulk(body []byte) error {
+	resp, err := sink.do(http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("bulk request failed: status code %d: %s", resp.StatusCode, string(b))
+	}
+	var bulkResp bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bulkResp); err != nil {
+		return fmt.Errorf("failed to decode the bulk response: %v", err)
+	}
+	if !bulkResp.Errors {
+		return nil
+	}
+	for _, item := range bulkResp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				return fmt.Errorf("failed to index document: %s: %s", result.Error.Type, result.Error.Reason)
+			}
+		}
+	}
+	return errors.New("bulk request had errors")
+}
+
+func (sink *ElasticsearchSink) do(method, path, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(sink.ctx, method, sink.cfg.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case len(sink.cfg.APIKey) > 0:
+		req.Header.Set("Authorization", "ApiKey "+sink.cfg.APIKey)
+	case len(sink.cfg.Username) > 0:
+		req.SetBasicAuth(sink.cfg.Username, sink.cfg.Password)
+	}
+	resp, err := sink.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	return resp, nil
+}
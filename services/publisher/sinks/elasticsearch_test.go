@@ -0,0 +1,169 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchSink(t *testing.T) {
+	r := require.New(t)
+
+	var (
+		mu      sync.Mutex
+		indices []string
+		bulks   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		r.Equal("ApiKey secret", req.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(req.Body)
+		switch {
+		case req.Method == http.MethodPut:
+			indices = append(indices, req.URL.Path)
+			if req.URL.Path == "/forta-batches" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":{"type":"resource_already_exists_exception"}}`))
+				return
+			}
+			w.Write([]byte(`{"acknowledged":true}`))
+		case req.URL.Path == "/_bulk":
+			r.Equal("application/x-ndjson", req.Header.Get("Content-Type"))
+			bulks = append(bulks, string(body))
+			w.Write([]byte(`{"errors":false,"items":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewElasticsearchSink(context.Background(), config.ElasticsearchSinkConfig{
+		Enable:     true,
+		URL:        server.URL + "/",
+		AlertIndex: "forta-alerts",
+		BatchIndex: "forta-batches",
+		APIKey:     "secret",
+	})
+	r.NoError(err)
+
+	r.NoError(sink.WriteAlerts(context.Background(), []*protocol.Alert{
+		{
+			Id:        "0x1",
+			Timestamp: "2022-01-01T00:00:00Z",
+			Finding: &protocol.Finding{
+				AlertId:  "ALERT-1",
+				Severity: protocol.Finding_HIGH,
+				Metadata: map[string]string{"a.b": "c"},
+			},
+			Agent: &protocol.AgentInfo{Id: "0xagent"},
+			Tags:  map[string]string{"chainId": "1", "blockNumber": "123"},
+		},
+	}))
+	r.NoError(sink.WriteBatchRef(context.Background(), &BatchRef{Ref: "ref", FormatVersion: 1, AlertCount: 1}))
+	r.NoError(sink.Close())
+
+	r.ElementsMatch([]string{"/forta-alerts", "/forta-batches"}, indices)
+	r.Len(bulks, 2)
+
+	scanner := bufio.NewScanner(strings.NewReader(bulks[0]))
+	r.True(scanner.Scan())
+	r.JSONEq(`{"index":{"_index":"forta-alerts","_id":"0x1"}}`, scanner.Text())
+	r.True(scanner.Scan())
+	var doc AlertDocument
+	r.NoError(json.Unmarshal(scanner.Bytes(), &doc))
+	r.Equal("ALERT-1", doc.AlertID)
+	r.Equal("HIGH", doc.Severity)
+	r.Equal("0xagent", doc.AgentID)
+	r.Equal(uint64(1), *doc.ChainID)
+	r.Equal(uint64(123), *doc.BlockNumber)
+	r.Equal([]MetadataEntry{{Key: "a.b", Value: "c"}}, doc.Metadata)
+
+	r.True(strings.HasPrefix(bulks[1], `{"index":{"_id":"ref","_index":"forta-batches"}}`))
+	r.Contains(bulks[1], `"formatVersion":1`)
+}
+
+func TestElasticsearchSink_BulkErrors(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`))
+	}))
+	defer server.Close()
+
+	sink, err := NewElasticsearchSink(context.Background(), config.ElasticsearchSinkConfig{URL: server.URL})
+	r.NoError(err)
+	defer sink.Close()
+
+	err = sink.bulk([]byte("{}\n"))
+	r.Error(err)
+	r.Contains(err.Error(), "mapper_parsing_exception")
+}
+
+func TestElasticsearchSink_WriteAfterClose(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	sink, err := NewElasticsearchSink(context.Background(), config.ElasticsearchSinkConfig{URL: server.URL})
+	r.NoError(err)
+	r.NoError(sink.Close())
+	r.NoError(sink.Close())
+
+	// does not panic after the queue is closed
+	r.ErrorIs(sink.WriteBatchRef(context.Background(), &BatchRef{Ref: "ref"}), errElasticsearchSinkClosed)
+}
+
+func TestElasticsearchSink_RetryIndices(t *testing.T) {
+	r := require.New(t)
+
+	var (
+		mu          sync.Mutex
+		indexFailed bool
+		bulks       int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case req.Method == http.MethodPut && !indexFailed:
+			indexFailed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case req.Method == http.MethodPut:
+			w.Write([]byte(`{"acknowledged":true}`))
+		default:
+			// the documents are not indexed before the indices are created
+			r.True(indexFailed)
+			bulks++
+			w.Write([]byte(`{"errors":false,"items":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewElasticsearchSink(context.Background(), config.ElasticsearchSinkConfig{
+		URL:        server.URL,
+		AlertIndex: "forta-alerts",
+		BatchIndex: "forta-batches",
+	})
+	r.NoError(err)
+	r.NoError(sink.WriteBatchRef(context.Background(), &BatchRef{Ref: "ref"}))
+
+	r.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return bulks == 1
+	}, time.Second*5, time.Millisecond*10)
+	r.NoError(sink.Close())
+}
@@ -0,0 +1,107 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	log "github.com/sirupsen/logrus"
+)
+
+// SASL mechanisms
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// KafkaSink writes the findings and the batch references to Kafka topics.
+type KafkaSink struct {
+	cfg    config.KafkaSinkConfig
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a new Kafka sink. The messages are written asynchronously.
+func NewKafkaSink(cfg config.KafkaSinkConfig) (*KafkaSink, error) {
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := newSASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaSink{
+		cfg: cfg,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Balancer:     &kafka.Hash{},
+			BatchTimeout: time.Second,
+			RequiredAcks: kafka.RequireOne,
+			Async:        true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					log.WithError(err).WithField("messages", len(messages)).Warn("failed to write messages to kafka")
+				}
+			},
+			Transport: &kafka.Transport{
+				TLS:  tlsConfig,
+				SASL: mechanism,
+			},
+		},
+	}, nil
+}
+
+func newSASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Mechanism {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unknown sasl mechanism: %s", cfg.Mechanism)
+	}
+}
+
+// Name returns the sink name.
+func (sink *KafkaSink) Name() string {
+	return "kafka"
+}
+
+// WriteAlerts writes the alerts to the alert topic by using the alert hashes as the keys.
+func (sink *KafkaSink) WriteAlerts(ctx context.Context, alerts []*protocol.Alert) error {
+	messages := make([]kafka.Message, 0, len(alerts))
+	for _, alert := range alerts {
+		b, err := json.Marshal(alert)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert: %v", err)
+		}
+		messages = append(messages, kafka.Message{Topic: sink.cfg.AlertTopic, Key: []byte(alert.Id), Value: b})
+	}
+	return sink.writer.WriteMessages(ctx, messages...)
+}
+
+// WriteBatchRef writes the batch reference to the batch topic.
+func (sink *KafkaSink) WriteBatchRef(ctx context.Context, ref *BatchRef) error {
+	b, err := json.Marshal(ref)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch ref: %v", err)
+	}
+	return sink.writer.WriteMessages(ctx, kafka.Message{Topic: sink.cfg.BatchTopic, Key: []byte(ref.Ref), Value: b})
+}
+
+// Close flushes the pending messages and closes the writer.
+func (sink *KafkaSink) Close() error {
+	return sink.writer.Close()
+}
@@ -0,0 +1,88 @@
+package sinks
+
+import (
+	"context"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// BatchRef describes a published batch.
+type BatchRef struct {
+	Ref           string    `json:"ref"`
+	StorageRef    string    `json:"storageRef,omitempty"`
+	FormatVersion uint8     `json:"formatVersion"`
+	Scanner       string    `json:"scanner"`
+	ChainID       uint64    `json:"chainId"`
+	BlockStart    uint64    `json:"blockStart"`
+	BlockEnd      uint64    `json:"blockEnd"`
+	AlertCount    uint32    `json:"alertCount"`
+	MaxSeverity   string    `json:"maxSeverity"`
+	ReceiptID     string    `json:"receiptId,omitempty"`
+	PublishedAt   time.Time `json:"publishedAt"`
+}
+
+// Sink receives the published findings and batch references.
+type Sink interface {
+	Name() string
+	WriteAlerts(ctx context.Context, alerts []*protocol.Alert) error
+	WriteBatchRef(ctx context.Context, ref *BatchRef) error
+	Close() error
+}
+
+// Sinks writes to all of the configured sinks.
+type Sinks []Sink
+
+// New creates the configured sinks.
+func New(ctx context.Context, cfg config.SinksConfig) (Sinks, error) {
+	var sinks Sinks
+	if cfg.Kafka.Enable {
+		sink, err := NewKafkaSink(cfg.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.Elasticsearch.Enable {
+		sink, err := NewElasticsearchSink(ctx, cfg.Elasticsearch)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// WriteAlerts writes the alerts to all of the sinks. The sink errors are only logged
+// since the sinks are not critical for publishing.
+func (sinks Sinks) WriteAlerts(ctx context.Context, alerts []*protocol.Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+	for _, sink := range sinks {
+		if err := sink.WriteAlerts(ctx, alerts); err != nil {
+			log.WithError(err).WithField("sink", sink.Name()).Warn("failed to write alerts to the sink")
+		}
+	}
+}
+
+// WriteBatchRef writes the batch reference to all of the sinks.
+func (sinks Sinks) WriteBatchRef(ctx context.Context, ref *BatchRef) {
+	for _, sink := range sinks {
+		if err := sink.WriteBatchRef(ctx, ref); err != nil {
+			log.WithError(err).WithField("sink", sink.Name()).Warn("failed to write the batch ref to the sink")
+		}
+	}
+}
+
+// Close closes all of the sinks.
+func (sinks Sinks) Close() error {
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			log.WithError(err).WithField("sink", sink.Name()).Warn("failed to close the sink")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testSink struct {
+	alerts []*protocol.Alert
+	refs   []*BatchRef
+	err    error
+}
+
+func (sink *testSink) Name() string {
+	return "test"
+}
+
+func (sink *testSink) WriteAlerts(ctx context.Context, alerts []*protocol.Alert) error {
+	sink.alerts = append(sink.alerts, alerts...)
+	return sink.err
+}
+
+func (sink *testSink) WriteBatchRef(ctx context.Context, ref *BatchRef) error {
+	sink.refs = append(sink.refs, ref)
+	return sink.err
+}
+
+func (sink *testSink) Close() error {
+	return sink.err
+}
+
+func TestSinks(t *testing.T) {
+	r := require.New(t)
+
+	failing := &testSink{err: errors.New("failed")}
+	working := &testSink{}
+	sinks := Sinks{failing, working}
+
+	sinks.WriteAlerts(context.Background(), []*protocol.Alert{{Id: "0x1"}})
+	sinks.WriteAlerts(context.Background(), nil)
+	sinks.WriteBatchRef(context.Background(), &BatchRef{Ref: "ref"})
+	r.NoError(sinks.Close())
+
+	// one failing sink should not affect the others
+	r.Len(working.alerts, 1)
+	r.Len(working.refs, 1)
+	r.Len(failing.refs, 1)
+
+	// nil sinks are no-op
+	var noSinks Sinks
+	noSinks.WriteAlerts(context.Background(), []*protocol.Alert{{Id: "0x1"}})
+	r.NoError(noSinks.Close())
+}
+
+func TestNew(t *testing.T) {
+	r := require.New(t)
+
+	sinks, err := New(context.Background(), config.SinksConfig{})
+	r.NoError(err)
+	r.Empty(sinks)
+
+	sinks, err = New(context.Background(), config.SinksConfig{
+		Kafka: config.KafkaSinkConfig{
+			Enable:  true,
+			Brokers: []string{"localhost:9092"},
+			SASL:    config.KafkaSASLConfig{Mechanism: SASLScramSHA512, Username: "user", Password: "pass"},
+			TLS:     config.SinkTLSConfig{Enable: true},
+		},
+	})
+	r.NoError(err)
+	r.Len(sinks, 1)
+	r.Equal("kafka", sinks[0].Name())
+	r.NoError(sinks.Close())
+
+	_, err = New(context.Background(), config.SinksConfig{
+		Kafka: config.KafkaSinkConfig{Enable: true, SASL: config.KafkaSASLConfig{Mechanism: "bogus"}},
+	})
+	r.Error(err)
+	_, err = New(context.Background(), config.SinksConfig{
+		Kafka: config.KafkaSinkConfig{Enable: true, TLS: config.SinkTLSConfig{Enable: true, CAFile: "/does/not/exist"}},
+	})
+	r.Error(err)
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/clients/awssig"
+	"github.com/forta-network/forta-node/config"
+)
+
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	defaultGCSRegion   = "auto"
+	requestTimeout     = time.Second * 30
+
+	signingService = "s3"
+)
+
+var objectExtensions = map[string]string{
+	EncodingGzip: ".gz",
+	EncodingZstd: ".zst",
+}
+
+// bucketStorage puts the batches to an S3 bucket or to a GCS bucket by using
+// the S3 compatible XML API of GCS with HMAC keys.
+type bucketStorage struct {
+	cfg      config.BatchStorageConfig
+	endpoint *url.URL
+	region   string
+	scheme   string
+	client   *http.Client
+	now      func() time.Time
+}
+
+func newBucketStorage(cfg config.BatchStorageConfig) (*bucketStorage, error) {
+	if len(cfg.Bucket) == 0 {
+		return nil, errors.New("bucket is required")
+	}
+	if len(cfg.AccessKeyID) == 0 || len(cfg.SecretAccessKey) == 0 {
+		return nil, errors.New("access key id and secret access key are required")
+	}
+	storage := &bucketStorage{
+		cfg:    cfg,
+		region: cfg.Region,
+		client: &http.Client{Timeout: requestTimeout},
+		now:    time.Now,
+	}
+	endpoint := cfg.Endpoint
+	switch cfg.Type {
+	case TypeGCS:
+		storage.scheme = "gs"
+		if len(endpoint) == 0 {
+			endpoint = defaultGCSEndpoint
+		}
+		if len(storage.region) == 0 {
+			storage.region = defaultGCSRegion
+		}
+	default:
+		storage.scheme = "s3"
+		if len(storage.region) == 0 {
+			return nil, errors.New("region is required")
+		}
+		if len(endpoint) == 0 {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", storage.region)
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %v", err)
+	}
+	storage.endpoint = u
+	return storage, nil
+}
+
+// Store puts the batch to the bucket by using the content hash as the object name.
+func (storage *bucketStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	return storage.storeEncoded(ctx, payload, "")
+}
+
+func (storage *bucketStorage) storeEncoded(ctx context.Context, payload []byte, encoding string) (string, error) {
+	hash := payloadHash(payload)
+	key := fmt.Sprintf("%s%s.json%s", storage.cfg.Prefix, hash, objectExtensions[encoding])
+
+	u := *storage.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + storage.cfg.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(encoding) > 0 {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	storage.sign(req, hash)
+
+	resp, err := storage.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to put the batch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("failed to put the batch: status code %d: %s", resp.StatusCode, string(body))
+	}
+	return fmt.Sprintf("%s://%s/%s", storage.scheme, storage.cfg.Bucket, key), nil
+}
+
+// sign signs the request with AWS Signature Version 4.
+func (storage *bucketStorage) sign(req *http.Request, payloadHash string) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	awssig.SignRequest(req, awssig.Credentials{
+		AccessKeyID:     storage.cfg.AccessKeyID,
+		SecretAccessKey: storage.cfg.SecretAccessKey,
+	}, storage.region, signingService, payloadHash, storage.now())
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// Content encodings
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+const refEncodingSep = "#encoding="
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrEncodingNotSupported is returned when the storage rejects the content encoding.
+var ErrEncodingNotSupported = errors.New("content encoding is not supported")
+
+// Compress compresses the payload with the encoding.
+func Compress(payload []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return payload, nil
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case EncodingZstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(payload, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", encoding)
+	}
+}
+
+// Decompress decompresses the payload which was compressed with the encoding.
+func Decompress(payload []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return payload, nil
+	case EncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case EncodingZstd:
+		r, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return r.DecodeAll(payload, nil)
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", encoding)
+	}
+}
+
+// DetectEncoding detects the encoding of the payload from the magic bytes.
+func DetectEncoding(payload []byte) string {
+	switch {
+	case bytes.HasPrefix(payload, gzipMagic):
+		return EncodingGzip
+	case bytes.HasPrefix(payload, zstdMagic):
+		return EncodingZstd
+	default:
+		return ""
+	}
+}
+
+// FormatRef records the content encoding in the batch reference.
+func FormatRef(ref, encoding string) string {
+	if len(encoding) == 0 {
+		return ref
+	}
+	return ref + refEncodingSep + encoding
+}
+
+// ParseRef splits the batch reference into the storage reference and the content encoding.
+func ParseRef(ref string) (string, string) {
+	i := strings.LastIndex(ref, refEncodingSep)
+	if i < 0 {
+		return ref, ""
+	}
+	return ref[:i], ref[i+len(refEncodingSep):]
+}
+
+// encodingStorage stores the payloads with a content encoding.
+type encodingStorage interface {
+	storeEncoded(ctx context.Context, payload []byte, encoding string) (string, error)
+}
+
+// compressedStorage compresses the batches before storing them. It falls back to storing
+// the batches uncompressed if the storage rejects the encoding.
+type compressedStorage struct {
+	storage  encodingStorage
+	encoding string
+}
+
+// Store compresses and stores the batch and records the encoding in the reference.
+func (storage *compressedStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	if len(storage.encoding) == 0 {
+		return storage.storage.storeEncoded(ctx, payload, "")
+	}
+	compressed, err := Compress(payload, storage.encoding)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress the batch: %v", err)
+	}
+	ref, err := storage.storage.storeEncoded(ctx, compressed, storage.encoding)
+	if errors.Is(err, ErrEncodingNotSupported) {
+		log.WithField("encoding", storage.encoding).Warn("batch storage does not support the encoding - disabling compression")
+		storage.encoding = ""
+		return storage.storage.storeEncoded(ctx, payload, "")
+	}
+	if err != nil {
+		return "", err
+	}
+	log.WithFields(log.Fields{
+		"encoding":       storage.encoding,
+		"size":           len(payload),
+		"compressedSize": len(compressed),
+	}).Debug("compressed the batch")
+	return FormatRef(ref, storage.encoding), nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	r := require.New(t)
+
+	for _, encoding := range []string{"", EncodingGzip, EncodingZstd} {
+		compressed, err := Compress(testPayload, encoding)
+		r.NoError(err)
+		r.Equal(encoding, DetectEncoding(compressed))
+		decompressed, err := Decompress(compressed, encoding)
+		r.NoError(err)
+		r.Equal(testPayload, decompressed)
+	}
+
+	_, err := Compress(testPayload, "br")
+	r.Error(err)
+}
+
+func TestParseRef(t *testing.T) {
+	r := require.New(t)
+
+	ref := FormatRef("s3://bucket/batch.json.zst", EncodingZstd)
+	r.Equal("s3://bucket/batch.json.zst#encoding=zstd", ref)
+	storageRef, encoding := ParseRef(ref)
+	r.Equal("s3://bucket/batch.json.zst", storageRef)
+	r.Equal(EncodingZstd, encoding)
+
+	storageRef, encoding = ParseRef("Qm123")
+	r.Equal("Qm123", storageRef)
+	r.Empty(encoding)
+}
+
+func TestCompressedStorage_Bucket(t *testing.T) {
+	r := require.New(t)
+
+	var req *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	storage, err := New(config.BatchStorageConfig{
+		Type:            TypeS3,
+		Region:          "us-east-1",
+		Bucket:          "bucket",
+		Endpoint:        server.URL,
+		AccessKeyID:     "key-id",
+		SecretAccessKey: "secret",
+		Compression:     EncodingGzip,
+	}, nil)
+	r.NoError(err)
+
+	ref, err := storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	hash := payloadHash(body)
+	r.Equal("s3://bucket/"+hash+".json.gz#encoding=gzip", ref)
+	r.Equal(EncodingGzip, req.Header.Get("Content-Encoding"))
+	decompressed, err := Decompress(body, EncodingGzip)
+	r.NoError(err)
+	r.Equal(testPayload, decompressed)
+}
+
+func TestCompressedStorage_HTTPSFallback(t *testing.T) {
+	r := require.New(t)
+
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, body)
+		if len(req.Header.Get("Content-Encoding")) > 0 {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer server.Close()
+
+	storage, err := New(config.BatchStorageConfig{
+		Type:        TypeHTTPS,
+		URL:         server.URL,
+		Compression: EncodingZstd,
+	}, nil)
+	r.NoError(err)
+
+	ref, err := storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	r.Equal(payloadHash(testPayload), ref)
+	r.Len(bodies, 2)
+	r.Equal(EncodingZstd, DetectEncoding(bodies[0]))
+	r.Equal(testPayload, bodies[1])
+
+	// should not try to compress again
+	_, err = storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	r.Len(bodies, 3)
+	r.Equal(testPayload, bodies[2])
+}
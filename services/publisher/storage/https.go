@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// BatchHashHeader carries the hex encoded SHA-256 hash of the batch posted to an HTTPS endpoint.
+const BatchHashHeader = "X-Forta-Batch-Hash"
+
+// httpsStorage posts the batches to an HTTPS endpoint. The endpoint can return the batch
+// reference in the Location header. Otherwise, the content hash is used as the reference.
+type httpsStorage struct {
+	cfg    config.BatchStorageConfig
+	client *http.Client
+}
+
+func newHTTPSStorage(cfg config.BatchStorageConfig) (*httpsStorage, error) {
+	if len(cfg.URL) == 0 {
+		return nil, fmt.Errorf("url is required")
+	}
+	return &httpsStorage{cfg: cfg, client: &http.Client{Timeout: requestTimeout}}, nil
+}
+
+// Store posts the batch to the endpoint.
+func (storage *httpsStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	return storage.storeEncoded(ctx, payload, "")
+}
+
+func (storage *httpsStorage) storeEncoded(ctx context.Context, payload []byte, encoding string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, storage.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the request: %v", err)
+	}
+	hash := payloadHash(payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(BatchHashHeader, hash)
+	if len(encoding) > 0 {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	for k, v := range storage.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := storage.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to post the batch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnsupportedMediaType && len(encoding) > 0 {
+		return "", ErrEncodingNotSupported
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("failed to post the batch: status code %d: %s", resp.StatusCode, string(body))
+	}
+	if location := resp.Header.Get("Location"); len(location) > 0 {
+		return location, nil
+	}
+	return hash, nil
+}
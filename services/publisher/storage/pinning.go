@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// pinRetryInterval is multiplied by the attempt number.
+var pinRetryInterval = time.Second * 2
+
+// pinningTarget uploads and pins the batches by using the IPFS HTTP API of a node or a service.
+type pinningTarget struct {
+	cfg    config.IPFSPinningTarget
+	client *http.Client
+
+	lastPin    health.TimeTracker
+	lastPinErr health.ErrorTracker
+}
+
+type ipfsAddResponse struct {
+	Hash string `json:"Hash"`
+}
+
+// add uploads the payload in the same way the batch CID is calculated.
+func (target *pinningTarget) add(ctx context.Context, payload []byte) (string, error) {
+	// the ipfs client adds a trailing newline before calculating the hash
+	if !bytes.HasSuffix(payload, []byte("\n")) {
+		payload = append(append([]byte{}, payload...), '\n')
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "batch")
+	if err != nil {
+		return "", err
+	}
+	part.Write(payload)
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(target.cfg.APIURL, "/") + "/api/v0/add?pin=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the request: %v", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if len(target.cfg.Username) > 0 {
+		req.SetBasicAuth(target.cfg.Username, target.cfg.Password)
+	}
+	for k, v := range target.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := target.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload the batch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("upload failed with status code %d: %s", resp.StatusCode, string(b))
+	}
+	var addResp ipfsAddResponse
+	if err := json.NewDecoder(resp.Body).Decode(&addResp); err != nil {
+		return "", fmt.Errorf("failed to decode the response: %v", err)
+	}
+	return addResp.Hash, nil
+}
+
+// pin tries to upload the batch until the attempts are exhausted and verifies the returned CID.
+func (target *pinningTarget) pin(ctx context.Context, payload []byte, expectedCid string, maxAttempts int) (err error) {
+	logger := log.WithFields(log.Fields{"target": target.cfg.Name, "ref": expectedCid})
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var cid string
+		cid, err = target.add(ctx, payload)
+		if err == nil && cid != expectedCid {
+			err = fmt.Errorf("target returned cid %s instead of %s", cid, expectedCid)
+		}
+		if err == nil {
+			break
+		}
+		logger.WithError(err).WithField("attempt", attempt).Warn("failed to pin the batch")
+		if attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pinRetryInterval * time.Duration(attempt)):
+		}
+	}
+	target.lastPinErr.Set(err)
+	if err != nil {
+		metrics.BatchPins.WithLabelValues(target.cfg.Name, "failure").Inc()
+		return err
+	}
+	target.lastPin.Set()
+	metrics.BatchPins.WithLabelValues(target.cfg.Name, "success").Inc()
+	return nil
+}
+
+// pinnedStorage uploads the batches to all of the pinning targets in addition to calculating
+// the CID so that the batches are reachable even if one of the pinning providers is lost.
+type pinnedStorage struct {
+	storage     BatchStorage
+	targets     []*pinningTarget
+	quorum      int
+	maxAttempts int
+}
+
+func newPinnedStorage(storage BatchStorage, cfg config.IPFSPinningConfig) (*pinnedStorage, error) {
+	quorum := cfg.Quorum
+	if quorum == 0 {
+		quorum = len(cfg.Targets)
+	}
+	if quorum > len(cfg.Targets) {
+		return nil, fmt.Errorf("pinning quorum %d is greater than the number of targets", quorum)
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+	pinned := &pinnedStorage{storage: storage, quorum: quorum, maxAttempts: maxAttempts}
+	names := make(map[string]bool)
+	for _, targetCfg := range cfg.Targets {
+		if names[targetCfg.Name] {
+			return nil, fmt.Errorf("duplicate pinning target name: %s", targetCfg.Name)
+		}
+		names[targetCfg.Name] = true
+		pinned.targets = append(pinned.targets, &pinningTarget{
+			cfg:    targetCfg,
+			client: &http.Client{Timeout: requestTimeout},
+		})
+	}
+	return pinned, nil
+}
+
+// Store uploads the batch to the targets in parallel and fails if the quorum is not reached.
+func (pinned *pinnedStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	ref, err := pinned.storage.Store(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		pinnedCount int
+		failed      []string
+	)
+	for _, target := range pinned.targets {
+		wg.Add(1)
+		go func(target *pinningTarget) {
+			defer wg.Done()
+			err := target.pin(ctx, payload, ref, pinned.maxAttempts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, target.cfg.Name)
+				return
+			}
+			pinnedCount++
+		}(target)
+	}
+	wg.Wait()
+
+	if pinnedCount < pinned.quorum {
+		return "", fmt.Errorf("batch is pinned by %d targets out of the required %d (failed: %s)", pinnedCount, pinned.quorum, strings.Join(failed, ", "))
+	}
+	if len(failed) > 0 {
+		log.WithFields(log.Fields{
+			"ref":    ref,
+			"failed": strings.Join(failed, ", "),
+		}).Warn("batch is not pinned by all targets")
+	}
+	return ref, nil
+}
+
+// Health returns the last pin time and error of every target.
+func (pinned *pinnedStorage) Health() health.Reports {
+	var reports health.Reports
+	for _, target := range pinned.targets {
+		reports = append(reports,
+			target.lastPin.GetReport(fmt.Sprintf("event.batch-pin.%s.time", target.cfg.Name)),
+			target.lastPinErr.GetReport(fmt.Sprintf("event.batch-pin.%s.error", target.cfg.Name)),
+		)
+	}
+	return reports
+}
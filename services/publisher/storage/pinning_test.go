@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+const testCid = "QmTest"
+
+type cidStorage struct{}
+
+func (cidStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	return testCid, nil
+}
+
+func newIPFSServer(t *testing.T, handle func(attempt int32) (int, string)) *httptest.Server {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/api/v0/add", req.URL.Path)
+		require.Equal(t, "true", req.URL.Query().Get("pin"))
+		file, _, err := req.FormFile("file")
+		require.NoError(t, err)
+		b, _ := ioutil.ReadAll(file)
+		require.Equal(t, string(testPayload)+"\n", string(b))
+
+		status, cid := handle(atomic.AddInt32(&attempts, 1))
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"Name":"batch","Hash":"%s","Size":"1"}`, cid)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPinnedStorage(t *testing.T) {
+	r := require.New(t)
+	pinRetryInterval = time.Millisecond
+
+	// succeeds after a retry
+	flaky := newIPFSServer(t, func(attempt int32) (int, string) {
+		if attempt == 1 {
+			return http.StatusInternalServerError, ""
+		}
+		return http.StatusOK, testCid
+	})
+	failing := newIPFSServer(t, func(attempt int32) (int, string) {
+		return http.StatusInternalServerError, ""
+	})
+	wrongCid := newIPFSServer(t, func(attempt int32) (int, string) {
+		return http.StatusOK, "QmOther"
+	})
+
+	targets := []config.IPFSPinningTarget{
+		{Name: "flaky", APIURL: flaky.URL},
+		{Name: "failing", APIURL: failing.URL},
+		{Name: "wrong-cid", APIURL: wrongCid.URL},
+	}
+
+	pinned, err := newPinnedStorage(cidStorage{}, config.IPFSPinningConfig{Targets: targets, Quorum: 1, MaxAttempts: 2})
+	r.NoError(err)
+	ref, err := pinned.Store(context.Background(), testPayload)
+	r.NoError(err)
+	r.Equal(testCid, ref)
+	r.Len(pinned.Health(), 6)
+
+	// all targets are required by default
+	pinned, err = newPinnedStorage(cidStorage{}, config.IPFSPinningConfig{Targets: targets, MaxAttempts: 2})
+	r.NoError(err)
+	_, err = pinned.Store(context.Background(), testPayload)
+	r.Error(err)
+
+	_, err = newPinnedStorage(cidStorage{}, config.IPFSPinningConfig{Targets: targets, Quorum: 4})
+	r.Error(err)
+	_, err = newPinnedStorage(cidStorage{}, config.IPFSPinningConfig{Targets: append(targets, targets[0])})
+	r.Error(err)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/forta-network/forta-core-go/ipfs"
+	"github.com/forta-network/forta-node/config"
+)
+
+// Storage types
+const (
+	TypeIPFS  = "ipfs"
+	TypeS3    = "s3"
+	TypeGCS   = "gcs"
+	TypeHTTPS = "https"
+)
+
+// BatchStorage stores the encoded alert batches and returns the references to them.
+type BatchStorage interface {
+	Store(ctx context.Context, payload []byte) (string, error)
+}
+
+// New creates the batch storage from the config.
+func New(cfg config.BatchStorageConfig, ipfsClient ipfs.Client) (BatchStorage, error) {
+	var (
+		storage encodingStorage
+		err     error
+	)
+	switch cfg.Type {
+	case "", TypeIPFS:
+		// the alert api uploads the batches to ipfs so we can't change the encoding
+		if len(cfg.Compression) > 0 {
+			return nil, fmt.Errorf("batch compression is not supported with ipfs storage: %s", cfg.Compression)
+		}
+		if len(cfg.Pinning.Targets) > 0 {
+			return newPinnedStorage(&ipfsStorage{client: ipfsClient}, cfg.Pinning)
+		}
+		return &ipfsStorage{client: ipfsClient}, nil
+	case TypeS3, TypeGCS:
+		storage, err = newBucketStorage(cfg)
+	case TypeHTTPS:
+		storage, err = newHTTPSStorage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown batch storage type: %s", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &compressedStorage{storage: storage, encoding: cfg.Compression}, nil
+}
+
+// IsIPFS tells if the storage type makes the batches available on IPFS.
+func IsIPFS(storageType string) bool {
+	return storageType == "" || storageType == TypeIPFS
+}
+
+// payloadHash returns the hex encoded SHA-256 hash of the payload.
+func payloadHash(payload []byte) string {
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:])
+}
+
+type ipfsStorage struct {
+	client ipfs.Client
+}
+
+// Store calculates the IPFS CID of the batch. The batch content is delivered to the alert API
+// which makes it available on IPFS.
+func (storage *ipfsStorage) Store(ctx context.Context, payload []byte) (string, error) {
+	return storage.client.CalculateFileHash(payload)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+var testPayload = []byte(`{"batch":"test"}`)
+
+func TestBucketStorage(t *testing.T) {
+	r := require.New(t)
+
+	var req *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	storage, err := New(config.BatchStorageConfig{
+		Type:            TypeGCS,
+		Bucket:          "bucket",
+		Prefix:          "batches/",
+		Endpoint:        server.URL,
+		AccessKeyID:     "key-id",
+		SecretAccessKey: "secret",
+	}, nil)
+	r.NoError(err)
+
+	ref, err := storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	hash := payloadHash(testPayload)
+	r.Equal("gs://bucket/batches/"+hash+".json", ref)
+	r.Equal(http.MethodPut, req.Method)
+	r.Equal("/bucket/batches/"+hash+".json", req.URL.Path)
+	r.Equal(testPayload, body)
+	r.Equal(hash, req.Header.Get("X-Amz-Content-Sha256"))
+	r.True(strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key-id/"))
+	r.Contains(req.Header.Get("Authorization"), "/auto/s3/aws4_request")
+}
+
+func TestBucketStorage_Invalid(t *testing.T) {
+	r := require.New(t)
+
+	_, err := New(config.BatchStorageConfig{Type: TypeS3, Bucket: "bucket"}, nil)
+	r.Error(err)
+	_, err = New(config.BatchStorageConfig{
+		Type: TypeS3, Bucket: "bucket", AccessKeyID: "key-id", SecretAccessKey: "secret",
+	}, nil)
+	r.Error(err, "region is required")
+}
+
+func TestNew_IPFSCompression(t *testing.T) {
+	_, err := New(config.BatchStorageConfig{Type: TypeIPFS, Compression: "zstd"}, nil)
+	require.Error(t, err)
+}
+
+func TestHTTPSStorage(t *testing.T) {
+	r := require.New(t)
+
+	var location string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("Bearer token", req.Header.Get("Authorization"))
+		r.Equal(payloadHash(testPayload), req.Header.Get(BatchHashHeader))
+		if len(location) > 0 {
+			w.Header().Set("Location", location)
+		}
+	}))
+	defer server.Close()
+
+	storage, err := New(config.BatchStorageConfig{
+		Type:    TypeHTTPS,
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}, nil)
+	r.NoError(err)
+
+	ref, err := storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	r.Equal(payloadHash(testPayload), ref)
+
+	location = "https://batches.example.com/1"
+	ref, err = storage.Store(context.Background(), testPayload)
+	r.NoError(err)
+	r.Equal(location, ref)
+}
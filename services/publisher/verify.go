@@ -0,0 +1,87 @@
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/encoding"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+)
+
+// VerifyBatch verifies the scanner signature of the batch and checks if the batch content
+// is consistent: every alert must be signed by the same scanner and the alert count, max
+// severity and the block range must match the alerts and the results. The expected scanner
+// address is not checked if it is empty.
+func VerifyBatch(signedBatch *protocol.SignedPayload, expectedScanner string) (*protocol.AlertBatch, error) {
+	if signedBatch.Type != protocol.SignedPayload_BATCH {
+		return nil, fmt.Errorf("not a batch payload: %s", signedBatch.Type)
+	}
+	if err := security.VerifySignedPayload(signedBatch); err != nil {
+		return nil, fmt.Errorf("invalid batch signature: %v", err)
+	}
+	scanner := signedBatch.Signature.Signer
+	if len(expectedScanner) > 0 && !strings.EqualFold(scanner, expectedScanner) {
+		return nil, fmt.Errorf("batch is signed by %s instead of %s", scanner, expectedScanner)
+	}
+
+	var batch protocol.AlertBatch
+	if err := encoding.DecodeGzippedProto(signedBatch.Encoded, &batch); err != nil {
+		return nil, fmt.Errorf("failed to decode the batch: %v", err)
+	}
+
+	var (
+		alertCount  uint32
+		maxSeverity protocol.Finding_Severity
+	)
+	verifyAlerts := func(agentAlertsList []*protocol.AgentAlerts) error {
+		for _, agentAlerts := range agentAlertsList {
+			for _, alert := range agentAlerts.Alerts {
+				if alert.Alert == nil {
+					return errors.New("empty alert")
+				}
+				if err := security.VerifyAlertSignature(alert); err != nil {
+					return fmt.Errorf("invalid signature for alert %s: %v", alert.Alert.Id, err)
+				}
+				if !strings.EqualFold(alert.Signature.Signer, scanner) {
+					return fmt.Errorf("alert %s is signed by another scanner: %s", alert.Alert.Id, alert.Signature.Signer)
+				}
+				if alert.Alert.Finding != nil && alert.Alert.Finding.Severity > maxSeverity {
+					maxSeverity = alert.Alert.Finding.Severity
+				}
+				alertCount++
+			}
+		}
+		return nil
+	}
+
+	for _, blockRes := range batch.Results {
+		if blockRes.Block == nil {
+			return nil, errors.New("block results without a block")
+		}
+		blockNum := blockRes.Block.BlockNumber
+		if blockNum < batch.BlockStart || blockNum > batch.BlockEnd {
+			return nil, fmt.Errorf("block %d is out of the batch range %d-%d", blockNum, batch.BlockStart, batch.BlockEnd)
+		}
+		if err := verifyAlerts(blockRes.Results); err != nil {
+			return nil, err
+		}
+		for _, txRes := range blockRes.Transactions {
+			if err := verifyAlerts(txRes.Results); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := verifyAlerts(batch.PrivateAlerts); err != nil {
+		return nil, err
+	}
+
+	if alertCount != batch.AlertCount {
+		return nil, fmt.Errorf("alert count mismatch: batch has %d alerts but says %d", alertCount, batch.AlertCount)
+	}
+	if maxSeverity != batch.MaxSeverity {
+		return nil, fmt.Errorf("max severity mismatch: found %s but batch says %s", maxSeverity, batch.MaxSeverity)
+	}
+	return &batch, nil
+}
@@ -0,0 +1,95 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) *keystore.Key {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &keystore.Key{Address: crypto.PubkeyToAddress(privateKey.PublicKey), PrivateKey: privateKey}
+}
+
+func testSignedBatch(t *testing.T, key *keystore.Key, modify func(batch *protocol.AlertBatch)) *protocol.SignedPayload {
+	r := require.New(t)
+
+	signedAlert, err := security.SignAlert(key, &protocol.Alert{
+		Id:      "0x1",
+		Finding: &protocol.Finding{Severity: protocol.Finding_HIGH},
+	})
+	r.NoError(err)
+	batch := &protocol.AlertBatch{
+		ChainId:     1,
+		BlockStart:  10,
+		BlockEnd:    11,
+		AlertCount:  1,
+		MaxSeverity: protocol.Finding_HIGH,
+		Results: []*protocol.BlockResults{
+			{
+				Block: &protocol.Block{BlockNumber: 11},
+				Results: []*protocol.AgentAlerts{
+					{Alerts: []*protocol.SignedAlert{signedAlert}},
+				},
+			},
+		},
+	}
+	if modify != nil {
+		modify(batch)
+	}
+	signedBatch, err := security.SignBatch(key, batch)
+	r.NoError(err)
+	return signedBatch
+}
+
+func TestVerifyBatch(t *testing.T) {
+	r := require.New(t)
+	key := testKey(t)
+
+	batch, err := VerifyBatch(testSignedBatch(t, key, nil), key.Address.Hex())
+	r.NoError(err)
+	r.Equal(uint32(1), batch.AlertCount)
+
+	_, err = VerifyBatch(testSignedBatch(t, key, nil), testKey(t).Address.Hex())
+	r.Error(err)
+
+	signedBatch := testSignedBatch(t, key, nil)
+	signedBatch.Encoded = testSignedBatch(t, key, func(batch *protocol.AlertBatch) {
+		batch.BlockEnd = 12
+	}).Encoded
+	_, err = VerifyBatch(signedBatch, "")
+	r.Error(err)
+}
+
+func TestVerifyBatch_Content(t *testing.T) {
+	key := testKey(t)
+	otherKey := testKey(t)
+
+	for name, modify := range map[string]func(batch *protocol.AlertBatch){
+		"alert count": func(batch *protocol.AlertBatch) {
+			batch.AlertCount = 2
+		},
+		"max severity": func(batch *protocol.AlertBatch) {
+			batch.MaxSeverity = protocol.Finding_CRITICAL
+		},
+		"block range": func(batch *protocol.AlertBatch) {
+			batch.BlockEnd = 10
+		},
+		"alert signer": func(batch *protocol.AlertBatch) {
+			alert := batch.Results[0].Results[0].Alerts[0]
+			signedAlert, err := security.SignAlert(otherKey, alert.Alert)
+			require.NoError(t, err)
+			batch.Results[0].Results[0].Alerts[0] = signedAlert
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := VerifyBatch(testSignedBatch(t, key, modify), "")
+			require.Error(t, err)
+		})
+	}
+}
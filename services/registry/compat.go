@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// filterIncompatible skips the agents which have version constraints that this node does not
+// satisfy, instead of letting them fail later with the gRPC errors.
+func (rs *RegistryService) filterIncompatible(agts []*config.AgentConfig) []*config.AgentConfig {
+	var (
+		filtered     []*config.AgentConfig
+		ms           []*protocol.AgentMetric
+		incompatible = make(map[string]string)
+	)
+	for _, agt := range agts {
+		if err := config.CheckAgentCompatibility(agt, rs.nodeVersion); err != nil {
+			log.WithField("agent", agt.ID).WithError(err).Warn("registry: skipping the incompatible agent")
+			ms = append(ms, metrics.CreateAgentMetric(agt.ID, metrics.MetricIncompatible, 1))
+			incompatible[agt.ID] = err.Error()
+			continue
+		}
+		filtered = append(filtered, agt)
+	}
+	metrics.SendAgentMetrics(rs.msgClient, ms)
+
+	rs.agentsMu.Lock()
+	rs.incompatible = incompatible
+	rs.agentsMu.Unlock()
+	return filtered
+}
+
+func (rs *RegistryService) incompatibleReport() *health.Report {
+	report := &health.Report{
+		Name:   "agents.incompatible",
+		Status: health.StatusInfo,
+	}
+	rs.agentsMu.RLock()
+	defer rs.agentsMu.RUnlock()
+	if len(rs.incompatible) == 0 {
+		return report
+	}
+	var details []string
+	for agentID, reason := range rs.incompatible {
+		details = append(details, fmt.Sprintf("%s: %s", agentID, reason))
+	}
+	sort.Strings(details)
+	report.Status = health.StatusLagging
+	report.Details = strings.Join(details, "; ")
+	return report
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// loadDisabledAgents loads the agents which were disabled before the restart.
+func (rs *RegistryService) loadDisabledAgents() error {
+	disabled := make(map[string]bool)
+	if rs.disabledStore != nil {
+		agentIDs, err := rs.disabledStore.Get()
+		if err != nil {
+			return err
+		}
+		for _, agentID := range agentIDs {
+			disabled[strings.ToLower(agentID)] = true
+		}
+	}
+	rs.agentsMu.Lock()
+	rs.disabled = disabled
+	rs.agentsMu.Unlock()
+	return nil
+}
+
+// filterDisabled skips the agents which are disabled by the operator.
+func (rs *RegistryService) filterDisabled(agts []*config.AgentConfig) []*config.AgentConfig {
+	rs.agentsMu.RLock()
+	defer rs.agentsMu.RUnlock()
+	var filtered []*config.AgentConfig
+	for _, agt := range agts {
+		if agt != nil && rs.disabled[strings.ToLower(agt.ID)] {
+			log.WithField("agent", agt.ID).Info("registry: skipping the disabled agent")
+			continue
+		}
+		filtered = append(filtered, agt)
+	}
+	return filtered
+}
+
+// DisableAgent stops running the agent until it is enabled again.
+func (rs *RegistryService) DisableAgent(agentID string) error {
+	return rs.setDisabled(agentID, true)
+}
+
+// EnableAgent lets the disabled agent run again if it is still assigned.
+func (rs *RegistryService) EnableAgent(agentID string) error {
+	return rs.setDisabled(agentID, false)
+}
+
+func (rs *RegistryService) setDisabled(agentID string, disabled bool) error {
+	agentID = strings.ToLower(agentID)
+	rs.agentsMu.Lock()
+	if rs.disabled == nil {
+		rs.disabled = make(map[string]bool)
+	}
+	if disabled {
+		rs.disabled[agentID] = true
+	} else {
+		delete(rs.disabled, agentID)
+	}
+	agentIDs := rs.disabledAgentsUnsafe()
+	rs.agentsMu.Unlock()
+
+	if rs.disabledStore != nil {
+		if err := rs.disabledStore.Put(agentIDs); err != nil {
+			return err
+		}
+	}
+	log.WithFields(log.Fields{
+		"agent":    agentID,
+		"disabled": disabled,
+	}).Info("registry: changed the agent state")
+
+	// publish the agents again so that the change takes effect immediately
+	if err := rs.sem.Acquire(context.Background(), 1); err != nil {
+		return err
+	}
+	defer rs.sem.Release(1)
+	if rs.published && !rs.cfg.Replay.Enabled() {
+		rs.republishAgents()
+	}
+	return nil
+}
+
+// DisabledAgents returns the agents which are disabled by the operator.
+func (rs *RegistryService) DisabledAgents() []string {
+	rs.agentsMu.RLock()
+	defer rs.agentsMu.RUnlock()
+	return rs.disabledAgentsUnsafe()
+}
+
+func (rs *RegistryService) disabledAgentsUnsafe() []string {
+	agentIDs := make([]string, 0, len(rs.disabled))
+	for agentID := range rs.disabled {
+		agentIDs = append(agentIDs, agentID)
+	}
+	sort.Strings(agentIDs)
+	return agentIDs
+}
+
+func (rs *RegistryService) disabledReport() *health.Report {
+	agentIDs := rs.DisabledAgents()
+	return &health.Report{
+		Name:    "agents.disabled",
+		Status:  health.StatusInfo,
+		Details: strings.Join(agentIDs, ","),
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain/registry"
+	coreregistry "github.com/forta-network/forta-core-go/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+// listenEvents listens to the registry contract events which can change the agents of this
+// scanner and triggers a refresh without waiting for the next check. The periodic check
+// continues to work as a fallback.
+func (rs *RegistryService) listenEvents() {
+	retryInterval := time.Duration(rs.cfg.Registry.CheckIntervalSeconds) * time.Second
+	for {
+		listener, err := coreregistry.NewListener(context.Background(), coreregistry.ListenerConfig{
+			Name:       "registry-events",
+			JsonRpcURL: rs.cfg.Registry.JsonRpc.Url,
+			ENSAddress: rs.cfg.ENSConfig.ContractAddress,
+			Handlers: coreregistry.Handlers{
+				DispatchHandler:      rs.handleDispatchEvent,
+				ScannerActionHandler: rs.handleScannerEvent,
+				AgentActionHandler:   rs.handleAgentEvent,
+				SaveAgentHandler:     rs.handleAgentSaveEvent,
+			},
+			ContractFilter: &coreregistry.ContractFilter{
+				AgentRegistry:    true,
+				ScannerRegistry:  true,
+				DispatchRegistry: true,
+			},
+		})
+		if err == nil {
+			log.Info("registry: listening to the registry events")
+			err = listener.Listen()
+		}
+		log.WithError(err).Warn("registry: stopped listening to the registry events - retrying")
+		time.Sleep(retryInterval)
+	}
+}
+
+// requestRefresh triggers a check unless one is already pending.
+func (rs *RegistryService) requestRefresh(logger *log.Entry, reason string) {
+	select {
+	case rs.refreshCh <- struct{}{}:
+		logger.WithField("reason", reason).Info("registry: checking the agents after the registry event")
+	default:
+	}
+}
+
+func (rs *RegistryService) isThisScanner(scannerID string) bool {
+	return strings.EqualFold(scannerID, rs.scannerAddress.Hex())
+}
+
+func (rs *RegistryService) runsAgent(agentID string) bool {
+	rs.agentsMu.RLock()
+	defer rs.agentsMu.RUnlock()
+	for _, agt := range rs.agentsConfigs {
+		if strings.EqualFold(agt.ID, agentID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *RegistryService) handleDispatchEvent(logger *log.Entry, msg *registry.DispatchMessage) error {
+	if rs.isThisScanner(msg.ScannerID) {
+		rs.requestRefresh(logger, msg.Action)
+	}
+	return nil
+}
+
+func (rs *RegistryService) handleScannerEvent(logger *log.Entry, msg *registry.ScannerMessage) error {
+	if rs.isThisScanner(msg.ScannerID) {
+		rs.requestRefresh(logger, msg.Action)
+	}
+	return nil
+}
+
+func (rs *RegistryService) handleAgentEvent(logger *log.Entry, msg *registry.AgentMessage) error {
+	if rs.runsAgent(msg.AgentID) {
+		rs.requestRefresh(logger, msg.Action)
+	}
+	return nil
+}
+
+func (rs *RegistryService) handleAgentSaveEvent(logger *log.Entry, msg *registry.AgentSaveMessage) error {
+	return rs.handleAgentEvent(logger, &msg.AgentMessage)
+}
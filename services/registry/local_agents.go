@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// readLocalAgents reads the private agents declared in the local agent definitions file and
+// returns true if they have changed since the last read. The last valid definitions are kept
+// if the file becomes invalid.
+func (rs *RegistryService) readLocalAgents() bool {
+	if len(rs.localAgentsPath) == 0 {
+		return false
+	}
+	b, err := ioutil.ReadFile(rs.localAgentsPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warn("registry: failed to read the local agent definitions")
+		return false
+	}
+	if bytes.Equal(b, rs.localAgentsRaw) {
+		return false
+	}
+	agts, err := config.ParseLocalAgentDefinitions(b, rs.cfg.ChainID)
+	if err != nil {
+		log.WithError(err).Error("registry: invalid local agent definitions - ignoring the changes")
+		return false
+	}
+	log.WithField("count", len(agts)).Info("registry: loaded the local agent definitions")
+	rs.localAgents = agts
+	rs.localAgentsRaw = b
+	return true
+}
+
+// mergeLocalAgents adds the local agents to the registry agents. The registry agents win if
+// the same agent is also declared locally.
+func mergeLocalAgents(agts, localAgts []*config.AgentConfig) []*config.AgentConfig {
+	if len(localAgts) == 0 {
+		return agts
+	}
+	merged := append([]*config.AgentConfig{}, agts...)
+	for _, localAgt := range localAgts {
+		var found bool
+		for _, agt := range agts {
+			if agt != nil && strings.EqualFold(agt.ID, localAgt.ID) {
+				found = true
+				break
+			}
+		}
+		if found {
+			log.WithField("agent", localAgt.ID).Warn("registry: local agent is already assigned by the registry - ignoring")
+			continue
+		}
+		merged = append(merged, localAgt)
+	}
+	return merged
+}
@@ -3,6 +3,9 @@ package registry
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"path"
+	"sync"
 	"time"
 
 	"github.com/forta-network/forta-node/store"
@@ -28,11 +31,25 @@ type RegistryService struct {
 
 	rpcClient     *rpc.Client
 	registryStore store.RegistryStore
+	snapshotStore store.RegistrySnapshotStore
+	disabledStore store.DisabledAgentsStore
 
-	agentsConfigs []*config.AgentConfig
-	done          chan struct{}
-	version       string
-	sem           *semaphore.Weighted
+	agentsConfigs   []*config.AgentConfig
+	registryAgents  []*config.AgentConfig
+	localAgents     []*config.AgentConfig
+	localAgentsRaw  []byte
+	localAgentsPath string
+	agentsMu        sync.RWMutex
+	published       bool
+	fromSnapshot    *store.RegistrySnapshot
+	refreshCh       chan struct{}
+	done            chan struct{}
+	version         string
+	sem             *semaphore.Weighted
+	minStake        *big.Int
+	nodeVersion     string
+	incompatible    map[string]string
+	disabled        map[string]bool
 
 	lastChecked        health.TimeTracker
 	lastChangeDetected health.TimeTracker
@@ -52,11 +69,16 @@ type EthClient interface {
 // New creates a new service.
 func New(cfg config.Config, scannerAddress common.Address, msgClient clients.MessageClient, ethClient ethereum.Client) *RegistryService {
 	return &RegistryService{
-		cfg:            cfg,
-		scannerAddress: scannerAddress,
-		msgClient:      msgClient,
-		ethClient:      ethClient,
-		done:           make(chan struct{}),
+		cfg:             cfg,
+		scannerAddress:  scannerAddress,
+		msgClient:       msgClient,
+		ethClient:       ethClient,
+		snapshotStore:   store.NewRegistrySnapshotStore(cfg.FortaDir),
+		disabledStore:   store.NewDisabledAgentsStore(cfg.FortaDir),
+		localAgentsPath: path.Join(cfg.FortaDir, config.DefaultAgentsFileName),
+		nodeVersion:     config.Version,
+		refreshCh:       make(chan struct{}, 1),
+		done:            make(chan struct{}),
 	}
 }
 
@@ -74,7 +96,24 @@ func (rs *RegistryService) Init() error {
 	if err != nil {
 		return err
 	}
+	if legacyAddr := rs.cfg.Registry.Legacy.ENSAddress; len(legacyAddr) > 0 && !rs.cfg.PrivateModeConfig.Enable {
+		legacyCfg := rs.cfg
+		legacyCfg.ENSConfig.ContractAddress = legacyAddr
+		legacyCfg.ENSConfig.Override = false
+		legacyStore, err := store.NewRegistryStore(context.Background(), legacyCfg, rs.ethClient)
+		if err != nil {
+			return fmt.Errorf("failed to create the legacy registry store: %v", err)
+		}
+		log.WithField("ensAddress", legacyAddr).Info("registry: merging the assignments from the legacy registry")
+		regStr = store.NewMergedRegistryStore(regStr, legacyStore)
+	}
 	rs.registryStore = regStr
+	if !rs.cfg.PrivateModeConfig.Enable {
+		rs.minStake, err = parseStake(rs.cfg.Registry.MinimumAgentStake)
+		if err != nil {
+			return fmt.Errorf("invalid minimum agent stake: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -84,6 +123,9 @@ func (rs *RegistryService) Start() error {
 	if err := rs.Init(); err != nil {
 		return err
 	}
+	if err := rs.loadDisabledAgents(); err != nil {
+		return err
+	}
 	rs.sem = semaphore.NewWeighted(1)
 	return rs.start()
 }
@@ -97,10 +139,17 @@ func (rs *RegistryService) start() error {
 			if err != nil {
 				log.WithError(err).Error("failed to publish the latest agents")
 			}
-			<-ticker.C
+			select {
+			case <-ticker.C:
+			case <-rs.refreshCh:
+			}
 		}
 	}()
 
+	if rs.cfg.Registry.ListenEvents && !rs.cfg.PrivateModeConfig.Enable && !rs.cfg.Replay.Enabled() {
+		go rs.listenEvents()
+	}
+
 	return nil
 }
 
@@ -109,22 +158,113 @@ func (rs *RegistryService) publishLatestAgents() error {
 	if rs.sem.TryAcquire(1) {
 		defer rs.sem.Release(1)
 		rs.lastChecked.Set()
+		if rs.cfg.Replay.Enabled() {
+			rs.publishReplayAgents()
+			return nil
+		}
+		localChanged := rs.readLocalAgents()
 		agts, changed, err := rs.registryStore.GetAgentsIfChanged(rs.scannerAddress.Hex())
 		if err != nil {
+			if !rs.published {
+				rs.publishSnapshot()
+			} else if localChanged {
+				rs.republishAgents()
+			}
 			return fmt.Errorf("failed to get the scanner list agents version: %v", err)
 		}
-		if changed {
+		switch {
+		case changed:
 			rs.lastChangeDetected.Set()
-			log.WithField("count", len(agts)).Infof("publishing list of agents")
-			rs.agentsConfigs = agts
-			rs.msgClient.Publish(messaging.SubjectAgentsVersionsLatest, agts)
-		} else {
+			rs.saveSnapshot(agts)
+			rs.publishAgents(agts, nil)
+		case localChanged:
+			rs.republishAgents()
+		default:
 			log.Info("registry: no agent changes detected")
 		}
 	}
 	return nil
 }
 
+// publishAgents publishes the registry agents together with the local agents.
+func (rs *RegistryService) publishAgents(agts []*config.AgentConfig, snapshot *store.RegistrySnapshot) {
+	rs.registryAgents = agts
+	agts = rs.filterByStake(rs.filterIncompatible(rs.filterAgents(agts)))
+	agts = rs.filterDisabled(mergeLocalAgents(agts, rs.filterAgents(rs.localAgents)))
+	agts = config.ApplyAgentScaling(agts, rs.cfg.AgentScaling)
+	log.WithField("count", len(agts)).Infof("publishing list of agents")
+	rs.agentsMu.Lock()
+	rs.agentsConfigs = agts
+	rs.fromSnapshot = snapshot
+	rs.agentsMu.Unlock()
+	rs.msgClient.Publish(messaging.SubjectAgentsVersionsLatest, agts)
+	rs.published = true
+}
+
+// publishReplayAgents publishes only the agents which are replaying the blocks.
+func (rs *RegistryService) publishReplayAgents() {
+	agts := rs.cfg.Replay.Agents
+	log.WithField("count", len(agts)).Infof("publishing list of replay agents")
+	rs.agentsMu.Lock()
+	rs.agentsConfigs = agts
+	rs.agentsMu.Unlock()
+	rs.msgClient.Publish(messaging.SubjectAgentsVersionsLatest, agts)
+	rs.published = true
+}
+
+// republishAgents publishes the last registry agents again with the latest local agents.
+func (rs *RegistryService) republishAgents() {
+	rs.agentsMu.RLock()
+	snapshot := rs.fromSnapshot
+	rs.agentsMu.RUnlock()
+	rs.publishAgents(rs.registryAgents, snapshot)
+}
+
+// saveSnapshot persists the agent assignments for the next startup.
+func (rs *RegistryService) saveSnapshot(agts []*config.AgentConfig) {
+	if rs.snapshotStore == nil {
+		return
+	}
+	if err := rs.snapshotStore.Put(&store.RegistrySnapshot{
+		Scanner: rs.scannerAddress.Hex(),
+		SavedAt: time.Now().UTC(),
+		Agents:  agts,
+	}); err != nil {
+		log.WithError(err).Warn("registry: failed to save the snapshot")
+	}
+}
+
+// publishSnapshot publishes the last known agent assignments if the node has not published
+// any agents since startup, so that it can keep scanning while the registry is unreachable.
+// The agents are reconciled with the registry after it becomes reachable again.
+func (rs *RegistryService) publishSnapshot() {
+	if rs.published || rs.snapshotStore == nil {
+		return
+	}
+	snapshot, err := rs.snapshotStore.Get()
+	if err != nil {
+		log.WithError(err).Warn("registry: failed to load the snapshot")
+		return
+	}
+	if snapshot == nil || snapshot.Scanner != rs.scannerAddress.Hex() {
+		return
+	}
+	log.WithField("savedAt", snapshot.SavedAt).Warn("registry: starting with the agents from the last snapshot")
+	rs.publishAgents(snapshot.Agents, snapshot)
+}
+
+// filterAgents applies the local allowlist and denylist on top of the registry assignments.
+func (rs *RegistryService) filterAgents(agts []*config.AgentConfig) []*config.AgentConfig {
+	filtered := rs.cfg.AgentFilter.Filter(agts)
+	if len(filtered) != len(agts) {
+		log.WithFields(log.Fields{
+			"assigned": len(agts),
+			"allowed":  len(filtered),
+		}).Info("registry: skipping agents by the local agent filter")
+	}
+	return filtered
+}
+
 // Stop stops the registry service.
 func (rs *RegistryService) Stop() error {
 	return nil
@@ -149,5 +289,23 @@ func (rs *RegistryService) Health() health.Reports {
 			Status:  health.StatusInfo,
 			Details: rs.lastChangeDetected.String(),
 		},
+		rs.snapshotReport(),
+		rs.incompatibleReport(),
+		rs.disabledReport(),
+	}
+}
+
+func (rs *RegistryService) snapshotReport() *health.Report {
+	report := &health.Report{
+		Name:   "snapshot",
+		Status: health.StatusInfo,
+	}
+	rs.agentsMu.RLock()
+	snapshot := rs.fromSnapshot
+	rs.agentsMu.RUnlock()
+	if snapshot != nil {
+		report.Status = health.StatusLagging
+		report.Details = fmt.Sprintf("running the agents from the snapshot saved at %s", snapshot.SavedAt.Format(time.RFC3339))
 	}
+	return report
 }
@@ -1,20 +1,28 @@
 package registry
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path"
 	"testing"
 
 	"golang.org/x/sync/semaphore"
 
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain/registry"
 	"github.com/forta-network/forta-node/clients/messaging"
 	mock_clients "github.com/forta-network/forta-node/clients/mocks"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/store"
 	mock_store "github.com/forta-network/forta-node/store/mocks"
 
 	"github.com/forta-network/forta-node/services/registry/regtypes"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -60,6 +68,7 @@ func (s *Suite) SetupTest() {
 		scannerAddress: testScannerAddress,
 		msgClient:      s.msgClient,
 		registryStore:  s.registryStore,
+		refreshCh:      make(chan struct{}, 1),
 		done:           make(chan struct{}),
 		sem:            semaphore.NewWeighted(1),
 	}
@@ -109,3 +118,200 @@ func (s *Suite) TestDoNotPublishChanges() {
 	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, nil)
 	s.NoError(s.service.publishLatestAgents())
 }
+
+func (s *Suite) TestPublishFilteredChanges() {
+	s.service.cfg.AgentFilter.Denylist = []string{testAgentIDStr}
+	configs := (agentConfigs)([]*config.AgentConfig{
+		{
+			ID:    testAgentIDStr,
+			Image: fmt.Sprintf("%s/%s", testContainerRegistry, testImageRef),
+		},
+	})
+
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(configs, true, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{})
+
+	s.NoError(s.service.publishLatestAgents())
+}
+
+func (s *Suite) refreshRequested() bool {
+	select {
+	case <-s.service.refreshCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Suite) TestRegistryEventsRequestRefresh() {
+	logger := log.WithField("test", true)
+	s.service.agentsConfigs = []*config.AgentConfig{{ID: testAgentIDStr}}
+
+	s.NoError(s.service.handleDispatchEvent(logger, &registry.DispatchMessage{ScannerID: "0x1"}))
+	s.False(s.refreshRequested())
+	s.NoError(s.service.handleDispatchEvent(logger, &registry.DispatchMessage{ScannerID: testScannerAddressStr}))
+	s.True(s.refreshRequested())
+
+	s.NoError(s.service.handleScannerEvent(logger, &registry.ScannerMessage{ScannerID: testScannerAddressStr}))
+	s.True(s.refreshRequested())
+
+	s.NoError(s.service.handleAgentEvent(logger, &registry.AgentMessage{AgentID: "0x1"}))
+	s.False(s.refreshRequested())
+	s.NoError(s.service.handleAgentSaveEvent(logger, &registry.AgentSaveMessage{AgentMessage: registry.AgentMessage{AgentID: testAgentIDStr}}))
+	s.True(s.refreshRequested())
+
+	// does not block when a refresh is already pending
+	s.NoError(s.service.handleDispatchEvent(logger, &registry.DispatchMessage{ScannerID: testScannerAddressStr}))
+	s.NoError(s.service.handleDispatchEvent(logger, &registry.DispatchMessage{ScannerID: testScannerAddressStr}))
+	s.True(s.refreshRequested())
+}
+
+func (s *Suite) TestPublishSnapshotWhenRegistryIsUnreachable() {
+	s.service.snapshotStore = store.NewRegistrySnapshotStore(s.T().TempDir())
+	configs := (agentConfigs)([]*config.AgentConfig{
+		{
+			ID:    testAgentIDStr,
+			Image: fmt.Sprintf("%s/%s", testContainerRegistry, testImageRef),
+		},
+	})
+
+	// no snapshot yet
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, errors.New("unreachable"))
+	s.Error(s.service.publishLatestAgents())
+
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(configs, true, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, configs)
+	s.NoError(s.service.publishLatestAgents())
+
+	// restart with the snapshot
+	s.service.published = false
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, errors.New("unreachable"))
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, configs)
+	s.Error(s.service.publishLatestAgents())
+	s.Equal(health.StatusLagging, s.service.snapshotReport().Status)
+
+	// published once
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, errors.New("unreachable"))
+	s.Error(s.service.publishLatestAgents())
+
+	// reconciled after the registry is reachable
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(agentConfigs{}, true, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{})
+	s.NoError(s.service.publishLatestAgents())
+	s.Equal(health.StatusInfo, s.service.snapshotReport().Status)
+}
+
+func (s *Suite) TestPublishSkipsAgentsWithLowStake() {
+	const lowStakeAgentID = "0x3000000000000000000000000000000000000000000000000000000000000000"
+	const unknownStakeAgentID = "0x4000000000000000000000000000000000000000000000000000000000000000"
+	s.service.minStake = big.NewInt(100)
+	configs := []*config.AgentConfig{{ID: testAgentIDStr}, {ID: lowStakeAgentID}, {ID: unknownStakeAgentID}}
+
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(configs, true, nil)
+	s.registryStore.EXPECT().GetAgentStake(testAgentIDStr).Return(big.NewInt(100), nil)
+	s.registryStore.EXPECT().GetAgentStake(lowStakeAgentID).Return(big.NewInt(99), nil)
+	s.registryStore.EXPECT().GetAgentStake(unknownStakeAgentID).Return(nil, errors.New("failed"))
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{configs[0], configs[2]})
+
+	s.NoError(s.service.publishLatestAgents())
+}
+
+func (s *Suite) TestPublishSkipsIncompatibleAgents() {
+	const newerNodeAgentID = "0x3000000000000000000000000000000000000000000000000000000000000000"
+	const newerProtocolAgentID = "0x4000000000000000000000000000000000000000000000000000000000000000"
+	s.service.nodeVersion = "0.5.0"
+	configs := []*config.AgentConfig{
+		{ID: testAgentIDStr, NodeVersion: ">=0.4.0", ProtocolVersion: "1.0.0"},
+		{ID: newerNodeAgentID, NodeVersion: ">=0.6.0"},
+		{ID: newerProtocolAgentID, ProtocolVersion: "2.0.0"},
+	}
+
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(configs, true, nil)
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{configs[0]})
+
+	s.NoError(s.service.publishLatestAgents())
+	report := s.service.incompatibleReport()
+	s.Equal(health.StatusLagging, report.Status)
+	s.Contains(report.Details, newerNodeAgentID)
+	s.Contains(report.Details, newerProtocolAgentID)
+}
+
+func TestParseStake(t *testing.T) {
+	r := require.New(t)
+
+	stake, err := parseStake("")
+	r.NoError(err)
+	r.Nil(stake)
+
+	stake, err = parseStake("1.5")
+	r.NoError(err)
+	r.Equal("1500000000000000000", stake.String())
+
+	_, err = parseStake("-1")
+	r.Error(err)
+}
+
+func (s *Suite) TestPublishLocalAgents() {
+	s.service.cfg.ChainID = 1
+	s.service.localAgentsPath = path.Join(s.T().TempDir(), "agents.yml")
+	registryAgents := agentConfigs{{ID: testAgentIDStr, Image: "registry-image"}}
+	writeLocalAgents := func(content string) {
+		s.r.NoError(ioutil.WriteFile(s.service.localAgentsPath, []byte(content), 0644))
+	}
+
+	writeLocalAgents(`
+agents:
+  - id: private-1
+    image: private-image-1
+  - id: private-2
+    image: private-image-2
+    chainIds: [ 137 ]
+  - id: ` + testAgentIDStr + `
+    image: duplicate-image
+`)
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return([]*config.AgentConfig(registryAgents), true, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{registryAgents[0], {ID: "private-1", Image: "private-image-1"}})
+	s.NoError(s.service.publishLatestAgents())
+
+	// republishes when only the local agents change
+	writeLocalAgents(`
+agents:
+  - id: private-3
+    image: private-image-3
+`)
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{registryAgents[0], {ID: "private-3", Image: "private-image-3"}})
+	s.NoError(s.service.publishLatestAgents())
+
+	// invalid definitions are ignored
+	writeLocalAgents(`
+agents:
+  - id: private-4
+`)
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(nil, false, nil)
+	s.NoError(s.service.publishLatestAgents())
+}
+
+func (s *Suite) TestDisableAndEnableAgent() {
+	const otherAgentID = "0x3000000000000000000000000000000000000000000000000000000000000000"
+	s.service.disabledStore = store.NewDisabledAgentsStore(s.T().TempDir())
+	configs := []*config.AgentConfig{{ID: testAgentIDStr}, {ID: otherAgentID}}
+
+	s.registryStore.EXPECT().GetAgentsIfChanged(s.service.scannerAddress.Hex()).Return(configs, true, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs(configs))
+	s.NoError(s.service.publishLatestAgents())
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs{configs[1]})
+	s.NoError(s.service.DisableAgent(testAgentIDStr))
+	s.Equal([]string{testAgentIDStr}, s.service.DisabledAgents())
+
+	// persisted across restarts
+	s.NoError(s.service.loadDisabledAgents())
+	s.Equal([]string{testAgentIDStr}, s.service.DisabledAgents())
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, agentConfigs(configs))
+	s.NoError(s.service.EnableAgent(testAgentIDStr))
+	s.Empty(s.service.DisabledAgents())
+}
@@ -0,0 +1,271 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	rpcProxyTimeout      = time.Second * 30
+	rpcProxyMaxCacheSize = 1000
+)
+
+// these methods return the same result forever when they are called with an explicit block number
+var blockScopedMethods = map[string]bool{
+	"eth_call":         true,
+	"eth_getCode":      true,
+	"eth_getBalance":   true,
+	"eth_getStorageAt": true,
+}
+
+// these methods return the same result for the same chain
+var chainScopedMethods = map[string]bool{
+	"eth_chainId": true,
+	"net_version": true,
+}
+
+type rpcMessage struct {
+	JsonRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// RPCProxy is a local JSON-RPC proxy for the registry reads. It fails over between the
+// configured registry endpoints and caches the responses which can never change, so that
+// the outage of a single endpoint does not freeze the assignment updates.
+type RPCProxy struct {
+	ctx       context.Context
+	endpoints []config.JsonRpcConfig
+	client    *http.Client
+	listener  net.Listener
+	server    *http.Server
+
+	active     int
+	lastSwitch health.TimeTracker
+	cache      map[string]json.RawMessage
+	mu         sync.Mutex
+}
+
+// NewRPCProxy creates a new registry RPC proxy which listens on a local port.
+func NewRPCProxy(ctx context.Context, endpoints []config.JsonRpcConfig) (*RPCProxy, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no registry endpoints")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the registry rpc proxy: %v", err)
+	}
+	return &RPCProxy{
+		ctx:       ctx,
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: rpcProxyTimeout},
+		listener:  listener,
+		cache:     make(map[string]json.RawMessage),
+	}, nil
+}
+
+// URL returns the local URL of the proxy.
+func (p *RPCProxy) URL() string {
+	return fmt.Sprintf("http://%s", p.listener.Addr().String())
+}
+
+// Start implements services.Service interface.
+func (p *RPCProxy) Start() error {
+	p.server = &http.Server{Handler: p}
+	go func() {
+		if err := p.server.Serve(p.listener); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("registry rpc proxy failed")
+		}
+	}()
+	return nil
+}
+
+// Stop implements services.Service interface.
+func (p *RPCProxy) Stop() error {
+	log.Infof("Stopping %s", p.Name())
+	if p.server != nil {
+		return p.server.Close()
+	}
+	return p.listener.Close()
+}
+
+// Name implements services.Service interface.
+func (p *RPCProxy) Name() string {
+	return "registry-rpc-proxy"
+}
+
+func (p *RPCProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+
+	var msg rpcMessage
+	cacheKey := ""
+	if err := json.Unmarshal(body, &msg); err == nil {
+		cacheKey = cacheKeyOf(&msg)
+	}
+	if cacheKey != "" {
+		if result, ok := p.cached(cacheKey); ok {
+			writeRPCResponse(w, http.StatusOK, &rpcMessage{JsonRPC: "2.0", ID: msg.ID, Result: result})
+			return
+		}
+	}
+
+	status, respBody, err := p.forward(req.Context(), body)
+	if err != nil {
+		log.WithError(err).Warn("all registry endpoints failed")
+		writeRPCResponse(w, http.StatusBadGateway, &rpcMessage{
+			JsonRPC: "2.0",
+			ID:      msg.ID,
+			Error:   json.RawMessage(`{"code":-32000,"message":"registry endpoints are unavailable"}`),
+		})
+		return
+	}
+
+	if cacheKey != "" {
+		var resp rpcMessage
+		if err := json.Unmarshal(respBody, &resp); err == nil && len(resp.Error) == 0 && len(resp.Result) > 0 {
+			p.store(cacheKey, resp.Result)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(respBody)
+}
+
+// forward tries the endpoints starting from the active one and makes the first healthy
+// endpoint the active one.
+func (p *RPCProxy) forward(ctx context.Context, body []byte) (int, []byte, error) {
+	p.mu.Lock()
+	start := p.active
+	p.mu.Unlock()
+
+	var lastErr error
+	for i := 0; i < len(p.endpoints); i++ {
+		index := (start + i) % len(p.endpoints)
+		status, respBody, err := p.send(ctx, p.endpoints[index], body)
+		if err == nil {
+			p.setActive(index)
+			return status, respBody, nil
+		}
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		log.WithError(err).WithField("endpoint", index).Warn("registry endpoint failed")
+		lastErr = err
+	}
+	return 0, nil, lastErr
+}
+
+func (p *RPCProxy) send(ctx context.Context, endpoint config.JsonRpcConfig, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for h, v := range endpoint.Headers {
+		req.Header.Set(h, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return 0, nil, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+func (p *RPCProxy) setActive(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active == index {
+		return
+	}
+	log.WithFields(log.Fields{
+		"from": p.active,
+		"to":   index,
+	}).Warn("switching the registry endpoint")
+	p.active = index
+	p.lastSwitch.Set()
+}
+
+func (p *RPCProxy) cached(key string) (json.RawMessage, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.cache[key]
+	return result, ok
+}
+
+func (p *RPCProxy) store(key string, result json.RawMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// the cached values are cheap to fetch again so just start over when it is full
+	if len(p.cache) >= rpcProxyMaxCacheSize {
+		p.cache = make(map[string]json.RawMessage)
+	}
+	p.cache[key] = result
+}
+
+// cacheKeyOf returns a key only if the request always yields the same result.
+func cacheKeyOf(msg *rpcMessage) string {
+	if chainScopedMethods[msg.Method] {
+		return msg.Method
+	}
+	if !blockScopedMethods[msg.Method] {
+		return ""
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(msg.Params, &params); err != nil || len(params) == 0 {
+		return ""
+	}
+	var block string
+	if err := json.Unmarshal(params[len(params)-1], &block); err != nil || !strings.HasPrefix(block, "0x") {
+		return ""
+	}
+	return fmt.Sprintf("%s%s", msg.Method, string(msg.Params))
+}
+
+func writeRPCResponse(w http.ResponseWriter, status int, msg *rpcMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(msg)
+}
+
+// Health returns the active endpoint and the last switch time.
+func (p *RPCProxy) Health() health.Reports {
+	p.mu.Lock()
+	active := p.active
+	p.mu.Unlock()
+	return health.Reports{
+		&health.Report{
+			Name:    "registry.endpoint.active",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", active),
+		},
+		p.lastSwitch.GetReport("registry.endpoint.last-switch"),
+	}
+}
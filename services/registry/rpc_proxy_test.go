@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEndpoint(status int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+}
+
+func postRPC(t *testing.T, url, body string) (int, string) {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestRPCProxyFailover(t *testing.T) {
+	r := require.New(t)
+
+	var primaryCalls, fallbackCalls int32
+	primary := newTestEndpoint(http.StatusServiceUnavailable, &primaryCalls)
+	defer primary.Close()
+	fallback := newTestEndpoint(http.StatusOK, &fallbackCalls)
+	defer fallback.Close()
+
+	proxy, err := NewRPCProxy(context.Background(), []config.JsonRpcConfig{{Url: primary.URL}, {Url: fallback.URL}})
+	r.NoError(err)
+	r.NoError(proxy.Start())
+	defer proxy.Stop()
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	status, body := postRPC(t, proxy.URL(), req)
+	r.Equal(http.StatusOK, status)
+	r.Contains(body, `"result":"0x1"`)
+	r.Equal(int32(1), primaryCalls)
+
+	// sticks with the fallback
+	postRPC(t, proxy.URL(), req)
+	r.Equal(int32(1), primaryCalls)
+	r.Equal(int32(2), fallbackCalls)
+	r.Equal("1", proxy.Health()[0].Details)
+}
+
+func TestRPCProxyAllFailed(t *testing.T) {
+	r := require.New(t)
+
+	var calls int32
+	endpoint := newTestEndpoint(http.StatusTooManyRequests, &calls)
+	defer endpoint.Close()
+
+	proxy, err := NewRPCProxy(context.Background(), []config.JsonRpcConfig{{Url: endpoint.URL}})
+	r.NoError(err)
+	r.NoError(proxy.Start())
+	defer proxy.Stop()
+
+	status, body := postRPC(t, proxy.URL(), `{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}`)
+	r.Equal(http.StatusBadGateway, status)
+	r.Contains(body, `"id":7`)
+	r.Contains(body, "-32000")
+}
+
+func TestRPCProxyCache(t *testing.T) {
+	r := require.New(t)
+
+	var calls int32
+	endpoint := newTestEndpoint(http.StatusOK, &calls)
+	defer endpoint.Close()
+
+	proxy, err := NewRPCProxy(context.Background(), []config.JsonRpcConfig{{Url: endpoint.URL}})
+	r.NoError(err)
+	r.NoError(proxy.Start())
+	defer proxy.Stop()
+
+	call := func(id int, block string) string {
+		_, body := postRPC(t, proxy.URL(), fmt.Sprintf(
+			`{"jsonrpc":"2.0","id":%d,"method":"eth_call","params":[{"to":"0x1"},"%s"]}`, id, block,
+		))
+		return body
+	}
+
+	call(1, "0x10")
+	body := call(2, "0x10")
+	r.Equal(int32(1), calls)
+	r.Contains(body, `"id":2`)
+	r.Contains(body, `"result":"0x1"`)
+
+	call(3, "latest")
+	call(4, "latest")
+	r.Equal(int32(3), calls)
+}
@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+var weiPerFort = new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
+// parseStake converts the FORT amount to wei.
+func parseStake(fort string) (*big.Int, error) {
+	if len(fort) == 0 {
+		return nil, nil
+	}
+	amount, ok := new(big.Float).SetString(fort)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid stake amount: %s", fort)
+	}
+	wei, _ := new(big.Float).Mul(amount, weiPerFort).Int(nil)
+	return wei, nil
+}
+
+// filterByStake skips the agents which have less active stake than the minimum. The agents
+// are not skipped if the stake cannot be checked.
+func (rs *RegistryService) filterByStake(agts []*config.AgentConfig) []*config.AgentConfig {
+	if rs.minStake == nil || rs.minStake.Sign() == 0 {
+		return agts
+	}
+	var (
+		filtered []*config.AgentConfig
+		ms       []*protocol.AgentMetric
+	)
+	for _, agt := range agts {
+		logger := log.WithField("agent", agt.ID)
+		stake, err := rs.registryStore.GetAgentStake(agt.ID)
+		if err != nil {
+			logger.WithError(err).Warn("registry: failed to check the agent stake - not skipping")
+			filtered = append(filtered, agt)
+			continue
+		}
+		if stake.Cmp(rs.minStake) < 0 {
+			logger.WithFields(log.Fields{
+				"stake":        stake.String(),
+				"minimumStake": rs.minStake.String(),
+			}).Warn("registry: skipping the agent because the stake is below the minimum")
+			ms = append(ms, metrics.CreateAgentMetric(agt.ID, metrics.MetricStakeTooLow, 1))
+			continue
+		}
+		filtered = append(filtered, agt)
+	}
+	metrics.SendAgentMetrics(rs.msgClient, ms)
+	return filtered
+}
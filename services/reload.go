@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const configWatchInterval = time.Second * 10
+
+// ConfigReloader is implemented by the services which can apply the config changes
+// without a restart.
+type ConfigReloader interface {
+	ReloadConfig(cfg config.Config) error
+	// ReloadableFields returns the paths of the config fields which the service reloads.
+	ReloadableFields() []string
+}
+
+// reloadc receives the SIGHUP signals if the config reloading is enabled.
+var reloadc chan struct{}
+
+func enableConfigReload() {
+	reloadc = make(chan struct{}, 1)
+}
+
+// watchConfig reloads the config when the config files change or when SIGHUP is received.
+func watchConfig(ctx context.Context, logger *log.Entry, cfg config.Config, serviceList []Service) {
+	lastModTime := configModTime()
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-reloadc:
+			logger.Info("received reload signal")
+
+		case <-ticker.C:
+			modTime := configModTime()
+			if modTime.Equal(lastModTime) {
+				continue
+			}
+			lastModTime = modTime
+			logger.Info("config file changed")
+		}
+
+		newCfg, err := config.GetConfigForContainer()
+		if err != nil {
+			logger.WithError(err).Error("failed to read the new config - keeping the current config")
+			continue
+		}
+		reloadConfig(logger, cfg, newCfg, serviceList)
+		cfg = newCfg
+	}
+}
+
+// reloadConfig applies the reloadable changes and reports the ones which require a restart.
+func reloadConfig(logger *log.Entry, oldCfg, newCfg config.Config, serviceList []Service) {
+	// the contract addresses are runtime values which are resolved at the start
+	newCfg.Registry.ContractAddress = oldCfg.Registry.ContractAddress
+
+	reloadable, restart := config.SplitReloadable(config.ChangedFields(oldCfg, newCfg))
+	var (
+		failed         []string
+		failedServices []string
+	)
+	if len(reloadable) > 0 {
+		levels, err := logging.ParseLevels(newCfg.Log)
+		if err != nil {
+			logger.WithError(err).Error("invalid log level - keeping the current levels")
+			failed = append(failed, fieldsUnder(reloadable, "log")...)
+		} else {
+			logging.SetLevels(levels)
+		}
+		for _, service := range serviceList {
+			reloader, ok := service.(ConfigReloader)
+			if !ok {
+				continue
+			}
+			if err := reloader.ReloadConfig(newCfg); err != nil {
+				logger.WithError(err).WithField("service", service.Name()).Error("failed to reload config")
+				failedServices = append(failedServices, service.Name())
+				failed = append(failed, fieldsUnder(reloadable, reloader.ReloadableFields()...)...)
+			}
+		}
+	}
+
+	if len(restart) > 0 || len(failed) > 0 || len(failedServices) > 0 {
+		warnLogger := logger.WithField("fields", append(restart, failed...))
+		if len(failedServices) > 0 {
+			warnLogger = warnLogger.WithField("failedServices", failedServices)
+		}
+		warnLogger.Warn("config changes require a restart")
+	}
+	var reloaded []string
+	for _, field := range reloadable {
+		if !containsField(failed, field) {
+			reloaded = append(reloaded, field)
+		}
+	}
+	if len(reloaded) > 0 {
+		logger.WithField("fields", reloaded).Info("reloaded config")
+	}
+}
+
+// fieldsUnder returns the unique fields which are at or under any of the paths.
+func fieldsUnder(fields []string, paths ...string) (matched []string) {
+	for _, field := range fields {
+		for _, path := range paths {
+			if (field == path || strings.HasPrefix(field, path+".")) && !containsField(matched, field) {
+				matched = append(matched, field)
+			}
+		}
+	}
+	return
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// configModTime returns the last modification time of the config files.
+func configModTime() time.Time {
+	var modTime time.Time
+	for _, filename := range []string{config.DefaultContainerConfigPath, config.DefaultContainerRemoteConfigPath} {
+		info, err := os.Stat(filename)
+		if err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type testReloader struct {
+	TestService
+	reloaded *config.Config
+	err      error
+}
+
+func (tr *testReloader) ReloadConfig(cfg config.Config) error {
+	tr.reloaded = &cfg
+	return tr.err
+}
+
+func (tr *testReloader) ReloadableFields() []string {
+	return []string{"publish.filter"}
+}
+
+func TestReloadConfig(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	var oldCfg, newCfg config.Config
+	oldCfg.Log.Level = "info"
+	newCfg.Log.Level = "trace"
+	reloader := &testReloader{}
+	logger := logrus.WithField("test", "reload")
+
+	// no reload if only the restart requiring fields changed
+	oldCfg.Scan.JsonRpc.Url = "http://old:8545"
+	reloadConfig(logger, oldCfg, config.Config{Log: oldCfg.Log}, []Service{reloader})
+	assert.Nil(t, reloader.reloaded)
+
+	reloadConfig(logger, oldCfg, newCfg, []Service{&TestService{}, reloader})
+	assert.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+	assert.NotNil(t, reloader.reloaded)
+	assert.Equal(t, "trace", reloader.reloaded.Log.Level)
+}
+
+func TestReloadConfigFailed(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	logger, hook := test.NewNullLogger()
+
+	var oldCfg, newCfg config.Config
+	newCfg.Log.Level = "debug"
+	newCfg.Publish.Filter.MinSeverity = "HIGH"
+	reloader := &testReloader{err: errors.New("failed")}
+
+	reloadConfig(logger.WithField("test", "reload"), oldCfg, newCfg, []Service{reloader})
+
+	var warned, reloaded *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		switch entry.Message {
+		case "config changes require a restart":
+			warned = entry
+		case "reloaded config":
+			reloaded = entry
+		}
+	}
+	if assert.NotNil(t, warned) {
+		assert.Equal(t, []string{"publish.filter.minSeverity"}, warned.Data["fields"])
+		assert.Equal(t, []string{reloader.Name()}, warned.Data["failedServices"])
+	}
+	if assert.NotNil(t, reloaded) {
+		assert.Equal(t, []string{"log.level"}, reloaded.Data["fields"])
+	}
+}
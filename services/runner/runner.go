@@ -11,9 +11,11 @@ import (
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/ethereum"
 	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
+	"github.com/forta-network/forta-node/signer"
 	"github.com/forta-network/forta-node/store"
 	log "github.com/sirupsen/logrus"
 )
@@ -32,6 +34,7 @@ type Runner struct {
 	currentSupervisorImg string
 	containerMu          sync.RWMutex // protects above refs and containers
 
+	apiSec       *apisecurity.Security
 	healthClient health.HealthClient
 }
 
@@ -41,17 +44,18 @@ type EthereumClient interface {
 }
 
 // NewRunner creates a new runner.
-func NewRunner(ctx context.Context, cfg config.Config,
+func NewRunner(ctx context.Context, cfg config.Config, apiSec *apisecurity.Security,
 	imgStore store.FortaImageStore, runnerDockerClient clients.DockerClient,
 	globalDockerClient clients.DockerClient,
 ) *Runner {
 	return &Runner{
 		ctx:          ctx,
 		cfg:          cfg,
+		apiSec:       apiSec,
 		imgStore:     imgStore,
 		dockerClient: runnerDockerClient,
 		globalClient: globalDockerClient,
-		healthClient: health.NewClient(),
+		healthClient: healthutils.NewClient(apiSec),
 	}
 }
 
@@ -66,7 +70,9 @@ func (runner *Runner) Start() error {
 		return fmt.Errorf("failed to nuke leftover containers at start: %v", err)
 	}
 
-	health.StartServer(runner.ctx, "", healthutils.DefaultHealthServerErrHandler, runner.checkHealth)
+	if err := healthutils.NewHealthService(runner.ctx, runner.apiSec, runner.checkHealth).Start(); err != nil {
+		return err
+	}
 
 	if runner.cfg.AutoUpdate.Disable || runner.cfg.PrivateModeConfig.Enable {
 		runner.startEmbeddedSupervisor()
@@ -280,6 +286,9 @@ func (runner *Runner) startUpdater(logger *log.Entry, latestRefs store.ImageRefs
 			config.DefaultContainerPort: config.DefaultContainerPort,
 			"":                          config.DefaultHealthPort, // random host port
 		},
+		Files: map[string][]byte{
+			config.DefaultSecretsFileName: runner.cfg.Secrets.Bytes(),
+		},
 		DialHost:    true,
 		MaxLogSize:  runner.cfg.Log.MaxLogSize,
 		MaxLogFiles: runner.cfg.Log.MaxLogFiles,
@@ -303,6 +312,19 @@ func (runner *Runner) startSupervisor(logger *log.Entry, latestRefs store.ImageR
 	if err != nil {
 		return err
 	}
+	runtimeSocket, err := clients.RuntimeSocketPath(runner.cfg.ContainerRuntime)
+	if err != nil {
+		return err
+	}
+	supervisorVolumes := map[string]string{
+		// give access to the host container runtime
+		runtimeSocket:       clients.ContainerRuntimeSocketPath,
+		runner.cfg.FortaDir: config.DefaultContainerFortaDirPath,
+	}
+	if runner.cfg.Signer.Type == signer.TypePKCS11 {
+		// the hsm library is loaded from the same path in the containers
+		supervisorVolumes[runner.cfg.Signer.PKCS11.Module] = runner.cfg.Signer.PKCS11.Module
+	}
 	sc, err := runner.dockerClient.StartContainer(runner.ctx, clients.DockerContainerConfig{
 		Name:  config.DockerSupervisorContainerName,
 		Image: supervisorRef,
@@ -311,17 +333,17 @@ func (runner *Runner) startSupervisor(logger *log.Entry, latestRefs store.ImageR
 			// supervisor needs to know and mount the forta dir on the host os
 			config.EnvHostFortaDir: runner.cfg.FortaDir,
 			config.EnvReleaseInfo:  latestRefs.ReleaseInfo.String(),
+			// supervisor mounts the runtime socket on the host os to the other containers
+			config.EnvHostRuntimeSocket: runtimeSocket,
 		},
-		Volumes: map[string]string{
-			// give access to host docker
-			"/var/run/docker.sock": "/var/run/docker.sock",
-			runner.cfg.FortaDir:    config.DefaultContainerFortaDirPath,
-		},
+		Volumes: supervisorVolumes,
 		Ports: map[string]string{
-			"": config.DefaultHealthPort, // random host port
+			"":           config.DefaultHealthPort, // random host port
+			"127.0.0.1:": config.DefaultAdminPort,  // random localhost port
 		},
 		Files: map[string][]byte{
-			"passphrase": []byte(runner.cfg.Passphrase),
+			"passphrase":                  []byte(runner.cfg.Passphrase),
+			config.DefaultSecretsFileName: runner.cfg.Secrets.Bytes(),
 		},
 		DialHost:    true,
 		MaxLogSize:  runner.cfg.Log.MaxLogSize,
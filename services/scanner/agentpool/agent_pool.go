@@ -2,7 +2,9 @@ package agentpool
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,53 +19,108 @@ import (
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/services/scanner"
 	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/forta-network/forta-node/tracing"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
 )
 
 // AgentPool maintains the pool of agents that the scanner should
 // interact with.
 type AgentPool struct {
 	ctx          context.Context
+	cfg          config.ScannerConfig
 	agents       []*poolagent.Agent
 	txResults    chan *scanner.TxResult
 	blockResults chan *scanner.BlockResult
 	msgClient    clients.MessageClient
 	dialer       func(config.AgentConfig) (clients.AgentClient, error)
+	router       *replicaRouter
+	agentTarget  func(config.AgentConfig) string
 	mu           sync.RWMutex
+
+	// the running old versions of the agents which are stopped after the new versions are attached
+	cutovers map[string]*poolagent.Agent
 }
 
 // NewAgentPool creates a new agent pool.
 func NewAgentPool(ctx context.Context, cfg config.ScannerConfig, msgClient clients.MessageClient) *AgentPool {
 	agentPool := &AgentPool{
 		ctx:          ctx,
+		cfg:          cfg,
 		txResults:    make(chan *scanner.TxResult),
 		blockResults: make(chan *scanner.BlockResult),
 		msgClient:    msgClient,
-		dialer: func(ac config.AgentConfig) (clients.AgentClient, error) {
-			client := agentgrpc.NewClient()
-			if err := client.Dial(ac); err != nil {
-				return nil, err
-			}
-			return client, nil
-		},
+		router:       newReplicaRouter(),
 	}
+	agentPool.dialer = agentPool.dialAgent
 
 	agentPool.registerMessageHandlers()
 	go agentPool.logAgentChanBuffersLoop()
 	return agentPool
 }
 
+// SetAgentTarget overrides the addresses which the agents are dialed at. This is useful when
+// the agent containers are not reachable by name from where the pool runs.
+func (ap *AgentPool) SetAgentTarget(agentTarget func(config.AgentConfig) string) {
+	ap.agentTarget = agentTarget
+}
+
+func (ap *AgentPool) dialAgent(ac config.AgentConfig) (clients.AgentClient, error) {
+	client := agentgrpc.NewClient(ap.cfg.AgentGrpc)
+	if ap.agentTarget != nil {
+		client.WithTarget(ap.agentTarget(ac))
+	}
+	client.OnConnectionEvent(func(event agentgrpc.ConnectionEvent) {
+		metricsList := []*protocol.AgentMetric{
+			metrics.CreateAgentMetric(ac.ID, fmt.Sprintf("%s.%s", metrics.MetricGrpcConnState, strings.ToLower(event.State.String())), 1),
+		}
+		if event.Redialed {
+			metricsList = append(metricsList, metrics.CreateAgentMetric(ac.ID, metrics.MetricGrpcConnRedial, 1))
+		}
+		metrics.SendAgentMetrics(ap.msgClient, metricsList)
+	})
+	client.OnCall(func(stats *agentgrpc.CallStats) {
+		metrics.SendAgentMetrics(ap.msgClient, metrics.GetGrpcCallMetrics(
+			ac.ID, stats.MethodName(), stats.Duration, stats.RequestBytes, stats.ResponseBytes, stats.Code.String(),
+		))
+	})
+	if err := client.Dial(ac); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// CheckReady returns an error if none of the agents in the pool are ready to receive requests.
+func (ap *AgentPool) CheckReady() error {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	if len(ap.agents) == 0 {
+		return nil
+	}
+	for _, agent := range ap.agents {
+		if agent.IsReady() {
+			return nil
+		}
+	}
+	return fmt.Errorf("none of the %d agents are ready", len(ap.agents))
+}
+
 // Health implements health.Reporter interface.
 func (ap *AgentPool) Health() health.Reports {
 	ap.mu.RLock()
 	defer ap.mu.RUnlock()
 
 	agentCount := len(ap.agents)
-	var fullCount int
+	var fullCount, readyCount int
 	for _, agent := range ap.agents {
 		if agent.TxBufferIsFull() {
 			fullCount++
 		}
+		if agent.IsReady() {
+			readyCount++
+		}
 	}
 	status := health.StatusOK
 	if agentCount == 0 {
@@ -75,6 +132,11 @@ func (ap *AgentPool) Health() health.Reports {
 			Status:  status,
 			Details: strconv.Itoa(agentCount),
 		},
+		&health.Report{
+			Name:    "agents.ready",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(readyCount),
+		},
 		&health.Report{
 			Name:    "agents.lagging",
 			Status:  health.StatusInfo,
@@ -83,6 +145,18 @@ func (ap *AgentPool) Health() health.Reports {
 	}
 }
 
+// AgentStatuses returns the runtime statuses of the agents in the pool.
+func (ap *AgentPool) AgentStatuses() []*poolagent.Status {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	statuses := make([]*poolagent.Status, 0, len(ap.agents))
+	for _, agent := range ap.agents {
+		statuses = append(statuses, agent.Status())
+	}
+	return statuses
+}
+
 // Name implements health.Reporter interface.
 func (ap *AgentPool) Name() string {
 	return "agent-pool"
@@ -106,13 +180,15 @@ func (ap *AgentPool) discardAgent(discarded *poolagent.Agent) {
 
 // SendEvaluateTxRequest sends the request to all of the active agents which
 // should be processing the block.
-func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
+func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest, ref *scanner.MessageRef) {
 	startTime := time.Now()
 	lg := log.WithFields(log.Fields{
 		"tx":        req.Event.Transaction.Hash,
 		"component": "pool",
 	})
 	lg.Debug("SendEvaluateTxRequest")
+	_, span := tracing.StartSpan(ap.ctx, req.Event.Block.BlockNumber, "agentpool.dispatch_tx", attribute.String("tx.hash", req.Event.Transaction.Hash))
+	defer span.End()
 
 	ap.mu.RLock()
 	agents := ap.agents
@@ -123,9 +199,26 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 		lg.WithError(err).Error("failed to encode message")
 		return
 	}
+	compressed := ap.compressedMessages(encoded)
+	chunks, err := ap.txChunks(req, encoded)
+	if err != nil {
+		lg.WithError(err).Error("failed to encode message chunks")
+		return
+	}
+	// the same request is shared by the agents
+	txRequest := &poolagent.TxRequest{
+		Original:   req,
+		Encoded:    encoded,
+		Compressed: compressed,
+		Chunks:     chunks,
+		Ref:        ref,
+	}
 	var metricsList []*protocol.AgentMetric
 	for _, agent := range agents {
-		if !agent.IsReady() || !agent.ShouldProcessBlock(req.Event.Block.BlockNumber) {
+		if !agent.IsReady() || !agent.IsServing() || agent.IsPaused() || !agent.ShouldProcessBlock(req.Event.Block.BlockNumber) {
+			continue
+		}
+		if !ap.router.ShouldRouteTx(agent.Config(), req) {
 			continue
 		}
 		lg.WithFields(log.Fields{
@@ -134,16 +227,24 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 		}).Debug("sending tx request to evalTxCh")
 
 		// unblock req send and discard agent if agent is closed
-
+		// and fall to default case if the buffer reached its current limit
+		txRequestCh := agent.TxRequestCh()
+		if agent.TxBufferIsFull() {
+			txRequestCh = nil
+		}
+		ref.Retain()
 		select {
 		case <-agent.Closed():
+			ref.Release()
 			ap.discardAgent(agent)
-		case agent.TxRequestCh() <- &poolagent.TxRequest{
-			Original: req,
-			Encoded:  encoded,
-		}:
+		case txRequestCh <- txRequest:
+			agent.TxRequestSent()
+			metrics.EventsDispatched.WithLabelValues(metrics.EventKindTx).Inc()
 		default: // do not try to send if the buffer is full
+			ref.Release()
 			lg.WithField("agent", agent.Config().ID).Debug("agent tx request buffer is full - skipping")
+			agent.TxRequestDropped()
+			metrics.ObserveEventLoss(metrics.LossStageDispatch, metrics.LossReasonBufferFull, metrics.EventKindTx, agent.Config().ID, 1)
 			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricTxDrop, 1))
 		}
 		lg.WithFields(log.Fields{
@@ -158,6 +259,39 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 	}).Debug("Finished SendEvaluateTxRequest")
 }
 
+// compressedMessages prepares the lazily compressed messages if the compression is enabled.
+func (ap *AgentPool) compressedMessages(encoded *grpc.PreparedMsg) *agentgrpc.CompressedMessages {
+	if len(ap.cfg.AgentGrpc.Compression) == 0 {
+		return nil
+	}
+	return agentgrpc.NewCompressedMessages(encoded)
+}
+
+// txChunks splits the request into multiple messages if the encoded message is larger than
+// the agents can receive, so that the event is not lost.
+func (ap *AgentPool) txChunks(req *protocol.EvaluateTxRequest, encoded *grpc.PreparedMsg) ([]*grpc.PreparedMsg, error) {
+	maxBytes := ap.cfg.AgentGrpc.GetMaxSendMessageBytes()
+	size := agentgrpc.EncodedSize(encoded)
+	if size <= maxBytes {
+		return nil, nil
+	}
+	chunks, truncated, err := agentgrpc.EncodeTxChunks(req, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{
+		"tx":        req.Event.Transaction.Hash,
+		"size":      size,
+		"chunks":    len(chunks),
+		"truncated": truncated,
+	}).Warn("message is too large - splitting the traces")
+	// the traces which are too large even alone are not sent completely
+	if truncated > 0 {
+		metrics.ObserveEventLoss(metrics.LossStageDispatch, metrics.LossReasonTooLarge, metrics.EventKindTx, "", 1)
+	}
+	return chunks, nil
+}
+
 // TxResults returns the receive-only tx results channel.
 func (ap *AgentPool) TxResults() <-chan *scanner.TxResult {
 	return ap.txResults
@@ -165,13 +299,16 @@ func (ap *AgentPool) TxResults() <-chan *scanner.TxResult {
 
 // SendEvaluateBlockRequest sends the request to all of the active agents which
 // should be processing the block.
-func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest) {
+func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest, ref *scanner.MessageRef) {
 	startTime := time.Now()
 	lg := log.WithFields(log.Fields{
 		"block":     req.Event.BlockNumber,
 		"component": "pool",
 	})
 	lg.Debug("SendEvaluateBlockRequest")
+	_, span := tracing.StartSpan(ap.ctx, req.Event.BlockNumber, "agentpool.dispatch_block")
+	defer span.End()
+
 	ap.mu.RLock()
 	agents := ap.agents
 	ap.mu.RUnlock()
@@ -181,10 +318,21 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 		lg.WithError(err).Error("failed to encode message")
 		return
 	}
+	compressed := ap.compressedMessages(encoded)
+	// the same request is shared by the agents
+	blockRequest := &poolagent.BlockRequest{
+		Original:   req,
+		Encoded:    encoded,
+		Compressed: compressed,
+		Ref:        ref,
+	}
 
 	var metricsList []*protocol.AgentMetric
 	for _, agent := range agents {
-		if !agent.IsReady() || !agent.ShouldProcessBlock(req.Event.BlockNumber) {
+		if !agent.IsReady() || !agent.IsServing() || agent.IsPaused() || !agent.ShouldProcessBlock(req.Event.BlockNumber) {
+			continue
+		}
+		if !ap.router.ShouldRouteBlock(agent.Config(), req) {
 			continue
 		}
 
@@ -194,15 +342,24 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 		}).Debug("sending block request to evalBlockCh")
 
 		// unblock req send if agent is closed
+		// and fall to default case if the buffer reached its current limit
+		blockRequestCh := agent.BlockRequestCh()
+		if agent.BlockBufferIsFull() {
+			blockRequestCh = nil
+		}
+		ref.Retain()
 		select {
 		case <-agent.Closed():
+			ref.Release()
 			ap.discardAgent(agent)
-		case agent.BlockRequestCh() <- &poolagent.BlockRequest{
-			Original: req,
-			Encoded:  encoded,
-		}:
+		case blockRequestCh <- blockRequest:
+			agent.BlockRequestSent()
+			metrics.EventsDispatched.WithLabelValues(metrics.EventKindBlock).Inc()
 		default: // do not try to send if the buffer is full
+			ref.Release()
 			lg.WithField("agent", agent.Config().ID).Warn("agent block request buffer is full - skipping")
+			agent.BlockRequestDropped()
+			metrics.ObserveEventLoss(metrics.LossStageDispatch, metrics.LossReasonBufferFull, metrics.EventKindBlock, agent.Config().ID, 1)
 			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricBlockDrop, 1))
 		}
 		lg.WithFields(log.Fields{
@@ -226,7 +383,20 @@ func (ap *AgentPool) logAgentChanBuffersLoop() {
 	ticker := time.NewTicker(time.Second * 30)
 	for range ticker.C {
 		ap.logAgentStatuses()
+		ap.adaptAgentBuffers()
+	}
+}
+
+func (ap *AgentPool) adaptAgentBuffers() {
+	ap.mu.RLock()
+	agents := ap.agents
+	ap.mu.RUnlock()
+
+	var metricsList []*protocol.AgentMetric
+	for _, agent := range agents {
+		metricsList = append(metricsList, agent.AdaptBuffers()...)
 	}
+	metrics.SendAgentMetrics(ap.msgClient, metricsList)
 }
 
 func (ap *AgentPool) logAgentStatuses() {
@@ -263,15 +433,29 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 			found = found || (agent.Config().ContainerName() == agentCfg.ContainerName())
 		}
 		if !found {
-			newAgents = append(newAgents, poolagent.New(ap.ctx, agentCfg, ap.msgClient, ap.txResults, ap.blockResults))
+			newAgents = append(newAgents, poolagent.New(ap.ctx, agentCfg, ap.cfg.GetAgentBufferConfig(agentCfg.ID), ap.cfg.AgentGrpc.IsStreaming(agentCfg.ID), ap.msgClient, ap.txResults, ap.blockResults))
 			agentsToRun = append(agentsToRun, agentCfg)
 			log.WithField("agent", agentCfg.ID).Info("will trigger start")
 		}
 	}
 
+	// The new versions which are not attached yet.
+	var pendingVersions []config.AgentConfig
+	for _, agentCfg := range latestVersions {
+		var ready bool
+		for _, agent := range ap.agents {
+			ready = ready || (agent.Config().ContainerName() == agentCfg.ContainerName() && agent.IsReady())
+		}
+		if !ready {
+			pendingVersions = append(pendingVersions, agentCfg)
+		}
+	}
+
 	// Find the missing agents in the latest versions and send a "stop" message.
 	// Otherwise, add to the new agents list so we keep on running.
+	// The old versions keep running until the new versions are attached.
 	var agentsToStop []config.AgentConfig
+	cutovers := make(map[string]*poolagent.Agent)
 	for _, agent := range ap.agents {
 		var found bool
 		var agentCfg config.AgentConfig
@@ -281,6 +465,12 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 				break
 			}
 		}
+		if newVersion, ok := findNewVersion(agent, pendingVersions); !found && ok {
+			cutovers[newVersion.ContainerName()] = agent
+			newAgents = append(newAgents, agent)
+			log.WithField("agent", agent.Config().ID).WithField("image", agent.Config().Image).Info("will trigger stop after the new version is attached")
+			continue
+		}
 		if !found {
 			agent.Close()
 			agentsToStop = append(agentsToStop, agent.Config())
@@ -291,6 +481,7 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 	}
 
 	ap.agents = newAgents
+	ap.cutovers = cutovers
 	if len(agentsToRun) > 0 {
 		ap.msgClient.Publish(messaging.SubjectAgentsActionRun, agentsToRun)
 	}
@@ -309,13 +500,20 @@ func (ap *AgentPool) handleStatusRunning(payload messaging.AgentPayload) error {
 	for _, agentCfg := range payload {
 		for _, agent := range ap.agents {
 			if agent.Config().ContainerName() == agentCfg.ContainerName() {
-				c, err := ap.dialer(agent.Config())
+				// the supervisor sends the token it injected to the agent container
+				dialCfg := agent.Config()
+				dialCfg.Token = agentCfg.Token
+				c, err := ap.dialer(dialCfg)
 				if err != nil {
 					log.WithField("agent", agent.Config().ID).WithError(err).Error("handleStatusRunning: error while dialing")
 					agentsToStop = append(agentsToStop, agent.Config())
 					continue
 				}
 				agent.SetClient(c)
+				// stop dispatching to the old version before dispatching to the new version
+				if oldVersion, ok := ap.cutOver(agent); ok {
+					agentsToStop = append(agentsToStop, oldVersion)
+				}
 				agent.SetReady()
 				agent.StartProcessing()
 				log.WithField("agent", agent.Config().ID).WithField("image", agent.Config().Image).Info("attached")
@@ -332,6 +530,46 @@ func (ap *AgentPool) handleStatusRunning(payload messaging.AgentPayload) error {
 	return nil
 }
 
+// findNewVersion finds the pending new version of a running agent.
+func findNewVersion(agent *poolagent.Agent, pendingVersions []config.AgentConfig) (config.AgentConfig, bool) {
+	if !agent.IsReady() || agent.IsClosed() {
+		return config.AgentConfig{}, false
+	}
+	for _, agentCfg := range pendingVersions {
+		if agentCfg.ID == agent.Config().ID && agentCfg.Replica == agent.Config().Replica {
+			return agentCfg, true
+		}
+	}
+	return config.AgentConfig{}, false
+}
+
+// cutOver removes the old version of the attached agent from the pool.
+func (ap *AgentPool) cutOver(attached *poolagent.Agent) (config.AgentConfig, bool) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	oldVersion, ok := ap.cutovers[attached.Config().ContainerName()]
+	if !ok {
+		return config.AgentConfig{}, false
+	}
+	delete(ap.cutovers, attached.Config().ContainerName())
+
+	var newAgents []*poolagent.Agent
+	for _, agent := range ap.agents {
+		if agent != oldVersion {
+			newAgents = append(newAgents, agent)
+		}
+	}
+	ap.agents = newAgents
+	oldVersion.Close()
+	log.WithFields(log.Fields{
+		"agent":    oldVersion.Config().ID,
+		"oldImage": oldVersion.Config().Image,
+		"newImage": attached.Config().Image,
+	}).Info("cut over to the new version")
+	return oldVersion.Config(), true
+}
+
 func (ap *AgentPool) handleStatusStopped(payload messaging.AgentPayload) error {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
@@ -357,8 +595,41 @@ func (ap *AgentPool) handleStatusStopped(payload messaging.AgentPayload) error {
 	return nil
 }
 
+// handleActionPause stops sending the requests to the agents which the supervisor shed
+// because the host is under pressure.
+func (ap *AgentPool) handleActionPause(payload messaging.AgentPayload) error {
+	ap.setPaused(payload, true)
+	return nil
+}
+
+// handleActionResume starts sending the requests to the agents again.
+func (ap *AgentPool) handleActionResume(payload messaging.AgentPayload) error {
+	ap.setPaused(payload, false)
+	return nil
+}
+
+func (ap *AgentPool) setPaused(payload messaging.AgentPayload, paused bool) {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	for _, agentCfg := range payload {
+		for _, agent := range ap.agents {
+			if agent.Config().ContainerName() != agentCfg.ContainerName() {
+				continue
+			}
+			agent.SetPaused(paused)
+			log.WithFields(log.Fields{
+				"agent":  agent.Config().ContainerName(),
+				"paused": paused,
+			}).Info("changed agent dispatch")
+		}
+	}
+}
+
 func (ap *AgentPool) registerMessageHandlers() {
 	ap.msgClient.Subscribe(messaging.SubjectAgentsVersionsLatest, messaging.AgentsHandler(ap.handleAgentVersionsUpdate))
 	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusRunning, messaging.AgentsHandler(ap.handleStatusRunning))
 	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusStopped, messaging.AgentsHandler(ap.handleStatusStopped))
+	ap.msgClient.Subscribe(messaging.SubjectAgentsActionPause, messaging.AgentsHandler(ap.handleActionPause))
+	ap.msgClient.Subscribe(messaging.SubjectAgentsActionResume, messaging.AgentsHandler(ap.handleActionResume))
 }
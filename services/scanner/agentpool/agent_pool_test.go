@@ -14,6 +14,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -51,6 +52,7 @@ func (s *Suite) SetupTest() {
 		txResults:    make(chan *scanner.TxResult),
 		blockResults: make(chan *scanner.BlockResult),
 		msgClient:    s.msgClient,
+		router:       newReplicaRouter(),
 		dialer: func(agentCfg config.AgentConfig) (clients.AgentClient, error) {
 			return s.agentClient, nil
 		},
@@ -77,10 +79,12 @@ func (s *Suite) TestStartProcessStop() {
 	// Given that the agent is known to the pool but it is not ready yet
 	s.r.Equal(1, len(s.ap.agents))
 	s.r.False(s.ap.agents[0].IsReady())
+	s.r.Error(s.ap.CheckReady())
 	// When the agent pool receives a message saying that the agent started to run
 	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
 	// Then the agent must be marked ready
 	s.r.True(s.ap.agents[0].IsReady())
+	s.r.NoError(s.ap.CheckReady())
 
 	// Given that the agent is running
 	// When an evaluate requests are received
@@ -102,7 +106,7 @@ func (s *Suite) TestStartProcessStop() {
 		gomock.Any(), agentgrpc.MethodEvaluateTx,
 		gomock.AssignableToTypeOf(&grpc.PreparedMsg{}), gomock.AssignableToTypeOf(&protocol.EvaluateTxResponse{}),
 	).Return(nil)
-	s.ap.SendEvaluateTxRequest(txReq)
+	s.ap.SendEvaluateTxRequest(txReq, nil)
 	txResult := <-s.ap.TxResults()
 	txResp.Timestamp = txResult.Response.Timestamp // bypass - hard to match
 
@@ -111,7 +115,7 @@ func (s *Suite) TestStartProcessStop() {
 		gomock.AssignableToTypeOf(&grpc.PreparedMsg{}), gomock.AssignableToTypeOf(&protocol.EvaluateBlockResponse{}),
 	).Return(nil)
 	s.msgClient.EXPECT().Publish(messaging.SubjectScannerBlock, gomock.Any())
-	s.ap.SendEvaluateBlockRequest(blockReq)
+	s.ap.SendEvaluateBlockRequest(blockReq, nil)
 	blockResult := <-s.ap.BlockResults()
 	blockResp.Timestamp = blockResult.Response.Timestamp // bypass - hard to match
 
@@ -128,3 +132,86 @@ func (s *Suite) TestStartProcessStop() {
 	s.agentClient.EXPECT().Close()
 	s.r.NoError(s.ap.handleAgentVersionsUpdate(emptyPayload))
 }
+
+// TestVersionCutover tests that the old version of an agent keeps running until the new version is attached.
+func (s *Suite) TestVersionCutover() {
+	oldVersion := config.AgentConfig{
+		ID:    testAgentID,
+		Image: "disco.forta.network/bafybeiold@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+	}
+	newVersion := config.AgentConfig{
+		ID:    testAgentID,
+		Image: "disco.forta.network/bafybeinew@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, messaging.AgentPayload{oldVersion})
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(messaging.AgentPayload{oldVersion}))
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, gomock.Any())
+	s.r.NoError(s.ap.handleStatusRunning(messaging.AgentPayload{oldVersion}))
+
+	// When the new version arrives, only the new version should be started
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, messaging.AgentPayload{newVersion})
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(messaging.AgentPayload{newVersion}))
+	s.r.Len(s.ap.agents, 2)
+	oldAgent := s.ap.agents[1]
+	s.r.Equal(oldVersion, oldAgent.Config())
+	s.r.True(oldAgent.IsReady())
+
+	// The same update again should not stop the old version
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(messaging.AgentPayload{newVersion}))
+	s.r.Len(s.ap.agents, 2)
+
+	// When the new version is attached, the old version should be stopped
+	s.agentClient.EXPECT().Close()
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, gomock.Any())
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, []config.AgentConfig{oldVersion})
+	s.r.NoError(s.ap.handleStatusRunning(messaging.AgentPayload{newVersion}))
+	s.r.Len(s.ap.agents, 1)
+	s.r.Equal(newVersion, s.ap.agents[0].Config())
+	s.r.True(oldAgent.IsClosed())
+}
+
+// TestPauseResume tests that the requests are not sent to the paused agents.
+func (s *Suite) TestPauseResume() {
+	agentConfig := config.AgentConfig{
+		ID: testAgentID,
+	}
+	agentPayload := messaging.AgentPayload{agentConfig}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, gomock.Any())
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, gomock.Any())
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+
+	// When the agent is paused, the block request should not be sent to it
+	s.r.NoError(s.ap.handleActionPause(agentPayload))
+	s.r.True(s.ap.agents[0].IsPaused())
+	s.r.True(s.ap.agents[0].Status().Paused)
+	s.msgClient.EXPECT().Publish(messaging.SubjectScannerBlock, gomock.Any())
+	s.ap.SendEvaluateBlockRequest(&protocol.EvaluateBlockRequest{Event: &protocol.BlockEvent{BlockNumber: "123123"}}, nil)
+
+	// When the agent is resumed, the requests should be sent again
+	s.r.NoError(s.ap.handleActionResume(agentPayload))
+	s.r.False(s.ap.agents[0].IsPaused())
+	s.agentClient.EXPECT().Invoke(
+		gomock.Any(), agentgrpc.MethodEvaluateBlock,
+		gomock.AssignableToTypeOf(&grpc.PreparedMsg{}), gomock.AssignableToTypeOf(&protocol.EvaluateBlockResponse{}),
+	).Return(nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectScannerBlock, gomock.Any())
+	s.ap.SendEvaluateBlockRequest(&protocol.EvaluateBlockRequest{Event: &protocol.BlockEvent{BlockNumber: "123123"}}, nil)
+	<-s.ap.BlockResults()
+}
+
+// TestMetricsCollector tests that the agent statuses are exported as metrics.
+func (s *Suite) TestMetricsCollector() {
+	collector := NewMetricsCollector(s.ap)
+	s.r.Equal(0, testutil.CollectAndCount(collector))
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, gomock.Any())
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(messaging.AgentPayload{{ID: testAgentID}}))
+
+	s.r.Equal(10, testutil.CollectAndCount(collector))
+	s.r.Equal(1, testutil.CollectAndCount(collector, "forta_agent_ready"))
+	s.r.Equal(2, testutil.CollectAndCount(collector, "forta_agent_latency_milliseconds"))
+	s.r.Equal(1, testutil.CollectAndCount(collector, "forta_agent_findings_total"))
+}
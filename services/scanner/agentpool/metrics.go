@@ -0,0 +1,75 @@
+package agentpool
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	agentReadyDesc = prometheus.NewDesc(
+		"forta_agent_ready", "Whether the agent replica is ready to receive requests",
+		[]string{"agent", "replica"}, nil,
+	)
+	agentBufferDesc = prometheus.NewDesc(
+		"forta_agent_buffer_depth", "Number of requests waiting in the agent replica buffer by kind",
+		[]string{"agent", "replica", "kind"}, nil,
+	)
+	agentLatencyDesc = prometheus.NewDesc(
+		"forta_agent_latency_milliseconds", "Moving average of the agent replica request latency by kind",
+		[]string{"agent", "replica", "kind"}, nil,
+	)
+	agentErrorsDesc = prometheus.NewDesc(
+		"forta_agent_errors_total", "Number of failed agent replica requests by kind",
+		[]string{"agent", "replica", "kind"}, nil,
+	)
+	agentProcessedDesc = prometheus.NewDesc(
+		"forta_agent_processed_total", "Number of successful agent replica requests by kind",
+		[]string{"agent", "replica", "kind"}, nil,
+	)
+	agentFindingsDesc = prometheus.NewDesc(
+		"forta_agent_findings_total", "Number of findings returned by the agent replica",
+		[]string{"agent", "replica"}, nil,
+	)
+)
+
+// MetricsCollector exports the agent statuses in the pool as Prometheus metrics.
+type MetricsCollector struct {
+	pool *AgentPool
+}
+
+// NewMetricsCollector creates a new collector for the agent pool.
+func NewMetricsCollector(pool *AgentPool) *MetricsCollector {
+	return &MetricsCollector{pool: pool}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (mc *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- agentReadyDesc
+	ch <- agentBufferDesc
+	ch <- agentLatencyDesc
+	ch <- agentErrorsDesc
+	ch <- agentProcessedDesc
+	ch <- agentFindingsDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (mc *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range mc.pool.AgentStatuses() {
+		replica := strconv.FormatUint(uint64(status.Replica), 10)
+		var ready float64
+		if status.Ready {
+			ready = 1
+		}
+		ch <- prometheus.MustNewConstMetric(agentReadyDesc, prometheus.GaugeValue, ready, status.ID, replica)
+		ch <- prometheus.MustNewConstMetric(agentBufferDesc, prometheus.GaugeValue, float64(status.TxBuffer), status.ID, replica, "tx")
+		ch <- prometheus.MustNewConstMetric(agentBufferDesc, prometheus.GaugeValue, float64(status.BlockBuffer), status.ID, replica, "block")
+		ch <- prometheus.MustNewConstMetric(agentLatencyDesc, prometheus.GaugeValue, status.TxLatencyMs, status.ID, replica, "tx")
+		ch <- prometheus.MustNewConstMetric(agentLatencyDesc, prometheus.GaugeValue, status.BlockLatencyMs, status.ID, replica, "block")
+		ch <- prometheus.MustNewConstMetric(agentErrorsDesc, prometheus.CounterValue, float64(status.TxErrors), status.ID, replica, "tx")
+		ch <- prometheus.MustNewConstMetric(agentErrorsDesc, prometheus.CounterValue, float64(status.BlockErrors), status.ID, replica, "block")
+		ch <- prometheus.MustNewConstMetric(agentProcessedDesc, prometheus.CounterValue, float64(status.TxProcessed), status.ID, replica, "tx")
+		ch <- prometheus.MustNewConstMetric(agentProcessedDesc, prometheus.CounterValue, float64(status.BlockProcessed), status.ID, replica, "block")
+		ch <- prometheus.MustNewConstMetric(agentFindingsDesc, prometheus.CounterValue, float64(status.Findings), status.ID, replica)
+	}
+}
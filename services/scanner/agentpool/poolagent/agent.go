@@ -2,13 +2,19 @@ package poolagent
 
 import (
 	"context"
+	"errors"
 	"github.com/forta-network/forta-core-go/domain"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients"
@@ -22,7 +28,7 @@ import (
 
 // Constants
 const (
-	DefaultBufferSize = 2000
+	DefaultBufferSize = config.DefaultAgentBufferSize
 	AgentTimeout      = 30 * time.Second
 	MaxFindings       = 10
 )
@@ -34,42 +40,74 @@ type Agent struct {
 
 	txRequests    chan *TxRequest // never closed - deallocated when agent is discarded
 	txResults     chan<- *scanner.TxResult
+	txBuffer      *bufferLimit
 	blockRequests chan *BlockRequest // never closed - deallocated when agent is discarded
 	blockResults  chan<- *scanner.BlockResult
+	blockBuffer   *bufferLimit
 
 	errCounter *errorCounter
+	stats      agentStats
 	msgClient  clients.MessageClient
-
-	client    clients.AgentClient
-	ready     chan struct{}
-	readyOnce sync.Once
-	closed    chan struct{}
-	closeOnce sync.Once
+	streaming  bool
+
+	client     clients.AgentClient
+	notServing uint32 // set atomically
+	paused     uint32 // set atomically
+	ready      chan struct{}
+	readyOnce  sync.Once
+	closed     chan struct{}
+	closeOnce  sync.Once
 }
 
 // TxRequest contains the original request data and the encoded message.
 type TxRequest struct {
-	Original *protocol.EvaluateTxRequest
-	Encoded  *grpc.PreparedMsg
+	Original   *protocol.EvaluateTxRequest
+	Encoded    *grpc.PreparedMsg
+	Compressed *agentgrpc.CompressedMessages
+	// Chunks is set when the encoded message is too large and the traces are split.
+	Chunks []*grpc.PreparedMsg
+	// Ref is released when the agent is done with the request.
+	Ref *scanner.MessageRef
 }
 
 // BlockRequest contains the original request data and the encoded message.
 type BlockRequest struct {
-	Original *protocol.EvaluateBlockRequest
-	Encoded  *grpc.PreparedMsg
+	Original   *protocol.EvaluateBlockRequest
+	Encoded    *grpc.PreparedMsg
+	Compressed *agentgrpc.CompressedMessages
+	// Ref is released when the agent is done with the request.
+	Ref *scanner.MessageRef
+}
+
+func callOptions(compressed *agentgrpc.CompressedMessages) []grpc.CallOption {
+	if compressed == nil {
+		return nil
+	}
+	return []grpc.CallOption{compressed}
 }
 
 // New creates a new agent.
-func New(ctx context.Context, agentCfg config.AgentConfig, msgClient clients.MessageClient, txResults chan<- *scanner.TxResult, blockResults chan<- *scanner.BlockResult) *Agent {
+func New(ctx context.Context, agentCfg config.AgentConfig, bufCfg config.AgentBufferConfig, streaming bool, msgClient clients.MessageClient, txResults chan<- *scanner.TxResult, blockResults chan<- *scanner.BlockResult) *Agent {
+	if bufCfg.Size <= 0 {
+		bufCfg.Size = DefaultBufferSize
+	}
+	if bufCfg.MaxSize < bufCfg.Size {
+		bufCfg.MaxSize = bufCfg.Size
+	}
+	txBuffer := newBufferLimit(bufCfg)
+	blockBuffer := newBufferLimit(bufCfg)
 	return &Agent{
 		ctx:           ctx,
 		config:        agentCfg,
-		txRequests:    make(chan *TxRequest, DefaultBufferSize),
+		txRequests:    make(chan *TxRequest, txBuffer.Capacity()),
 		txResults:     txResults,
-		blockRequests: make(chan *BlockRequest, DefaultBufferSize),
+		txBuffer:      txBuffer,
+		blockRequests: make(chan *BlockRequest, blockBuffer.Capacity()),
 		blockResults:  blockResults,
+		blockBuffer:   blockBuffer,
 		errCounter:    NewErrorCounter(3, isCriticalErr),
 		msgClient:     msgClient,
+		streaming:     streaming,
 		ready:         make(chan struct{}),
 		closed:        make(chan struct{}),
 	}
@@ -82,20 +120,64 @@ func isCriticalErr(err error) bool {
 	// 	strings.Contains(errStr, codes.Unavailable.String())
 }
 
+// lossReason tells if the request was lost because the agent did not respond in time.
+func lossReason(err error) string {
+	if status.Code(err) == codes.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded) {
+		return metrics.LossReasonTimeout
+	}
+	return metrics.LossReasonError
+}
+
 // LogStatus logs the status of the agent.
 func (agent *Agent) LogStatus() {
 	log.WithFields(log.Fields{
-		"agent":       agent.config.ID,
-		"blockBuffer": len(agent.blockRequests),
-		"txBuffer":    len(agent.txRequests),
-		"ready":       agent.IsReady(),
-		"closed":      agent.IsClosed(),
+		"agent":            agent.config.ID,
+		"blockBuffer":      len(agent.blockRequests),
+		"blockBufferLimit": agent.blockBuffer.Limit(),
+		"txBuffer":         len(agent.txRequests),
+		"txBufferLimit":    agent.txBuffer.Limit(),
+		"ready":            agent.IsReady(),
+		"closed":           agent.IsClosed(),
 	}).Debug("agent status")
 }
 
 // TxBufferIsFull tells if an agent input buffer is full.
 func (agent *Agent) TxBufferIsFull() bool {
-	return len(agent.txRequests) == DefaultBufferSize
+	return agent.txBuffer.IsFull(len(agent.txRequests))
+}
+
+// BlockBufferIsFull tells if an agent input buffer is full.
+func (agent *Agent) BlockBufferIsFull() bool {
+	return agent.blockBuffer.IsFull(len(agent.blockRequests))
+}
+
+// TxRequestSent records the buffer usage after a tx request is sent.
+func (agent *Agent) TxRequestSent() {
+	agent.txBuffer.Observe(len(agent.txRequests))
+}
+
+// TxRequestDropped records a dropped tx request.
+func (agent *Agent) TxRequestDropped() {
+	agent.txBuffer.Drop()
+}
+
+// BlockRequestSent records the buffer usage after a block request is sent.
+func (agent *Agent) BlockRequestSent() {
+	agent.blockBuffer.Observe(len(agent.blockRequests))
+}
+
+// BlockRequestDropped records a dropped block request.
+func (agent *Agent) BlockRequestDropped() {
+	agent.blockBuffer.Drop()
+}
+
+// AdaptBuffers adjusts the buffer limits to the observed throughput and returns
+// the high-water mark metrics of the last observation window.
+func (agent *Agent) AdaptBuffers() []*protocol.AgentMetric {
+	return []*protocol.AgentMetric{
+		metrics.CreateAgentMetric(agent.config.ID, metrics.MetricTxBufferHighWater, float64(agent.txBuffer.Adapt())),
+		metrics.CreateAgentMetric(agent.config.ID, metrics.MetricBlockBufferHighWater, float64(agent.blockBuffer.Adapt())),
+	}
 }
 
 // Config returns the agent config.
@@ -160,14 +242,64 @@ func isChanClosed(ch chan struct{}) bool {
 	}
 }
 
+// healthWatcher is implemented by the clients which watch the agent health.
+type healthWatcher interface {
+	OnHealthChange(handler func(serving bool))
+}
+
 // SetClient sets the agent client for sending the requests.
 func (agent *Agent) SetClient(agentClient clients.AgentClient) {
 	agent.client = agentClient
+	if hw, ok := agentClient.(healthWatcher); ok {
+		hw.OnHealthChange(agent.setServing)
+	}
+}
+
+func (agent *Agent) setServing(serving bool) {
+	var notServing uint32
+	if !serving {
+		notServing = 1
+	}
+	if atomic.SwapUint32(&agent.notServing, notServing) != notServing {
+		log.WithFields(log.Fields{
+			"agent":   agent.config.ID,
+			"serving": serving,
+		}).Info("agent health changed")
+		// let the supervisor restart the unresponsive agent
+		if !serving && !agent.IsClosed() {
+			agent.msgClient.Publish(messaging.SubjectAgentsStatusUnhealthy, messaging.AgentPayload{agent.config})
+		}
+	}
+}
+
+// IsServing tells if the agent reports that it is serving. The agents which do not
+// implement the health protocol are always serving.
+func (agent *Agent) IsServing() bool {
+	return atomic.LoadUint32(&agent.notServing) == 0
+}
+
+// SetPaused pauses or resumes sending the requests to the agent.
+func (agent *Agent) SetPaused(paused bool) {
+	var value uint32
+	if paused {
+		value = 1
+	}
+	atomic.StoreUint32(&agent.paused, value)
+}
+
+// IsPaused tells if sending the requests to the agent is paused because the host is under pressure.
+func (agent *Agent) IsPaused() bool {
+	return atomic.LoadUint32(&agent.paused) == 1
 }
 
 // StartProcessing launches the goroutines to concurrently process incoming requests
 // from request channels.
 func (agent *Agent) StartProcessing() {
+	if agent.streaming {
+		go agent.streamTransactions()
+		go agent.streamBlocks()
+		return
+	}
 	go agent.processTransactions()
 	go agent.processBlocks()
 }
@@ -179,66 +311,136 @@ func (agent *Agent) processTransactions() {
 		"evaluate":  "transaction",
 	})
 	for request := range agent.txRequests {
-		startTime := time.Now()
 		if agent.IsClosed() {
+			request.Ref.Release()
 			return
 		}
-		ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
-		lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
-		resp := new(protocol.EvaluateTxResponse)
-
-		requestTime := time.Now().UTC()
-		err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateTx, request.Encoded, resp)
-		responseTime := time.Now().UTC()
-		cancel()
-		if err == nil {
-			// truncate findings
-			if len(resp.Findings) > MaxFindings {
-				dropped := len(resp.Findings) - MaxFindings
-				droppedMetric := metrics.CreateAgentMetric(agent.config.ID, metrics.MetricFindingsDropped, float64(dropped))
-				agent.msgClient.PublishProto(messaging.SubjectMetricAgent, droppedMetric)
-				resp.Findings = resp.Findings[:MaxFindings]
-			}
-			var duration time.Duration
-			resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
-			lg.WithField("duration", duration).Debugf("request successful")
-
-			if resp.Metadata == nil {
-				resp.Metadata = make(map[string]string)
-			}
-			resp.Metadata["imageHash"] = agent.config.ImageHash()
-
-			ts := domain.TrackingTimestampsFromMessage(request.Original.Event.Timestamps)
-			ts.BotRequest = requestTime
-			ts.BotResponse = responseTime
-
-			agent.txResults <- &scanner.TxResult{
-				AgentConfig: agent.config,
-				Request:     request.Original,
-				Response:    resp,
-				Timestamps:  ts,
-			}
-			lg.WithField("duration", time.Since(startTime)).Debugf("sent results")
-			continue
-		}
-		lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
-		if agent.errCounter.TooManyErrs(err) {
-			lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
-			agent.Close()
-			agent.msgClient.Publish(messaging.SubjectAgentsActionStop, messaging.AgentPayload{agent.config})
-			agent.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
-				Metrics: []*protocol.AgentMetric{{
-					AgentId:   agent.config.ID,
-					Timestamp: time.Now().Format(time.RFC3339),
-					Name:      metrics.MetricStop,
-					Value:     1,
-				}},
-			})
+		if agent.processTxRequest(lg, request) {
 			return
 		}
 	}
 }
 
+// processTxRequest invokes the agent with the tx request and tells if the agent should stop processing.
+func (agent *Agent) processTxRequest(lg *log.Entry, request *TxRequest) bool {
+	startTime := time.Now()
+	ctx, span := tracing.StartSpan(
+		agent.ctx, request.Original.Event.Block.BlockNumber, "agent.evaluate_tx",
+		agent.spanAttributes(attribute.String("tx.hash", request.Original.Event.Transaction.Hash))...,
+	)
+	ctx, cancel := context.WithTimeout(agentgrpc.ContextWithTraceID(ctx, request.Original.RequestId), AgentTimeout)
+	lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
+	resp := new(protocol.EvaluateTxResponse)
+
+	requestTime := time.Now().UTC()
+	err := agent.evaluateTx(ctx, request, resp)
+	responseTime := time.Now().UTC()
+	cancel()
+	tracing.EndSpan(span, err)
+	agent.stats.TxDone(responseTime.Sub(requestTime), err)
+	if err == nil {
+		agent.sendTxResult(lg, request, resp, startTime, requestTime, responseTime)
+		return false
+	}
+	lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
+	metrics.ObserveEventLoss(metrics.LossStageAgent, lossReason(err), metrics.EventKindTx, agent.config.ID, 1)
+	request.Ref.Release()
+	return agent.handleTxErr(lg, startTime, err)
+}
+
+// evaluateTx invokes the agent with the tx request or with the chunks of it.
+func (agent *Agent) evaluateTx(ctx context.Context, request *TxRequest, resp *protocol.EvaluateTxResponse) error {
+	if len(request.Chunks) == 0 {
+		return agent.client.Invoke(ctx, agentgrpc.MethodEvaluateTx, request.Encoded, resp, callOptions(request.Compressed)...)
+	}
+	for i, chunk := range request.Chunks {
+		chunkResp := new(protocol.EvaluateTxResponse)
+		chunkCtx := agentgrpc.ContextWithTraceChunk(ctx, i, len(request.Chunks))
+		if err := agent.client.Invoke(chunkCtx, agentgrpc.MethodEvaluateTx, chunk, chunkResp); err != nil {
+			return err
+		}
+		mergeTxResponse(resp, chunkResp)
+	}
+	return nil
+}
+
+// mergeTxResponse merges the response of a chunk into the combined response.
+func mergeTxResponse(resp, chunkResp *protocol.EvaluateTxResponse) {
+	if resp.Status != protocol.ResponseStatus_ERROR {
+		resp.Status = chunkResp.Status
+	}
+	resp.Errors = append(resp.Errors, chunkResp.Errors...)
+	resp.Findings = append(resp.Findings, chunkResp.Findings...)
+	if len(chunkResp.Metadata) > 0 && resp.Metadata == nil {
+		resp.Metadata = make(map[string]string)
+	}
+	for k, v := range chunkResp.Metadata {
+		resp.Metadata[k] = v
+	}
+	resp.Private = resp.Private || chunkResp.Private
+}
+
+// countTruncatedFindings counts the findings which exceeded the max number of findings.
+func (agent *Agent) countTruncatedFindings(findings []*protocol.Finding, chainIDHex string) {
+	chainID, _ := hexutil.DecodeUint64(chainIDHex)
+	for _, finding := range findings {
+		labels := append(metrics.FindingLabels(agent.config.ID, finding, chainID), metrics.DropReasonTruncated)
+		metrics.FindingsDropped.WithLabelValues(labels...).Inc()
+	}
+}
+
+func (agent *Agent) sendTxResult(lg *log.Entry, request *TxRequest, resp *protocol.EvaluateTxResponse, startTime, requestTime, responseTime time.Time) {
+	// truncate findings
+	if len(resp.Findings) > MaxFindings {
+		dropped := len(resp.Findings) - MaxFindings
+		droppedMetric := metrics.CreateAgentMetric(agent.config.ID, metrics.MetricFindingsDropped, float64(dropped))
+		agent.msgClient.PublishProto(messaging.SubjectMetricAgent, droppedMetric)
+		agent.countTruncatedFindings(resp.Findings[MaxFindings:], request.Original.Event.GetNetwork().GetChainId())
+		resp.Findings = resp.Findings[:MaxFindings]
+	}
+	var duration time.Duration
+	resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
+	lg.WithField("duration", duration).Debugf("request successful")
+
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]string)
+	}
+	resp.Metadata["imageHash"] = agent.config.ImageHash()
+	agent.stats.FindingsSent(len(resp.Findings))
+
+	ts := domain.TrackingTimestampsFromMessage(request.Original.Event.Timestamps)
+	ts.BotRequest = requestTime
+	ts.BotResponse = responseTime
+
+	agent.txResults <- &scanner.TxResult{
+		AgentConfig: agent.config,
+		Request:     request.Original,
+		Response:    resp,
+		Timestamps:  ts,
+		Ref:         request.Ref,
+	}
+	lg.WithField("duration", time.Since(startTime)).Debugf("sent results")
+}
+
+// handleTxErr tells if the agent should stop processing.
+func (agent *Agent) handleTxErr(lg *log.Entry, startTime time.Time, err error) bool {
+	if !agent.errCounter.TooManyErrs(err) {
+		return false
+	}
+	lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
+	agent.Close()
+	agent.msgClient.Publish(messaging.SubjectAgentsActionStop, messaging.AgentPayload{agent.config})
+	agent.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
+		Metrics: []*protocol.AgentMetric{{
+			AgentId:   agent.config.ID,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Name:      metrics.MetricStop,
+			Value:     1,
+		}},
+	})
+	return true
+}
+
 func (agent *Agent) processBlocks() {
 	lg := log.WithFields(log.Fields{
 		"agent":     agent.config.ID,
@@ -246,58 +448,91 @@ func (agent *Agent) processBlocks() {
 		"evaluate":  "block",
 	})
 	for request := range agent.blockRequests {
-		startTime := time.Now()
 		if agent.IsClosed() {
+			request.Ref.Release()
 			return
 		}
-
-		ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
-		lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
-		resp := new(protocol.EvaluateBlockResponse)
-		requestTime := time.Now().UTC()
-		err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateBlock, request.Encoded, resp)
-		responseTime := time.Now().UTC()
-		cancel()
-		if err == nil {
-			// truncate findings
-			if len(resp.Findings) > MaxFindings {
-				dropped := len(resp.Findings) - MaxFindings
-				droppedMetric := metrics.CreateAgentMetric(agent.config.ID, metrics.MetricFindingsDropped, float64(dropped))
-				agent.msgClient.PublishProto(messaging.SubjectMetricAgent, droppedMetric)
-				resp.Findings = resp.Findings[:MaxFindings]
-			}
-			var duration time.Duration
-			resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
-			lg.WithField("duration", duration).Debugf("request successful")
-
-			if resp.Metadata == nil {
-				resp.Metadata = make(map[string]string)
-			}
-			resp.Metadata["imageHash"] = agent.config.ImageHash()
-
-			ts := domain.TrackingTimestampsFromMessage(request.Original.Event.Timestamps)
-			ts.BotRequest = requestTime
-			ts.BotResponse = responseTime
-
-			agent.blockResults <- &scanner.BlockResult{
-				AgentConfig: agent.config,
-				Request:     request.Original,
-				Response:    resp,
-				Timestamps:  ts,
-			}
-			lg.WithField("duration", time.Since(startTime)).Debugf("sent results")
-			continue
-		}
-		lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
-		if agent.errCounter.TooManyErrs(err) {
-			lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
-			agent.Close()
-			agent.msgClient.Publish(messaging.SubjectAgentsActionStop, messaging.AgentPayload{agent.config})
+		if agent.processBlockRequest(lg, request) {
 			return
 		}
 	}
 }
 
+// processBlockRequest invokes the agent with the block request and tells if the agent should stop processing.
+func (agent *Agent) processBlockRequest(lg *log.Entry, request *BlockRequest) bool {
+	startTime := time.Now()
+	ctx, span := tracing.StartSpan(agent.ctx, request.Original.Event.BlockNumber, "agent.evaluate_block", agent.spanAttributes()...)
+	ctx, cancel := context.WithTimeout(agentgrpc.ContextWithTraceID(ctx, request.Original.RequestId), AgentTimeout)
+	lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
+	resp := new(protocol.EvaluateBlockResponse)
+	requestTime := time.Now().UTC()
+	err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateBlock, request.Encoded, resp, callOptions(request.Compressed)...)
+	responseTime := time.Now().UTC()
+	cancel()
+	tracing.EndSpan(span, err)
+	agent.stats.BlockDone(responseTime.Sub(requestTime), err)
+	if err == nil {
+		agent.sendBlockResult(lg, request, resp, startTime, requestTime, responseTime)
+		return false
+	}
+	lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
+	metrics.ObserveEventLoss(metrics.LossStageAgent, lossReason(err), metrics.EventKindBlock, agent.config.ID, 1)
+	request.Ref.Release()
+	return agent.handleBlockErr(lg, startTime, err)
+}
+
+func (agent *Agent) sendBlockResult(lg *log.Entry, request *BlockRequest, resp *protocol.EvaluateBlockResponse, startTime, requestTime, responseTime time.Time) {
+	// truncate findings
+	if len(resp.Findings) > MaxFindings {
+		dropped := len(resp.Findings) - MaxFindings
+		droppedMetric := metrics.CreateAgentMetric(agent.config.ID, metrics.MetricFindingsDropped, float64(dropped))
+		agent.msgClient.PublishProto(messaging.SubjectMetricAgent, droppedMetric)
+		agent.countTruncatedFindings(resp.Findings[MaxFindings:], request.Original.Event.GetNetwork().GetChainId())
+		resp.Findings = resp.Findings[:MaxFindings]
+	}
+	var duration time.Duration
+	resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
+	lg.WithField("duration", duration).Debugf("request successful")
+
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]string)
+	}
+	resp.Metadata["imageHash"] = agent.config.ImageHash()
+	agent.stats.FindingsSent(len(resp.Findings))
+
+	ts := domain.TrackingTimestampsFromMessage(request.Original.Event.Timestamps)
+	ts.BotRequest = requestTime
+	ts.BotResponse = responseTime
+
+	agent.blockResults <- &scanner.BlockResult{
+		AgentConfig: agent.config,
+		Request:     request.Original,
+		Response:    resp,
+		Timestamps:  ts,
+		Ref:         request.Ref,
+	}
+	lg.WithField("duration", time.Since(startTime)).Debugf("sent results")
+}
+
+// handleBlockErr tells if the agent should stop processing.
+func (agent *Agent) handleBlockErr(lg *log.Entry, startTime time.Time, err error) bool {
+	if !agent.errCounter.TooManyErrs(err) {
+		return false
+	}
+	lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
+	agent.Close()
+	agent.msgClient.Publish(messaging.SubjectAgentsActionStop, messaging.AgentPayload{agent.config})
+	return true
+}
+
+// spanAttributes returns the attributes of the agent evaluation spans.
+func (agent *Agent) spanAttributes(attrs ...attribute.KeyValue) []attribute.KeyValue {
+	return append([]attribute.KeyValue{
+		attribute.String("agent.id", agent.config.ID),
+		attribute.Int64("agent.replica", int64(agent.config.Replica)),
+	}, attrs...)
+}
+
 func calculateResponseTime(startTime *time.Time) (timestamp string, latencyMs uint32, duration time.Duration) {
 	now := time.Now().UTC()
 	duration = now.Sub(*startTime)
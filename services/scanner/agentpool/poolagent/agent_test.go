@@ -0,0 +1,22 @@
+package poolagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLossReason(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(metrics.LossReasonTimeout, lossReason(status.Error(codes.DeadlineExceeded, "deadline exceeded")))
+	r.Equal(metrics.LossReasonTimeout, lossReason(fmt.Errorf("failed: %w", context.DeadlineExceeded)))
+	r.Equal(metrics.LossReasonError, lossReason(status.Error(codes.Unavailable, "unavailable")))
+	r.Equal(metrics.LossReasonError, lossReason(errors.New("failed")))
+}
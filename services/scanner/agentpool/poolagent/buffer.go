@@ -0,0 +1,88 @@
+package poolagent
+
+import (
+	"sync"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// bufferLimit is the soft limit of a request channel. The channel is allocated with
+// the max capacity and the limit decides how much of it can be used. In adaptive mode,
+// the limit grows when requests are dropped and shrinks when the buffer stays mostly empty.
+type bufferLimit struct {
+	cfg       config.AgentBufferConfig
+	limit     int
+	highWater int
+	drops     int
+	mu        sync.RWMutex
+}
+
+func newBufferLimit(cfg config.AgentBufferConfig) *bufferLimit {
+	return &bufferLimit{
+		cfg:   cfg,
+		limit: cfg.Size,
+	}
+}
+
+// Capacity returns the capacity the channel should be allocated with.
+func (bl *bufferLimit) Capacity() int {
+	if bl.cfg.Adaptive {
+		return bl.cfg.MaxSize
+	}
+	return bl.cfg.Size
+}
+
+// Limit returns the current limit.
+func (bl *bufferLimit) Limit() int {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return bl.limit
+}
+
+// IsFull tells if the given buffer length reached the current limit.
+func (bl *bufferLimit) IsFull(length int) bool {
+	return length >= bl.Limit()
+}
+
+// Observe records the buffer length after a send.
+func (bl *bufferLimit) Observe(length int) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if length > bl.highWater {
+		bl.highWater = length
+	}
+}
+
+// Drop records a dropped request.
+func (bl *bufferLimit) Drop() {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.drops++
+	bl.highWater = bl.limit
+}
+
+// Adapt closes the current observation window, adjusts the limit if adaptive and
+// returns the high-water mark of the window.
+func (bl *bufferLimit) Adapt() (highWater int) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	highWater = bl.highWater
+	if bl.cfg.Adaptive {
+		switch {
+		case bl.drops > 0:
+			bl.limit *= 2
+		case highWater < bl.limit/4:
+			bl.limit /= 2
+		}
+		if bl.limit > bl.cfg.MaxSize {
+			bl.limit = bl.cfg.MaxSize
+		}
+		if bl.limit < bl.cfg.MinSize {
+			bl.limit = bl.cfg.MinSize
+		}
+	}
+	bl.highWater = 0
+	bl.drops = 0
+	return
+}
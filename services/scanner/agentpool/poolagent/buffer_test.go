@@ -0,0 +1,49 @@
+package poolagent
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferLimit_Fixed(t *testing.T) {
+	r := require.New(t)
+
+	bl := newBufferLimit(config.AgentBufferConfig{Size: 10, MinSize: 1, MaxSize: 100})
+	r.Equal(10, bl.Capacity())
+	r.False(bl.IsFull(9))
+	r.True(bl.IsFull(10))
+
+	bl.Drop()
+	r.Equal(10, bl.Adapt())
+	r.Equal(10, bl.Limit())
+}
+
+func TestBufferLimit_Adaptive(t *testing.T) {
+	r := require.New(t)
+
+	bl := newBufferLimit(config.AgentBufferConfig{Size: 10, Adaptive: true, MinSize: 4, MaxSize: 30})
+	r.Equal(30, bl.Capacity())
+
+	// grows when requests are dropped but not above the max
+	bl.Drop()
+	r.Equal(10, bl.Adapt())
+	r.Equal(20, bl.Limit())
+	bl.Drop()
+	bl.Adapt()
+	r.Equal(30, bl.Limit())
+
+	// stays the same when the usage is reasonable
+	bl.Observe(15)
+	r.Equal(15, bl.Adapt())
+	r.Equal(30, bl.Limit())
+
+	// shrinks when mostly empty but not below the min
+	bl.Observe(1)
+	bl.Adapt()
+	r.Equal(15, bl.Limit())
+	bl.Adapt()
+	bl.Adapt()
+	r.Equal(4, bl.Limit())
+}
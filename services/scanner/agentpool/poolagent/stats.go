@@ -0,0 +1,133 @@
+package poolagent
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWeight is the weight of the latest sample in the moving average.
+const latencyWeight = 0.2
+
+// agentStats keeps recent request statistics of an agent.
+type agentStats struct {
+	txLatencyMs    float64
+	blockLatencyMs float64
+	txErrors       uint64
+	blockErrors    uint64
+	txProcessed    uint64
+	blockProcessed uint64
+	findings       uint64
+	lastErr        string
+	lastErrTime    time.Time
+	mu             sync.RWMutex
+}
+
+func movingAverage(avg float64, sample time.Duration) float64 {
+	sampleMs := float64(sample.Milliseconds())
+	if avg == 0 {
+		return sampleMs
+	}
+	return avg*(1-latencyWeight) + sampleMs*latencyWeight
+}
+
+func (as *agentStats) TxDone(latency time.Duration, err error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if err != nil {
+		as.txErrors++
+		as.setErrUnsafe(err)
+		return
+	}
+	as.txProcessed++
+	as.txLatencyMs = movingAverage(as.txLatencyMs, latency)
+}
+
+func (as *agentStats) BlockDone(latency time.Duration, err error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if err != nil {
+		as.blockErrors++
+		as.setErrUnsafe(err)
+		return
+	}
+	as.blockProcessed++
+	as.blockLatencyMs = movingAverage(as.blockLatencyMs, latency)
+}
+
+func (as *agentStats) FindingsSent(count int) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.findings += uint64(count)
+}
+
+func (as *agentStats) setErrUnsafe(err error) {
+	as.lastErr = err.Error()
+	as.lastErrTime = time.Now().UTC()
+}
+
+// Status contains the runtime status of an agent in the pool.
+type Status struct {
+	ID               string     `json:"id"`
+	Image            string     `json:"image"`
+	ImageHash        string     `json:"imageHash"`
+	Manifest         string     `json:"manifest,omitempty"`
+	Version          string     `json:"version,omitempty"`
+	ChainIDs         []int64    `json:"chainIds,omitempty"`
+	ContainerName    string     `json:"containerName"`
+	Replica          uint       `json:"replica,omitempty"`
+	Ready            bool       `json:"ready"`
+	Serving          bool       `json:"serving"`
+	Paused           bool       `json:"paused"`
+	Closed           bool       `json:"closed"`
+	TxBuffer         int        `json:"txBuffer"`
+	TxBufferLimit    int        `json:"txBufferLimit"`
+	BlockBuffer      int        `json:"blockBuffer"`
+	BlockBufferLimit int        `json:"blockBufferLimit"`
+	TxLatencyMs      float64    `json:"txLatencyMs"`
+	BlockLatencyMs   float64    `json:"blockLatencyMs"`
+	TxErrors         uint64     `json:"txErrors"`
+	BlockErrors      uint64     `json:"blockErrors"`
+	TxProcessed      uint64     `json:"txProcessed"`
+	BlockProcessed   uint64     `json:"blockProcessed"`
+	Findings         uint64     `json:"findings"`
+	LastError        string     `json:"lastError,omitempty"`
+	LastErrorTime    *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// Status returns the runtime status of the agent.
+func (agent *Agent) Status() *Status {
+	agent.stats.mu.RLock()
+	defer agent.stats.mu.RUnlock()
+
+	status := &Status{
+		ID:               agent.config.ID,
+		Image:            agent.config.Image,
+		ImageHash:        agent.config.ImageHash(),
+		Manifest:         agent.config.Manifest,
+		Version:          agent.config.Version,
+		ChainIDs:         agent.config.ChainIDs,
+		ContainerName:    agent.config.ContainerName(),
+		Replica:          agent.config.Replica,
+		Ready:            agent.IsReady(),
+		Serving:          agent.IsServing(),
+		Paused:           agent.IsPaused(),
+		Closed:           agent.IsClosed(),
+		TxBuffer:         len(agent.txRequests),
+		TxBufferLimit:    agent.txBuffer.Limit(),
+		BlockBuffer:      len(agent.blockRequests),
+		BlockBufferLimit: agent.blockBuffer.Limit(),
+		TxLatencyMs:      agent.stats.txLatencyMs,
+		BlockLatencyMs:   agent.stats.blockLatencyMs,
+		TxErrors:         agent.stats.txErrors,
+		BlockErrors:      agent.stats.blockErrors,
+		TxProcessed:      agent.stats.txProcessed,
+		BlockProcessed:   agent.stats.blockProcessed,
+		Findings:         agent.stats.findings,
+		LastError:        agent.stats.lastErr,
+	}
+	if !agent.stats.lastErrTime.IsZero() {
+		lastErrTime := agent.stats.lastErrTime
+		status.LastErrorTime = &lastErrTime
+	}
+	return status
+}
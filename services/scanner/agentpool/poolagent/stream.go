@@ -0,0 +1,292 @@
+package poolagent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	log "github.com/sirupsen/logrus"
+)
+
+var errStreamRequestTimeout = errors.New("stream request timed out")
+
+// pendingRequest is a request which was sent on a stream and is waiting for the response.
+type pendingRequest struct {
+	txRequest    *TxRequest
+	blockRequest *BlockRequest
+	startTime    time.Time
+	requestTime  time.Time
+}
+
+func (req *pendingRequest) release() {
+	if req.txRequest != nil {
+		req.txRequest.Ref.Release()
+		return
+	}
+	req.blockRequest.Ref.Release()
+}
+
+// pendingRequests keeps the requests sent on a stream by the request ID.
+type pendingRequests struct {
+	requests map[string]*pendingRequest
+	mu       sync.Mutex
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{
+		requests: make(map[string]*pendingRequest),
+	}
+}
+
+func (pr *pendingRequests) Add(requestID string, req *pendingRequest) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.requests[requestID] = req
+}
+
+func (pr *pendingRequests) Remove(requestID string) (*pendingRequest, bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	req, ok := pr.requests[requestID]
+	delete(pr.requests, requestID)
+	return req, ok
+}
+
+// Expire removes and returns the requests which are waiting for longer than the timeout.
+func (pr *pendingRequests) Expire(timeout time.Duration) (expired []*pendingRequest) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	for requestID, req := range pr.requests {
+		if time.Since(req.startTime) > timeout {
+			expired = append(expired, req)
+			delete(pr.requests, requestID)
+		}
+	}
+	return
+}
+
+// Drain removes and returns all of the requests in the order they were sent.
+func (pr *pendingRequests) Drain() []*pendingRequest {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	requests := make([]*pendingRequest, 0, len(pr.requests))
+	for _, req := range pr.requests {
+		requests = append(requests, req)
+	}
+	pr.requests = make(map[string]*pendingRequest)
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].startTime.Before(requests[j].startTime)
+	})
+	return requests
+}
+
+// ReleaseAll releases the messages of the requests which will not get a response.
+func (pr *pendingRequests) ReleaseAll() {
+	for _, req := range pr.Drain() {
+		req.release()
+	}
+}
+
+// streamTransactions sends the tx requests on a bi-directional stream and handles
+// the responses asynchronously. It falls back to the unary requests if the agent
+// does not support streaming or the stream breaks.
+func (agent *Agent) streamTransactions() {
+	lg := log.WithFields(log.Fields{
+		"agent":     agent.config.ID,
+		"component": "agent",
+		"evaluate":  "transaction",
+		"stream":    true,
+	})
+	ctx, cancel := context.WithCancel(agent.ctx)
+	defer cancel()
+
+	stream, err := agent.client.EvaluateTxStream(ctx)
+	if err != nil {
+		lg.WithError(err).Warn("failed to open stream - falling back to unary requests")
+		agent.processTransactions()
+		return
+	}
+
+	pending := newPendingRequests()
+	defer pending.ReleaseAll()
+	streamErr := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				streamErr <- err
+				return
+			}
+			responseTime := time.Now().UTC()
+			req, ok := pending.Remove(resp.Metadata[agentgrpc.StreamRequestIDKey])
+			if !ok {
+				lg.Warn("received response for unknown request - ignoring")
+				continue
+			}
+			agent.stats.TxDone(responseTime.Sub(req.requestTime), nil)
+			agent.sendTxResult(lg, req.txRequest, resp, req.startTime, req.requestTime, responseTime)
+		}
+	}()
+
+	ticker := time.NewTicker(AgentTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-agent.closed:
+			stream.CloseSend()
+			return
+
+		case err := <-streamErr:
+			lg.WithError(err).Warn("stream failed - falling back to unary requests")
+			if agent.retryPending(lg, pending) {
+				return
+			}
+			agent.processTransactions()
+			return
+
+		case <-ticker.C:
+			for _, req := range pending.Expire(AgentTimeout) {
+				agent.stats.TxDone(0, errStreamRequestTimeout)
+				lg.WithField("request", req.txRequest.Original.RequestId).Error("stream request timed out")
+				req.txRequest.Ref.Release()
+				if agent.handleTxErr(lg, req.startTime, errStreamRequestTimeout) {
+					return
+				}
+			}
+
+		case request := <-agent.txRequests:
+			// the chunks are sent with unary requests since the stream messages are not split
+			if len(request.Chunks) > 0 {
+				if agent.processTxRequest(lg, request) {
+					return
+				}
+				continue
+			}
+			startTime := time.Now()
+			pending.Add(request.Original.RequestId, &pendingRequest{
+				txRequest:   request,
+				startTime:   startTime,
+				requestTime: time.Now().UTC(),
+			})
+			if err := stream.Send(request.Encoded, request.Compressed); err != nil {
+				// the request is sent again with the rest of the pending requests
+				lg.WithError(err).Warn("failed to send on stream - falling back to unary requests")
+				cancel()
+				if agent.retryPending(lg, pending) {
+					return
+				}
+				agent.processTransactions()
+				return
+			}
+		}
+	}
+}
+
+// streamBlocks sends the block requests on a bi-directional stream and handles
+// the responses asynchronously. It falls back to the unary requests if the agent
+// does not support streaming or the stream breaks.
+func (agent *Agent) streamBlocks() {
+	lg := log.WithFields(log.Fields{
+		"agent":     agent.config.ID,
+		"component": "agent",
+		"evaluate":  "block",
+		"stream":    true,
+	})
+	ctx, cancel := context.WithCancel(agent.ctx)
+	defer cancel()
+
+	stream, err := agent.client.EvaluateBlockStream(ctx)
+	if err != nil {
+		lg.WithError(err).Warn("failed to open stream - falling back to unary requests")
+		agent.processBlocks()
+		return
+	}
+
+	pending := newPendingRequests()
+	defer pending.ReleaseAll()
+	streamErr := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				streamErr <- err
+				return
+			}
+			responseTime := time.Now().UTC()
+			req, ok := pending.Remove(resp.Metadata[agentgrpc.StreamRequestIDKey])
+			if !ok {
+				lg.Warn("received response for unknown request - ignoring")
+				continue
+			}
+			agent.stats.BlockDone(responseTime.Sub(req.requestTime), nil)
+			agent.sendBlockResult(lg, req.blockRequest, resp, req.startTime, req.requestTime, responseTime)
+		}
+	}()
+
+	ticker := time.NewTicker(AgentTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-agent.closed:
+			stream.CloseSend()
+			return
+
+		case err := <-streamErr:
+			lg.WithError(err).Warn("stream failed - falling back to unary requests")
+			if agent.retryPending(lg, pending) {
+				return
+			}
+			agent.processBlocks()
+			return
+
+		case <-ticker.C:
+			for _, req := range pending.Expire(AgentTimeout) {
+				agent.stats.BlockDone(0, errStreamRequestTimeout)
+				lg.WithField("request", req.blockRequest.Original.RequestId).Error("stream request timed out")
+				req.blockRequest.Ref.Release()
+				if agent.handleBlockErr(lg, req.startTime, errStreamRequestTimeout) {
+					return
+				}
+			}
+
+		case request := <-agent.blockRequests:
+			startTime := time.Now()
+			pending.Add(request.Original.RequestId, &pendingRequest{
+				blockRequest: request,
+				startTime:    startTime,
+				requestTime:  time.Now().UTC(),
+			})
+			if err := stream.Send(request.Encoded, request.Compressed); err != nil {
+				// the request is sent again with the rest of the pending requests
+				lg.WithError(err).Warn("failed to send on stream - falling back to unary requests")
+				cancel()
+				if agent.retryPending(lg, pending) {
+					return
+				}
+				agent.processBlocks()
+				return
+			}
+		}
+	}
+}
+
+// retryPending sends the requests which did not get a response on the broken stream again
+// with the unary requests. It tells if the agent should stop processing.
+func (agent *Agent) retryPending(lg *log.Entry, pending *pendingRequests) (stop bool) {
+	for _, req := range pending.Drain() {
+		if stop || agent.IsClosed() {
+			req.release()
+			stop = true
+			continue
+		}
+		if req.txRequest != nil {
+			stop = agent.processTxRequest(lg, req.txRequest)
+		} else {
+			stop = agent.processBlockRequest(lg, req.blockRequest)
+		}
+	}
+	return
+}
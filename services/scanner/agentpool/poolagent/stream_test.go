@@ -0,0 +1,30 @@
+package poolagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingRequestsDrain(t *testing.T) {
+	r := require.New(t)
+
+	pending := newPendingRequests()
+	now := time.Now()
+	for i, requestID := range []string{"c", "a", "b"} {
+		pending.Add(requestID, &pendingRequest{
+			txRequest: &TxRequest{Original: &protocol.EvaluateTxRequest{RequestId: requestID}},
+			startTime: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	// the requests are retried in the order they were sent
+	var requestIDs []string
+	for _, req := range pending.Drain() {
+		requestIDs = append(requestIDs, req.txRequest.Original.RequestId)
+	}
+	r.Equal([]string{"c", "a", "b"}, requestIDs)
+	r.Empty(pending.Drain())
+}
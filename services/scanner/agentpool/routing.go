@@ -0,0 +1,119 @@
+package agentpool
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+)
+
+const virtualNodesPerReplica = 64
+
+// hashRing is a consistent hashing ring which maps keys to replica indexes.
+type hashRing struct {
+	hashes   []uint32
+	replicas map[uint32]uint
+}
+
+func newHashRing(replicaCount uint) *hashRing {
+	ring := &hashRing{
+		replicas: make(map[uint32]uint),
+	}
+	for replica := uint(0); replica < replicaCount; replica++ {
+		for vnode := 0; vnode < virtualNodesPerReplica; vnode++ {
+			hash := hashKey(strconv.Itoa(int(replica)) + "-" + strconv.Itoa(vnode))
+			ring.hashes = append(ring.hashes, hash)
+			ring.replicas[hash] = replica
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+	return ring
+}
+
+// Locate finds the replica index for the key.
+func (ring *hashRing) Locate(key string) uint {
+	hash := hashKey(key)
+	i := sort.Search(len(ring.hashes), func(i int) bool {
+		return ring.hashes[i] >= hash
+	})
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.replicas[ring.hashes[i]]
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// replicaRouter decides which replicas of an agent should receive a request.
+type replicaRouter struct {
+	rings map[uint]*hashRing
+	mu    sync.Mutex
+}
+
+func newReplicaRouter() *replicaRouter {
+	return &replicaRouter{
+		rings: make(map[uint]*hashRing),
+	}
+}
+
+func (rr *replicaRouter) getRing(replicaCount uint) *hashRing {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	ring, ok := rr.rings[replicaCount]
+	if !ok {
+		ring = newHashRing(replicaCount)
+		rr.rings[replicaCount] = ring
+	}
+	return ring
+}
+
+// ShouldRouteTx tells if the tx request should be sent to given agent replica. Every tx is
+// evaluated by exactly one of the replicas so that the replicas do not raise the same findings
+// for a tx. Stateful agents receive the transactions by the sender address so each replica
+// keeps a complete view of the addresses it owns. The rest of the replicated agents receive
+// a share of the transactions by the tx hash.
+func (rr *replicaRouter) ShouldRouteTx(agentCfg config.AgentConfig, req *protocol.EvaluateTxRequest) bool {
+	if !agentCfg.IsReplicated() {
+		return true
+	}
+	ring := rr.getRing(agentCfg.Replicas)
+	if address := routingAddress(req.Event); agentCfg.Stateful && len(address) > 0 {
+		return ring.Locate(address) == agentCfg.Replica
+	}
+	return ring.Locate(req.Event.Transaction.Hash) == agentCfg.Replica
+}
+
+// routingAddress returns the sender of the tx, or the lowest of the touched addresses if the
+// sender is not known.
+func routingAddress(event *protocol.TransactionEvent) string {
+	if event.Transaction != nil && len(event.Transaction.From) > 0 {
+		return strings.ToLower(event.Transaction.From)
+	}
+	var lowest string
+	for address := range event.Addresses {
+		address = strings.ToLower(address)
+		if len(lowest) == 0 || address < lowest {
+			lowest = address
+		}
+	}
+	return lowest
+}
+
+// ShouldRouteBlock tells if the block request should be sent to given agent replica.
+// Every block is evaluated by only one of the replicas.
+func (rr *replicaRouter) ShouldRouteBlock(agentCfg config.AgentConfig, req *protocol.EvaluateBlockRequest) bool {
+	if !agentCfg.IsReplicated() {
+		return true
+	}
+	return rr.getRing(agentCfg.Replicas).Locate(req.Event.BlockNumber) == agentCfg.Replica
+}
@@ -0,0 +1,70 @@
+package agentpool
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRing_Stable(t *testing.T) {
+	r := require.New(t)
+
+	ring := newHashRing(4)
+	replica := ring.Locate("0xabc")
+	r.Less(replica, uint(4))
+	r.Equal(replica, newHashRing(4).Locate("0xabc"))
+}
+
+func TestReplicaRouter_StatefulTx(t *testing.T) {
+	r := require.New(t)
+
+	router := newReplicaRouter()
+	req := &protocol.EvaluateTxRequest{
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"},
+			Addresses:   map[string]bool{"0xAbC": true},
+		},
+	}
+	owner := router.getRing(3).Locate("0xabc")
+
+	for replica := uint(0); replica < 3; replica++ {
+		agentCfg := config.AgentConfig{Replicas: 3, Replica: replica, Stateful: true}
+		r.Equal(replica == owner, router.ShouldRouteTx(agentCfg, req))
+	}
+	r.True(router.ShouldRouteTx(config.AgentConfig{}, req))
+}
+
+func TestReplicaRouter_StatefulTx_OneReplica(t *testing.T) {
+	r := require.New(t)
+
+	router := newReplicaRouter()
+	ring := router.getRing(3)
+
+	// find two addresses which are owned by different replicas
+	sender := "0xaaa"
+	var other string
+	for i := 0; len(other) == 0; i++ {
+		address := fmt.Sprintf("0xb%d", i)
+		if ring.Locate(address) != ring.Locate(sender) {
+			other = address
+		}
+	}
+	req := &protocol.EvaluateTxRequest{
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1", From: "0xAAA"},
+			Addresses:   map[string]bool{"0xAAA": true, other: true},
+		},
+	}
+
+	var routed []uint
+	for replica := uint(0); replica < 3; replica++ {
+		agentCfg := config.AgentConfig{Replicas: 3, Replica: replica, Stateful: true}
+		if router.ShouldRouteTx(agentCfg, req) {
+			routed = append(routed, replica)
+		}
+	}
+	r.Equal([]uint{ring.Locate(sender)}, routed)
+}
@@ -10,10 +10,11 @@ import (
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/golang/protobuf/jsonpb"
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/forta-network/forta-core-go/protocol"
@@ -104,9 +105,15 @@ func (t *BlockAnalyzerService) Start() error {
 			resStr, err := m.MarshalToString(result.Response)
 			if err != nil {
 				log.Error("error marshaling response", err)
+				result.Ref.Release()
 				continue
 			}
 			log.Debugf(resStr)
+			_, span := tracing.StartSpan(
+				t.ctx, result.Request.Event.BlockNumber, "analyzer.handle_block_result",
+				attribute.String("agent.id", result.AgentConfig.ID),
+				attribute.Int("findings", len(result.Response.Findings)),
+			)
 
 			rt := &clients.AgentRoundTrip{
 				AgentConfig:       result.AgentConfig,
@@ -135,6 +142,8 @@ func (t *BlockAnalyzerService) Start() error {
 				}
 			}
 			t.publishMetrics(result)
+			span.End()
+			result.Ref.Release()
 
 			t.lastOutputActivity.Set()
 		}
@@ -144,19 +153,12 @@ func (t *BlockAnalyzerService) Start() error {
 	go func() {
 		// for each block
 		for block := range t.cfg.BlockChannel {
-			// convert to message
-			blockEvt, err := block.ToMessage()
-			if err != nil {
-				log.WithError(err).Error("error converting block event to message (skipping)")
-				continue
-			}
-
-			// create a request
-			requestId := uuid.Must(uuid.NewUUID())
-			request := &protocol.EvaluateBlockRequest{RequestId: requestId.String(), Event: blockEvt}
+			// create a request from the pooled messages
+			request, ref := newBlockRequest(block)
 
 			// forward to the pool
-			t.cfg.AgentPool.SendEvaluateBlockRequest(request)
+			t.cfg.AgentPool.SendEvaluateBlockRequest(request, ref)
+			ref.Release()
 
 			t.lastInputActivity.Set()
 		}
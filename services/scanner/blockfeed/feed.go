@@ -0,0 +1,305 @@
+package blockfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultFetchTimeout is the default deadline for fetching the data of a block.
+const DefaultFetchTimeout = 2 * time.Minute
+
+// Config configures the block feed.
+type Config struct {
+	feeds.BlockFeedConfig
+	// FetchTimeout is the shared deadline of the requests of a block.
+	FetchTimeout time.Duration
+	// RequireTraces makes the feed retry a block which cannot be traced instead of
+	// scanning it without the traces.
+	RequireTraces bool
+}
+
+type bfHandler struct {
+	Handler func(evt *domain.BlockEvent) error
+	ErrCh   chan<- error
+}
+
+// blockFeed is a block feed which fetches the block, the traces and the logs of each block
+// concurrently.
+type blockFeed struct {
+	start         *big.Int
+	end           *big.Int
+	offset        int
+	ctx           context.Context
+	client        ethereum.Client
+	traceClient   ethereum.Client
+	cache         utils.Cache
+	chainID       *big.Int
+	tracing       bool
+	requireTraces bool
+	started       bool
+	rateLimit     *time.Ticker
+	maxBlockAge   *time.Duration
+	fetchTimeout  time.Duration
+
+	lastBlock    health.MessageTracker
+	lastFetchErr health.ErrorTracker
+	lastTraceErr health.ErrorTracker
+
+	handlers   []bfHandler
+	handlersMu sync.RWMutex
+}
+
+// New creates a new block feed.
+func New(ctx context.Context, client, traceClient ethereum.Client, cfg Config) (*blockFeed, error) {
+	if cfg.Offset < 0 {
+		return nil, fmt.Errorf("offset cannot be below zero: offset=%d", cfg.Offset)
+	}
+	fetchTimeout := cfg.FetchTimeout
+	if fetchTimeout <= 0 {
+		fetchTimeout = DefaultFetchTimeout
+	}
+	return &blockFeed{
+		start:         cfg.Start,
+		end:           cfg.End,
+		offset:        cfg.Offset,
+		ctx:           ctx,
+		client:        client,
+		traceClient:   traceClient,
+		cache:         utils.NewCache(10000),
+		chainID:       cfg.ChainID,
+		tracing:       cfg.Tracing,
+		requireTraces: cfg.RequireTraces,
+		rateLimit:     cfg.RateLimit,
+		maxBlockAge:   cfg.SkipBlocksOlderThan,
+		fetchTimeout:  fetchTimeout,
+	}, nil
+}
+
+func (bf *blockFeed) initialize() error {
+	if bf.start == nil {
+		res, err := bf.client.BlockByNumber(bf.ctx, nil)
+		if err != nil {
+			return err
+		}
+		log.Debugf("retrieved block number %s", res.Number)
+
+		bf.start, err = utils.HexToBigInt(res.Number)
+		if err != nil {
+			return fmt.Errorf("error converting blocknum hex to bigint: %v", err)
+		}
+
+		// should be a positive number
+		if bf.start.Sign() <= 0 {
+			return fmt.Errorf("got invalid block number during initialization: %d", bf.start.Uint64())
+		}
+	}
+	log.Infof("initialized block number %d", bf.start)
+
+	if bf.chainID == nil {
+		chainID, err := bf.client.ChainID(bf.ctx)
+		if err != nil {
+			return err
+		}
+		bf.chainID = chainID
+	}
+	log.Infof("initialized chainId %d", bf.chainID)
+
+	return nil
+}
+
+// IsStarted implements feeds.BlockFeed interface.
+func (bf *blockFeed) IsStarted() bool {
+	return bf.started
+}
+
+// Start implements feeds.BlockFeed interface.
+func (bf *blockFeed) Start() {
+	if !bf.started {
+		go bf.loop()
+	}
+}
+
+// StartRange implements feeds.BlockFeed interface.
+func (bf *blockFeed) StartRange(start int64, end int64, rate int64) {
+	if !bf.started {
+		if rate > 0 {
+			bf.rateLimit = time.NewTicker(time.Duration(rate) * time.Millisecond)
+		}
+		bf.start = big.NewInt(start)
+		bf.end = big.NewInt(end)
+		go bf.loop()
+	}
+}
+
+// Subscribe implements feeds.BlockFeed interface.
+func (bf *blockFeed) Subscribe(handler func(evt *domain.BlockEvent) error) <-chan error {
+	bf.handlersMu.Lock()
+	defer bf.handlersMu.Unlock()
+
+	errCh := make(chan error)
+	bf.handlers = append(bf.handlers, bfHandler{
+		Handler: handler,
+		ErrCh:   errCh,
+	})
+	return errCh
+}
+
+func (bf *blockFeed) loop() {
+	if err := bf.initialize(); err != nil {
+		log.WithError(err).Panic("failed to initialize")
+	}
+
+	bf.started = true
+	defer func() {
+		bf.started = false
+	}()
+	err := bf.forEachBlock()
+	if err == nil {
+		return
+	}
+	if err != feeds.ErrEndBlockReached {
+		log.WithError(err).Warn("failed while processing blocks")
+	}
+	bf.handlersMu.RLock()
+	handlers := bf.handlers
+	bf.handlersMu.RUnlock()
+	for _, handler := range handlers {
+		handler.ErrCh <- err
+	}
+}
+
+func (bf *blockFeed) forEachBlock() error {
+	increment := big.NewInt(1)
+	currentBlockNum := new(big.Int).Set(bf.start)
+
+	for {
+		if bf.ctx.Err() != nil {
+			return bf.ctx.Err()
+		}
+		if bf.rateLimit != nil {
+			<-bf.rateLimit.C
+		}
+
+		blockNumToAnalyze := new(big.Int).Sub(currentBlockNum, big.NewInt(int64(bf.offset)))
+		logger := log.WithFields(log.Fields{
+			"currentBlock":   currentBlockNum.Uint64(),
+			"blockToAnalyze": blockNumToAnalyze.Uint64(),
+		})
+
+		if bf.end != nil && blockNumToAnalyze.Uint64() > bf.end.Uint64() {
+			logger.Info("end block reached - exiting")
+			return feeds.ErrEndBlockReached
+		}
+		if bf.cache.Exists(blockNumToAnalyze.String()) {
+			logger.Info("already analyzed block - skipping")
+			currentBlockNum.Add(currentBlockNum, increment)
+			continue
+		}
+
+		// the current block needs to exist before the block behind it is analyzed
+		if blockNumToAnalyze.Cmp(currentBlockNum) != 0 {
+			if _, err := bf.client.BlockByNumber(bf.ctx, currentBlockNum); err != nil {
+				logger.WithError(err).Error("error getting block")
+				continue
+			}
+		}
+
+		data, err := bf.fetchBlock(blockNumToAnalyze)
+		var tooOld *tooOldError
+		switch {
+		case errors.As(err, &tooOld):
+			logger.WithField("age", tooOld.age).Warnf("block is older than %v - setting current block num to latest", bf.maxBlockAge)
+			latestBlockNum, err := bf.client.BlockNumber(bf.ctx)
+			if err != nil {
+				logger.WithError(err).Error("failed to get latest block number")
+				continue
+			}
+			currentBlockNum = latestBlockNum
+			continue
+
+		case err != nil:
+			// the whole block is fetched again
+			bf.lastFetchErr.Set(err)
+			logger.WithError(err).Error("error fetching block")
+			continue
+		}
+		bf.lastFetchErr.Set(nil)
+		metrics.BlockFeedFetchDuration.Observe(data.fetchTime.Seconds())
+
+		block := data.block
+		logger = logger.WithFields(log.Fields{
+			"blockHash":         block.Hash,
+			"blockToAnalyzeHex": block.Number,
+		})
+		bf.lastBlock.Set(blockNumToAnalyze.String())
+
+		if bf.tracing {
+			bf.lastTraceErr.Set(data.traceErr)
+		}
+		if data.traceErr != nil {
+			logger.WithError(data.traceErr).Error("error tracing block")
+		}
+		traces := data.traces
+		if len(traces) > 0 && block.Hash != utils.String(traces[0].BlockHash) {
+			logger.WithFields(log.Fields{
+				"traceBlockHash": utils.String(traces[0].BlockHash),
+			}).Warn("trace block hash != ethereum block hash, ignoring traces")
+			traces = nil
+		}
+
+		blockTs, err := block.GetTimestamp()
+		if err != nil {
+			logger.WithError(err).Error("failed to get block timestamp")
+			continue
+		}
+
+		evt := &domain.BlockEvent{
+			EventType: domain.EventTypeBlock,
+			Block:     block,
+			ChainID:   bf.chainID,
+			Traces:    traces,
+			Logs:      data.logs,
+			Timestamps: &domain.TrackingTimestamps{
+				Block: *blockTs,
+				Feed:  time.Now().UTC(),
+			},
+		}
+		bf.handlersMu.RLock()
+		handlers := bf.handlers
+		bf.handlersMu.RUnlock()
+		for _, handler := range handlers {
+			if err := handler.Handler(evt); err != nil {
+				return err
+			}
+		}
+		bf.cache.Add(blockNumToAnalyze.String())
+
+		currentBlockNum.Add(currentBlockNum, increment)
+	}
+}
+
+// Name returns the name of this implementation.
+func (bf *blockFeed) Name() string {
+	return "block-feed"
+}
+
+// Health implements the health.Reporter interface.
+func (bf *blockFeed) Health() health.Reports {
+	return health.Reports{
+		bf.lastBlock.GetReport("last-block"),
+		bf.lastFetchErr.GetReport("fetch.error"),
+		bf.lastTraceErr.GetReport("trace.error"),
+	}
+}
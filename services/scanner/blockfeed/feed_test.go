@@ -0,0 +1,209 @@
+package blockfeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	mock_ethereum "github.com/forta-network/forta-core-go/ethereum/mocks"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testBlockHash = "0x4fc0862e76691f5312964883954d5c2db35e2b8f7a4f191775a4f50c69804a8d"
+
+var (
+	testBlockNum = big.NewInt(1)
+	testErr      = errors.New("test")
+)
+
+func testBlock() *domain.Block {
+	return &domain.Block{
+		Hash:      testBlockHash,
+		Number:    "0x1",
+		Timestamp: "0x62585400",
+	}
+}
+
+func testLogs() []types.Log {
+	return []types.Log{
+		{
+			Address:     common.HexToAddress("0x5A98FcBEA516Cf06857215779Fd812CA3beF1B32"),
+			Topics:      []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")},
+			Data:        []byte{1, 2},
+			BlockNumber: 1,
+			TxHash:      common.HexToHash("0x1"),
+			TxIndex:     2,
+			BlockHash:   common.HexToHash(testBlockHash),
+			Index:       3,
+		},
+		{
+			Removed: true,
+		},
+	}
+}
+
+func testTraces() []domain.Trace {
+	blockHash := testBlockHash
+	return []domain.Trace{{BlockHash: &blockHash, Type: "call"}}
+}
+
+func getTestBlockFeed(t *testing.T, cfg Config) (*blockFeed, *mock_ethereum.MockClient, *mock_ethereum.MockClient) {
+	ctrl := gomock.NewController(t)
+	client := mock_ethereum.NewMockClient(ctrl)
+	traceClient := mock_ethereum.NewMockClient(ctrl)
+	cfg.Start = testBlockNum
+	cfg.End = testBlockNum
+	cfg.ChainID = big.NewInt(1)
+	cfg.Tracing = true
+	bf, err := New(context.Background(), client, traceClient, cfg)
+	require.NoError(t, err)
+	return bf, client, traceClient
+}
+
+// collectEvents makes the feed process the test block and returns the events.
+func collectEvents(t *testing.T, bf *blockFeed) []*domain.BlockEvent {
+	var events []*domain.BlockEvent
+	bf.Subscribe(func(evt *domain.BlockEvent) error {
+		events = append(events, evt)
+		return nil
+	})
+	require.Equal(t, feeds.ErrEndBlockReached, bf.forEachBlock())
+	return events
+}
+
+func TestFetchConcurrently(t *testing.T) {
+	r := require.New(t)
+
+	bf, client, traceClient := getTestBlockFeed(t, Config{})
+
+	// none of the requests return before all of them are sent
+	var wg sync.WaitGroup
+	wg.Add(3)
+	wait := func() {
+		wg.Done()
+		wg.Wait()
+	}
+	client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).DoAndReturn(func(ctx context.Context, number *big.Int) (*domain.Block, error) {
+		wait()
+		return testBlock(), nil
+	})
+	client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, q interface{}) ([]types.Log, error) {
+		wait()
+		return testLogs(), nil
+	})
+	traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).DoAndReturn(func(ctx context.Context, number *big.Int) ([]domain.Trace, error) {
+		wait()
+		return testTraces(), nil
+	})
+
+	events := collectEvents(t, bf)
+	r.Len(events, 1)
+	r.Equal(testBlockHash, events[0].Block.Hash)
+	r.Len(events[0].Logs, 2)
+	r.Len(events[0].Traces, 1)
+	r.Equal(big.NewInt(1), events[0].ChainID)
+}
+
+func TestTraceFailure(t *testing.T) {
+	r := require.New(t)
+
+	bf, client, traceClient := getTestBlockFeed(t, Config{})
+
+	client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).Return(testBlock(), nil)
+	client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(testLogs(), nil)
+	traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).Return(nil, testErr)
+
+	// the block is scanned without the traces
+	events := collectEvents(t, bf)
+	r.Len(events, 1)
+	r.Nil(events[0].Traces)
+	r.Len(events[0].Logs, 2)
+	r.Equal(health.StatusFailing, bf.lastTraceErr.GetReport("").Status)
+}
+
+func TestRequireTraces(t *testing.T) {
+	r := require.New(t)
+
+	bf, client, traceClient := getTestBlockFeed(t, Config{RequireTraces: true})
+
+	client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).Return(testBlock(), nil).Times(2)
+	client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(testLogs(), nil).Times(2)
+	gomock.InOrder(
+		traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).Return(nil, testErr),
+		traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).Return(testTraces(), nil),
+	)
+
+	// the block is fetched again
+	events := collectEvents(t, bf)
+	r.Len(events, 1)
+	r.Len(events[0].Traces, 1)
+}
+
+func TestLogsFailure(t *testing.T) {
+	r := require.New(t)
+
+	bf, client, traceClient := getTestBlockFeed(t, Config{})
+
+	client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).Return(testBlock(), nil).Times(2)
+	gomock.InOrder(
+		client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(nil, testErr),
+		client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(testLogs(), nil),
+	)
+	gomock.InOrder(
+		// the pending trace request is cancelled after the logs fail
+		traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).DoAndReturn(func(ctx context.Context, number *big.Int) ([]domain.Trace, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).Return(testTraces(), nil),
+	)
+
+	events := collectEvents(t, bf)
+	r.Len(events, 1)
+	r.Len(events[0].Logs, 2)
+	r.Len(events[0].Traces, 1)
+}
+
+func TestFetchTimeout(t *testing.T) {
+	r := require.New(t)
+
+	bf, client, traceClient := getTestBlockFeed(t, Config{FetchTimeout: time.Millisecond * 50})
+
+	gomock.InOrder(
+		client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).DoAndReturn(func(ctx context.Context, number *big.Int) (*domain.Block, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		client.EXPECT().BlockByNumber(gomock.Any(), testBlockNum).Return(testBlock(), nil),
+	)
+	client.EXPECT().GetLogs(gomock.Any(), gomock.Any()).Return(testLogs(), nil).Times(2)
+	traceClient.EXPECT().TraceBlock(gomock.Any(), testBlockNum).Return(testTraces(), nil).Times(2)
+
+	events := collectEvents(t, bf)
+	r.Len(events, 1)
+	r.Equal(health.StatusOK, bf.lastFetchErr.GetReport("").Status)
+}
+
+func TestToLogEntries(t *testing.T) {
+	r := require.New(t)
+
+	// same as the JSON conversion of the logs
+	logs := testLogs()
+	b, err := json.Marshal(logs)
+	r.NoError(err)
+	var expected []domain.LogEntry
+	r.NoError(json.Unmarshal(b, &expected))
+
+	r.Equal(expected, toLogEntries(logs))
+	r.Nil(toLogEntries(nil))
+}
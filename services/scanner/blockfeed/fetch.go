@@ -0,0 +1,133 @@
+package blockfeed
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	eth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/domain"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// blockData is the data of a block which is fetched concurrently.
+type blockData struct {
+	block     *domain.Block
+	traces    []domain.Trace
+	logs      []domain.LogEntry
+	traceErr  error
+	fetchTime time.Duration
+}
+
+// tooOldError is returned when the fetched block is older than the max block age.
+type tooOldError struct {
+	age time.Duration
+}
+
+func (err *tooOldError) Error() string {
+	return fmt.Sprintf("block is too old: %v", err.age)
+}
+
+// fetchBlock gets the block, the traces and the logs of the block concurrently within the fetch
+// timeout. A failure to get the block or the logs cancels the rest of the requests and fails the
+// whole block. A failure to trace the block only fails the block if the traces are required,
+// otherwise the block is scanned without the traces.
+func (bf *blockFeed) fetchBlock(number *big.Int) (*blockData, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(bf.ctx, bf.fetchTimeout)
+	defer cancel()
+	grp, ctx := errgroup.WithContext(ctx)
+
+	var data blockData
+	grp.Go(func() error {
+		block, err := bf.client.BlockByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to get block: %v", err)
+		}
+		if tooOld, age := blockIsTooOld(block, bf.maxBlockAge); tooOld {
+			return &tooOldError{age: *age}
+		}
+		data.block = block
+		return nil
+	})
+	grp.Go(func() error {
+		logs, err := bf.client.GetLogs(ctx, eth.FilterQuery{
+			FromBlock: number,
+			ToBlock:   number,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get logs: %v", err)
+		}
+		data.logs = toLogEntries(logs)
+		return nil
+	})
+	if bf.tracing {
+		grp.Go(func() error {
+			traces, err := bf.traceClient.TraceBlock(ctx, number)
+			if err != nil {
+				if bf.requireTraces {
+					return fmt.Errorf("failed to trace block: %v", err)
+				}
+				data.traceErr = err
+				return nil
+			}
+			data.traces = traces
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+	data.fetchTime = time.Since(start)
+	return &data, nil
+}
+
+func blockIsTooOld(block *domain.Block, maxAge *time.Duration) (bool, *time.Duration) {
+	if maxAge == nil {
+		return false, nil
+	}
+	age, err := block.Age()
+	if err != nil || age == nil {
+		log.WithFields(log.Fields{
+			"blockHex": block.Number,
+		}).WithError(err).Errorf("error getting age of block")
+		return false, age
+	}
+	return *age > *maxAge, age
+}
+
+// toLogEntries converts the logs to the same entries as their JSON representation.
+func toLogEntries(logs []types.Log) []domain.LogEntry {
+	if logs == nil {
+		return nil
+	}
+	entries := make([]domain.LogEntry, len(logs))
+	for i := range logs {
+		l := &logs[i]
+		entry := &entries[i]
+		entry.Address = strPtr(hexutil.Encode(l.Address.Bytes()))
+		entry.BlockHash = strPtr(l.BlockHash.Hex())
+		entry.BlockNumber = strPtr(hexutil.EncodeUint64(l.BlockNumber))
+		entry.Data = strPtr(hexutil.Encode(l.Data))
+		entry.LogIndex = strPtr(hexutil.EncodeUint64(uint64(l.Index)))
+		removed := l.Removed
+		entry.Removed = &removed
+		if l.Topics != nil {
+			entry.Topics = make([]*string, len(l.Topics))
+			for j, topic := range l.Topics {
+				entry.Topics[j] = strPtr(topic.Hex())
+			}
+		}
+		entry.TransactionHash = strPtr(l.TxHash.Hex())
+		entry.TransactionIndex = strPtr(hexutil.EncodeUint64(uint64(l.TxIndex)))
+	}
+	return entries
+}
+
+func strPtr(s string) *string {
+	return &s
+}
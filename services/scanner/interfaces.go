@@ -12,6 +12,8 @@ type TxResult struct {
 	Request     *protocol.EvaluateTxRequest
 	Response    *protocol.EvaluateTxResponse
 	Timestamps  *domain.TrackingTimestamps
+	// Ref is the reference to the request which the result holder should release.
+	Ref *MessageRef
 }
 
 // BlockResult contains request and response data.
@@ -20,13 +22,16 @@ type BlockResult struct {
 	Request     *protocol.EvaluateBlockRequest
 	Response    *protocol.EvaluateBlockResponse
 	Timestamps  *domain.TrackingTimestamps
+	// Ref is the reference to the request which the result holder should release.
+	Ref *MessageRef
 }
 
 // AgentPool contains all of the agents which we can forward the block and tx requests
-// to and receive the results from.
+// to and receive the results from. The pool retains the request reference for each agent
+// which receives the request and the caller still needs to release its own reference.
 type AgentPool interface {
-	SendEvaluateTxRequest(req *protocol.EvaluateTxRequest)
+	SendEvaluateTxRequest(req *protocol.EvaluateTxRequest, ref *MessageRef)
 	TxResults() <-chan *TxResult
-	SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest)
+	SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest, ref *MessageRef)
 	BlockResults() <-chan *BlockResult
 }
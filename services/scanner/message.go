@@ -0,0 +1,258 @@
+package scanner
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/google/uuid"
+)
+
+// zeroPaddedAddressPrefix is the prefix of the log topics which are addresses.
+const zeroPaddedAddressPrefix = "0x000000000000000000000000"
+
+// newTxRequest creates the request of the tx event from the pooled buffers. The returned
+// reference is held by the caller.
+func newTxRequest(evt *domain.TransactionEvent) (*protocol.EvaluateTxRequest, *MessageRef) {
+	bufs := getTxEventBuffers()
+	request := &protocol.EvaluateTxRequest{
+		RequestId: uuid.Must(uuid.NewUUID()).String(),
+		Event:     txEventToMessage(evt, bufs),
+	}
+	return request, newMessageRef(bufs.recycle)
+}
+
+// newBlockRequest creates the request of the block event from the pooled buffers. The returned
+// reference is held by the caller.
+func newBlockRequest(evt *domain.BlockEvent) (*protocol.EvaluateBlockRequest, *MessageRef) {
+	bufs := getBlockEventBuffers()
+	request := &protocol.EvaluateBlockRequest{
+		RequestId: uuid.Must(uuid.NewUUID()).String(),
+		Event:     blockEventToMessage(evt, bufs),
+	}
+	return request, newMessageRef(bufs.recycle)
+}
+
+// txEventToMessage converts the tx event to the same message as domain.TransactionEvent.ToMessage
+// without the JSON round trips and by filling the nested parts from the buffers.
+func txEventToMessage(evt *domain.TransactionEvent, bufs *txEventBuffers) *protocol.TransactionEvent {
+	addresses := bufs.addresses
+	tx := evt.Transaction
+
+	for i := range evt.BlockEvt.Traces {
+		trace := &evt.BlockEvt.Traces[i]
+		if trace.TransactionHash == nil || *trace.TransactionHash != tx.Hash {
+			continue
+		}
+		addAddress(addresses, trace.Action.Address)
+		addAddress(addresses, trace.Action.RefundAddress)
+		addAddress(addresses, trace.Action.To)
+		addAddress(addresses, trace.Action.From)
+		traceToMessage(trace, bufs.nextTrace())
+	}
+
+	addAddress(addresses, tx.To)
+	addAddressValue(addresses, tx.From)
+
+	for i := range evt.BlockEvt.Logs {
+		logEntry := &evt.BlockEvt.Logs[i]
+		if str(logEntry.TransactionHash) != tx.Hash {
+			continue
+		}
+		l := bufs.nextLog()
+		logToMessage(logEntry, l)
+		addAddressValue(addresses, l.Address)
+		for _, topic := range l.Topics {
+			if strings.HasPrefix(topic, zeroPaddedAddressPrefix) {
+				addAddressValue(addresses, lowerHex(common.HexToAddress(topic)))
+			}
+		}
+	}
+
+	var contractAddress string
+	isDeploy := tx.To == nil
+	if isDeploy {
+		contractAddress = lowerHex(crypto.CreateAddress(common.HexToAddress(tx.From), uint64(utils.HexToInt64(tx.Nonce))))
+		addAddressValue(addresses, contractAddress)
+	}
+
+	network := &protocol.TransactionEvent_Network{}
+	if evt.BlockEvt.ChainID != nil {
+		network.ChainId = utils.BigIntToHex(evt.BlockEvt.ChainID)
+	}
+
+	var traces []*protocol.TransactionEvent_Trace
+	if len(bufs.traces) > 0 {
+		traces = bufs.traces
+	}
+	var logs []*protocol.TransactionEvent_Log
+	if len(bufs.logs) > 0 {
+		logs = bufs.logs
+	}
+	msg := &protocol.TransactionEvent{
+		Type: protocol.TransactionEvent_BLOCK,
+		Transaction: &protocol.TransactionEvent_EthTransaction{
+			Nonce:    tx.Nonce,
+			GasPrice: tx.GasPrice,
+			Gas:      tx.Gas,
+			Value:    str(tx.Value),
+			Input:    str(tx.Input),
+			V:        tx.V,
+			R:        tx.R,
+			S:        tx.S,
+			To:       strings.ToLower(str(tx.To)),
+			Hash:     tx.Hash,
+			From:     strings.ToLower(tx.From),
+		},
+		Network:   network,
+		Traces:    traces,
+		Addresses: addresses,
+		Logs:      logs,
+		// for backwards compatibility
+		Receipt: &protocol.TransactionEvent_EthReceipt{
+			Status:           "0x1",
+			Logs:             logs,
+			TransactionHash:  tx.Hash,
+			ContractAddress:  contractAddress,
+			GasUsed:          tx.Gas,
+			BlockHash:        evt.BlockEvt.Block.Hash,
+			BlockNumber:      evt.BlockEvt.Block.Number,
+			TransactionIndex: tx.TransactionIndex,
+		},
+		IsContractDeployment: isDeploy,
+		ContractAddress:      contractAddress,
+		Block: &protocol.TransactionEvent_EthBlock{
+			BlockHash:      evt.BlockEvt.Block.Hash,
+			BlockNumber:    evt.BlockEvt.Block.Number,
+			BlockTimestamp: evt.BlockEvt.Block.Timestamp,
+		},
+		Timestamps: evt.Timestamps.ToMessage(),
+	}
+	bufs.event = msg
+	return msg
+}
+
+func traceToMessage(trace *domain.Trace, msg *protocol.TransactionEvent_Trace) {
+	msg.Action.CallType = str(trace.Action.CallType)
+	msg.Action.To = strings.ToLower(str(trace.Action.To))
+	msg.Action.Input = str(trace.Action.Input)
+	msg.Action.From = strings.ToLower(str(trace.Action.From))
+	msg.Action.Value = str(trace.Action.Value)
+	msg.Action.Init = str(trace.Action.Init)
+	msg.Action.Address = strings.ToLower(str(trace.Action.Address))
+	msg.Action.Balance = str(trace.Action.Balance)
+	msg.Action.RefundAddress = strings.ToLower(str(trace.Action.RefundAddress))
+
+	msg.BlockHash = str(trace.BlockHash)
+	if trace.BlockNumber != nil {
+		msg.BlockNumber = int64(*trace.BlockNumber)
+	}
+	if trace.Result != nil {
+		if msg.Result == nil {
+			msg.Result = &protocol.TransactionEvent_TraceResult{}
+		}
+		msg.Result.GasUsed = str(trace.Result.GasUsed)
+		msg.Result.Address = str(trace.Result.Address)
+		msg.Result.Code = str(trace.Result.Code)
+		msg.Result.Output = str(trace.Result.Output)
+	} else {
+		msg.Result = nil
+	}
+	msg.Subtraces = int64(trace.Subtraces)
+	for _, traceAddress := range trace.TraceAddress {
+		msg.TraceAddress = append(msg.TraceAddress, int64(traceAddress))
+	}
+	msg.TransactionHash = str(trace.TransactionHash)
+	if trace.TransactionPosition != nil {
+		msg.TransactionPosition = int64(*trace.TransactionPosition)
+	}
+	msg.Type = trace.Type
+	msg.Error = str(trace.Error)
+}
+
+func logToMessage(logEntry *domain.LogEntry, msg *protocol.TransactionEvent_Log) {
+	msg.Address = strings.ToLower(str(logEntry.Address))
+	for _, topic := range logEntry.Topics {
+		msg.Topics = append(msg.Topics, str(topic))
+	}
+	msg.Data = str(logEntry.Data)
+	msg.BlockNumber = str(logEntry.BlockNumber)
+	msg.TransactionHash = str(logEntry.TransactionHash)
+	msg.TransactionIndex = str(logEntry.TransactionIndex)
+	msg.BlockHash = str(logEntry.BlockHash)
+	msg.LogIndex = str(logEntry.LogIndex)
+	msg.Removed = logEntry.Removed != nil && *logEntry.Removed
+}
+
+// blockEventToMessage converts the block event to the same message as domain.BlockEvent.ToMessage
+// by filling the nested parts from the buffers.
+func blockEventToMessage(evt *domain.BlockEvent, bufs *blockEventBuffers) *protocol.BlockEvent {
+	block := evt.Block
+	for _, tx := range block.Transactions {
+		bufs.transactions = append(bufs.transactions, tx.Hash)
+	}
+	for _, uncle := range block.Uncles {
+		bufs.uncles = append(bufs.uncles, str(uncle))
+	}
+	msg := &protocol.BlockEvent_EthBlock{
+		Difficulty:       str(block.Difficulty),
+		Hash:             block.Hash,
+		Number:           block.Number,
+		ParentHash:       block.ParentHash,
+		Timestamp:        block.Timestamp,
+		Nonce:            str(block.Nonce),
+		ExtraData:        str(block.ExtraData),
+		GasLimit:         str(block.GasLimit),
+		GasUsed:          str(block.GasUsed),
+		LogsBloom:        str(block.LogsBloom),
+		Miner:            str(block.Miner),
+		MixHash:          str(block.MixHash),
+		Size:             str(block.Size),
+		StateRoot:        str(block.StateRoot),
+		ReceiptsRoot:     str(block.ReceiptsRoot),
+		TotalDifficulty:  str(block.TotalDifficulty),
+		Sha3Uncles:       str(block.Sha3Uncles),
+		Uncles:           bufs.uncles,
+		TransactionsRoot: str(block.TransactionsRoot),
+		Transactions:     bufs.transactions,
+	}
+	bufs.block = msg
+	return &protocol.BlockEvent{
+		Type:        protocol.BlockEvent_BLOCK,
+		BlockHash:   block.Hash,
+		BlockNumber: block.Number,
+		Network: &protocol.BlockEvent_Network{
+			ChainId: utils.BigIntToHex(evt.ChainID),
+		},
+		Block:      msg,
+		Timestamps: evt.Timestamps.ToMessage(),
+	}
+}
+
+func str(val *string) string {
+	if val == nil {
+		return ""
+	}
+	return *val
+}
+
+func addAddressValue(addresses map[string]bool, addr string) {
+	if addr != "" {
+		addresses[strings.ToLower(addr)] = true
+	}
+}
+
+func addAddress(addresses map[string]bool, addr *string) {
+	if addr != nil {
+		addAddressValue(addresses, *addr)
+	}
+}
+
+// lowerHex returns the lowercase hex of the address without computing the checksum.
+func lowerHex(addr common.Address) string {
+	return "0x" + hex.EncodeToString(addr.Bytes())
+}
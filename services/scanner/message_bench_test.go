@@ -0,0 +1,20 @@
+package scanner
+
+import "testing"
+
+func BenchmarkTxEventToMessage(b *testing.B) {
+	txEvent := testTxEvents(testBlockEvent())[0]
+	b.Run("domain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			txEvent.ToMessage()
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, ref := newTxRequest(txEvent)
+			ref.Release()
+		}
+	})
+}
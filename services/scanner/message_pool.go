@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// MessageRef counts the holders of a request message which was built from the pooled buffers.
+// The analyzer, the agent pool and every agent which receives the request hold a reference and
+// the buffers are put back to the pool after the last one is released.
+//
+// Only the nested parts of the events (traces, logs, addresses, transaction hashes) are reused.
+// The top level messages are allocated for each event since the publisher keeps reading them
+// after the agents and the analyzer are done.
+type MessageRef struct {
+	refs     int32
+	detached uint32
+	recycle  func()
+}
+
+func newMessageRef(recycle func()) *MessageRef {
+	return &MessageRef{refs: 1, recycle: recycle}
+}
+
+// Retain adds a holder to the message.
+func (ref *MessageRef) Retain() {
+	if ref == nil {
+		return
+	}
+	atomic.AddInt32(&ref.refs, 1)
+}
+
+// Release removes a holder from the message and recycles the buffers if it was the last one.
+func (ref *MessageRef) Release() {
+	if ref == nil {
+		return
+	}
+	if atomic.AddInt32(&ref.refs, -1) != 0 || atomic.LoadUint32(&ref.detached) == 1 {
+		return
+	}
+	ref.recycle()
+}
+
+// Detach prevents recycling the buffers of the message. This is needed when the whole event
+// needs to live longer than the holders, e.g. when it is included in an alert batch.
+func (ref *MessageRef) Detach() {
+	if ref == nil {
+		return
+	}
+	atomic.StoreUint32(&ref.detached, 1)
+}
+
+// txEventBuffers keeps the nested parts of a tx event. The slices are kept with their
+// capacity and the items beyond the length are reused by the next event.
+type txEventBuffers struct {
+	event     *protocol.TransactionEvent
+	traces    []*protocol.TransactionEvent_Trace
+	logs      []*protocol.TransactionEvent_Log
+	addresses map[string]bool
+}
+
+var txEventPool = sync.Pool{
+	New: func() interface{} {
+		return &txEventBuffers{addresses: make(map[string]bool)}
+	},
+}
+
+func getTxEventBuffers() *txEventBuffers {
+	return txEventPool.Get().(*txEventBuffers)
+}
+
+// recycle detaches the buffers from the event and puts them back to the pool.
+func (bufs *txEventBuffers) recycle() {
+	if bufs.event != nil {
+		bufs.event.Traces = nil
+		bufs.event.Logs = nil
+		bufs.event.Addresses = nil
+		if bufs.event.Receipt != nil {
+			bufs.event.Receipt.Logs = nil
+		}
+		bufs.event = nil
+	}
+	bufs.traces = bufs.traces[:0]
+	bufs.logs = bufs.logs[:0]
+	for address := range bufs.addresses {
+		delete(bufs.addresses, address)
+	}
+	txEventPool.Put(bufs)
+}
+
+// nextTrace returns a reset trace which is appended to the traces.
+func (bufs *txEventBuffers) nextTrace() *protocol.TransactionEvent_Trace {
+	n := len(bufs.traces)
+	if n < cap(bufs.traces) {
+		bufs.traces = bufs.traces[:n+1]
+		if trace := bufs.traces[n]; trace != nil {
+			action, result, traceAddress := trace.Action, trace.Result, trace.TraceAddress
+			trace.Reset()
+			action.Reset()
+			trace.Action = action
+			if result != nil {
+				result.Reset()
+			}
+			trace.Result = result
+			trace.TraceAddress = traceAddress[:0]
+			return trace
+		}
+	} else {
+		bufs.traces = append(bufs.traces, nil)
+	}
+	trace := &protocol.TransactionEvent_Trace{Action: &protocol.TransactionEvent_TraceAction{}}
+	bufs.traces[n] = trace
+	return trace
+}
+
+// nextLog returns a reset log which is appended to the logs.
+func (bufs *txEventBuffers) nextLog() *protocol.TransactionEvent_Log {
+	n := len(bufs.logs)
+	if n < cap(bufs.logs) {
+		bufs.logs = bufs.logs[:n+1]
+		if l := bufs.logs[n]; l != nil {
+			topics := l.Topics
+			l.Reset()
+			l.Topics = topics[:0]
+			return l
+		}
+	} else {
+		bufs.logs = append(bufs.logs, nil)
+	}
+	l := &protocol.TransactionEvent_Log{}
+	bufs.logs[n] = l
+	return l
+}
+
+// blockEventBuffers keeps the nested parts of a block event.
+type blockEventBuffers struct {
+	block        *protocol.BlockEvent_EthBlock
+	transactions []string
+	uncles       []string
+}
+
+var blockEventPool = sync.Pool{
+	New: func() interface{} {
+		return &blockEventBuffers{}
+	},
+}
+
+func getBlockEventBuffers() *blockEventBuffers {
+	return blockEventPool.Get().(*blockEventBuffers)
+}
+
+// recycle detaches the buffers from the block and puts them back to the pool.
+func (bufs *blockEventBuffers) recycle() {
+	if bufs.block != nil {
+		bufs.block.Transactions = nil
+		bufs.block.Uncles = nil
+		bufs.block = nil
+	}
+	bufs.transactions = bufs.transactions[:0]
+	bufs.uncles = bufs.uncles[:0]
+	blockEventPool.Put(bufs)
+}
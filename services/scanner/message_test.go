@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func testBlockEvent() *domain.BlockEvent {
+	txHash1 := "0x1111111111111111111111111111111111111111111111111111111111111111"
+	txHash2 := "0x2222222222222222222222222222222222222222222222222222222222222222"
+	removed := true
+	ts := time.Unix(1650000000, 0).UTC()
+	return &domain.BlockEvent{
+		EventType: domain.EventTypeBlock,
+		ChainID:   big.NewInt(137),
+		Block: &domain.Block{
+			Hash:       "0xb10c",
+			Number:     "0x10",
+			ParentHash: "0xb10b",
+			Timestamp:  "0x62585400",
+			Miner:      strPtr("0xMiner"),
+			GasLimit:   strPtr("0x1c9c380"),
+			Uncles:     []*string{strPtr("0xuncle"), nil},
+			Transactions: []domain.Transaction{
+				{
+					Hash:             txHash1,
+					From:             "0xAbCd000000000000000000000000000000000001",
+					To:               strPtr("0xAbCd000000000000000000000000000000000002"),
+					Nonce:            "0x5",
+					Gas:              "0x5208",
+					GasPrice:         "0x3b9aca00",
+					Value:            strPtr("0x1"),
+					Input:            strPtr("0xdeadbeef"),
+					TransactionIndex: "0x0",
+					V:                "0x1",
+					R:                "0x2",
+					S:                "0x3",
+				},
+				{
+					Hash:             txHash2,
+					From:             "0xAbCd000000000000000000000000000000000003",
+					Nonce:            "0x7",
+					Gas:              "0x10000",
+					GasPrice:         "0x3b9aca00",
+					TransactionIndex: "0x1",
+				},
+			},
+		},
+		Logs: []domain.LogEntry{
+			{
+				Address:         strPtr("0xAbCd000000000000000000000000000000000004"),
+				Topics:          []*string{strPtr("0xddf252ad"), strPtr("0x000000000000000000000000AbCd000000000000000000000000000000000005"), nil},
+				Data:            strPtr("0x01"),
+				BlockNumber:     strPtr("0x10"),
+				TransactionHash: strPtr(txHash1),
+				LogIndex:        strPtr("0x0"),
+				Removed:         &removed,
+			},
+			{
+				Address:         strPtr("0xAbCd000000000000000000000000000000000006"),
+				TransactionHash: strPtr(txHash2),
+				LogIndex:        strPtr("0x1"),
+			},
+			{
+				Address: strPtr("0xAbCd000000000000000000000000000000000007"),
+			},
+		},
+		Traces: []domain.Trace{
+			{
+				Action: domain.TraceAction{
+					CallType: strPtr("call"),
+					From:     strPtr("0xAbCd000000000000000000000000000000000001"),
+					To:       strPtr("0xAbCd000000000000000000000000000000000002"),
+					Input:    strPtr("0xdeadbeef"),
+					Gas:      strPtr("0x1"),
+				},
+				BlockHash:           strPtr("0xb10c"),
+				BlockNumber:         intPtr(16),
+				Result:              &domain.TraceResult{GasUsed: strPtr("0x10"), Output: strPtr("0x"), Address: strPtr("0xAbCd000000000000000000000000000000000008")},
+				Subtraces:           1,
+				TraceAddress:        []int{},
+				TransactionHash:     strPtr(txHash1),
+				TransactionPosition: intPtr(0),
+				Type:                "call",
+			},
+			{
+				Action: domain.TraceAction{
+					Address:       strPtr("0xAbCd000000000000000000000000000000000009"),
+					RefundAddress: strPtr("0xAbCd00000000000000000000000000000000000a"),
+					Balance:       strPtr("0x0"),
+				},
+				TraceAddress:    []int{0, 1},
+				TransactionHash: strPtr(txHash1),
+				Type:            "suicide",
+				Error:           strPtr("Reverted"),
+			},
+			{
+				Action:          domain.TraceAction{Init: strPtr("0x60")},
+				TransactionHash: strPtr(txHash2),
+				Type:            "create",
+			},
+		},
+		Timestamps: &domain.TrackingTimestamps{Block: ts, Feed: ts},
+	}
+}
+
+func testTxEvents(blockEvt *domain.BlockEvent) (txEvents []*domain.TransactionEvent) {
+	for i := range blockEvt.Block.Transactions {
+		txEvents = append(txEvents, &domain.TransactionEvent{
+			BlockEvt:    blockEvt,
+			Transaction: &blockEvt.Block.Transactions[i],
+			Timestamps:  blockEvt.Timestamps,
+		})
+	}
+	return
+}
+
+func TestTxEventToMessage(t *testing.T) {
+	r := require.New(t)
+
+	for _, txEvent := range testTxEvents(testBlockEvent()) {
+		expected, err := txEvent.ToMessage()
+		r.NoError(err)
+
+		// reuse the buffers of the previous conversions
+		req, ref := newTxRequest(txEvent)
+		r.True(proto.Equal(expected, req.Event), "expected: %v\nactual: %v", expected, req.Event)
+		ref.Release()
+	}
+}
+
+func TestBlockEventToMessage(t *testing.T) {
+	r := require.New(t)
+
+	blockEvent := testBlockEvent()
+	expected, err := blockEvent.ToMessage()
+	r.NoError(err)
+
+	for i := 0; i < 2; i++ {
+		req, ref := newBlockRequest(blockEvent)
+		r.True(proto.Equal(expected, req.Event), "expected: %v\nactual: %v", expected, req.Event)
+		ref.Release()
+	}
+}
+
+func TestMessageRef(t *testing.T) {
+	r := require.New(t)
+
+	txEvent := testTxEvents(testBlockEvent())[0]
+
+	// the nested parts are detached from the event after the last holder is done
+	req, ref := newTxRequest(txEvent)
+	ref.Retain()
+	ref.Release()
+	r.NotEmpty(req.Event.Traces)
+	r.NotEmpty(req.Event.Logs)
+	r.NotEmpty(req.Event.Addresses)
+	ref.Release()
+	r.Nil(req.Event.Traces)
+	r.Nil(req.Event.Logs)
+	r.Nil(req.Event.Receipt.Logs)
+	r.Nil(req.Event.Addresses)
+	r.NotEmpty(req.Event.Transaction.Hash)
+	r.NotEmpty(req.Event.Block.BlockNumber)
+
+	// a detached event is kept as is
+	req, ref = newTxRequest(txEvent)
+	ref.Detach()
+	ref.Release()
+	r.NotEmpty(req.Event.Traces)
+	r.NotEmpty(req.Event.Logs)
+	r.NotEmpty(req.Event.Addresses)
+
+	// nil references are ignored
+	var nilRef *MessageRef
+	nilRef.Retain()
+	nilRef.Detach()
+	nilRef.Release()
+}
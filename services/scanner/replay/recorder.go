@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// Finding is a finding written to the replay findings file.
+type Finding struct {
+	AgentID     string            `json:"agentId"`
+	ChainID     string            `json:"chainId"`
+	BlockNumber string            `json:"blockNumber"`
+	TxHash      string            `json:"txHash,omitempty"`
+	AlertID     string            `json:"alertId"`
+	Finding     *protocol.Finding `json:"finding"`
+}
+
+// Recorder writes the findings to a file as JSON lines instead of publishing them.
+type Recorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+	count int
+}
+
+// NewRecorder creates a new recorder which truncates the findings file.
+func NewRecorder(filename string) (*Recorder, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the findings file: %v", err)
+	}
+	return &Recorder{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Notify implements the clients.PublishClient interface.
+func (r *Recorder) Notify(ctx context.Context, req *protocol.NotifyRequest) (*protocol.NotifyResponse, error) {
+	if req.SignedAlert == nil || req.SignedAlert.Alert == nil {
+		return &protocol.NotifyResponse{}, nil
+	}
+	alert := req.SignedAlert.Alert
+	finding := &Finding{
+		ChainID:     req.SignedAlert.ChainId,
+		BlockNumber: req.SignedAlert.BlockNumber,
+		AlertID:     alert.Id,
+		Finding:     alert.Finding,
+	}
+	if req.AgentInfo != nil {
+		finding.AgentID = req.AgentInfo.Id
+	}
+	if req.EvalTxRequest != nil && req.EvalTxRequest.Event != nil && req.EvalTxRequest.Event.Transaction != nil {
+		finding.TxHash = req.EvalTxRequest.Event.Transaction.Hash
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(finding); err != nil {
+		return nil, fmt.Errorf("failed to write the finding: %v", err)
+	}
+	r.count++
+	return &protocol.NotifyResponse{}, nil
+}
+
+// Count returns the number of the written findings.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Close closes the findings file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -0,0 +1,211 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	checkInterval = time.Second
+	drainPeriod   = 10 * time.Second // how long the agents should be idle after the last block
+)
+
+// AgentPool runs the replay agents.
+type AgentPool interface {
+	AgentStatuses() []*poolagent.Status
+}
+
+// Status is the progress of the replay.
+type Status struct {
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+	Started   bool   `json:"started"`
+	LastBlock uint64 `json:"lastBlock"`
+	Findings  int    `json:"findings"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Service starts streaming the replay block range after all replay agents are ready and
+// detects when the agents are done with the range.
+type Service struct {
+	ctx       context.Context
+	cfg       config.ReplayConfig
+	feed      feeds.BlockFeed
+	agentPool AgentPool
+	recorder  *Recorder
+	rateLimit int
+
+	mu        sync.RWMutex
+	started   bool
+	lastBlock uint64
+	feedDone  bool
+	idleSince time.Time
+	done      bool
+	err       error
+}
+
+// NewService creates a new replay service.
+func NewService(ctx context.Context, cfg config.Config, feed feeds.BlockFeed, agentPool AgentPool, recorder *Recorder) *Service {
+	return &Service{
+		ctx:       ctx,
+		cfg:       cfg.Replay,
+		feed:      feed,
+		agentPool: agentPool,
+		recorder:  recorder,
+		rateLimit: cfg.Scan.BlockRateLimit,
+	}
+}
+
+// Start implements the services.Service interface.
+func (s *Service) Start() error {
+	errCh := s.feed.Subscribe(s.handleBlock)
+	go func() {
+		err := <-errCh
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.feedDone = true
+		if err != nil && !errors.Is(err, feeds.ErrEndBlockReached) {
+			s.err = err
+		}
+	}()
+	go s.run()
+	return nil
+}
+
+func (s *Service) handleBlock(evt *domain.BlockEvent) error {
+	blockNumber, err := hexutil.DecodeUint64(evt.Block.Number)
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastBlock = blockNumber
+	return nil
+}
+
+func (s *Service) run() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.check() {
+			return
+		}
+	}
+}
+
+// check starts the range after the agents are ready and tells if the replay is done.
+func (s *Service) check() bool {
+	statuses := s.agentPool.AgentStatuses()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		if !agentsReady(s.cfg.Agents, statuses) {
+			return false
+		}
+		log.WithFields(log.Fields{
+			"from": s.cfg.FromBlock,
+			"to":   s.cfg.ToBlock,
+		}).Info("replay agents are ready - starting the block range")
+		s.feed.StartRange(int64(s.cfg.FromBlock), int64(s.cfg.ToBlock), int64(s.rateLimit))
+		s.started = true
+		return false
+	}
+
+	if !s.feedDone || !agentsIdle(statuses) {
+		s.idleSince = time.Time{}
+		return false
+	}
+	if s.idleSince.IsZero() {
+		s.idleSince = time.Now()
+	}
+	if time.Since(s.idleSince) < drainPeriod {
+		return false
+	}
+	s.done = true
+	log.WithField("findings", s.recorder.Count()).Info("replay is done")
+	return true
+}
+
+func agentsReady(agents []*config.AgentConfig, statuses []*poolagent.Status) bool {
+	ready := make(map[string]bool)
+	for _, status := range statuses {
+		if status.Ready {
+			ready[status.ID] = true
+		}
+	}
+	for _, agent := range agents {
+		if !ready[agent.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+func agentsIdle(statuses []*poolagent.Status) bool {
+	for _, status := range statuses {
+		if status.TxBuffer > 0 || status.BlockBuffer > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Status returns the progress of the replay.
+func (s *Service) Status() *Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := &Status{
+		FromBlock: s.cfg.FromBlock,
+		ToBlock:   s.cfg.ToBlock,
+		Started:   s.started,
+		LastBlock: s.lastBlock,
+		Findings:  s.recorder.Count(),
+		Done:      s.done,
+	}
+	if s.err != nil {
+		status.Error = s.err.Error()
+	}
+	return status
+}
+
+// Stop implements the services.Service interface.
+func (s *Service) Stop() error {
+	log.Infof("Stopping %s", s.Name())
+	return s.recorder.Close()
+}
+
+// Name returns the name of the service.
+func (s *Service) Name() string {
+	return "replay"
+}
+
+// Health implements the health.Reporter interface.
+func (s *Service) Health() health.Reports {
+	status := s.Status()
+	return health.Reports{
+		&health.Report{
+			Name:    "progress",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d/%d", status.LastBlock, status.ToBlock),
+		},
+	}
+}
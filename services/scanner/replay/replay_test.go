@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentsReady(t *testing.T) {
+	r := require.New(t)
+
+	agents := []*config.AgentConfig{{ID: "0x1"}, {ID: "0x2"}}
+	r.False(agentsReady(agents, nil))
+	r.False(agentsReady(agents, []*poolagent.Status{{ID: "0x1", Ready: true}, {ID: "0x2"}}))
+	r.True(agentsReady(agents, []*poolagent.Status{{ID: "0x1", Ready: true}, {ID: "0x2", Ready: true}}))
+}
+
+func TestAgentsIdle(t *testing.T) {
+	r := require.New(t)
+
+	r.True(agentsIdle([]*poolagent.Status{{ID: "0x1"}}))
+	r.False(agentsIdle([]*poolagent.Status{{ID: "0x1", TxBuffer: 1}}))
+	r.False(agentsIdle([]*poolagent.Status{{ID: "0x1", BlockBuffer: 1}}))
+}
+
+func TestRecorder(t *testing.T) {
+	r := require.New(t)
+
+	filename := path.Join(t.TempDir(), config.DefaultReplayFindingsFileName)
+	recorder, err := NewRecorder(filename)
+	r.NoError(err)
+
+	_, err = recorder.Notify(context.Background(), &protocol.NotifyRequest{
+		SignedAlert: &protocol.SignedAlert{
+			Alert:       &protocol.Alert{Id: "alert-id", Finding: &protocol.Finding{AlertId: "ALERT-1"}},
+			ChainId:     "1",
+			BlockNumber: "0x64",
+		},
+		AgentInfo: &protocol.AgentInfo{Id: "0x1"},
+	})
+	r.NoError(err)
+	_, err = recorder.Notify(context.Background(), &protocol.NotifyRequest{}) // no alert
+	r.NoError(err)
+	r.Equal(1, recorder.Count())
+	r.NoError(recorder.Close())
+
+	b, err := ioutil.ReadFile(filename)
+	r.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	r.Len(lines, 1)
+	var finding Finding
+	r.NoError(json.Unmarshal([]byte(lines[0]), &finding))
+	r.Equal("0x1", finding.AgentID)
+	r.Equal("0x64", finding.BlockNumber)
+	r.Equal("ALERT-1", finding.Finding.AlertId)
+}
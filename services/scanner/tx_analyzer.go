@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"context"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/forta-network/forta-core-go/domain"
@@ -16,7 +19,6 @@ import (
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
 
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -34,6 +36,8 @@ type TxAnalyzerServiceConfig struct {
 	AlertSender clients.AlertSender
 	AgentPool   AgentPool
 	MsgClient   clients.MessageClient
+	// Workers is the number of workers which convert the tx events to requests.
+	Workers int
 }
 
 // WARNING, this must be deterministic (any maps must be converted to sorted lists)
@@ -103,6 +107,12 @@ func (t *TxAnalyzerService) Start() error {
 	go func() {
 		for result := range t.cfg.AgentPool.TxResults() {
 			ts := time.Now().UTC()
+			_, span := tracing.StartSpan(
+				t.ctx, result.Request.Event.Block.BlockNumber, "analyzer.handle_tx_result",
+				attribute.String("agent.id", result.AgentConfig.ID),
+				attribute.String("tx.hash", result.Request.Event.Transaction.Hash),
+				attribute.Int("findings", len(result.Response.Findings)),
+			)
 
 			rt := &clients.AgentRoundTrip{
 				AgentConfig:    result.AgentConfig,
@@ -110,6 +120,11 @@ func (t *TxAnalyzerService) Start() error {
 				EvalTxResponse: result.Response,
 			}
 
+			// the publisher keeps the whole event in the batch with the alerts
+			if len(result.Response.Findings) > 0 {
+				result.Ref.Detach()
+			}
+
 			if len(result.Response.Findings) == 0 {
 				if err := t.cfg.AlertSender.NotifyWithoutAlert(
 					rt, result.Timestamps,
@@ -132,6 +147,8 @@ func (t *TxAnalyzerService) Start() error {
 				}
 			}
 			t.publishMetrics(result)
+			span.End()
+			result.Ref.Release()
 
 			t.lastOutputActivity.Set()
 		}
@@ -139,24 +156,14 @@ func (t *TxAnalyzerService) Start() error {
 
 	// Gear 1: loops over transactions and distributes to all agents
 	go func() {
-		// for each transaction
-		for tx := range t.cfg.TxChannel {
-			// convert to message
-			msg, err := tx.ToMessage()
-			if err != nil {
-				log.WithError(err).Error("error converting tx event to message (skipping)")
-				continue
-			}
-
-			// create a request
-			requestId := uuid.Must(uuid.NewUUID())
-			request := &protocol.EvaluateTxRequest{RequestId: requestId.String(), Event: msg}
-
-			// forward to the pool
-			t.cfg.AgentPool.SendEvaluateTxRequest(request)
+		// for each transaction, create a request from the pooled messages in the workers
+		convertTxEvents(t.cfg.TxChannel, t.cfg.Workers, func(request *protocol.EvaluateTxRequest, ref *MessageRef) {
+			// forward to the pool in the order of the transactions
+			t.cfg.AgentPool.SendEvaluateTxRequest(request, ref)
+			ref.Release()
 
 			t.lastInputActivity.Set()
-		}
+		})
 	}()
 
 	return nil
@@ -180,6 +187,9 @@ func (t *TxAnalyzerService) Health() health.Reports {
 }
 
 func NewTxAnalyzerService(ctx context.Context, cfg TxAnalyzerServiceConfig) (*TxAnalyzerService, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
 	return &TxAnalyzerService{
 		cfg: cfg,
 		ctx: ctx,
@@ -2,13 +2,20 @@ package scanner
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/domain"
 	"github.com/forta-network/forta-core-go/ethereum"
 	"github.com/forta-network/forta-core-go/feeds"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -21,8 +28,10 @@ type TxStreamService struct {
 	txOutput    chan *domain.TransactionEvent
 	txFeed      feeds.TransactionFeed
 
-	lastBlockActivity health.TimeTracker
-	lastTxActivity    health.TimeTracker
+	lastBlockActivity  health.TimeTracker
+	lastTxActivity     health.TimeTracker
+	lastBlockTimestamp int64 // unix seconds
+	lastBlockNumber    uint64
 }
 
 type TxStreamServiceConfig struct {
@@ -40,13 +49,44 @@ func (t *TxStreamService) ReadOnlyTxStream() <-chan *domain.TransactionEvent {
 }
 
 func (t *TxStreamService) handleBlock(evt *domain.BlockEvent) error {
+	metrics.ObserveBlock(evt.Block, time.Now())
+	if ts, err := evt.Block.GetTimestamp(); err == nil {
+		atomic.StoreInt64(&t.lastBlockTimestamp, ts.Unix())
+	}
+	t.countSkippedBlocks(evt.Block.Number)
+	_, span := tracing.StartSpan(t.ctx, evt.Block.Number, "feed.block")
 	t.blockOutput <- evt
+	span.End()
 	t.lastBlockActivity.Set()
 	return nil
 }
 
+// countSkippedBlocks counts the blocks which the feed skipped before this block, e.g. because
+// they were too old.
+func (t *TxStreamService) countSkippedBlocks(blockNumberHex string) {
+	blockNumber, err := hexutil.DecodeUint64(blockNumberHex)
+	if err != nil {
+		return
+	}
+	if t.lastBlockNumber > 0 && blockNumber > t.lastBlockNumber+1 {
+		skipped := int(blockNumber - t.lastBlockNumber - 1)
+		metrics.ObserveEventLoss(metrics.LossStageFeed, metrics.LossReasonSkipped, metrics.EventKindBlock, "", skipped)
+		log.WithFields(log.Fields{
+			"from":    t.lastBlockNumber + 1,
+			"to":      blockNumber - 1,
+			"skipped": skipped,
+		}).Warn("skipped blocks")
+	}
+	if blockNumber > t.lastBlockNumber {
+		t.lastBlockNumber = blockNumber
+	}
+}
+
 func (t *TxStreamService) handleTx(evt *domain.TransactionEvent) error {
+	metrics.BlockFeedTransactions.Inc()
+	_, span := tracing.StartSpan(t.ctx, evt.BlockEvt.Block.Number, "feed.tx", attribute.String("tx.hash", evt.Transaction.Hash))
 	t.txOutput <- evt
+	span.End()
 	t.lastTxActivity.Set()
 	return nil
 }
@@ -84,6 +124,19 @@ func (t *TxStreamService) Health() health.Reports {
 	}
 }
 
+// CheckLag returns an error if the stream has not received any blocks or if the latest block
+// is older than the max lag.
+func (t *TxStreamService) CheckLag(maxLag time.Duration) error {
+	ts := atomic.LoadInt64(&t.lastBlockTimestamp)
+	if ts == 0 {
+		return errors.New("no blocks received yet")
+	}
+	if lag := time.Since(time.Unix(ts, 0)); lag > maxLag {
+		return fmt.Errorf("latest block is %s behind (max: %s)", lag.Truncate(time.Second), maxLag)
+	}
+	return nil
+}
+
 func NewTxStreamService(ctx context.Context, ethClient ethereum.Client, blockFeed feeds.BlockFeed, cfg TxStreamServiceConfig) (*TxStreamService, error) {
 	txOutput := make(chan *domain.TransactionEvent)
 	blockOutput := make(chan *domain.BlockEvent)
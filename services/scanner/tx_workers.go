@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// txRequestJob is a tx event which is converted to a request by one of the workers.
+type txRequestJob struct {
+	evt    *domain.TransactionEvent
+	result chan *txRequestResult
+}
+
+type txRequestResult struct {
+	request *protocol.EvaluateTxRequest
+	ref     *MessageRef
+}
+
+// convertTxEvents converts the tx events to requests with a bounded number of workers and
+// hands the requests to the handler in the same order as the events. It returns after the
+// event channel is closed and all of the requests are handled.
+func convertTxEvents(txCh <-chan *domain.TransactionEvent, workers int, handler func(request *protocol.EvaluateTxRequest, ref *MessageRef)) {
+	jobs := make(chan *txRequestJob, workers)
+	// limits how far the workers can get ahead of the handler
+	pending := make(chan *txRequestJob, workers*2)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				request, ref := newTxRequest(job.evt)
+				job.result <- &txRequestResult{request: request, ref: ref}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for evt := range txCh {
+			job := &txRequestJob{evt: evt, result: make(chan *txRequestResult, 1)}
+			// keep the order before the job is picked up by a worker
+			pending <- job
+			jobs <- job
+		}
+	}()
+
+	for job := range pending {
+		result := <-job.result
+		handler(result.request, result.ref)
+	}
+}
@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTxEvents(t *testing.T) {
+	r := require.New(t)
+
+	blockEvt := testBlockEvent()
+	txEvt := testTxEvents(blockEvt)[0]
+
+	const count = 1000
+	txCh := make(chan *domain.TransactionEvent)
+	go func() {
+		for i := 0; i < count; i++ {
+			tx := *txEvt.Transaction
+			tx.Hash = fmt.Sprintf("0x%064x", i)
+			txCh <- &domain.TransactionEvent{
+				BlockEvt:    blockEvt,
+				Transaction: &tx,
+				Timestamps:  txEvt.Timestamps,
+			}
+		}
+		close(txCh)
+	}()
+
+	// the requests are handled in the order of the events
+	var hashes []string
+	convertTxEvents(txCh, 8, func(request *protocol.EvaluateTxRequest, ref *MessageRef) {
+		hashes = append(hashes, request.Event.Transaction.Hash)
+		ref.Release()
+	})
+	r.Len(hashes, count)
+	for i, hash := range hashes {
+		r.Equal(fmt.Sprintf("0x%064x", i), hash)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/logging"
 )
 
 const (
@@ -30,7 +31,7 @@ type Service interface {
 	Name() string
 }
 
-var sigc = make(chan os.Signal)
+var sigc = make(chan os.Signal, 1)
 
 var execIDKey = struct{}{}
 
@@ -83,16 +84,14 @@ func ContainerMain(name string, getServices func(ctx context.Context, cfg config
 		return
 	}
 
-	lvl, err := log.ParseLevel(cfg.Log.Level)
-	if err != nil {
-		logger.WithError(err).Error("could not initialize log level")
+	if err := logging.Init(cfg.Log, name); err != nil {
+		logger.WithError(err).Error("could not initialize logging")
 		return
 	}
-	log.SetLevel(lvl)
-	log.SetFormatter(&log.JSONFormatter{})
 	logger.Info("starting")
 	defer logger.Info("exiting")
 
+	enableConfigReload()
 	ctx, cancel := InitMainContext()
 	defer cancel()
 
@@ -101,6 +100,7 @@ func ContainerMain(name string, getServices func(ctx context.Context, cfg config
 		logger.WithError(err).Error("could not initialize services")
 		return
 	}
+	go watchConfig(ctx, logger, cfg, serviceList)
 
 	if err := StartServices(ctx, cancel, logger, serviceList); err != nil {
 		logger.WithError(err).Error("failed to start services")
@@ -123,10 +123,21 @@ func InitMainContext() (context.Context, context.CancelFunc) {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 	go func() {
-		sig := <-sigc
-		log.Infof("received signal: %s", sig.String())
-		gracefulShutdown = sig == GracefulShutdownSignal
-		cancel()
+		for {
+			sig := <-sigc
+			log.Infof("received signal: %s", sig.String())
+			// reload the config instead of exiting if possible
+			if sig == syscall.SIGHUP && reloadc != nil {
+				select {
+				case reloadc <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			gracefulShutdown = sig == GracefulShutdownSignal
+			cancel()
+			return
+		}
 	}()
 	return ctx, cancel
 }
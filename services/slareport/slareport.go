@@ -0,0 +1,258 @@
+package slareport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReportsDirName is the directory in the forta dir which keeps the reports.
+const ReportsDirName = "sla-reports"
+
+const reportFileTimeFormat = "20060102T150405Z"
+
+// Report is the performance record of the scanner in a time window.
+type Report struct {
+	Scanner           string  `json:"scanner"`
+	ChainID           int     `json:"chainId"`
+	WindowStart       string  `json:"windowStart"`
+	WindowEnd         string  `json:"windowEnd"`
+	UptimeSeconds     int64   `json:"uptimeSeconds"`
+	UptimeRatio       float64 `json:"uptimeRatio"`
+	BlocksScanned     uint64  `json:"blocksScanned"`
+	LatestBlock       uint64  `json:"latestBlock"`
+	EventsDispatched  uint64  `json:"eventsDispatched"`
+	FindingsPublished uint64  `json:"findingsPublished"`
+}
+
+// SignedReport keeps the report as it was signed so that the signature can be verified.
+type SignedReport struct {
+	Report    json.RawMessage     `json:"report"`
+	Signature *protocol.Signature `json:"signature"`
+}
+
+// Verify verifies the signature of the report.
+func (sr *SignedReport) Verify() error {
+	if sr.Signature == nil {
+		return errors.New("missing signature")
+	}
+	return security.VerifySignature(sr.Report, sr.Signature.Signer, sr.Signature.Signature)
+}
+
+// counters are the totals of the pipeline counters since the start of the process.
+type counters struct {
+	blocks   uint64
+	events   uint64
+	findings uint64
+}
+
+func readCounters() counters {
+	return counters{
+		blocks:   uint64(metrics.CounterTotal(metrics.BlockFeedBlocks)),
+		events:   uint64(metrics.CounterTotal(metrics.EventsDispatched)),
+		findings: uint64(metrics.CounterTotal(metrics.FindingsPublished)),
+	}
+}
+
+// Service creates a signed report at the end of each time window, stores it and sends it
+// to the endpoint in the config.
+type Service struct {
+	ctx        context.Context
+	cfg        config.SLAReportConfig
+	chainID    int
+	signer     signer.Signer
+	dir        string
+	startedAt  time.Time
+	last       counters
+	httpClient *http.Client
+
+	lastReport    health.TimeTracker
+	lastReportErr health.ErrorTracker
+}
+
+// NewService creates a new SLA report service.
+func NewService(ctx context.Context, cfg config.Config, scannerSigner signer.Signer) *Service {
+	return &Service{
+		ctx:        ctx,
+		cfg:        cfg.SLAReport,
+		chainID:    cfg.ChainID,
+		signer:     scannerSigner,
+		dir:        path.Join(config.DefaultContainerFortaDirPath, ReportsDirName),
+		startedAt:  time.Now(),
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}
+}
+
+// Start implements services.Service interface.
+func (svc *Service) Start() error {
+	if !svc.cfg.Enable {
+		return nil
+	}
+	log.Infof("Starting %s", svc.Name())
+	if err := os.MkdirAll(svc.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create the reports dir: %v", err)
+	}
+	go svc.run()
+	return nil
+}
+
+// run creates the reports at the end of the windows which are aligned to the interval.
+func (svc *Service) run() {
+	interval := time.Duration(svc.cfg.IntervalMinutes) * time.Minute
+	windowStart := time.Now().Truncate(interval)
+	for {
+		windowEnd := windowStart.Add(interval)
+		timer := time.NewTimer(time.Until(windowEnd))
+		select {
+		case <-svc.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		err := svc.report(windowStart, windowEnd, readCounters())
+		if err != nil {
+			log.WithError(err).Error("failed to create the sla report")
+		}
+		svc.lastReport.Set()
+		svc.lastReportErr.Set(err)
+		windowStart = windowEnd
+	}
+}
+
+// report creates the report of the window using the difference of the counters since the last window.
+func (svc *Service) report(windowStart, windowEnd time.Time, current counters) error {
+	uptimeStart := windowStart
+	if svc.startedAt.After(uptimeStart) {
+		uptimeStart = svc.startedAt
+	}
+	uptime := windowEnd.Sub(uptimeStart)
+	report := &Report{
+		Scanner:           svc.signer.Address().Hex(),
+		ChainID:           svc.chainID,
+		WindowStart:       windowStart.UTC().Format(time.RFC3339),
+		WindowEnd:         windowEnd.UTC().Format(time.RFC3339),
+		UptimeSeconds:     int64(uptime.Seconds()),
+		UptimeRatio:       uptime.Seconds() / windowEnd.Sub(windowStart).Seconds(),
+		BlocksScanned:     current.blocks - svc.last.blocks,
+		LatestBlock:       uint64(metrics.GaugeValue(metrics.BlockFeedLatestBlock)),
+		EventsDispatched:  current.events - svc.last.events,
+		FindingsPublished: current.findings - svc.last.findings,
+	}
+	svc.last = current
+
+	signed, err := signReport(svc.signer, report)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(signed)
+	if err != nil {
+		return fmt.Errorf("failed to encode the report: %v", err)
+	}
+	fileName := path.Join(svc.dir, windowStart.UTC().Format(reportFileTimeFormat)+".json")
+	if err := os.WriteFile(fileName, b, 0644); err != nil {
+		return fmt.Errorf("failed to write the report: %v", err)
+	}
+	svc.prune()
+	log.WithFields(log.Fields{
+		"windowStart": report.WindowStart,
+		"uptime":      report.UptimeRatio,
+		"blocks":      report.BlocksScanned,
+		"findings":    report.FindingsPublished,
+	}).Info("created sla report")
+
+	if len(svc.cfg.URL) == 0 {
+		return nil
+	}
+	return svc.send(b)
+}
+
+func signReport(scannerSigner signer.Signer, report *Report) (*SignedReport, error) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the report: %v", err)
+	}
+	sig, err := signer.SignBytes(scannerSigner, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the report: %v", err)
+	}
+	return &SignedReport{Report: b, Signature: sig}, nil
+}
+
+func (svc *Service) send(b []byte) error {
+	req, err := http.NewRequestWithContext(svc.ctx, http.MethodPost, svc.cfg.URL, bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := svc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the report: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("report endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// prune removes the oldest reports which exceed the max number of reports.
+func (svc *Service) prune() {
+	entries, err := os.ReadDir(svc.dir)
+	if err != nil {
+		log.WithError(err).Warn("failed to list the sla reports")
+		return
+	}
+	var fileNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			fileNames = append(fileNames, entry.Name())
+		}
+	}
+	sort.Strings(fileNames)
+	for i := 0; i < len(fileNames)-svc.cfg.MaxReports; i++ {
+		if err := os.Remove(path.Join(svc.dir, fileNames[i])); err != nil {
+			log.WithError(err).Warn("failed to remove the sla report")
+		}
+	}
+}
+
+// Stop implements services.Service interface.
+func (svc *Service) Stop() error {
+	return nil
+}
+
+// Name implements services.Service interface.
+func (svc *Service) Name() string {
+	return "sla-report"
+}
+
+// Health implements health.Reporter interface.
+func (svc *Service) Health() health.Reports {
+	if !svc.cfg.Enable {
+		return nil
+	}
+	return health.Reports{
+		&health.Report{
+			Name:    "event.report.time",
+			Status:  health.StatusInfo,
+			Details: svc.lastReport.String(),
+		},
+		svc.lastReportErr.GetReport("event.report.error"),
+	}
+}
@@ -0,0 +1,77 @@
+package slareport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/signer"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	r := require.New(t)
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		received <- b
+	}))
+	defer server.Close()
+
+	windowStart := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	windowEnd := windowStart.Add(time.Hour)
+	svc := &Service{
+		ctx:        context.Background(),
+		cfg:        config.SLAReportConfig{Enable: true, IntervalMinutes: 60, URL: server.URL, MaxReports: 1},
+		chainID:    1,
+		signer:     signer.NewKeystoreSigner(key),
+		dir:        t.TempDir(),
+		startedAt:  windowStart.Add(time.Minute * 15),
+		last:       counters{blocks: 10, events: 100, findings: 1},
+		httpClient: server.Client(),
+	}
+	// an older report which should be pruned
+	oldReportFile := path.Join(svc.dir, "20220601T090000Z.json")
+	r.NoError(os.WriteFile(oldReportFile, []byte("{}"), 0644))
+
+	r.NoError(svc.report(windowStart, windowEnd, counters{blocks: 310, events: 3100, findings: 5}))
+
+	var signed SignedReport
+	r.NoError(json.Unmarshal(<-received, &signed))
+	r.NoError(signed.Verify())
+	var report Report
+	r.NoError(json.Unmarshal(signed.Report, &report))
+	r.Equal(key.Address.Hex(), report.Scanner)
+	r.Equal("2022-06-01T10:00:00Z", report.WindowStart)
+	r.Equal(int64(45*60), report.UptimeSeconds)
+	r.Equal(0.75, report.UptimeRatio)
+	r.Equal(uint64(300), report.BlocksScanned)
+	r.Equal(uint64(3000), report.EventsDispatched)
+	r.Equal(uint64(4), report.FindingsPublished)
+
+	// the stored report is the same as the sent one
+	b, err := os.ReadFile(path.Join(svc.dir, "20220601T100000Z.json"))
+	r.NoError(err)
+	var stored SignedReport
+	r.NoError(json.Unmarshal(b, &stored))
+	r.NoError(stored.Verify())
+	r.NoFileExists(oldReportFile)
+
+	// tampering breaks the signature
+	signed.Report = []byte(`{"uptimeRatio":1}`)
+	r.Error(signed.Verify())
+}
@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"fmt"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// egressLimitScript shapes the traffic which leaves the default route interface of the container.
+// The traffic to the local subnet (the scanner and the JSON-RPC proxy) is not limited.
+const egressLimitScript = `set -e
+dev=$(ip route show default | awk '{print $5}')
+subnet=$(ip -o -4 addr show dev "$dev" | awk '{print $4}')
+tc qdisc replace dev "$dev" root handle 1: htb default 20
+tc class add dev "$dev" parent 1: classid 1:10 htb rate 10gbit
+tc class add dev "$dev" parent 1: classid 1:20 htb rate %[1]dkbit ceil %[1]dkbit
+tc filter add dev "$dev" parent 1: protocol ip prio 1 u32 match ip dst "$subnet" flowid 1:10
+`
+
+// limitAgentEgress limits the internet upload bandwidth of the agent container by running a
+// short-lived helper container in the network namespace of the agent. The limit is lost when the
+// agent container restarts so this should be called after every start.
+func (sup *SupervisorService) limitAgentEgress(agent config.AgentConfig, agentContainerID string) error {
+	if !sup.config.Config.AgentNetwork.AllowsEgress(agent.ID) {
+		return nil // no internet access
+	}
+	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig, agent.ID)
+	if limits.EgressKbit == 0 {
+		return nil
+	}
+
+	helper, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
+		Name:      fmt.Sprintf("%s-egress-%s", agent.ContainerName(), shortContainerID(agentContainerID)),
+		Image:     sup.nodeImage,
+		NetworkID: fmt.Sprintf("container:%s", agentContainerID),
+		CapAdd:    []string{"NET_ADMIN"},
+		Cmd:       []string{"sh", "-c", fmt.Sprintf(egressLimitScript, limits.EgressKbit)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start the egress limit helper: %v", err)
+	}
+	if err := sup.client.WaitContainerExit(sup.ctx, helper.ID); err != nil {
+		return fmt.Errorf("failed while waiting for the egress limit helper: %v", err)
+	}
+	exitCode, err := sup.client.GetContainerExitCode(sup.ctx, helper.ID)
+	if err := sup.client.RemoveContainer(sup.ctx, helper.ID); err != nil {
+		log.WithError(err).WithField("container", helper.Name).Warn("failed to remove the egress limit helper")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the exit code of the egress limit helper: %v", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("egress limit helper failed with exit code %d", exitCode)
+	}
+	log.WithFields(log.Fields{
+		"agent": agent.ContainerName(),
+		"kbit":  limits.EgressKbit,
+	}).Info("limited agent egress bandwidth")
+	return nil
+}
+
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,153 @@
+package supervisor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const agentContainerNamePrefix = config.ContainerNamePrefix + "-agent-"
+
+func (sup *SupervisorService) cleanupAgents() {
+	interval := time.Duration(sup.config.Config.AgentCleanup.IntervalMinutes) * time.Minute
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-sup.ctx.Done():
+			ticker.Stop()
+			return
+
+		case <-ticker.C:
+			if err := sup.doCleanupAgents(); err != nil {
+				log.WithError(err).Warn("failed to clean up agents")
+			}
+		}
+	}
+}
+
+func (sup *SupervisorService) doCleanupAgents() error {
+	if err := sup.removeOrphanAgentContainers(); err != nil {
+		return fmt.Errorf("failed to remove orphan agent containers: %v", err)
+	}
+	if err := sup.removeStaleAgentImages(time.Now()); err != nil {
+		return fmt.Errorf("failed to remove stale agent images: %v", err)
+	}
+	return nil
+}
+
+// removeOrphanAgentContainers removes the stopped agent containers which the supervisor does not
+// manage anymore, together with their anonymous volumes.
+func (sup *SupervisorService) removeOrphanAgentContainers() error {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+
+	containers, err := sup.client.GetContainers(sup.ctx)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		name := container.Names[0][1:]
+		if !strings.HasPrefix(name, agentContainerNamePrefix) || container.State == "running" {
+			continue
+		}
+		if _, ok := sup.getContainerUnsafe(name); ok {
+			continue
+		}
+		log.WithField("container", name).Info("removing orphan agent container")
+		if err := sup.client.RemoveContainer(sup.ctx, container.ID); err != nil {
+			log.WithError(err).WithField("container", name).Warn("failed to remove orphan agent container")
+		}
+	}
+	return nil
+}
+
+// removeStaleAgentImages removes the agent images which have not been used by any container
+// for longer than the retention period.
+func (sup *SupervisorService) removeStaleAgentImages(now time.Time) error {
+	containers, err := sup.globalClient.GetContainers(sup.ctx)
+	if err != nil {
+		return err
+	}
+	inUse := make(map[string]bool)
+	for _, container := range containers {
+		inUse[container.ImageID] = true
+	}
+
+	images, err := sup.agentImageClient.GetImages(sup.ctx)
+	if err != nil {
+		return err
+	}
+	if sup.unusedImages == nil {
+		sup.unusedImages = make(map[string]time.Time)
+	}
+	retention := time.Duration(sup.config.Config.AgentCleanup.ImageRetentionHours) * time.Hour
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if !sup.isAgentImage(image) {
+			continue
+		}
+		seen[image.ID] = true
+		if inUse[image.ID] {
+			delete(sup.unusedImages, image.ID)
+			continue
+		}
+		unusedSince, ok := sup.unusedImages[image.ID]
+		if !ok {
+			unusedSince = now
+			sup.unusedImages[image.ID] = now
+		}
+		if now.Sub(unusedSince) < retention {
+			continue
+		}
+
+		logger := log.WithField("image", image.ID)
+		logger.Info("removing stale agent image")
+		for _, ref := range imageRefs(image) {
+			if err := sup.agentImageClient.RemoveImage(sup.ctx, ref); err != nil {
+				logger.WithError(err).WithField("ref", ref).Warn("failed to remove stale agent image")
+				break
+			}
+		}
+		delete(sup.unusedImages, image.ID)
+	}
+
+	// forget the images which were removed by others
+	for id := range sup.unusedImages {
+		if !seen[id] {
+			delete(sup.unusedImages, id)
+		}
+	}
+	return nil
+}
+
+// isAgentImage tells if the image was pulled from the agent registry or one of its mirrors.
+func (sup *SupervisorService) isAgentImage(image types.ImageSummary) bool {
+	hosts := []string{sup.config.Config.Registry.ContainerRegistry}
+	for _, mirror := range sup.config.Config.Registry.Mirrors {
+		hosts = append(hosts, mirror.Host)
+	}
+	for _, ref := range imageRefs(image) {
+		for _, host := range hosts {
+			if len(host) > 0 && strings.HasPrefix(ref, host+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func imageRefs(image types.ImageSummary) []string {
+	var refs []string
+	for _, list := range [][]string{image.RepoTags, image.RepoDigests} {
+		for _, ref := range list {
+			if !strings.HasPrefix(ref, "<none>") {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
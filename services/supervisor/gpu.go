@@ -0,0 +1,37 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+)
+
+// Agent GPU errors
+var (
+	errGPUsNotAllowed   = errors.New("agent requests GPUs but they are not enabled for it in the config")
+	errGPUsNotAvailable = errors.New("agent requests GPUs but the host does not have the nvidia runtime")
+)
+
+// getAgentGPUs returns how many GPUs should be passed to the agent. The agents that request
+// GPUs are not started if the node cannot provide them, since they cannot work without.
+func (sup *SupervisorService) getAgentGPUs(agent config.AgentConfig) (int, error) {
+	if agent.GPUs == 0 {
+		return 0, nil
+	}
+	if !sup.config.Config.ResourcesConfig.AllowsGPUs(agent.ID) {
+		return 0, errGPUsNotAllowed
+	}
+	if sup.gpuRuntime == nil {
+		hasRuntime, err := sup.client.HasRuntime(sup.ctx, clients.RuntimeNvidia)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check the gpu support: %v", err)
+		}
+		sup.gpuRuntime = &hasRuntime
+	}
+	if !*sup.gpuRuntime {
+		return 0, errGPUsNotAvailable
+	}
+	return agent.GPUs, nil
+}
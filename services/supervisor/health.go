@@ -6,7 +6,6 @@ import (
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
 
-	"fmt"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -66,16 +65,34 @@ func (sup *SupervisorService) doHealthCheck() error {
 }
 
 func (sup *SupervisorService) ensureUp(knownContainer *Container, foundContainer *types.Container) error {
+	if knownContainer.IsAgent {
+		return sup.ensureAgentUp(knownContainer, foundContainer)
+	}
 	switch foundContainer.State {
 	case "created", "running", "restarting", "paused", "dead":
 		return nil
 	case "exited":
 		log.Warnf("starting exited container '%s'", knownContainer.Name)
-		_, err := sup.client.StartContainer(sup.ctx, knownContainer.Config)
-		if err != nil {
-			return fmt.Errorf("failed to start container '%s': %v", knownContainer.Name, err)
-		}
+		return sup.restartService(knownContainer, "exited", false)
+	default:
+		log.Panicf("unhandled container state: %s", foundContainer.State)
+	}
+	return nil
+}
+
+// ensureAgentUp restarts the exited and dead agent containers with a backoff.
+func (sup *SupervisorService) ensureAgentUp(knownContainer *Container, foundContainer *types.Container) error {
+	switch foundContainer.State {
+	case "running":
+		sup.forgetRestarts(knownContainer.Name)
 		return nil
+	case "created", "restarting", "paused":
+		return nil
+	case "exited", "dead":
+		if sup.isShed(knownContainer.Name) {
+			return nil
+		}
+		return sup.restartAgent(knownContainer, foundContainer.State, false)
 	default:
 		log.Panicf("unhandled container state: %s", foundContainer.State)
 	}
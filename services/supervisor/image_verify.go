@@ -0,0 +1,43 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/config"
+)
+
+// Agent image verification errors
+var (
+	errImageNotPinned      = errors.New("agent image is not pinned to a digest")
+	errImageDigestMismatch = errors.New("local agent image digest does not match the manifest")
+)
+
+// verifyAgentImage checks that the local image has the digest from the agent manifest so that
+// a tampered registry or local image cache cannot swap the agent code. The local agents are
+// allowed to use the image tags.
+func (sup *SupervisorService) verifyAgentImage(agent config.AgentConfig) error {
+	if sup.config.Config.Registry.SkipImageVerification {
+		return nil
+	}
+	_, digest := utils.SplitImageRef(agent.Image)
+	if len(digest) == 0 {
+		if agent.IsLocal {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", errImageNotPinned, agent.Image)
+	}
+
+	repoDigests, err := sup.agentImageClient.GetImageDigests(sup.ctx, agent.Image)
+	if err != nil {
+		return fmt.Errorf("failed to inspect the agent image: %v", err)
+	}
+	for _, repoDigest := range repoDigests {
+		if strings.HasSuffix(repoDigest, fmt.Sprintf("@sha256:%s", digest)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: expected %s, found %v", errImageDigestMismatch, digest, repoDigests)
+}
@@ -0,0 +1,92 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+const containerStatsTimeout = 5 * time.Second
+
+var (
+	containerCPUDesc = prometheus.NewDesc(
+		"forta_container_cpu_percent", "CPU usage of the node container",
+		[]string{"container", "agent"}, nil,
+	)
+	containerMemoryDesc = prometheus.NewDesc(
+		"forta_container_memory_bytes", "Memory usage of the node container",
+		[]string{"container", "agent"}, nil,
+	)
+	containerMemoryLimitDesc = prometheus.NewDesc(
+		"forta_container_memory_limit_bytes", "Memory limit of the node container",
+		[]string{"container", "agent"}, nil,
+	)
+	containersManagedDesc = prometheus.NewDesc(
+		"forta_containers_managed", "Number of containers managed by the supervisor by kind",
+		[]string{"kind"}, nil,
+	)
+)
+
+// MetricsCollector exports the resource usage of the containers managed by the supervisor.
+type MetricsCollector struct {
+	sup *SupervisorService
+}
+
+// NewMetricsCollector creates a new collector for the supervisor.
+func NewMetricsCollector(sup *SupervisorService) *MetricsCollector {
+	return &MetricsCollector{sup: sup}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (mc *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- containerCPUDesc
+	ch <- containerMemoryDesc
+	ch <- containerMemoryLimitDesc
+	ch <- containersManagedDesc
+}
+
+// Collect implements the prometheus.Collector interface. The stats of the containers are
+// requested concurrently since each request takes a while.
+func (mc *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	mc.sup.mu.RLock()
+	containers := make([]*Container, len(mc.sup.containers))
+	copy(containers, mc.sup.containers)
+	mc.sup.mu.RUnlock()
+
+	var agents, services float64
+	for _, container := range containers {
+		if container.IsAgent {
+			agents++
+		} else {
+			services++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(containersManagedDesc, prometheus.GaugeValue, agents, "agent")
+	ch <- prometheus.MustNewConstMetric(containersManagedDesc, prometheus.GaugeValue, services, "service")
+
+	ctx, cancel := context.WithTimeout(mc.sup.ctx, containerStatsTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container *Container) {
+			defer wg.Done()
+			stats, err := mc.sup.client.GetContainerStats(ctx, container.ID)
+			if err != nil {
+				log.WithError(err).WithField("container", container.Name).Debug("failed to get container stats")
+				return
+			}
+			var agentID string
+			if container.AgentConfig != nil {
+				agentID = container.AgentConfig.ID
+			}
+			ch <- prometheus.MustNewConstMetric(containerCPUDesc, prometheus.GaugeValue, stats.CPUPercent, container.Name, agentID)
+			ch <- prometheus.MustNewConstMetric(containerMemoryDesc, prometheus.GaugeValue, float64(stats.MemoryBytes), container.Name, agentID)
+			ch <- prometheus.MustNewConstMetric(containerMemoryLimitDesc, prometheus.GaugeValue, float64(stats.MemoryLimitBytes), container.Name, agentID)
+		}(container)
+	}
+	wg.Wait()
+}
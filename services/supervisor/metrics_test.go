@@ -0,0 +1,47 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-node/clients"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	r := require.New(t)
+
+	dockerClient := mock_clients.NewMockDockerClient(gomock.NewController(t))
+	sup := &SupervisorService{
+		ctx:    context.Background(),
+		client: dockerClient,
+		containers: []*Container{
+			{DockerContainer: clients.DockerContainer{ID: "1", Name: config.DockerScannerContainerName}},
+			{DockerContainer: clients.DockerContainer{ID: "2", Name: "forta-agent-1"}, IsAgent: true, AgentConfig: &config.AgentConfig{ID: "0x1"}},
+			{DockerContainer: clients.DockerContainer{ID: "3", Name: "forta-agent-2"}, IsAgent: true, AgentConfig: &config.AgentConfig{ID: "0x2"}},
+		},
+	}
+	dockerClient.EXPECT().GetContainerStats(gomock.Any(), "1").Return(&clients.ContainerStats{CPUPercent: 10, MemoryBytes: 100, MemoryLimitBytes: 1000}, nil)
+	dockerClient.EXPECT().GetContainerStats(gomock.Any(), "2").Return(&clients.ContainerStats{CPUPercent: 20, MemoryBytes: 200, MemoryLimitBytes: 2000}, nil)
+	dockerClient.EXPECT().GetContainerStats(gomock.Any(), "3").Return(nil, errors.New("failed"))
+
+	registry := prometheus.NewPedanticRegistry()
+	r.NoError(registry.Register(NewMetricsCollector(sup)))
+	r.NoError(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP forta_container_cpu_percent CPU usage of the node container
+# TYPE forta_container_cpu_percent gauge
+forta_container_cpu_percent{agent="",container="forta-scanner"} 10
+forta_container_cpu_percent{agent="0x1",container="forta-agent-1"} 20
+# HELP forta_containers_managed Number of containers managed by the supervisor by kind
+# TYPE forta_containers_managed gauge
+forta_containers_managed{kind="agent"} 2
+forta_containers_managed{kind="service"} 1
+`), "forta_container_cpu_percent", "forta_containers_managed"))
+}
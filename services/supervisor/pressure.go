@@ -0,0 +1,235 @@
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+	log "github.com/sirupsen/logrus"
+)
+
+const hostPressureActionStop = "stop"
+
+// hostUsage contains the usage percentages of the host resources.
+type hostUsage struct {
+	Memory float64
+	Disk   float64
+	CPU    float64
+}
+
+// readHostUsage reads the host resource usage. The supervisor container sees the memory and the CPU
+// of the host and the Forta directory is on the host disk.
+func readHostUsage() (*hostUsage, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory usage: %v", err)
+	}
+	du, err := disk.Usage(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read disk usage: %v", err)
+	}
+	// measures since the previous call
+	cpuPercents, err := cpu.Percent(0, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cpu usage: %v", err)
+	}
+	usage := &hostUsage{
+		Memory: vm.UsedPercent,
+		Disk:   du.UsedPercent,
+	}
+	if len(cpuPercents) > 0 {
+		usage.CPU = cpuPercents[0]
+	}
+	return usage, nil
+}
+
+// exceeded returns the first resource which is above its watermark minus the margin and which
+// shedding the agents can relieve. Pausing the agents only relieves the CPU and stopping them also
+// frees the memory.
+func (usage *hostUsage) exceeded(cfg config.HostWatermarksConfig, margin float64) (string, bool) {
+	switch {
+	case cfg.Action == hostPressureActionStop && usage.Memory >= cfg.MemoryPercent-margin:
+		return "memory", true
+	case usage.CPU >= cfg.CPUPercent-margin:
+		return "cpu", true
+	}
+	return "", false
+}
+
+// diskExceeded tells if the disk usage is above its watermark. The disk is not relieved by
+// shedding the agents so this is only reported.
+func (usage *hostUsage) diskExceeded(cfg config.HostWatermarksConfig) bool {
+	return usage.Disk >= cfg.DiskPercent
+}
+
+func (sup *SupervisorService) protectHost() {
+	interval := time.Duration(sup.config.Config.HostWatermarks.CheckIntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-sup.ctx.Done():
+			ticker.Stop()
+			return
+
+		case <-ticker.C:
+			if err := sup.doProtectHost(); err != nil {
+				log.WithError(err).Warn("failed to check the host resources")
+			}
+		}
+	}
+}
+
+// doProtectHost sheds one more agent at every check while the host usage is above a watermark
+// and resumes one agent at every check after the usage falls enough below the watermarks.
+func (sup *SupervisorService) doProtectHost() error {
+	usage, err := sup.hostUsage()
+	if err != nil {
+		return err
+	}
+	sup.pressureMu.Lock()
+	sup.lastHostUsage = usage
+	sup.pressureMu.Unlock()
+
+	cfg := sup.config.Config.HostWatermarks
+	if usage.diskExceeded(cfg) {
+		log.WithField("disk", usage.Disk).Warnf("host disk usage is above %.0f%%", cfg.DiskPercent)
+	}
+	if resource, ok := usage.exceeded(cfg, 0); ok {
+		return sup.shedAgent(resource, usage)
+	}
+	if _, ok := usage.exceeded(cfg, cfg.ResumeMarginPercent); !ok {
+		return sup.resumeAgent()
+	}
+	return nil
+}
+
+// shedAgent pauses the lowest priority agent which is not shed yet.
+func (sup *SupervisorService) shedAgent(resource string, usage *hostUsage) error {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+	sup.pressureMu.Lock()
+	defer sup.pressureMu.Unlock()
+
+	var candidates []*Container
+	for _, container := range sup.containers {
+		if container.IsAgent && container.AgentConfig != nil && !sup.isShedUnsafe(container.Name) {
+			candidates = append(candidates, container)
+		}
+	}
+	logger := log.WithFields(log.Fields{
+		"resource": resource,
+		"memory":   usage.Memory,
+		"disk":     usage.Disk,
+		"cpu":      usage.CPU,
+	})
+	if len(candidates) == 0 {
+		logger.Warn("host is under pressure but there are no more agents to shed")
+		return nil
+	}
+	resourcesCfg := sup.config.Config.ResourcesConfig
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi := resourcesCfg.GetAgentPriority(candidates[i].AgentConfig.ID)
+		pj := resourcesCfg.GetAgentPriority(candidates[j].AgentConfig.ID)
+		if pi != pj {
+			return pi < pj
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	shed := candidates[0]
+
+	logger.WithField("agent", shed.Name).Warn("host is under pressure - shedding agent")
+	sup.msgClient.Publish(messaging.SubjectAgentsActionPause, messaging.AgentPayload{*shed.AgentConfig})
+	sup.shedAgents = append(sup.shedAgents, shed.Name)
+	if sup.config.Config.HostWatermarks.Action == hostPressureActionStop {
+		if err := sup.client.StopContainer(sup.ctx, shed.ID); err != nil {
+			return fmt.Errorf("failed to stop container '%s': %v", shed.Name, err)
+		}
+	}
+	return nil
+}
+
+// resumeAgent resumes the last shed agent.
+func (sup *SupervisorService) resumeAgent() error {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+	sup.pressureMu.Lock()
+	defer sup.pressureMu.Unlock()
+
+	if len(sup.shedAgents) == 0 {
+		return nil
+	}
+	name := sup.shedAgents[len(sup.shedAgents)-1]
+	container, ok := sup.getContainerUnsafe(name)
+	if !ok {
+		sup.shedAgents = sup.shedAgents[:len(sup.shedAgents)-1]
+		return nil
+	}
+
+	log.WithField("agent", name).Info("host pressure subsided - resuming agent")
+	if sup.config.Config.HostWatermarks.Action == hostPressureActionStop {
+		if _, err := sup.client.StartContainer(sup.ctx, container.Config); err != nil {
+			return fmt.Errorf("failed to start container '%s': %v", name, err)
+		}
+		if err := sup.limitAgentEgress(*container.AgentConfig, container.ID); err != nil {
+			log.WithError(err).WithField("agent", name).Error("failed to limit agent egress bandwidth")
+		}
+	}
+	sup.shedAgents = sup.shedAgents[:len(sup.shedAgents)-1]
+	sup.msgClient.Publish(messaging.SubjectAgentsActionResume, messaging.AgentPayload{*container.AgentConfig})
+	return nil
+}
+
+// isShed tells if the agent was shed because of the host pressure so that it is not restarted.
+func (sup *SupervisorService) isShed(containerName string) bool {
+	sup.pressureMu.Lock()
+	defer sup.pressureMu.Unlock()
+	return sup.isShedUnsafe(containerName)
+}
+
+// forgetShed forgets the shed agent after it is stopped.
+func (sup *SupervisorService) forgetShed(containerName string) {
+	sup.pressureMu.Lock()
+	defer sup.pressureMu.Unlock()
+	for i, name := range sup.shedAgents {
+		if name == containerName {
+			sup.shedAgents = append(sup.shedAgents[:i], sup.shedAgents[i+1:]...)
+			return
+		}
+	}
+}
+
+func (sup *SupervisorService) isShedUnsafe(containerName string) bool {
+	for _, name := range sup.shedAgents {
+		if name == containerName {
+			return true
+		}
+	}
+	return false
+}
+
+func (sup *SupervisorService) hostPressureReport() *health.Report {
+	sup.pressureMu.Lock()
+	defer sup.pressureMu.Unlock()
+
+	report := &health.Report{
+		Name:   "host.pressure",
+		Status: health.StatusOK,
+	}
+	if sup.lastHostUsage != nil {
+		report.Details = fmt.Sprintf(
+			"memory=%.1f%% disk=%.1f%% cpu=%.1f%% shed=%d",
+			sup.lastHostUsage.Memory, sup.lastHostUsage.Disk, sup.lastHostUsage.CPU, len(sup.shedAgents),
+		)
+	}
+	diskExceeded := sup.lastHostUsage != nil && sup.lastHostUsage.diskExceeded(sup.config.Config.HostWatermarks)
+	if len(sup.shedAgents) > 0 || diskExceeded {
+		report.Status = health.StatusLagging
+	}
+	return report
+}
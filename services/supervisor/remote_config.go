@@ -0,0 +1,141 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const remoteConfigMaxSize = 1 << 20
+
+var remoteConfigClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+func (sup *SupervisorService) syncRemoteConfig() {
+	interval := time.Duration(sup.config.Config.RemoteConfig.RefreshIntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-sup.ctx.Done():
+			ticker.Stop()
+			return
+
+		case <-ticker.C:
+			sup.refreshRemoteConfig()
+		}
+	}
+}
+
+func (sup *SupervisorService) refreshRemoteConfig() {
+	err := sup.doRefreshRemoteConfig()
+	sup.lastRemoteConfigSync.Set()
+	sup.lastRemoteConfigSyncError.Set(err)
+	if err != nil {
+		log.WithError(err).Warn("failed to refresh the remote config - keeping the last verified config")
+	}
+}
+
+// doRefreshRemoteConfig fetches and verifies the remote config and writes it to the Forta dir
+// if it is newer than the current one. The services reload the config after the file changes.
+func (sup *SupervisorService) doRefreshRemoteConfig() error {
+	remoteCfg := sup.config.Config.RemoteConfig
+	body, err := fetchRemoteConfig(sup.ctx, remoteCfg.URL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the remote config: %v", err)
+	}
+	sigFile, err := fetchRemoteConfig(sup.ctx, remoteCfg.GetSignatureURL())
+	if err != nil {
+		return fmt.Errorf("failed to fetch the remote config signature: %v", err)
+	}
+	sig, err := config.ParseRemoteConfigSignature(sigFile)
+	if err != nil {
+		return err
+	}
+	signer := common.HexToAddress(remoteCfg.SignerAddress).Hex()
+	if err := security.VerifySignature(config.RemoteConfigSignedBytes(sig.Timestamp, body), signer, sig.Signature); err != nil {
+		return fmt.Errorf("failed to verify the remote config signature: %v", err)
+	}
+	if err := config.CheckRemoteConfig(body); err != nil {
+		return err
+	}
+
+	// only the configs which are signed later than the current one are accepted
+	current, _ := ioutil.ReadFile(sup.remoteConfigPath)
+	if lastTimestamp := sup.lastRemoteConfigTimestamp(); sig.Timestamp <= lastTimestamp {
+		if sig.Timestamp == lastTimestamp && bytes.Equal(current, body) {
+			return nil
+		}
+		return fmt.Errorf("the remote config is not newer than the current one: signed at %d, current signed at %d", sig.Timestamp, lastTimestamp)
+	}
+
+	if !bytes.Equal(current, body) {
+		if err := writeFileAtomic(sup.remoteConfigPath, body); err != nil {
+			return fmt.Errorf("failed to write the remote config: %v", err)
+		}
+		log.WithField("url", remoteCfg.URL).Info("updated the remote config")
+	}
+	if err := writeFileAtomic(sup.remoteConfigSignaturePath(), sigFile); err != nil {
+		return fmt.Errorf("failed to write the remote config signature: %v", err)
+	}
+	return nil
+}
+
+// remoteConfigSignaturePath returns the path of the signature of the current remote config.
+func (sup *SupervisorService) remoteConfigSignaturePath() string {
+	return sup.remoteConfigPath + ".sig"
+}
+
+// lastRemoteConfigTimestamp returns the signing time of the current remote config.
+func (sup *SupervisorService) lastRemoteConfigTimestamp() int64 {
+	b, err := ioutil.ReadFile(sup.remoteConfigSignaturePath())
+	if err != nil {
+		return 0
+	}
+	sig, err := config.ParseRemoteConfigSignature(b)
+	if err != nil {
+		return 0
+	}
+	return sig.Timestamp
+}
+
+func writeFileAtomic(filename string, b []byte) error {
+	tmpPath := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filename)
+}
+
+func fetchRemoteConfig(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := remoteConfigClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, remoteConfigMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > remoteConfigMaxSize {
+		return nil, fmt.Errorf("exceeds %d bytes", remoteConfigMaxSize)
+	}
+	return b, nil
+}
@@ -0,0 +1,165 @@
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients/messaging"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	restartBackoffBase = time.Second * 5
+	restartBackoffMax  = time.Minute * 5
+
+	// an agent is in a crash loop if it is restarted this many times within the window
+	crashLoopRestarts = 5
+	crashLoopWindow   = time.Minute * 10
+)
+
+// restartTracker keeps the recent restarts of an agent container to back off exponentially.
+type restartTracker struct {
+	restarts    []time.Time
+	nextAttempt time.Time
+	crashLoop   bool
+}
+
+func (rt *restartTracker) due(now time.Time) bool {
+	return !now.Before(rt.nextAttempt)
+}
+
+// record adds a restart and tells if the agent has just entered a crash loop.
+func (rt *restartTracker) record(now time.Time) bool {
+	var recent []time.Time
+	for _, t := range rt.restarts {
+		if now.Sub(t) < crashLoopWindow {
+			recent = append(recent, t)
+		}
+	}
+	rt.restarts = append(recent, now)
+
+	backoff := restartBackoffBase << (len(rt.restarts) - 1)
+	if backoff > restartBackoffMax || backoff <= 0 {
+		backoff = restartBackoffMax
+	}
+	rt.nextAttempt = now.Add(backoff)
+
+	wasCrashLoop := rt.crashLoop
+	rt.crashLoop = len(rt.restarts) >= crashLoopRestarts
+	return rt.crashLoop && !wasCrashLoop
+}
+
+// stable tells if the agent has been running without restarts for long enough.
+func (rt *restartTracker) stable(now time.Time) bool {
+	return len(rt.restarts) == 0 || now.Sub(rt.restarts[len(rt.restarts)-1]) >= crashLoopWindow
+}
+
+func (sup *SupervisorService) getRestartTrackerUnsafe(containerName string) *restartTracker {
+	if sup.restarts == nil {
+		sup.restarts = make(map[string]*restartTracker)
+	}
+	tracker, ok := sup.restarts[containerName]
+	if !ok {
+		tracker = &restartTracker{}
+		sup.restarts[containerName] = tracker
+	}
+	return tracker
+}
+
+// forgetRestarts clears the restart history of an agent which has been stable for a while.
+func (sup *SupervisorService) forgetRestarts(containerName string) {
+	sup.restartsMu.Lock()
+	defer sup.restartsMu.Unlock()
+	tracker, ok := sup.restarts[containerName]
+	if ok && tracker.stable(time.Now()) {
+		if tracker.crashLoop {
+			log.WithField("container", containerName).Info("agent recovered from the crash loop")
+		}
+		delete(sup.restarts, containerName)
+	}
+}
+
+// restartAgent restarts the agent container unless it is backing off from the previous restarts.
+// The container is stopped first if it is still running.
+func (sup *SupervisorService) restartAgent(container *Container, reason string, stop bool) error {
+	sup.restartsMu.Lock()
+	defer sup.restartsMu.Unlock()
+	tracker := sup.getRestartTrackerUnsafe(container.Name)
+
+	now := time.Now()
+	logger := log.WithFields(log.Fields{
+		"container": container.Name,
+		"reason":    reason,
+	})
+	if !tracker.due(now) {
+		logger.WithField("nextAttempt", tracker.nextAttempt).Debug("backing off from restarting the agent")
+		return nil
+	}
+
+	logger.Warn("restarting the agent container")
+	if stop {
+		if err := sup.client.StopContainer(sup.ctx, container.ID); err != nil {
+			return fmt.Errorf("failed to stop container '%s': %v", container.Name, err)
+		}
+	}
+	enteredCrashLoop := tracker.record(now)
+	if _, err := sup.client.StartContainer(sup.ctx, container.Config); err != nil {
+		return fmt.Errorf("failed to start container '%s': %v", container.Name, err)
+	}
+	if err := sup.limitAgentEgress(*container.AgentConfig, container.ID); err != nil {
+		logger.WithError(err).Error("failed to limit agent egress bandwidth")
+	}
+
+	payload := messaging.AgentPayload{*container.AgentConfig}
+	sup.msgClient.Publish(messaging.SubjectAgentsStatusRestarted, payload)
+	if enteredCrashLoop {
+		logger.WithField("restarts", len(tracker.restarts)).Error("agent is in a crash loop")
+		sup.msgClient.Publish(messaging.SubjectAgentsStatusCrashLoop, payload)
+	}
+	return nil
+}
+
+// handleAgentUnhealthy restarts the agents which the scanner found unresponsive.
+func (sup *SupervisorService) handleAgentUnhealthy(payload messaging.AgentPayload) error {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+
+	for _, agentCfg := range payload {
+		container, ok := sup.getContainerUnsafe(agentCfg.ContainerName())
+		if !ok || !container.IsAgent {
+			log.Warnf("container for agent '%s' was not found - skipping restart", agentCfg.ContainerName())
+			continue
+		}
+		if sup.isShed(container.Name) {
+			continue
+		}
+		if err := sup.restartAgent(container, "unhealthy", true); err != nil {
+			log.WithError(err).Error("failed to restart the unhealthy agent")
+		}
+	}
+	return nil
+}
+
+func (sup *SupervisorService) crashLoopReport() *health.Report {
+	report := &health.Report{
+		Name:   "agents.crash-loop",
+		Status: health.StatusOK,
+	}
+	sup.restartsMu.Lock()
+	defer sup.restartsMu.Unlock()
+	var names []string
+	for name, tracker := range sup.restarts {
+		if tracker.crashLoop {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		report.Status = health.StatusLagging
+		report.Details = strings.Join(names, ",")
+	}
+	return report
+}
@@ -0,0 +1,55 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+)
+
+const seccompUnconfined = "unconfined"
+
+// loadSeccompProfile reads the custom seccomp profile of the agents from the forta dir.
+func (sup *SupervisorService) loadSeccompProfile() error {
+	profile := sup.config.Config.AgentSecurity.SeccompProfile
+	if len(profile) == 0 || profile == seccompUnconfined {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path.Join(config.DefaultContainerFortaDirPath, profile))
+	if err != nil {
+		return fmt.Errorf("failed to read the agent seccomp profile: %v", err)
+	}
+	// the runtime expects the profile inline and on a single line
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, b); err != nil {
+		return fmt.Errorf("invalid agent seccomp profile: %v", err)
+	}
+	sup.seccompProfile = compacted.String()
+	return nil
+}
+
+// applyAgentSecurity hardens the agent container config according to the node config.
+func (sup *SupervisorService) applyAgentSecurity(containerCfg *clients.DockerContainerConfig) {
+	securityCfg := sup.config.Config.AgentSecurity
+	if securityCfg.DisableHardening {
+		return
+	}
+
+	containerCfg.ReadOnlyRootFS = !securityCfg.WritableRootFS
+
+	containerCfg.SecurityOpts = []string{"no-new-privileges"}
+	switch {
+	case securityCfg.SeccompProfile == seccompUnconfined:
+		containerCfg.SecurityOpts = append(containerCfg.SecurityOpts, "seccomp=unconfined")
+	case len(sup.seccompProfile) > 0:
+		containerCfg.SecurityOpts = append(containerCfg.SecurityOpts, fmt.Sprintf("seccomp=%s", sup.seccompProfile))
+	}
+
+	containerCfg.CapDrop = []string{"ALL"}
+	containerCfg.CapAdd = securityCfg.AddCapabilities
+	containerCfg.UsernsMode = securityCfg.UserNamespace
+}
@@ -2,6 +2,7 @@ package supervisor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -12,23 +13,25 @@ import (
 	"github.com/forta-network/forta-core-go/manifest"
 	"github.com/forta-network/forta-core-go/release"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ipfs/go-cid"
+	"github.com/nats-io/nats-server/v2/server"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/forta-network/forta-core-go/clients/agentlogs"
 	"github.com/forta-network/forta-core-go/clients/health"
-	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/apisecurity"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/healthutils"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/signer"
 )
 
 const (
 	// SupervisorStrategyVersion is for versioning the critical changes in supervisor's management strategy.
 	// It's effective in deciding if an agent container should be restarted or not.
-	SupervisorStrategyVersion = "1"
+	SupervisorStrategyVersion = "3"
 )
 
 // SupervisorService manages the scanner node's service and agent containers.
@@ -47,28 +50,57 @@ type SupervisorService struct {
 	maxLogSize  string
 	maxLogFiles int
 
+	agentMaxLogSize  string
+	agentMaxLogFiles int
+
+	gpuRuntime           *bool // nil until checked
+	diskQuotaUnsupported bool
+	seccompProfile       string // custom agent seccomp profile
+	nodeImage            string // for the helper containers
+
+	unusedImages map[string]time.Time // agent image ID -> first time seen unused
+
 	scannerContainer *clients.DockerContainer
 	jsonRpcContainer *clients.DockerContainer
+	natsServer       *server.Server // if the message bus is embedded
 	containers       []*Container
 	mu               sync.RWMutex
 
+	restarts   map[string]*restartTracker
+	restartsMu sync.Mutex
+
+	serviceWatches map[string]*serviceWatch
+	watchdogMu     sync.Mutex
+	livenessProbe  func(containerName string) error
+	restartNode    func()
+
+	shedAgents    []string // the agents which were shed because of the host pressure, in order
+	lastHostUsage *hostUsage
+	pressureMu    sync.Mutex
+	hostUsage     func() (*hostUsage, error)
+
 	lastRun                   health.TimeTracker
 	lastStop                  health.TimeTracker
 	lastTelemetryRequest      health.TimeTracker
 	lastTelemetryRequestError health.ErrorTracker
 	lastAgentLogsRequest      health.TimeTracker
 	lastAgentLogsRequestError health.ErrorTracker
+	lastRemoteConfigSync      health.TimeTracker
+	lastRemoteConfigSyncError health.ErrorTracker
 
-	healthClient health.HealthClient
+	remoteConfigPath string
+
+	healthClient *healthutils.Client
 
 	agentLogsClient agentlogs.Client
 	prevAgentLogs   agentlogs.Agents
 }
 
 type SupervisorServiceConfig struct {
-	Config     config.Config
-	Passphrase string
-	Key        *keystore.Key
+	Config      config.Config
+	Passphrase  string
+	Signer      signer.Signer
+	APISecurity *apisecurity.Security
 }
 
 // Container extends the default container data.
@@ -79,11 +111,25 @@ type Container struct {
 }
 
 func (sup *SupervisorService) Start() error {
+	if sup.config.Config.RemoteConfig.Enabled() {
+		// start the services with the latest remote config
+		sup.refreshRemoteConfig()
+	}
 	if err := sup.start(); err != nil {
 		return err
 	}
 
 	go sup.healthCheck()
+	go sup.watchdog()
+	if !sup.config.Config.AgentCleanup.Disable {
+		go sup.cleanupAgents()
+	}
+	if sup.config.Config.HostWatermarks.Enable {
+		go sup.protectHost()
+	}
+	if sup.config.Config.RemoteConfig.Enabled() {
+		go sup.syncRemoteConfig()
+	}
 
 	return nil
 }
@@ -115,6 +161,11 @@ func (sup *SupervisorService) start() error {
 	if len(hostFortaDir) == 0 {
 		return fmt.Errorf("supervisor needs to know $%s to mount to the other containers it runs", config.EnvHostFortaDir)
 	}
+	// older runners do not set this and only support docker
+	hostRuntimeSocket := os.Getenv(config.EnvHostRuntimeSocket)
+	if len(hostRuntimeSocket) == 0 {
+		hostRuntimeSocket = clients.ContainerRuntimeSocketPath
+	}
 	releaseInfo := release.ReleaseInfoFromString(os.Getenv(config.EnvReleaseInfo))
 	releaseInfo, err = sup.getFullReleaseInfo(releaseInfo)
 	if err != nil {
@@ -126,6 +177,11 @@ func (sup *SupervisorService) start() error {
 
 	sup.maxLogSize = sup.config.Config.Log.MaxLogSize
 	sup.maxLogFiles = sup.config.Config.Log.MaxLogFiles
+	sup.agentMaxLogSize = sup.config.Config.Log.AgentMaxLogSize
+	sup.agentMaxLogFiles = sup.config.Config.Log.AgentMaxLogFiles
+	if err := sup.loadSeccompProfile(); err != nil {
+		return err
+	}
 
 	if err := sup.removeOldContainers(); err != nil {
 		return err
@@ -140,6 +196,7 @@ func (sup *SupervisorService) start() error {
 		return fmt.Errorf("failed to get the supervisor container: %v", err)
 	}
 	commonNodeImage := supervisorContainer.Image
+	sup.nodeImage = commonNodeImage
 
 	nodeNetworkID, err := sup.client.CreatePublicNetwork(sup.ctx, config.DockerNetworkName)
 	if err != nil {
@@ -178,29 +235,13 @@ func (sup *SupervisorService) start() error {
 	sup.addContainerUnsafe(ipfsContainer)
 
 	// start nats, wait for it and connect from the supervisor
-	natsContainer, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
-		Name:  config.DockerNatsContainerName,
-		Image: "nats:2.3.2",
-		Ports: map[string]string{
-			"4222": "4222",
-			"6222": "6222",
-			"8222": "8222",
-		},
-		NetworkID:   internalNetworkID,
-		MaxLogFiles: sup.maxLogFiles,
-		MaxLogSize:  sup.maxLogSize,
-	})
+	natsAddr, err := sup.startMessageBus(internalNetworkID)
 	if err != nil {
 		return err
 	}
-	sup.addContainerUnsafe(natsContainer)
-
-	if err := sup.client.WaitContainerStart(sup.ctx, natsContainer.ID); err != nil {
-		return fmt.Errorf("failed while waiting for nats to start: %v", err)
-	}
 	// in tests, this is already set to a mock client
 	if sup.msgClient == nil {
-		sup.msgClient = messaging.NewClient("supervisor", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+		sup.msgClient = messaging.NewClient("supervisor", natsAddr)
 	}
 	sup.registerMessageHandlers()
 
@@ -209,12 +250,16 @@ func (sup *SupervisorService) start() error {
 		Image: commonNodeImage,
 		Cmd:   []string{config.DefaultFortaNodeBinaryPath, "json-rpc"},
 		Volumes: map[string]string{
-			// give access to host docker
-			"/var/run/docker.sock": "/var/run/docker.sock",
-			hostFortaDir:           config.DefaultContainerFortaDirPath,
+			// give access to the host container runtime
+			hostRuntimeSocket: clients.ContainerRuntimeSocketPath,
+			hostFortaDir:      config.DefaultContainerFortaDirPath,
 		},
 		Ports: map[string]string{
-			"": config.DefaultHealthPort, // random host port
+			"":           config.DefaultHealthPort, // random host port
+			"127.0.0.1:": config.DefaultAdminPort,  // random localhost port
+		},
+		Files: map[string][]byte{
+			config.DefaultSecretsFileName: sup.config.Config.Secrets.Bytes(),
 		},
 		DialHost:    true,
 		NetworkID:   nodeNetworkID,
@@ -226,6 +271,19 @@ func (sup *SupervisorService) start() error {
 	}
 	sup.addContainerUnsafe(sup.jsonRpcContainer)
 
+	scannerPorts := map[string]string{
+		"":           config.DefaultHealthPort, // random host port
+		"127.0.0.1:": config.DefaultAdminPort,  // random localhost port
+	}
+	if graphqlCfg := sup.config.Config.Publish.LocalStore.GraphQL; graphqlCfg.Enable {
+		scannerPorts[fmt.Sprintf("127.0.0.1:%d", graphqlCfg.Port)] = config.DefaultGraphQLPort
+	}
+	scannerVolumes := map[string]string{
+		hostFortaDir: config.DefaultContainerFortaDirPath,
+	}
+	if signerCfg := sup.config.Config.Signer; signerCfg.Type == signer.TypePKCS11 {
+		scannerVolumes[signerCfg.PKCS11.Module] = signerCfg.PKCS11.Module
+	}
 	sup.scannerContainer, err = sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
 		Name:  config.DockerScannerContainerName,
 		Image: commonNodeImage,
@@ -233,14 +291,11 @@ func (sup *SupervisorService) start() error {
 		Env: map[string]string{
 			config.EnvReleaseInfo: releaseInfo.String(),
 		},
-		Volumes: map[string]string{
-			hostFortaDir: config.DefaultContainerFortaDirPath,
-		},
-		Ports: map[string]string{
-			"": config.DefaultHealthPort, // random host port
-		},
+		Volumes: scannerVolumes,
+		Ports:   scannerPorts,
 		Files: map[string][]byte{
-			"passphrase": []byte(sup.config.Passphrase),
+			"passphrase":                  []byte(sup.config.Passphrase),
+			config.DefaultSecretsFileName: sup.config.Config.Secrets.Bytes(),
 		},
 		DialHost:    true,
 		NetworkID:   nodeNetworkID,
@@ -264,6 +319,46 @@ func (sup *SupervisorService) start() error {
 	return nil
 }
 
+// startMessageBus starts the NATS server either in a container or inside the supervisor and
+// returns the address which the supervisor should connect to.
+func (sup *SupervisorService) startMessageBus(networkID string) (string, error) {
+	if sup.config.Config.Messaging.IsLocal() {
+		return "", errors.New("the local message bus cannot connect the node containers - use nats or embedded")
+	}
+	if sup.config.Config.Messaging.IsEmbedded() {
+		natsServer, err := messaging.StartEmbeddedServer()
+		if err != nil {
+			return "", err
+		}
+		sup.natsServer = natsServer
+		return fmt.Sprintf("127.0.0.1:%s", config.DefaultNatsPort), nil
+	}
+
+	natsContainer, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
+		Name:  config.DockerNatsContainerName,
+		Image: "nats:2.3.2",
+		// enable jetstream for the durable subjects
+		Cmd: []string{"--config", "nats-server.conf", "-js"},
+		Ports: map[string]string{
+			"4222": "4222",
+			"6222": "6222",
+			"8222": "8222",
+		},
+		NetworkID:   networkID,
+		MaxLogFiles: sup.maxLogFiles,
+		MaxLogSize:  sup.maxLogSize,
+	})
+	if err != nil {
+		return "", err
+	}
+	sup.addContainerUnsafe(natsContainer)
+
+	if err := sup.client.WaitContainerStart(sup.ctx, natsContainer.ID); err != nil {
+		return "", fmt.Errorf("failed while waiting for nats to start: %v", err)
+	}
+	return messaging.ServerAddress(sup.config.Config.Messaging), nil
+}
+
 func (sup *SupervisorService) attachToNetwork(containerName, nodeNetworkID string) error {
 	container, err := sup.client.GetContainerByName(sup.ctx, containerName)
 	if err != nil {
@@ -276,19 +371,20 @@ func (sup *SupervisorService) attachToNetwork(containerName, nodeNetworkID strin
 }
 
 func (sup *SupervisorService) ensureNodeImages() error {
-	for _, image := range []struct {
+	type nodeImage struct {
 		Name string
 		Ref  string
-	}{
-		{
-			Name: "nats",
-			Ref:  "nats:2.3.2",
-		},
+	}
+	images := []nodeImage{
 		{
 			Name: "ipfs/go-ipfs",
 			Ref:  "ipfs/go-ipfs:v0.12.2",
 		},
-	} {
+	}
+	if !sup.config.Config.Messaging.IsEmbedded() {
+		images = append([]nodeImage{{Name: "nats", Ref: "nats:2.3.2"}}, images...)
+	}
+	for _, image := range images {
 		if err := sup.client.EnsureLocalImage(sup.ctx, image.Name, image.Ref); err != nil {
 			return err
 		}
@@ -393,14 +489,14 @@ func (sup *SupervisorService) syncTelemetryData() {
 }
 
 func (sup *SupervisorService) doSyncTelemetryData() error {
-	scannerJwt, err := security.CreateScannerJWT(sup.config.Key, map[string]interface{}{
+	scannerJwt, err := signer.CreateScannerJWT(sup.config.Signer, map[string]interface{}{
 		"access": "telemetry",
 	})
 	if err != nil {
 		return err
 	}
 	return sup.healthClient.SendReports(
-		fmt.Sprintf("http://host.docker.internal:%s/health", config.DefaultHealthPort),
+		sup.healthClient.URL("host.docker.internal", config.DefaultHealthPort),
 		sup.config.Config.TelemetryConfig.URL,
 		scannerJwt,
 	)
@@ -453,6 +549,9 @@ func (sup *SupervisorService) Stop() error {
 			logger.Info("requested to stop container")
 		}
 	}
+	if sup.natsServer != nil {
+		sup.natsServer.Shutdown()
+	}
 	return nil
 }
 
@@ -460,6 +559,16 @@ func (sup *SupervisorService) Name() string {
 	return "supervisor"
 }
 
+// CheckReady returns an error if some of the service containers are not running.
+func (sup *SupervisorService) CheckReady() error {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+	if missing := config.DockerSupervisorManagedContainers - len(sup.containers); missing > 0 {
+		return fmt.Errorf("missing %d containers", missing)
+	}
+	return nil
+}
+
 // Health implements the health.Reporter interface.
 func (sup *SupervisorService) Health() health.Reports {
 	sup.mu.RLock()
@@ -470,7 +579,7 @@ func (sup *SupervisorService) Health() health.Reports {
 		containersStatus = health.StatusFailing
 	}
 
-	return health.Reports{
+	return append(health.Reports{
 		&health.Report{
 			Name:    "containers.managed",
 			Status:  containersStatus,
@@ -490,7 +599,11 @@ func (sup *SupervisorService) Health() health.Reports {
 		sup.lastTelemetryRequestError.GetReport("event.telemetry-sync.error"),
 		sup.lastAgentLogsRequest.GetReport("event.agent-logs-sync.time"),
 		sup.lastAgentLogsRequestError.GetReport("event.agent-logs-sync.error"),
-	}
+		sup.lastRemoteConfigSync.GetReport("event.remote-config-sync.time"),
+		sup.lastRemoteConfigSyncError.GetReport("event.remote-config-sync.error"),
+		sup.crashLoopReport(),
+		sup.hostPressureReport(),
+	}, sup.watchdogReports()...)
 }
 
 func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*SupervisorService, error) {
@@ -498,6 +611,7 @@ func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*Su
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the docker client: %v", err)
 	}
+	dockerClient.SetImageMirrors(cfg.Config.Registry.Mirrors)
 	globalClient, err := clients.NewDockerClient("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the global docker client: %v", err)
@@ -509,20 +623,21 @@ func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*Su
 	}
 
 	// agent image client is helpful for loading private mode agents from a restricted container registry
-	var agentImageClient clients.DockerClient
+	var (
+		username string
+		password string
+	)
 	if cfg.Config.PrivateModeConfig.Enable && cfg.Config.PrivateModeConfig.ContainerRegistry != nil {
-		agentImageClient, err = clients.NewAuthDockerClient(
-			"",
-			cfg.Config.PrivateModeConfig.ContainerRegistry.Username,
-			cfg.Config.PrivateModeConfig.ContainerRegistry.Password,
-		)
-	} else {
-		agentImageClient, err = clients.NewDockerClient("")
+		username = cfg.Config.PrivateModeConfig.ContainerRegistry.Username
+		password = cfg.Config.PrivateModeConfig.ContainerRegistry.Password
 	}
+	agentImageClient, err := clients.NewAuthDockerClient("", username, password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the private docker client: %v", err)
 	}
+	agentImageClient.SetImageMirrors(cfg.Config.Registry.Mirrors)
 
+	healthClient := healthutils.NewClient(cfg.APISecurity)
 	return &SupervisorService{
 		ctx:              ctx,
 		client:           dockerClient,
@@ -530,7 +645,13 @@ func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*Su
 		agentImageClient: agentImageClient,
 		releaseClient:    releaseClient,
 		config:           cfg,
-		healthClient:     health.NewClient(),
+		healthClient:     healthClient,
 		agentLogsClient:  agentlogs.NewClient(cfg.Config.AgentLogsConfig.URL),
+		livenessProbe: func(containerName string) error {
+			return probeLiveness(healthClient, containerName)
+		},
+		restartNode:      services.InterruptMainContext,
+		hostUsage:        readHostUsage,
+		remoteConfigPath: config.DefaultContainerRemoteConfigPath,
 	}, nil
 }
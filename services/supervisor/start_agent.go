@@ -1,6 +1,8 @@
 package supervisor
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 
@@ -15,58 +17,134 @@ var (
 	errAgentAlreadyRunning = errors.New("agent already running")
 )
 
-func (sup *SupervisorService) startAgent(agent config.AgentConfig) error {
+// startAgent starts the agent container and returns the token which the scanner should
+// present to the agent.
+func (sup *SupervisorService) startAgent(agent config.AgentConfig) (string, error) {
 	if err := sup.agentImageClient.EnsureLocalImage(sup.ctx, fmt.Sprintf("agent %s", agent.ID), agent.Image); err != nil {
-		return err
+		return "", err
 	}
 
 	sup.mu.Lock()
 	defer sup.mu.Unlock()
 
-	_, ok := sup.getContainerUnsafe(agent.ContainerName())
+	container, ok := sup.getContainerUnsafe(agent.ContainerName())
 	if ok {
-		return errAgentAlreadyRunning
+		if container.AgentConfig != nil {
+			return container.AgentConfig.Token, errAgentAlreadyRunning
+		}
+		return "", errAgentAlreadyRunning
+	}
+
+	if err := sup.verifyAgentImage(agent); err != nil {
+		return "", err
 	}
 
-	nwID, err := sup.client.CreatePublicNetwork(sup.ctx, agent.ContainerName())
+	gpus, err := sup.getAgentGPUs(agent)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig)
+	token, err := generateAgentToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate agent token: %v", err)
+	}
+	agent.Token = token
+
+	// the agents are isolated on internal networks unless they are allowed the internet access
+	var nwID string
+	if sup.config.Config.AgentNetwork.AllowsEgress(agent.ID) {
+		nwID, err = sup.client.CreatePublicNetwork(sup.ctx, agent.ContainerName())
+	} else {
+		nwID, err = sup.client.CreateInternalNetwork(sup.ctx, agent.ContainerName())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig, agent.ID)
 
-	agentContainer, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
+	containerCfg := clients.DockerContainerConfig{
 		Name:           agent.ContainerName(),
 		Image:          agent.Image,
 		NetworkID:      nwID,
 		LinkNetworkIDs: []string{},
 		Env: map[string]string{
-			config.EnvJsonRpcHost:   config.DockerJSONRPCProxyContainerName,
-			config.EnvJsonRpcPort:   "8545",
-			config.EnvAgentGrpcPort: agent.GrpcPort(),
+			config.EnvJsonRpcHost:    config.DockerJSONRPCProxyContainerName,
+			config.EnvJsonRpcPort:    "8545",
+			config.EnvAgentGrpcPort:  agent.GrpcPort(),
+			config.EnvAgentGrpcToken: token,
 		},
-		MaxLogFiles: sup.maxLogFiles,
-		MaxLogSize:  sup.maxLogSize,
+		MaxLogFiles: sup.agentMaxLogFiles,
+		MaxLogSize:  sup.agentMaxLogSize,
 		CPUQuota:    limits.CPUQuota,
+		CPUShares:   limits.CPUShares,
 		Memory:      limits.Memory,
+		GPUs:        gpus,
+		Tmpfs:       map[string]string{"/tmp": scratchOpts(limits.ScratchMiB)},
 		Labels: map[string]string{
 			clients.DockerLabelFortaSupervisorStrategyVersion: SupervisorStrategyVersion,
 		},
-	})
+	}
+	if !sup.diskQuotaUnsupported {
+		containerCfg.DiskQuotaMiB = limits.DiskMiB
+	}
+	sup.applyAgentNetwork(&containerCfg, agent.ID)
+	sup.applyAgentSecurity(&containerCfg)
+	agentContainer, err := sup.client.StartContainer(sup.ctx, containerCfg)
+	if err != nil && containerCfg.DiskQuotaMiB > 0 && clients.IsStorageOptUnsupportedErr(err) {
+		log.WithError(err).Warn("storage driver does not support agent disk quotas - running agents without")
+		sup.diskQuotaUnsupported = true
+		containerCfg.DiskQuotaMiB = 0
+		agentContainer, err = sup.client.StartContainer(sup.ctx, containerCfg)
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 	// Attach the scanner and the JSON-RPC proxy to the agent's network.
 	for _, containerID := range []string{sup.scannerContainer.ID, sup.jsonRpcContainer.ID} {
 		err := sup.client.AttachNetwork(sup.ctx, containerID, nwID)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
 	sup.addContainerUnsafe(agentContainer, &agent)
 
-	return nil
+	if err := sup.limitAgentEgress(agent, agentContainer.ID); err != nil {
+		log.WithError(err).WithField("agent", agent.ContainerName()).Error("failed to limit agent egress bandwidth")
+	}
+
+	return token, nil
+}
+
+// applyAgentNetwork sets the DNS, hosts and proxy settings of the agents which can access the internet.
+func (sup *SupervisorService) applyAgentNetwork(containerCfg *clients.DockerContainerConfig, agentID string) {
+	networkCfg := sup.config.Config.AgentNetwork
+	if !networkCfg.AllowsEgress(agentID) {
+		return
+	}
+	containerCfg.DNS = networkCfg.DNS
+	containerCfg.ExtraHosts = networkCfg.ExtraHosts
+	for k, v := range networkCfg.ProxyEnv() {
+		containerCfg.Env[k] = v
+	}
+}
+
+// scratchOpts returns the tmpfs mount options of the agent scratch space.
+func scratchOpts(sizeMiB int) string {
+	opts := "rw,nosuid,nodev"
+	if sizeMiB > 0 {
+		opts = fmt.Sprintf("%s,size=%dm", opts, sizeMiB)
+	}
+	return opts
+}
+
+func generateAgentToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 func (sup *SupervisorService) getContainerUnsafe(name string) (*Container, bool) {
@@ -98,7 +176,8 @@ func (sup *SupervisorService) handleAgentRun(payload messaging.AgentPayload) err
 	}).Infof("handle agent run")
 
 	for _, agent := range payload {
-		err := sup.startAgent(agent)
+		token, err := sup.startAgent(agent)
+		agent.Token = token
 		if err == errAgentAlreadyRunning {
 			log.Infof("agent container '%s' is already running - skipped", agent.ContainerName())
 			sup.msgClient.Publish(messaging.SubjectAgentsStatusRunning, messaging.AgentPayload{agent})
@@ -133,6 +212,7 @@ func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) er
 		}
 		log.Infof("successfully stopped the container: %v", agentCfg.ContainerName())
 		stopped[container.ID] = true
+		sup.forgetShed(container.Name)
 	}
 
 	// Remove the stopped agents from the list.
@@ -154,4 +234,5 @@ func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) er
 func (sup *SupervisorService) registerMessageHandlers() {
 	sup.msgClient.Subscribe(messaging.SubjectAgentsActionRun, messaging.AgentsHandler(sup.handleAgentRun))
 	sup.msgClient.Subscribe(messaging.SubjectAgentsActionStop, messaging.AgentsHandler(sup.handleAgentStop))
+	sup.msgClient.Subscribe(messaging.SubjectAgentsStatusUnhealthy, messaging.AgentsHandler(sup.handleAgentUnhealthy))
 }
@@ -1,12 +1,26 @@
 package supervisor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-core-go/security"
 
 	"github.com/docker/docker/api/types"
 
@@ -75,6 +89,32 @@ func (m configMatcher) String() string {
 	return fmt.Sprintf("%+v", (clients.DockerContainerConfig)(m))
 }
 
+// payloadMatcher matches the agent payloads which carry the generated agent tokens.
+type payloadMatcher messaging.AgentPayload
+
+// Matches implements the gomock.Matcher interface.
+func (m payloadMatcher) Matches(x interface{}) bool {
+	payload, ok := x.(messaging.AgentPayload)
+	if !ok || len(payload) != len(m) {
+		return false
+	}
+	for i, agentCfg := range payload {
+		if len(agentCfg.Token) == 0 {
+			return false
+		}
+		agentCfg.Token = m[i].Token
+		if !reflect.DeepEqual(agentCfg, m[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements the gomock.Matcher interface.
+func (m payloadMatcher) String() string {
+	return fmt.Sprintf("%+v with tokens", (messaging.AgentPayload)(m))
+}
+
 // SetupTest sets up the test.
 func (s *Suite) SetupTest() {
 	s.r = require.New(s.T())
@@ -128,6 +168,7 @@ func (s *Suite) SetupTest() {
 	s.dockerClient.EXPECT().WaitContainerStart(service.ctx, gomock.Any()).Return(nil).AnyTimes()
 	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsActionRun, gomock.Any())
 	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsActionStop, gomock.Any())
+	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsStatusUnhealthy, gomock.Any())
 
 	s.r.NoError(service.start())
 }
@@ -185,18 +226,161 @@ func (s *Suite) TestAgentRun() {
 	// Creates the agent network, starts the agent container, attaches the scanner and the proxy to the
 	// agent network, publishes a "running" message.
 	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
-	s.dockerClient.EXPECT().CreatePublicNetwork(s.service.ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{testImageRef}, nil)
+	s.dockerClient.EXPECT().CreateInternalNetwork(s.service.ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
 	s.dockerClient.EXPECT().StartContainer(s.service.ctx, (configMatcher)(clients.DockerContainerConfig{
 		Name: agentConfig.ContainerName(),
 	})).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
 	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testScannerContainerID, testAgentNetworkID)
 	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testProxyContainerID, testAgentNetworkID)
 
-	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, (payloadMatcher)(agentPayload))
 
 	s.r.NoError(s.service.handleAgentRun(agentPayload))
 }
 
+// TestAgentRunWithEgress tests running an agent which is allowed the internet access.
+func (s *Suite) TestAgentRunWithEgress() {
+	s.service.config.Config.AgentNetwork.EgressAgents = []string{testAgentID}
+	agentConfig, _ := testAgentData()
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{testImageRef}, nil)
+	s.service.config.Config.AgentNetwork.DNS = []string{"10.0.0.2"}
+	s.service.config.Config.AgentNetwork.HTTPProxy = "http://proxy:3128"
+	s.dockerClient.EXPECT().CreatePublicNetwork(s.service.ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+			s.r.Equal([]string{"10.0.0.2"}, cfg.DNS)
+			s.r.Equal("http://proxy:3128", cfg.Env["HTTP_PROXY"])
+			return &clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil
+		},
+	)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testProxyContainerID, testAgentNetworkID)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.NoError(err)
+}
+
+// TestAgentRunWithGPUs tests passing the GPUs to an agent which requests them.
+func (s *Suite) TestAgentRunWithGPUs() {
+	s.service.config.Config.ResourcesConfig.EnableAgentGPUs = true
+	agentConfig, _ := testAgentData()
+	agentConfig.GPUs = 2
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{testImageRef}, nil)
+	s.dockerClient.EXPECT().HasRuntime(s.service.ctx, clients.RuntimeNvidia).Return(true, nil)
+	s.dockerClient.EXPECT().CreateInternalNetwork(s.service.ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+			s.r.Equal(2, cfg.GPUs)
+			return &clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil
+		},
+	)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testProxyContainerID, testAgentNetworkID)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.NoError(err)
+}
+
+// TestAgentRunGPUsNotAllowed tests refusing an agent which requests GPUs when they are not enabled.
+func (s *Suite) TestAgentRunGPUsNotAllowed() {
+	agentConfig, _ := testAgentData()
+	agentConfig.GPUs = 1
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{testImageRef}, nil)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.ErrorIs(err, errGPUsNotAllowed)
+}
+
+// TestAgentRunWithoutDiskQuota tests running the agents without the disk quotas when the storage
+// driver does not support them.
+func (s *Suite) TestAgentRunWithoutDiskQuota() {
+	agentConfig, _ := testAgentData()
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{testImageRef}, nil)
+	s.dockerClient.EXPECT().CreateInternalNetwork(s.service.ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	gomock.InOrder(
+		s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+				s.r.Equal(2000, cfg.DiskQuotaMiB)
+				s.r.Equal("rw,nosuid,nodev,size=256m", cfg.Tmpfs["/tmp"])
+				return nil, errors.New("--storage-opt is supported only for overlay over xfs with 'pquota' mount option")
+			},
+		),
+		s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+				s.r.Zero(cfg.DiskQuotaMiB)
+				return &clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil
+			},
+		),
+	)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(s.service.ctx, testProxyContainerID, testAgentNetworkID)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.NoError(err)
+	s.r.True(s.service.diskQuotaUnsupported)
+}
+
+// TestAgentSecurity tests hardening the agent containers.
+func (s *Suite) TestAgentSecurity() {
+	s.service.config.Config.AgentSecurity.AddCapabilities = []string{"NET_BIND_SERVICE"}
+
+	var containerCfg clients.DockerContainerConfig
+	s.service.applyAgentSecurity(&containerCfg)
+	s.r.True(containerCfg.ReadOnlyRootFS)
+	s.r.Equal([]string{"no-new-privileges"}, containerCfg.SecurityOpts)
+	s.r.Equal([]string{"ALL"}, containerCfg.CapDrop)
+	s.r.Equal([]string{"NET_BIND_SERVICE"}, containerCfg.CapAdd)
+
+	s.service.config.Config.AgentSecurity.SeccompProfile = "unconfined"
+	s.service.config.Config.AgentSecurity.WritableRootFS = true
+	containerCfg = clients.DockerContainerConfig{}
+	s.service.applyAgentSecurity(&containerCfg)
+	s.r.False(containerCfg.ReadOnlyRootFS)
+	s.r.Equal([]string{"no-new-privileges", "seccomp=unconfined"}, containerCfg.SecurityOpts)
+
+	s.service.config.Config.AgentSecurity.DisableHardening = true
+	containerCfg = clients.DockerContainerConfig{}
+	s.service.applyAgentSecurity(&containerCfg)
+	s.r.Equal(clients.DockerContainerConfig{}, containerCfg)
+}
+
+// TestCleanupAgents tests removing the orphan agent containers and the stale agent images.
+func (s *Suite) TestCleanupAgents() {
+	s.service.config.Config.Registry.ContainerRegistry = "disco.forta.network"
+	s.service.config.Config.AgentCleanup.ImageRetentionHours = 1
+	s.TestAgentRun()
+
+	agentConfig, _ := testAgentData()
+	s.dockerClient.EXPECT().GetContainers(s.service.ctx).Return([]types.Container{
+		{Names: []string{"/" + agentConfig.ContainerName()}, ID: testAgentContainerID, State: "exited"},
+		{Names: []string{"/forta-agent-orphan"}, ID: "orphan-id", State: "exited"},
+		{Names: []string{"/forta-agent-running"}, ID: "running-id", State: "running"},
+	}, nil)
+	s.dockerClient.EXPECT().RemoveContainer(s.service.ctx, "orphan-id").Return(nil)
+
+	images := []types.ImageSummary{
+		{ID: "in-use", RepoDigests: []string{"disco.forta.network/bafy1@sha256:1"}},
+		{ID: "stale", RepoTags: []string{"disco.forta.network/bafy2:latest"}, RepoDigests: []string{"disco.forta.network/bafy2@sha256:2"}},
+		{ID: "other", RepoTags: []string{"nats:2.3.2"}},
+	}
+	s.globalClient.EXPECT().GetContainers(s.service.ctx).Return([]types.Container{{ImageID: "in-use"}}, nil).Times(2)
+	s.agentImageClient.EXPECT().GetImages(s.service.ctx).Return(images, nil).Times(2)
+
+	// the first sight starts the retention period
+	now := time.Now()
+	s.r.NoError(s.service.doCleanupAgents())
+
+	s.agentImageClient.EXPECT().RemoveImage(s.service.ctx, "disco.forta.network/bafy2:latest").Return(nil)
+	s.agentImageClient.EXPECT().RemoveImage(s.service.ctx, "disco.forta.network/bafy2@sha256:2").Return(nil)
+	s.r.NoError(s.service.removeStaleAgentImages(now.Add(time.Hour * 2)))
+	s.r.Empty(s.service.unusedImages)
+}
+
 // TestAgentRunAgain tests running an agent twice.
 func (s *Suite) TestAgentRunAgain() {
 	s.TestAgentRun()
@@ -206,11 +390,32 @@ func (s *Suite) TestAgentRunAgain() {
 	// Expect it to only publish a message again to ensure the subscribers that
 	// the agent is running.
 	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
-	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, (payloadMatcher)(agentPayload))
 
 	s.r.NoError(s.service.handleAgentRun(agentPayload))
 }
 
+// TestAgentRunImageMismatch tests refusing an agent image with an unexpected digest.
+func (s *Suite) TestAgentRunImageMismatch() {
+	agentConfig, _ := testAgentData()
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.agentImageClient.EXPECT().GetImageDigests(s.service.ctx, agentConfig.Image).Return([]string{
+		"some.docker.registry.io/foobar@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+	}, nil)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.ErrorIs(err, errImageDigestMismatch)
+}
+
+// TestAgentRunImageNotPinned tests refusing a registry agent image without a digest.
+func (s *Suite) TestAgentRunImageNotPinned() {
+	agentConfig := config.AgentConfig{ID: testAgentID, Image: "some.docker.registry.io/foobar:latest"}
+	s.agentImageClient.EXPECT().EnsureLocalImage(s.service.ctx, "agent test-agent", agentConfig.Image).Return(nil)
+
+	_, err := s.service.startAgent(agentConfig)
+	s.r.ErrorIs(err, errImageNotPinned)
+}
+
 // TestAgentStop tests stopping an agent.
 func (s *Suite) TestAgentStopOne() {
 	s.TestAgentRun()
@@ -231,3 +436,271 @@ func (s *Suite) TestAgentStopNone() {
 
 	s.r.NoError(s.service.handleAgentStop(agentPayload))
 }
+
+// TestAgentRestartUnhealthy tests restarting an unhealthy agent with a backoff.
+func (s *Suite) TestAgentRestartUnhealthy() {
+	s.TestAgentRun()
+
+	_, agentPayload := testAgentData()
+	s.dockerClient.EXPECT().StopContainer(s.service.ctx, testAgentContainerID)
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).Return(&clients.DockerContainer{ID: testAgentContainerID}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRestarted, gomock.Any())
+
+	s.r.NoError(s.service.handleAgentUnhealthy(agentPayload))
+	// backing off from the second restart
+	s.r.NoError(s.service.handleAgentUnhealthy(agentPayload))
+}
+
+// TestWatchdog tests restarting a hanging node service and escalating to a node restart.
+func (s *Suite) TestWatchdog() {
+	for _, container := range s.service.containers {
+		if container.ID == testScannerContainerID {
+			container.Name = config.DockerScannerContainerName
+		}
+	}
+	probeErr := errors.New("timeout")
+	s.service.livenessProbe = func(containerName string) error {
+		return probeErr
+	}
+	var nodeRestarted bool
+	s.service.restartNode = func() {
+		nodeRestarted = true
+	}
+
+	for i := 0; i < livenessProbeFailures-1; i++ {
+		s.service.doWatchdog()
+	}
+	s.r.Equal(health.StatusLagging, s.service.watchdogReports()[0].Status)
+
+	// restarts after the consecutive probe failures
+	s.dockerClient.EXPECT().StopContainer(s.service.ctx, testScannerContainerID)
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).Return(&clients.DockerContainer{ID: testScannerContainerID}, nil)
+	s.service.doWatchdog()
+	reports := s.service.watchdogReports()
+	s.r.Len(reports, 1)
+	s.r.Equal("watchdog.forta-scanner", reports[0].Name)
+	s.r.Contains(reports[0].Details, "restarted (hang)")
+
+	// responding again after a failed probe
+	s.service.doWatchdog()
+	probeErr = nil
+	s.service.doWatchdog()
+	s.r.Contains(s.service.watchdogReports()[0].Details, "responding again")
+
+	// restarts the node when the service keeps failing
+	scanner, _ := s.service.getContainerUnsafe(config.DockerScannerContainerName)
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).Return(&clients.DockerContainer{ID: testScannerContainerID}, nil)
+	s.r.NoError(s.service.restartService(scanner, "exited", false))
+	s.r.False(nodeRestarted)
+	s.r.NoError(s.service.restartService(scanner, "exited", false))
+	s.r.True(nodeRestarted)
+	s.r.Contains(s.service.watchdogReports()[0].Details, "restarting the node")
+}
+
+func (s *Suite) TestHostPressure() {
+	lowPriority := config.AgentConfig{ID: "0xlow", Image: testImageRef}
+	highPriority := config.AgentConfig{ID: "0xhigh", Image: testImageRef}
+	s.service.addContainerUnsafe(&clients.DockerContainer{Name: "agent-high", ID: "high-id"}, &highPriority)
+	s.service.addContainerUnsafe(&clients.DockerContainer{Name: "agent-low", ID: "low-id"}, &lowPriority)
+	s.service.config.Config.ResourcesConfig.AgentOverrides = []config.AgentResourcesOverride{
+		{AgentID: highPriority.ID, Priority: 10},
+	}
+	s.service.config.Config.HostWatermarks = config.HostWatermarksConfig{
+		MemoryPercent:       90,
+		DiskPercent:         90,
+		CPUPercent:          95,
+		ResumeMarginPercent: 10,
+		Action:              hostPressureActionStop,
+	}
+	usage := &hostUsage{Memory: 95}
+	s.service.hostUsage = func() (*hostUsage, error) {
+		return usage, nil
+	}
+
+	// sheds the lowest priority agent first
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionPause, messaging.AgentPayload{lowPriority})
+	s.dockerClient.EXPECT().StopContainer(s.service.ctx, "low-id")
+	s.r.NoError(s.service.doProtectHost())
+	s.r.True(s.service.isShed("agent-low"))
+	s.r.Equal(health.StatusLagging, s.service.hostPressureReport().Status)
+
+	// does not restart the shed agent
+	s.r.NoError(s.service.ensureAgentUp(&Container{DockerContainer: clients.DockerContainer{Name: "agent-low"}}, &types.Container{State: "exited"}))
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionPause, messaging.AgentPayload{highPriority})
+	s.dockerClient.EXPECT().StopContainer(s.service.ctx, "high-id")
+	s.r.NoError(s.service.doProtectHost())
+
+	// keeps the agents shed while the usage is within the margin
+	usage.Memory = 85
+	s.r.NoError(s.service.doProtectHost())
+	s.r.Len(s.service.shedAgents, 2)
+
+	// resumes the last shed agent first
+	usage.Memory = 70
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).Return(&clients.DockerContainer{ID: "high-id"}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionResume, messaging.AgentPayload{highPriority})
+	s.r.NoError(s.service.doProtectHost())
+	s.r.False(s.service.isShed("agent-high"))
+	s.r.True(s.service.isShed("agent-low"))
+}
+
+func (s *Suite) TestHostPressureUnrelievable() {
+	agent := config.AgentConfig{ID: "0xagent", Image: testImageRef}
+	s.service.addContainerUnsafe(&clients.DockerContainer{Name: "agent", ID: "agent-id"}, &agent)
+	s.service.config.Config.HostWatermarks = config.HostWatermarksConfig{
+		MemoryPercent:       90,
+		DiskPercent:         90,
+		CPUPercent:          95,
+		ResumeMarginPercent: 10,
+		Action:              "pause",
+	}
+	usage := &hostUsage{Memory: 95, Disk: 95}
+	s.service.hostUsage = func() (*hostUsage, error) {
+		return usage, nil
+	}
+
+	// pausing the agents frees neither the memory nor the disk
+	s.r.NoError(s.service.doProtectHost())
+	s.r.False(s.service.isShed("agent"))
+	s.r.Equal(health.StatusLagging, s.service.hostPressureReport().Status)
+
+	// pausing the agents relieves the cpu
+	usage.CPU = 99
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionPause, messaging.AgentPayload{agent})
+	s.r.NoError(s.service.doProtectHost())
+	s.r.True(s.service.isShed("agent"))
+}
+
+func (s *Suite) TestLimitAgentEgress() {
+	agent := config.AgentConfig{ID: testAgentID, Image: testImageRef}
+
+	// no limits for the agents without internet access
+	s.service.config.Config.ResourcesConfig.AgentMaxEgressMbps = 2
+	s.r.NoError(s.service.limitAgentEgress(agent, testAgentContainerID))
+
+	s.service.config.Config.AgentNetwork.AllowEgress = true
+	s.service.nodeImage = testImageRef
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+			s.r.Equal(testImageRef, cfg.Image)
+			s.r.Equal("container:"+testAgentContainerID, cfg.NetworkID)
+			s.r.Equal([]string{"NET_ADMIN"}, cfg.CapAdd)
+			s.r.Contains(cfg.Cmd[2], "rate 2000kbit ceil 2000kbit")
+			return &clients.DockerContainer{Name: cfg.Name, ID: "helper-id"}, nil
+		},
+	)
+	s.dockerClient.EXPECT().WaitContainerExit(s.service.ctx, "helper-id")
+	s.dockerClient.EXPECT().GetContainerExitCode(s.service.ctx, "helper-id").Return(0, nil)
+	s.dockerClient.EXPECT().RemoveContainer(s.service.ctx, "helper-id")
+	s.r.NoError(s.service.limitAgentEgress(agent, testAgentContainerID))
+}
+
+func (s *Suite) TestLimitAgentEgressHelperFails() {
+	agent := config.AgentConfig{ID: testAgentID, Image: testImageRef}
+	s.service.config.Config.ResourcesConfig.AgentMaxEgressMbps = 2
+	s.service.config.Config.AgentNetwork.AllowEgress = true
+	s.service.nodeImage = testImageRef
+
+	s.dockerClient.EXPECT().StartContainer(s.service.ctx, gomock.Any()).Return(&clients.DockerContainer{Name: "helper", ID: "helper-id"}, nil)
+	s.dockerClient.EXPECT().WaitContainerExit(s.service.ctx, "helper-id")
+	s.dockerClient.EXPECT().GetContainerExitCode(s.service.ctx, "helper-id").Return(2, nil)
+	s.dockerClient.EXPECT().RemoveContainer(s.service.ctx, "helper-id")
+	err := s.service.limitAgentEgress(agent, testAgentContainerID)
+	s.r.Error(err)
+	s.r.Contains(err.Error(), "exit code 2")
+}
+
+func TestRestartTracker(t *testing.T) {
+	r := require.New(t)
+
+	var rt restartTracker
+	now := time.Now()
+	r.True(rt.due(now))
+
+	for i := 0; i < crashLoopRestarts-1; i++ {
+		r.False(rt.record(now))
+		now = rt.nextAttempt
+	}
+	r.Equal(restartBackoffBase<<(crashLoopRestarts-2), rt.nextAttempt.Sub(rt.restarts[len(rt.restarts)-1]))
+	r.True(rt.record(now))
+	r.True(rt.crashLoop)
+	r.False(rt.due(now))
+	r.False(rt.stable(now))
+	r.True(rt.stable(now.Add(crashLoopWindow)))
+}
+
+func TestRefreshRemoteConfig(t *testing.T) {
+	r := require.New(t)
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	var remoteCfg, sigFile []byte
+	sign := func(timestamp int64, b []byte) {
+		sig, err := security.SignBytes(key, config.RemoteConfigSignedBytes(timestamp, b))
+		r.NoError(err)
+		remoteCfg = b
+		sigFile, _ = json.Marshal(&config.RemoteConfigSignature{Timestamp: timestamp, Signature: sig.Signature})
+	}
+	sign(100, []byte("log:\n  level: debug\n"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/config.yml":
+			w.Write(remoteCfg)
+		case "/config.yml.sig":
+			w.Write(sigFile)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	sup := &SupervisorService{
+		ctx:              context.Background(),
+		remoteConfigPath: path.Join(t.TempDir(), config.DefaultRemoteConfigFileName),
+	}
+	sup.config.Config.RemoteConfig.URL = server.URL + "/config.yml"
+	sup.config.Config.RemoteConfig.SignerAddress = strings.ToLower(key.Address.Hex())
+	r.NoError(sup.doRefreshRemoteConfig())
+	b, err := ioutil.ReadFile(sup.remoteConfigPath)
+	r.NoError(err)
+	r.Equal(remoteCfg, b)
+	r.Equal(int64(100), sup.lastRemoteConfigTimestamp())
+	r.NoError(sup.doRefreshRemoteConfig(), "not changed")
+
+	// keeps the last verified config if the signature does not match
+	remoteCfg = []byte("log:\n  level: trace\n")
+	r.Error(sup.doRefreshRemoteConfig())
+	b, err = ioutil.ReadFile(sup.remoteConfigPath)
+	r.NoError(err)
+	r.Equal("log:\n  level: debug\n", string(b))
+
+	// the signature covers the timestamp
+	sign(100, []byte("log:\n  level: debug\n"))
+	sigFile = bytes.Replace(sigFile, []byte(`"timestamp":100`), []byte(`"timestamp":300`), 1)
+	r.Error(sup.doRefreshRemoteConfig())
+
+	sign(200, []byte("log:\n  level: warn\n"))
+	r.NoError(sup.doRefreshRemoteConfig())
+	b, err = ioutil.ReadFile(sup.remoteConfigPath)
+	r.NoError(err)
+	r.Equal("log:\n  level: warn\n", string(b))
+
+	// rejects the older configs which are replayed
+	sign(100, []byte("log:\n  level: debug\n"))
+	r.Error(sup.doRefreshRemoteConfig())
+	sign(200, []byte("log:\n  level: info\n"))
+	r.Error(sup.doRefreshRemoteConfig())
+	b, err = ioutil.ReadFile(sup.remoteConfigPath)
+	r.NoError(err)
+	r.Equal("log:\n  level: warn\n", string(b))
+
+	// rejects the configs which change the remote config settings
+	sign(300, []byte("remoteConfig:\n  url: https://example.com\n"))
+	r.Error(sup.doRefreshRemoteConfig())
+
+	sup.config.Config.RemoteConfig.SignatureURL = server.URL + "/missing.sig"
+	r.Error(sup.doRefreshRemoteConfig())
+}
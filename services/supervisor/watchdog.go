@@ -0,0 +1,187 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/healthutils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	livenessProbeInterval = time.Second * 30
+	livenessProbeTimeout  = time.Second * 10
+	// a service is restarted if it does not respond to this many probes in a row
+	livenessProbeFailures = 3
+
+	// the whole node is restarted if a service is restarted this many times within the window
+	serviceRestartsBeforeNodeRestart = 3
+	serviceRestartWindow             = time.Minute * 30
+)
+
+// probedServices are the node containers which serve the health API. The publisher runs
+// in the scanner container.
+var probedServices = map[string]bool{
+	config.DockerScannerContainerName:      true,
+	config.DockerJSONRPCProxyContainerName: true,
+}
+
+// serviceWatch keeps the liveness state of a node service container.
+type serviceWatch struct {
+	failedProbes   int
+	restarts       []time.Time
+	lastTransition string
+	transitionTime time.Time
+}
+
+func (sw *serviceWatch) transition(now time.Time, format string, args ...interface{}) {
+	sw.lastTransition = fmt.Sprintf(format, args...)
+	sw.transitionTime = now
+}
+
+// probeLiveness checks if the service responds to the health requests.
+func probeLiveness(healthClient *healthutils.Client, containerName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), livenessProbeTimeout)
+	defer cancel()
+	resp, err := healthClient.Get(ctx, healthClient.URL(containerName, config.DefaultHealthPort))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (sup *SupervisorService) watchdog() {
+	ticker := time.NewTicker(livenessProbeInterval)
+	for {
+		select {
+		case <-sup.ctx.Done():
+			ticker.Stop()
+			return
+
+		case <-ticker.C:
+			sup.doWatchdog()
+		}
+	}
+}
+
+// doWatchdog probes the node services and restarts the ones which hang.
+func (sup *SupervisorService) doWatchdog() {
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+
+	for _, container := range sup.containers {
+		if container.IsAgent || !probedServices[container.Name] {
+			continue
+		}
+		err := sup.livenessProbe(container.Name)
+
+		sup.watchdogMu.Lock()
+		watch := sup.getServiceWatchUnsafe(container.Name)
+		if err == nil {
+			if watch.failedProbes > 0 {
+				watch.transition(time.Now(), "responding again")
+			}
+			watch.failedProbes = 0
+			sup.watchdogMu.Unlock()
+			continue
+		}
+		watch.failedProbes++
+		watch.transition(time.Now(), "failed %d liveness probes: %v", watch.failedProbes, err)
+		shouldRestart := watch.failedProbes >= livenessProbeFailures
+		sup.watchdogMu.Unlock()
+
+		log.WithError(err).WithField("container", container.Name).Warn("liveness probe failed")
+		if shouldRestart {
+			if err := sup.restartService(container, "hang", true); err != nil {
+				log.WithError(err).Error("failed to restart the hanging service")
+			}
+		}
+	}
+}
+
+// restartService restarts a node service container and restarts the whole node if the service
+// keeps failing.
+func (sup *SupervisorService) restartService(container *Container, reason string, stop bool) error {
+	sup.watchdogMu.Lock()
+	defer sup.watchdogMu.Unlock()
+
+	now := time.Now()
+	watch := sup.getServiceWatchUnsafe(container.Name)
+	var recent []time.Time
+	for _, t := range watch.restarts {
+		if now.Sub(t) < serviceRestartWindow {
+			recent = append(recent, t)
+		}
+	}
+	watch.restarts = append(recent, now)
+	watch.failedProbes = 0
+
+	logger := log.WithFields(log.Fields{
+		"container": container.Name,
+		"reason":    reason,
+		"restarts":  len(watch.restarts),
+	})
+	if len(watch.restarts) >= serviceRestartsBeforeNodeRestart {
+		watch.transition(now, "restarting the node after %d restarts (%s)", len(watch.restarts), reason)
+		logger.Error("service keeps failing - restarting the node")
+		sup.restartNode()
+		return nil
+	}
+
+	watch.transition(now, "restarted (%s)", reason)
+	logger.Warn("restarting the service container")
+	if stop {
+		if err := sup.client.StopContainer(sup.ctx, container.ID); err != nil {
+			return fmt.Errorf("failed to stop container '%s': %v", container.Name, err)
+		}
+	}
+	if _, err := sup.client.StartContainer(sup.ctx, container.Config); err != nil {
+		return fmt.Errorf("failed to start container '%s': %v", container.Name, err)
+	}
+	return nil
+}
+
+func (sup *SupervisorService) getServiceWatchUnsafe(containerName string) *serviceWatch {
+	if sup.serviceWatches == nil {
+		sup.serviceWatches = make(map[string]*serviceWatch)
+	}
+	watch, ok := sup.serviceWatches[containerName]
+	if !ok {
+		watch = &serviceWatch{}
+		sup.serviceWatches[containerName] = watch
+	}
+	return watch
+}
+
+// watchdogReports reports the last watchdog transition of each node service.
+func (sup *SupervisorService) watchdogReports() health.Reports {
+	sup.watchdogMu.Lock()
+	defer sup.watchdogMu.Unlock()
+
+	now := time.Now()
+	var reports health.Reports
+	for name, watch := range sup.serviceWatches {
+		status := health.StatusOK
+		if watch.failedProbes > 0 || (len(watch.restarts) > 0 && now.Sub(watch.restarts[len(watch.restarts)-1]) < serviceRestartWindow) {
+			status = health.StatusLagging
+		}
+		var details string
+		if len(watch.lastTransition) > 0 {
+			details = fmt.Sprintf("%s at %s", watch.lastTransition, watch.transitionTime.Format(time.RFC3339))
+		}
+		reports = append(reports, &health.Report{
+			Name:    fmt.Sprintf("watchdog.%s", name),
+			Status:  status,
+			Details: details,
+		})
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
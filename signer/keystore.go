@@ -0,0 +1,40 @@
+package signer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KeystoreSigner signs with a key decrypted from the keystore.
+type KeystoreSigner struct {
+	key *keystore.Key
+}
+
+// NewKeystoreSigner creates a new keystore signer.
+func NewKeystoreSigner(key *keystore.Key) *KeystoreSigner {
+	return &KeystoreSigner{key: key}
+}
+
+// Address implements Signer interface.
+func (ks *KeystoreSigner) Address() common.Address {
+	return ks.key.Address
+}
+
+// Sign implements Signer interface.
+func (ks *KeystoreSigner) Sign(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), ks.key.PrivateKey)
+}
+
+// SignTx implements Signer interface.
+func (ks *KeystoreSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), ks.key.PrivateKey)
+}
+
+// Close implements Signer interface.
+func (ks *KeystoreSigner) Close() error {
+	return nil
+}
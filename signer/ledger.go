@@ -0,0 +1,66 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-node/config"
+)
+
+// LedgerSigner signs the transactions with an account of a Ledger device. The Ethereum app
+// of the Ledger does not sign arbitrary data so it can not sign the alert batches.
+type LedgerSigner struct {
+	wallet  accounts.Wallet
+	account accounts.Account
+}
+
+// NewLedgerSigner opens the first Ledger device and derives the account.
+func NewLedgerSigner(cfg config.LedgerConfig) (Signer, error) {
+	path, err := accounts.ParseDerivationPath(cfg.DerivationPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %v", err)
+	}
+	hub, err := usbwallet.NewLedgerHub()
+	if err != nil {
+		return nil, fmt.Errorf("failed to access the usb devices: %v", err)
+	}
+	wallets := hub.Wallets()
+	if len(wallets) == 0 {
+		return nil, errors.New("no ledger device found (is it connected and unlocked?)")
+	}
+	wallet := wallets[0]
+	if err := wallet.Open(""); err != nil {
+		return nil, fmt.Errorf("failed to open the ledger device (is the ethereum app open?): %v", err)
+	}
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("failed to derive the ledger account: %v", err)
+	}
+	return &LedgerSigner{wallet: wallet, account: account}, nil
+}
+
+// Address implements Signer interface.
+func (ls *LedgerSigner) Address() common.Address {
+	return ls.account.Address
+}
+
+// Sign implements Signer interface.
+func (ls *LedgerSigner) Sign(data []byte) ([]byte, error) {
+	return nil, ErrDataSigningUnsupported
+}
+
+// SignTx implements Signer interface. The transaction needs to be confirmed on the device.
+func (ls *LedgerSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return ls.wallet.SignTx(ls.account, tx, chainID)
+}
+
+// Close implements Signer interface.
+func (ls *LedgerSigner) Close() error {
+	return ls.wallet.Close()
+}
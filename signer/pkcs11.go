@@ -0,0 +1,169 @@
+//go:build cgo
+// +build cgo
+
+package signer
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-node/config"
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS11Signer signs with a secp256k1 key which never leaves the HSM.
+type PKCS11Signer struct {
+	ctx        *pkcs11.Ctx
+	session    pkcs11.SessionHandle
+	privateKey pkcs11.ObjectHandle
+	address    common.Address
+	mu         sync.Mutex
+}
+
+// NewPKCS11Signer loads the PKCS#11 module, logs in to the token and finds the key.
+func NewPKCS11Signer(cfg config.PKCS11Config) (Signer, error) {
+	if len(cfg.Module) == 0 || len(cfg.TokenLabel) == 0 || len(cfg.KeyLabel) == 0 {
+		return nil, errors.New("the pkcs11 signer needs the module, the token label and the key label")
+	}
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load the pkcs11 module %s", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize the pkcs11 module: %v", err)
+	}
+	ps := &PKCS11Signer{ctx: ctx}
+	if err := ps.open(cfg.TokenLabel, cfg.KeyLabel, cfg.UserPIN); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (ps *PKCS11Signer) open(tokenLabel, keyLabel, pin string) error {
+	slots, err := ps.ctx.GetSlotList(true)
+	if err != nil {
+		return fmt.Errorf("failed to list the pkcs11 slots: %v", err)
+	}
+	var (
+		slot  uint
+		found bool
+	)
+	for _, s := range slots {
+		info, err := ps.ctx.GetTokenInfo(s)
+		if err == nil && info.Label == tokenLabel {
+			slot, found = s, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("pkcs11 token not found: %s", tokenLabel)
+	}
+	ps.session, err = ps.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open a pkcs11 session: %v", err)
+	}
+	if err := ps.ctx.Login(ps.session, pkcs11.CKU_USER, pin); err != nil {
+		return fmt.Errorf("failed to log in to the pkcs11 token: %v", err)
+	}
+	ps.privateKey, err = ps.findKey(pkcs11.CKO_PRIVATE_KEY, keyLabel)
+	if err != nil {
+		return err
+	}
+	publicKey, err := ps.findKey(pkcs11.CKO_PUBLIC_KEY, keyLabel)
+	if err != nil {
+		return err
+	}
+	attrs, err := ps.ctx.GetAttributeValue(ps.session, publicKey, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil || len(attrs) == 0 {
+		return fmt.Errorf("failed to read the public key: %v", err)
+	}
+	// the point is usually wrapped in a DER octet string
+	point := attrs[0].Value
+	var unwrapped []byte
+	if _, err := asn1.Unmarshal(point, &unwrapped); err == nil {
+		point = unwrapped
+	}
+	pubKey, err := crypto.UnmarshalPubkey(point)
+	if err != nil {
+		return fmt.Errorf("the pkcs11 key is not a secp256k1 key: %v", err)
+	}
+	ps.address = crypto.PubkeyToAddress(*pubKey)
+	return nil
+}
+
+func (ps *PKCS11Signer) findKey(class uint, label string) (pkcs11.ObjectHandle, error) {
+	if err := ps.ctx.FindObjectsInit(ps.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to search the pkcs11 keys: %v", err)
+	}
+	objects, _, err := ps.ctx.FindObjects(ps.session, 1)
+	ps.ctx.FindObjectsFinal(ps.session)
+	if err != nil {
+		return 0, fmt.Errorf("failed to search the pkcs11 keys: %v", err)
+	}
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("pkcs11 key not found: %s", label)
+	}
+	return objects[0], nil
+}
+
+// Address implements Signer interface.
+func (ps *PKCS11Signer) Address() common.Address {
+	return ps.address
+}
+
+// Sign implements Signer interface.
+func (ps *PKCS11Signer) Sign(data []byte) ([]byte, error) {
+	return ps.signHash(crypto.Keccak256(data))
+}
+
+func (ps *PKCS11Signer) signHash(hash []byte) ([]byte, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}
+	if err := ps.ctx.SignInit(ps.session, mechanism, ps.privateKey); err != nil {
+		return nil, fmt.Errorf("failed to sign with the hsm: %v", err)
+	}
+	sig, err := ps.ctx.Sign(ps.session, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the hsm: %v", err)
+	}
+	return toRecoverableSignature(hash, sig, ps.address)
+}
+
+// SignTx implements Signer interface.
+func (ps *PKCS11Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.LatestSignerForChainID(chainID)
+	sig, err := ps.signHash(txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txSigner, sig)
+}
+
+// Close implements Signer interface.
+func (ps *PKCS11Signer) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.session != 0 {
+		ps.ctx.Logout(ps.session)
+		ps.ctx.CloseSession(ps.session)
+	}
+	ps.ctx.Finalize()
+	ps.ctx.Destroy()
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !cgo
+// +build !cgo
+
+package signer
+
+import (
+	"errors"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// NewPKCS11Signer fails because the PKCS#11 modules can only be loaded by the cgo builds.
+func NewPKCS11Signer(cfg config.PKCS11Config) (Signer, error) {
+	return nil, errors.New("the pkcs11 signer is not supported by this build (requires cgo)")
+}
@@ -0,0 +1,43 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// toRecoverableSignature converts the [R || S] signature which the HSMs return into the
+// [R || S || V] signature that Ethereum expects. The S value is normalized to the lower half
+// of the curve order and V is found by recovering the signer address.
+func toRecoverableSignature(hash, sig []byte, address common.Address) ([]byte, error) {
+	if len(sig) != 64 {
+		return nil, errors.New("unexpected signature length")
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(secp256k1N, s)
+	}
+	recoverable := make([]byte, 65)
+	r.FillBytes(recoverable[:32])
+	s.FillBytes(recoverable[32:64])
+	for v := byte(0); v < 2; v++ {
+		recoverable[64] = v
+		pubKey, err := crypto.SigToPub(hash, recoverable)
+		if err != nil {
+			continue
+		}
+		if recovered := crypto.PubkeyToAddress(*pubKey); bytes.Equal(recovered.Bytes(), address.Bytes()) {
+			return recoverable, nil
+		}
+	}
+	return nil, errors.New("signature does not belong to the signer key")
+}
@@ -0,0 +1,246 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+)
+
+const remoteSignerTimeout = time.Second * 10
+
+// Web3Signer signs with a key kept by a Web3Signer instance by using its eth1 API.
+type Web3Signer struct {
+	url        string
+	authToken  string
+	publicKey  string
+	address    common.Address
+	httpClient *http.Client
+}
+
+// NewWeb3Signer finds the scanner key among the keys of the Web3Signer.
+func NewWeb3Signer(cfg config.RemoteSignerConfig) (Signer, error) {
+	if len(cfg.URL) == 0 {
+		return nil, errors.New("the web3signer url is not set")
+	}
+	ws := &Web3Signer{
+		url:        strings.TrimRight(cfg.URL, "/"),
+		authToken:  cfg.AuthToken,
+		httpClient: &http.Client{Timeout: remoteSignerTimeout},
+	}
+	var publicKeys []string
+	if err := ws.do(http.MethodGet, "/api/v1/eth1/publicKeys", nil, &publicKeys); err != nil {
+		return nil, fmt.Errorf("failed to get the web3signer keys: %v", err)
+	}
+	for _, publicKey := range publicKeys {
+		address, err := publicKeyToAddress(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid web3signer key %s: %v", publicKey, err)
+		}
+		if len(cfg.Address) > 0 && !strings.EqualFold(address.Hex(), cfg.Address) {
+			continue
+		}
+		if len(cfg.Address) == 0 && len(publicKeys) > 1 {
+			return nil, errors.New("the web3signer has multiple keys - please set the scanner address in the config")
+		}
+		ws.publicKey, ws.address = publicKey, address
+		return ws, nil
+	}
+	return nil, fmt.Errorf("scanner key not found in the web3signer: %s", cfg.Address)
+}
+
+// publicKeyToAddress converts the uncompressed public key hex to the address.
+func publicKeyToAddress(publicKey string) (common.Address, error) {
+	b, err := hexutil.Decode(publicKey)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(b) == 64 {
+		b = append([]byte{4}, b...)
+	}
+	pubKey, err := crypto.UnmarshalPubkey(b)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+func (ws *Web3Signer) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(b)
+	}
+	req, err := http.NewRequest(method, ws.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(ws.authToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+ws.authToken)
+	}
+	resp, err := ws.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("web3signer responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if s, ok := out.(*string); ok {
+		*s = strings.TrimSpace(string(b))
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+// Address implements Signer interface.
+func (ws *Web3Signer) Address() common.Address {
+	return ws.address
+}
+
+// Sign implements Signer interface. The Web3Signer signs the Keccak-256 hash of the data.
+func (ws *Web3Signer) Sign(data []byte) ([]byte, error) {
+	var sigHex string
+	if err := ws.do(http.MethodPost, "/api/v1/eth1/sign/"+ws.publicKey, map[string]string{
+		"data": hexutil.Encode(data),
+	}, &sigHex); err != nil {
+		return nil, fmt.Errorf("failed to sign with the web3signer: %v", err)
+	}
+	sig, err := hexutil.Decode(sigHex)
+	if err != nil || len(sig) != 65 {
+		return nil, fmt.Errorf("invalid web3signer signature: %s", sigHex)
+	}
+	// the v value is recovered again since the web3signer returns it as 27 or 28
+	return toRecoverableSignature(crypto.Keccak256(data), sig[:64], ws.address)
+}
+
+// SignTx implements Signer interface. Only the legacy transactions are supported since the
+// signed data needs to be encoded here.
+func (ws *Web3Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.LegacyTxType {
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+	data, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, uint(0), uint(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the transaction: %v", err)
+	}
+	sig, err := ws.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(types.NewEIP155Signer(chainID), sig)
+}
+
+// Close implements Signer interface.
+func (ws *Web3Signer) Close() error {
+	return nil
+}
+
+// ClefSigner signs the transactions with an account of Clef. Clef only signs the typed data so
+// it can not sign the alert batches.
+type ClefSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewClefSigner connects to Clef and checks the scanner account.
+func NewClefSigner(cfg config.RemoteSignerConfig) (Signer, error) {
+	if len(cfg.URL) == 0 || !common.IsHexAddress(cfg.Address) {
+		return nil, errors.New("the clef signer needs the url and the scanner address")
+	}
+	client, err := rpc.DialHTTPWithClient(cfg.URL, &http.Client{Timeout: time.Minute})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial clef: %v", err)
+	}
+	if len(cfg.AuthToken) > 0 {
+		client.SetHeader("Authorization", "Bearer "+cfg.AuthToken)
+	}
+	cs := &ClefSigner{client: client, address: common.HexToAddress(cfg.Address)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+	defer cancel()
+	var addresses []common.Address
+	if err := client.CallContext(ctx, &addresses, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list the clef accounts: %v", err)
+	}
+	for _, address := range addresses {
+		if address == cs.address {
+			return cs, nil
+		}
+	}
+	client.Close()
+	return nil, fmt.Errorf("scanner account not found in clef: %s", cfg.Address)
+}
+
+// Address implements Signer interface.
+func (cs *ClefSigner) Address() common.Address {
+	return cs.address
+}
+
+// Sign implements Signer interface.
+func (cs *ClefSigner) Sign(data []byte) ([]byte, error) {
+	return nil, ErrDataSigningUnsupported
+}
+
+// SignTx implements Signer interface. The transaction needs to be approved in Clef.
+func (cs *ClefSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.LegacyTxType {
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+	data := hexutil.Bytes(tx.Data())
+	var to *common.MixedcaseAddress
+	if tx.To() != nil {
+		t := common.NewMixedcaseAddress(*tx.To())
+		to = &t
+	}
+	args := &apitypes.SendTxArgs{
+		From:     common.NewMixedcaseAddress(cs.address),
+		To:       to,
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     &data,
+		ChainID:  (*hexutil.Big)(chainID),
+	}
+	var result struct {
+		Raw hexutil.Bytes      `json:"raw"`
+		Tx  *types.Transaction `json:"tx"`
+	}
+	if err := cs.client.Call(&result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("failed to sign with clef: %v", err)
+	}
+	return result.Tx, nil
+}
+
+// Close implements Signer interface.
+func (cs *ClefSigner) Close() error {
+	cs.client.Close()
+	return nil
+}
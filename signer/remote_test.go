@@ -0,0 +1,65 @@
+package signer
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeb3Signer(t *testing.T) {
+	r := require.New(t)
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	publicKey := hexutil.Encode(crypto.FromECDSAPub(&privateKey.PublicKey)[1:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("Bearer token", req.Header.Get("Authorization"))
+		switch req.URL.Path {
+		case "/api/v1/eth1/publicKeys":
+			json.NewEncoder(w).Encode([]string{publicKey})
+		case "/api/v1/eth1/sign/" + publicKey:
+			var body struct {
+				Data string `json:"data"`
+			}
+			r.NoError(json.NewDecoder(req.Body).Decode(&body))
+			sig, err := crypto.Sign(crypto.Keccak256(hexutil.MustDecode(body.Data)), privateKey)
+			r.NoError(err)
+			sig[64] += 27
+			w.Write([]byte(hexutil.Encode(sig)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewWeb3Signer(config.RemoteSignerConfig{URL: server.URL, AuthToken: "token"})
+	r.NoError(err)
+	r.Equal(crypto.PubkeyToAddress(privateKey.PublicKey), s.Address())
+
+	signedBatch, err := SignBatch(s, &protocol.AlertBatch{ChainId: 1, BlockStart: 10, BlockEnd: 20})
+	r.NoError(err)
+	r.NoError(security.VerifySignedPayload(signedBatch))
+
+	chainID := big.NewInt(137)
+	tx := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(0), 21000, big.NewInt(1), []byte{1})
+	signedTx, err := s.SignTx(tx, chainID)
+	r.NoError(err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	r.NoError(err)
+	r.Equal(s.Address(), sender)
+
+	_, err = NewWeb3Signer(config.RemoteSignerConfig{URL: server.URL, AuthToken: "token", Address: "0x0000000000000000000000000000000000000001"})
+	r.Error(err)
+}
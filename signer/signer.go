@@ -0,0 +1,73 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/config"
+)
+
+// signer types
+const (
+	TypeKeystore   = "keystore"
+	TypePKCS11     = "pkcs11"
+	TypeLedger     = "ledger"
+	TypeWeb3Signer = "web3signer"
+	TypeClef       = "clef"
+)
+
+// ErrDataSigningUnsupported is returned by the signers which can only sign transactions.
+var ErrDataSigningUnsupported = errors.New("the signer can only sign transactions")
+
+// Signer produces the signatures of the scanner. The scanner key can be kept in a keystore,
+// an HSM, a hardware wallet or a remote signer.
+type Signer interface {
+	Address() common.Address
+	// Sign returns the [R || S || V] signature of the Keccak-256 hash of the data where V is 0 or 1.
+	Sign(data []byte) ([]byte, error)
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	Close() error
+}
+
+// New creates the signer in the config. The key is loaded only if the keystore is used.
+func New(cfg config.SignerConfig, loadKey func() (*keystore.Key, error)) (Signer, error) {
+	switch cfg.Type {
+	case TypePKCS11:
+		return NewPKCS11Signer(cfg.PKCS11)
+	case TypeLedger:
+		return NewLedgerSigner(cfg.Ledger)
+	case TypeWeb3Signer:
+		return NewWeb3Signer(cfg.Remote)
+	case TypeClef:
+		return NewClefSigner(cfg.Remote)
+	default:
+		key, err := loadKey()
+		if err != nil {
+			return nil, err
+		}
+		return NewKeystoreSigner(key), nil
+	}
+}
+
+// SignsTransactionsOnly tells if the signer type can only be used with the registration commands.
+func SignsTransactionsOnly(signerType string) bool {
+	return signerType == TypeLedger || signerType == TypeClef
+}
+
+// Load creates the signer of a node service from the keys mounted to the container.
+func Load(cfg config.Config) (Signer, error) {
+	if SignsTransactionsOnly(cfg.Signer.Type) {
+		return nil, fmt.Errorf("the %s signer can only be used with the registration commands", cfg.Signer.Type)
+	}
+	// can't dial localhost - need to dial host gateway from container
+	cfg.Signer.Remote.URL = utils.ConvertToDockerHostURL(cfg.Signer.Remote.URL)
+	return New(cfg.Signer, func() (*keystore.Key, error) {
+		return security.LoadKey(config.DefaultContainerKeyDirPath)
+	})
+}
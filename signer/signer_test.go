@@ -0,0 +1,80 @@
+package signer
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/stretchr/testify/require"
+)
+
+func testSigner(t *testing.T) *KeystoreSigner {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return NewKeystoreSigner(&keystore.Key{Address: crypto.PubkeyToAddress(privateKey.PublicKey), PrivateKey: privateKey})
+}
+
+func TestSignaturesVerifiedByNetwork(t *testing.T) {
+	r := require.New(t)
+
+	s := testSigner(t)
+
+	signedAlert, err := SignAlert(s, &protocol.Alert{Id: "0x1", Timestamp: "2022-06-01T10:00:00Z", Metadata: map[string]string{"a": "b"}})
+	r.NoError(err)
+	r.NoError(security.VerifyAlertSignature(signedAlert))
+
+	signedBatch, err := SignBatch(s, &protocol.AlertBatch{ChainId: 1, BlockStart: 10, BlockEnd: 20})
+	r.NoError(err)
+	r.NoError(security.VerifySignedPayload(signedBatch))
+	r.Equal(s.Address().Hex(), signedBatch.Signature.Signer)
+
+	token, err := CreateScannerJWT(s, map[string]interface{}{"access": "telemetry"})
+	r.NoError(err)
+	scannerToken, err := security.VerifyScannerJWT(token)
+	r.NoError(err)
+	r.Equal(s.Address().Hex(), scannerToken.Scanner)
+}
+
+func TestTransactOpts(t *testing.T) {
+	r := require.New(t)
+
+	s := testSigner(t)
+	chainID := big.NewInt(137)
+	opts := NewTransactOpts(s, chainID)
+	r.Equal(s.Address(), opts.From)
+
+	tx := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	signedTx, err := opts.Signer(s.Address(), tx)
+	r.NoError(err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	r.NoError(err)
+	r.Equal(s.Address(), sender)
+
+	_, err = opts.Signer(common.HexToAddress("0x2"), tx)
+	r.Error(err)
+}
+
+func TestToRecoverableSignature(t *testing.T) {
+	r := require.New(t)
+
+	s := testSigner(t)
+	hash := crypto.Keccak256([]byte("test"))
+	sig, err := s.Sign([]byte("test"))
+	r.NoError(err)
+
+	// the hsms can return the high s value which is equally valid for ecdsa
+	highS := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64]))
+	rs := append(append([]byte{}, sig[:32]...), highS.FillBytes(make([]byte, 32))...)
+
+	recoverable, err := toRecoverableSignature(hash, rs, s.Address())
+	r.NoError(err)
+	r.Equal(sig, recoverable)
+
+	_, err = toRecoverableSignature(hash, rs, common.HexToAddress("0x1"))
+	r.Error(err)
+}
@@ -0,0 +1,136 @@
+package signer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/encoding"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+)
+
+// The functions below produce the same signatures as the forta-core-go security package
+// so that the network can verify them regardless of where the scanner key is kept.
+
+// SignBytes signs the Keccak-256 hash of the bytes.
+func SignBytes(signer Signer, b []byte) (*protocol.Signature, error) {
+	sig, err := signer.Sign(b)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Signature{
+		Signature: hexutil.Encode(sig),
+		Algorithm: "ECDSA",
+		Signer:    signer.Address().Hex(),
+	}, nil
+}
+
+// SignString signs the string.
+func SignString(signer Signer, input string) (*protocol.Signature, error) {
+	return SignBytes(signer, []byte(input))
+}
+
+// SignAlert signs the alert using the alert ID, the metadata and the timestamp.
+func SignAlert(signer Signer, alert *protocol.Alert) (*protocol.SignedAlert, error) {
+	metadata := utils.MapToList(alert.Metadata)
+	hash := crypto.Keccak256Hash([]byte(fmt.Sprintf("%s%s%s", alert.Id, strings.Join(metadata, ""), alert.Timestamp)))
+	signature, err := SignBytes(signer, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.SignedAlert{
+		Alert:     alert,
+		Signature: signature,
+	}, nil
+}
+
+func signPayload(signer Signer, payloadType protocol.SignedPayload_PayloadType, msg proto.Message) (*protocol.SignedPayload, error) {
+	encoded, err := encoding.EncodeGzippedProto(msg)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := SignString(signer, encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.SignedPayload{
+		Type:      payloadType,
+		Encoded:   encoded,
+		Signature: signature,
+	}, nil
+}
+
+// SignBatch signs the alert batch.
+func SignBatch(signer Signer, payload *protocol.AlertBatch) (*protocol.SignedPayload, error) {
+	return signPayload(signer, protocol.SignedPayload_BATCH, payload)
+}
+
+// SignBatchSummary signs the alert batch summary.
+func SignBatchSummary(signer Signer, payload *protocol.BatchSummary) (*protocol.SignedPayload, error) {
+	return signPayload(signer, protocol.SignedPayload_BATCH_SUMMARY, payload)
+}
+
+// ethSigningMethod signs the tokens in the same way as the "ETH" method which forta-core-go
+// registers for verification.
+type ethSigningMethod struct{}
+
+func (ethSigningMethod) Verify(signingString, signature string, key interface{}) error {
+	return errors.New("not implemented")
+}
+
+func (ethSigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	signer, ok := key.(Signer)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	sig, err := signer.Sign([]byte(signingString))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func (ethSigningMethod) Alg() string {
+	return "ETH"
+}
+
+// CreateScannerJWT creates a short-lived token which identifies the scanner.
+func CreateScannerJWT(signer Signer, claims map[string]interface{}) (string, error) {
+	now := time.Now().UTC()
+	mapClaims := jwt.MapClaims{
+		"jti": uuid.Must(uuid.NewUUID()).String(),
+		"sub": signer.Address().Hex(),
+		"iat": now.Unix(),
+		"nbf": now.Add(-30 * time.Second).Unix(),
+		"exp": now.Add(30 * time.Second).Unix(),
+	}
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+	return jwt.NewWithClaims(ethSigningMethod{}, mapClaims).SignedString(signer)
+}
+
+// NewTransactOpts creates the options which sign the contract transactions with the signer.
+func NewTransactOpts(signer Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(tx, chainID)
+		},
+	}
+}
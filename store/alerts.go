@@ -0,0 +1,270 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/forta-network/forta-core-go/protocol"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// Alert store defaults
+const (
+	DefaultAlertQueryLimit = 50
+	MaxAlertQueryLimit     = 1000
+
+	alertKeyPrefix    = "alert/"
+	alertStoreGCEvery = time.Minute * 10
+)
+
+// AlertStore persists the alerts locally.
+type AlertStore interface {
+	Put(alert *protocol.Alert) error
+	Query(query *AlertQuery) (*AlertPage, error)
+	Close() error
+}
+
+// AlertQuery filters the stored alerts. The zero values do not filter.
+type AlertQuery struct {
+	AgentID     string
+	MinSeverity protocol.Finding_Severity
+	Address     string
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Cursor      string
+
+	// AfterAlertID skips the alerts until after the alert with this ID.
+	AfterAlertID string
+	// Filter is used together with the other fields if it is set.
+	Filter func(alert *protocol.Alert) bool
+}
+
+// AlertPage is a page of alerts from newest to oldest.
+type AlertPage struct {
+	Alerts     []*protocol.Alert `json:"alerts"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+}
+
+// ParseAlertQuery parses the query from URL query values.
+func ParseAlertQuery(values url.Values) (*AlertQuery, error) {
+	query := &AlertQuery{
+		AgentID: values.Get("agent"),
+		Address: values.Get("address"),
+		Cursor:  values.Get("cursor"),
+	}
+	if severity := values.Get("severity"); len(severity) > 0 {
+		value, ok := protocol.Finding_Severity_value[strings.ToUpper(severity)]
+		if !ok {
+			return nil, fmt.Errorf("invalid severity: %s", severity)
+		}
+		query.MinSeverity = protocol.Finding_Severity(value)
+	}
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		value := values.Get(param.name)
+		if len(value) == 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' time: %v", param.name, err)
+		}
+		*param.t = t
+	}
+	if limit := values.Get("limit"); len(limit) > 0 {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+func (query *AlertQuery) matches(alert *protocol.Alert) bool {
+	if query.Filter != nil && !query.Filter(alert) {
+		return false
+	}
+	if len(query.AgentID) > 0 && (alert.Agent == nil || !strings.EqualFold(alert.Agent.Id, query.AgentID)) {
+		return false
+	}
+	if alert.Finding == nil {
+		return query.MinSeverity == 0 && len(query.Address) == 0
+	}
+	if alert.Finding.Severity < query.MinSeverity {
+		return false
+	}
+	if len(query.Address) == 0 {
+		return true
+	}
+	for _, address := range alert.Finding.Addresses {
+		if strings.EqualFold(address, query.Address) {
+			return true
+		}
+	}
+	return false
+}
+
+type alertStore struct {
+	db        *badger.DB
+	retention time.Duration
+	closed    chan struct{}
+}
+
+// NewAlertStore creates a new alert store in the directory. The alerts expire after
+// the retention period. An empty directory makes an in-memory store.
+func NewAlertStore(dir string, retention time.Duration) (*alertStore, error) {
+	opts := badger.DefaultOptions(dir).WithLoggingLevel(badger.WARNING)
+	if len(dir) == 0 {
+		opts = opts.WithInMemory(true)
+	}
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the alert store: %v", err)
+	}
+	store := &alertStore{
+		db:        db,
+		retention: retention,
+		closed:    make(chan struct{}),
+	}
+	if len(dir) > 0 {
+		go store.collectGarbage()
+	}
+	return store, nil
+}
+
+// collectGarbage cleans up the value log periodically so that the expired alerts
+// do not take up space.
+func (store *alertStore) collectGarbage() {
+	ticker := time.NewTicker(alertStoreGCEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-store.closed:
+			return
+		case <-ticker.C:
+			for store.db.RunValueLogGC(0.5) == nil {
+			}
+		}
+	}
+}
+
+func alertKey(ts time.Time, alertID string) []byte {
+	key := make([]byte, len(alertKeyPrefix)+8, len(alertKeyPrefix)+8+len(alertID)+1)
+	copy(key, alertKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(alertKeyPrefix):], uint64(ts.UnixNano()))
+	key = append(key, '/')
+	return append(key, alertID...)
+}
+
+func alertKeyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[len(alertKeyPrefix):])))
+}
+
+// Put stores the alert.
+func (store *alertStore) Put(alert *protocol.Alert) error {
+	ts, err := time.Parse(time.RFC3339Nano, alert.Timestamp)
+	if err != nil {
+		ts = time.Now().UTC()
+	}
+	b, err := proto.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the alert: %v", err)
+	}
+	return store.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry(alertKey(ts, alert.Id), b)
+		if store.retention > 0 {
+			entry = entry.WithTTL(store.retention)
+		}
+		return txn.SetEntry(entry)
+	})
+}
+
+// Query returns the page of alerts which match the query.
+func (store *alertStore) Query(query *AlertQuery) (*AlertPage, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultAlertQueryLimit
+	}
+	if limit > MaxAlertQueryLimit {
+		limit = MaxAlertQueryLimit
+	}
+
+	seekKey := []byte(alertKeyPrefix + "\xff")
+	if !query.To.IsZero() {
+		seekKey = alertKey(query.To, "\xff")
+	}
+	var cursor []byte
+	if len(query.Cursor) > 0 {
+		var err error
+		cursor, err = hex.DecodeString(query.Cursor)
+		if err != nil || !strings.HasPrefix(string(cursor), alertKeyPrefix) {
+			return nil, errors.New("invalid cursor")
+		}
+		seekKey = cursor
+	}
+
+	page := &AlertPage{}
+	err := store.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		opts.Prefix = []byte(alertKeyPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var lastKey []byte
+		skipping := len(query.AfterAlertID) > 0
+		for it.Seek(seekKey); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			if cursor != nil && string(key) == string(cursor) {
+				continue
+			}
+			if !query.From.IsZero() && alertKeyTime(key).Before(query.From) {
+				return nil
+			}
+			if len(page.Alerts) == limit {
+				page.NextCursor = hex.EncodeToString(lastKey)
+				return nil
+			}
+			alert := &protocol.Alert{}
+			if err := item.Value(func(val []byte) error {
+				return proto.Unmarshal(val, alert)
+			}); err != nil {
+				log.WithError(err).WithField("key", string(key)).Warn("failed to read the stored alert - skipping")
+				continue
+			}
+			if skipping {
+				skipping = alert.Id != query.AfterAlertID
+				continue
+			}
+			if !query.matches(alert) {
+				continue
+			}
+			page.Alerts = append(page.Alerts, alert)
+			lastKey = key
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the alerts: %v", err)
+	}
+	return page, nil
+}
+
+// Close closes the store.
+func (store *alertStore) Close() error {
+	close(store.closed)
+	return store.db.Close()
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlert(id, agentID string, severity protocol.Finding_Severity, ts time.Time, addresses ...string) *protocol.Alert {
+	return &protocol.Alert{
+		Id:        id,
+		Timestamp: ts.Format(time.RFC3339Nano),
+		Agent:     &protocol.AgentInfo{Id: agentID},
+		Finding:   &protocol.Finding{Severity: severity, Addresses: addresses},
+	}
+}
+
+func alertIDs(page *AlertPage) (ids []string) {
+	for _, alert := range page.Alerts {
+		ids = append(ids, alert.Id)
+	}
+	return
+}
+
+func TestAlertStore_Query(t *testing.T) {
+	r := require.New(t)
+
+	alertStore, err := NewAlertStore("", time.Hour)
+	r.NoError(err)
+	defer alertStore.Close()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	r.NoError(alertStore.Put(testAlert("1", "0xagent1", protocol.Finding_LOW, start, "0xabc")))
+	r.NoError(alertStore.Put(testAlert("2", "0xagent2", protocol.Finding_HIGH, start.Add(time.Second))))
+	r.NoError(alertStore.Put(testAlert("3", "0xagent1", protocol.Finding_CRITICAL, start.Add(time.Second*2), "0xdef")))
+
+	page, err := alertStore.Query(&AlertQuery{})
+	r.NoError(err)
+	r.Equal([]string{"3", "2", "1"}, alertIDs(page))
+	r.Empty(page.NextCursor)
+
+	page, err = alertStore.Query(&AlertQuery{AgentID: "0xAGENT1"})
+	r.NoError(err)
+	r.Equal([]string{"3", "1"}, alertIDs(page))
+
+	page, err = alertStore.Query(&AlertQuery{MinSeverity: protocol.Finding_HIGH})
+	r.NoError(err)
+	r.Equal([]string{"3", "2"}, alertIDs(page))
+
+	page, err = alertStore.Query(&AlertQuery{Address: "0xABC"})
+	r.NoError(err)
+	r.Equal([]string{"1"}, alertIDs(page))
+
+	page, err = alertStore.Query(&AlertQuery{From: start.Add(time.Second), To: start.Add(time.Second)})
+	r.NoError(err)
+	r.Equal([]string{"2"}, alertIDs(page))
+}
+
+func TestAlertStore_Pagination(t *testing.T) {
+	r := require.New(t)
+
+	alertStore, err := NewAlertStore("", time.Hour)
+	r.NoError(err)
+	defer alertStore.Close()
+
+	start := time.Now().UTC()
+	for i, id := range []string{"1", "2", "3"} {
+		r.NoError(alertStore.Put(testAlert(id, "0xagent", protocol.Finding_INFO, start.Add(time.Duration(i)*time.Second))))
+	}
+
+	page, err := alertStore.Query(&AlertQuery{Limit: 2})
+	r.NoError(err)
+	r.Equal([]string{"3", "2"}, alertIDs(page))
+	r.NotEmpty(page.NextCursor)
+
+	page, err = alertStore.Query(&AlertQuery{Limit: 2, Cursor: page.NextCursor})
+	r.NoError(err)
+	r.Equal([]string{"1"}, alertIDs(page))
+	r.Empty(page.NextCursor)
+
+	_, err = alertStore.Query(&AlertQuery{Cursor: "invalid"})
+	r.Error(err)
+}
+
+func TestParseAlertQuery(t *testing.T) {
+	r := require.New(t)
+
+	query, err := ParseAlertQuery(url.Values{
+		"agent":    {"0xagent"},
+		"severity": {"high"},
+		"from":     {"2021-10-01T00:00:00Z"},
+		"limit":    {"10"},
+	})
+	r.NoError(err)
+	r.Equal("0xagent", query.AgentID)
+	r.Equal(protocol.Finding_HIGH, query.MinSeverity)
+	r.Equal(2021, query.From.Year())
+	r.True(query.To.IsZero())
+	r.Equal(10, query.Limit)
+
+	_, err = ParseAlertQuery(url.Values{"severity": {"bogus"}})
+	r.Error(err)
+	_, err = ParseAlertQuery(url.Values{"limit": {"-1"}})
+	r.Error(err)
+}
+
+func TestAlertStore_AfterAlertIDAndFilter(t *testing.T) {
+	r := require.New(t)
+
+	alertStore, err := NewAlertStore("", time.Hour)
+	r.NoError(err)
+	defer alertStore.Close()
+
+	start := time.Now().UTC()
+	for i, id := range []string{"1", "2", "3", "4"} {
+		r.NoError(alertStore.Put(testAlert(id, "0xagent", protocol.Finding_INFO, start.Add(time.Duration(i)*time.Second))))
+	}
+
+	page, err := alertStore.Query(&AlertQuery{AfterAlertID: "3"})
+	r.NoError(err)
+	r.Equal([]string{"2", "1"}, alertIDs(page))
+
+	page, err = alertStore.Query(&AlertQuery{
+		AfterAlertID: "4",
+		Filter: func(alert *protocol.Alert) bool {
+			return alert.Id != "2"
+		},
+	})
+	r.NoError(err)
+	r.Equal([]string{"3", "1"}, alertIDs(page))
+}
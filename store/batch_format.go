@@ -0,0 +1,128 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// Batch format versions of the locally stored batches, i.e. the queued and the dead letter batch
+// files. The published batches have a separate version since they are encoded differently.
+const (
+	// BatchFormatV0 is the unversioned protobuf encoding which was used before versioning.
+	// The batches decoded by 'forta batch decode' as JSON are also read as this version.
+	BatchFormatV0 uint8 = 0
+	// BatchFormatV1 is the protobuf encoding with the version header.
+	BatchFormatV1 uint8 = 1
+
+	CurrentBatchFormat = BatchFormatV1
+)
+
+// PublishedBatchFormat is the format version of the published batches, i.e. the JSON encoded
+// signed alert batches. The payload itself has no field to carry it so it is recorded in the
+// batch references instead.
+const PublishedBatchFormat uint8 = 1
+
+// batchFormatMagic precedes the version byte in the versioned batches. It can't be confused
+// with a protobuf encoded batch since 0xff is not a valid protobuf field tag.
+var batchFormatMagic = []byte{0xff, 'F', 'B', 'F'}
+
+// ErrUnsupportedBatchFormat is returned when the batch is written by a newer version.
+var ErrUnsupportedBatchFormat = errors.New("unsupported batch format version")
+
+// batchDecoders contains the decoders of the payloads which follow the version header.
+var batchDecoders = map[uint8]func(payload []byte, batch *protocol.AlertBatch) error{
+	BatchFormatV1: func(payload []byte, batch *protocol.AlertBatch) error {
+		return proto.Unmarshal(payload, batch)
+	},
+}
+
+// EncodeBatch encodes the batch in the current format.
+func EncodeBatch(batch *protocol.AlertBatch) ([]byte, error) {
+	payload, err := proto.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the batch: %v", err)
+	}
+	b := make([]byte, 0, len(batchFormatMagic)+1+len(payload))
+	b = append(b, batchFormatMagic...)
+	b = append(b, CurrentBatchFormat)
+	return append(b, payload...), nil
+}
+
+// DecodeBatch decodes a batch in any of the supported formats and returns the format version.
+func DecodeBatch(b []byte) (*protocol.AlertBatch, uint8, error) {
+	batch := &protocol.AlertBatch{}
+	if !bytes.HasPrefix(b, batchFormatMagic) || len(b) == len(batchFormatMagic) {
+		if err := decodeUnversionedBatch(b, batch); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode the unversioned batch: %v", err)
+		}
+		return batch, BatchFormatV0, nil
+	}
+
+	version := b[len(batchFormatMagic)]
+	decode, ok := batchDecoders[version]
+	if !ok {
+		return nil, version, fmt.Errorf("%w: %d (latest supported: %d)", ErrUnsupportedBatchFormat, version, CurrentBatchFormat)
+	}
+	if err := decode(b[len(batchFormatMagic)+1:], batch); err != nil {
+		return nil, version, fmt.Errorf("failed to decode the batch (format v%d): %v", version, err)
+	}
+	return batch, version, nil
+}
+
+func decodeUnversionedBatch(b []byte, batch *protocol.AlertBatch) error {
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		return json.Unmarshal(trimmed, batch)
+	}
+	return proto.Unmarshal(b, batch)
+}
+
+// MigrateBatchFiles rewrites the local batch files in the directory which are not in the current
+// format and returns how many of them were migrated. The files are replaced atomically.
+func MigrateBatchFiles(dir string) (int, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the batch dir: %v", err)
+	}
+	var migrated int
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), batchFileExt) {
+			continue
+		}
+		filePath := path.Join(dir, entry.Name())
+		b, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+		batch, version, err := DecodeBatch(b)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to decode %s: %v", entry.Name(), err)
+		}
+		if version == CurrentBatchFormat {
+			continue
+		}
+		if b, err = EncodeBatch(batch); err != nil {
+			return migrated, err
+		}
+		tmpPath := filePath + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+			return migrated, fmt.Errorf("failed to write %s: %v", entry.Name(), err)
+		}
+		if err := os.Rename(tmpPath, filePath); err != nil {
+			return migrated, fmt.Errorf("failed to replace %s: %v", entry.Name(), err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
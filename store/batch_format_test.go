@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBatchFormat(t *testing.T) {
+	r := require.New(t)
+
+	b, err := EncodeBatch(&protocol.AlertBatch{BlockStart: 1})
+	r.NoError(err)
+	batch, version, err := DecodeBatch(b)
+	r.NoError(err)
+	r.Equal(CurrentBatchFormat, version)
+	r.Equal(uint64(1), batch.BlockStart)
+
+	// unversioned protobuf
+	b, err = proto.Marshal(&protocol.AlertBatch{BlockStart: 2})
+	r.NoError(err)
+	batch, version, err = DecodeBatch(b)
+	r.NoError(err)
+	r.Equal(BatchFormatV0, version)
+	r.Equal(uint64(2), batch.BlockStart)
+
+	// decoded json batch
+	b, err = json.Marshal(&protocol.AlertBatch{BlockStart: 3})
+	r.NoError(err)
+	batch, version, err = DecodeBatch(b)
+	r.NoError(err)
+	r.Equal(BatchFormatV0, version)
+	r.Equal(uint64(3), batch.BlockStart)
+
+	// from the future
+	_, _, err = DecodeBatch(append(append([]byte{}, batchFormatMagic...), 100))
+	r.True(errors.Is(err, ErrUnsupportedBatchFormat))
+}
+
+func TestMigrateBatchFiles(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	legacy, err := proto.Marshal(&protocol.AlertBatch{BlockStart: 1})
+	r.NoError(err)
+	r.NoError(ioutil.WriteFile(path.Join(dir, "1"+batchFileExt), legacy, 0644))
+	current, err := EncodeBatch(&protocol.AlertBatch{BlockStart: 2})
+	r.NoError(err)
+	r.NoError(ioutil.WriteFile(path.Join(dir, "2"+batchFileExt), current, 0644))
+
+	migrated, err := MigrateBatchFiles(dir)
+	r.NoError(err)
+	r.Equal(1, migrated)
+
+	b, err := ioutil.ReadFile(path.Join(dir, "1"+batchFileExt))
+	r.NoError(err)
+	batch, version, err := DecodeBatch(b)
+	r.NoError(err)
+	r.Equal(CurrentBatchFormat, version)
+	r.Equal(uint64(1), batch.BlockStart)
+
+	migrated, err = MigrateBatchFiles(dir)
+	r.NoError(err)
+	r.Equal(0, migrated)
+
+	migrated, err = MigrateBatchFiles(path.Join(dir, "does-not-exist"))
+	r.NoError(err)
+	r.Equal(0, migrated)
+}
@@ -0,0 +1,281 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	batchFileExt     = ".batch"
+	batchAttemptsExt = ".attempts"
+)
+
+// QueuedBatch is a batch which is waiting to be published.
+type QueuedBatch struct {
+	ID            string
+	Batch         *protocol.AlertBatch
+	QueuedAt      time.Time
+	Attempts      int
+	LastAttemptAt time.Time
+}
+
+// batchAttempts is kept next to the queued batch so that the attempts survive the restarts.
+type batchAttempts struct {
+	Attempts      int       `json:"attempts"`
+	LastAttemptAt time.Time `json:"lastAttemptAt"`
+}
+
+// BatchQueue keeps the unpublished batches until they are published.
+type BatchQueue interface {
+	Push(batch *protocol.AlertBatch) error
+	Peek() (*QueuedBatch, error)
+	Remove(id string) error
+	Update(id string, batch *protocol.AlertBatch) error
+	AddAttempt(id string) (int, error)
+	Len() int
+	Oldest() (time.Time, bool)
+}
+
+type fileBatchQueue struct {
+	dir         string
+	maxBatches  int
+	ids         []string
+	deadLetters DeadLetterStore
+	mu          sync.Mutex
+}
+
+// NewFileBatchQueue creates a queue which persists the batches as files in the directory
+// so that they survive the restarts. The oldest batch is dropped when the queue is full.
+func NewFileBatchQueue(dir string, maxBatches int) (*fileBatchQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the batch queue dir: %v", err)
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the batch queue dir: %v", err)
+	}
+	queue := &fileBatchQueue{dir: dir, maxBatches: maxBatches}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), batchFileExt) {
+			continue
+		}
+		queue.ids = append(queue.ids, strings.TrimSuffix(entry.Name(), batchFileExt))
+	}
+	sort.Strings(queue.ids)
+	return queue, nil
+}
+
+// SetDeadLetterStore makes the queue move the dropped batches to the dead letter store.
+func (queue *fileBatchQueue) SetDeadLetterStore(deadLetters DeadLetterStore) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.deadLetters = deadLetters
+}
+
+func (queue *fileBatchQueue) filePath(id string) string {
+	return path.Join(queue.dir, id+batchFileExt)
+}
+
+func (queue *fileBatchQueue) attemptsPath(id string) string {
+	return path.Join(queue.dir, id+batchAttemptsExt)
+}
+
+func (queue *fileBatchQueue) readAttempts(id string) batchAttempts {
+	var attempts batchAttempts
+	b, err := ioutil.ReadFile(queue.attemptsPath(id))
+	if err == nil {
+		err = json.Unmarshal(b, &attempts)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).WithField("batch", id).Warn("failed to read the attempts of the queued batch")
+	}
+	return attempts
+}
+
+// Push writes the batch to the end of the queue.
+func (queue *fileBatchQueue) Push(batch *protocol.AlertBatch) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	// zero-padded so that the ids are sorted by time
+	id := fmt.Sprintf("%020d", time.Now().UnixNano())
+	if len(queue.ids) > 0 && id <= queue.ids[len(queue.ids)-1] {
+		last, _ := strconv.ParseInt(queue.ids[len(queue.ids)-1], 10, 64)
+		id = fmt.Sprintf("%020d", last+1)
+	}
+	if err := queue.writeBatch(id, batch); err != nil {
+		return err
+	}
+	queue.ids = append(queue.ids, id)
+
+	for queue.maxBatches > 0 && len(queue.ids) > queue.maxBatches {
+		dropped := queue.ids[0]
+		if queue.deadLetters != nil {
+			log.WithField("batch", dropped).Warn("batch queue is full - moving the oldest batch to dead letters")
+			queue.moveToDeadLetters(dropped, "batch queue is full")
+		} else {
+			log.WithField("batch", dropped).Warn("batch queue is full - dropping the oldest batch")
+		}
+		if err := queue.remove(dropped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (queue *fileBatchQueue) writeBatch(id string, batch *protocol.AlertBatch) error {
+	b, err := EncodeBatch(batch)
+	if err != nil {
+		return err
+	}
+	tmpPath := path.Join(queue.dir, id+".tmp")
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return fmt.Errorf("failed to write the batch: %v", err)
+	}
+	if err := os.Rename(tmpPath, queue.filePath(id)); err != nil {
+		return fmt.Errorf("failed to move the batch file: %v", err)
+	}
+	return nil
+}
+
+func (queue *fileBatchQueue) moveToDeadLetters(id, reason string) {
+	b, err := ioutil.ReadFile(queue.filePath(id))
+	if err == nil {
+		letter := &DeadLetter{
+			ID:       id,
+			Reason:   reason,
+			Attempts: queue.readAttempts(id).Attempts,
+			QueuedAt: idTime(id),
+			FailedAt: time.Now().UTC(),
+		}
+		// the batches which cannot be decoded are kept as they are
+		var decodeErr error
+		if letter.Batch, _, decodeErr = DecodeBatch(b); decodeErr != nil {
+			letter.Encoded = b
+		}
+		err = queue.deadLetters.Put(letter)
+	}
+	if err != nil {
+		log.WithError(err).WithField("batch", id).Error("failed to move the batch to dead letters")
+	}
+}
+
+// Peek returns the oldest batch or nil if the queue is empty.
+func (queue *fileBatchQueue) Peek() (*QueuedBatch, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	for len(queue.ids) > 0 {
+		id := queue.ids[0]
+		b, err := ioutil.ReadFile(queue.filePath(id))
+		if err == nil {
+			var batch *protocol.AlertBatch
+			if batch, _, err = DecodeBatch(b); err == nil {
+				attempts := queue.readAttempts(id)
+				return &QueuedBatch{
+					ID:            id,
+					Batch:         batch,
+					QueuedAt:      idTime(id),
+					Attempts:      attempts.Attempts,
+					LastAttemptAt: attempts.LastAttemptAt,
+				}, nil
+			}
+		}
+		if queue.deadLetters != nil {
+			log.WithError(err).WithField("batch", id).Warn("failed to read the queued batch - moving to dead letters")
+			queue.moveToDeadLetters(id, fmt.Sprintf("failed to read the queued batch: %v", err))
+		} else {
+			log.WithError(err).WithField("batch", id).Warn("failed to read the queued batch - dropping")
+		}
+		if err := queue.remove(id); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Remove removes the batch from the queue.
+func (queue *fileBatchQueue) Remove(id string) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return queue.remove(id)
+}
+
+// Update replaces the queued batch, e.g. after more data is added to it.
+func (queue *fileBatchQueue) Update(id string, batch *protocol.AlertBatch) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	for _, queuedID := range queue.ids {
+		if queuedID == id {
+			return queue.writeBatch(id, batch)
+		}
+	}
+	return fmt.Errorf("batch %s is not in the queue", id)
+}
+
+// AddAttempt records a failed attempt to publish the batch and returns the number of attempts.
+func (queue *fileBatchQueue) AddAttempt(id string) (int, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	attempts := queue.readAttempts(id)
+	attempts.Attempts++
+	attempts.LastAttemptAt = time.Now().UTC()
+	b, err := json.Marshal(&attempts)
+	if err != nil {
+		return 0, err
+	}
+	if err := ioutil.WriteFile(queue.attemptsPath(id), b, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write the batch attempts: %v", err)
+	}
+	return attempts.Attempts, nil
+}
+
+func (queue *fileBatchQueue) remove(id string) error {
+	if err := os.Remove(queue.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the queued batch: %v", err)
+	}
+	if err := os.Remove(queue.attemptsPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove the batch attempts: %v", err)
+	}
+	for i, queuedID := range queue.ids {
+		if queuedID == id {
+			queue.ids = append(queue.ids[:i], queue.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Len returns the number of batches in the queue.
+func (queue *fileBatchQueue) Len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return len(queue.ids)
+}
+
+// Oldest returns the time the oldest batch was queued at.
+func (queue *fileBatchQueue) Oldest() (time.Time, bool) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if len(queue.ids) == 0 {
+		return time.Time{}, false
+	}
+	return idTime(queue.ids[0]), true
+}
+
+func idTime(id string) time.Time {
+	nanos, _ := strconv.ParseInt(id, 10, 64)
+	return time.Unix(0, nanos)
+}
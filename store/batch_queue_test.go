@@ -0,0 +1,124 @@
+package store
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBatchQueue(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	queue, err := NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	_, ok := queue.Oldest()
+	r.False(ok)
+	queued, err := queue.Peek()
+	r.NoError(err)
+	r.Nil(queued)
+
+	for i := uint64(1); i <= 3; i++ {
+		r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: i}))
+	}
+	// the oldest one is dropped
+	r.Equal(2, queue.Len())
+
+	// the batches survive the restart
+	queue, err = NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	r.Equal(2, queue.Len())
+	_, ok = queue.Oldest()
+	r.True(ok)
+
+	queued, err = queue.Peek()
+	r.NoError(err)
+	r.Equal(uint64(2), queued.Batch.BlockStart)
+	r.NoError(queue.Remove(queued.ID))
+
+	queued, err = queue.Peek()
+	r.NoError(err)
+	r.Equal(uint64(3), queued.Batch.BlockStart)
+	r.NoError(queue.Remove(queued.ID))
+	r.Equal(0, queue.Len())
+}
+
+func TestFileBatchQueue_Attempts(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	queue, err := NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 1}))
+	queued, err := queue.Peek()
+	r.NoError(err)
+	r.Equal(0, queued.Attempts)
+	attempts, err := queue.AddAttempt(queued.ID)
+	r.NoError(err)
+	r.Equal(1, attempts)
+	attempts, err = queue.AddAttempt(queued.ID)
+	r.NoError(err)
+	r.Equal(2, attempts)
+
+	// the attempts survive the restart
+	queue, err = NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	queued, err = queue.Peek()
+	r.NoError(err)
+	r.Equal(2, queued.Attempts)
+	r.False(queued.LastAttemptAt.IsZero())
+
+	// and are removed with the batch
+	r.NoError(queue.Remove(queued.ID))
+	r.NoFileExists(queue.attemptsPath(queued.ID))
+}
+
+func TestFileBatchQueue_Update(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	queue, err := NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 1}))
+	queued, err := queue.Peek()
+	r.NoError(err)
+	queued.Batch.Metrics = append(queued.Batch.Metrics, &protocol.AgentMetrics{AgentId: "0x1"})
+	r.NoError(queue.Update(queued.ID, queued.Batch))
+	r.Error(queue.Update("unknown", queued.Batch))
+
+	// the update survives the restart
+	queue, err = NewFileBatchQueue(dir, 2)
+	r.NoError(err)
+	queued, err = queue.Peek()
+	r.NoError(err)
+	r.Len(queued.Batch.Metrics, 1)
+	r.Equal("0x1", queued.Batch.Metrics[0].AgentId)
+	r.Equal(1, queue.Len())
+}
+
+func TestFileBatchQueue_Undecodable(t *testing.T) {
+	r := require.New(t)
+
+	queue, err := NewFileBatchQueue(t.TempDir(), 2)
+	r.NoError(err)
+	deadLetters, err := NewFileDeadLetterStore(t.TempDir())
+	r.NoError(err)
+	queue.SetDeadLetterStore(deadLetters)
+
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 1}))
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 2}))
+	r.NoError(ioutil.WriteFile(queue.filePath(queue.ids[0]), []byte("bad"), 0644))
+
+	// the undecodable batch is kept as a dead letter
+	queued, err := queue.Peek()
+	r.NoError(err)
+	r.Equal(uint64(2), queued.Batch.BlockStart)
+	letters, err := deadLetters.List()
+	r.NoError(err)
+	r.Len(letters, 1)
+	b, err := ioutil.ReadFile(deadLetters.batchPath(letters[0].ID))
+	r.NoError(err)
+	r.Equal([]byte("bad"), b)
+}
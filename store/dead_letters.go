@@ -0,0 +1,207 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+const deadLetterInfoExt = ".json"
+
+// ErrDeadLetterNotFound is returned when there is no dead letter with the given ID.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a batch which could not be published within the retry budget.
+type DeadLetter struct {
+	ID         string               `json:"id"`
+	Reason     string               `json:"reason"`
+	Attempts   int                  `json:"attempts"`
+	QueuedAt   time.Time            `json:"queuedAt"`
+	FailedAt   time.Time            `json:"failedAt"`
+	BlockStart uint64               `json:"blockStart"`
+	BlockEnd   uint64               `json:"blockEnd"`
+	AlertCount uint32               `json:"alertCount"`
+	Batch      *protocol.AlertBatch `json:"batch,omitempty"`
+	// Encoded is the batch file content if the batch could not be decoded.
+	Encoded []byte `json:"-"`
+}
+
+// DeadLetterStore keeps the batches which failed permanently so that they can be
+// inspected and retried later.
+type DeadLetterStore interface {
+	Put(letter *DeadLetter) error
+	Get(id string) (*DeadLetter, error)
+	List() ([]*DeadLetter, error)
+	Remove(id string) error
+	Len() int
+	Oldest() (time.Time, bool)
+}
+
+type fileDeadLetterStore struct {
+	dir     string
+	letters map[string]*DeadLetter
+	mu      sync.Mutex
+}
+
+// NewFileDeadLetterStore creates a store which keeps each dead letter as a batch file
+// and an info file next to it in the directory.
+func NewFileDeadLetterStore(dir string) (*fileDeadLetterStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the dead letter dir: %v", err)
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the dead letter dir: %v", err)
+	}
+	store := &fileDeadLetterStore{dir: dir, letters: make(map[string]*DeadLetter)}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), deadLetterInfoExt) {
+			continue
+		}
+		b, err := ioutil.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the dead letter info: %v", err)
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(b, &letter); err != nil {
+			return nil, fmt.Errorf("failed to decode the dead letter info %s: %v", entry.Name(), err)
+		}
+		store.letters[letter.ID] = &letter
+	}
+	return store, nil
+}
+
+func (store *fileDeadLetterStore) batchPath(id string) string {
+	return path.Join(store.dir, id+batchFileExt)
+}
+
+func (store *fileDeadLetterStore) infoPath(id string) string {
+	return path.Join(store.dir, id+deadLetterInfoExt)
+}
+
+// Put writes the dead letter. The batch is written first so that the info file is never
+// found without the batch.
+func (store *fileDeadLetterStore) Put(letter *DeadLetter) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var (
+		b   []byte
+		err error
+	)
+	switch {
+	case letter.Batch != nil:
+		letter.BlockStart = letter.Batch.BlockStart
+		letter.BlockEnd = letter.Batch.BlockEnd
+		letter.AlertCount = letter.Batch.AlertCount
+		if b, err = EncodeBatch(letter.Batch); err != nil {
+			return err
+		}
+	case len(letter.Encoded) > 0:
+		b = letter.Encoded
+	default:
+		return errors.New("dead letter has no batch")
+	}
+	if err := ioutil.WriteFile(store.batchPath(letter.ID), b, 0644); err != nil {
+		return fmt.Errorf("failed to write the dead letter batch: %v", err)
+	}
+	// the batch is kept in the batch file
+	info := *letter
+	info.Batch = nil
+	info.Encoded = nil
+	b, err = json.MarshalIndent(&info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the dead letter info: %v", err)
+	}
+	if err := ioutil.WriteFile(store.infoPath(letter.ID), b, 0644); err != nil {
+		return fmt.Errorf("failed to write the dead letter info: %v", err)
+	}
+	store.letters[letter.ID] = &info
+	return nil
+}
+
+// Get returns the dead letter with the batch.
+func (store *fileDeadLetterStore) Get(id string) (*DeadLetter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	letter, ok := store.letters[id]
+	if !ok {
+		return nil, ErrDeadLetterNotFound
+	}
+	b, err := ioutil.ReadFile(store.batchPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the dead letter batch: %v", err)
+	}
+	batch, _, err := DecodeBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the dead letter batch: %v", err)
+	}
+	result := *letter
+	result.Batch = batch
+	return &result, nil
+}
+
+// List returns the dead letters without the batches from oldest to newest.
+func (store *fileDeadLetterStore) List() ([]*DeadLetter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	letters := make([]*DeadLetter, 0, len(store.letters))
+	for _, letter := range store.letters {
+		result := *letter
+		result.Batch = nil
+		letters = append(letters, &result)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt.Before(letters[j].FailedAt)
+	})
+	return letters, nil
+}
+
+// Remove removes the dead letter.
+func (store *fileDeadLetterStore) Remove(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.letters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	for _, filePath := range []string{store.infoPath(id), store.batchPath(id)} {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the dead letter: %v", err)
+		}
+	}
+	delete(store.letters, id)
+	return nil
+}
+
+// Len returns the number of dead letters.
+func (store *fileDeadLetterStore) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return len(store.letters)
+}
+
+// Oldest returns the failure time of the oldest dead letter.
+func (store *fileDeadLetterStore) Oldest() (time.Time, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var oldest time.Time
+	for _, letter := range store.letters {
+		if oldest.IsZero() || letter.FailedAt.Before(oldest) {
+			oldest = letter.FailedAt
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDeadLetterStore(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+
+	deadLetters, err := NewFileDeadLetterStore(dir)
+	r.NoError(err)
+	_, ok := deadLetters.Oldest()
+	r.False(ok)
+
+	failedAt := time.Now().UTC().Truncate(time.Second)
+	r.NoError(deadLetters.Put(&DeadLetter{
+		ID:       "2",
+		Reason:   "failed",
+		Attempts: 3,
+		FailedAt: failedAt.Add(time.Second),
+		Batch:    &protocol.AlertBatch{BlockStart: 2, BlockEnd: 3, AlertCount: 1},
+	}))
+	r.NoError(deadLetters.Put(&DeadLetter{
+		ID:       "1",
+		FailedAt: failedAt,
+		Batch:    &protocol.AlertBatch{BlockStart: 1},
+	}))
+	r.Error(deadLetters.Put(&DeadLetter{ID: "3"}))
+
+	// the dead letters survive the restart
+	deadLetters, err = NewFileDeadLetterStore(dir)
+	r.NoError(err)
+	r.Equal(2, deadLetters.Len())
+	oldest, ok := deadLetters.Oldest()
+	r.True(ok)
+	r.True(failedAt.Equal(oldest))
+
+	letters, err := deadLetters.List()
+	r.NoError(err)
+	r.Len(letters, 2)
+	r.Equal("1", letters[0].ID)
+	r.Nil(letters[0].Batch)
+	r.Equal("failed", letters[1].Reason)
+	r.Equal(3, letters[1].Attempts)
+	r.Equal(uint64(2), letters[1].BlockStart)
+	r.Equal(uint32(1), letters[1].AlertCount)
+
+	letter, err := deadLetters.Get("2")
+	r.NoError(err)
+	r.Equal(uint64(3), letter.Batch.BlockEnd)
+
+	r.NoError(deadLetters.Remove("2"))
+	_, err = deadLetters.Get("2")
+	r.ErrorIs(err, ErrDeadLetterNotFound)
+	r.ErrorIs(deadLetters.Remove("2"), ErrDeadLetterNotFound)
+	r.Equal(1, deadLetters.Len())
+}
+
+func TestFileBatchQueue_DeadLetters(t *testing.T) {
+	r := require.New(t)
+
+	queue, err := NewFileBatchQueue(t.TempDir(), 1)
+	r.NoError(err)
+	deadLetters, err := NewFileDeadLetterStore(t.TempDir())
+	r.NoError(err)
+	queue.SetDeadLetterStore(deadLetters)
+
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 1}))
+	r.NoError(queue.Push(&protocol.AlertBatch{BlockStart: 2}))
+	r.Equal(1, queue.Len())
+
+	letters, err := deadLetters.List()
+	r.NoError(err)
+	r.Len(letters, 1)
+	r.Equal(uint64(1), letters[0].BlockStart)
+	r.Equal("batch queue is full", letters[0].Reason)
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const disabledAgentsFileName = "disabled-agents.json"
+
+// DisabledAgentsStore persists the agents which are disabled by the operator so that they stay
+// disabled after restarts.
+type DisabledAgentsStore interface {
+	Get() ([]string, error)
+	Put([]string) error
+}
+
+type disabledAgentsStore struct {
+	filePath string
+}
+
+// NewDisabledAgentsStore creates a new disabled agents store.
+func NewDisabledAgentsStore(dir string) *disabledAgentsStore {
+	return &disabledAgentsStore{
+		filePath: path.Join(dir, disabledAgentsFileName),
+	}
+}
+
+// Get returns the disabled agent IDs.
+func (store *disabledAgentsStore) Get() ([]string, error) {
+	b, err := ioutil.ReadFile(store.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the disabled agents: %v", err)
+	}
+	var agentIDs []string
+	if err := json.Unmarshal(b, &agentIDs); err != nil {
+		return nil, fmt.Errorf("invalid disabled agents file: %v", err)
+	}
+	return agentIDs, nil
+}
+
+// Put replaces the disabled agent IDs.
+func (store *disabledAgentsStore) Put(agentIDs []string) error {
+	b, err := json.Marshal(agentIDs)
+	if err != nil {
+		return err
+	}
+	tmpPath := store.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return fmt.Errorf("failed to write the disabled agents: %v", err)
+	}
+	return os.Rename(tmpPath, store.filePath)
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/manifest"
+)
+
+// Agent manifest verification errors
+var (
+	ErrUnsignedManifest      = errors.New("agent manifest is not signed")
+	ErrManifestOwnerMismatch = errors.New("agent manifest is not signed by the agent owner")
+)
+
+type rawSignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature string          `json:"signature"`
+}
+
+// VerifyAgentManifest checks that the manifest is signed by the owner of the agent and returns the
+// decoded manifest. The signature is over the keccak256 hash of the manifest JSON, as published by
+// the agent developer tooling.
+func VerifyAgentManifest(b []byte, owner string) (*manifest.SignedAgentManifest, error) {
+	var raw rawSignedManifest
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid agent manifest: %v", err)
+	}
+	if len(raw.Manifest) == 0 || len(raw.Signature) == 0 {
+		return nil, ErrUnsignedManifest
+	}
+	signer, err := recoverSigner(raw.Manifest, raw.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent manifest signature: %v", err)
+	}
+	if !strings.EqualFold(signer, owner) {
+		return nil, fmt.Errorf("%w: signer %s, owner %s", ErrManifestOwnerMismatch, signer, owner)
+	}
+	return decodeAgentManifest(b)
+}
+
+// manifestConstraints are the optional compatibility and resource request fields of the manifest.
+type manifestConstraints struct {
+	Manifest struct {
+		NodeVersion     string `json:"nodeVersion"`
+		ProtocolVersion string `json:"protocolVersion"`
+		Resources       struct {
+			GPUs int `json:"gpus"`
+		} `json:"resources"`
+	} `json:"manifest"`
+}
+
+func decodeManifestConstraints(b []byte) (nodeVersion, protocolVersion string) {
+	var constraints manifestConstraints
+	if err := json.Unmarshal(b, &constraints); err != nil {
+		return "", ""
+	}
+	return constraints.Manifest.NodeVersion, constraints.Manifest.ProtocolVersion
+}
+
+// decodeManifestGPUs returns the number of GPUs requested by the agent.
+func decodeManifestGPUs(b []byte) int {
+	var constraints manifestConstraints
+	if err := json.Unmarshal(b, &constraints); err != nil || constraints.Manifest.Resources.GPUs < 0 {
+		return 0
+	}
+	return constraints.Manifest.Resources.GPUs
+}
+
+func decodeAgentManifest(b []byte) (*manifest.SignedAgentManifest, error) {
+	var signedManifest manifest.SignedAgentManifest
+	if err := json.Unmarshal(b, &signedManifest); err != nil {
+		return nil, fmt.Errorf("invalid agent manifest: %v", err)
+	}
+	if signedManifest.Manifest == nil {
+		return nil, errors.New("invalid agent manifest: no manifest")
+	}
+	return &signedManifest, nil
+}
+
+func recoverSigner(message []byte, sigHex string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+	if err != nil {
+		return "", err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return "", fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	// the signatures from the ethereum tooling have the recovery id as 27 or 28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubKey, err := crypto.SigToPub(crypto.Keccak256(message), sig)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(*pubKey).Hex(), nil
+}
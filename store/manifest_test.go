@@ -0,0 +1,49 @@
+package store
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `{"from":"0x1","name":"test","agentId":"0x2","imageReference":"bafybeide7cspdmxqjcpa3qvrayvfpiix2it4v6mjejjc22q72zbq7rm4re"}`
+
+func signTestManifest(t *testing.T) (string, string) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(crypto.Keccak256([]byte(testManifest)), key)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), "0x" + hex.EncodeToString(sig)
+}
+
+func TestVerifyAgentManifest(t *testing.T) {
+	r := require.New(t)
+
+	owner, sig := signTestManifest(t)
+	b := []byte(fmt.Sprintf(`{"manifest":%s,"signature":"%s"}`, testManifest, sig))
+
+	signedManifest, err := VerifyAgentManifest(b, owner)
+	r.NoError(err)
+	r.Equal("test", *signedManifest.Manifest.Name)
+
+	_, err = VerifyAgentManifest(b, "0x0000000000000000000000000000000000000001")
+	r.ErrorIs(err, ErrManifestOwnerMismatch)
+
+	_, err = VerifyAgentManifest([]byte(fmt.Sprintf(`{"manifest":%s}`, testManifest)), owner)
+	r.ErrorIs(err, ErrUnsignedManifest)
+
+	_, err = VerifyAgentManifest([]byte(fmt.Sprintf(`{"manifest":%s,"signature":"0x1234"}`, testManifest)), owner)
+	r.Error(err)
+}
+
+func TestDecodeManifestGPUs(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(0, decodeManifestGPUs([]byte(fmt.Sprintf(`{"manifest":%s}`, testManifest))))
+	r.Equal(2, decodeManifestGPUs([]byte(`{"manifest":{"resources":{"gpus":2}}}`)))
+	r.Equal(0, decodeManifestGPUs([]byte(`{"manifest":{"resources":{"gpus":-1}}}`)))
+}
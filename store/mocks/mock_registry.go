@@ -5,6 +5,7 @@
 package mock_store
 
 import (
+	big "math/big"
 	reflect "reflect"
 
 	config "github.com/forta-network/forta-node/config"
@@ -49,6 +50,21 @@ func (mr *MockRegistryStoreMockRecorder) FindAgentGlobally(agentID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAgentGlobally", reflect.TypeOf((*MockRegistryStore)(nil).FindAgentGlobally), agentID)
 }
 
+// GetAgentStake mocks base method.
+func (m *MockRegistryStore) GetAgentStake(agentID string) (*big.Int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgentStake", agentID)
+	ret0, _ := ret[0].(*big.Int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgentStake indicates an expected call of GetAgentStake.
+func (mr *MockRegistryStoreMockRecorder) GetAgentStake(agentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgentStake", reflect.TypeOf((*MockRegistryStore)(nil).GetAgentStake), agentID)
+}
+
 // GetAgentsIfChanged mocks base method.
 func (m *MockRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConfig, bool, error) {
 	m.ctrl.T.Helper()
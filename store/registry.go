@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/forta-network/forta-core-go/contracts/contract_forta_staking"
 	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-core-go/ipfs"
 	"github.com/forta-network/forta-core-go/manifest"
 	"github.com/forta-network/forta-core-go/registry"
 	"github.com/forta-network/forta-core-go/utils"
@@ -20,17 +25,21 @@ import (
 type RegistryStore interface {
 	FindAgentGlobally(agentID string) (*config.AgentConfig, error)
 	GetAgentsIfChanged(scanner string) ([]*config.AgentConfig, bool, error)
+	GetAgentStake(agentID string) (*big.Int, error)
 }
 
 type registryStore struct {
 	ctx context.Context
-	mc  manifest.Client
+	ic  ipfs.Client
 	rc  registry.Client
 	cfg config.Config
 
 	lastUpdate time.Time
 	version    string
 	mu         sync.Mutex
+
+	staking   *contract_forta_staking.FortaStakingCaller
+	stakingMu sync.Mutex
 }
 
 func (rs *registryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConfig, bool, error) {
@@ -60,7 +69,7 @@ func (rs *registryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConf
 
 		var failedLoadingAny bool
 		err := rs.rc.ForEachAssignedAgent(scanner, func(a *registry.Agent) error {
-			agtCfg, err := rs.makeAgentConfig(a.AgentID, a.Manifest)
+			agtCfg, err := rs.makeAgentConfig(a.AgentID, a.Manifest, a.Owner)
 			if err != nil {
 				failedLoadingAny = true
 				log.WithField("agentId", a.AgentID).WithError(err).Warn("could not parse config for agent")
@@ -92,18 +101,45 @@ func (rs *registryStore) FindAgentGlobally(agentID string) (*config.AgentConfig,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the latest ref: %v, agentID: %s", err, agentID)
 	}
-	return rs.makeAgentConfig(agentID, agt.Manifest)
+	return rs.makeAgentConfig(agentID, agt.Manifest, agt.Owner)
+}
+
+// GetAgentStake returns the active stake of the agent.
+func (rs *registryStore) GetAgentStake(agentID string) (*big.Int, error) {
+	staking, err := rs.getStakingCaller()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the staking contract caller: %v", err)
+	}
+	return staking.ActiveStakeFor(&bind.CallOpts{Context: rs.ctx}, registry.SubjectTypeAgent, utils.AgentHexToBigInt(agentID))
+}
+
+func (rs *registryStore) getStakingCaller() (*contract_forta_staking.FortaStakingCaller, error) {
+	rs.stakingMu.Lock()
+	defer rs.stakingMu.Unlock()
+	if rs.staking != nil {
+		return rs.staking, nil
+	}
+	ec, err := ethclient.DialContext(rs.ctx, rs.cfg.Registry.JsonRpc.Url)
+	if err != nil {
+		return nil, err
+	}
+	staking, err := contract_forta_staking.NewFortaStakingCaller(rs.rc.RegistryContracts().FortaStaking, ec)
+	if err != nil {
+		return nil, err
+	}
+	rs.staking = staking
+	return staking, nil
 }
 
-func (rs *registryStore) makeAgentConfig(agentID string, ref string) (*config.AgentConfig, error) {
+func (rs *registryStore) makeAgentConfig(agentID string, ref string, owner string) (*config.AgentConfig, error) {
 	if len(ref) == 0 {
 		return nil, nil
 	}
-	var agentData *manifest.SignedAgentManifest
+	var b []byte
 
 	var err error
 	for i := 0; i < 10; i++ {
-		agentData, err = rs.mc.GetAgentManifest(rs.ctx, ref)
+		b, err = rs.ic.GetBytes(rs.ctx, ref)
 		if err == nil {
 			break
 		}
@@ -113,6 +149,16 @@ func (rs *registryStore) makeAgentConfig(agentID string, ref string) (*config.Ag
 		return nil, err
 	}
 
+	var agentData *manifest.SignedAgentManifest
+	if rs.cfg.Registry.SkipManifestVerification {
+		agentData, err = decodeAgentManifest(b)
+	} else {
+		agentData, err = VerifyAgentManifest(b, owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	if agentData.Manifest.ImageReference == nil {
 		return nil, fmt.Errorf("invalid agent image reference, it is nil")
 	}
@@ -122,15 +168,26 @@ func (rs *registryStore) makeAgentConfig(agentID string, ref string) (*config.Ag
 		return nil, fmt.Errorf("invalid agent image reference '%s': %v", *agentData.Manifest.ImageReference, err)
 	}
 
+	var version string
+	if agentData.Manifest.Version != nil {
+		version = *agentData.Manifest.Version
+	}
+
+	nodeVersion, protocolVersion := decodeManifestConstraints(b)
 	return &config.AgentConfig{
-		ID:       agentID,
-		Image:    image,
-		Manifest: ref,
+		ID:              agentID,
+		Image:           image,
+		Manifest:        ref,
+		Version:         version,
+		ChainIDs:        agentData.Manifest.ChainIDs,
+		NodeVersion:     nodeVersion,
+		ProtocolVersion: protocolVersion,
+		GPUs:            decodeManifestGPUs(b),
 	}, nil
 }
 
 func NewRegistryStore(ctx context.Context, cfg config.Config, ethClient ethereum.Client) (*registryStore, error) {
-	mc, err := manifest.NewClient(cfg.Registry.IPFS.GatewayURL)
+	ic, err := ipfs.NewClient(cfg.Registry.IPFS.GatewayURL)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +204,7 @@ func NewRegistryStore(ctx context.Context, cfg config.Config, ethClient ethereum
 	return &registryStore{
 		ctx: ctx,
 		cfg: cfg,
-		mc:  mc,
+		ic:  ic,
 		rc:  rc,
 	}, nil
 }
@@ -178,6 +235,10 @@ func (rs *privateRegistryStore) FindAgentGlobally(agentID string) (*config.Agent
 	return nil, errors.New("feature not available (private/local registry)")
 }
 
+func (rs *privateRegistryStore) GetAgentStake(agentID string) (*big.Int, error) {
+	return nil, errors.New("feature not available (private/local registry)")
+}
+
 func (rs *privateRegistryStore) makePrivateModeAgentConfig(id string, image string) *config.AgentConfig {
 	return &config.AgentConfig{
 		ID:      id,
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// mergedRegistryStore reads the assignments from multiple registries and merges them, so that
+// the agents assigned in both the legacy and the new registry contracts are not lost during a
+// migration. The first store is the primary one and the rest are read on a best effort basis.
+type mergedRegistryStore struct {
+	stores []RegistryStore
+	last   [][]*config.AgentConfig
+	mu     sync.Mutex
+}
+
+// NewMergedRegistryStore creates a registry store which merges the assignments from the given stores.
+func NewMergedRegistryStore(stores ...RegistryStore) *mergedRegistryStore {
+	return &mergedRegistryStore{
+		stores: stores,
+		last:   make([][]*config.AgentConfig, len(stores)),
+	}
+}
+
+func (ms *mergedRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConfig, bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var changed bool
+	for i, store := range ms.stores {
+		agts, storeChanged, err := store.GetAgentsIfChanged(scanner)
+		if err != nil && i == 0 {
+			return nil, false, err
+		}
+		if err != nil {
+			log.WithError(err).WithField("registry", i).Warn("failed to read the assignments from the secondary registry - using the last known")
+			continue
+		}
+		if storeChanged {
+			ms.last[i] = agts
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, false, nil
+	}
+
+	agts := []*config.AgentConfig{}
+	seen := make(map[string]bool)
+	for _, storeAgts := range ms.last {
+		for _, agt := range storeAgts {
+			if agt == nil || seen[strings.ToLower(agt.ID)] {
+				continue
+			}
+			seen[strings.ToLower(agt.ID)] = true
+			agts = append(agts, agt)
+		}
+	}
+	return agts, true, nil
+}
+
+func (ms *mergedRegistryStore) FindAgentGlobally(agentID string) (agt *config.AgentConfig, err error) {
+	for _, store := range ms.stores {
+		agt, err = store.FindAgentGlobally(agentID)
+		if err == nil && agt != nil {
+			return agt, nil
+		}
+	}
+	return agt, err
+}
+
+// GetAgentStake returns the highest stake of the agent in the registries.
+func (ms *mergedRegistryStore) GetAgentStake(agentID string) (*big.Int, error) {
+	var (
+		highest *big.Int
+		lastErr = errors.New("no registry to check the stake")
+	)
+	for _, store := range ms.stores {
+		stake, err := store.GetAgentStake(agentID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if highest == nil || stake.Cmp(highest) > 0 {
+			highest = stake
+		}
+	}
+	if highest == nil {
+		return nil, lastErr
+	}
+	return highest, nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	mock_store "github.com/forta-network/forta-node/store/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergedRegistryStore(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	primary := mock_store.NewMockRegistryStore(ctrl)
+	legacy := mock_store.NewMockRegistryStore(ctrl)
+	merged := NewMergedRegistryStore(primary, legacy)
+
+	const scanner = "0x1"
+	agent1 := &config.AgentConfig{ID: "0xA"}
+	agent2 := &config.AgentConfig{ID: "0xb"}
+	agent1Legacy := &config.AgentConfig{ID: "0xa", Image: "legacy"}
+
+	primary.EXPECT().GetAgentsIfChanged(scanner).Return([]*config.AgentConfig{agent1}, true, nil)
+	legacy.EXPECT().GetAgentsIfChanged(scanner).Return([]*config.AgentConfig{agent1Legacy, agent2, nil}, true, nil)
+	agts, changed, err := merged.GetAgentsIfChanged(scanner)
+	r.NoError(err)
+	r.True(changed)
+	r.Equal([]*config.AgentConfig{agent1, agent2}, agts)
+
+	// legacy failures keep the last known assignments
+	primary.EXPECT().GetAgentsIfChanged(scanner).Return([]*config.AgentConfig{}, true, nil)
+	legacy.EXPECT().GetAgentsIfChanged(scanner).Return(nil, false, errors.New("failed"))
+	agts, changed, err = merged.GetAgentsIfChanged(scanner)
+	r.NoError(err)
+	r.True(changed)
+	r.Equal([]*config.AgentConfig{agent1Legacy, agent2}, agts)
+
+	primary.EXPECT().GetAgentsIfChanged(scanner).Return(nil, false, nil)
+	legacy.EXPECT().GetAgentsIfChanged(scanner).Return(nil, false, nil)
+	_, changed, err = merged.GetAgentsIfChanged(scanner)
+	r.NoError(err)
+	r.False(changed)
+
+	primary.EXPECT().GetAgentsIfChanged(scanner).Return(nil, false, errors.New("failed"))
+	_, _, err = merged.GetAgentsIfChanged(scanner)
+	r.Error(err)
+
+	primary.EXPECT().GetAgentStake("0xa").Return(big.NewInt(1), nil)
+	legacy.EXPECT().GetAgentStake("0xa").Return(big.NewInt(2), nil)
+	stake, err := merged.GetAgentStake("0xa")
+	r.NoError(err)
+	r.Equal(int64(2), stake.Int64())
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+const registrySnapshotFileName = "registry-snapshot.json"
+
+// RegistrySnapshot is the last known agent assignments of a scanner.
+type RegistrySnapshot struct {
+	Scanner string                `json:"scanner"`
+	SavedAt time.Time             `json:"savedAt"`
+	Agents  []*config.AgentConfig `json:"agents"`
+}
+
+// RegistrySnapshotStore persists the last known agent assignments so that the node can start
+// with the same agents while the registry is unreachable.
+type RegistrySnapshotStore interface {
+	Get() (*RegistrySnapshot, error)
+	Put(*RegistrySnapshot) error
+}
+
+type registrySnapshotStore struct {
+	filePath string
+}
+
+// NewRegistrySnapshotStore creates a new snapshot store.
+func NewRegistrySnapshotStore(dir string) *registrySnapshotStore {
+	return &registrySnapshotStore{
+		filePath: path.Join(dir, registrySnapshotFileName),
+	}
+}
+
+// Get returns the snapshot or nil if there is no snapshot yet.
+func (store *registrySnapshotStore) Get() (*RegistrySnapshot, error) {
+	b, err := ioutil.ReadFile(store.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the registry snapshot: %v", err)
+	}
+	var snapshot RegistrySnapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid registry snapshot: %v", err)
+	}
+	return &snapshot, nil
+}
+
+// Put replaces the snapshot.
+func (store *registrySnapshotStore) Put(snapshot *RegistrySnapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmpPath := store.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return fmt.Errorf("failed to write the registry snapshot: %v", err)
+	}
+	return os.Rename(tmpPath, store.filePath)
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxTrackedBlocks is the number of latest blocks which the pipeline spans can be attached to.
+var MaxTrackedBlocks = 1000
+
+// The requests and the results do not carry a context while they travel through the channels
+// of the pipeline, so the span context of each block is kept here by the block number.
+var blocks = &blockSpans{contexts: make(map[uint64]trace.SpanContext)}
+
+type blockSpans struct {
+	contexts map[uint64]trace.SpanContext
+	order    []uint64
+	mu       sync.Mutex
+}
+
+// BlockContext returns a context which has the span of the block as the parent. The first call
+// for a block starts the trace of the block, so every stage of the pipeline is in the same trace.
+func BlockContext(ctx context.Context, blockNumber string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	number, err := hexutil.DecodeUint64(blockNumber)
+	if err != nil {
+		return ctx
+	}
+
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	if sc, ok := blocks.contexts[number]; ok {
+		return trace.ContextWithSpanContext(ctx, sc)
+	}
+	blockCtx, span := Tracer().Start(
+		ctx, "block", trace.WithNewRoot(),
+		trace.WithAttributes(attribute.Int64("block.number", int64(number))),
+	)
+	span.End()
+	if !span.SpanContext().IsValid() {
+		return ctx // tracing is disabled
+	}
+	blocks.contexts[number] = span.SpanContext()
+	blocks.order = append(blocks.order, number)
+	if len(blocks.order) > MaxTrackedBlocks {
+		delete(blocks.contexts, blocks.order[0])
+		blocks.order = blocks.order[1:]
+	}
+	return blockCtx
+}
+
+// BlockLinks returns the links to the traces of the tracked blocks in the range.
+func BlockLinks(start, end uint64) []trace.Link {
+	blocks.mu.Lock()
+	defer blocks.mu.Unlock()
+
+	var links []trace.Link
+	for _, number := range blocks.order {
+		if number >= start && number <= end {
+			links = append(links, trace.Link{SpanContext: blocks.contexts[number]})
+		}
+	}
+	return links
+}
+
+// StartSpan starts a span of a pipeline stage in the trace of the block.
+func StartSpan(ctx context.Context, blockNumber, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(BlockContext(ctx, blockNumber), name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error, if any, and ends the span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
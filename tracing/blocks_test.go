@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBlockSpans(t *testing.T) {
+	r := require.New(t)
+
+	// no-op without a provider
+	r.False(trace.SpanContextFromContext(BlockContext(context.Background(), "0x1")).IsValid())
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	maxBlocks := MaxTrackedBlocks
+	MaxTrackedBlocks = 2
+	defer func() { MaxTrackedBlocks = maxBlocks }()
+
+	_, span1 := StartSpan(nil, "0x1", "stage1")
+	EndSpan(span1, nil)
+	_, span2 := StartSpan(context.Background(), "0x1", "stage2")
+	EndSpan(span2, errors.New("failed"))
+	_, span3 := StartSpan(context.Background(), "0x2", "stage1")
+	span3.End()
+
+	spans := recorder.Ended()
+	r.Len(spans, 5)
+	r.Equal("block", spans[0].Name())
+	blockTrace := spans[0].SpanContext().TraceID()
+	r.Equal(blockTrace, spans[1].SpanContext().TraceID())
+	r.Equal(spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	r.Equal(blockTrace, spans[2].SpanContext().TraceID())
+	r.Equal(codes.Error, spans[2].Status().Code)
+	r.NotEqual(blockTrace, spans[4].SpanContext().TraceID())
+
+	r.Len(BlockLinks(1, 2), 2)
+	r.Len(BlockLinks(2, 10), 1)
+
+	// the oldest block is forgotten
+	BlockContext(context.Background(), "0x3")
+	r.Len(BlockLinks(1, 1), 0)
+	r.Len(BlockLinks(1, 3), 2)
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName      = "github.com/forta-network/forta-node"
+	shutdownTimeout = 10 * time.Second
+)
+
+// Tracer returns the tracer of the node. The spans are not recorded unless a service is started.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Service exports the spans to the OTLP endpoint in the config.
+type Service struct {
+	cfg      config.TracingConfig
+	provider *sdktrace.TracerProvider
+}
+
+// NewService creates a new tracing service and installs the global tracer provider if tracing
+// is enabled, so that the spans started before the service starts are exported as well.
+func NewService(ctx context.Context, cfg config.TracingConfig, serviceName string) (*Service, error) {
+	svc := &Service{cfg: cfg}
+	if !cfg.Enable {
+		return svc, nil
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+		otlptracegrpc.WithHeaders(cfg.Headers),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	// the connection is established lazily so that the node does not depend on the collector
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the trace exporter: %v", err)
+	}
+	svc.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String("forta-"+serviceName),
+		)),
+	)
+	otel.SetTracerProvider(svc.provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return svc, nil
+}
+
+// Start implements services.Service interface.
+func (svc *Service) Start() error {
+	if svc.provider != nil {
+		log.WithField("endpoint", svc.cfg.Endpoint).Info("exporting traces")
+	}
+	return nil
+}
+
+// Stop implements services.Service interface. It flushes the remaining spans.
+func (svc *Service) Stop() error {
+	log.Infof("Stopping %s", svc.Name())
+	if svc.provider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return svc.provider.Shutdown(ctx)
+}
+
+// Name implements services.Service interface.
+func (svc *Service) Name() string {
+	return "tracing"
+}